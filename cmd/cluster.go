@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Manage cluster lifecycle",
+	Long:  `Adopt and manage existing Kubernetes clusters with sloth-kubernetes`,
+}
+
+var clusterImportCmd = &cobra.Command{
+	Use:   "import [stack-name]",
+	Short: "Adopt an existing cluster into a stack",
+	Long: `Import an existing, manually deployed cluster into a stack without
+re-provisioning it. The nodes file lists every node (name, provider, IPs,
+roles and SSH user); their details are stored as stack outputs so the vpn,
+nodes and status commands work against them.
+
+Each node is checked over SSH during import. Unreachable nodes are still
+imported, but a warning is printed for each one.`,
+	Example: `  # Import nodes from a YAML (or JSON) file
+  sloth-kubernetes cluster import production --nodes nodes.yaml
+
+  # nodes.yaml
+  - name: master-1
+    provider: digitalocean
+    publicIP: 203.0.113.10
+    privateIP: 10.10.0.10
+    wireGuardIP: 10.8.0.10
+    roles: [master]
+  - name: worker-1
+    provider: aws
+    publicIP: 203.0.113.20
+    roles: [worker]
+    sshUser: ubuntu`,
+	RunE: runClusterImport,
+}
+
+var (
+	importNodesFile  string
+	importSSHKeyPath string
+	importSkipCheck  bool
+)
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+	clusterCmd.AddCommand(clusterImportCmd)
+
+	clusterImportCmd.Flags().StringVar(&importNodesFile, "nodes", "", "YAML or JSON file listing the existing nodes (required)")
+	clusterImportCmd.Flags().StringVar(&importSSHKeyPath, "ssh-key", "", "SSH private key used to reach the nodes (default: the stack key)")
+	clusterImportCmd.Flags().BoolVar(&importSkipCheck, "skip-connectivity-check", false, "Import without checking SSH connectivity to each node")
+	addForceUnlockFlag(clusterImportCmd)
+	clusterImportCmd.MarkFlagRequired("nodes")
+}
+
+// ImportedNode describes an existing node in a cluster import file
+type ImportedNode struct {
+	Name        string   `json:"name" yaml:"name"`
+	Provider    string   `json:"provider" yaml:"provider"`
+	Region      string   `json:"region,omitempty" yaml:"region,omitempty"`
+	PublicIP    string   `json:"publicIP" yaml:"publicIP"`
+	PrivateIP   string   `json:"privateIP,omitempty" yaml:"privateIP,omitempty"`
+	WireGuardIP string   `json:"wireGuardIP,omitempty" yaml:"wireGuardIP,omitempty"`
+	Roles       []string `json:"roles" yaml:"roles"`
+	SSHUser     string   `json:"sshUser,omitempty" yaml:"sshUser,omitempty"`
+}
+
+// checkImportedNode verifies that a node accepts SSH connections; replaced in tests
+var checkImportedNode = func(node ImportedNode, sshKeyPath string) error {
+	sshArgs := append([]string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"},
+		buildNodeSSHArgs(sshKeyPath, node.SSHUser, node.PublicIP, "", false)...)
+	output, err := exec.Command("ssh", append(sshArgs, "true")...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func runClusterImport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	stack := getStackFromArgs(args, 0)
+	if stack == "" {
+		return fmt.Errorf("usage: sloth-kubernetes cluster import <stack-name> --nodes <file>")
+	}
+
+	nodes, err := loadImportedNodes(importNodesFile)
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("📥 Importing %d node(s) into stack: %s", len(nodes), stack))
+
+	unlock, err := lockStack(ctx, stack, "cluster-import")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !importSkipCheck {
+		sshKeyPath := importSSHKeyPath
+		if sshKeyPath == "" {
+			sshKeyPath = GetSSHKeyPath(stack)
+		}
+
+		unreachable := 0
+		for _, node := range nodes {
+			if err := checkImportedNode(node, sshKeyPath); err != nil {
+				printWarning(fmt.Sprintf("%s (%s@%s) is unreachable: %v", node.Name, node.SSHUser, node.PublicIP, err))
+				unreachable++
+				continue
+			}
+			color.Green("  ✓ %s (%s@%s)", node.Name, node.SSHUser, node.PublicIP)
+		}
+		if unreachable > 0 {
+			printWarning(fmt.Sprintf("%d of %d node(s) are unreachable; they will be imported anyway", unreachable, len(nodes)))
+		}
+	}
+
+	program := func(ctx *pulumi.Context) error {
+		ctx.Export("clusterName", pulumi.String(stack))
+		ctx.Export("nodes", pulumi.ToMap(importedNodesOutput(nodes)))
+		ctx.Export("imported", pulumi.Bool(true))
+		return nil
+	}
+
+	ws, err := newProgramWorkspace(ctx, program)
+	if err != nil {
+		return err
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.UpsertStack(ctx, fullyQualifiedStackName, ws)
+	if err != nil {
+		return fmt.Errorf("failed to create or select stack: %w", err)
+	}
+
+	if _, err := s.Up(ctx, optup.ProgressStreams(os.Stdout)); err != nil {
+		return fmt.Errorf("failed to import cluster: %w", err)
+	}
+
+	fmt.Println()
+	printSuccess(fmt.Sprintf("✅ Imported %d node(s) into stack '%s'", len(nodes), stack))
+	printInfo(fmt.Sprintf("Run 'sloth-kubernetes nodes list %s' to see them", stack))
+
+	return nil
+}
+
+// loadImportedNodes reads and validates a cluster import file. JSON files are
+// accepted as well, since JSON is valid YAML.
+func loadImportedNodes(path string) ([]ImportedNode, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes file: %w", err)
+	}
+
+	var nodes []ImportedNode
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to parse nodes file: %w", err)
+	}
+
+	if err := validateImportedNodes(nodes); err != nil {
+		return nil, err
+	}
+
+	for i := range nodes {
+		if nodes[i].SSHUser == "" {
+			nodes[i].SSHUser = getSSHUserForProvider(nodes[i].Provider)
+		}
+	}
+
+	return nodes, nil
+}
+
+// validateImportedNodes checks that every node has the fields the other commands rely on
+func validateImportedNodes(nodes []ImportedNode) error {
+	if len(nodes) == 0 {
+		return fmt.Errorf("nodes file does not list any nodes")
+	}
+
+	seen := make(map[string]bool, len(nodes))
+	for i, node := range nodes {
+		if node.Name == "" {
+			return fmt.Errorf("node %d: name is required", i+1)
+		}
+		if seen[node.Name] {
+			return fmt.Errorf("node %s: duplicate name", node.Name)
+		}
+		seen[node.Name] = true
+
+		if node.Provider == "" {
+			return fmt.Errorf("node %s: provider is required", node.Name)
+		}
+		if node.PublicIP == "" {
+			return fmt.Errorf("node %s: publicIP is required", node.Name)
+		}
+		if len(node.Roles) == 0 {
+			return fmt.Errorf("node %s: at least one role is required", node.Name)
+		}
+	}
+
+	return nil
+}
+
+// importedNodesOutput builds the "nodes" stack output in the same layout the
+// deploy program exports, so ParseNodeOutputs reads imported nodes unchanged
+func importedNodesOutput(nodes []ImportedNode) map[string]interface{} {
+	output := make(map[string]interface{}, len(nodes))
+	for i, node := range nodes {
+		roles := make([]interface{}, 0, len(node.Roles))
+		for _, role := range node.Roles {
+			roles = append(roles, role)
+		}
+
+		output[fmt.Sprintf("node_%d", i)] = map[string]interface{}{
+			"name":       node.Name,
+			"provider":   node.Provider,
+			"region":     node.Region,
+			"public_ip":  node.PublicIP,
+			"private_ip": node.PrivateIP,
+			"vpn_ip":     node.WireGuardIP,
+			"roles":      roles,
+			"ssh_user":   node.SSHUser,
+			"status":     "imported",
+		}
+	}
+	return output
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+var clusterBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Run Velero backups of a cluster",
+	Long: `Run Velero backups of a cluster deployed with backup enabled
+(backup.enabled with provider velero). Commands run kubectl on the first
+master over SSH, through the bastion when the stack has one.`,
+}
+
+var clusterBackupNowCmd = &cobra.Command{
+	Use:   "now [stack-name]",
+	Short: "Take an on-demand Velero backup and wait for it",
+	Long: `Create a Velero Backup in the cluster and wait until it finishes, reporting
+its phase (New, InProgress, Completed, PartiallyFailed, Failed).`,
+	Example: `  # Back up the whole cluster
+  sloth-kubernetes cluster backup now production --name before-upgrade
+
+  # Back up only some namespaces
+  sloth-kubernetes cluster backup now production --name apps --include-namespaces app,db`,
+	RunE: runClusterBackupNow,
+}
+
+var clusterRestoreCmd = &cobra.Command{
+	Use:   "restore [stack-name]",
+	Short: "Restore a Velero backup and wait for it",
+	Long: `Create a Velero Restore from a backup and wait until it finishes, reporting
+its phase (New, InProgress, Completed, PartiallyFailed, Failed).`,
+	Example: `  # Restore everything a backup holds
+  sloth-kubernetes cluster restore production --from before-upgrade
+
+  # Restore one namespace
+  sloth-kubernetes cluster restore production --from before-upgrade --include-namespaces app`,
+	RunE: runClusterRestore,
+}
+
+var (
+	clusterBackupName        string
+	clusterRestoreFrom       string
+	clusterBackupIncludeNS   []string
+	clusterBackupExcludeNS   []string
+	clusterBackupWaitTimeout time.Duration
+)
+
+func init() {
+	clusterCmd.AddCommand(clusterBackupCmd)
+	clusterBackupCmd.AddCommand(clusterBackupNowCmd)
+	clusterCmd.AddCommand(clusterRestoreCmd)
+
+	clusterBackupNowCmd.Flags().StringVar(&clusterBackupName, "name", "", "Backup name (default: manual-<timestamp>)")
+	clusterRestoreCmd.Flags().StringVar(&clusterRestoreFrom, "from", "", "Backup to restore (required)")
+	clusterRestoreCmd.MarkFlagRequired("from")
+
+	for _, c := range []*cobra.Command{clusterBackupNowCmd, clusterRestoreCmd} {
+		c.Flags().StringSliceVar(&clusterBackupIncludeNS, "include-namespaces", nil, "Namespaces to include (default: all)")
+		c.Flags().StringSliceVar(&clusterBackupExcludeNS, "exclude-namespaces", nil, "Namespaces to exclude")
+		c.Flags().DurationVar(&clusterBackupWaitTimeout, "timeout", 30*time.Minute, "How long to wait for Velero to finish")
+	}
+}
+
+// veleroResourceName matches the names Kubernetes accepts for backups,
+// restores and namespaces
+var veleroResourceName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// veleroClient drives Velero through kubectl run on a master; run is
+// replaced in tests
+type veleroClient struct {
+	run      func(script string) (string, error)
+	interval time.Duration
+}
+
+// veleroRequest is a Velero Backup or Restore to create
+type veleroRequest struct {
+	Kind              string // Backup or Restore
+	Name              string
+	BackupName        string // Restores only
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+}
+
+func runClusterBackupNow(cmd *cobra.Command, args []string) error {
+	name := clusterBackupName
+	if name == "" {
+		name = "manual-" + time.Now().UTC().Format("20060102-150405")
+	}
+	return runVeleroRequest(args, "backup", veleroRequest{
+		Kind:              "Backup",
+		Name:              name,
+		IncludeNamespaces: clusterBackupIncludeNS,
+		ExcludeNamespaces: clusterBackupExcludeNS,
+	})
+}
+
+func runClusterRestore(cmd *cobra.Command, args []string) error {
+	return runVeleroRequest(args, "restore", veleroRequest{
+		Kind:              "Restore",
+		Name:              restoreName(clusterRestoreFrom, time.Now()),
+		BackupName:        clusterRestoreFrom,
+		IncludeNamespaces: clusterBackupIncludeNS,
+		ExcludeNamespaces: clusterBackupExcludeNS,
+	})
+}
+
+// runVeleroRequest creates a Backup or Restore in the stack's cluster, waits
+// for Velero to finish it and records the operation
+func runVeleroRequest(args []string, operation string, req veleroRequest) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	stack := getStackFromArgs(args, 0)
+	if stack == "" {
+		return fmt.Errorf("usage: sloth-kubernetes cluster %s <stack-name>", operation)
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	if req.Kind == "Restore" {
+		printHeader(fmt.Sprintf("♻️  Restoring backup '%s' in stack: %s", req.BackupName, stack))
+	} else {
+		printHeader(fmt.Sprintf("💾 Backing up stack: %s", stack))
+	}
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse node outputs: %w", err)
+	}
+	master, ok := firstMasterNode(nodes)
+	if !ok {
+		return fmt.Errorf("no master node found in stack '%s'", stack)
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	client := &veleroClient{
+		run: func(script string) (string, error) {
+			return runNodeScriptOutput(master, script, sshKeyPath, bastionIP)
+		},
+		interval: 5 * time.Second,
+	}
+
+	phase, err := client.execute(req, clusterBackupWaitTimeout, func(phase string) {
+		color.Cyan("⏳ %s %s: %s", req.Kind, req.Name, phase)
+	})
+
+	target := req.Name
+	if req.Kind == "Restore" {
+		target = req.BackupName
+	}
+	if err != nil {
+		operations.RecordBackupOperation(stack, operation, target, "failed", req.IncludeNamespaces, time.Since(startTime), err)
+		return err
+	}
+
+	fmt.Println()
+	if phase == "PartiallyFailed" {
+		operations.RecordBackupOperation(stack, operation, target, "partial", req.IncludeNamespaces, time.Since(startTime), nil)
+		printWarning(fmt.Sprintf("%s %s partially failed; inspect it with: kubectl -n velero describe %s %s",
+			req.Kind, req.Name, strings.ToLower(req.Kind), req.Name))
+		return nil
+	}
+	operations.RecordBackupOperation(stack, operation, target, "success", req.IncludeNamespaces, time.Since(startTime), nil)
+	printSuccess(fmt.Sprintf("✅ %s %s completed in %s", req.Kind, req.Name, time.Since(startTime).Round(time.Second)))
+	return nil
+}
+
+// restoreName names a restore after its backup and the time, trimming the
+// backup name to keep within the 63 characters Kubernetes allows
+func restoreName(backupName string, now time.Time) string {
+	suffix := "-" + now.UTC().Format("20060102-150405")
+	if len(backupName) > 63-len(suffix) {
+		backupName = strings.TrimRight(backupName[:63-len(suffix)], "-")
+	}
+	return backupName + suffix
+}
+
+// firstMasterNode returns the first master among the stack's nodes
+func firstMasterNode(nodes []NodeInfo) (NodeInfo, bool) {
+	for _, node := range nodes {
+		if isMasterNode(node) {
+			return node, true
+		}
+	}
+	return NodeInfo{}, false
+}
+
+func (r veleroRequest) validate() error {
+	if !veleroResourceName.MatchString(r.Name) || len(r.Name) > 63 {
+		return fmt.Errorf("invalid %s name %q: use lowercase letters, digits and '-'", strings.ToLower(r.Kind), r.Name)
+	}
+	if r.Kind == "Restore" && !veleroResourceName.MatchString(r.BackupName) {
+		return fmt.Errorf("invalid backup name %q", r.BackupName)
+	}
+	for _, ns := range append(append([]string{}, r.IncludeNamespaces...), r.ExcludeNamespaces...) {
+		if ns != "*" && !veleroResourceName.MatchString(ns) {
+			return fmt.Errorf("invalid namespace %q", ns)
+		}
+	}
+	return nil
+}
+
+// manifest renders the Backup or Restore resource
+func (r veleroRequest) manifest() string {
+	var builder strings.Builder
+	builder.WriteString("apiVersion: velero.io/v1\n")
+	builder.WriteString(fmt.Sprintf("kind: %s\n", r.Kind))
+	builder.WriteString("metadata:\n")
+	builder.WriteString(fmt.Sprintf("  name: %s\n", r.Name))
+	builder.WriteString("  namespace: velero\n")
+	builder.WriteString("spec:\n")
+	if r.BackupName != "" {
+		builder.WriteString(fmt.Sprintf("  backupName: %s\n", r.BackupName))
+	}
+	writeList := func(key string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		builder.WriteString(fmt.Sprintf("  %s:\n", key))
+		for _, value := range values {
+			builder.WriteString(fmt.Sprintf("    - %q\n", value))
+		}
+	}
+	writeList("includedNamespaces", r.IncludeNamespaces)
+	writeList("excludedNamespaces", r.ExcludeNamespaces)
+	return builder.String()
+}
+
+// veleroKubectlScript wraps kubectl commands so they find the cluster on an
+// RKE2 or RKE master
+func veleroKubectlScript(commands string) string {
+	return `export PATH=$PATH:/var/lib/rancher/rke2/bin
+if [ -z "$KUBECONFIG" ] && [ -f /etc/rancher/rke2/rke2.yaml ]; then
+    export KUBECONFIG=/etc/rancher/rke2/rke2.yaml
+fi
+` + commands
+}
+
+// installed reports whether the Velero CRDs exist in the cluster
+func (c *veleroClient) installed() bool {
+	_, err := c.run(veleroKubectlScript("kubectl get crd backups.velero.io restores.velero.io >/dev/null\n"))
+	return err == nil
+}
+
+// execute creates the request and polls its phase until Velero finishes it.
+// Completed and PartiallyFailed are returned as phases; Failed and
+// FailedValidation are errors. progress is called whenever the phase changes.
+func (c *veleroClient) execute(req veleroRequest, timeout time.Duration, progress func(phase string)) (string, error) {
+	if !c.installed() {
+		return "", fmt.Errorf("velero is not installed in the cluster; enable backup (provider velero) in the configuration and redeploy")
+	}
+
+	create := veleroKubectlScript(fmt.Sprintf("set -e\nkubectl create -f - <<'MANIFEST'\n%sMANIFEST\n", req.manifest()))
+	if _, err := c.run(create); err != nil {
+		return "", fmt.Errorf("failed to create %s %s: %w", strings.ToLower(req.Kind), req.Name, err)
+	}
+
+	get := veleroKubectlScript(fmt.Sprintf("kubectl -n velero get %s %s -o jsonpath='{.status.phase}'\n",
+		strings.ToLower(req.Kind), req.Name))
+	deadline := time.Now().Add(timeout)
+	last := ""
+	for {
+		output, err := c.run(get)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s %s status: %w", strings.ToLower(req.Kind), req.Name, err)
+		}
+		phase := strings.TrimSpace(output)
+		if phase == "" {
+			phase = "New"
+		}
+		if phase != last {
+			progress(phase)
+			last = phase
+		}
+
+		switch phase {
+		case "Completed", "PartiallyFailed":
+			return phase, nil
+		case "Failed", "FailedValidation":
+			return phase, fmt.Errorf("%s %s %s", strings.ToLower(req.Kind), req.Name, phase)
+		}
+
+		if time.Now().After(deadline) {
+			return phase, fmt.Errorf("timed out after %s waiting for %s %s (phase %s)", timeout, strings.ToLower(req.Kind), req.Name, phase)
+		}
+		time.Sleep(c.interval)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterBackupCmd_Structure(t *testing.T) {
+	assert.Equal(t, "now [stack-name]", clusterBackupNowCmd.Use)
+	assert.NotNil(t, clusterBackupNowCmd.Flags().Lookup("name"))
+	assert.NotNil(t, clusterBackupNowCmd.Flags().Lookup("include-namespaces"))
+	assert.NotNil(t, clusterBackupNowCmd.Flags().Lookup("exclude-namespaces"))
+
+	assert.Equal(t, "restore [stack-name]", clusterRestoreCmd.Use)
+	assert.NotNil(t, clusterRestoreCmd.Flags().Lookup("from"))
+	assert.NotNil(t, clusterRestoreCmd.Flags().Lookup("include-namespaces"))
+}
+
+// fakeVelero answers the scripts a veleroClient runs: the CRD check, the
+// create and then each phase in turn
+type fakeVelero struct {
+	installed bool
+	phases    []string
+	created   string
+}
+
+func (f *fakeVelero) run(script string) (string, error) {
+	switch {
+	case strings.Contains(script, "get crd"):
+		if !f.installed {
+			return "", errors.New("NotFound")
+		}
+		return "", nil
+	case strings.Contains(script, "kubectl create"):
+		f.created = script
+		return "", nil
+	default:
+		phase := f.phases[0]
+		if len(f.phases) > 1 {
+			f.phases = f.phases[1:]
+		}
+		return phase, nil
+	}
+}
+
+func TestVeleroClient_ExecuteReportsPhases(t *testing.T) {
+	fake := &fakeVelero{installed: true, phases: []string{"", "InProgress", "InProgress", "Completed"}}
+	client := &veleroClient{run: fake.run}
+
+	var seen []string
+	phase, err := client.execute(veleroRequest{Kind: "Backup", Name: "nightly", IncludeNamespaces: []string{"app"}},
+		time.Minute, func(phase string) { seen = append(seen, phase) })
+	require.NoError(t, err)
+	assert.Equal(t, "Completed", phase)
+	assert.Equal(t, []string{"New", "InProgress", "Completed"}, seen)
+	assert.Contains(t, fake.created, "kind: Backup\n")
+	assert.Contains(t, fake.created, "  includedNamespaces:\n    - \"app\"\n")
+}
+
+func TestVeleroClient_ExecuteFailures(t *testing.T) {
+	_, err := (&veleroClient{run: (&fakeVelero{}).run}).execute(veleroRequest{Kind: "Backup", Name: "nightly"}, time.Minute, func(string) {})
+	assert.ErrorContains(t, err, "velero is not installed")
+
+	fake := &fakeVelero{installed: true, phases: []string{"Failed"}}
+	phase, err := (&veleroClient{run: fake.run}).execute(veleroRequest{Kind: "Restore", Name: "r", BackupName: "b"}, time.Minute, func(string) {})
+	assert.Equal(t, "Failed", phase)
+	assert.EqualError(t, err, "restore r Failed")
+
+	fake = &fakeVelero{installed: true, phases: []string{"InProgress"}}
+	_, err = (&veleroClient{run: fake.run}).execute(veleroRequest{Kind: "Backup", Name: "slow"}, 0, func(string) {})
+	assert.ErrorContains(t, err, "timed out")
+}
+
+func TestVeleroRequest_Manifest(t *testing.T) {
+	manifest := veleroRequest{Kind: "Restore", Name: "r1", BackupName: "nightly", ExcludeNamespaces: []string{"kube-system"}}.manifest()
+	assert.Contains(t, manifest, "kind: Restore\n")
+	assert.Contains(t, manifest, "  namespace: velero\n")
+	assert.Contains(t, manifest, "  backupName: nightly\n")
+	assert.Contains(t, manifest, "  excludedNamespaces:\n    - \"kube-system\"\n")
+	assert.NotContains(t, manifest, "includedNamespaces")
+}
+
+func TestVeleroRequest_Validate(t *testing.T) {
+	assert.NoError(t, veleroRequest{Kind: "Backup", Name: "nightly-1", IncludeNamespaces: []string{"*"}}.validate())
+	assert.Error(t, veleroRequest{Kind: "Backup", Name: "Nightly"}.validate())
+	assert.Error(t, veleroRequest{Kind: "Backup", Name: "ok", ExcludeNamespaces: []string{"a;b"}}.validate())
+	assert.Error(t, veleroRequest{Kind: "Restore", Name: "ok", BackupName: "$(x)"}.validate())
+}
+
+func TestRestoreName(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	assert.Equal(t, "nightly-20261018-093000", restoreName("nightly", now))
+
+	long := restoreName(strings.Repeat("a", 60), now)
+	assert.Len(t, long, 63)
+	assert.True(t, strings.HasSuffix(long, "-20261018-093000"))
+}
+
+func TestFirstMasterNode(t *testing.T) {
+	master, ok := firstMasterNode([]NodeInfo{
+		{Name: "workers-1", Roles: []string{"worker"}},
+		{Name: "masters-1", Roles: []string{"controlplane", "etcd"}},
+	})
+	require.True(t, ok)
+	assert.Equal(t, "masters-1", master.Name)
+
+	_, ok = firstMasterNode([]NodeInfo{{Name: "workers-1", Roles: []string{"worker"}}})
+	assert.False(t, ok)
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var clusterKubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig [stack-name]",
+	Short: "Fetch the cluster kubeconfig from a master",
+	Long: `Read the RKE2 kubeconfig (/etc/rancher/rke2/rke2.yaml) from the first master
+over SSH, through the bastion when the stack has one, and point its server at
+the master's public IP, or its VPN IP with --vpn-ip.
+
+The kubeconfig is printed to stdout, or with --merge added to ~/.kube/config
+under a cluster, user and context named after the stack. Entries with the same
+name are replaced and the context becomes the current one.`,
+	Example: `  # Print the kubeconfig
+  sloth-kubernetes cluster kubeconfig production
+
+  # Add it to ~/.kube/config as context "production"
+  sloth-kubernetes cluster kubeconfig production --merge
+
+  # Private cluster: reach the API server over the VPN
+  sloth-kubernetes cluster kubeconfig production --merge --vpn-ip`,
+	RunE: runClusterKubeconfig,
+}
+
+var (
+	clusterKubeconfigMerge bool
+	clusterKubeconfigVPNIP bool
+)
+
+// clusterKubeconfigScript prints the distribution's admin kubeconfig; K3s
+// clusters keep it under /etc/rancher/k3s
+const clusterKubeconfigScript = "cat /etc/rancher/rke2/rke2.yaml 2>/dev/null || cat /etc/rancher/k3s/k3s.yaml\n"
+
+func init() {
+	clusterCmd.AddCommand(clusterKubeconfigCmd)
+
+	clusterKubeconfigCmd.Flags().BoolVar(&clusterKubeconfigMerge, "merge", false, "Merge into ~/.kube/config instead of printing")
+	clusterKubeconfigCmd.Flags().BoolVar(&clusterKubeconfigVPNIP, "vpn-ip", false, "Point the server at the master's VPN IP instead of its public IP")
+}
+
+// kubeconfigFile is the subset of a kubeconfig the merge needs; the entry
+// bodies and any other top-level fields are kept as they are
+type kubeconfigFile struct {
+	APIVersion     string                 `yaml:"apiVersion"`
+	Kind           string                 `yaml:"kind"`
+	Clusters       []kubeconfigCluster    `yaml:"clusters"`
+	Contexts       []kubeconfigContext    `yaml:"contexts"`
+	Users          []kubeconfigUser       `yaml:"users"`
+	CurrentContext string                 `yaml:"current-context"`
+	Extra          map[string]interface{} `yaml:",inline"`
+}
+
+type kubeconfigCluster struct {
+	Name    string                 `yaml:"name"`
+	Cluster map[string]interface{} `yaml:"cluster"`
+}
+
+type kubeconfigContext struct {
+	Name    string                 `yaml:"name"`
+	Context map[string]interface{} `yaml:"context"`
+}
+
+type kubeconfigUser struct {
+	Name string                 `yaml:"name"`
+	User map[string]interface{} `yaml:"user"`
+}
+
+func runClusterKubeconfig(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	stack := getStackFromArgs(args, 0)
+	if stack == "" {
+		return fmt.Errorf("usage: sloth-kubernetes cluster kubeconfig <stack-name>")
+	}
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse node outputs: %w", err)
+	}
+	master, ok := firstMasterNode(nodes)
+	if !ok {
+		return fmt.Errorf("no master node found in stack '%s'", stack)
+	}
+
+	serverIP, err := kubeconfigServerIP(master, clusterKubeconfigVPNIP)
+	if err != nil {
+		return err
+	}
+
+	raw, err := runNodeScriptOutput(master, clusterKubeconfigScript, GetSSHKeyPath(stack), bastionIPFromOutputs(outputs))
+	if err != nil {
+		return fmt.Errorf("failed to read kubeconfig from %s: %w", master.Name, err)
+	}
+
+	fetched, err := parseKubeconfig([]byte(raw))
+	if err != nil {
+		return fmt.Errorf("kubeconfig read from %s is invalid: %w", master.Name, err)
+	}
+	fetched = rewriteClusterKubeconfig(fetched, stack, serverIP)
+
+	if !clusterKubeconfigMerge {
+		data, err := yaml.Marshal(fetched)
+		if err != nil {
+			return fmt.Errorf("failed to encode kubeconfig: %w", err)
+		}
+		fmt.Print(string(data))
+		return nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	path := filepath.Join(home, ".kube", "config")
+	if err := mergeKubeconfigFile(path, fetched); err != nil {
+		return err
+	}
+
+	printSuccess(fmt.Sprintf("Merged context '%s' into %s (server %s)", stack, path, kubeconfigServer(serverIP)))
+	fmt.Println()
+	color.Green("🎯 You can now use kubectl:")
+	fmt.Println("   kubectl get nodes")
+	return nil
+}
+
+// kubeconfigServerIP picks the address kubectl reaches the master's API
+// server on
+func kubeconfigServerIP(master NodeInfo, vpnIP bool) (string, error) {
+	if vpnIP {
+		if master.WireGuardIP == "" {
+			return "", fmt.Errorf("master %s has no VPN IP in the stack outputs", master.Name)
+		}
+		return master.WireGuardIP, nil
+	}
+	if master.PublicIP == "" {
+		return "", fmt.Errorf("master %s has no public IP; use --vpn-ip to reach it over the VPN", master.Name)
+	}
+	return master.PublicIP, nil
+}
+
+func kubeconfigServer(ip string) string {
+	return "https://" + net.JoinHostPort(ip, "6443")
+}
+
+// parseKubeconfig decodes a kubeconfig and checks it holds at least one
+// cluster with a server, one user and one context
+func parseKubeconfig(data []byte) (*kubeconfigFile, error) {
+	var config kubeconfigFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	if config.Kind != "Config" {
+		return nil, fmt.Errorf("kind is %q, want Config", config.Kind)
+	}
+	if len(config.Clusters) == 0 || len(config.Users) == 0 || len(config.Contexts) == 0 {
+		return nil, fmt.Errorf("kubeconfig needs at least one cluster, user and context")
+	}
+	for _, cluster := range config.Clusters {
+		if server, _ := cluster.Cluster["server"].(string); server == "" {
+			return nil, fmt.Errorf("cluster %q has no server", cluster.Name)
+		}
+	}
+	return &config, nil
+}
+
+// rewriteClusterKubeconfig renames the fetched cluster, user and context
+// ("default" on RKE2) after the stack and points the server at serverIP
+func rewriteClusterKubeconfig(config *kubeconfigFile, stack, serverIP string) *kubeconfigFile {
+	renamed := renameKubeconfig(config, stack)
+	renamed.Clusters[0].Cluster["server"] = kubeconfigServer(serverIP)
+	return renamed
+}
+
+// renameKubeconfig names the cluster, user and context of a single-cluster
+// kubeconfig after the stack, as merged kubeconfigs are keyed. The admin
+// kubeconfig holds a single entry of each.
+func renameKubeconfig(config *kubeconfigFile, stack string) *kubeconfigFile {
+	cluster := config.Clusters[0]
+	cluster.Name = stack
+
+	user := config.Users[0]
+	user.Name = stack
+
+	kubeContext := config.Contexts[0]
+	kubeContext.Name = stack
+	if kubeContext.Context == nil {
+		kubeContext.Context = map[string]interface{}{}
+	}
+	kubeContext.Context["cluster"] = stack
+	kubeContext.Context["user"] = stack
+
+	return &kubeconfigFile{
+		APIVersion:     "v1",
+		Kind:           "Config",
+		Clusters:       []kubeconfigCluster{cluster},
+		Contexts:       []kubeconfigContext{kubeContext},
+		Users:          []kubeconfigUser{user},
+		CurrentContext: stack,
+		Extra:          config.Extra,
+	}
+}
+
+// mergeKubeconfig adds the entries of src to dst, replacing those with the
+// same name, and makes src's context the current one
+func mergeKubeconfig(dst, src *kubeconfigFile) {
+	if dst.APIVersion == "" {
+		dst.APIVersion = "v1"
+	}
+	if dst.Kind == "" {
+		dst.Kind = "Config"
+	}
+
+	for _, cluster := range src.Clusters {
+		dst.Clusters = replaceKubeconfigEntry(dst.Clusters, cluster, func(c kubeconfigCluster) string { return c.Name })
+	}
+	for _, user := range src.Users {
+		dst.Users = replaceKubeconfigEntry(dst.Users, user, func(u kubeconfigUser) string { return u.Name })
+	}
+	for _, kubeContext := range src.Contexts {
+		dst.Contexts = replaceKubeconfigEntry(dst.Contexts, kubeContext, func(c kubeconfigContext) string { return c.Name })
+	}
+	dst.CurrentContext = src.CurrentContext
+}
+
+func replaceKubeconfigEntry[T any](entries []T, entry T, name func(T) string) []T {
+	for i, existing := range entries {
+		if name(existing) == name(entry) {
+			entries[i] = entry
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+// mergeKubeconfigFile merges config into the kubeconfig at path, creating it
+// when missing. The file is replaced atomically and kept private.
+func mergeKubeconfigFile(path string, config *kubeconfigFile) error {
+	merged := &kubeconfigFile{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, merged); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	mergeKubeconfig(merged, config)
+
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to encode kubeconfig: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return nil
+}
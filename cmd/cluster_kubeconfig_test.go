@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const rke2Kubeconfig = `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority-data: Q0E=
+    server: https://127.0.0.1:6443
+  name: default
+contexts:
+- context:
+    cluster: default
+    user: default
+  name: default
+current-context: default
+kind: Config
+preferences: {}
+users:
+- name: default
+  user:
+    client-certificate-data: Q0VSVA==
+    client-key-data: S0VZ
+`
+
+func TestClusterKubeconfigCmd_Flags(t *testing.T) {
+	cmd, _, err := clusterCmd.Find([]string{"kubeconfig"})
+	require.NoError(t, err)
+	assert.Same(t, clusterKubeconfigCmd, cmd)
+	assert.Equal(t, "false", cmd.Flags().Lookup("merge").DefValue)
+	assert.Equal(t, "false", cmd.Flags().Lookup("vpn-ip").DefValue)
+}
+
+func TestParseKubeconfig(t *testing.T) {
+	config, err := parseKubeconfig([]byte(rke2Kubeconfig))
+	require.NoError(t, err)
+	assert.Equal(t, "default", config.Clusters[0].Name)
+	assert.Equal(t, "https://127.0.0.1:6443", config.Clusters[0].Cluster["server"])
+
+	_, err = parseKubeconfig([]byte("cat: /etc/rancher/k3s/k3s.yaml: No such file or directory"))
+	assert.Error(t, err)
+
+	_, err = parseKubeconfig([]byte("apiVersion: v1\nkind: Config\nclusters: []\n"))
+	assert.EqualError(t, err, "kubeconfig needs at least one cluster, user and context")
+
+	_, err = parseKubeconfig([]byte("kind: Config\nclusters:\n- name: a\n  cluster: {}\nusers:\n- name: a\ncontexts:\n- name: a\n"))
+	assert.EqualError(t, err, `cluster "a" has no server`)
+}
+
+func TestKubeconfigServerIP(t *testing.T) {
+	master := NodeInfo{Name: "master-1", PublicIP: "203.0.113.10", WireGuardIP: "10.8.0.10"}
+
+	ip, err := kubeconfigServerIP(master, false)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.10", ip)
+
+	ip, err = kubeconfigServerIP(master, true)
+	require.NoError(t, err)
+	assert.Equal(t, "10.8.0.10", ip)
+
+	_, err = kubeconfigServerIP(NodeInfo{Name: "master-1", WireGuardIP: "10.8.0.10"}, false)
+	assert.ErrorContains(t, err, "--vpn-ip")
+	_, err = kubeconfigServerIP(NodeInfo{Name: "master-1", PublicIP: "203.0.113.10"}, true)
+	assert.Error(t, err)
+
+	assert.Equal(t, "https://[2001:db8::1]:6443", kubeconfigServer("2001:db8::1"))
+}
+
+func TestRewriteClusterKubeconfig(t *testing.T) {
+	config, err := parseKubeconfig([]byte(rke2Kubeconfig))
+	require.NoError(t, err)
+
+	rewritten := rewriteClusterKubeconfig(config, "production", "10.8.0.10")
+	assert.Equal(t, "production", rewritten.CurrentContext)
+	require.Len(t, rewritten.Clusters, 1)
+	assert.Equal(t, "production", rewritten.Clusters[0].Name)
+	assert.Equal(t, "https://10.8.0.10:6443", rewritten.Clusters[0].Cluster["server"])
+	assert.Equal(t, "Q0E=", rewritten.Clusters[0].Cluster["certificate-authority-data"])
+	assert.Equal(t, "production", rewritten.Users[0].Name)
+	assert.Equal(t, "S0VZ", rewritten.Users[0].User["client-key-data"])
+	assert.Equal(t, "production", rewritten.Contexts[0].Name)
+	assert.Equal(t, "production", rewritten.Contexts[0].Context["cluster"])
+	assert.Equal(t, "production", rewritten.Contexts[0].Context["user"])
+
+	// The rewritten file is still a valid kubeconfig
+	data, err := yaml.Marshal(rewritten)
+	require.NoError(t, err)
+	_, err = parseKubeconfig(data)
+	assert.NoError(t, err)
+}
+
+func TestMergeKubeconfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".kube", "config")
+	existing := `apiVersion: v1
+kind: Config
+clusters:
+- name: staging
+  cluster:
+    server: https://198.51.100.5:6443
+- name: production
+  cluster:
+    server: https://192.0.2.1:6443
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: staging
+    namespace: apps
+users:
+- name: staging
+  user:
+    token: abc
+current-context: staging
+preferences: {}
+`
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(existing), 0600))
+
+	config, err := parseKubeconfig([]byte(rke2Kubeconfig))
+	require.NoError(t, err)
+	require.NoError(t, mergeKubeconfigFile(path, rewriteClusterKubeconfig(config, "production", "203.0.113.10")))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if os.PathSeparator == '/' {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	merged, err := parseKubeconfig(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "production", merged.CurrentContext)
+	require.Len(t, merged.Clusters, 2, "the stale production cluster is replaced")
+	assert.Equal(t, "staging", merged.Clusters[0].Name)
+	assert.Equal(t, "https://203.0.113.10:6443", merged.Clusters[1].Cluster["server"])
+	require.Len(t, merged.Contexts, 2)
+	assert.Equal(t, "apps", merged.Contexts[0].Context["namespace"], "other contexts are kept as they are")
+	require.Len(t, merged.Users, 2)
+	assert.Equal(t, "abc", merged.Users[0].User["token"])
+	assert.Contains(t, merged.Extra, "preferences")
+}
+
+func TestMergeKubeconfigFile_CreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".kube", "config")
+
+	config, err := parseKubeconfig([]byte(rke2Kubeconfig))
+	require.NoError(t, err)
+	require.NoError(t, mergeKubeconfigFile(path, rewriteClusterKubeconfig(config, "dev", "203.0.113.10")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	merged, err := parseKubeconfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", merged.APIVersion)
+	assert.Equal(t, "dev", merged.CurrentContext)
+	assert.Len(t, merged.Clusters, 1)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator"
+	"github.com/chalkan3/sloth-kubernetes/internal/validation"
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+var clusterPlanCmd = &cobra.Command{
+	Use:   "plan [stack-name]",
+	Short: "Show how a new configuration differs from the deployed one",
+	Long: `Compare a new configuration file with the configuration the last deploy
+stored in the stack, and print what 'cluster deploy' would change:
+
+  • Node pools and nodes added, removed or resized
+  • Providers added or removed
+  • Network and VPN changes
+  • Addons enabled or disabled
+  • Kubernetes distribution and version changes
+
+Nothing is created or modified, and no Pulumi preview is run: the diff is
+computed from the two configurations alone. The last deployment's metadata
+is shown alongside, including whether the manifest changed since then.`,
+	Example: `  # Preview the changes of an edited manifest
+  sloth-kubernetes cluster plan production --config cluster.lisp`,
+	RunE: runClusterPlan,
+}
+
+func init() {
+	clusterCmd.AddCommand(clusterPlanCmd)
+}
+
+func runClusterPlan(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	stack := getStackFromArgs(args, 0)
+	if stack == "" {
+		return fmt.Errorf("usage: sloth-kubernetes cluster plan <stack-name> --config <file>")
+	}
+	if cfgFile == "" {
+		return fmt.Errorf("--config is required: the new configuration to compare with the deployed one")
+	}
+
+	cfg, err := loadConfiguration()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := validation.ValidateClusterConfig(cfg); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+	if err := validation.ValidateNodePools(cfg); err != nil {
+		return fmt.Errorf("node pool validation failed: %w", err)
+	}
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	previous, err := parseStoredClusterConfig(outputs)
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("📝 Cluster Plan - Stack: %s", stack))
+	if meta := parseDeploymentMeta(outputs); meta != nil {
+		printDeploymentMetaSummary(meta, lispManifestContent)
+	}
+
+	printConfigDiff(previous.Diff(cfg))
+	return nil
+}
+
+// parseDeploymentMeta reads the deploymentMeta output of the last deploy; nil
+// when the stack has none or it cannot be parsed
+func parseDeploymentMeta(outputs auto.OutputMap) *orchestrator.DeploymentMetadata {
+	output, ok := outputs["deploymentMeta"]
+	if !ok || output.Value == nil {
+		return nil
+	}
+	raw, ok := output.Value.(string)
+	if !ok || raw == "" {
+		return nil
+	}
+
+	var meta orchestrator.DeploymentMetadata
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return nil
+	}
+	return &meta
+}
+
+// manifestChecksum returns the checksum a deploy records for a manifest
+func manifestChecksum(manifest string) string {
+	hash := sha256.Sum256([]byte(manifest))
+	return hex.EncodeToString(hash[:])
+}
+
+// printDeploymentMetaSummary describes the last deployment and whether the
+// new manifest differs from the one it deployed
+func printDeploymentMetaSummary(meta *orchestrator.DeploymentMetadata, manifest string) {
+	fmt.Println()
+	fmt.Printf("  Last deployment: %s (#%d) at %s, %d node(s)\n", meta.DeploymentID, meta.DeploymentCount, meta.LastDeployedAt, meta.CurrentNodeCount)
+	switch {
+	case meta.ConfigChecksum == "":
+	case meta.ConfigChecksum == manifestChecksum(manifest):
+		fmt.Println("  Manifest: unchanged since the last deployment")
+	default:
+		fmt.Println("  Manifest: changed since the last deployment")
+	}
+}
+
+// printConfigDiff prints the changed sections, additions in green, removals
+// in red and modifications in yellow
+func printConfigDiff(diff config.ConfigDiff) {
+	fmt.Println()
+	if !diff.HasChanges() {
+		printSuccess("✅ No changes: the configuration matches the deployed one")
+		return
+	}
+
+	var added, removed, modified int
+	for _, section := range diff {
+		if len(section.Changes) == 0 {
+			continue
+		}
+		color.New(color.Bold).Println(section.Name + ":")
+		for _, change := range section.Changes {
+			line := "  " + string(change.Op) + " " + change.Name
+			if change.Detail != "" {
+				line += ": " + change.Detail
+			}
+			switch change.Op {
+			case config.ChangeAdd:
+				added++
+				color.Green(line)
+			case config.ChangeRemove:
+				removed++
+				color.Red(line)
+			default:
+				modified++
+				color.Yellow(line)
+			}
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Plan: %d to add, %d to change, %d to remove. Run 'sloth-kubernetes deploy' to apply.\n", added, modified, removed)
+}
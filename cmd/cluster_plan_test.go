@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterPlanCmd_Structure(t *testing.T) {
+	assert.Equal(t, "plan [stack-name]", clusterPlanCmd.Use)
+	assert.NotEmpty(t, clusterPlanCmd.Short)
+	assert.NotNil(t, clusterPlanCmd.RunE)
+
+	found := false
+	for _, sub := range clusterCmd.Commands() {
+		if sub == clusterPlanCmd {
+			found = true
+		}
+	}
+	assert.True(t, found, "plan is a cluster subcommand")
+}
+
+func TestParseDeploymentMeta(t *testing.T) {
+	outputs := auto.OutputMap{
+		"deploymentMeta": {Value: `{"deploymentId":"deploy-42","deploymentCount":3,"configChecksum":"` + manifestChecksum("(cluster)") + `"}`},
+	}
+
+	meta := parseDeploymentMeta(outputs)
+
+	require.NotNil(t, meta)
+	assert.Equal(t, "deploy-42", meta.DeploymentID)
+	assert.Equal(t, 3, meta.DeploymentCount)
+	assert.Equal(t, manifestChecksum("(cluster)"), meta.ConfigChecksum)
+
+	assert.Nil(t, parseDeploymentMeta(auto.OutputMap{}))
+	assert.Nil(t, parseDeploymentMeta(auto.OutputMap{"deploymentMeta": {Value: "not json"}}))
+}
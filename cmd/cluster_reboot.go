@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator"
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/health"
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+var clusterRebootCmd = &cobra.Command{
+	Use:   "reboot [stack-name] [node-name]",
+	Short: "Reboot a node through its cloud provider",
+	Long: `Reboot a node through the cloud API, for recovering a node that is hung or
+unreachable from inside the cluster. The command waits until the node accepts
+SSH again (through the bastion when the stack has one) before reporting
+success.
+
+With --drain the node is cordoned and drained first, and uncordoned once it
+is back and reports Ready.
+
+Rebooting is supported on DigitalOcean, Linode and AWS.`,
+	Example: `  # Reboot a hung worker
+  sloth-kubernetes cluster reboot production worker-1
+
+  # Move workloads off the node first
+  sloth-kubernetes cluster reboot production worker-1 --drain`,
+	RunE: runClusterReboot,
+}
+
+var (
+	rebootDrain bool
+
+	// rebootReadyTimeout bounds the SSH and Kubernetes readiness waits after a reboot
+	rebootReadyTimeout = 10 * time.Minute
+
+	// rebootPollInterval is how often a rebooting node is probed
+	rebootPollInterval = 5 * time.Second
+)
+
+func init() {
+	clusterCmd.AddCommand(clusterRebootCmd)
+
+	clusterRebootCmd.Flags().BoolVar(&rebootDrain, "drain", false, "Drain the node before rebooting and uncordon it afterwards")
+	addForceUnlockFlag(clusterRebootCmd)
+}
+
+// nodeRebooter runs a node reboot; its kubectl and SSH steps are replaced in tests
+type nodeRebooter struct {
+	kubectl func(args ...string) error
+	waitSSH func(ctx context.Context, node NodeInfo) error
+}
+
+func runClusterReboot(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: sloth-kubernetes cluster reboot <stack-name> <node-name> [--drain]")
+	}
+	stack := args[0]
+	name := args[1]
+
+	printHeader(fmt.Sprintf("🔄 Rebooting node '%s' in stack: %s", name, stack))
+
+	unlock, err := lockStack(ctx, stack, "cluster-reboot")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse node outputs: %w", err)
+	}
+
+	var target *NodeInfo
+	for i := range nodes {
+		if nodes[i].Name == name {
+			target = &nodes[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("node '%s' not found in stack '%s'", name, stack)
+	}
+
+	cfg, err := parseStoredClusterConfig(outputs)
+	if err != nil {
+		// Without the stored configuration every node is rebooted with the
+		// provider's default credentials
+		cfg = &config.ClusterConfig{}
+	}
+	orch, err := orchestrator.ForStack(cfg, stackNodeOutputs(nodes))
+	if err != nil {
+		return err
+	}
+	provider, _, err := orch.GetProviderForNode(name)
+	if err != nil {
+		return err
+	}
+
+	if rebootDrain {
+		if err := configureKubectlForStack(stack); err != nil {
+			return err
+		}
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	rebooter := &nodeRebooter{
+		kubectl: func(args ...string) error { return executeKubectl(args) },
+		waitSSH: func(ctx context.Context, node NodeInfo) error {
+			return waitForNodeSSHAfterReboot(ctx, node, sshKeyPath, bastionIP)
+		},
+	}
+
+	err = rebooter.reboot(ctx, *target, provider, rebootDrain)
+
+	details := "Reboot"
+	if rebootDrain {
+		details = "Reboot with drain"
+	}
+	roles := strings.Join(target.Roles, ",")
+	if err != nil {
+		operations.RecordNodeOperation(stack, "reboot", name, roles, target.PublicIP, "failed", details, time.Since(startTime), err)
+		return err
+	}
+	operations.RecordNodeOperation(stack, "reboot", name, roles, target.PublicIP, "success", details, time.Since(startTime), nil)
+
+	fmt.Println()
+	printSuccess(fmt.Sprintf("✅ Node '%s' rebooted and reachable over SSH", name))
+
+	return nil
+}
+
+// reboot optionally drains the node, reboots it through the provider, waits
+// for SSH and, after a drain, for Kubernetes to report it Ready before
+// uncordoning it
+func (r *nodeRebooter) reboot(ctx context.Context, node NodeInfo, provider providers.Provider, drain bool) error {
+	if drain {
+		color.Cyan("🚧 Draining %s...", node.Name)
+		if err := r.kubectl("drain", node.Name, "--ignore-daemonsets", "--delete-emptydir-data", "--timeout=5m"); err != nil {
+			return fmt.Errorf("failed to drain node '%s': %w", node.Name, err)
+		}
+	}
+
+	color.Cyan("🔄 Rebooting %s...", node.Name)
+	if err := provider.RebootNode(ctx, stackNodeOutput(node)); err != nil {
+		if drain {
+			printWarning(fmt.Sprintf("Node '%s' is still cordoned; run 'kubectl uncordon %s' once it is healthy", node.Name, node.Name))
+		}
+		return fmt.Errorf("failed to reboot node '%s': %w", node.Name, err)
+	}
+
+	color.Cyan("🔑 Waiting for SSH on %s...", node.Name)
+	if err := r.waitSSH(ctx, node); err != nil {
+		if drain {
+			printWarning(fmt.Sprintf("Node '%s' is still cordoned", node.Name))
+		}
+		return fmt.Errorf("node '%s' not reachable over SSH after reboot: %w", node.Name, err)
+	}
+
+	if !drain {
+		return nil
+	}
+
+	color.Cyan("☸️  Waiting for %s to become Ready...", node.Name)
+	if err := r.kubectl("wait", "--for=condition=Ready", "node/"+node.Name, fmt.Sprintf("--timeout=%s", rebootReadyTimeout)); err != nil {
+		printWarning(fmt.Sprintf("Node '%s' is still cordoned", node.Name))
+		return fmt.Errorf("node '%s' did not become Ready after reboot: %w", node.Name, err)
+	}
+	if err := r.kubectl("uncordon", node.Name); err != nil {
+		return fmt.Errorf("failed to uncordon node '%s': %w", node.Name, err)
+	}
+	return nil
+}
+
+// waitForNodeSSHAfterReboot waits until the node answers on SSH: its SSH
+// banner through the health checker when it has a public IP, otherwise an
+// SSH session through the bastion
+func waitForNodeSSHAfterReboot(ctx context.Context, node NodeInfo, sshKeyPath, bastionIP string) error {
+	ctx, cancel := context.WithTimeout(ctx, rebootReadyTimeout)
+	defer cancel()
+
+	if bastionIP == "" && node.PublicIP != "" {
+		return health.NewHealthChecker(nil).WaitForSSH(ctx, node.Name, net.JoinHostPort(node.PublicIP, "22"), rebootPollInterval)
+	}
+
+	for {
+		err := checkNodeReachable(node, sshKeyPath, bastionIP)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(rebootPollInterval):
+		}
+	}
+}
+
+// stackNodeOutput returns the provider view of a stack node
+func stackNodeOutput(node NodeInfo) *providers.NodeOutput {
+	return &providers.NodeOutput{
+		Name:     node.Name,
+		Provider: node.Provider,
+		Region:   node.Region,
+		Size:     node.Size,
+	}
+}
+
+// stackNodeOutputs returns the provider view of every stack node, for
+// orchestrator.ForStack
+func stackNodeOutputs(nodes []NodeInfo) []*providers.NodeOutput {
+	outputs := make([]*providers.NodeOutput, len(nodes))
+	for i, node := range nodes {
+		outputs[i] = stackNodeOutput(node)
+	}
+	return outputs
+}
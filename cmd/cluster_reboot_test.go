@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+type fakeRebootProvider struct {
+	providers.Provider
+	rebootErr error
+	rebooted  []string
+}
+
+func (p *fakeRebootProvider) RebootNode(ctx context.Context, node *providers.NodeOutput) error {
+	if p.rebootErr != nil {
+		return p.rebootErr
+	}
+	p.rebooted = append(p.rebooted, node.Name)
+	return nil
+}
+
+func newTestRebooter(calls *[]string, sshErr error) *nodeRebooter {
+	return &nodeRebooter{
+		kubectl: func(args ...string) error {
+			*calls = append(*calls, strings.Join(args, " "))
+			return nil
+		},
+		waitSSH: func(ctx context.Context, node NodeInfo) error {
+			*calls = append(*calls, "wait-ssh "+node.Name)
+			return sshErr
+		},
+	}
+}
+
+func TestClusterRebootCmd_Structure(t *testing.T) {
+	assert.Equal(t, "reboot [stack-name] [node-name]", clusterRebootCmd.Use)
+	assert.NotNil(t, clusterRebootCmd.RunE)
+
+	flag := clusterRebootCmd.Flags().Lookup("drain")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+}
+
+func TestNodeRebooter_Reboot(t *testing.T) {
+	var calls []string
+	provider := &fakeRebootProvider{}
+	node := NodeInfo{Name: "worker-1", Provider: "fake"}
+
+	require.NoError(t, newTestRebooter(&calls, nil).reboot(context.Background(), node, provider, false))
+
+	assert.Equal(t, []string{"worker-1"}, provider.rebooted)
+	assert.Equal(t, []string{"wait-ssh worker-1"}, calls, "no kubectl calls without --drain")
+}
+
+func TestNodeRebooter_RebootWithDrain(t *testing.T) {
+	var calls []string
+	provider := &fakeRebootProvider{}
+	node := NodeInfo{Name: "worker-1", Provider: "fake"}
+
+	require.NoError(t, newTestRebooter(&calls, nil).reboot(context.Background(), node, provider, true))
+
+	require.Len(t, calls, 4)
+	assert.True(t, strings.HasPrefix(calls[0], "drain worker-1"))
+	assert.Equal(t, "wait-ssh worker-1", calls[1])
+	assert.True(t, strings.HasPrefix(calls[2], "wait --for=condition=Ready node/worker-1"))
+	assert.Equal(t, "uncordon worker-1", calls[3])
+}
+
+func TestNodeRebooter_RebootFailureKeepsNodeCordoned(t *testing.T) {
+	var calls []string
+	provider := &fakeRebootProvider{rebootErr: errors.New("droplet locked")}
+	node := NodeInfo{Name: "worker-1", Provider: "fake"}
+
+	err := newTestRebooter(&calls, nil).reboot(context.Background(), node, provider, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to reboot node 'worker-1': droplet locked")
+	require.Len(t, calls, 1, "nothing runs after a failed reboot")
+	assert.True(t, strings.HasPrefix(calls[0], "drain worker-1"))
+}
+
+func TestNodeRebooter_SSHTimeout(t *testing.T) {
+	var calls []string
+	provider := &fakeRebootProvider{}
+	node := NodeInfo{Name: "worker-1", Provider: "fake"}
+
+	err := newTestRebooter(&calls, errors.New("connection refused")).reboot(context.Background(), node, provider, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "node 'worker-1' not reachable over SSH after reboot")
+	assert.NotContains(t, calls, "uncordon worker-1")
+}
+
+func TestWaitForNodeSSHAfterReboot_PollsThroughBastion(t *testing.T) {
+	origCheck, origInterval := checkNodeReachable, rebootPollInterval
+	defer func() { checkNodeReachable, rebootPollInterval = origCheck, origInterval }()
+	rebootPollInterval = time.Millisecond
+
+	attempts := 0
+	checkNodeReachable = func(node NodeInfo, sshKeyPath, bastionIP string) error {
+		attempts++
+		assert.Equal(t, "198.51.100.1", bastionIP)
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	node := NodeInfo{Name: "worker-1", PrivateIP: "10.0.1.5"}
+	require.NoError(t, waitForNodeSSHAfterReboot(context.Background(), node, "/tmp/key", "198.51.100.1"))
+	assert.Equal(t, 3, attempts)
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator"
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+var clusterResizeCmd = &cobra.Command{
+	Use:   "resize [stack-name] [node-name...]",
+	Short: "Change the instance size of one or more nodes",
+	Long: `Resize nodes in place through the cloud API. The nodes are resized at the
+same time, each one cordoned and drained, powered off where the cloud
+requires a stopped instance (DigitalOcean, Linode, AWS), resized, started,
+checked for VPN connectivity and Kubernetes readiness, and uncordoned.
+
+The new size is checked against the sizes each node's provider offers before
+any node is drained. On a high-availability cluster, resizing more masters at
+once than etcd's quorum tolerates (all of them, or two of three) is refused;
+resize masters one at a time.
+
+Update the node pool size in your configuration afterwards so the next deploy
+keeps the new size.`,
+	Example: `  # Resize two workers
+  sloth-kubernetes cluster resize production worker-1 worker-2 --size s-4vcpu-8gb
+
+  # Resize a master
+  sloth-kubernetes cluster resize production master-1 --size s-8vcpu-16gb`,
+	RunE: runClusterResize,
+}
+
+var clusterResizeSize string
+
+func init() {
+	clusterCmd.AddCommand(clusterResizeCmd)
+
+	clusterResizeCmd.Flags().StringVar(&clusterResizeSize, "size", "", "New node size/type (required)")
+	addForceUnlockFlag(clusterResizeCmd)
+	clusterResizeCmd.MarkFlagRequired("size")
+}
+
+// resizeTarget is a node to resize and the provider that resizes it
+type resizeTarget struct {
+	node     NodeInfo
+	provider providers.Provider
+}
+
+func runClusterResize(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	if len(args) < 2 {
+		return fmt.Errorf("usage: sloth-kubernetes cluster resize <stack-name> <node-name>... --size <size>")
+	}
+	stack := args[0]
+	names := args[1:]
+
+	printHeader(fmt.Sprintf("📐 Resizing %s in stack: %s", strings.Join(names, ", "), stack))
+
+	unlock, err := lockStack(ctx, stack, "cluster-resize")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse node outputs: %w", err)
+	}
+
+	cfg, err := parseStoredClusterConfig(outputs)
+	if err != nil {
+		// Without the stored configuration, treat a cluster with a quorum
+		// of masters as highly available
+		cfg = &config.ClusterConfig{Cluster: config.ClusterSpec{HighAvailability: len(masterNodeNames(nodes)) >= 3}}
+	}
+
+	orch, err := orchestrator.ForStack(cfg, stackNodeOutputs(nodes))
+	if err != nil {
+		return err
+	}
+	providerFor := func(name string) (providers.Provider, error) {
+		provider, _, err := orch.GetProviderForNode(name)
+		return provider, err
+	}
+
+	targets, err := planClusterResize(cfg, nodes, names, clusterResizeSize, providerFor)
+	if err != nil {
+		return err
+	}
+
+	if err := configureKubectlForStack(stack); err != nil {
+		return err
+	}
+
+	vpnMode, _ := detectVPNMode(outputs)
+	resizer := &nodeResizer{
+		kubectl: func(args ...string) error { return executeKubectl(args) },
+		verifyVPN: func(ctx context.Context, node NodeInfo) error {
+			return verifyNodeVPN(ctx, stack, node, vpnMode, bastionIPFromOutputs(outputs))
+		},
+	}
+
+	failures := resizeNodesConcurrently(ctx, resizer, targets, clusterResizeSize)
+
+	var errs []error
+	for _, target := range targets {
+		node := target.node
+		details := fmt.Sprintf("Resize %s -> %s", node.Size, clusterResizeSize)
+		roles := strings.Join(node.Roles, ",")
+		if err := failures[node.Name]; err != nil {
+			operations.RecordNodeOperation(stack, "resize", node.Name, roles, node.PublicIP, "failed", details, time.Since(startTime), err)
+			errs = append(errs, err)
+			continue
+		}
+		operations.RecordNodeOperation(stack, "resize", node.Name, roles, node.PublicIP, "success", details, time.Since(startTime), nil)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d node(s) failed to resize: %w", len(errs), len(targets), errors.Join(errs...))
+	}
+
+	fmt.Println()
+	printSuccess(fmt.Sprintf("✅ Resized %s to %s", strings.Join(names, ", "), clusterResizeSize))
+	printWarning("Update the node pool size in your configuration so the next deploy keeps the new size")
+
+	return nil
+}
+
+// planClusterResize resolves the nodes to resize and their providers, and
+// checks everything that can be checked before a node is drained: that each
+// node exists, offers the new size and is not that size already, and that the
+// masters among them can go down together. providerFor returns the provider
+// instance that created the named node.
+func planClusterResize(cfg *config.ClusterConfig, nodes []NodeInfo, names []string, newSize string, providerFor func(node string) (providers.Provider, error)) ([]resizeTarget, error) {
+	byName := make(map[string]NodeInfo, len(nodes))
+	for _, node := range nodes {
+		byName[node.Name] = node
+	}
+
+	seen := make(map[string]bool, len(names))
+	var targets []resizeTarget
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		node, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("node '%s' not found in stack", name)
+		}
+		provider, err := providerFor(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := providers.ValidateNodeSize(provider, newSize); err != nil {
+			return nil, fmt.Errorf("node '%s': %w", name, err)
+		}
+		if node.Size == newSize {
+			return nil, fmt.Errorf("node '%s' is already size %s", name, newSize)
+		}
+		targets = append(targets, resizeTarget{node: node, provider: provider})
+	}
+
+	resizing := make([]string, len(targets))
+	for i, target := range targets {
+		resizing[i] = target.node.Name
+	}
+	if err := orchestrator.CheckConcurrentMasterResize(cfg, masterNodeNames(nodes), resizing); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// resizeNodesConcurrently resizes every target at once and returns the error
+// of each node that failed
+func resizeNodesConcurrently(ctx context.Context, resizer *nodeResizer, targets []resizeTarget, newSize string) map[string]error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures = make(map[string]error)
+	)
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target resizeTarget) {
+			defer wg.Done()
+			if err := resizer.resize(ctx, target.node, target.provider, newSize); err != nil {
+				mu.Lock()
+				failures[target.node.Name] = err
+				mu.Unlock()
+			}
+		}(target)
+	}
+	wg.Wait()
+	return failures
+}
+
+// masterNodeNames returns the names of the master nodes, sorted
+func masterNodeNames(nodes []NodeInfo) []string {
+	var names []string
+	for _, node := range nodes {
+		if isMasterNode(node) {
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
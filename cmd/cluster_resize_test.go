@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+func TestClusterResizeCmd_Structure(t *testing.T) {
+	assert.Equal(t, "resize [stack-name] [node-name...]", clusterResizeCmd.Use)
+	assert.NotNil(t, clusterResizeCmd.RunE)
+	assert.NotNil(t, clusterResizeCmd.Flags().Lookup("size"))
+	assert.NotNil(t, clusterResizeCmd.Flags().Lookup("force-unlock"))
+}
+
+func resizeTestNodes() []NodeInfo {
+	return []NodeInfo{
+		{Name: "master-1", Provider: "fake", Size: "small", Roles: []string{"master"}},
+		{Name: "master-2", Provider: "fake", Size: "small", Roles: []string{"master"}},
+		{Name: "master-3", Provider: "fake", Size: "small", Roles: []string{"master"}},
+		{Name: "worker-1", Provider: "fake", Size: "small", Roles: []string{"worker"}},
+		{Name: "worker-2", Provider: "fake", Size: "large", Roles: []string{"worker"}},
+	}
+}
+
+func fakeResizeProviderFor(name string) (providers.Provider, error) {
+	return &fakeResizeProvider{}, nil
+}
+
+func TestPlanClusterResize(t *testing.T) {
+	ha := &config.ClusterConfig{Cluster: config.ClusterSpec{HighAvailability: true}}
+
+	targets, err := planClusterResize(ha, resizeTestNodes(), []string{"worker-1", "master-2", "worker-1"}, "large", fakeResizeProviderFor)
+	require.NoError(t, err)
+	require.Len(t, targets, 2, "duplicate names are resized once")
+	assert.Equal(t, "worker-1", targets[0].node.Name)
+	assert.Equal(t, "master-2", targets[1].node.Name)
+}
+
+func TestPlanClusterResize_Refusals(t *testing.T) {
+	ha := &config.ClusterConfig{Cluster: config.ClusterSpec{HighAvailability: true}}
+	nodes := resizeTestNodes()
+
+	tests := []struct {
+		name    string
+		cfg     *config.ClusterConfig
+		nodes   []string
+		size    string
+		wantErr string
+	}{
+		{"unknown node", ha, []string{"worker-9"}, "large", "node 'worker-9' not found"},
+		{"unavailable size", ha, []string{"worker-1"}, "huge", `size "huge" is not available on fake`},
+		{"same size", ha, []string{"worker-1", "worker-2"}, "large", "node 'worker-2' is already size large"},
+		{"all masters", ha, []string{"master-1", "master-2", "master-3"}, "large", "refusing to resize all 3 masters"},
+		{"quorum", ha, []string{"master-1", "master-3", "worker-1"}, "large", "etcd keeps quorum with at most 1 of 3 masters down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := planClusterResize(tt.cfg, nodes, tt.nodes, tt.size, fakeResizeProviderFor)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err := planClusterResize(&config.ClusterConfig{}, nodes, []string{"master-1", "master-2", "master-3"}, "large", fakeResizeProviderFor)
+	assert.NoError(t, err, "masters of a cluster without HA may be resized together")
+}
+
+func TestResizeNodesConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	resizer := &nodeResizer{
+		kubectl: func(args ...string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, strings.Join(args, " "))
+			return nil
+		},
+		verifyVPN: func(ctx context.Context, node NodeInfo) error { return nil },
+	}
+	failing := &fakeResizeProvider{resizeErr: errors.New("quota exceeded")}
+	targets := []resizeTarget{
+		{node: NodeInfo{Name: "worker-1", Provider: "fake", Size: "small"}, provider: &fakeResizeProvider{}},
+		{node: NodeInfo{Name: "worker-2", Provider: "fake", Size: "small"}, provider: failing},
+	}
+
+	failures := resizeNodesConcurrently(context.Background(), resizer, targets, "large")
+
+	require.Len(t, failures, 1)
+	assert.Contains(t, failures["worker-2"].Error(), "quota exceeded")
+	assert.Contains(t, calls, "uncordon worker-1")
+	assert.NotContains(t, calls, "uncordon worker-2", "a node that failed to resize stays cordoned")
+}
+
+func TestMasterNodeNames(t *testing.T) {
+	assert.Equal(t, []string{"master-1", "master-2", "master-3"}, masterNodeNames(resizeTestNodes()))
+	assert.Nil(t, masterNodeNames(nil))
+}
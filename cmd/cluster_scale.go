@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator"
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+var clusterScaleCmd = &cobra.Command{
+	Use:   "scale [stack-name]",
+	Short: "Grow or shrink a node pool",
+	Long: `Change the node count of a pool without editing the configuration file.
+The configuration stored by the last deploy is loaded, the pool's current
+nodes are compared with the new count, and the stack is updated with the new
+count: missing nodes (<pool>-1..<pool>-N) are created and the highest
+numbered ones are removed. Nodes being removed are cordoned and drained first.
+
+Provider credentials are read from the environment (DIGITALOCEAN_TOKEN,
+LINODE_TOKEN, AWS_ACCESS_KEY_ID, ...), since the stored configuration does
+not keep them. A master pool of a high-availability cluster cannot be scaled
+below 3 nodes.`,
+	Example: `  # Add workers
+  sloth-kubernetes cluster scale production --pool workers --count 5
+
+  # Remove workers without prompting
+  sloth-kubernetes cluster scale production --pool workers --count 2 --yes`,
+	RunE: runClusterScale,
+}
+
+var (
+	scalePoolName string
+	scaleCount    int
+)
+
+func init() {
+	clusterCmd.AddCommand(clusterScaleCmd)
+
+	clusterScaleCmd.Flags().StringVar(&scalePoolName, "pool", "", "Node pool to scale (required)")
+	clusterScaleCmd.Flags().IntVar(&scaleCount, "count", 0, "Desired number of nodes in the pool (required)")
+	addForceUnlockFlag(clusterScaleCmd)
+	clusterScaleCmd.MarkFlagRequired("pool")
+	clusterScaleCmd.MarkFlagRequired("count")
+}
+
+// poolScaler drains the nodes a scale down removes; its kubectl calls are
+// replaced in tests
+type poolScaler struct {
+	kubectl func(args ...string) error
+}
+
+func runClusterScale(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	stack := getStackFromArgs(args, 0)
+	if stack == "" {
+		return fmt.Errorf("usage: sloth-kubernetes cluster scale <stack-name> --pool <pool> --count <n>")
+	}
+
+	printHeader(fmt.Sprintf("📏 Scaling node pool '%s' in stack: %s", scalePoolName, stack))
+
+	unlock, err := lockStack(ctx, stack, "cluster-scale")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	cfg, err := storedClusterConfig(outputs)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse node outputs: %w", err)
+	}
+	current := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		current = append(current, node.Name)
+	}
+
+	plan, err := orchestrator.PlanPoolScale(cfg, scalePoolName, current, scaleCount)
+	if err != nil {
+		return err
+	}
+	if len(plan.Add) == 0 && len(plan.Remove) == 0 {
+		printInfo(fmt.Sprintf("Node pool '%s' already has %d node(s)", scalePoolName, scaleCount))
+		return nil
+	}
+
+	printScalePlan(plan)
+	if !autoApprove && !confirm(fmt.Sprintf("Scale node pool '%s' from %d to %d?", plan.Pool, plan.From, plan.To)) {
+		color.Yellow("Scale cancelled")
+		return nil
+	}
+
+	scaler := &poolScaler{kubectl: func(args ...string) error { return executeKubectl(args) }}
+	if len(plan.Remove) > 0 {
+		if err := configureKubectlForStack(stack); err != nil {
+			return err
+		}
+		if err := scaler.drain(plan.Remove); err != nil {
+			return err
+		}
+	}
+
+	pool := cfg.NodePools[scalePoolName]
+	pool.Count = scaleCount
+	cfg.NodePools[scalePoolName] = pool
+
+	stackName = stack
+	lispManifestContent = config.GenerateLisp(cfg)
+	loadPreviousDeploymentMeta(ctx, s)
+
+	deployStack, err := prepareDeployStack(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printInfo("🚀 Updating stack...")
+	_, err = deployStack.Up(ctx, optup.ProgressStreams(os.Stdout))
+
+	details := fmt.Sprintf("Scale %s %d -> %d", plan.Pool, plan.From, plan.To)
+	roles := strings.Join(pool.Roles, ",")
+	if err != nil {
+		operations.RecordNodeOperation(stack, "scale", plan.Pool, roles, "", "failed", details, time.Since(startTime), err)
+		if len(plan.Remove) > 0 {
+			printWarning(fmt.Sprintf("Drained node(s) %s are still cordoned", strings.Join(plan.Remove, ", ")))
+		}
+		return fmt.Errorf("failed to scale node pool '%s': %w", plan.Pool, err)
+	}
+	operations.RecordNodeOperation(stack, "scale", plan.Pool, roles, "", "success", details, time.Since(startTime), nil)
+
+	scaler.forget(plan.Remove)
+
+	fmt.Println()
+	printSuccess(fmt.Sprintf("✅ Node pool '%s' scaled from %d to %d node(s)", plan.Pool, plan.From, plan.To))
+	printWarning("Update the pool count in your configuration file so the next deploy keeps it")
+
+	return nil
+}
+
+// storedClusterConfig reads the configuration the last deploy stored in the
+// stack and resolves the ${VAR} placeholders that stand in for credentials
+func storedClusterConfig(outputs auto.OutputMap) (*config.ClusterConfig, error) {
+	cfg, err := parseStoredClusterConfig(outputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.InterpolateEnv(cfg); err != nil {
+		return nil, fmt.Errorf("stored configuration needs provider credentials from the environment: %w", err)
+	}
+	return cfg, nil
+}
+
+// parseStoredClusterConfig reads the configuration the last deploy stored in
+// the stack, leaving credential placeholders unresolved
+func parseStoredClusterConfig(outputs auto.OutputMap) (*config.ClusterConfig, error) {
+	jsonOutput, ok := outputs["configJson"]
+	if !ok || jsonOutput.Value == nil {
+		return nil, fmt.Errorf("no stored configuration found in stack outputs; redeploy with the configuration file")
+	}
+	jsonStr, ok := jsonOutput.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("configJson is not a string")
+	}
+
+	var cfg config.ClusterConfig
+	if err := json.Unmarshal([]byte(jsonStr), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse stored configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+func printScalePlan(plan *orchestrator.PoolScalePlan) {
+	fmt.Println()
+	fmt.Printf("  Pool: %s (%d -> %d)\n", plan.Pool, plan.From, plan.To)
+	for _, name := range plan.Add {
+		color.Green("  + %s", name)
+	}
+	for _, name := range plan.Remove {
+		color.Red("  - %s", name)
+	}
+}
+
+// drain cordons and drains each node before the stack update destroys it
+func (s *poolScaler) drain(names []string) error {
+	for _, name := range names {
+		color.Cyan("🚧 Draining %s...", name)
+		if err := s.kubectl("drain", name, "--ignore-daemonsets", "--delete-emptydir-data", "--timeout=5m"); err != nil {
+			return fmt.Errorf("failed to drain node '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// forget removes the destroyed nodes from the Kubernetes API; failures only
+// leave NotReady node objects behind, so they are reported as warnings
+func (s *poolScaler) forget(names []string) {
+	for _, name := range names {
+		if err := s.kubectl("delete", "node", name, "--ignore-not-found"); err != nil {
+			printWarning(fmt.Sprintf("Failed to delete node object '%s': %v", name, err))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterScaleCmd_Structure(t *testing.T) {
+	assert.Equal(t, "scale [stack-name]", clusterScaleCmd.Use)
+	assert.NotNil(t, clusterScaleCmd.RunE)
+	assert.NotNil(t, clusterScaleCmd.Flags().Lookup("pool"))
+	assert.NotNil(t, clusterScaleCmd.Flags().Lookup("count"))
+}
+
+func TestStoredClusterConfig(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_TOKEN", "do-token")
+
+	outputs := auto.OutputMap{"configJson": auto.OutputValue{Value: `{
+  "providers": {"digitalocean": {"enabled": true, "token": "${DIGITALOCEAN_TOKEN}"}},
+  "nodePools": {"workers": {"name": "workers", "provider": "digitalocean", "count": 3, "roles": ["worker"]}}
+}`}}
+
+	cfg, err := storedClusterConfig(outputs)
+	require.NoError(t, err)
+	assert.Equal(t, "do-token", cfg.Providers.DigitalOcean.Token)
+	assert.Equal(t, 3, cfg.NodePools["workers"].Count)
+}
+
+func TestStoredClusterConfig_Errors(t *testing.T) {
+	_, err := storedClusterConfig(auto.OutputMap{})
+	assert.ErrorContains(t, err, "no stored configuration")
+
+	outputs := auto.OutputMap{"configJson": auto.OutputValue{Value: `{"providers": {"linode": {"token": "${SLOTH_SCALE_TEST_UNSET_TOKEN}"}}}`}}
+	_, err = storedClusterConfig(outputs)
+	assert.ErrorContains(t, err, "SLOTH_SCALE_TEST_UNSET_TOKEN")
+}
+
+func TestPoolScaler_DrainAndForget(t *testing.T) {
+	var calls []string
+	scaler := &poolScaler{kubectl: func(args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "delete" && args[2] == "workers-3" {
+			return errors.New("forbidden")
+		}
+		return nil
+	}}
+
+	require.NoError(t, scaler.drain([]string{"workers-4", "workers-3"}))
+	scaler.forget([]string{"workers-4", "workers-3"})
+
+	assert.Equal(t, []string{
+		"drain workers-4 --ignore-daemonsets --delete-emptydir-data --timeout=5m",
+		"drain workers-3 --ignore-daemonsets --delete-emptydir-data --timeout=5m",
+		"delete node workers-4 --ignore-not-found",
+		"delete node workers-3 --ignore-not-found",
+	}, calls)
+}
+
+func TestPoolScaler_DrainFailureStops(t *testing.T) {
+	calls := 0
+	scaler := &poolScaler{kubectl: func(args ...string) error {
+		calls++
+		return errors.New("timed out")
+	}}
+
+	err := scaler.drain([]string{"workers-4", "workers-3"})
+	assert.EqualError(t, err, "failed to drain node 'workers-4': timed out")
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,425 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/health"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
+)
+
+var clusterStatusCmd = &cobra.Command{
+	Use:   "status [stack-name]",
+	Short: "Show node, VPN and Kubernetes health in one report",
+	Long: `Check the cluster's subsystems and combine them into one report with an
+overall verdict:
+
+  • Nodes:      every node accepts SSH connections (through the bastion when
+                the stack has one)
+  • VPN:        the WireGuard or Tailscale mesh as one node sees it
+  • Kubernetes: node readiness, API server components (/readyz) and
+                kube-system pods, read with kubectl on the first master
+
+The verdict is green when every subsystem is healthy, red when any is
+critical, and yellow otherwise, including when a subsystem could not be
+checked. A subsystem that cannot be reached is reported as unknown and the
+others are still checked. The command exits with an error on red.`,
+	Example: `  # Show the cluster status
+  sloth-kubernetes cluster status production
+
+  # JSON for monitoring
+  sloth-kubernetes cluster status production --output json`,
+	RunE: runClusterStatus,
+}
+
+var clusterStatusOutput string
+
+// Overall verdicts of 'cluster status'
+const (
+	clusterVerdictGreen  = "green"
+	clusterVerdictYellow = "yellow"
+	clusterVerdictRed    = "red"
+)
+
+// clusterStatusWorkers bounds the SSH reachability checks run at once
+const clusterStatusWorkers = 10
+
+func init() {
+	clusterCmd.AddCommand(clusterStatusCmd)
+
+	clusterStatusCmd.Flags().StringVarP(&clusterStatusOutput, "output", "o", "table", "Output format: table|json")
+}
+
+// clusterStatusReport is the state shown by 'cluster status'
+type clusterStatusReport struct {
+	Stack      string                   `json:"stack"`
+	Verdict    string                   `json:"verdict"`
+	CheckedAt  time.Time                `json:"checkedAt"`
+	Subsystems []clusterSubsystemStatus `json:"subsystems"`
+	Nodes      []clusterNodeStatus      `json:"nodes"`
+	VPN        *vpnStatusReport         `json:"vpn,omitempty"`
+}
+
+// clusterSubsystemStatus is the health of one subsystem
+type clusterSubsystemStatus struct {
+	Name    string             `json:"name"`
+	Status  health.CheckStatus `json:"status"`
+	Message string             `json:"message"`
+	Details []string           `json:"details,omitempty"`
+}
+
+// clusterNodeStatus is whether a node accepted an SSH connection
+type clusterNodeStatus struct {
+	Name      string `json:"name"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// checkNodeReachable connects to a node over SSH; replaced in tests
+var checkNodeReachable = func(node NodeInfo, sshKeyPath, bastionIP string) error {
+	_, err := runNodeScriptOutput(node, "true\n", sshKeyPath, bastionIP)
+	return err
+}
+
+// fetchVPNLiveStatus reads the mesh state from the first reachable node;
+// replaced in tests
+var fetchVPNLiveStatus = func(ctx context.Context, mode VPNMode, nodes []NodeInfo, sshKeyPath, bastionIP string) (*vpnLiveStatus, error) {
+	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
+		SSHKeyPath:     sshKeyPath,
+		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
+		ConnectTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize VPN manager: %w", err)
+	}
+
+	watcher := &vpnStatusWatcher{
+		mode:           mode,
+		nodes:          nodes,
+		connMgr:        vpnMgr.GetConnectionManager(),
+		bastionEnabled: bastionIP != "",
+		bastionIP:      bastionIP,
+	}
+	defer watcher.Close()
+
+	live, _, err := watcher.fetch(ctx)
+	return live, err
+}
+
+// runClusterStatusKubectl runs clusterStatusKubectlScript on a master;
+// replaced in tests
+var runClusterStatusKubectl = func(master NodeInfo, sshKeyPath, bastionIP string) (string, error) {
+	return runNodeScriptOutput(master, clusterStatusKubectlScript, sshKeyPath, bastionIP)
+}
+
+// clusterStatusKubectlScript prints node readiness, the API server's
+// readiness checks and the kube-system pods, separated by "---" lines
+var clusterStatusKubectlScript = veleroKubectlScript(`kubectl get nodes --no-headers
+echo ---
+kubectl get --raw='/readyz?verbose' 2>&1
+echo ---
+kubectl -n kube-system get pods --no-headers
+`)
+
+func runClusterStatus(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if clusterStatusOutput != "table" && clusterStatusOutput != "json" {
+		return fmt.Errorf("invalid output format: %s (must be table or json)", clusterStatusOutput)
+	}
+
+	stack := getStackFromArgs(args, 0)
+	if stack == "" {
+		return fmt.Errorf("usage: sloth-kubernetes cluster status <stack-name>")
+	}
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	if clusterStatusOutput == "table" {
+		printHeader(fmt.Sprintf("🩺 Cluster Status - Stack: %s", stack))
+		fmt.Println()
+		color.Cyan("Checking nodes, VPN and Kubernetes...")
+		fmt.Println()
+	}
+
+	report := buildClusterStatusReport(ctx, stack, outputs)
+
+	if clusterStatusOutput == "json" {
+		if err := writeVPNJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printClusterStatusReport(report)
+	}
+
+	if report.Verdict == clusterVerdictRed {
+		return fmt.Errorf("cluster %s is unhealthy", stack)
+	}
+	return nil
+}
+
+// buildClusterStatusReport checks every subsystem. Each is checked even when
+// another could not be reached.
+func buildClusterStatusReport(ctx context.Context, stack string, outputs auto.OutputMap) clusterStatusReport {
+	report := clusterStatusReport{Stack: stack, CheckedAt: time.Now()}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil || len(nodes) == 0 {
+		message := "no nodes in the stack outputs"
+		if err != nil {
+			message = fmt.Sprintf("failed to parse node outputs: %v", err)
+		}
+		for _, name := range []string{"Nodes", "VPN", "Kubernetes"} {
+			report.Subsystems = append(report.Subsystems, clusterSubsystemStatus{Name: name, Status: health.StatusUnknown, Message: message})
+		}
+		report.Verdict = clusterVerdict(report.Subsystems)
+		return report
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+
+	var nodesStatus clusterSubsystemStatus
+	report.Nodes, nodesStatus = checkClusterNodes(nodes, sshKeyPath, bastionIP)
+
+	var vpnStatus clusterSubsystemStatus
+	report.VPN, vpnStatus = checkClusterVPN(ctx, stack, outputs, nodes, sshKeyPath, bastionIP)
+
+	report.Subsystems = []clusterSubsystemStatus{
+		nodesStatus,
+		vpnStatus,
+		checkClusterKubernetes(nodes, sshKeyPath, bastionIP),
+	}
+	report.Verdict = clusterVerdict(report.Subsystems)
+	return report
+}
+
+// checkClusterNodes checks every node accepts SSH connections
+func checkClusterNodes(nodes []NodeInfo, sshKeyPath, bastionIP string) ([]clusterNodeStatus, clusterSubsystemStatus) {
+	results := make([]clusterNodeStatus, len(nodes))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, clusterStatusWorkers)
+	for i, node := range nodes {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, node NodeInfo) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i] = clusterNodeStatus{Name: node.Name, Reachable: true}
+			if err := checkNodeReachable(node, sshKeyPath, bastionIP); err != nil {
+				results[i] = clusterNodeStatus{Name: node.Name, Error: err.Error()}
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	status := clusterSubsystemStatus{Name: "Nodes"}
+	reachable := 0
+	for _, result := range results {
+		if result.Reachable {
+			reachable++
+			continue
+		}
+		status.Details = append(status.Details, fmt.Sprintf("%s is unreachable: %s", result.Name, result.Error))
+	}
+	status.Message = fmt.Sprintf("%d/%d nodes reachable", reachable, len(nodes))
+	switch {
+	case reachable == len(nodes):
+		status.Status = health.StatusHealthy
+	case reachable > 0:
+		status.Status = health.StatusWarning
+	default:
+		status.Status = health.StatusCritical
+	}
+	return results, status
+}
+
+// checkClusterVPN reads the mesh state and rates it like 'vpn status'
+func checkClusterVPN(ctx context.Context, stack string, outputs auto.OutputMap, nodes []NodeInfo, sshKeyPath, bastionIP string) (*vpnStatusReport, clusterSubsystemStatus) {
+	mode, _ := detectVPNMode(outputs)
+	live, err := fetchVPNLiveStatus(ctx, mode, nodes, sshKeyPath, bastionIP)
+	if err != nil {
+		live = nil
+	}
+	report := buildVPNStatusReport(stack, outputs, nodes, live)
+
+	status := clusterSubsystemStatus{Name: "VPN"}
+	switch report.Status {
+	case vpnStatusConnected:
+		status.Status = health.StatusHealthy
+		status.Message = fmt.Sprintf("%s mesh connected, %d/%d peers online", report.Mode, report.ConnectedPeers, report.TotalPeers)
+	case vpnStatusDegraded:
+		status.Status = health.StatusWarning
+		status.Message = fmt.Sprintf("%s mesh degraded, %d/%d peers online", report.Mode, report.ConnectedPeers, report.TotalPeers)
+	case vpnStatusDisconnected:
+		status.Status = health.StatusCritical
+		status.Message = fmt.Sprintf("%s mesh disconnected, no peers online", report.Mode)
+	default:
+		status.Status = health.StatusUnknown
+		status.Message = fmt.Sprintf("%s mesh state could not be read", report.Mode)
+		if err != nil {
+			status.Message += ": " + err.Error()
+		}
+	}
+	for _, peer := range report.Peers {
+		if !peer.Online {
+			status.Details = append(status.Details, fmt.Sprintf("%s is offline", peer.Name))
+		}
+	}
+	return &report, status
+}
+
+// checkClusterKubernetes reads node readiness, the API server's readiness
+// checks and the kube-system pods with kubectl on the first master
+func checkClusterKubernetes(nodes []NodeInfo, sshKeyPath, bastionIP string) clusterSubsystemStatus {
+	status := clusterSubsystemStatus{Name: "Kubernetes", Status: health.StatusUnknown}
+
+	master, ok := firstMasterNode(nodes)
+	if !ok {
+		status.Message = "no master node in the stack outputs"
+		return status
+	}
+	output, err := runClusterStatusKubectl(master, sshKeyPath, bastionIP)
+	if err != nil {
+		status.Message = fmt.Sprintf("kubectl on %s failed: %v", master.Name, err)
+		return status
+	}
+	return parseClusterKubernetesStatus(output)
+}
+
+// parseClusterKubernetesStatus rates the output of clusterStatusKubectlScript.
+// Failed API server checks or no ready node are critical; other not ready
+// nodes and unhealthy kube-system pods are warnings.
+func parseClusterKubernetesStatus(output string) clusterSubsystemStatus {
+	status := clusterSubsystemStatus{Name: "Kubernetes", Status: health.StatusHealthy}
+	sections := strings.SplitN(output, "---\n", 3)
+	for len(sections) < 3 {
+		sections = append(sections, "")
+	}
+
+	total, ready := 0, 0
+	for _, line := range strings.Split(strings.TrimSpace(sections[0]), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		total++
+		if fields[1] == "Ready" {
+			ready++
+			continue
+		}
+		status.Details = append(status.Details, fmt.Sprintf("node %s is %s", fields[0], fields[1]))
+	}
+
+	var failedChecks []string
+	for _, line := range strings.Split(sections[1], "\n") {
+		if check, ok := strings.CutPrefix(strings.TrimSpace(line), "[-]"); ok {
+			failedChecks = append(failedChecks, strings.Fields(check)[0])
+		}
+	}
+	for _, check := range failedChecks {
+		status.Details = append(status.Details, fmt.Sprintf("API server check %s failed", check))
+	}
+
+	unhealthyPods := 0
+	for _, line := range strings.Split(strings.TrimSpace(sections[2]), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[2] == "Running" || fields[2] == "Completed" {
+			continue
+		}
+		unhealthyPods++
+		status.Details = append(status.Details, fmt.Sprintf("kube-system pod %s is %s", fields[0], fields[2]))
+	}
+
+	status.Message = fmt.Sprintf("%d/%d nodes ready", ready, total)
+	if len(failedChecks) > 0 {
+		status.Message += fmt.Sprintf(", %d API server check(s) failing", len(failedChecks))
+	}
+	if unhealthyPods > 0 {
+		status.Message += fmt.Sprintf(", %d kube-system pod(s) unhealthy", unhealthyPods)
+	}
+
+	switch {
+	case total == 0 || ready == 0 || len(failedChecks) > 0:
+		status.Status = health.StatusCritical
+	case ready < total || unhealthyPods > 0:
+		status.Status = health.StatusWarning
+	}
+	return status
+}
+
+// clusterVerdict is red when any subsystem is critical, green when all are
+// healthy and yellow otherwise
+func clusterVerdict(subsystems []clusterSubsystemStatus) string {
+	verdict := clusterVerdictGreen
+	for _, subsystem := range subsystems {
+		switch subsystem.Status {
+		case health.StatusCritical:
+			return clusterVerdictRed
+		case health.StatusHealthy:
+		default:
+			verdict = clusterVerdictYellow
+		}
+	}
+	return verdict
+}
+
+// printClusterStatusReport prints the report as a table followed by the
+// problems found
+func printClusterStatusReport(report clusterStatusReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	color.New(color.Bold).Fprintln(w, "SUBSYSTEM\tSTATUS\tDETAILS")
+	fmt.Fprintln(w, "---------\t------\t-------")
+	for _, subsystem := range report.Subsystems {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", subsystem.Name, getHealthColor(subsystem.Status).Sprint(subsystem.Status), subsystem.Message)
+	}
+	w.Flush()
+
+	var problems []string
+	for _, subsystem := range report.Subsystems {
+		problems = append(problems, subsystem.Details...)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		fmt.Println()
+		color.Yellow("Problems:")
+		for _, problem := range problems {
+			fmt.Printf("  • %s\n", problem)
+		}
+	}
+
+	fmt.Println()
+	switch report.Verdict {
+	case clusterVerdictGreen:
+		color.Green("🟢 Cluster %s is healthy", report.Stack)
+	case clusterVerdictYellow:
+		color.Yellow("🟡 Cluster %s is degraded or partly unchecked", report.Stack)
+	default:
+		color.Red("🔴 Cluster %s is unhealthy", report.Stack)
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/health"
+)
+
+const clusterStatusKubectlOutput = `master-1   Ready      control-plane,etcd,master   10d   v1.29.4+rke2r1
+worker-1   Ready      <none>                      10d   v1.29.4+rke2r1
+---
+[+]ping ok
+[+]etcd ok
+[+]poststarthook/start-apiserver-admission-initializer ok
+readyz check passed
+---
+coredns-6799fbcd5-abcde        1/1   Running     0     10d
+helm-install-rke2-canal-xyz    0/1   Completed   0     10d
+kube-proxy-worker-1            1/1   Running     0     10d
+`
+
+// stubClusterStatusChecks replaces the SSH-backed checks for a test
+func stubClusterStatusChecks(t *testing.T, reachable func(NodeInfo) error, live *vpnLiveStatus, liveErr error, kubectl string, kubectlErr error) {
+	t.Helper()
+	origReachable, origVPN, origKubectl := checkNodeReachable, fetchVPNLiveStatus, runClusterStatusKubectl
+	t.Cleanup(func() {
+		checkNodeReachable, fetchVPNLiveStatus, runClusterStatusKubectl = origReachable, origVPN, origKubectl
+	})
+
+	checkNodeReachable = func(node NodeInfo, _, _ string) error { return reachable(node) }
+	fetchVPNLiveStatus = func(context.Context, VPNMode, []NodeInfo, string, string) (*vpnLiveStatus, error) {
+		return live, liveErr
+	}
+	runClusterStatusKubectl = func(NodeInfo, string, string) (string, error) { return kubectl, kubectlErr }
+}
+
+func clusterStatusOutputs() auto.OutputMap {
+	return auto.OutputMap{
+		"nodes": auto.OutputValue{Value: importedNodesOutput([]ImportedNode{
+			{Name: "master-1", Provider: "digitalocean", PublicIP: "203.0.113.10", Roles: []string{"master"}},
+			{Name: "worker-1", Provider: "digitalocean", PublicIP: "203.0.113.20", Roles: []string{"worker"}},
+		})},
+	}
+}
+
+func subsystemByName(t *testing.T, report clusterStatusReport, name string) clusterSubsystemStatus {
+	t.Helper()
+	for _, subsystem := range report.Subsystems {
+		if subsystem.Name == name {
+			return subsystem
+		}
+	}
+	require.Failf(t, "subsystem not reported", "%s", name)
+	return clusterSubsystemStatus{}
+}
+
+func TestClusterStatusCmd_Flags(t *testing.T) {
+	cmd, _, err := clusterCmd.Find([]string{"status"})
+	require.NoError(t, err)
+	assert.Same(t, clusterStatusCmd, cmd)
+
+	flag := cmd.Flags().Lookup("output")
+	require.NotNil(t, flag)
+	assert.Equal(t, "table", flag.DefValue)
+	assert.Equal(t, "o", flag.Shorthand)
+	assert.Contains(t, cmd.Example, "--output json")
+}
+
+func TestClusterVerdict(t *testing.T) {
+	status := func(statuses ...health.CheckStatus) []clusterSubsystemStatus {
+		subsystems := make([]clusterSubsystemStatus, len(statuses))
+		for i, s := range statuses {
+			subsystems[i] = clusterSubsystemStatus{Status: s}
+		}
+		return subsystems
+	}
+
+	assert.Equal(t, clusterVerdictGreen, clusterVerdict(status(health.StatusHealthy, health.StatusHealthy)))
+	assert.Equal(t, clusterVerdictYellow, clusterVerdict(status(health.StatusHealthy, health.StatusWarning)))
+	assert.Equal(t, clusterVerdictYellow, clusterVerdict(status(health.StatusUnknown, health.StatusHealthy)))
+	assert.Equal(t, clusterVerdictRed, clusterVerdict(status(health.StatusUnknown, health.StatusCritical, health.StatusWarning)))
+}
+
+func TestParseClusterKubernetesStatus(t *testing.T) {
+	status := parseClusterKubernetesStatus(clusterStatusKubectlOutput)
+	assert.Equal(t, health.StatusHealthy, status.Status)
+	assert.Equal(t, "2/2 nodes ready", status.Message)
+	assert.Empty(t, status.Details)
+
+	status = parseClusterKubernetesStatus(`master-1   Ready      control-plane   10d   v1.29.4
+worker-1   NotReady   <none>          10d   v1.29.4
+---
+[+]ping ok
+---
+coredns-6799fbcd5-abcde   0/1   CrashLoopBackOff   12   10d
+`)
+	assert.Equal(t, health.StatusWarning, status.Status)
+	assert.Equal(t, "1/2 nodes ready, 1 kube-system pod(s) unhealthy", status.Message)
+	assert.Equal(t, []string{
+		"node worker-1 is NotReady",
+		"kube-system pod coredns-6799fbcd5-abcde is CrashLoopBackOff",
+	}, status.Details)
+
+	status = parseClusterKubernetesStatus(`master-1   Ready   control-plane   10d   v1.29.4
+---
+[+]ping ok
+[-]etcd failed: reason withheld
+readyz check failed
+---
+`)
+	assert.Equal(t, health.StatusCritical, status.Status)
+	assert.Equal(t, []string{"API server check etcd failed"}, status.Details)
+}
+
+func TestBuildClusterStatusReport_Healthy(t *testing.T) {
+	stubClusterStatusChecks(t,
+		func(NodeInfo) error { return nil },
+		&vpnLiveStatus{wireGuardPeers: map[string]bool{"master-1": true, "worker-1": true}}, nil,
+		clusterStatusKubectlOutput, nil)
+
+	report := buildClusterStatusReport(context.Background(), "prod", clusterStatusOutputs())
+	assert.Equal(t, clusterVerdictGreen, report.Verdict)
+	assert.Len(t, report.Nodes, 2)
+	require.NotNil(t, report.VPN)
+	assert.Equal(t, vpnStatusConnected, report.VPN.Status)
+	for _, subsystem := range report.Subsystems {
+		assert.Equal(t, health.StatusHealthy, subsystem.Status, subsystem.Name)
+	}
+}
+
+func TestBuildClusterStatusReport_DegradesGracefully(t *testing.T) {
+	stubClusterStatusChecks(t,
+		func(node NodeInfo) error {
+			if node.Name == "worker-1" {
+				return errors.New("connection timed out")
+			}
+			return nil
+		},
+		nil, errors.New("no node answered"),
+		"", errors.New("connection refused"))
+
+	report := buildClusterStatusReport(context.Background(), "prod", clusterStatusOutputs())
+	assert.Equal(t, clusterVerdictYellow, report.Verdict)
+
+	nodes := subsystemByName(t, report, "Nodes")
+	assert.Equal(t, health.StatusWarning, nodes.Status)
+	assert.Equal(t, "1/2 nodes reachable", nodes.Message)
+	assert.Equal(t, []string{"worker-1 is unreachable: connection timed out"}, nodes.Details)
+
+	vpnStatus := subsystemByName(t, report, "VPN")
+	assert.Equal(t, health.StatusUnknown, vpnStatus.Status)
+	assert.Contains(t, vpnStatus.Message, "no node answered")
+
+	k8s := subsystemByName(t, report, "Kubernetes")
+	assert.Equal(t, health.StatusUnknown, k8s.Status)
+	assert.Contains(t, k8s.Message, "kubectl on master-1 failed")
+}
+
+func TestBuildClusterStatusReport_Red(t *testing.T) {
+	stubClusterStatusChecks(t,
+		func(NodeInfo) error { return errors.New("no route to host") },
+		&vpnLiveStatus{wireGuardPeers: map[string]bool{"master-1": false, "worker-1": false}}, nil,
+		clusterStatusKubectlOutput, nil)
+
+	report := buildClusterStatusReport(context.Background(), "prod", clusterStatusOutputs())
+	assert.Equal(t, clusterVerdictRed, report.Verdict)
+	assert.Equal(t, health.StatusCritical, subsystemByName(t, report, "Nodes").Status)
+	assert.Equal(t, health.StatusCritical, subsystemByName(t, report, "VPN").Status)
+}
+
+func TestBuildClusterStatusReport_NoNodes(t *testing.T) {
+	report := buildClusterStatusReport(context.Background(), "prod", auto.OutputMap{"nodes": auto.OutputValue{Value: map[string]interface{}{}}})
+	assert.Equal(t, clusterVerdictYellow, report.Verdict)
+	require.Len(t, report.Subsystems, 3)
+	for _, subsystem := range report.Subsystems {
+		assert.Equal(t, health.StatusUnknown, subsystem.Status)
+		assert.Equal(t, "no nodes in the stack outputs", subsystem.Message)
+	}
+}
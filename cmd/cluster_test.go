@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterImportCmd_Structure(t *testing.T) {
+	assert.Equal(t, "import [stack-name]", clusterImportCmd.Use)
+	assert.NotNil(t, clusterImportCmd.RunE)
+
+	flag := clusterImportCmd.Flags().Lookup("nodes")
+	require.NotNil(t, flag)
+	assert.Equal(t, "", flag.DefValue)
+	assert.NotNil(t, clusterImportCmd.Flags().Lookup("ssh-key"))
+	assert.NotNil(t, clusterImportCmd.Flags().Lookup("skip-connectivity-check"))
+}
+
+func TestLoadImportedNodes(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "nodes.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+- name: master-1
+  provider: digitalocean
+  publicIP: 203.0.113.10
+  privateIP: 10.10.0.10
+  wireGuardIP: 10.8.0.10
+  roles: [master]
+- name: worker-1
+  provider: aws
+  publicIP: 203.0.113.20
+  roles: [worker]
+  sshUser: admin
+`), 0600))
+
+	nodes, err := loadImportedNodes(yamlPath)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "10.8.0.10", nodes[0].WireGuardIP)
+	assert.Equal(t, "root", nodes[0].SSHUser, "default user comes from the provider")
+	assert.Equal(t, "admin", nodes[1].SSHUser)
+
+	jsonPath := filepath.Join(dir, "nodes.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(
+		`[{"name":"n1","provider":"linode","publicIP":"198.51.100.1","roles":["master","etcd"]}]`), 0600))
+
+	nodes, err = loadImportedNodes(jsonPath)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, []string{"master", "etcd"}, nodes[0].Roles)
+
+	_, err = loadImportedNodes(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestValidateImportedNodes(t *testing.T) {
+	valid := ImportedNode{Name: "n1", Provider: "aws", PublicIP: "1.2.3.4", Roles: []string{"worker"}}
+
+	tests := []struct {
+		name    string
+		nodes   []ImportedNode
+		wantErr string
+	}{
+		{"valid", []ImportedNode{valid}, ""},
+		{"empty", nil, "does not list any nodes"},
+		{"missing name", []ImportedNode{{Provider: "aws", PublicIP: "1.2.3.4", Roles: []string{"worker"}}}, "name is required"},
+		{"duplicate name", []ImportedNode{valid, valid}, "duplicate name"},
+		{"missing provider", []ImportedNode{{Name: "n1", PublicIP: "1.2.3.4", Roles: []string{"worker"}}}, "provider is required"},
+		{"missing public IP", []ImportedNode{{Name: "n1", Provider: "aws", Roles: []string{"worker"}}}, "publicIP is required"},
+		{"missing roles", []ImportedNode{{Name: "n1", Provider: "aws", PublicIP: "1.2.3.4"}}, "role is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImportedNodes(tt.nodes)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestImportedNodesOutput_ParsesAsNodeInfo(t *testing.T) {
+	nodes := []ImportedNode{
+		{Name: "master-1", Provider: "digitalocean", PublicIP: "203.0.113.10", PrivateIP: "10.10.0.10",
+			WireGuardIP: "10.8.0.10", Roles: []string{"master"}, SSHUser: "root"},
+		{Name: "worker-1", Provider: "aws", PublicIP: "203.0.113.20", Roles: []string{"worker"}, SSHUser: "ubuntu"},
+	}
+
+	outputs := auto.OutputMap{
+		"nodes": auto.OutputValue{Value: importedNodesOutput(nodes)},
+	}
+
+	parsed, err := ParseNodeOutputs(outputs)
+	require.NoError(t, err)
+	require.Len(t, parsed, 2)
+
+	byName := map[string]NodeInfo{}
+	for _, n := range parsed {
+		byName[n.Name] = n
+	}
+
+	master := byName["master-1"]
+	assert.Equal(t, "203.0.113.10", master.PublicIP)
+	assert.Equal(t, "10.10.0.10", master.PrivateIP)
+	assert.Equal(t, "10.8.0.10", master.WireGuardIP)
+	assert.Equal(t, []string{"master"}, master.Roles)
+	assert.Equal(t, "imported", master.Status)
+
+	worker := byName["worker-1"]
+	assert.Equal(t, "ubuntu", worker.SSHUser)
+	assert.Equal(t, "ubuntu", sshUserForNode(worker))
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <input-file>",
+	Short: "Convert a cluster config between Lisp, YAML and JSON",
+	Long: `Translate a cluster configuration file into another format.
+
+Formats are picked from the file extensions (.lisp, .yaml/.yml, .json) and
+can be forced with --from and --to. ${VAR} references are carried over
+unresolved and defaults are not filled in; comments are not preserved. Lisp
+output replaces credentials with environment variable placeholders.`,
+	Example: `  # Convert a Lisp config to YAML
+  sloth-kubernetes config convert cluster.lisp -o cluster.yaml
+
+  # Print a YAML config as JSON
+  sloth-kubernetes config convert cluster.yaml --to json
+
+  # Convert back to Lisp
+  sloth-kubernetes config convert cluster.json -o cluster.lisp`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConvert,
+}
+
+var (
+	convertOutput string
+	convertFrom   string
+	convertTo     string
+)
+
+func init() {
+	configCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVarP(&convertOutput, "output", "o", "", "Output file (default: stdout)")
+	convertCmd.Flags().StringVar(&convertFrom, "from", "", "Input format: lisp|yaml|json (default: from extension)")
+	convertCmd.Flags().StringVar(&convertTo, "to", "", "Output format: lisp|yaml|json (default: from --output extension)")
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	input := args[0]
+
+	from, err := config.ParseConfigFormat(convertFrom)
+	if err != nil {
+		return err
+	}
+	to, err := resolveConvertTarget(convertTo, convertOutput)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfigFile(input, from)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", input, err)
+	}
+
+	data, err := config.MarshalConfig(cfg, to)
+	if err != nil {
+		return err
+	}
+
+	if convertOutput == "" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(convertOutput, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", convertOutput, err)
+	}
+	printSuccess(fmt.Sprintf("Converted %s to %s (%s)", input, convertOutput, configFormatName(to)))
+	return nil
+}
+
+// resolveConvertTarget picks the output format from --to, or from the output
+// file extension when --to is not set
+func resolveConvertTarget(to, output string) (config.ConfigFormat, error) {
+	format, err := config.ParseConfigFormat(to)
+	if err != nil {
+		return "", err
+	}
+	if format != "" {
+		return format, nil
+	}
+	if output == "" {
+		return "", fmt.Errorf("--to is required when writing to stdout")
+	}
+	return config.DetectConfigFormat(output), nil
+}
+
+func configFormatName(format config.ConfigFormat) string {
+	switch format {
+	case config.FormatYAML:
+		return "YAML"
+	case config.FormatJSON:
+		return "JSON"
+	}
+	return "Lisp"
+}
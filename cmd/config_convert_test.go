@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+func TestConvertCmd_Structure(t *testing.T) {
+	assert.Equal(t, "convert <input-file>", convertCmd.Use)
+	assert.NotNil(t, convertCmd.RunE)
+	assert.NotNil(t, convertCmd.Flags().Lookup("output"))
+	assert.NotNil(t, convertCmd.Flags().Lookup("from"))
+	assert.NotNil(t, convertCmd.Flags().Lookup("to"))
+}
+
+func TestResolveConvertTarget(t *testing.T) {
+	format, err := resolveConvertTarget("json", "")
+	require.NoError(t, err)
+	assert.Equal(t, config.FormatJSON, format)
+
+	format, err = resolveConvertTarget("", "out.yml")
+	require.NoError(t, err)
+	assert.Equal(t, config.FormatYAML, format)
+
+	_, err = resolveConvertTarget("", "")
+	assert.ErrorContains(t, err, "--to is required")
+
+	_, err = resolveConvertTarget("toml", "out.toml")
+	assert.Error(t, err)
+}
+
+func TestRunConvert_LispToYAML(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "cluster.lisp")
+	require.NoError(t, os.WriteFile(input, []byte(generateMinimalLispConfig()), 0600))
+
+	convertTo, convertOutput, convertFrom = "yaml", "", ""
+	defer func() { convertTo = "" }()
+
+	var out bytes.Buffer
+	convertCmd.SetOut(&out)
+	defer convertCmd.SetOut(nil)
+
+	require.NoError(t, runConvert(convertCmd, []string{input}))
+	assert.Contains(t, out.String(), "name: my-cluster")
+	assert.Contains(t, out.String(), "nodePools:")
+}
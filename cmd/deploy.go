@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/fatih/color"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
@@ -21,6 +23,7 @@ import (
 	"github.com/chalkan3/sloth-kubernetes/internal/validation"
 	"github.com/chalkan3/sloth-kubernetes/pkg/addons"
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
 	"github.com/chalkan3/sloth-kubernetes/pkg/vpc"
 )
@@ -31,6 +34,8 @@ var (
 	wireguardEndpoint string
 	wireguardPubKey   string
 	dryRun            bool
+	strictValidation  bool
+	deployForce       bool
 )
 
 var deployCmd = &cobra.Command{
@@ -68,6 +73,9 @@ func init() {
 	deployCmd.Flags().StringVar(&wireguardEndpoint, "wireguard-endpoint", "", "WireGuard server endpoint (e.g., 1.2.3.4:51820)")
 	deployCmd.Flags().StringVar(&wireguardPubKey, "wireguard-pubkey", "", "WireGuard server public key")
 	deployCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview changes without applying")
+	addForceUnlockFlag(deployCmd)
+	deployCmd.Flags().BoolVar(&strictValidation, "strict", false, "Treat ambiguous configuration (such as both VPNs enabled) as an error")
+	deployCmd.Flags().BoolVar(&deployForce, "force", false, "Deploy even when the estimated monthly cost is over the cost-control budget")
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
@@ -85,6 +93,14 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	stackName = targetStack
 	printInfo(fmt.Sprintf("📦 Using stack: %s", stackName))
 
+	if !dryRun {
+		unlock, err := lockStack(ctx, stackName, "deploy")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
 	// Print header
 	printHeader("🚀 Kubernetes Multi-Cloud Deployment")
 
@@ -116,6 +132,15 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 	s.Stop()
+	vpnWarning, err := validation.ValidateVPNSelection(cfg, strictValidation)
+	if err != nil {
+		color.Red("❌ Configuration validation failed")
+		fmt.Println()
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+	if vpnWarning != "" {
+		color.Yellow("⚠️  Warning: %s", vpnWarning)
+	}
 	color.Green("✅ Configuration structure is valid")
 
 	// Step 2: Validate API tokens presence
@@ -198,6 +223,13 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 	s.Stop()
 	color.Green("✅ Resource sizes validated")
 
+	// Step 8: Check the estimated cost against the budget
+	if err := checkDeployCost(cfg, deployForce); err != nil {
+		color.Red("❌ Cost check failed")
+		fmt.Println()
+		return err
+	}
+
 	fmt.Println()
 	color.Green("✅ All pre-deployment validations passed!")
 	fmt.Println()
@@ -213,6 +245,91 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Setup Pulumi Automation API stack
+	fmt.Println()
+	printInfo("🔧 Setting up Pulumi stack...")
+
+	stack, err := prepareDeployStack(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	printSuccess("Pulumi stack configured")
+
+	// Refresh stack
+	fmt.Println()
+	printInfo("🔄 Refreshing stack state...")
+	_, err = stack.Refresh(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to refresh stack: %w", err)
+	}
+
+	// Fetch previous deployment metadata for scale tracking
+	if loadPreviousDeploymentMeta(ctx, stack) {
+		printInfo("📊 Found previous deployment metadata (tracking scale operations)")
+	}
+
+	if dryRun {
+		// Preview mode
+		fmt.Println()
+		printInfo("📋 Previewing changes (dry-run mode)...")
+
+		prev, err := stack.Preview(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to preview: %w", err)
+		}
+
+		printPreviewSummary(prev)
+		return nil
+	}
+
+	// Deploy!
+	fmt.Println()
+	printHeader("🚀 Deploying cluster...")
+	fmt.Println()
+
+	// Setup progress streams
+	stdoutStreamer := optup.ProgressStreams(os.Stdout)
+
+	// Time each phase from the engine events
+	tracker := newPhaseTracker(time.Now())
+	engineEvents := make(chan events.EngineEvent)
+	eventsDone := make(chan struct{})
+	go func() {
+		for event := range engineEvents {
+			tracker.observe(event, time.Now())
+		}
+		close(eventsDone)
+	}()
+
+	res, err := stack.Up(ctx, stdoutStreamer, optup.EventStreams(engineEvents))
+	<-eventsDone
+
+	summary := tracker.summary(time.Now(), err)
+	fmt.Println()
+	printDeployTimingReport(summary)
+	if saveErr := operations.SaveDeploySummary(stackName, summary); saveErr != nil {
+		printWarning(fmt.Sprintf("Failed to store deploy summary: %v", saveErr))
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to deploy: %w", err)
+	}
+
+	// Print success
+	fmt.Println()
+	printSuccess("✅ Cluster deployed successfully!")
+	fmt.Println()
+
+	// Print outputs
+	printClusterOutputs(res.Outputs)
+
+	return nil
+}
+
+// prepareDeployStack builds the cluster Pulumi program and upserts the stack that
+// runs it. Both deploy and plan use it so that previews match real deployments.
+func prepareDeployStack(ctx context.Context, cfg *config.ClusterConfig) (auto.Stack, error) {
 	// Create Pulumi program
 	program := func(ctx *pulumi.Context) error {
 		// Phase 1: Create VPCs if configured
@@ -230,7 +347,8 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		// Phase 2: Create cluster orchestrator FIRST (to generate SSH keys)
 		ctx.Log.Info("📊 Phase 2: WireGuard VPN Server Creation", nil)
 		ctx.Log.Info("📊 Phase 3: Kubernetes Cluster Creation", nil)
-		clusterOrch, err := orchestrator.NewSimpleRealOrchestratorComponent(ctx, "kubernetes-cluster", cfg, lispManifestContent, previousDeploymentMeta)
+		clusterOrch, err := orchestrator.NewSimpleRealOrchestratorComponentWithOptions(ctx, "kubernetes-cluster", cfg, lispManifestContent, previousDeploymentMeta,
+			orchestrator.Options{WireGuardAllocations: previousWireGuardAllocations})
 		if err != nil {
 			return fmt.Errorf("failed to create orchestrator: %w", err)
 		}
@@ -253,10 +371,32 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Setup Pulumi Automation API stack
-	fmt.Println()
-	printInfo("🔧 Setting up Pulumi stack...")
+	ws, err := newProgramWorkspace(ctx, program)
+	if err != nil {
+		return auto.Stack{}, err
+	}
 
+	// For S3 backend, we need to use fully qualified stack name: organization/project/stack
+	// We use "organization" as the organization name (self-managed backend doesn't need real org)
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stackName)
+
+	stack, err := auto.UpsertStack(ctx, fullyQualifiedStackName, ws)
+	if err != nil {
+		return auto.Stack{}, fmt.Errorf("failed to create or select stack: %w", err)
+	}
+
+	// Set configuration
+	if err := setStackConfig(ctx, stack, cfg); err != nil {
+		return auto.Stack{}, fmt.Errorf("failed to set stack config: %w", err)
+	}
+
+	return stack, nil
+}
+
+// newProgramWorkspace creates a local workspace that runs the given inline Pulumi
+// program against the configured backend, forwarding the S3/Pulumi credentials
+// to the Pulumi subprocess.
+func newProgramWorkspace(ctx context.Context, program pulumi.RunFunc) (auto.Workspace, error) {
 	// Create workspace with backend URL from environment
 	// Note: LoadSavedConfig() already set all environment variables at line 74
 	// For S3 backend, we need to set the project name
@@ -306,79 +446,104 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	ws, err := auto.NewLocalWorkspace(ctx, workspaceOpts...)
 	if err != nil {
-		return fmt.Errorf("failed to create workspace: %w", err)
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
 	}
+	return ws, nil
+}
 
-	// For S3 backend, we need to use fully qualified stack name: organization/project/stack
-	// We use "organization" as the organization name (self-managed backend doesn't need real org)
-	fullyQualifiedStackName := fmt.Sprintf("organization/%s/%s", projectName, stackName)
-
-	stack, err := auto.UpsertStack(ctx, fullyQualifiedStackName, ws)
+// loadPreviousDeploymentMeta reads the deploymentMeta output of the last
+// deployment so the program can track scale operations, and its WireGuard
+// allocations so nodes keep their addresses. It reports whether previous
+// metadata was found.
+func loadPreviousDeploymentMeta(ctx context.Context, stack auto.Stack) bool {
+	outputs, err := stack.Outputs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create or select stack: %w", err)
-	}
-
-	// Set configuration
-	if err := setStackConfig(ctx, stack, cfg); err != nil {
-		return fmt.Errorf("failed to set stack config: %w", err)
+		return false
 	}
-
-	printSuccess("Pulumi stack configured")
-
-	// Refresh stack
-	fmt.Println()
-	printInfo("🔄 Refreshing stack state...")
-	_, err = stack.Refresh(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to refresh stack: %w", err)
+	previousWireGuardAllocations = wireGuardAllocationsFromOutputs(outputs)
+	if metaOutput, ok := outputs["deploymentMeta"]; ok && metaOutput.Value != nil {
+		if metaStr, ok := metaOutput.Value.(string); ok {
+			previousDeploymentMeta = metaStr
+			return true
+		}
 	}
+	return false
+}
 
-	// Fetch previous deployment metadata for scale tracking
-	outputs, err := stack.Outputs(ctx)
-	if err == nil {
-		if metaOutput, ok := outputs["deploymentMeta"]; ok && metaOutput.Value != nil {
-			if metaStr, ok := metaOutput.Value.(string); ok {
-				previousDeploymentMeta = metaStr
-				printInfo("📊 Found previous deployment metadata (tracking scale operations)")
+// wireGuardAllocationsFromOutputs returns the wireguard_allocations output,
+// keyed by node name. A stack deployed before the output existed falls back
+// to the vpn_ip of each node in the nodes output.
+func wireGuardAllocationsFromOutputs(outputs auto.OutputMap) map[string]string {
+	allocations := make(map[string]string)
+	if output, ok := outputs["wireguard_allocations"]; ok {
+		if ips, ok := output.Value.(map[string]interface{}); ok {
+			for name, ip := range ips {
+				if ip, ok := ip.(string); ok && ip != "" {
+					allocations[name] = ip
+				}
 			}
+			return allocations
 		}
 	}
 
-	if dryRun {
-		// Preview mode
-		fmt.Println()
-		printInfo("📋 Previewing changes (dry-run mode)...")
-
-		prev, err := stack.Preview(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to preview: %w", err)
+	if output, ok := outputs["nodes"]; ok {
+		if nodes, ok := output.Value.(map[string]interface{}); ok {
+			for _, node := range nodes {
+				fields, ok := node.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := fields["name"].(string)
+				ip, _ := fields["vpn_ip"].(string)
+				if name != "" && ip != "" {
+					allocations[name] = ip
+				}
+			}
 		}
+	}
+	return allocations
+}
 
-		printPreviewSummary(prev)
+// checkDeployCost prints the estimated monthly cost when cost-control
+// estimation is enabled and refuses a deploy over the monthly budget unless
+// force is set
+func checkDeployCost(cfg *config.ClusterConfig, force bool) error {
+	costControl := cfg.CostControl
+	if costControl == nil || !costControl.Estimate {
 		return nil
 	}
 
-	// Deploy!
-	fmt.Println()
-	printHeader("🚀 Deploying cluster...")
-	fmt.Println()
-
-	// Setup progress streams
-	stdoutStreamer := optup.ProgressStreams(os.Stdout)
-
-	res, err := stack.Up(ctx, stdoutStreamer)
+	estimate, err := orchestrator.EstimateConfigCost(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to deploy: %w", err)
+		return fmt.Errorf("cost estimation failed: %w", err)
 	}
 
-	// Print success
-	fmt.Println()
-	printSuccess("✅ Cluster deployed successfully!")
-	fmt.Println()
-
-	// Print outputs
-	printClusterOutputs(res.Outputs)
+	if estimate.Partial() {
+		printWarning(fmt.Sprintf("No price known for %s", strings.Join(estimate.Unpriced, ", ")))
+		printInfo(fmt.Sprintf("Estimated monthly cost: at least $%.2f", estimate.MonthlyTotal))
+	} else {
+		printInfo(fmt.Sprintf("Estimated monthly cost: $%.2f", estimate.MonthlyTotal))
+	}
+	providerNames := make([]string, 0, len(estimate.PerProvider))
+	for name := range estimate.PerProvider {
+		providerNames = append(providerNames, name)
+	}
+	sort.Strings(providerNames)
+	for _, name := range providerNames {
+		fmt.Printf("  %s: $%.2f\n", name, estimate.PerProvider[name])
+	}
 
+	if err := estimate.CheckBudget(costControl); err != nil {
+		if !force {
+			return fmt.Errorf("%w (use --force to deploy anyway)", err)
+		}
+		printWarning(fmt.Sprintf("%v; deploying anyway because --force is set", err))
+		return nil
+	}
+	if estimate.NearBudget(costControl) {
+		printWarning(fmt.Sprintf("Estimated monthly cost $%.2f is over %d%% of the $%.2f budget",
+			estimate.MonthlyTotal, costControl.AlertThreshold, costControl.MonthlyBudget))
+	}
 	return nil
 }
 
@@ -388,6 +553,9 @@ var lispManifestContent string
 // previousDeploymentMeta stores the previous deployment metadata for scale tracking
 var previousDeploymentMeta string
 
+// previousWireGuardAllocations stores the WireGuard IPs of the previous deployment
+var previousWireGuardAllocations map[string]string
+
 func loadConfiguration() (*config.ClusterConfig, error) {
 	var cfg *config.ClusterConfig
 	var err error
@@ -403,11 +571,17 @@ func loadConfiguration() (*config.ClusterConfig, error) {
 		}
 		lispManifestContent = string(rawContent)
 
-		cfg, err = config.LoadFromLisp(cfgFile)
+		configFormat := config.DetectConfigFormat(cfgFile)
+		cfg, err = config.ReadConfigFile(cfgFile, configFormat)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load config file: %w", err)
 		}
 
+		// Pulumi state keeps a Lisp manifest, so regenerate one for YAML/JSON input
+		if configFormat != config.FormatLisp {
+			lispManifestContent = config.GenerateLisp(cfg)
+		}
+
 		// DEBUG: Log pools immediately after loading
 		fmt.Printf("🔍 DEBUG [cmd/deploy.go]: Loaded %d node pools from Lisp\n", len(cfg.NodePools))
 		for poolName, pool := range cfg.NodePools {
@@ -794,6 +968,21 @@ func printClusterOutputs(outputs auto.OutputMap) {
 		fmt.Printf("  • API Endpoint: %v\n", endpoint.Value)
 	}
 
+	// Tracing Information
+	if tracing, ok := outputs["tracing"]; ok {
+		if info, ok := tracing.Value.(map[string]interface{}); ok {
+			fmt.Println()
+			color.Cyan("🔭 Tracing:")
+			if inCluster, _ := info["in_cluster"].(bool); inCluster {
+				fmt.Printf("  • Jaeger: installed in namespace observability\n")
+			} else {
+				fmt.Printf("  • Jaeger: external collector\n")
+			}
+			fmt.Printf("  • Endpoint: %v\n", info["endpoint"])
+			fmt.Printf("  • Sampling: %v\n", info["sampling"])
+		}
+	}
+
 	fmt.Println()
 	color.Green("🎯 Next Steps:")
 	fmt.Println("  1. Get kubeconfig: kubernetes-create kubeconfig -o ~/.kube/config")
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+// deployPhases lists the phases reported in the deploy summary, in order
+var deployPhases = []string{"providers", "ssh", "network", "firewall", "vpn", "nodes", "k8s", "addons"}
+
+// phaseSpan is the time between the first step and the last finished step of a phase
+type phaseSpan struct {
+	start   time.Time
+	end     time.Time
+	changed bool
+	failed  bool
+}
+
+// phaseTracker times deploy phases from the Pulumi engine event stream
+type phaseTracker struct {
+	mu     sync.Mutex
+	start  time.Time
+	phases map[string]*phaseSpan
+}
+
+func newPhaseTracker(start time.Time) *phaseTracker {
+	return &phaseTracker{start: start, phases: make(map[string]*phaseSpan)}
+}
+
+// observe records a resource step event received at the given time
+func (t *phaseTracker) observe(event events.EngineEvent, at time.Time) {
+	var meta apitype.StepEventMetadata
+	var failed bool
+	switch {
+	case event.ResourcePreEvent != nil:
+		meta = event.ResourcePreEvent.Metadata
+	case event.ResOutputsEvent != nil:
+		meta = event.ResOutputsEvent.Metadata
+	case event.ResOpFailedEvent != nil:
+		meta = event.ResOpFailedEvent.Metadata
+		failed = true
+	default:
+		return
+	}
+
+	phase := deployPhaseForURN(meta.URN)
+	if phase == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span, ok := t.phases[phase]
+	if !ok {
+		span = &phaseSpan{start: at}
+		t.phases[phase] = span
+	}
+	if at.After(span.end) {
+		span.end = at
+	}
+	if meta.Op != apitype.OpSame && meta.Op != apitype.OpRead {
+		span.changed = true
+	}
+	if failed {
+		span.failed = true
+	}
+}
+
+// summary builds the deploy summary. Phases that never finished a step are
+// timed up to end.
+func (t *phaseTracker) summary(end time.Time, deployErr error) *operations.DeploySummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := &operations.DeploySummary{
+		Timestamp: t.start.UTC(),
+		Status:    "success",
+		Duration:  end.Sub(t.start).Round(time.Second).String(),
+	}
+	if deployErr != nil {
+		summary.Status = "failed"
+	}
+
+	for _, phase := range deployPhases {
+		timing := operations.PhaseTiming{Phase: phase, Status: "skipped", Duration: "-"}
+
+		if span, ok := t.phases[phase]; ok {
+			spanEnd := span.end
+			if !spanEnd.After(span.start) {
+				spanEnd = end
+			}
+			elapsed := spanEnd.Sub(span.start).Round(time.Second)
+
+			timing.Duration = elapsed.String()
+			timing.Seconds = int64(elapsed.Seconds())
+			switch {
+			case span.failed:
+				timing.Status = "failed"
+			case span.changed:
+				timing.Status = "success"
+			default:
+				timing.Status = "unchanged"
+			}
+		}
+
+		summary.Phases = append(summary.Phases, timing)
+	}
+
+	return summary
+}
+
+// deployPhaseForURN maps a resource to its deploy phase. The type chain in the
+// URN is walked from the resource up through its parents, so raw resources
+// such as remote commands take the phase of the component that owns them.
+func deployPhaseForURN(urn string) string {
+	// urn:pulumi:<stack>::<project>::<parent-type>$<type>::<name>
+	parts := strings.Split(urn, "::")
+	if len(parts) < 4 {
+		return ""
+	}
+	types := strings.Split(parts[2], "$")
+
+	for i := len(types) - 1; i >= 0; i-- {
+		if phase := deployPhaseForType(types[i]); phase != "" {
+			return phase
+		}
+	}
+	return ""
+}
+
+// deployPhaseForType maps a single resource or component type to a phase
+func deployPhaseForType(resourceType string) string {
+	lower := strings.ToLower(resourceType)
+
+	switch {
+	case strings.HasPrefix(lower, "pulumi:providers:"), strings.Contains(lower, ":provider:"):
+		return "providers"
+	case strings.Contains(lower, ":security:sshkey"):
+		return "ssh"
+	case strings.Contains(lower, "firewall"), strings.Contains(lower, "securitygroup"):
+		return "firewall"
+	case strings.Contains(lower, "wireguard"), strings.Contains(lower, "tailscale"),
+		strings.Contains(lower, ":network:vpn"):
+		return "vpn"
+	case strings.Contains(lower, ":security:bastion"), strings.Contains(lower, ":network:"),
+		strings.Contains(lower, "vpc"), strings.Contains(lower, "subnet"),
+		strings.Contains(lower, "gateway"), strings.Contains(lower, "routetable"):
+		return "network"
+	case strings.Contains(lower, ":compute:"), strings.Contains(lower, ":provisioning:"):
+		return "nodes"
+	case strings.Contains(lower, ":cluster:"):
+		return "k8s"
+	case strings.Contains(lower, ":dns:"), strings.Contains(lower, ":salt:"),
+		strings.Contains(lower, ":addons:"), strings.Contains(lower, ":ingress:"),
+		strings.Contains(lower, ":health:"), strings.HasPrefix(lower, "sloth:kubernetes:"):
+		return "addons"
+	}
+	return ""
+}
+
+// printDeployTimingReport prints how long each phase took
+func printDeployTimingReport(summary *operations.DeploySummary) {
+	color.Cyan("⏱️  Deploy timing:")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PHASE\tDURATION\tSTATUS")
+	fmt.Fprintln(w, "-----\t--------\t------")
+
+	for _, phase := range summary.Phases {
+		statusIcon := "✅"
+		switch phase.Status {
+		case "failed":
+			statusIcon = "❌"
+		case "unchanged", "skipped":
+			statusIcon = "➖"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s %s\n", phase.Phase, phase.Duration, statusIcon, phase.Status)
+	}
+	fmt.Fprintln(w, "-----\t--------\t------")
+	fmt.Fprintf(w, "total\t%s\t%s\n", summary.Duration, summary.Status)
+	w.Flush()
+}
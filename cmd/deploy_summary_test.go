@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testURNPrefix = "urn:pulumi:prod::sloth-kubernetes::pulumi:pulumi:Stack$kubernetes-create:orchestrator:SimpleReal$"
+
+func TestDeployPhaseForURN(t *testing.T) {
+	tests := []struct {
+		urn  string
+		want string
+	}{
+		{"urn:pulumi:prod::sloth-kubernetes::pulumi:providers:digitalocean::default_4_49_0", "providers"},
+		{testURNPrefix + "kubernetes-create:security:SSHKey$tls:index/privateKey:PrivateKey::ssh-key", "ssh"},
+		{testURNPrefix + "kubernetes-create:security:Bastion$digitalocean:index/droplet:Droplet::bastion", "network"},
+		{"urn:pulumi:prod::sloth-kubernetes::pulumi:pulumi:Stack$aws:ec2/vpc:Vpc::main", "network"},
+		{testURNPrefix + "kubernetes-create:compute:NodeDeployment$digitalocean:index/firewall:Firewall::fw", "firewall"},
+		{testURNPrefix + "kubernetes-create:network:WireGuardMesh$command:remote:Command::wg-master-1", "vpn"},
+		{testURNPrefix + "kubernetes-create:network:VPNValidator$command:remote:Command::validate", "vpn"},
+		{testURNPrefix + "kubernetes-create:compute:NodeDeployment$kubernetes-create:compute:RealNode$digitalocean:index/droplet:Droplet::master-1", "nodes"},
+		{testURNPrefix + "kubernetes-create:cluster:RKE2Real$command:remote:Command::install-master-1", "k8s"},
+		{testURNPrefix + "kubernetes-create:dns:DNSReal$digitalocean:index/dnsRecord:DnsRecord::api", "addons"},
+		{testURNPrefix + "sloth:kubernetes:ArgoCDInstaller$command:remote:Command::argocd", "addons"},
+		{"urn:pulumi:prod::sloth-kubernetes::pulumi:pulumi:Stack::sloth-kubernetes-prod", ""},
+		{"not-a-urn", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+"/"+tt.urn, func(t *testing.T) {
+			assert.Equal(t, tt.want, deployPhaseForURN(tt.urn))
+		})
+	}
+}
+
+func stepEvent(kind string, op apitype.OpType, urn string) events.EngineEvent {
+	meta := apitype.StepEventMetadata{Op: op, URN: urn}
+	var event events.EngineEvent
+	switch kind {
+	case "pre":
+		event.ResourcePreEvent = &apitype.ResourcePreEvent{Metadata: meta}
+	case "outputs":
+		event.ResOutputsEvent = &apitype.ResOutputsEvent{Metadata: meta}
+	case "failed":
+		event.ResOpFailedEvent = &apitype.ResOpFailedEvent{Metadata: meta}
+	}
+	return event
+}
+
+func TestPhaseTracker_Summary(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	sshURN := testURNPrefix + "kubernetes-create:security:SSHKey$tls:index/privateKey:PrivateKey::ssh-key"
+	nodeURN := testURNPrefix + "kubernetes-create:compute:NodeDeployment$digitalocean:index/droplet:Droplet::master-1"
+	vpnURN := testURNPrefix + "kubernetes-create:network:WireGuardMesh$command:remote:Command::wg-master-1"
+	k8sURN := testURNPrefix + "kubernetes-create:cluster:RKE2Real$command:remote:Command::install-master-1"
+
+	tracker := newPhaseTracker(start)
+	tracker.observe(stepEvent("pre", apitype.OpSame, sshURN), at(1))
+	tracker.observe(stepEvent("outputs", apitype.OpSame, sshURN), at(1))
+	tracker.observe(stepEvent("pre", apitype.OpCreate, nodeURN), at(2))
+	tracker.observe(stepEvent("outputs", apitype.OpCreate, nodeURN), at(62))
+	tracker.observe(stepEvent("pre", apitype.OpCreate, vpnURN), at(62))
+	tracker.observe(stepEvent("outputs", apitype.OpCreate, vpnURN), at(182))
+	tracker.observe(stepEvent("pre", apitype.OpCreate, k8sURN), at(182))
+	tracker.observe(stepEvent("failed", apitype.OpCreate, k8sURN), at(200))
+	tracker.observe(events.EngineEvent{}, at(201))
+
+	summary := tracker.summary(at(210), errors.New("boom"))
+	require.Len(t, summary.Phases, len(deployPhases))
+	assert.Equal(t, "failed", summary.Status)
+	assert.Equal(t, "3m30s", summary.Duration)
+
+	byPhase := map[string]struct {
+		status   string
+		duration string
+	}{}
+	for _, phase := range summary.Phases {
+		byPhase[phase.Phase] = struct {
+			status   string
+			duration string
+		}{phase.Status, phase.Duration}
+	}
+
+	assert.Equal(t, "skipped", byPhase["providers"].status)
+	assert.Equal(t, "-", byPhase["providers"].duration)
+	assert.Equal(t, "unchanged", byPhase["ssh"].status)
+	assert.Equal(t, "success", byPhase["nodes"].status)
+	assert.Equal(t, "1m0s", byPhase["nodes"].duration)
+	assert.Equal(t, "2m0s", byPhase["vpn"].duration)
+	assert.Equal(t, "failed", byPhase["k8s"].status)
+	assert.Equal(t, "18s", byPhase["k8s"].duration)
+}
+
+func TestPhaseTracker_UnfinishedPhaseRunsToEnd(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newPhaseTracker(start)
+
+	vpnURN := testURNPrefix + "kubernetes-create:network:TailscaleMesh$command:remote:Command::ts-master-1"
+	tracker.observe(stepEvent("pre", apitype.OpCreate, vpnURN), start.Add(10*time.Second))
+
+	summary := tracker.summary(start.Add(40*time.Second), nil)
+	assert.Equal(t, "success", summary.Status)
+	for _, phase := range summary.Phases {
+		if phase.Phase == "vpn" {
+			assert.Equal(t, "30s", phase.Duration)
+			assert.Equal(t, int64(30), phase.Seconds)
+		}
+	}
+}
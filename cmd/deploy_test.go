@@ -5,6 +5,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 )
 
@@ -40,7 +42,7 @@ func TestDeployFlags(t *testing.T) {
 	flags := deployCmd.Flags()
 
 	// Required flags
-	requiredFlags := []string{"do-token", "linode-token", "wireguard-endpoint", "wireguard-pubkey", "dry-run"}
+	requiredFlags := []string{"do-token", "linode-token", "wireguard-endpoint", "wireguard-pubkey", "dry-run", "force"}
 
 	for _, flagName := range requiredFlags {
 		flag := flags.Lookup(flagName)
@@ -50,6 +52,62 @@ func TestDeployFlags(t *testing.T) {
 	}
 }
 
+// TestCheckDeployCost tests the budget check run before deploying
+func TestCheckDeployCost(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Providers: config.ProvidersConfig{
+			DigitalOcean: &config.DigitalOceanProvider{Enabled: true, Region: "nyc3", DefaultSize: "s-2vcpu-4gb"},
+		},
+		NodePools: map[string]config.NodePool{
+			"workers": {Name: "workers", Provider: "digitalocean", Count: 3},
+		},
+	}
+
+	if err := checkDeployCost(cfg, false); err != nil {
+		t.Errorf("Expected no check without cost control, got %v", err)
+	}
+
+	cfg.CostControl = &config.CostControlConfig{Estimate: true, MonthlyBudget: 1}
+	err := checkDeployCost(cfg, false)
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected an over-budget error suggesting --force, got %v", err)
+	}
+	if err := checkDeployCost(cfg, true); err != nil {
+		t.Errorf("Expected --force to allow the deploy, got %v", err)
+	}
+
+	cfg.CostControl.MonthlyBudget = 100000
+	if err := checkDeployCost(cfg, false); err != nil {
+		t.Errorf("Expected a deploy within budget to pass, got %v", err)
+	}
+}
+
+// TestWireGuardAllocationsFromOutputs tests reading back the WireGuard
+// allocations a deployment exported
+func TestWireGuardAllocationsFromOutputs(t *testing.T) {
+	outputs := auto.OutputMap{
+		"wireguard_allocations": {Value: map[string]interface{}{"masters-1": "10.8.0.2", "workers-1": "10.8.0.3"}, Secret: true},
+		"nodes": {Value: map[string]interface{}{
+			"node_0": map[string]interface{}{"name": "masters-1", "vpn_ip": "10.8.0.10"},
+		}},
+	}
+	got := wireGuardAllocationsFromOutputs(outputs)
+	if len(got) != 2 || got["masters-1"] != "10.8.0.2" || got["workers-1"] != "10.8.0.3" {
+		t.Errorf("Expected the wireguard_allocations output, got %v", got)
+	}
+
+	// Stacks deployed before the output existed keep their nodes' VPN IPs
+	delete(outputs, "wireguard_allocations")
+	got = wireGuardAllocationsFromOutputs(outputs)
+	if len(got) != 1 || got["masters-1"] != "10.8.0.10" {
+		t.Errorf("Expected the nodes output vpn_ip, got %v", got)
+	}
+
+	if got := wireGuardAllocationsFromOutputs(auto.OutputMap{}); len(got) != 0 {
+		t.Errorf("Expected no allocations for a new stack, got %v", got)
+	}
+}
+
 // TestGetEnvOrFlag tests getEnvOrFlag helper
 func TestGetEnvOrFlag(t *testing.T) {
 	tests := []struct {
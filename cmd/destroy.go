@@ -33,6 +33,7 @@ WARNING: This action cannot be undone!`,
 func init() {
 	rootCmd.AddCommand(destroyCmd)
 	destroyCmd.Flags().BoolVar(&force, "force", false, "Force destroy even if there are dependencies")
+	addForceUnlockFlag(destroyCmd)
 }
 
 func runDestroy(cmd *cobra.Command, args []string) error {
@@ -73,6 +74,12 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	unlock, err := lockStack(ctx, targetStack, "destroy")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Get stack with S3 backend support
 	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
 	s.Suffix = " Connecting to Pulumi stack..."
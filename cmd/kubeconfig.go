@@ -23,13 +23,21 @@ var kubeconfigCmd = &cobra.Command{
 	Use:   "kubeconfig [stack-name]",
 	Short: "Get kubeconfig for kubectl access",
 	Long: `Retrieve the kubeconfig file for accessing the Kubernetes cluster.
-The kubeconfig can be printed to stdout or saved to a file.`,
+The kubeconfig can be printed to stdout or saved to a file.
+
+With --merge it is added to an existing kubeconfig file (--output, or
+~/.kube/config) under a cluster, user and context named after the stack.
+Entries with the same name are replaced and the context becomes the current
+one.`,
 	Example: `  # Print to stdout
   sloth-kubernetes kubeconfig aws-cluster
 
   # Save to file
   sloth-kubernetes kubeconfig aws-cluster -o ~/.kube/config
 
+  # Add context "aws-cluster" to ~/.kube/config
+  sloth-kubernetes kubeconfig aws-cluster --merge
+
   # Using --stack flag (alternative)
   sloth-kubernetes kubeconfig --stack aws-cluster -o ~/.kube/config`,
 	RunE: runKubeconfig,
@@ -38,7 +46,7 @@ The kubeconfig can be printed to stdout or saved to a file.`,
 func init() {
 	rootCmd.AddCommand(kubeconfigCmd)
 	kubeconfigCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file path (default: stdout)")
-	kubeconfigCmd.Flags().BoolVar(&merge, "merge", false, "Merge with existing kubeconfig (not implemented yet)")
+	kubeconfigCmd.Flags().BoolVar(&merge, "merge", false, "Merge into the --output file, or ~/.kube/config, instead of overwriting it")
 }
 
 func runKubeconfig(cmd *cobra.Command, args []string) error {
@@ -91,13 +99,34 @@ func runKubeconfig(cmd *cobra.Command, args []string) error {
 	// embedded between ---KUBECONFIG_START--- and ---KUBECONFIG_END--- markers
 	kubeConfigStr = extractKubeconfig(kubeConfigStr)
 
+	// Expand home directory
+	if strings.HasPrefix(outputFile, "~/") {
+		home, _ := os.UserHomeDir()
+		outputFile = filepath.Join(home, outputFile[2:])
+	}
+
+	if merge {
+		path := outputFile
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("failed to get home directory: %w", err)
+			}
+			path = filepath.Join(home, ".kube", "config")
+		}
+		if err := mergeStackKubeconfig(path, kubeConfigStr, targetStack); err != nil {
+			return err
+		}
+
+		printSuccess(fmt.Sprintf("Merged context '%s' into %s", targetStack, path))
+		fmt.Println()
+		color.Green("🎯 You can now use kubectl:")
+		fmt.Printf("   kubectl --context %s get nodes\n", targetStack)
+		return nil
+	}
+
 	// Output to file or stdout
 	if outputFile != "" {
-		// Expand home directory
-		if outputFile[:2] == "~/" {
-			home, _ := os.UserHomeDir()
-			outputFile = filepath.Join(home, outputFile[2:])
-		}
 
 		// Create directory if needed
 		dir := filepath.Dir(outputFile)
@@ -123,6 +152,16 @@ func runKubeconfig(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// mergeStackKubeconfig validates the stack's kubeconfig and merges it into
+// the kubeconfig at path under entries named after the stack
+func mergeStackKubeconfig(path, raw, stack string) error {
+	config, err := parseKubeconfig([]byte(raw))
+	if err != nil {
+		return fmt.Errorf("kubeconfig in the stack outputs is invalid: %w", err)
+	}
+	return mergeKubeconfigFile(path, renameKubeconfig(config, stack))
+}
+
 // extractKubeconfig extracts the kubeconfig from between markers if present
 // The kubeConfig output may contain installation logs with the actual kubeconfig
 // embedded between ---KUBECONFIG_START--- and ---KUBECONFIG_END--- markers
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKubeconfigCmd_Structure(t *testing.T) {
@@ -113,3 +117,22 @@ func TestKubeconfigCmd_MergeFeature(t *testing.T) {
 	// Check that merge flag has usage text
 	assert.NotEmpty(t, mergeFlag.Usage, "merge flag should have usage text")
 }
+
+func TestMergeStackKubeconfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	stackKubeconfig := strings.Replace(rke2Kubeconfig, "127.0.0.1", "203.0.113.10", 1)
+
+	require.NoError(t, mergeStackKubeconfig(path, stackKubeconfig, "production"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	merged, err := parseKubeconfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, "production", merged.CurrentContext)
+	require.Len(t, merged.Clusters, 1)
+	assert.Equal(t, "production", merged.Clusters[0].Name)
+	assert.Equal(t, "https://203.0.113.10:6443", merged.Clusters[0].Cluster["server"], "the stack's server is kept")
+
+	err = mergeStackKubeconfig(path, "installation log without a kubeconfig", "production")
+	assert.ErrorContains(t, err, "kubeconfig in the stack outputs is invalid")
+}
@@ -80,6 +80,16 @@ func runKubectl(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if err := configureKubectlForStack(targetStack); err != nil {
+		return err
+	}
+
+	return executeKubectl(kubectlArgs)
+}
+
+// configureKubectlForStack points the embedded kubectl at the stack's
+// kubeconfig, routing through the VPN daemon's SOCKS proxy when it is running
+func configureKubectlForStack(targetStack string) error {
 	// Get kubeconfig from stack
 	kubeconfigPath, err := GetKubeconfigFromStack(targetStack)
 	if err != nil {
@@ -99,6 +109,11 @@ func runKubectl(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	return nil
+}
+
+// executeKubectl runs the embedded kubectl with the given arguments
+func executeKubectl(kubectlArgs []string) error {
 	// Create the root kubectl command with all subcommands
 	kubectlRootCmd := kubectlcmd.NewDefaultKubectlCommand()
 	kubectlRootCmd.SetArgs(kubectlArgs)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
 )
 
@@ -37,12 +39,17 @@ var listNodesCmd = &cobra.Command{
 var sshNodeCmd = &cobra.Command{
 	Use:   "ssh [stack-name] [node-name]",
 	Short: "SSH into a cluster node",
-	Long:  `Connect to a cluster node via SSH using the stored private key`,
+	Long: `Connect to a cluster node via SSH using the stored private key.
+Opens an interactive shell with a PTY unless --command is given. When the
+stack has a bastion, the connection is proxied through it.`,
 	Example: `  # SSH into a specific node
   sloth-kubernetes nodes ssh production master-primary-nyc
 
   # SSH with custom command
-  sloth-kubernetes nodes ssh production worker-1 --command "docker ps"`,
+  sloth-kubernetes nodes ssh production worker-1 --command "docker ps"
+
+  # SSH over the node's private IP
+  sloth-kubernetes nodes ssh production worker-1 --ip-preference private`,
 	RunE: runSSHNode,
 }
 
@@ -77,6 +84,7 @@ var removeNodeCmd = &cobra.Command{
 var (
 	nodesOutputFormat string
 	sshCommand        string
+	sshIPPreference   string
 	forceRemove       bool
 	nodeName          string
 	nodeProvider      string
@@ -98,6 +106,7 @@ func init() {
 
 	// SSH flags
 	sshNodeCmd.Flags().StringVar(&sshCommand, "command", "", "Command to execute on the node")
+	sshNodeCmd.Flags().StringVar(&sshIPPreference, "ip-preference", "", "Address to connect to (public, private, wireguard); defaults to WireGuard→Private→Public via bastion, public otherwise")
 
 	// Add node flags
 	addNodeCmd.Flags().StringVar(&nodeName, "name", "", "Node name")
@@ -107,6 +116,8 @@ func init() {
 
 	// Remove node flags
 	removeNodeCmd.Flags().BoolVar(&forceRemove, "force", false, "Force remove without draining")
+	addForceUnlockFlag(addNodeCmd)
+	addForceUnlockFlag(removeNodeCmd)
 }
 
 func runListNodes(cmd *cobra.Command, args []string) error {
@@ -212,24 +223,11 @@ func runSSHNode(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("node '%s' not found in stack '%s'", nodeName, stack)
 	}
 
-	// Check if bastion is enabled
-	bastionEnabled := false
-	bastionIP := ""
-	if bastionEnabledOutput, ok := outputs["bastion_enabled"]; ok {
-		if bastionEnabledOutput.Value != nil {
-			bastionEnabled = bastionEnabledOutput.Value == true
-		}
-	}
+	bastionIP := bastionIPFromOutputs(outputs)
 
-	// Get bastion info if enabled
-	if bastionEnabled {
-		if bastionOutput, ok := outputs["bastion"]; ok {
-			if bastionMap, ok := bastionOutput.Value.(map[string]interface{}); ok {
-				if pubIP, ok := bastionMap["public_ip"].(string); ok {
-					bastionIP = pubIP
-				}
-			}
-		}
+	targetIP, err := resolveNodeIP(*targetNode, sshIPPreference, bastionIP != "")
+	if err != nil {
+		return err
 	}
 
 	// Extract SSH key path
@@ -237,44 +235,18 @@ func runSSHNode(cmd *cobra.Command, args []string) error {
 
 	// Determine SSH user based on provider
 	// AWS uses "ubuntu", DigitalOcean/Linode use "root"
-	sshUser := getSSHUserForProvider(targetNode.Provider)
-
-	// Build SSH command based on bastion mode
-	var sshArgs []string
+	sshUser := sshUserForNode(*targetNode)
 
-	if bastionEnabled && bastionIP != "" {
-		// Bastion mode: Use ProxyCommand to connect through bastion
+	if bastionIP != "" {
 		printInfo(fmt.Sprintf("🏰 Bastion mode detected - connecting via bastion (%s)", bastionIP))
-		printInfo(fmt.Sprintf("   Target: %s (VPN IP: %s)", targetNode.Name, targetNode.WireGuardIP))
-
-		// Use VPN IP for connection (nodes are on private network)
-		targetIP := targetNode.WireGuardIP
-		if targetIP == "" {
-			// Fallback to public IP if VPN IP not available
-			targetIP = targetNode.PublicIP
-			printInfo("⚠️  VPN IP not available, using public IP")
-		}
-
-		// Bastion always uses root (it's a custom image)
-		sshArgs = []string{
-			"-i", sshKeyPath,
-			"-o", "StrictHostKeyChecking=accept-new",
-			"-o", "UserKnownHostsFile=/dev/null",
-			"-o", fmt.Sprintf("ProxyCommand=ssh -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-			fmt.Sprintf("%s@%s", sshUser, targetIP),
-		}
+		printInfo(fmt.Sprintf("   Target: %s (%s) as %s", targetNode.Name, targetIP, sshUser))
 	} else {
-		// Direct mode: Connect directly to node public IP
-		printInfo(fmt.Sprintf("🌍 Direct mode - connecting to %s (%s) as %s", targetNode.Name, targetNode.PublicIP, sshUser))
-
-		sshArgs = []string{
-			"-i", sshKeyPath,
-			"-o", "StrictHostKeyChecking=accept-new",
-			"-o", "UserKnownHostsFile=/dev/null",
-			fmt.Sprintf("%s@%s", sshUser, targetNode.PublicIP),
-		}
+		printInfo(fmt.Sprintf("🌍 Direct mode - connecting to %s (%s) as %s", targetNode.Name, targetIP, sshUser))
 	}
 
+	// Only allocate a PTY for interactive sessions
+	sshArgs := buildNodeSSHArgs(sshKeyPath, sshUser, targetIP, bastionIP, sshCommand == "")
+
 	// Add custom command if specified
 	if sshCommand != "" {
 		sshArgs = append(sshArgs, sshCommand)
@@ -301,20 +273,144 @@ func runSSHNode(cmd *cobra.Command, args []string) error {
 	return execCmd.Run()
 }
 
+// IP preferences accepted by --ip-preference
+const (
+	ipPreferencePublic    = "public"
+	ipPreferencePrivate   = "private"
+	ipPreferenceWireGuard = "wireguard"
+)
+
+// resolveNodeIP returns the address used to reach a node over SSH.
+// With no preference it follows the runVPNConfig priority: behind a bastion the
+// node is reached on its WireGuard IP, then private, then public; without a
+// bastion only the public IP is routable.
+func resolveNodeIP(node NodeInfo, preference string, viaBastion bool) (string, error) {
+	var ip string
+	switch preference {
+	case "":
+		if !viaBastion {
+			ip = node.PublicIP
+			break
+		}
+		for _, candidate := range []string{node.WireGuardIP, node.PrivateIP, node.PublicIP} {
+			if candidate != "" {
+				ip = candidate
+				break
+			}
+		}
+	case ipPreferencePublic:
+		ip = node.PublicIP
+	case ipPreferencePrivate:
+		ip = node.PrivateIP
+	case ipPreferenceWireGuard:
+		ip = node.WireGuardIP
+	default:
+		return "", fmt.Errorf("invalid --ip-preference %q (must be public, private or wireguard)", preference)
+	}
+
+	if ip == "" {
+		if preference == "" {
+			return "", fmt.Errorf("node '%s' has no reachable IP address", node.Name)
+		}
+		return "", fmt.Errorf("node '%s' has no %s IP address", node.Name, preference)
+	}
+	return ip, nil
+}
+
+// buildNodeSSHArgs builds the ssh arguments for connecting to targetIP,
+// hopping through the bastion via ProxyCommand when bastionIP is set.
+// interactive forces PTY allocation for shell sessions.
+func buildNodeSSHArgs(sshKeyPath, sshUser, targetIP, bastionIP string, interactive bool) []string {
+	args := []string{
+		"-i", sshKeyPath,
+		"-o", "StrictHostKeyChecking=accept-new",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+
+	if bastionIP != "" {
+		// Bastion always uses root (it's a custom image)
+		args = append(args, "-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP))
+	}
+
+	if interactive {
+		args = append(args, "-t")
+	}
+
+	return append(args, fmt.Sprintf("%s@%s", sshUser, targetIP))
+}
+
+// bastionIPFromOutputs returns the bastion public IP, or "" when the stack has no bastion
+func bastionIPFromOutputs(outputs auto.OutputMap) string {
+	bastionEnabledOutput, ok := outputs["bastion_enabled"]
+	if !ok || bastionEnabledOutput.Value != true {
+		return ""
+	}
+
+	if bastionOutput, ok := outputs["bastion"]; ok {
+		if bastionMap, ok := bastionOutput.Value.(map[string]interface{}); ok {
+			if pubIP, ok := bastionMap["public_ip"].(string); ok {
+				return pubIP
+			}
+		}
+	}
+	return ""
+}
+
+// sshUserForNode returns the node's own SSH user (the sshUser override from the
+// cluster config, or the imported node's user), falling back to the provider default
+func sshUserForNode(node NodeInfo) string {
+	if node.SSHUser != "" {
+		return node.SSHUser
+	}
+	return getSSHUserForProvider(node.Provider)
+}
+
 // getSSHUserForProvider returns the appropriate SSH user for a cloud provider
 func getSSHUserForProvider(provider string) string {
-	switch provider {
-	case "aws":
-		return "ubuntu" // AWS Ubuntu AMIs use "ubuntu" user
-	case "azure":
-		return "azureuser" // Azure default user
-	case "gcp":
-		return "ubuntu" // GCP Ubuntu images use "ubuntu" user
-	case "digitalocean", "linode":
-		return "root" // DigitalOcean and Linode use root by default
-	default:
-		return "root" // Default to root for unknown providers
+	return config.DefaultSSHUser(provider)
+}
+
+// isMasterNode reports whether a node runs the control plane
+func isMasterNode(node NodeInfo) bool {
+	for _, role := range node.Roles {
+		switch role {
+		case "master", "controlplane", "control-plane":
+			return true
+		}
 	}
+	return false
+}
+
+// runNodeScript runs a script as root on a node over SSH
+func runNodeScript(node NodeInfo, script, sshKeyPath, bastionIP string) error {
+	_, err := runNodeScriptOutput(node, script, sshKeyPath, bastionIP)
+	return err
+}
+
+// runNodeScriptOutput runs a script as root on a node over SSH, through the
+// bastion when there is one, and returns its standard output
+func runNodeScriptOutput(node NodeInfo, script, sshKeyPath, bastionIP string) (string, error) {
+	targetIP, err := resolveNodeIP(node, "", bastionIP != "")
+	if err != nil {
+		return "", err
+	}
+	user := sshUserForNode(node)
+	shell := "bash -s"
+	if user != "root" {
+		shell = "sudo bash -s"
+	}
+
+	sshArgs := append([]string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"},
+		buildNodeSSHArgs(sshKeyPath, user, targetIP, bastionIP, false)...)
+	sshCmd := exec.Command("ssh", append(sshArgs, shell)...)
+	sshCmd.Stdin = strings.NewReader(script)
+	var stderr strings.Builder
+	sshCmd.Stderr = &stderr
+	output, err := sshCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()+string(output)))
+	}
+	return string(output), nil
 }
 
 func runAddNode(cmd *cobra.Command, args []string) error {
@@ -334,6 +430,12 @@ func runAddNode(cmd *cobra.Command, args []string) error {
 
 	printHeader(fmt.Sprintf("➕ Adding node to stack: %s", stack))
 
+	unlock, err := lockStack(context.Background(), stack, "nodes-add")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Get the config file path
 	configFile := cfgFile
 	if configFile == "" {
@@ -433,6 +535,12 @@ func runRemoveNode(cmd *cobra.Command, args []string) error {
 
 	printHeader(fmt.Sprintf("➖ Removing node '%s' from stack: %s", node, stack))
 
+	unlock, err := lockStack(context.Background(), stack, "nodes-remove")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	if !forceRemove {
 		color.Yellow("⚠️  Node will be drained before removal")
 	} else {
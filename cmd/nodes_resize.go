@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+var resizeNodeCmd = &cobra.Command{
+	Use:   "resize [stack-name] [node-name]",
+	Short: "Change the size of a node in place",
+	Long: `Resize a node without replacing it. The node is drained, resized through
+the cloud API (power cycling it where the cloud requires a stopped instance),
+checked for VPN connectivity and Kubernetes readiness, and uncordoned.
+
+Resizing is supported on DigitalOcean, Linode and AWS. This is 'cluster resize'
+for a single node. Update the node pool size in your configuration afterwards
+so the next deploy keeps the new size.`,
+	Example: `  # Resize a worker
+  sloth-kubernetes nodes resize production worker-1 --size s-4vcpu-8gb`,
+	RunE: runResizeNode,
+}
+
+var (
+	resizeNodeSize string
+
+	// resizeReadyTimeout bounds the VPN and Kubernetes readiness checks after a resize
+	resizeReadyTimeout = 10 * time.Minute
+)
+
+func init() {
+	nodesCmd.AddCommand(resizeNodeCmd)
+
+	resizeNodeCmd.Flags().StringVar(&resizeNodeSize, "size", "", "New node size/type (required)")
+	addForceUnlockFlag(resizeNodeCmd)
+	resizeNodeCmd.MarkFlagRequired("size")
+}
+
+// nodeResizer runs a node resize; its kubectl and VPN steps are replaced in tests
+type nodeResizer struct {
+	kubectl   func(args ...string) error
+	verifyVPN func(ctx context.Context, node NodeInfo) error
+}
+
+// runResizeNode resizes a single node by running 'cluster resize' on it, so
+// both commands share the size, master quorum and readiness checks
+func runResizeNode(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: sloth-kubernetes nodes resize <stack-name> <node-name> --size <size>")
+	}
+
+	clusterResizeSize = resizeNodeSize
+	return runClusterResize(cmd, args)
+}
+
+// resize drains the node, resizes it through the provider, waits for the VPN
+// and Kubernetes to report it ready, and uncordons it. The size is validated
+// before the node is drained.
+func (r *nodeResizer) resize(ctx context.Context, node NodeInfo, provider providers.Provider, newSize string) error {
+	if err := providers.ValidateNodeSize(provider, newSize); err != nil {
+		return err
+	}
+	if newSize == node.Size {
+		return fmt.Errorf("node '%s' is already size %s", node.Name, newSize)
+	}
+
+	color.Cyan("🚧 Draining %s...", node.Name)
+	if err := r.kubectl("drain", node.Name, "--ignore-daemonsets", "--delete-emptydir-data", "--timeout=5m"); err != nil {
+		return fmt.Errorf("failed to drain node '%s': %w", node.Name, err)
+	}
+
+	color.Cyan("📐 Resizing %s from %s to %s...", node.Name, node.Size, newSize)
+	if err := provider.ResizeNode(ctx, stackNodeOutput(node), newSize); err != nil {
+		printWarning(fmt.Sprintf("Node '%s' is still cordoned; run 'kubectl uncordon %s' once it is healthy", node.Name, node.Name))
+		return fmt.Errorf("failed to resize node '%s': %w", node.Name, err)
+	}
+
+	color.Cyan("🔐 Verifying VPN connectivity...")
+	if err := r.verifyVPN(ctx, node); err != nil {
+		printWarning(fmt.Sprintf("Node '%s' is still cordoned", node.Name))
+		return fmt.Errorf("node '%s' did not rejoin the VPN after resize: %w", node.Name, err)
+	}
+
+	color.Cyan("☸️  Waiting for %s to become Ready...", node.Name)
+	if err := r.kubectl("wait", "--for=condition=Ready", "node/"+node.Name, fmt.Sprintf("--timeout=%s", resizeReadyTimeout)); err != nil {
+		printWarning(fmt.Sprintf("Node '%s' is still cordoned", node.Name))
+		return fmt.Errorf("node '%s' did not become Ready after resize: %w", node.Name, err)
+	}
+
+	if err := r.kubectl("uncordon", node.Name); err != nil {
+		return fmt.Errorf("failed to uncordon node '%s': %w", node.Name, err)
+	}
+
+	return nil
+}
+
+// verifyNodeVPN waits until the node's VPN interface is back up after a power cycle
+func verifyNodeVPN(ctx context.Context, stack string, node NodeInfo, mode VPNMode, bastionIP string) error {
+	targetIP, err := resolveNodeIP(node, "", bastionIP != "")
+	if err != nil {
+		return err
+	}
+
+	check := "sudo wg show wg0 >/dev/null"
+	if mode == VPNModeTailscale {
+		check = "sudo tailscale status >/dev/null"
+	}
+
+	sshArgs := append([]string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"},
+		buildNodeSSHArgs(GetSSHKeyPath(stack), sshUserForNode(node), targetIP, bastionIP, false)...)
+	sshArgs = append(sshArgs, check)
+
+	deadline := time.Now().Add(resizeReadyTimeout)
+	for {
+		output, err := exec.CommandContext(ctx, "ssh", sshArgs...).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+		}
+		time.Sleep(10 * time.Second)
+	}
+}
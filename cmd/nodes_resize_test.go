@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+type fakeResizeProvider struct {
+	providers.Provider
+	resizeErr error
+	resized   string
+}
+
+func (p *fakeResizeProvider) GetName() string    { return "fake" }
+func (p *fakeResizeProvider) GetSizes() []string { return []string{"small", "large"} }
+func (p *fakeResizeProvider) ResizeNode(ctx context.Context, node *providers.NodeOutput, newSize string) error {
+	if p.resizeErr != nil {
+		return p.resizeErr
+	}
+	p.resized = newSize
+	node.Size = newSize
+	return nil
+}
+
+func newTestResizer(calls *[]string, vpnErr error) *nodeResizer {
+	return &nodeResizer{
+		kubectl: func(args ...string) error {
+			*calls = append(*calls, strings.Join(args, " "))
+			return nil
+		},
+		verifyVPN: func(ctx context.Context, node NodeInfo) error {
+			*calls = append(*calls, "verify-vpn "+node.Name)
+			return vpnErr
+		},
+	}
+}
+
+func TestResizeNodeCmd_Structure(t *testing.T) {
+	assert.Equal(t, "resize [stack-name] [node-name]", resizeNodeCmd.Use)
+	assert.NotNil(t, resizeNodeCmd.RunE)
+	assert.NotNil(t, resizeNodeCmd.Flags().Lookup("size"))
+}
+
+func TestNodeResizer_Resize(t *testing.T) {
+	var calls []string
+	provider := &fakeResizeProvider{}
+	node := NodeInfo{Name: "worker-1", Provider: "fake", Size: "small"}
+
+	err := newTestResizer(&calls, nil).resize(context.Background(), node, provider, "large")
+	require.NoError(t, err)
+	assert.Equal(t, "large", provider.resized)
+
+	require.Len(t, calls, 4)
+	assert.True(t, strings.HasPrefix(calls[0], "drain worker-1"))
+	assert.Equal(t, "verify-vpn worker-1", calls[1])
+	assert.True(t, strings.HasPrefix(calls[2], "wait --for=condition=Ready node/worker-1"))
+	assert.Equal(t, "uncordon worker-1", calls[3])
+}
+
+func TestNodeResizer_ValidatesSizeBeforeDraining(t *testing.T) {
+	node := NodeInfo{Name: "worker-1", Provider: "fake", Size: "small"}
+
+	for _, size := range []string{"huge", "small"} {
+		var calls []string
+		err := newTestResizer(&calls, nil).resize(context.Background(), node, &fakeResizeProvider{}, size)
+		assert.Error(t, err, size)
+		assert.Empty(t, calls, "node must not be drained for size %s", size)
+	}
+}
+
+func TestNodeResizer_LeavesNodeCordonedOnFailure(t *testing.T) {
+	node := NodeInfo{Name: "worker-1", Provider: "fake", Size: "small"}
+
+	var calls []string
+	err := newTestResizer(&calls, nil).resize(context.Background(), node,
+		&fakeResizeProvider{resizeErr: errors.New("api down")}, "large")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api down")
+	assert.Len(t, calls, 1, "only the drain should have run")
+
+	calls = nil
+	err = newTestResizer(&calls, errors.New("no handshake")).resize(context.Background(), node,
+		&fakeResizeProvider{}, "large")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VPN")
+	assert.NotContains(t, strings.Join(calls, "\n"), "uncordon")
+}
@@ -596,3 +596,87 @@ func TestSSHArguments(t *testing.T) {
 		})
 	}
 }
+
+// TestResolveNodeIP tests address selection for --ip-preference
+func TestResolveNodeIP(t *testing.T) {
+	node := NodeInfo{Name: "worker-1", PublicIP: "203.0.113.10", PrivateIP: "10.0.0.10", WireGuardIP: "10.8.0.10"}
+
+	tests := []struct {
+		name       string
+		node       NodeInfo
+		preference string
+		viaBastion bool
+		want       string
+		wantErr    bool
+	}{
+		{"Default via bastion prefers WireGuard", node, "", true, "10.8.0.10", false},
+		{"Default via bastion falls back to private", NodeInfo{Name: "n", PublicIP: "203.0.113.10", PrivateIP: "10.0.0.10"}, "", true, "10.0.0.10", false},
+		{"Default via bastion falls back to public", NodeInfo{Name: "n", PublicIP: "203.0.113.10"}, "", true, "203.0.113.10", false},
+		{"Default direct uses public", node, "", false, "203.0.113.10", false},
+		{"Public preference", node, "public", true, "203.0.113.10", false},
+		{"Private preference", node, "private", false, "10.0.0.10", false},
+		{"WireGuard preference", node, "wireguard", false, "10.8.0.10", false},
+		{"Missing preferred IP", NodeInfo{Name: "n", PublicIP: "203.0.113.10"}, "wireguard", true, "", true},
+		{"Invalid preference", node, "ipv6", false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveNodeIP(tt.node, tt.preference, tt.viaBastion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveNodeIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Expected IP %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestSSHUserForNode tests the per-node SSH user read from the stack outputs
+func TestSSHUserForNode(t *testing.T) {
+	tests := []struct {
+		name string
+		node NodeInfo
+		want string
+	}{
+		{"AWS default", NodeInfo{Provider: "aws"}, "ubuntu"},
+		{"GCP default", NodeInfo{Provider: "gcp"}, "ubuntu"},
+		{"Azure default", NodeInfo{Provider: "azure"}, "azureuser"},
+		{"DigitalOcean default", NodeInfo{Provider: "digitalocean"}, "root"},
+		{"Linode default", NodeInfo{Provider: "linode"}, "root"},
+		{"Hetzner default", NodeInfo{Provider: "hetzner"}, "root"},
+		{"Override from outputs", NodeInfo{Provider: "gcp", SSHUser: "debian"}, "debian"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sshUserForNode(tt.node); got != tt.want {
+				t.Errorf("sshUserForNode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildNodeSSHArgs tests the shared SSH argument builder
+func TestBuildNodeSSHArgs(t *testing.T) {
+	direct := buildNodeSSHArgs("/root/.ssh/id_rsa", "ubuntu", "203.0.113.10", "", true)
+	joined := strings.Join(direct, " ")
+	if strings.Contains(joined, "ProxyCommand") {
+		t.Error("Direct mode should not use ProxyCommand")
+	}
+	if !strings.Contains(joined, "-t") {
+		t.Error("Interactive session should request a PTY")
+	}
+	if direct[len(direct)-1] != "ubuntu@203.0.113.10" {
+		t.Errorf("Expected target last, got %q", direct[len(direct)-1])
+	}
+
+	proxied := strings.Join(buildNodeSSHArgs("/root/.ssh/id_rsa", "root", "10.8.0.10", "203.0.113.1", false), " ")
+	if !strings.Contains(proxied, "ProxyCommand=ssh -q -i /root/.ssh/id_rsa") || !strings.Contains(proxied, "root@203.0.113.1") {
+		t.Errorf("Bastion mode should proxy through the bastion, got %q", proxied)
+	}
+	if strings.Contains(proxied, " -t ") {
+		t.Error("Non-interactive session should not request a PTY")
+	}
+}
@@ -20,6 +20,7 @@ type NodeInfo struct {
 	WireGuardIP string   `json:"wireGuardIP" yaml:"wireGuardIP"`
 	Roles       []string `json:"roles" yaml:"roles"`
 	Status      string   `json:"status" yaml:"status"`
+	SSHUser     string   `json:"sshUser,omitempty" yaml:"sshUser,omitempty"` // Set for imported nodes
 }
 
 // VPNPeerInfo represents a VPN peer (external client)
@@ -90,6 +91,9 @@ func ParseNodeOutputs(outputs auto.OutputMap) ([]NodeInfo, error) {
 		if status, ok := nodeMap["status"].(string); ok {
 			node.Status = status
 		}
+		if sshUser, ok := nodeMap["ssh_user"].(string); ok {
+			node.SSHUser = sshUser
+		}
 
 		// Parse roles array
 		if rolesData, ok := nodeMap["roles"].([]interface{}); ok {
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/common"
+	"github.com/chalkan3/sloth-kubernetes/internal/validation"
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// planSchemaVersion is bumped whenever the JSON plan layout changes incompatibly
+const planSchemaVersion = "1"
+
+var planJSON bool
+
+var planCmd = &cobra.Command{
+	Use:   "plan [stack-name]",
+	Short: "Show the intended cluster and the pending infrastructure changes",
+	Long: `Plan loads and validates the cluster configuration, runs a Pulumi preview
+against the stack, and reports what a deploy would do.
+
+With --json the plan is written to stdout as a stable, machine-readable
+document (providers, node pools, networking, addons and the preview change
+summary) so CI pipelines and policy engines such as OPA can gate on it.
+Progress output is sent to stderr in this mode.`,
+	Example: `  # Human-readable plan
+  sloth-kubernetes plan my-cluster --config cluster.lisp
+
+  # Machine-readable plan for CI / OPA
+  sloth-kubernetes plan my-cluster --config cluster.lisp --json > plan.json
+  opa eval -i plan.json -d policy.rego "data.cluster.deny"`,
+	RunE: runPlan,
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	planCmd.Flags().BoolVar(&planJSON, "json", false, "Output the plan as JSON for automation")
+}
+
+// ClusterPlan is the machine-readable description of an intended cluster
+type ClusterPlan struct {
+	SchemaVersion string          `json:"schemaVersion"`
+	Stack         string          `json:"stack"`
+	Cluster       PlanCluster     `json:"cluster"`
+	Providers     []PlanProvider  `json:"providers"`
+	NodePools     []PlanNodePool  `json:"nodePools"`
+	Nodes         []PlanNode      `json:"nodes"`
+	Network       PlanNetwork     `json:"network"`
+	Addons        []string        `json:"addons"`
+	Changes       map[string]int  `json:"changes"`
+	Summary       PlanNodeSummary `json:"summary"`
+}
+
+// PlanCluster describes cluster-wide settings
+type PlanCluster struct {
+	Name          string `json:"name"`
+	Environment   string `json:"environment"`
+	Distribution  string `json:"distribution"`
+	Version       string `json:"version"`
+	NetworkPlugin string `json:"networkPlugin"`
+}
+
+// PlanProvider describes an enabled cloud provider
+type PlanProvider struct {
+	Name        string `json:"name"`
+	Region      string `json:"region"`
+	DefaultSize string `json:"defaultSize,omitempty"`
+}
+
+// PlanNodePool describes a node pool with its effective region and size
+type PlanNodePool struct {
+	Name     string            `json:"name"`
+	Provider string            `json:"provider"`
+	Count    int               `json:"count"`
+	Roles    []string          `json:"roles"`
+	Size     string            `json:"size"`
+	Region   string            `json:"region"`
+	Spot     bool              `json:"spot"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// PlanNode describes a standalone node
+type PlanNode struct {
+	Name     string   `json:"name"`
+	Provider string   `json:"provider"`
+	Roles    []string `json:"roles"`
+	Size     string   `json:"size"`
+	Region   string   `json:"region"`
+}
+
+// PlanNetwork describes the cluster networking
+type PlanNetwork struct {
+	Mode        string `json:"mode"`
+	VPN         string `json:"vpn"`
+	PodCIDR     string `json:"podCidr"`
+	ServiceCIDR string `json:"serviceCidr"`
+	VPNSubnet   string `json:"vpnSubnet,omitempty"`
+	DNSDomain   string `json:"dnsDomain,omitempty"`
+	Bastion     bool   `json:"bastion"`
+}
+
+// PlanNodeSummary aggregates node counts
+type PlanNodeSummary struct {
+	TotalNodes int            `json:"totalNodes"`
+	Masters    int            `json:"masters"`
+	Workers    int            `json:"workers"`
+	ByProvider map[string]int `json:"byProvider"`
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// In JSON mode stdout carries only the plan document
+	if planJSON {
+		restore := redirectStdoutToStderr()
+		defer restore()
+	}
+
+	_ = common.LoadSavedConfig()
+
+	targetStack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+	stackName = targetStack
+
+	cfg, err := loadConfiguration()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if err := validation.ValidateClusterConfig(cfg); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+	if err := validation.ValidateNodePools(cfg); err != nil {
+		return fmt.Errorf("node pool validation failed: %w", err)
+	}
+
+	plan := buildClusterPlan(targetStack, cfg)
+
+	if !planJSON {
+		printDeploymentSummary(cfg)
+		printInfo("🔧 Setting up Pulumi stack...")
+	}
+
+	stack, err := prepareDeployStack(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	loadPreviousDeploymentMeta(ctx, stack)
+
+	prev, err := stack.Preview(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to preview: %w", err)
+	}
+	plan.Changes = planChangeSummary(prev)
+
+	if !planJSON {
+		printPreviewSummary(prev)
+		return nil
+	}
+
+	return writePlanJSON(plan)
+}
+
+// buildClusterPlan converts a cluster config into a deterministic plan document.
+// Slices are sorted by name so the output is stable across runs.
+func buildClusterPlan(stack string, cfg *config.ClusterConfig) *ClusterPlan {
+	plan := &ClusterPlan{
+		SchemaVersion: planSchemaVersion,
+		Stack:         stack,
+		Cluster: PlanCluster{
+			Name:          cfg.Metadata.Name,
+			Environment:   cfg.Metadata.Environment,
+			Distribution:  cfg.Kubernetes.Distribution,
+			Version:       cfg.Kubernetes.Version,
+			NetworkPlugin: cfg.Kubernetes.NetworkPlugin,
+		},
+		Providers: []PlanProvider{},
+		NodePools: []PlanNodePool{},
+		Nodes:     []PlanNode{},
+		Addons:    cfg.EnabledAddons(),
+		Changes:   map[string]int{},
+		Summary:   PlanNodeSummary{ByProvider: map[string]int{}},
+	}
+
+	for _, name := range cfg.EnabledProviderNames() {
+		region, size := cfg.ProviderDefaults(name)
+		plan.Providers = append(plan.Providers, PlanProvider{Name: name, Region: region, DefaultSize: size})
+	}
+
+	poolNames := make([]string, 0, len(cfg.NodePools))
+	for name := range cfg.NodePools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+
+	for _, name := range poolNames {
+		pool := cfg.NodePools[name]
+		region, size := cfg.ProviderDefaults(pool.Provider)
+		if pool.Region != "" {
+			region = pool.Region
+		}
+		if pool.Size != "" {
+			size = pool.Size
+		}
+		plan.NodePools = append(plan.NodePools, PlanNodePool{
+			Name:     name,
+			Provider: pool.Provider,
+			Count:    pool.Count,
+			Roles:    pool.Roles,
+			Size:     size,
+			Region:   region,
+			Spot:     pool.SpotInstance || pool.Preemptible,
+			Labels:   pool.Labels,
+		})
+		plan.addToSummary(pool.Provider, pool.Roles, pool.Count)
+	}
+
+	nodes := append([]config.NodeConfig(nil), cfg.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	for _, node := range nodes {
+		plan.Nodes = append(plan.Nodes, PlanNode{
+			Name:     node.Name,
+			Provider: node.Provider,
+			Roles:    node.Roles,
+			Size:     node.Size,
+			Region:   node.Region,
+		})
+		plan.addToSummary(node.Provider, node.Roles, 1)
+	}
+
+	plan.Network = PlanNetwork{
+		Mode:        cfg.Network.Mode,
+		VPN:         "none",
+		PodCIDR:     cfg.Kubernetes.PodCIDR,
+		ServiceCIDR: cfg.Kubernetes.ServiceCIDR,
+		DNSDomain:   cfg.Network.DNS.Domain,
+		Bastion:     cfg.Security.Bastion != nil && cfg.Security.Bastion.Enabled,
+	}
+	if cfg.Network.Tailscale != nil && cfg.Network.Tailscale.Enabled {
+		plan.Network.VPN = "tailscale"
+	} else if cfg.Network.WireGuard != nil && cfg.Network.WireGuard.Enabled {
+		plan.Network.VPN = "wireguard"
+		plan.Network.VPNSubnet = cfg.Network.WireGuard.SubnetCIDR
+	}
+
+	return plan
+}
+
+// addToSummary counts nodes by provider and role
+func (p *ClusterPlan) addToSummary(provider string, roles []string, count int) {
+	p.Summary.TotalNodes += count
+	p.Summary.ByProvider[provider] += count
+	for _, role := range roles {
+		switch role {
+		case "master", "controlplane":
+			p.Summary.Masters += count
+		case "worker":
+			p.Summary.Workers += count
+		}
+	}
+}
+
+// planChangeSummary converts the Pulumi preview change summary into plain string keys
+func planChangeSummary(prev auto.PreviewResult) map[string]int {
+	changes := map[string]int{}
+	for op, count := range prev.ChangeSummary {
+		changes[string(op)] = count
+	}
+	return changes
+}
+
+// writePlanJSON writes the plan as indented JSON to the real stdout
+func writePlanJSON(plan *ClusterPlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	_, err = fmt.Fprintln(planStdout, string(data))
+	return err
+}
+
+// planStdout is where the JSON document is written; it is captured before
+// progress output is redirected to stderr
+var planStdout = os.Stdout
+
+// redirectStdoutToStderr sends human-oriented progress output to stderr so that
+// stdout carries only machine-readable output. It returns a restore function.
+func redirectStdoutToStderr() func() {
+	stdout, colorOut := os.Stdout, color.Output
+	planStdout = stdout
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+	return func() {
+		os.Stdout = stdout
+		color.Output = colorOut
+	}
+}
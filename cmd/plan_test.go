@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanCmd_Structure(t *testing.T) {
+	assert.NotNil(t, planCmd)
+	assert.Equal(t, "plan [stack-name]", planCmd.Use)
+	assert.NotEmpty(t, planCmd.Short)
+	assert.NotNil(t, planCmd.RunE)
+
+	flag := planCmd.Flags().Lookup("json")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+}
+
+func planTestConfig() *config.ClusterConfig {
+	return &config.ClusterConfig{
+		Metadata: config.Metadata{Name: "prod", Environment: "production"},
+		Providers: config.ProvidersConfig{
+			DigitalOcean: &config.DigitalOceanProvider{Enabled: true, Region: "nyc3", DefaultSize: "s-2vcpu-4gb"},
+			Linode:       &config.LinodeProvider{Enabled: true, Region: "us-east"},
+		},
+		Network: config.NetworkConfig{
+			Mode:      "wireguard",
+			WireGuard: &config.WireGuardConfig{Enabled: true, SubnetCIDR: "10.8.0.0/24"},
+		},
+		Kubernetes: config.KubernetesConfig{Distribution: "rke2", PodCIDR: "10.42.0.0/16", ServiceCIDR: "10.43.0.0/16"},
+		NodePools: map[string]config.NodePool{
+			"workers": {Provider: "linode", Count: 3, Roles: []string{"worker"}, Size: "g6-standard-4"},
+			"masters": {Provider: "digitalocean", Count: 3, Roles: []string{"master"}},
+		},
+		Addons: config.AddonsConfig{ArgoCD: &config.ArgoCDConfig{Enabled: true}},
+	}
+}
+
+func TestBuildClusterPlan_EffectiveValuesAndOrdering(t *testing.T) {
+	plan := buildClusterPlan("prod", planTestConfig())
+
+	assert.Equal(t, planSchemaVersion, plan.SchemaVersion)
+	assert.Equal(t, "prod", plan.Stack)
+
+	require.Len(t, plan.Providers, 2)
+	assert.Equal(t, "digitalocean", plan.Providers[0].Name)
+	assert.Equal(t, "linode", plan.Providers[1].Name)
+
+	require.Len(t, plan.NodePools, 2)
+	assert.Equal(t, "masters", plan.NodePools[0].Name)
+	assert.Equal(t, "nyc3", plan.NodePools[0].Region, "region inherited from provider")
+	assert.Equal(t, "s-2vcpu-4gb", plan.NodePools[0].Size, "size inherited from provider")
+	assert.Equal(t, "workers", plan.NodePools[1].Name)
+	assert.Equal(t, "g6-standard-4", plan.NodePools[1].Size)
+
+	assert.Equal(t, 6, plan.Summary.TotalNodes)
+	assert.Equal(t, 3, plan.Summary.Masters)
+	assert.Equal(t, 3, plan.Summary.Workers)
+	assert.Equal(t, map[string]int{"digitalocean": 3, "linode": 3}, plan.Summary.ByProvider)
+
+	assert.Equal(t, "wireguard", plan.Network.VPN)
+	assert.Equal(t, "10.8.0.0/24", plan.Network.VPNSubnet)
+	assert.Equal(t, []string{"argocd"}, plan.Addons)
+}
+
+func TestBuildClusterPlan_StableJSON(t *testing.T) {
+	first, err := json.Marshal(buildClusterPlan("prod", planTestConfig()))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		next, err := json.Marshal(buildClusterPlan("prod", planTestConfig()))
+		require.NoError(t, err)
+		assert.JSONEq(t, string(first), string(next))
+		assert.Equal(t, string(first), string(next))
+	}
+}
+
+func TestBuildClusterPlan_EmptyConfigUsesEmptyCollections(t *testing.T) {
+	data, err := json.Marshal(buildClusterPlan("empty", &config.ClusterConfig{}))
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, []interface{}{}, decoded["providers"])
+	assert.Equal(t, []interface{}{}, decoded["nodePools"])
+	assert.Equal(t, []interface{}{}, decoded["addons"])
+	assert.Equal(t, map[string]interface{}{}, decoded["changes"])
+}
@@ -3,7 +3,6 @@
 package cmd
 
 import (
-	"os"
 	"os/exec"
 	"syscall"
 )
@@ -15,18 +14,12 @@ func setupDaemonProcess(cmd *exec.Cmd) {
 	}
 }
 
-// isProcessRunning checks if a process with the given PID is still running
-func isProcessRunning(pid int) bool {
-	return syscall.Kill(pid, 0) == nil
-}
-
 // Platform-specific signal constants
 var (
 	signalInterrupt = syscall.SIGINT
 	signalTerminate = syscall.SIGTERM
 )
 
-// terminateProcess sends a termination signal to the process
-func terminateProcess(process *os.Process) error {
-	return process.Signal(syscall.SIGTERM)
-}
+// sshMultiplexSupported reports whether the ssh client can share connections
+// (ControlMaster)
+const sshMultiplexSupported = true
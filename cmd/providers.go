@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator"
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "Inspect the supported cloud providers",
+}
+
+var providersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List providers with their regions and sizes",
+	Long: `Print the regions and node sizes each supported provider accepts, so
+valid values can be picked before writing a configuration.
+
+Each provider is shown with its credential status: credentials are looked up
+in the configuration given with --config and in the environment
+(DIGITALOCEAN_TOKEN, LINODE_TOKEN, AWS_ACCESS_KEY_ID, ...). The catalog
+itself is built in, so providers without credentials are listed too.`,
+	Example: `  # List every provider
+  sloth-kubernetes providers list
+
+  # Only DigitalOcean regions
+  sloth-kubernetes providers list --provider do --regions
+
+  # Hetzner sizes, with credentials checked against a config
+  sloth-kubernetes providers list --provider hetzner --sizes -c cluster.lisp`,
+	Args: cobra.NoArgs,
+	RunE: runProvidersList,
+}
+
+var (
+	providersListProvider string
+	providersListRegions  bool
+	providersListSizes    bool
+)
+
+func init() {
+	rootCmd.AddCommand(providersCmd)
+	providersCmd.AddCommand(providersListCmd)
+
+	providersListCmd.Flags().StringVar(&providersListProvider, "provider", "", "Only list this provider (do is short for digitalocean)")
+	providersListCmd.Flags().BoolVar(&providersListRegions, "regions", false, "Only list regions")
+	providersListCmd.Flags().BoolVar(&providersListSizes, "sizes", false, "Only list sizes")
+}
+
+func runProvidersList(cmd *cobra.Command, args []string) error {
+	names := providers.ProviderNames()
+	if providersListProvider != "" {
+		name, err := canonicalProviderName(providersListProvider)
+		if err != nil {
+			return err
+		}
+		names = []string{name}
+	}
+
+	cfg := &config.ClusterConfig{}
+	if cfgFile != "" {
+		loaded, err := config.ReadConfigFile(cfgFile, config.DetectConfigFormat(cfgFile))
+		if err != nil {
+			return fmt.Errorf("failed to load config file: %w", err)
+		}
+		cfg = loaded
+	}
+
+	// Without a filter both lists are shown
+	showRegions, showSizes := providersListRegions, providersListSizes
+	if !showRegions && !showSizes {
+		showRegions, showSizes = true, true
+	}
+
+	return writeProviderCatalog(cmd.OutOrStdout(), names, cfg, showRegions, showSizes)
+}
+
+// canonicalProviderName resolves a provider name or alias to the name the
+// provider is registered under
+func canonicalProviderName(name string) (string, error) {
+	name = strings.ToLower(name)
+	if name == "do" {
+		name = "digitalocean"
+	}
+	for _, known := range providers.ProviderNames() {
+		if name == known {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown provider %q; supported providers: %s", name, strings.Join(providers.ProviderNames(), ", "))
+}
+
+// writeProviderCatalog prints each provider's credential status followed by
+// the regions and sizes asked for
+func writeProviderCatalog(w io.Writer, names []string, cfg *config.ClusterConfig, showRegions, showSizes bool) error {
+	bold := color.New(color.Bold)
+	for i, name := range names {
+		provider, err := providers.NewProviderByName(name)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		credentials := color.GreenString("credentials found")
+		if missing := orchestrator.MissingCredentials(cfg, name); len(missing) > 0 {
+			credentials = color.YellowString("missing %s", strings.Join(missing, ", "))
+		}
+		fmt.Fprintf(w, "%s (%s)\n", bold.Sprint(name), credentials)
+
+		if showRegions {
+			regions := provider.GetRegions()
+			fmt.Fprintf(w, "  Regions (%d): %s\n", len(regions), strings.Join(regions, ", "))
+		}
+		if showSizes {
+			sizes := provider.GetSizes()
+			fmt.Fprintf(w, "  Sizes (%d): %s\n", len(sizes), strings.Join(sizes, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+func TestProvidersListCmd_Structure(t *testing.T) {
+	assert.Equal(t, "list", providersListCmd.Use)
+	assert.NotNil(t, providersListCmd.RunE)
+	assert.NotNil(t, providersListCmd.Flags().Lookup("provider"))
+	assert.NotNil(t, providersListCmd.Flags().Lookup("regions"))
+	assert.NotNil(t, providersListCmd.Flags().Lookup("sizes"))
+}
+
+func TestCanonicalProviderName(t *testing.T) {
+	name, err := canonicalProviderName("do")
+	require.NoError(t, err)
+	assert.Equal(t, "digitalocean", name)
+
+	name, err = canonicalProviderName("Hetzner")
+	require.NoError(t, err)
+	assert.Equal(t, "hetzner", name)
+
+	_, err = canonicalProviderName("vultr")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "supported providers: digitalocean, linode, aws, gcp, azure, hetzner")
+}
+
+func TestWriteProviderCatalog(t *testing.T) {
+	t.Setenv("DIGITALOCEAN_TOKEN", "")
+	t.Setenv("LINODE_TOKEN", "env-token")
+
+	var out bytes.Buffer
+	require.NoError(t, writeProviderCatalog(&out, []string{"digitalocean", "linode"}, &config.ClusterConfig{}, true, false))
+
+	text := out.String()
+	assert.Contains(t, text, "digitalocean (missing token (or DIGITALOCEAN_TOKEN))")
+	assert.Contains(t, text, "linode (credentials found)")
+	assert.Contains(t, text, "  Regions (")
+	assert.Contains(t, text, "nyc3")
+	assert.NotContains(t, text, "Sizes")
+}
+
+func TestWriteProviderCatalog_CredentialsFromConfig(t *testing.T) {
+	t.Setenv("HCLOUD_TOKEN", "")
+	cfg := &config.ClusterConfig{
+		Providers: config.ProvidersConfig{Hetzner: &config.HetznerProvider{Token: "config-token"}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeProviderCatalog(&out, []string{"hetzner"}, cfg, false, true))
+
+	text := out.String()
+	assert.Contains(t, text, "hetzner (credentials found)")
+	assert.Contains(t, text, "  Sizes (")
+	assert.Contains(t, text, "cpx11")
+	assert.NotContains(t, text, "Regions")
+}
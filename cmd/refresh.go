@@ -57,6 +57,7 @@ func init() {
 	refreshCmd.Flags().BoolVar(&expectNoChanges, "expect-no-changes", false, "Return error if any changes are detected")
 	refreshCmd.Flags().BoolVar(&showSecrets, "show-secrets", false, "Show secret values in output")
 	refreshCmd.Flags().BoolVar(&skipPreview, "skip-preview", false, "Skip preview and refresh directly")
+	addForceUnlockFlag(refreshCmd)
 }
 
 func runRefresh(cmd *cobra.Command, args []string) error {
@@ -86,6 +87,12 @@ func runRefresh(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	unlock, err := lockStack(ctx, targetStack, "refresh")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Get stack with S3 backend support
 	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
 	s.Suffix = " Connecting to Pulumi stack..."
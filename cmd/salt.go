@@ -621,11 +621,13 @@ func autoJoinVPN(stackName string, outputs auto.OutputMap) error {
 
 	color.Cyan("📡 VPN IP: %s", vpnIP)
 
+	keepalive := clientKeepalive(outputs)
+
 	// Add peer to cluster nodes
 	color.Cyan("🔗 Adding peer to cluster nodes...")
 	for _, node := range nodes {
 		targetIP := node.PublicIP
-		peerAddScript := generatePeerAddScript(vpnIP, publicKey, "cli-auto-join")
+		peerAddScript := generatePeerAddScript(vpnIP, publicKey, "cli-auto-join", keepalive)
 
 		var sshCmd *exec.Cmd
 		if bastionEnabled && bastionIP != "" {
@@ -636,7 +638,7 @@ func autoJoinVPN(stackName string, outputs auto.OutputMap) error {
 					nodeTargetIP = node.PublicIP
 				}
 			}
-			sshUser := getSSHUserForNode(node.Provider)
+			sshUser := sshUserForNode(node)
 			sshCmd = exec.Command("ssh",
 				"-i", sshKeyPath,
 				"-o", "StrictHostKeyChecking=accept-new",
@@ -647,7 +649,7 @@ func autoJoinVPN(stackName string, outputs auto.OutputMap) error {
 				"bash", "-s",
 			)
 		} else {
-			sshUser := getSSHUserForNode(node.Provider)
+			sshUser := sshUserForNode(node)
 			sshCmd = exec.Command("ssh",
 				"-i", sshKeyPath,
 				"-o", "StrictHostKeyChecking=accept-new",
@@ -668,7 +670,7 @@ func autoJoinVPN(stackName string, outputs auto.OutputMap) error {
 
 	// Generate and install client config
 	color.Cyan("📝 Generating WireGuard configuration...")
-	clientConfig := generateClientConfig(privateKey, vpnIP, "cli-auto-join", nodes, nil, sshKeyPath, bastionEnabled, bastionIP)
+	clientConfig := generateClientConfig(privateKey, vpnIP, "cli-auto-join", nodes, nil, nil, loadAdvertisedRoutes(stackName), keepalive, sshKeyPath, bastionEnabled, bastionIP)
 
 	// Detect OS and install
 	osType := detectOS()
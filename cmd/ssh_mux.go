@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sshControlPersist is how long a shared SSH connection stays open after its
+// last command, so the next command against the same host can reuse it
+const sshControlPersist = "60s"
+
+// sshControlDir returns the directory holding the shared connection sockets
+func sshControlDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".sloth-kubernetes", "ssh")
+}
+
+// sshMultiplexOptions returns the ssh options sharing one connection per
+// host and user between commands (OpenSSH ControlMaster), or nil when the
+// client cannot multiplex or the socket directory is not usable
+func sshMultiplexOptions() []string {
+	if !sshMultiplexSupported {
+		return nil
+	}
+	dir := sshControlDir()
+	if dir == "" || os.MkdirAll(dir, 0700) != nil {
+		return nil
+	}
+	return []string{
+		"-o", "ControlMaster=auto",
+		// %C hashes host, port and user, keeping the socket path short
+		"-o", "ControlPath=" + filepath.Join(dir, "%C"),
+		"-o", "ControlPersist=" + sshControlPersist,
+	}
+}
+
+// vpnBastionHop returns the bastion to hop through, or "" when disabled
+func vpnBastionHop(bastionEnabled bool, bastionIP string) string {
+	if !bastionEnabled {
+		return ""
+	}
+	return bastionIP
+}
+
+// buildMultiplexedSSHArgs builds the arguments of a non-interactive ssh
+// command running remoteCmd on targetIP, hopping through the bastion via
+// ProxyCommand when bastionIP is set. Both the connection to the node and to
+// the bastion are shared with later commands against the same host.
+func buildMultiplexedSSHArgs(sshKeyPath, sshUser, targetIP, bastionIP string, connectTimeout time.Duration, remoteCmd string) []string {
+	mux := sshMultiplexOptions()
+
+	args := []string{
+		"-q",
+		"-i", sshKeyPath,
+		"-o", "StrictHostKeyChecking=accept-new",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", fmt.Sprintf("ConnectTimeout=%d", int(connectTimeout.Seconds())),
+	}
+	args = append(args, mux...)
+
+	if bastionIP != "" {
+		proxy := fmt.Sprintf("ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null", sshKeyPath)
+		for i := 1; i < len(mux); i += 2 {
+			// ProxyCommand expands % tokens itself, so the bastion's
+			// ControlPath token has to be escaped
+			proxy += fmt.Sprintf(" -o '%s'", strings.ReplaceAll(mux[i], "%", "%%"))
+		}
+		// Bastion always uses root (it's a custom image)
+		args = append(args, "-o", fmt.Sprintf("ProxyCommand=%s -W %%h:%%p root@%s", proxy, bastionIP))
+	}
+
+	return append(args, fmt.Sprintf("%s@%s", sshUser, targetIP), remoteCmd)
+}
+
+// nodeSSHCommand builds the ssh command running remoteCmd on a node, see
+// buildMultiplexedSSHArgs
+func nodeSSHCommand(sshKeyPath, sshUser, targetIP, bastionIP string, connectTimeout time.Duration, remoteCmd string) *exec.Cmd {
+	return exec.Command("ssh", buildMultiplexedSSHArgs(sshKeyPath, sshUser, targetIP, bastionIP, connectTimeout, remoteCmd)...)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHMultiplexOptions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	opts := sshMultiplexOptions()
+	if !sshMultiplexSupported {
+		assert.Nil(t, opts)
+		return
+	}
+
+	dir := filepath.Join(home, ".sloth-kubernetes", "ssh")
+	assert.Equal(t, []string{
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + filepath.Join(dir, "%C"),
+		"-o", "ControlPersist=60s",
+	}, opts)
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestBuildMultiplexedSSHArgs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	direct := buildMultiplexedSSHArgs("/keys/id_rsa", "ubuntu", "203.0.113.10", "", 5*time.Second, "sudo wg show")
+	require.GreaterOrEqual(t, len(direct), 2)
+	assert.Equal(t, []string{"ubuntu@203.0.113.10", "sudo wg show"}, direct[len(direct)-2:])
+	joined := strings.Join(direct, " ")
+	assert.Contains(t, joined, "-o ConnectTimeout=5")
+	assert.NotContains(t, joined, "ProxyCommand")
+
+	proxied := buildMultiplexedSSHArgs("/keys/id_rsa", "root", "10.8.0.10", "203.0.113.1", 10*time.Second, "uptime")
+	var proxy string
+	for _, arg := range proxied {
+		if strings.HasPrefix(arg, "ProxyCommand=") {
+			proxy = arg
+		}
+	}
+	require.NotEmpty(t, proxy)
+	assert.True(t, strings.HasPrefix(proxy, "ProxyCommand=ssh -q -i /keys/id_rsa"))
+	assert.True(t, strings.HasSuffix(proxy, " -W %h:%p root@203.0.113.1"))
+	assert.Equal(t, "root@10.8.0.10", proxied[len(proxied)-2])
+
+	if sshMultiplexSupported {
+		assert.Contains(t, joined, "-o ControlMaster=auto")
+		// The bastion connection is shared too, with the token escaped for ProxyCommand
+		assert.Contains(t, proxy, "-o 'ControlMaster=auto'")
+		assert.Contains(t, proxy, "-o 'ControlPath="+filepath.Join(home, ".sloth-kubernetes", "ssh", "%%C")+"'")
+	}
+}
+
+func TestVPNBastionHop(t *testing.T) {
+	assert.Equal(t, "203.0.113.1", vpnBastionHop(true, "203.0.113.1"))
+	assert.Equal(t, "", vpnBastionHop(false, "203.0.113.1"))
+	assert.Equal(t, "", vpnBastionHop(true, ""))
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/common"
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+// forceUnlock breaks a stack lock held by someone else
+var forceUnlock bool
+
+// newStackLocker builds the locker for the configured backend; replaced in tests
+var newStackLocker = func(ctx context.Context) (*operations.StackLocker, error) {
+	_ = common.LoadSavedConfig()
+
+	store, err := operations.NewLockStoreForBackend(ctx, os.Getenv("PULUMI_BACKEND_URL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stack lock store: %w", err)
+	}
+	return operations.NewStackLocker(store), nil
+}
+
+// addForceUnlockFlag registers --force-unlock on a command that mutates a stack
+func addForceUnlockFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&forceUnlock, "force-unlock", false, "Break a stale stack lock held by another user")
+}
+
+// lockStack takes the advisory lock on a stack for a mutating command. Read-only
+// commands never lock. The returned function releases the lock and is safe to defer.
+func lockStack(ctx context.Context, stack, operation string) (func(), error) {
+	locker, err := newStackLocker(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lock, broken, err := locker.Acquire(ctx, stack, operation, forceUnlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if broken != nil {
+		printWarning(fmt.Sprintf("Broke stack lock held by %s since %s (%s)",
+			broken.Owner, broken.AcquiredAt.Local().Format(time.RFC1123), broken.Operation))
+	}
+
+	return func() {
+		if err := locker.Release(context.Background(), lock); err != nil {
+			printWarning(fmt.Sprintf("Failed to release stack lock: %v", err))
+		}
+	}, nil
+}
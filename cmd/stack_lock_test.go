@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+func useTestStackLocker(t *testing.T, owner string) *operations.StackLocker {
+	t.Helper()
+
+	store := &operations.FileLockStore{Dir: t.TempDir()}
+	locker := operations.NewStackLocker(store)
+	locker.Owner = owner
+
+	orig := newStackLocker
+	newStackLocker = func(ctx context.Context) (*operations.StackLocker, error) { return locker, nil }
+	t.Cleanup(func() {
+		newStackLocker = orig
+		forceUnlock = false
+	})
+	return locker
+}
+
+func TestLockStack_BlocksOtherOwners(t *testing.T) {
+	ctx := context.Background()
+	locker := useTestStackLocker(t, "alice@laptop")
+
+	unlock, err := lockStack(ctx, "production", "deploy")
+	require.NoError(t, err)
+
+	other := operations.NewStackLocker(locker.Store)
+	other.Owner = "bob@ci"
+	_, _, err = other.Acquire(ctx, "production", "nodes-resize", false)
+	require.Error(t, err)
+
+	var locked *operations.StackLockedError
+	require.True(t, errors.As(err, &locked))
+	assert.Equal(t, "alice@laptop", locked.Lock.Owner)
+	assert.Contains(t, err.Error(), "stack production is locked by alice@laptop since")
+
+	// Other stacks are unaffected
+	_, _, err = other.Acquire(ctx, "staging", "deploy", false)
+	assert.NoError(t, err)
+
+	unlock()
+	current, err := locker.Current(ctx, "production")
+	require.NoError(t, err)
+	assert.Nil(t, current, "lock is released")
+}
+
+func TestLockStack_ForceUnlockBreaksLock(t *testing.T) {
+	ctx := context.Background()
+	locker := useTestStackLocker(t, "bob@ci")
+
+	stale := operations.NewStackLocker(locker.Store)
+	stale.Owner = "alice@laptop"
+	held, _, err := stale.Acquire(ctx, "production", "deploy", false)
+	require.NoError(t, err)
+
+	_, err = lockStack(ctx, "production", "deploy")
+	require.Error(t, err)
+
+	forceUnlock = true
+	unlock, err := lockStack(ctx, "production", "deploy")
+	require.NoError(t, err)
+
+	current, err := locker.Current(ctx, "production")
+	require.NoError(t, err)
+	assert.Equal(t, "bob@ci", current.Owner)
+
+	// The broken owner must not release the new holder's lock
+	assert.Error(t, stale.Release(ctx, held))
+
+	unlock()
+	current, err = locker.Current(ctx, "production")
+	require.NoError(t, err)
+	assert.Nil(t, current)
+}
+
+func TestStackLocker_ExpiredLockIsReplaced(t *testing.T) {
+	ctx := context.Background()
+	store := &operations.FileLockStore{Dir: t.TempDir()}
+
+	stale := operations.NewStackLocker(store)
+	stale.Owner = "alice@laptop"
+	stale.TTL = -time.Minute
+	_, _, err := stale.Acquire(ctx, "production", "deploy", false)
+	require.NoError(t, err)
+
+	locker := operations.NewStackLocker(store)
+	locker.Owner = "bob@ci"
+	held, broken, err := locker.Acquire(ctx, "production", "deploy", false)
+	require.NoError(t, err)
+	require.NotNil(t, broken)
+	assert.Equal(t, "alice@laptop", broken.Owner)
+	assert.NoError(t, locker.Release(ctx, held))
+}
+
+func TestForceUnlockFlag_OnMutatingCommands(t *testing.T) {
+	for _, c := range []*cobra.Command{deployCmd, destroyCmd, refreshCmd, resizeNodeCmd, clusterImportCmd, vpnJoinCmd, vpnLeaveCmd} {
+		assert.NotNil(t, c.Flags().Lookup("force-unlock"), c.CommandPath())
+	}
+
+	assert.Nil(t, statusCmd.Flags().Lookup("force-unlock"), "read-only commands do not lock")
+}
@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
 	"github.com/spf13/cobra"
 
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+	"github.com/chalkan3/sloth-kubernetes/pkg/provisioning"
+	"github.com/chalkan3/sloth-kubernetes/pkg/provisioning/upgrades"
 	"github.com/chalkan3/sloth-kubernetes/pkg/upgrade"
 )
 
@@ -79,6 +86,14 @@ var upgradeApplyCmd = &cobra.Command{
 	Short: "Execute cluster upgrade",
 	Long: `Execute the upgrade plan on the cluster.
 
+The upgrade follows the upgrade section of the configuration stored with the
+stack: masters are upgraded first and one at a time, then workers
+maxUnavailable at a time. Each node is drained (within drainTimeout), has
+RKE2 reinstalled at the target version over SSH, is uncordoned and is waited
+on until Ready. With autoRollback the nodes already upgraded return to the
+current version when a node fails; with pauseOnFailure the upgrade stops
+there instead. --strategy overrides the stored strategy.
+
 Use --dry-run to simulate the upgrade without making changes.
 Use --force to skip confirmation prompts.`,
 	RunE: runUpgradeApply,
@@ -124,7 +139,7 @@ func init() {
 
 	// Upgrade apply flags
 	upgradeApplyCmd.Flags().StringVar(&upgradeTargetVersion, "to", "", "Target Kubernetes version (required)")
-	upgradeApplyCmd.Flags().StringVar(&upgradeStrategy, "strategy", "rolling", "Upgrade strategy (rolling, blue-green, canary, in-place)")
+	upgradeApplyCmd.Flags().StringVar(&upgradeStrategy, "strategy", "rolling", "Upgrade strategy (rolling, canary; default: upgrade.strategy, or rolling)")
 	upgradeApplyCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "Simulate upgrade without making changes")
 	upgradeApplyCmd.Flags().BoolVarP(&upgradeVerbose, "verbose", "v", false, "Show verbose output")
 	upgradeApplyCmd.Flags().BoolVar(&upgradeForce, "force", false, "Skip confirmation prompts")
@@ -138,6 +153,8 @@ func init() {
 	upgradeRollbackCmd.Flags().BoolVar(&upgradeForce, "force", false, "Skip confirmation prompts")
 	upgradeRollbackCmd.Flags().BoolVarP(&upgradeVerbose, "verbose", "v", false, "Show verbose output")
 	upgradeRollbackCmd.Flags().StringVar(&upgradeKubeconfig, "kubeconfig", "", "Path to kubeconfig file")
+	addForceUnlockFlag(upgradeApplyCmd)
+	addForceUnlockFlag(upgradeRollbackCmd)
 
 	// Versions flags
 	upgradeVersionsCmd.Flags().StringVar(&upgradeKubeconfig, "kubeconfig", "", "Path to kubeconfig file")
@@ -211,12 +228,40 @@ func runUpgradeApply(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if !upgradeDryRun {
+		unlock, err := lockStack(context.Background(), targetStack, "upgrade")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	outputs, err := upgradeStackOutputs(context.Background(), targetStack)
+	if err != nil {
+		return err
+	}
+	stored, err := parseStoredClusterConfig(outputs)
+	if err != nil {
+		return err
+	}
+	if stored.Kubernetes.Distribution != "" && stored.Kubernetes.Distribution != "rke2" {
+		return fmt.Errorf("upgrade apply supports RKE2 clusters; this cluster runs %s", stored.Kubernetes.Distribution)
+	}
+	strategyOverride := ""
+	if cmd.Flags().Changed("strategy") {
+		strategyOverride = upgradeStrategy
+	}
+	settings, err := upgradeSettings(stored.Upgrade, strategyOverride)
+	if err != nil {
+		return err
+	}
+
 	manager, err := createUpgradeManager(targetStack)
 	if err != nil {
 		return err
 	}
 
-	strategy := upgrade.UpgradeStrategy(upgradeStrategy)
+	strategy := upgrade.UpgradeStrategy(settings.Strategy)
 	plan, err := manager.CreatePlan(upgradeTargetVersion, strategy)
 	if err != nil {
 		return fmt.Errorf("failed to create upgrade plan: %w", err)
@@ -255,7 +300,7 @@ func runUpgradeApply(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	startTime := time.Now()
-	result, err := manager.Execute(plan)
+	result, err := executeUpgradePlan(context.Background(), targetStack, outputs, plan, settings)
 	if err != nil {
 		// Record failed upgrade
 		operations.RecordUpgradeOperation(targetStack, "upgrade", plan.CurrentVersion, plan.TargetVersion, string(plan.Strategy), "failed", len(plan.Nodes), 0, len(plan.Nodes), time.Since(startTime), err)
@@ -281,10 +326,262 @@ func runUpgradeApply(cmd *cobra.Command, args []string) error {
 	if result.Status == upgrade.StatusFailed {
 		return fmt.Errorf("upgrade completed with failures")
 	}
+	if result.Status == upgrade.StatusRolledBack {
+		return fmt.Errorf("upgrade failed and was rolled back to %s", result.PreviousVersion)
+	}
 
 	return nil
 }
 
+// upgradeStackOutputs returns the outputs of the stack being upgraded
+func upgradeStackOutputs(ctx context.Context, stack string) (auto.OutputMap, error) {
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+	return outputs, nil
+}
+
+// upgradeSettings returns the stored upgrade settings with the strategy
+// override applied. Only the strategies that upgrade nodes in place are
+// supported; blue-green and surge would need new nodes provisioned.
+func upgradeSettings(stored *config.UpgradeConfig, strategy string) (*config.UpgradeConfig, error) {
+	settings := &config.UpgradeConfig{}
+	if stored != nil {
+		*settings = *stored
+	}
+	if strategy != "" {
+		settings.Strategy = strategy
+	}
+	if settings.Strategy == "" {
+		settings.Strategy = string(upgrade.StrategyRolling)
+	}
+
+	switch upgrade.UpgradeStrategy(settings.Strategy) {
+	case upgrade.StrategyRolling, upgrade.StrategyCanary:
+		return settings, nil
+	default:
+		return nil, fmt.Errorf("upgrade strategy %s is not supported by upgrade apply; use rolling or canary", settings.Strategy)
+	}
+}
+
+// executeUpgradePlan upgrades the nodes of plan through the provisioning
+// upgrade orchestrator, draining with kubectl and reinstalling RKE2 over SSH
+func executeUpgradePlan(ctx context.Context, stack string, outputs auto.OutputMap, plan *upgrade.UpgradePlan, settings *config.UpgradeConfig) (*upgrade.UpgradeResult, error) {
+	for _, check := range plan.PreChecks {
+		if check.Required && !check.Passed {
+			return nil, fmt.Errorf("required pre-check failed: %s - %s", check.Name, check.Message)
+		}
+	}
+
+	stackNodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse node outputs: %w", err)
+	}
+	nodes, byName, err := upgradeNodes(plan, stackNodes)
+	if err != nil {
+		return nil, fmt.Errorf("%w in stack '%s'", err, stack)
+	}
+
+	kubeconfigPath, err := GetKubeconfigFromStack(stack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig from stack '%s': %w", stack, err)
+	}
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	executor := execCommandExecutor{}
+
+	orch := upgrades.NewOrchestrator(&upgrades.OrchestratorConfig{
+		UpgradeConfig: settings,
+		Drainer:       upgrades.NewKubectlDrainer(kubeconfigPath, executor),
+		NodeUpgrader: &rke2NodeUpgrader{
+			nodes: byName,
+			ssh: func(node NodeInfo, script string) error {
+				return runNodeScript(node, script, sshKeyPath, bastionIP)
+			},
+		},
+		HealthChecker:  upgrades.NewKubectlHealthChecker(kubeconfigPath, executor),
+		EventEmitter:   upgradeEventPrinter{},
+		CurrentVersion: plan.CurrentVersion,
+	})
+
+	result := &upgrade.UpgradeResult{
+		ClusterName:     plan.ClusterName,
+		FromVersion:     plan.CurrentVersion,
+		ToVersion:       plan.TargetVersion,
+		PreviousVersion: plan.CurrentVersion,
+		NewVersion:      plan.TargetVersion,
+		Strategy:        plan.Strategy,
+		StartedAt:       time.Now(),
+		TotalNodes:      len(nodes),
+	}
+
+	upgradePlan, err := orch.Plan(ctx, plan.TargetVersion, nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan upgrade: %w", err)
+	}
+	execErr := orch.Execute(ctx, upgradePlan)
+	status, err := orch.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result.CompletedAt = time.Now()
+	result.Duration = result.CompletedAt.Sub(result.StartedAt)
+	upgradeResultFromStatus(result, upgradePlan, status, execErr)
+	return result, nil
+}
+
+// upgradeNodes returns the stack nodes in plan, labelled with their roles
+// for the upgrade orchestrator, and indexes them by name
+func upgradeNodes(plan *upgrade.UpgradePlan, stackNodes []NodeInfo) ([]*providers.NodeOutput, map[string]NodeInfo, error) {
+	byName := make(map[string]NodeInfo, len(stackNodes))
+	for _, node := range stackNodes {
+		byName[node.Name] = node
+	}
+
+	nodes := make([]*providers.NodeOutput, 0, len(plan.Nodes))
+	for _, planned := range plan.Nodes {
+		info, ok := byName[planned.NodeName]
+		if !ok {
+			return nil, nil, fmt.Errorf("node '%s' not found", planned.NodeName)
+		}
+		nodes = append(nodes, &providers.NodeOutput{
+			Name:        info.Name,
+			Provider:    info.Provider,
+			Region:      info.Region,
+			Size:        info.Size,
+			Labels:      map[string]string{"role": strings.Join(info.Roles, ",")},
+			WireGuardIP: info.WireGuardIP,
+			SSHUser:     info.SSHUser,
+		})
+	}
+	return nodes, byName, nil
+}
+
+// upgradeResultFromStatus fills in the outcome of an upgrade from the
+// orchestrator's final status
+func upgradeResultFromStatus(result *upgrade.UpgradeResult, plan *provisioning.UpgradePlan, status *provisioning.UpgradeStatus, execErr error) {
+	completed := make(map[string]bool, len(status.CompletedNodes))
+	for _, name := range status.CompletedNodes {
+		completed[name] = true
+	}
+	failed := make(map[string]bool, len(status.FailedNodes))
+	for _, name := range status.FailedNodes {
+		failed[name] = true
+	}
+
+	rolledBack := status.Phase == "rolled_back"
+	for _, node := range plan.Nodes {
+		nodeStatus := upgrade.NodeUpgradeStatus{
+			NodeName:       node.NodeName,
+			Name:           node.NodeName,
+			CurrentVersion: plan.CurrentVersion,
+			TargetVersion:  plan.TargetVersion,
+			Status:         upgrade.NodeStatusSkipped,
+		}
+		switch {
+		case failed[node.NodeName]:
+			nodeStatus.Status = upgrade.NodeStatusFailed
+			result.NodesFailed++
+		case completed[node.NodeName] && rolledBack:
+			nodeStatus.Status = upgrade.StatusRolledBack
+		case completed[node.NodeName]:
+			nodeStatus.Status = upgrade.NodeStatusCompleted
+			result.NodesUpgraded++
+		}
+		result.NodeResults = append(result.NodeResults, nodeStatus)
+	}
+
+	switch {
+	case execErr == nil:
+		result.Status = upgrade.StatusCompleted
+	case rolledBack:
+		result.Status = upgrade.StatusRolledBack
+	default:
+		result.Status = upgrade.StatusFailed
+	}
+	result.Success = execErr == nil
+	if execErr != nil {
+		result.Errors = append(result.Errors, execErr.Error())
+	}
+	if status.Phase == "paused_on_failure" {
+		result.Warnings = append(result.Warnings, "Upgrade paused on failure; the remaining nodes were not upgraded")
+	}
+}
+
+// rke2NodeUpgrader reinstalls RKE2 on a node over SSH; ssh is replaced in
+// tests
+type rke2NodeUpgrader struct {
+	nodes map[string]NodeInfo
+	ssh   func(node NodeInfo, script string) error
+}
+
+func (u *rke2NodeUpgrader) Upgrade(ctx context.Context, node *providers.NodeOutput, targetVersion string) error {
+	info, ok := u.nodes[node.Name]
+	if !ok {
+		return fmt.Errorf("node '%s' not found in stack", node.Name)
+	}
+	return u.ssh(info, rke2UpgradeScript(targetVersion, isMasterNode(info)))
+}
+
+// rke2UpgradeScript reinstalls RKE2 at version and restarts its service
+func rke2UpgradeScript(version string, master bool) string {
+	installType, service := "agent", "rke2-agent"
+	if master {
+		installType, service = "server", "rke2-server"
+	}
+	return fmt.Sprintf(`set -e
+curl -sfL https://get.rke2.io | INSTALL_RKE2_VERSION=%s INSTALL_RKE2_TYPE=%s sh -
+systemctl restart %s
+`, version, installType, service)
+}
+
+// execCommandExecutor runs the upgrade's kubectl commands locally
+type execCommandExecutor struct{}
+
+func (execCommandExecutor) Execute(ctx context.Context, command string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, command, args...).CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("%s %s: %w: %s", command, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// upgradeEventPrinter prints the upgrade orchestrator's progress as it happens
+type upgradeEventPrinter struct{}
+
+func (upgradeEventPrinter) Emit(event provisioning.Event) {
+	data, _ := event.Data.(map[string]interface{})
+	switch event.Type {
+	case "node_upgraded":
+		color.Green("[OK] %v upgraded to %v", data["node_name"], data["target_version"])
+	case "upgrade_paused_on_failure":
+		color.Yellow("[PAUSED] %v", data["error"])
+	case "upgrade_auto_rollback_triggered":
+		color.Yellow("[ROLLBACK] %v", data["error"])
+	case "node_rolled_back":
+		color.Yellow("[ROLLBACK] %v rolled back", data["node_name"])
+	}
+}
+
+func (upgradeEventPrinter) Subscribe(eventType string, handler provisioning.EventHandler) string {
+	return ""
+}
+
+func (upgradeEventPrinter) Unsubscribe(subscriptionID string) {}
+
 func runUpgradeRollback(cmd *cobra.Command, args []string) error {
 	printHeader("Cluster Rollback")
 
@@ -311,6 +608,12 @@ func runUpgradeRollback(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	unlock, err := lockStack(context.Background(), targetStack, "rollback")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	fmt.Println()
 	color.Cyan("Starting rollback...")
 	fmt.Println()
@@ -561,6 +864,9 @@ func printUpgradeResult(result *upgrade.UpgradeResult) {
 			case upgrade.NodeStatusSkipped:
 				statusIcon = "[SKIP]"
 				statusColor = color.New(color.FgYellow)
+			case upgrade.StatusRolledBack:
+				statusIcon = "[ROLLBACK]"
+				statusColor = color.New(color.FgYellow)
 			default:
 				statusIcon = "[?]"
 				statusColor = color.New(color.FgWhite)
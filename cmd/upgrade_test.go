@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/provisioning"
+	"github.com/chalkan3/sloth-kubernetes/pkg/provisioning/upgrades"
+	"github.com/chalkan3/sloth-kubernetes/pkg/upgrade"
+)
+
+// recordingExecutor records the kubectl commands of an upgrade and reports
+// every node Ready
+type recordingExecutor struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (e *recordingExecutor) Execute(ctx context.Context, command string, args ...string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, command+" "+strings.Join(args, " "))
+	return "True", nil
+}
+
+func TestUpgradeApplyCmd_Flags(t *testing.T) {
+	assert.NotNil(t, upgradeApplyCmd.Flags().Lookup("strategy"))
+	assert.Contains(t, upgradeApplyCmd.Long, "maxUnavailable")
+}
+
+func TestUpgradeSettings(t *testing.T) {
+	stored := &config.UpgradeConfig{Strategy: "canary", MaxUnavailable: 2, DrainTimeout: 90, AutoRollback: true}
+
+	settings, err := upgradeSettings(stored, "")
+	require.NoError(t, err)
+	assert.Equal(t, "canary", settings.Strategy)
+	assert.Equal(t, 2, settings.MaxUnavailable)
+	assert.True(t, settings.AutoRollback)
+
+	settings, err = upgradeSettings(stored, "rolling")
+	require.NoError(t, err)
+	assert.Equal(t, "rolling", settings.Strategy)
+	assert.Equal(t, "canary", stored.Strategy, "stored settings are not modified")
+
+	settings, err = upgradeSettings(nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "rolling", settings.Strategy)
+
+	_, err = upgradeSettings(&config.UpgradeConfig{Strategy: "blue-green"}, "")
+	assert.EqualError(t, err, "upgrade strategy blue-green is not supported by upgrade apply; use rolling or canary")
+}
+
+func TestRKE2UpgradeScript(t *testing.T) {
+	server := rke2UpgradeScript("v1.29.1+rke2r1", true)
+	assert.Contains(t, server, "INSTALL_RKE2_VERSION=v1.29.1+rke2r1 INSTALL_RKE2_TYPE=server")
+	assert.Contains(t, server, "systemctl restart rke2-server")
+
+	agent := rke2UpgradeScript("v1.29.1+rke2r1", false)
+	assert.Contains(t, agent, "INSTALL_RKE2_TYPE=agent")
+	assert.Contains(t, agent, "systemctl restart rke2-agent")
+}
+
+func TestUpgradeNodes(t *testing.T) {
+	plan := &upgrade.UpgradePlan{Nodes: []upgrade.NodeUpgradePlan{{NodeName: "masters-1"}, {NodeName: "workers-1"}}}
+	stackNodes := []NodeInfo{
+		{Name: "workers-1", Roles: []string{"worker"}},
+		{Name: "masters-1", Roles: []string{"master", "etcd"}},
+		{Name: "workers-2", Roles: []string{"worker"}},
+	}
+
+	nodes, byName, err := upgradeNodes(plan, stackNodes)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2, "only the planned nodes are upgraded")
+	assert.Equal(t, "masters-1", nodes[0].Name)
+	assert.Equal(t, "master,etcd", nodes[0].Labels["role"])
+	assert.Len(t, byName, 3)
+
+	plan.Nodes = append(plan.Nodes, upgrade.NodeUpgradePlan{NodeName: "ghost"})
+	_, _, err = upgradeNodes(plan, stackNodes)
+	assert.EqualError(t, err, "node 'ghost' not found")
+}
+
+func TestRKE2NodeUpgrader_ThroughUpgradeOrchestrator(t *testing.T) {
+	plan := &upgrade.UpgradePlan{
+		CurrentVersion: "v1.28.5+rke2r1",
+		TargetVersion:  "v1.29.1+rke2r1",
+		Nodes:          []upgrade.NodeUpgradePlan{{NodeName: "workers-1"}, {NodeName: "masters-1"}},
+	}
+	nodes, byName, err := upgradeNodes(plan, []NodeInfo{
+		{Name: "workers-1", Roles: []string{"worker"}},
+		{Name: "masters-1", Roles: []string{"master"}},
+	})
+	require.NoError(t, err)
+
+	executor := &recordingExecutor{}
+	var scripts []string
+	orch := upgrades.NewOrchestrator(&upgrades.OrchestratorConfig{
+		UpgradeConfig: &config.UpgradeConfig{Strategy: "rolling", DrainTimeout: 90},
+		Drainer:       upgrades.NewKubectlDrainer("/tmp/kubeconfig", executor),
+		NodeUpgrader: &rke2NodeUpgrader{
+			nodes: byName,
+			ssh: func(node NodeInfo, script string) error {
+				scripts = append(scripts, node.Name+": "+script)
+				return nil
+			},
+		},
+		HealthChecker:  upgrades.NewKubectlHealthChecker("/tmp/kubeconfig", executor),
+		CurrentVersion: plan.CurrentVersion,
+	})
+
+	upgradePlan, err := orch.Plan(context.Background(), plan.TargetVersion, nodes)
+	require.NoError(t, err)
+	require.NoError(t, orch.Execute(context.Background(), upgradePlan))
+
+	require.Len(t, scripts, 2)
+	assert.True(t, strings.HasPrefix(scripts[0], "masters-1: "), "masters upgrade first")
+	assert.Contains(t, scripts[0], "INSTALL_RKE2_TYPE=server")
+	assert.Contains(t, scripts[1], "INSTALL_RKE2_TYPE=agent")
+	assert.Equal(t, "kubectl --kubeconfig /tmp/kubeconfig cordon masters-1", executor.calls[0])
+	assert.Equal(t, "kubectl --kubeconfig /tmp/kubeconfig drain masters-1 --ignore-daemonsets --delete-emptydir-data --force --timeout=90s", executor.calls[1])
+
+	upgrader := &rke2NodeUpgrader{nodes: map[string]NodeInfo{}}
+	assert.ErrorContains(t, upgrader.Upgrade(context.Background(), nodes[0], "v1.29.1"), "not found")
+}
+
+func TestUpgradeResultFromStatus(t *testing.T) {
+	plan := &provisioning.UpgradePlan{
+		CurrentVersion: "v1.28.5",
+		TargetVersion:  "v1.29.1",
+		Nodes: []provisioning.UpgradeNodePlan{
+			{NodeName: "masters-1"}, {NodeName: "workers-1"}, {NodeName: "workers-2"},
+		},
+	}
+
+	result := &upgrade.UpgradeResult{}
+	upgradeResultFromStatus(result, plan, &provisioning.UpgradeStatus{
+		Phase:          "paused_on_failure",
+		CompletedNodes: []string{"masters-1"},
+		FailedNodes:    []string{"workers-1"},
+	}, errors.New("failed to upgrade node workers-1"))
+
+	assert.Equal(t, upgrade.StatusFailed, result.Status)
+	assert.Equal(t, 1, result.NodesUpgraded)
+	assert.Equal(t, 1, result.NodesFailed)
+	assert.Equal(t, []upgrade.UpgradeStatus{upgrade.NodeStatusCompleted, upgrade.NodeStatusFailed, upgrade.NodeStatusSkipped},
+		[]upgrade.UpgradeStatus{result.NodeResults[0].Status, result.NodeResults[1].Status, result.NodeResults[2].Status})
+	assert.NotEmpty(t, result.Warnings)
+
+	result = &upgrade.UpgradeResult{}
+	upgradeResultFromStatus(result, plan, &provisioning.UpgradeStatus{
+		Phase:          "rolled_back",
+		CompletedNodes: []string{"masters-1"},
+		FailedNodes:    []string{"workers-1"},
+	}, errors.New("upgrade failed, rolled back"))
+
+	assert.Equal(t, upgrade.StatusRolledBack, result.Status)
+	assert.Equal(t, 0, result.NodesUpgraded)
+	assert.Equal(t, upgrade.StatusRolledBack, result.NodeResults[0].Status)
+}
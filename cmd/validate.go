@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
+	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator"
 	"github.com/chalkan3/sloth-kubernetes/internal/validation"
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
@@ -16,16 +18,21 @@ import (
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate cluster configuration file",
-	Long: `Validate that your cluster configuration Lisp file is correct and ready for deployment.
+	Long: `Validate that your cluster configuration file is correct and ready for deployment.
+
+Lisp, YAML (.yaml/.yml) and JSON (.json) configs are accepted; the format
+is picked from the file extension.
 
 This command performs comprehensive validation including:
-  • Lisp S-expression syntax and structure
+  • Lisp S-expression (or YAML/JSON) syntax and structure
   • Required fields and metadata
   • Node distribution (masters/workers)
   • Provider configuration and credentials
   • Network and WireGuard VPN settings
   • DNS configuration
   • Resource limits and quotas
+  • Pre-flight checks: credentials, CIDR overlaps, duplicate node names
+    and HA quorum
 
 Use this before 'deploy' to catch configuration errors early.`,
 	Example: `  # Validate configuration file
@@ -41,6 +48,8 @@ Use this before 'deploy' to catch configuration errors early.`,
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().BoolVar(&strictValidation, "strict", false, "Treat ambiguous configuration (such as both VPNs enabled) as an error")
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
@@ -67,23 +76,26 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	color.Cyan("📄 Loading configuration: %s", configPath)
 	fmt.Println()
 
-	// Load configuration
-	cfg, err := config.LoadFromLisp(configPath)
+	// Load configuration in the format its extension implies (Lisp by default)
+	configFormat := config.DetectConfigFormat(configPath)
+	cfg, err := config.ReadConfigFile(configPath, configFormat)
 	if err != nil {
-		color.Red("❌ Failed to parse Lisp configuration")
+		color.Red("❌ Failed to parse %s configuration", configFormatName(configFormat))
 		fmt.Println()
 		color.Yellow("Error details:")
 		fmt.Printf("  %v\n", err)
 		fmt.Println()
-		color.Yellow("💡 Common issues:")
-		fmt.Println("  • Check S-expression syntax (matching parentheses)")
-		fmt.Println("  • Ensure all required fields are present")
-		fmt.Println("  • Verify quotes around strings")
-		fmt.Println()
+		if configFormat == config.FormatLisp {
+			color.Yellow("💡 Common issues:")
+			fmt.Println("  • Check S-expression syntax (matching parentheses)")
+			fmt.Println("  • Ensure all required fields are present")
+			fmt.Println("  • Verify quotes around strings")
+			fmt.Println()
+		}
 		return fmt.Errorf("failed to parse configuration: %w", err)
 	}
 
-	color.Green("✅ Lisp syntax is valid")
+	color.Green("✅ %s syntax is valid", configFormatName(configFormat))
 	fmt.Println()
 
 	// Validate metadata
@@ -189,6 +201,17 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	vpnWarning, err := validation.ValidateVPNSelection(cfg, strictValidation)
+	if err != nil {
+		color.Red("❌ VPN validation failed")
+		fmt.Printf("  %v\n", err)
+		fmt.Println()
+		return err
+	}
+	if vpnWarning != "" {
+		color.Yellow("⚠️  %s", vpnWarning)
+	}
+
 	if cfg.Network.WireGuard != nil && cfg.Network.WireGuard.Enabled {
 		color.Green("✅ WireGuard VPN: enabled")
 		if cfg.Network.WireGuard.Create {
@@ -254,6 +277,22 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Println()
 
+	// Pre-flight checks: credentials, CIDR overlaps, node names and quorum
+	printHeader("🧪 Pre-flight Checks")
+	fmt.Println()
+
+	if errs := orchestrator.ValidateConfig(cfg); len(errs) > 0 {
+		color.Red("❌ %d pre-flight check(s) failed", len(errs))
+		for _, e := range errs {
+			fmt.Printf("  • %v\n", e)
+		}
+		fmt.Println()
+		return fmt.Errorf("pre-flight checks failed: %w", errors.Join(errs...))
+	}
+
+	color.Green("✅ Credentials, CIDRs, node names and quorum: valid")
+	fmt.Println()
+
 	// Overall validation
 	printHeader("✨ Overall Validation")
 	fmt.Println()
@@ -289,9 +328,9 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
-	// Record the validation (6 checks: syntax, metadata, providers, nodes, network, kubernetes)
+	// Record the validation (7 checks: syntax, metadata, providers, nodes, network, kubernetes, pre-flight)
 	// Note: validate command doesn't require a stack, but we try to use it if available for recording
-	totalChecks := 6
+	totalChecks := 7
 	passedChecks := totalChecks
 	warningChecks := len(warnings)
 	if stackName != "" {
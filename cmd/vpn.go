@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/base64"
@@ -9,8 +10,12 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
@@ -19,10 +24,13 @@ import (
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/curve25519"
 
+	"github.com/chalkan3/sloth-kubernetes/internal/common"
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+	"github.com/chalkan3/sloth-kubernetes/pkg/qrcode"
 	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
 	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/wireguard"
 )
 
 // VPNMode represents the type of VPN being used
@@ -53,9 +61,12 @@ func detectVPNMode(outputs auto.OutputMap) (VPNMode, *config.ClusterConfig) {
 		if configStr != "" && configStr != "<nil>" {
 			var cfg config.ClusterConfig
 			if err := json.Unmarshal([]byte(configStr), &cfg); err == nil {
-				// Check for Tailscale enabled
-				if cfg.Network.Tailscale != nil && cfg.Network.Tailscale.Enabled {
+				// Check which VPN the deployment selected
+				switch cfg.Network.SelectedVPN() {
+				case config.VPNTailscale:
 					return VPNModeTailscale, &cfg
+				case config.VPNWireGuard:
+					return VPNModeWireGuard, &cfg
 				}
 				// Check mode field
 				if cfg.Network.Mode == "tailscale" {
@@ -77,15 +88,17 @@ func detectVPNMode(outputs auto.OutputMap) (VPNMode, *config.ClusterConfig) {
 
 // TailscalePeerInfo represents a peer in the Tailscale network
 type TailscalePeerInfo struct {
-	ID        string
-	PublicKey string
-	Hostname  string
-	TailnetIP string
-	Online    bool
-	LastSeen  time.Time
-	OS        string
-	ExitNode  bool
-	Relay     string
+	ID        string    `json:"id"`
+	PublicKey string    `json:"publicKey"`
+	Hostname  string    `json:"hostname"`
+	TailnetIP string    `json:"tailnetIp"`
+	Online    bool      `json:"online"`
+	LastSeen  time.Time `json:"lastSeen,omitzero"`
+	// LastHandshake is the last WireGuard handshake with the peer (zero if none)
+	LastHandshake time.Time `json:"lastHandshake,omitzero"`
+	OS            string    `json:"os,omitempty"`
+	ExitNode      bool      `json:"exitNode"`
+	Relay         string    `json:"relay,omitempty"`
 }
 
 var (
@@ -96,11 +109,24 @@ var (
 	vpnJoinInstall bool
 
 	// VPN leave command flags
-	vpnLeaveIP string
+	vpnLeaveIP    string
+	vpnLeaveLabel string
+
+	// VPN status command flags
+	vpnStatusWatch int
+
+	// VPN test command flags
+	vpnTestMatrix     bool
+	vpnTestStaleAfter time.Duration
+
+	// Output format shared by the status, peers, test and verify-config commands
+	vpnOutputFormat string
 
 	// VPN client config flags
 	vpnConfigOutput string
 	vpnConfigQR     bool
+	vpnConfigPeer   string
+	vpnConfigLabel  string
 )
 
 var vpnCmd = &cobra.Command{
@@ -112,9 +138,20 @@ var vpnCmd = &cobra.Command{
 var vpnStatusCmd = &cobra.Command{
 	Use:   "status [stack-name]",
 	Short: "Show VPN status and tunnels",
-	Long:  `Display the current status of the WireGuard VPN mesh including all tunnels`,
+	Long: `Display the current status of the WireGuard VPN mesh including all tunnels.
+With --watch the status is refreshed until Ctrl+C, marking peers that came
+online or went offline since the previous refresh.`,
 	Example: `  # Show VPN status for production stack
-  sloth-kubernetes vpn status production`,
+  sloth-kubernetes vpn status production
+
+  # Refresh every 5 seconds
+  sloth-kubernetes vpn status production --watch
+
+  # Refresh every 30 seconds
+  sloth-kubernetes vpn status production --watch=30
+
+  # Print the status as JSON
+  sloth-kubernetes vpn status production --output json`,
 	RunE: runVPNStatus,
 }
 
@@ -123,7 +160,10 @@ var vpnPeersCmd = &cobra.Command{
 	Short: "List all VPN peers",
 	Long:  `Display all nodes in the VPN mesh with their public keys and endpoints`,
 	Example: `  # List VPN peers
-  sloth-kubernetes vpn peers production`,
+  sloth-kubernetes vpn peers production
+
+  # List peers as JSON, e.g. to check handshakes in CI
+  sloth-kubernetes vpn peers production --output json`,
 	RunE: runVPNPeers,
 }
 
@@ -139,9 +179,24 @@ var vpnConfigCmd = &cobra.Command{
 var vpnTestCmd = &cobra.Command{
 	Use:   "test [stack-name]",
 	Short: "Test VPN connectivity",
-	Long:  `Test connectivity between all nodes in the VPN mesh`,
+	Long: `Test connectivity between all nodes in the VPN mesh.
+With --matrix the round-trip time of every ping is shown as an NxN matrix,
+followed by the failed and slowest links, to spot one-directional or
+high-latency links.
+
+In WireGuard mode every node must have a recent handshake with every other
+node; node→peer pairs without one within --stale-after are reported.`,
 	Example: `  # Test VPN connectivity
-  sloth-kubernetes vpn test production`,
+  sloth-kubernetes vpn test production
+
+  # Show the latency between every pair of nodes
+  sloth-kubernetes vpn test production --matrix
+
+  # Allow up to 5 minutes since the last handshake
+  sloth-kubernetes vpn test production --stale-after 5m
+
+  # Report the result of every ping as JSON
+  sloth-kubernetes vpn test production --output json`,
 	RunE: runVPNTest,
 }
 
@@ -173,32 +228,49 @@ var vpnLeaveCmd = &cobra.Command{
   sloth-kubernetes vpn leave production
 
   # Remove a specific peer by IP
-  sloth-kubernetes vpn leave production --vpn-ip 10.8.0.100`,
+  sloth-kubernetes vpn leave production --vpn-ip 10.8.0.100
+
+  # Remove a registered peer by label
+  sloth-kubernetes vpn leave production --label ci-server`,
 	RunE: runVPNLeave,
 }
 
 var vpnClientConfigCmd = &cobra.Command{
 	Use:   "client-config [stack-name]",
 	Short: "Generate WireGuard client configuration",
-	Long:  `Generate a WireGuard configuration file for connecting to the VPN mesh`,
+	Long: `Generate a WireGuard configuration file for connecting to the VPN mesh.
+The client keypair is generated locally; only the public key is sent to the nodes.`,
 	Example: `  # Generate client config
   sloth-kubernetes vpn client-config production
 
   # Save to file
   sloth-kubernetes vpn client-config production --output client.conf
 
-  # Generate QR code for mobile
-  sloth-kubernetes vpn client-config production --qr`,
+  # Show a QR code to scan with the WireGuard mobile app
+  sloth-kubernetes vpn client-config production --label phone --qr
+
+  # Save the QR code as an image instead of a .conf file
+  sloth-kubernetes vpn client-config production --label phone --output phone.png
+
+  # Use a single node as the entry point for the whole VPN subnet
+  sloth-kubernetes vpn client-config production --peer master-1 --output laptop.conf`,
 	RunE: runVPNClientConfig,
 }
 
 var vpnConnectCmd = &cobra.Command{
 	Use:   "connect [stack-name]",
-	Short: "Connect local machine to VPN (Tailscale only)",
-	Long: `Connect your local machine to the Tailscale VPN mesh using an embedded client.
-This does not require installing Tailscale system-wide - the client runs embedded in sloth-kubernetes.
-
-Note: This command only works with Tailscale/Headscale mode. For WireGuard, use 'vpn join'.`,
+	Short: "Connect local machine to VPN",
+	Long: `Connect your local machine to the VPN mesh using an embedded client.
+This does not require installing Tailscale or WireGuard system-wide - the client runs embedded in sloth-kubernetes.
+
+In Tailscale/Headscale mode the machine joins the tailnet with an ephemeral key.
+
+In WireGuard mode the machine is registered as a peer on every node, like 'vpn join',
+keeping its VPN IP across reconnects (the hostname is the peer label). The tunnel uses
+kernel WireGuard through wg-quick when running as root with wireguard-tools installed,
+and the embedded userspace implementation (wireguard-go) otherwise. Userspace tunnels
+still create a TUN interface, which needs root or CAP_NET_ADMIN on Linux. The tunnel is
+torn down on disconnect.`,
 	Example: `  # Connect to Tailscale mesh (foreground)
   sloth-kubernetes vpn connect my-cluster
 
@@ -206,15 +278,27 @@ Note: This command only works with Tailscale/Headscale mode. For WireGuard, use
   sloth-kubernetes vpn connect my-cluster --daemon
 
   # Connect with custom hostname
-  sloth-kubernetes vpn connect my-cluster --hostname my-laptop --daemon`,
+  sloth-kubernetes vpn connect my-cluster --hostname my-laptop --daemon
+
+  # Route all traffic through a cluster node (full tunnel)
+  sloth-kubernetes vpn connect my-cluster --exit-node worker-1
+
+  # Force the userspace WireGuard implementation
+  sloth-kubernetes vpn connect my-cluster --userspace
+
+  # Replace a connection that is already running
+  sloth-kubernetes vpn connect my-cluster --daemon --force
+
+  # Check the daemon is healthy (exits non-zero if not)
+  sloth-kubernetes vpn connect my-cluster --status`,
 	RunE: runVPNConnect,
 }
 
 var vpnDisconnectCmd = &cobra.Command{
 	Use:   "disconnect [stack-name]",
-	Short: "Disconnect from VPN (Tailscale only)",
-	Long:  `Disconnect your local machine from the Tailscale VPN mesh and clean up local state.`,
-	Example: `  # Disconnect from Tailscale mesh
+	Short: "Disconnect from VPN",
+	Long:  `Disconnect your local machine from the VPN mesh joined with 'vpn connect' and clean up local state.`,
+	Example: `  # Disconnect from the mesh
   sloth-kubernetes vpn disconnect my-cluster`,
 	RunE: runVPNDisconnect,
 }
@@ -223,6 +307,10 @@ var vpnDisconnectCmd = &cobra.Command{
 var vpnConnectHostname string
 var vpnConnectDaemon bool
 var vpnConnectInternalDaemon bool // Internal flag for the actual daemon process
+var vpnConnectStatus bool
+var vpnConnectForce bool
+var vpnConnectUserspace bool
+var vpnConnectExitNode string
 
 func init() {
 	rootCmd.AddCommand(vpnCmd)
@@ -238,9 +326,25 @@ func init() {
 	vpnCmd.AddCommand(vpnConnectCmd)
 	vpnCmd.AddCommand(vpnDisconnectCmd)
 
-	// Connect flags (Tailscale)
+	// Output format for status, peers, test and verify-config. client-config keeps its own
+	// --output flag for the file path, which takes precedence there.
+	vpnCmd.PersistentFlags().StringVar(&vpnOutputFormat, "output", "table", "Output format for status, peers, test and verify-config: table|json")
+
+	// Status flags
+	vpnStatusCmd.Flags().IntVar(&vpnStatusWatch, "watch", 0, "Refresh the status every N seconds until Ctrl+C (--watch alone refreshes every 5)")
+	vpnStatusCmd.Flags().Lookup("watch").NoOptDefVal = "5"
+
+	// Test flags
+	vpnTestCmd.Flags().BoolVar(&vpnTestMatrix, "matrix", false, "Show the round-trip time between every pair of nodes and the worst links")
+	vpnTestCmd.Flags().DurationVar(&vpnTestStaleAfter, "stale-after", wireGuardOnlineWindow, "Flag WireGuard peers without a handshake for this long")
+
+	// Connect flags
 	vpnConnectCmd.Flags().StringVar(&vpnConnectHostname, "hostname", "", "Custom hostname for this machine in the tailnet")
 	vpnConnectCmd.Flags().BoolVar(&vpnConnectDaemon, "daemon", false, "Run in background (daemon mode)")
+	vpnConnectCmd.Flags().BoolVar(&vpnConnectStatus, "status", false, "Query the health of the running VPN daemon")
+	vpnConnectCmd.Flags().BoolVar(&vpnConnectForce, "force", false, "Replace a VPN connection already running for this stack")
+	vpnConnectCmd.Flags().BoolVar(&vpnConnectUserspace, "userspace", false, "Use userspace WireGuard even when kernel WireGuard is available (WireGuard mode)")
+	vpnConnectCmd.Flags().StringVar(&vpnConnectExitNode, "exit-node", "", "Route all traffic through this cluster node as a Tailscale exit node (Tailscale mode)")
 	vpnConnectCmd.Flags().BoolVar(&vpnConnectInternalDaemon, "_internal-daemon", false, "Internal flag for daemon process")
 	vpnConnectCmd.Flags().MarkHidden("_internal-daemon")
 
@@ -252,10 +356,15 @@ func init() {
 
 	// Leave flags
 	vpnLeaveCmd.Flags().StringVar(&vpnLeaveIP, "vpn-ip", "", "VPN IP of peer to remove")
+	vpnLeaveCmd.Flags().StringVar(&vpnLeaveLabel, "label", "", "Label of the registered peer to remove")
+	addForceUnlockFlag(vpnJoinCmd)
+	addForceUnlockFlag(vpnLeaveCmd)
 
 	// Client config flags
-	vpnClientConfigCmd.Flags().StringVar(&vpnConfigOutput, "output", "", "Output file path")
-	vpnClientConfigCmd.Flags().BoolVar(&vpnConfigQR, "qr", false, "Generate QR code for mobile devices")
+	vpnClientConfigCmd.Flags().StringVar(&vpnConfigOutput, "output", "", "Output file path; a .png path saves the config as a QR code image (default ./wg0-client.conf)")
+	vpnClientConfigCmd.Flags().BoolVar(&vpnConfigQR, "qr", false, "Print the config as a QR code for the WireGuard mobile app")
+	vpnClientConfigCmd.Flags().StringVar(&vpnConfigPeer, "peer", "", "Peer only with this node and route the VPN subnet through it")
+	vpnClientConfigCmd.Flags().StringVar(&vpnConfigLabel, "label", "", "Peer label/name (e.g., 'laptop', 'ci-server')")
 }
 
 func runVPNStatus(cmd *cobra.Command, args []string) error {
@@ -267,7 +376,19 @@ func runVPNStatus(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	printHeader(fmt.Sprintf("🔐 VPN Status - Stack: %s", stack))
+	if err := validateVPNOutputFormat(); err != nil {
+		return err
+	}
+	if vpnStatusWatch < 0 {
+		return fmt.Errorf("--watch interval must be a positive number of seconds")
+	}
+	if vpnStatusWatch > 0 && vpnJSONOutput() {
+		return fmt.Errorf("--watch cannot be combined with --output json")
+	}
+
+	if !vpnJSONOutput() {
+		printHeader(fmt.Sprintf("🔐 VPN Status - Stack: %s", stack))
+	}
 
 	// Create workspace with S3 support
 	workspace, err := createWorkspaceWithS3Support(ctx)
@@ -312,8 +433,23 @@ func runVPNStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if vpnStatusWatch > 0 {
+		return watchVPNStatus(ctx, stack, outputs, nodes, sshKeyPath, bastionEnabled, bastionIP, time.Duration(vpnStatusWatch)*time.Second)
+	}
+
+	var live *vpnLiveStatus
+	if vpnMode, _ := detectVPNMode(outputs); vpnMode == VPNModeTailscale && len(nodes) > 0 {
+		if status, peers := getTailscaleStatusFromNode(nodes, sshKeyPath, bastionEnabled, bastionIP); status != nil {
+			live = &vpnLiveStatus{tailscalePeers: peers}
+		}
+	}
+	report := buildVPNStatusReport(stack, outputs, nodes, live)
+
+	if vpnJSONOutput() {
+		return writeVPNJSON(report)
+	}
 	fmt.Println()
-	printVPNStatusTable(outputs, nodes, sshKeyPath, bastionEnabled, bastionIP)
+	printVPNStatusReport(report)
 
 	return nil
 }
@@ -327,7 +463,13 @@ func runVPNPeers(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	printHeader(fmt.Sprintf("👥 VPN Peers - Stack: %s", stack))
+	if err := validateVPNOutputFormat(); err != nil {
+		return err
+	}
+
+	if !vpnJSONOutput() {
+		printHeader(fmt.Sprintf("👥 VPN Peers - Stack: %s", stack))
+	}
 
 	// Create workspace with S3 support
 	workspace, err := createWorkspaceWithS3Support(ctx)
@@ -378,331 +520,301 @@ func runVPNPeers(cmd *cobra.Command, args []string) error {
 	// Detect VPN mode
 	vpnMode, _ := detectVPNMode(outputs)
 
-	fmt.Println()
-	color.Cyan("ℹ  Fetching peer information from cluster nodes...")
-	fmt.Println()
+	if !vpnJSONOutput() {
+		fmt.Println()
+		color.Cyan("ℹ  Fetching peer information from cluster nodes...")
+		fmt.Println()
+	}
 
-	// Use appropriate peer display based on VPN mode
+	// Use appropriate peer collection based on VPN mode
 	if vpnMode == VPNModeTailscale {
-		return displayTailscalePeers(nodes, sshKeyPath, bastionEnabled, bastionIP)
+		report, err := collectTailscalePeers(stack, nodes, sshKeyPath, bastionEnabled, bastionIP)
+		if err != nil {
+			return err
+		}
+		if vpnJSONOutput() {
+			return writeVPNJSON(report)
+		}
+		printTailscalePeersReport(report)
+		return nil
+	}
+
+	report := collectWireGuardPeers(stack, nodes, sshKeyPath, bastionEnabled, bastionIP)
+	if vpnJSONOutput() {
+		return writeVPNJSON(report)
 	}
+	printWireGuardPeersReport(report)
+	return nil
+}
 
-	// WireGuard mode - use existing logic
+// collectWireGuardPeers reads every node's peer table concurrently and
+// cross-checks them
+func collectWireGuardPeers(stack string, nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) wireGuardPeersReport {
+	tables := collectWireGuardPeerTables(nodes, vpnPeersConcurrency, func(node NodeInfo) (string, string, error) {
+		return fetchWireGuardPeerTable(node, sshKeyPath, bastionEnabled, bastionIP)
+	})
 
-	// Collect peer information from all nodes
-	type PeerInfo struct {
-		NodeName      string
-		VPNIp         string
-		PublicKey     string
-		Label         string
-		Endpoint      string
-		LastHandshake string
-		Transfer      string
+	report := wireGuardPeersReport{
+		Stack:      stack,
+		Mode:       VPNModeWireGuard,
+		TotalNodes: len(nodes),
 	}
+	for _, table := range tables {
+		if table.err != nil {
+			report.Errors = append(report.Errors, vpnNodeError{Node: table.node.Name, Error: table.err.Error()})
+			continue
+		}
+		report.NodesReporting++
+	}
+	report.Peers, report.Warnings = mergeWireGuardPeerTables(tables, nodes)
+	return report
+}
 
-	var allPeers []PeerInfo
+// vpnPeersConcurrency bounds how many nodes 'vpn peers' queries at once
+const vpnPeersConcurrency = 8
+
+// wireGuardPeerInfo is a cluster node as it appears in another node's peer table
+type wireGuardPeerInfo struct {
+	NodeName  string `json:"node"`
+	VPNIp     string `json:"vpnIp"`
+	PublicKey string `json:"publicKey"`
+	Label     string `json:"label,omitempty"`
+	Endpoint  string `json:"endpoint"`
+	// LatestHandshake is zero when there has been no handshake
+	LatestHandshake time.Time `json:"latestHandshake,omitzero"`
+	LastHandshake   string    `json:"handshakeAge"` // e.g. "2m ago" or "Never"
+	ReceivedBytes   int64     `json:"receivedBytes"`
+	SentBytes       int64     `json:"sentBytes"`
+	Transfer        string    `json:"-"` // Formatted for the table
+}
 
-	// For each node, SSH and get WireGuard peer information
-	for _, node := range nodes {
-		// Determine target IP for SSH
-		targetIP := node.PublicIP
-		if bastionEnabled && bastionIP != "" {
-			// When using bastion, connect to private IP
-			if node.PrivateIP != "" {
-				targetIP = node.PrivateIP
+// wireGuardPeerTable is the peer table read from one node
+type wireGuardPeerTable struct {
+	node  NodeInfo
+	peers []wireGuardPeerInfo // Cluster nodes only
+	dump  string              // Raw 'wg show wg0 dump' peer lines, clients included
+	err   error
+}
+
+// collectWireGuardPeerTables fetches and parses the peer table of every node,
+// querying at most workers nodes at a time. Tables keep the order of nodes.
+func collectWireGuardPeerTables(nodes []NodeInfo, workers int, fetch func(node NodeInfo) (config string, dump string, err error)) []wireGuardPeerTable {
+	tables := make([]wireGuardPeerTable, len(nodes))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node NodeInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			config, dump, err := fetch(node)
+			tables[i] = wireGuardPeerTable{node: node, dump: dump, err: err}
+			if err == nil {
+				tables[i].peers = parseWireGuardPeerTable(config, dump, nodes, time.Now())
 			}
-		}
+		}(i, node)
+	}
 
-		// Get WireGuard config and peers from this node
-		// First get the config to extract labels from comments
-		fetchConfigCmd := "sudo cat /etc/wireguard/wg0.conf"
-		fetchPeersCmd := "sudo wg show wg0 dump | tail -n +2" // Skip header line
+	wg.Wait()
+	return tables
+}
 
-		// Fetch config to extract peer labels
-		sshUser := getSSHUserForProvider(node.Provider)
-		var configCmd *exec.Cmd
-		if bastionEnabled && bastionIP != "" {
-			configCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				fetchConfigCmd,
-			)
-		} else {
-			configCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				fetchConfigCmd,
-			)
-		}
+// fetchWireGuardPeerTable reads a node's wg0.conf (for peer labels) and its
+// 'wg show wg0 dump' peer lines. Only a failed dump is an error.
+func fetchWireGuardPeerTable(node NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) (string, string, error) {
+	var config string
+	if output, err := wireGuardPeersSSHCommand(node, sshKeyPath, bastionEnabled, bastionIP, "sudo cat /etc/wireguard/wg0.conf").CombinedOutput(); err == nil {
+		config = string(output)
+	}
 
-		// Parse labels from config
-		peerLabels := make(map[string]string) // map[publicKey]label
-		if configOutput, err := configCmd.CombinedOutput(); err == nil {
-			configLines := strings.Split(string(configOutput), "\n")
-			var currentLabel string
-			var currentPublicKey string
+	dump, err := wireGuardPeersSSHCommand(node, sshKeyPath, bastionEnabled, bastionIP, "sudo wg show wg0 dump | tail -n +2").CombinedOutput() // Skip header line
+	if err != nil {
+		return "", "", err
+	}
+	return config, string(dump), nil
+}
 
-			for _, line := range configLines {
-				line = strings.TrimSpace(line)
+// wireGuardPeersSSHCommand builds the ssh command that runs remoteCmd on node,
+// through the bastion (to its private IP) when one is enabled
+func wireGuardPeersSSHCommand(node NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string, remoteCmd string) *exec.Cmd {
+	targetIP := node.PublicIP
+	sshUser := sshUserForNode(node)
 
-				// Check for label comment (# Peer: xxx)
-				if strings.HasPrefix(line, "# Peer:") {
-					currentLabel = strings.TrimSpace(strings.TrimPrefix(line, "# Peer:"))
-				}
+	// When using bastion, connect to private IP
+	if bastionEnabled && bastionIP != "" && node.PrivateIP != "" {
+		targetIP = node.PrivateIP
+	}
 
-				// Check for PublicKey line
-				if strings.HasPrefix(line, "PublicKey") {
-					parts := strings.Split(line, "=")
-					if len(parts) == 2 {
-						currentPublicKey = strings.TrimSpace(parts[1])
-						if currentLabel != "" {
-							peerLabels[currentPublicKey] = currentLabel
-							currentLabel = "" // Reset for next peer
-						}
-					}
-				}
-			}
-		}
+	return nodeSSHCommand(sshKeyPath, sshUser, targetIP, vpnBastionHop(bastionEnabled, bastionIP), 5*time.Second, remoteCmd)
+}
 
-		// Fetch peer information
-		var sshCmd *exec.Cmd
-		if bastionEnabled && bastionIP != "" {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				fetchPeersCmd,
-			)
-		} else {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				fetchPeersCmd,
-			)
+// parseWireGuardPeerTable turns a node's wg0.conf and 'wg show wg0 dump' peer
+// lines into the cluster nodes it peers with. Peers that are not cluster
+// nodes (external clients) are skipped.
+func parseWireGuardPeerTable(config, dump string, nodes []NodeInfo, now time.Time) []wireGuardPeerInfo {
+	// Parse labels from config (# Peer: xxx comments before a PublicKey line)
+	peerLabels := make(map[string]string) // map[publicKey]label
+	var currentLabel string
+	for _, line := range strings.Split(config, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "# Peer:") {
+			currentLabel = strings.TrimSpace(strings.TrimPrefix(line, "# Peer:"))
+			continue
+		}
+		if key, value, ok := splitWGConfigLine(line); ok && key == "PublicKey" && currentLabel != "" {
+			peerLabels[value] = currentLabel
+			currentLabel = "" // Reset for next peer
 		}
+	}
 
-		output, err := sshCmd.CombinedOutput()
-		if err != nil {
-			color.Yellow(fmt.Sprintf("⚠  Failed to get peers from %s: %v", node.Name, err))
+	var peers []wireGuardPeerInfo
+	for _, line := range strings.Split(strings.TrimSpace(dump), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
 			continue
 		}
 
-		// Parse wg dump output
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, line := range lines {
-			if line == "" {
-				continue
-			}
+		publicKey := fields[0]
+		endpoint := fields[2]
 
-			fields := strings.Fields(line)
-			if len(fields) < 8 {
-				continue
-			}
+		// Extract VPN IP from allowed IPs (format: 10.8.0.X/32)
+		vpnIP := vpnIPOf(strings.Split(fields[3], ",")[0])
 
-			publicKey := fields[0]
-			endpoint := fields[2]
-			allowedIPs := fields[3]
-			lastHandshake := fields[4]
-			rxBytes := fields[5]
-			txBytes := fields[6]
-
-			// Extract VPN IP from allowed IPs (format: 10.8.0.X/32)
-			vpnIP := strings.TrimSuffix(allowedIPs, "/32")
-
-			// Format handshake time
-			handshakeStr := "Never"
-			if lastHandshake != "0" {
-				handshakeTime, err := strconv.ParseInt(lastHandshake, 10, 64)
-				if err == nil {
-					elapsed := time.Now().Unix() - handshakeTime
-					if elapsed < 60 {
-						handshakeStr = fmt.Sprintf("%ds ago", elapsed)
-					} else if elapsed < 3600 {
-						handshakeStr = fmt.Sprintf("%dm ago", elapsed/60)
-					} else if elapsed < 86400 {
-						handshakeStr = fmt.Sprintf("%dh ago", elapsed/3600)
-					} else {
-						handshakeStr = fmt.Sprintf("%dd ago", elapsed/86400)
-					}
-				}
+		// Find peer node name by VPN IP
+		peerNodeName := ""
+		for _, n := range nodes {
+			if vpnIPOf(n.WireGuardIP) == vpnIP {
+				peerNodeName = n.Name
+				break
 			}
+		}
+		if peerNodeName == "" {
+			continue
+		}
 
-			// Format transfer
-			rx, _ := strconv.ParseInt(rxBytes, 10, 64)
-			tx, _ := strconv.ParseInt(txBytes, 10, 64)
-			transferStr := fmt.Sprintf("↑ %s / ↓ %s", formatBytes(tx), formatBytes(rx))
-
-			// Format endpoint
-			if endpoint == "(none)" {
-				endpoint = "N/A"
-			}
+		handshakeStr := "Never"
+		var lastHandshake time.Time
+		if handshake, err := strconv.ParseInt(fields[4], 10, 64); err == nil && handshake > 0 {
+			lastHandshake = time.Unix(handshake, 0)
+			handshakeStr = formatHandshakeAge(&lastHandshake, now)
+		}
 
-			// Find peer node name by VPN IP
-			peerNodeName := ""
-			for _, n := range nodes {
-				// Compare VPN IPs, handling potential /32 suffix
-				nodeVPNIP := strings.TrimSuffix(n.WireGuardIP, "/32")
-				if nodeVPNIP == vpnIP {
-					peerNodeName = n.Name
-					break
-				}
-			}
+		rx, _ := strconv.ParseInt(fields[5], 10, 64)
+		tx, _ := strconv.ParseInt(fields[6], 10, 64)
 
-			// Only add peers that belong to cluster nodes (skip external/unknown peers)
-			if peerNodeName != "" {
-				// Get label from map
-				label := peerLabels[publicKey]
-
-				allPeers = append(allPeers, PeerInfo{
-					NodeName:      peerNodeName,
-					VPNIp:         vpnIP,
-					PublicKey:     publicKey[:16] + "...", // Truncate for display
-					Label:         label,
-					Endpoint:      endpoint,
-					LastHandshake: handshakeStr,
-					Transfer:      transferStr,
-				})
-			}
+		if endpoint == "(none)" {
+			endpoint = "N/A"
 		}
 
-		// Only need to get from one node since all should have the same peers
-		if len(allPeers) > 0 {
-			break
-		}
+		peers = append(peers, wireGuardPeerInfo{
+			NodeName:        peerNodeName,
+			VPNIp:           vpnIP,
+			PublicKey:       publicKey,
+			Label:           peerLabels[publicKey],
+			Endpoint:        endpoint,
+			LatestHandshake: lastHandshake,
+			LastHandshake:   handshakeStr,
+			ReceivedBytes:   rx,
+			SentBytes:       tx,
+			Transfer:        fmt.Sprintf("↑ %s / ↓ %s", formatBytes(tx), formatBytes(rx)),
+		})
 	}
+	return peers
+}
 
-	// Remove duplicates and display
-	seen := make(map[string]bool)
-	uniquePeers := []PeerInfo{}
-	for _, peer := range allPeers {
-		if !seen[peer.VPNIp] {
-			seen[peer.VPNIp] = true
-			uniquePeers = append(uniquePeers, peer)
+// mergeWireGuardPeerTables merges the peer tables of all reporting nodes into
+// one row per cluster peer, sorted by node name. It also returns a warning for
+// every node missing from another node's table and for every node whose public
+// key differs between tables, i.e. a partial mesh.
+func mergeWireGuardPeerTables(tables []wireGuardPeerTable, nodes []NodeInfo) ([]wireGuardPeerInfo, []string) {
+	var reporting []string
+	seenOn := make(map[string]map[string]bool)     // VPN IP -> reporting node -> listed
+	keysOn := make(map[string]map[string][]string) // VPN IP -> public key -> reporting nodes
+	rows := make(map[string]wireGuardPeerInfo)
+
+	for _, table := range tables {
+		if table.err != nil {
+			continue
 		}
-	}
-
-	// Display table
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
+		reporting = append(reporting, table.node.Name)
 
-	color.New(color.Bold).Fprintln(w, "NODE\tLABEL\tVPN IP\tPUBLIC KEY\tENDPOINT\tLAST HANDSHAKE\tTRANSFER")
-	fmt.Fprintln(w, "----\t-----\t------\t----------\t--------\t--------------\t--------")
-
-	if len(uniquePeers) == 0 {
-		fmt.Fprintln(w, "No peers found")
-	} else {
-		for _, peer := range uniquePeers {
-			label := peer.Label
-			if label == "" {
-				label = "-"
+		for _, peer := range table.peers {
+			if _, ok := rows[peer.VPNIp]; !ok {
+				rows[peer.VPNIp] = peer
+				seenOn[peer.VPNIp] = make(map[string]bool)
+				keysOn[peer.VPNIp] = make(map[string][]string)
 			}
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-				peer.NodeName,
-				label,
-				peer.VPNIp,
-				peer.PublicKey,
-				peer.Endpoint,
-				peer.LastHandshake,
-				peer.Transfer,
-			)
+			seenOn[peer.VPNIp][table.node.Name] = true
+			keysOn[peer.VPNIp][peer.PublicKey] = append(keysOn[peer.VPNIp][peer.PublicKey], table.node.Name)
 		}
 	}
+	sort.Strings(reporting)
 
-	fmt.Println()
-	color.Green(fmt.Sprintf("✓ Found %d peers in VPN mesh", len(uniquePeers)))
-
-	return nil
-}
-
-// displayTailscalePeers displays Tailscale peer information
-func displayTailscalePeers(nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) error {
-	// Get Tailscale status from first reachable node
-	_, peers := getTailscaleStatusFromNode(nodes, sshKeyPath, bastionEnabled, bastionIP)
-
-	if peers == nil {
-		return fmt.Errorf("failed to get Tailscale peer information from any node")
+	peers := make([]wireGuardPeerInfo, 0, len(rows))
+	for _, peer := range rows {
+		peers = append(peers, peer)
 	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].NodeName < peers[j].NodeName })
 
-	// Display table
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
+	expected := append([]NodeInfo(nil), nodes...)
+	sort.Slice(expected, func(i, j int) bool { return expected[i].Name < expected[j].Name })
 
-	color.New(color.Bold).Fprintln(w, "HOSTNAME\tTAILSCALE IP\tSTATUS\tOS\tLAST SEEN\tRELAY")
-	fmt.Fprintln(w, "--------\t------------\t------\t--\t---------\t-----")
+	var warnings []string
+	for _, node := range expected {
+		vpnIP := vpnIPOf(node.WireGuardIP)
+		if vpnIP == "" {
+			continue
+		}
 
-	if len(peers) == 0 {
-		fmt.Fprintln(w, "No peers found")
-	} else {
-		for _, peer := range peers {
-			// Format status
-			status := "🔴 Offline"
-			if peer.Online {
-				status = "🟢 Online"
+		var missing []string
+		for _, name := range reporting {
+			if name != node.Name && !seenOn[vpnIP][name] {
+				missing = append(missing, name)
 			}
+		}
+		if len(missing) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s (%s) is missing from the peer table of %s", node.Name, vpnIP, strings.Join(missing, ", ")))
+		}
 
-			// Format last seen
-			lastSeen := "Never"
-			if !peer.LastSeen.IsZero() {
-				elapsed := time.Since(peer.LastSeen)
-				if elapsed < time.Minute {
-					lastSeen = fmt.Sprintf("%ds ago", int(elapsed.Seconds()))
-				} else if elapsed < time.Hour {
-					lastSeen = fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
-				} else if elapsed < 24*time.Hour {
-					lastSeen = fmt.Sprintf("%dh ago", int(elapsed.Hours()))
-				} else {
-					lastSeen = fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+		if len(keysOn[vpnIP]) > 1 {
+			var variants []string
+			for key, on := range keysOn[vpnIP] {
+				sort.Strings(on)
+				short := key
+				if len(short) > 16 {
+					short = short[:16] + "..."
 				}
+				variants = append(variants, fmt.Sprintf("%s on %s", short, strings.Join(on, ", ")))
 			}
-			if peer.Online {
-				lastSeen = "Now"
-			}
-
-			// Format relay
-			relay := "Direct"
-			if peer.Relay != "" {
-				relay = peer.Relay
-			}
-
-			// Format OS
-			osName := peer.OS
-			if osName == "" {
-				osName = "unknown"
-			}
-
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
-				peer.Hostname,
-				peer.TailnetIP,
-				status,
-				osName,
-				lastSeen,
-				relay,
-			)
+			sort.Strings(variants)
+			warnings = append(warnings, fmt.Sprintf("%s (%s) has different public keys across nodes: %s", node.Name, vpnIP, strings.Join(variants, "; ")))
 		}
 	}
 
-	fmt.Println()
-	onlineCount := countOnlinePeers(peers)
-	color.Green(fmt.Sprintf("✓ Found %d peers in Tailscale network (%d online)", len(peers), onlineCount))
+	return peers, warnings
+}
 
-	return nil
+// collectTailscalePeers reads the tailnet peers from the first reachable node
+func collectTailscalePeers(stack string, nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) (tailscalePeersReport, error) {
+	status, peers := getTailscaleStatusFromNode(nodes, sshKeyPath, bastionEnabled, bastionIP)
+	if status == nil {
+		return tailscalePeersReport{}, fmt.Errorf("failed to get Tailscale peer information from any node")
+	}
+	if peers == nil {
+		peers = []TailscalePeerInfo{}
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Hostname < peers[j].Hostname })
+
+	return tailscalePeersReport{
+		Stack:  stack,
+		Mode:   VPNModeTailscale,
+		Online: countOnlinePeers(peers),
+		Peers:  peers,
+	}, nil
 }
 
 func runVPNConfig(cmd *cobra.Command, args []string) error {
@@ -761,60 +873,22 @@ func runVPNConfig(cmd *cobra.Command, args []string) error {
 
 	// Get SSH key and bastion info
 	sshKeyPath := GetSSHKeyPath(stack)
-	bastionEnabled := false
-	bastionIP := ""
-
-	if bastionEnabledOutput, ok := outputs["bastion_enabled"]; ok {
-		if bastionEnabledOutput.Value != nil {
-			bastionEnabled = bastionEnabledOutput.Value == true
-		}
-	}
-
-	if bastionEnabled {
-		if bastionOutput, ok := outputs["bastion"]; ok {
-			if bastionMap, ok := bastionOutput.Value.(map[string]interface{}); ok {
-				if pubIP, ok := bastionMap["public_ip"].(string); ok {
-					bastionIP = pubIP
-				}
-			}
-		}
-	}
+	bastionIP := bastionIPFromOutputs(outputs)
 
 	fmt.Println()
 	printInfo(fmt.Sprintf("Fetching WireGuard configuration from %s...", targetNode.Name))
 
 	// Determine target IP for SSH
-	targetIP := targetNode.WireGuardIP
-	if targetIP == "" {
-		targetIP = targetNode.PrivateIP
-		if targetIP == "" {
-			targetIP = targetNode.PublicIP
-		}
+	targetIP, err := resolveNodeIP(*targetNode, "", bastionIP != "")
+	if err != nil {
+		return err
 	}
 
 	// Fetch the WireGuard config
 	fetchCmd := "sudo cat /etc/wireguard/wg0.conf"
-	sshUser := getSSHUserForProvider(targetNode.Provider)
+	sshUser := sshUserForNode(*targetNode)
 
-	var sshCmd *exec.Cmd
-	if bastionEnabled && bastionIP != "" {
-		sshCmd = exec.Command("ssh",
-			"-i", sshKeyPath,
-			"-o", "StrictHostKeyChecking=accept-new",
-			"-o", "UserKnownHostsFile=/dev/null",
-			"-o", fmt.Sprintf("ProxyCommand=ssh -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-			fmt.Sprintf("%s@%s", sshUser, targetIP),
-			fetchCmd,
-		)
-	} else {
-		sshCmd = exec.Command("ssh",
-			"-i", sshKeyPath,
-			"-o", "StrictHostKeyChecking=accept-new",
-			"-o", "UserKnownHostsFile=/dev/null",
-			fmt.Sprintf("%s@%s", sshUser, targetNode.PublicIP),
-			fetchCmd,
-		)
-	}
+	sshCmd := nodeSSHCommand(sshKeyPath, sshUser, targetIP, bastionIP, 10*time.Second, fetchCmd)
 
 	output, err := sshCmd.CombinedOutput()
 	if err != nil {
@@ -844,7 +918,17 @@ func runVPNTest(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	printHeader(fmt.Sprintf("🧪 Testing VPN Connectivity - Stack: %s", stack))
+	if err := validateVPNOutputFormat(); err != nil {
+		return err
+	}
+
+	if vpnTestStaleAfter <= 0 {
+		return fmt.Errorf("--stale-after must be a positive duration")
+	}
+
+	if !vpnJSONOutput() {
+		printHeader(fmt.Sprintf("🧪 Testing VPN Connectivity - Stack: %s", stack))
+	}
 
 	// Create workspace with S3 support
 	workspace, err := createWorkspaceWithS3Support(ctx)
@@ -874,8 +958,10 @@ func runVPNTest(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no nodes found in stack")
 	}
 
-	fmt.Println()
-	printInfo(fmt.Sprintf("Found %d nodes to test", len(nodes)))
+	if !vpnJSONOutput() {
+		fmt.Println()
+		printInfo(fmt.Sprintf("Found %d nodes to test", len(nodes)))
+	}
 
 	// Get SSH key and bastion info
 	sshKeyPath := GetSSHKeyPath(stack)
@@ -901,88 +987,180 @@ func runVPNTest(cmd *cobra.Command, args []string) error {
 	// Detect VPN mode
 	vpnMode, _ := detectVPNMode(outputs)
 
+	var report vpnTestReport
 	if vpnMode == VPNModeTailscale {
-		return runTailscaleVPNTest(nodes, sshKeyPath, bastionEnabled, bastionIP)
+		report, err = runTailscaleVPNTest(stack, nodes, sshKeyPath, bastionEnabled, bastionIP)
+		if err != nil {
+			return err
+		}
+	} else {
+		report = runWireGuardVPNTest(stack, nodes, sshKeyPath, bastionEnabled, bastionIP)
 	}
 
-	// WireGuard VPN test
+	if vpnTestMatrix {
+		report.WorstLinks = worstVPNLinks(report.Pings, vpnWorstLinks)
+	}
 
-	// Test 1: Ping test between nodes
+	if vpnJSONOutput() {
+		return writeVPNJSON(report)
+	}
+	printVPNTestSummary(report)
+	if vpnTestMatrix {
+		fmt.Println()
+		printVPNWorstLinks(report)
+	}
+	return nil
+}
+
+// printVPNTestStep prints the heading of a 'vpn test' step in table mode
+func printVPNTestStep(title string) {
+	if vpnJSONOutput() {
+		return
+	}
 	fmt.Println()
-	printInfo("Test 1/3: Testing ping connectivity via VPN...")
+	printInfo(title)
 	fmt.Println()
+}
 
-	successCount := 0
-	totalTests := 0
+// runWireGuardVPNTest runs VPN connectivity tests for WireGuard mode
+func runWireGuardVPNTest(stack string, nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) vpnTestReport {
+	report := vpnTestReport{
+		Stack:      stack,
+		Mode:       VPNModeWireGuard,
+		TotalNodes: len(nodes),
+	}
+
+	// Test 1: Ping test between nodes
+	printVPNTestStep("Test 1/3: Testing ping connectivity via VPN...")
+	report.Pings = pingWireGuardNodes(nodes, sshKeyPath, bastionEnabled, bastionIP)
+	if !vpnJSONOutput() {
+		if vpnTestMatrix {
+			printVPNPingMatrix(report.Pings)
+		} else {
+			printVPNPingResults(report.Pings)
+		}
+	}
+
+	// Test 2: WireGuard handshake status
+	printVPNTestStep("Test 2/3: Checking WireGuard handshake status...")
+	report.PeerChecks = checkWireGuardHandshakes(nodes, sshKeyPath, bastionEnabled, bastionIP, vpnTestStaleAfter)
+	if !vpnJSONOutput() {
+		printWireGuardHandshakeChecks(report.PeerChecks, vpnTestStaleAfter)
+	}
 
+	// Test 3: Summary
+	printVPNTestStep("Test 3/3: Summary")
+	report.summarize()
+	return report
+}
+
+// pingWireGuardNodes pings every node from every other node over WireGuard
+func pingWireGuardNodes(nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) []vpnPingResult {
+	sources := make(map[string]NodeInfo)
+	var pings []vpnPingResult
 	for i, sourceNode := range nodes {
 		if sourceNode.WireGuardIP == "" {
 			continue
 		}
+		sources[sourceNode.Name] = sourceNode
 
 		for j, targetNode := range nodes {
 			if i == j || targetNode.WireGuardIP == "" {
 				continue
 			}
+			pings = append(pings, vpnPingResult{
+				Source:   sourceNode.Name,
+				Target:   targetNode.Name,
+				TargetIP: targetNode.WireGuardIP,
+			})
+		}
+	}
 
-			totalTests++
-
-			// Build ping command
-			pingCmd := fmt.Sprintf("ping -c 2 -W 2 %s > /dev/null 2>&1 && echo 'SUCCESS' || echo 'FAILED'", targetNode.WireGuardIP)
+	return collectVPNPings(pings, vpnTestConcurrency, func(ping vpnPingResult) (string, error) {
+		sourceNode := sources[ping.Source]
 
-			// Determine target IP for SSH
-			sourceIP := sourceNode.WireGuardIP
+		// Determine target IP for SSH
+		sourceIP := sourceNode.WireGuardIP
+		if sourceIP == "" {
+			sourceIP = sourceNode.PrivateIP
 			if sourceIP == "" {
-				sourceIP = sourceNode.PrivateIP
-				if sourceIP == "" {
-					sourceIP = sourceNode.PublicIP
-				}
+				sourceIP = sourceNode.PublicIP
 			}
+		}
 
-			// Build SSH command
-			sshUser := getSSHUserForProvider(sourceNode.Provider)
-			var sshCmd *exec.Cmd
-			if bastionEnabled && bastionIP != "" {
-				sshCmd = exec.Command("ssh",
-					"-q",
-					"-i", sshKeyPath,
-					"-o", "StrictHostKeyChecking=accept-new",
-					"-o", "UserKnownHostsFile=/dev/null",
-					"-o", "ConnectTimeout=5",
-					"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-					fmt.Sprintf("%s@%s", sshUser, sourceIP),
-					pingCmd,
-				)
-			} else {
-				sshCmd = exec.Command("ssh",
-					"-q",
-					"-i", sshKeyPath,
-					"-o", "StrictHostKeyChecking=accept-new",
-					"-o", "UserKnownHostsFile=/dev/null",
-					"-o", "ConnectTimeout=5",
-					fmt.Sprintf("%s@%s", sshUser, sourceNode.PublicIP),
-					pingCmd,
-				)
-			}
+		// Build SSH command
+		sshUser := sourceNode.SSHUser
+		// Through the bastion the node is reached on its VPN address
+		sshHost := sourceNode.PublicIP
+		if bastionEnabled && bastionIP != "" {
+			sshHost = sourceIP
+		}
+		sshCmd := nodeSSHCommand(sshKeyPath, sshUser, sshHost, vpnBastionHop(bastionEnabled, bastionIP), 5*time.Second, vpnPingCommand(ping.TargetIP))
+
+		output, err := sshCmd.CombinedOutput()
+		return string(output), err
+	})
+}
 
-			output, err := sshCmd.CombinedOutput()
-			result := strings.TrimSpace(string(output))
+// vpnTestConcurrency bounds how many pings 'vpn test' runs at once
+const vpnTestConcurrency = 8
 
-			if err == nil && result == "SUCCESS" {
-				fmt.Printf("  ✓ %s → %s (%s)\n", sourceNode.Name, targetNode.Name, targetNode.WireGuardIP)
-				successCount++
-			} else {
-				fmt.Printf("  ✗ %s → %s (%s) - Failed\n", sourceNode.Name, targetNode.Name, targetNode.WireGuardIP)
+// pingRTTPattern matches the summary line of iputils and BusyBox ping and
+// captures the average round-trip time in milliseconds
+var pingRTTPattern = regexp.MustCompile(`(?:rtt|round-trip) min/avg/max(?:/[a-z]+)? = [\d.]+/([\d.]+)/`)
+
+// vpnPingCommand pings ip twice, printing the ping output followed by
+// SUCCESS or FAILED
+func vpnPingCommand(ip string) string {
+	return fmt.Sprintf("ping -c 2 -W 2 %s 2>&1 && echo 'SUCCESS' || echo 'FAILED'", ip)
+}
+
+// parsePingOutput returns whether the vpnPingCommand output reports success
+// and the average round-trip time in milliseconds (0 when not reported)
+func parsePingOutput(output string) (bool, float64) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	passed := strings.TrimSpace(lines[len(lines)-1]) == "SUCCESS"
+
+	var rtt float64
+	if match := pingRTTPattern.FindStringSubmatch(output); match != nil {
+		rtt, _ = strconv.ParseFloat(match[1], 64)
+	}
+	return passed, rtt
+}
+
+// collectVPNPings runs every ping, at most workers at a time, and returns
+// them with their results in the same order. ping runs vpnPingCommand on the
+// source node and returns its output.
+func collectVPNPings(pings []vpnPingResult, workers int, ping func(ping vpnPingResult) (string, error)) []vpnPingResult {
+	results := make([]vpnPingResult, len(pings))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, p := range pings {
+		wg.Add(1)
+		go func(i int, p vpnPingResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			output, err := ping(p)
+			passed, rtt := parsePingOutput(output)
+			p.Passed = err == nil && passed
+			if p.Passed {
+				p.RTTMs = rtt
 			}
-		}
+			results[i] = p
+		}(i, p)
 	}
 
-	// Test 2: WireGuard handshake status
-	fmt.Println()
-	printInfo("Test 2/3: Checking WireGuard handshake status...")
-	fmt.Println()
+	wg.Wait()
+	return results
+}
 
-	handshakeOK := 0
+// checkWireGuardHandshakes reads the latest handshakes on every node and
+// flags the cluster peers without one within staleAfter
+func checkWireGuardHandshakes(nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string, staleAfter time.Duration) []vpnNodeCheck {
+	checks := []vpnNodeCheck{}
 	for _, node := range nodes {
 		if node.WireGuardIP == "" {
 			continue
@@ -997,388 +1175,270 @@ func runVPNTest(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		checkCmd := "sudo wg show wg0 latest-handshakes | wc -l"
-		sshUserHandshake := getSSHUserForProvider(node.Provider)
+		checkCmd := "sudo wg show wg0 dump | tail -n +2"
+		sshUserHandshake := sshUserForNode(node)
 
-		var sshCmd *exec.Cmd
+		// Through the bastion the node is reached on its VPN address
+		sshHost := node.PublicIP
 		if bastionEnabled && bastionIP != "" {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-				fmt.Sprintf("%s@%s", sshUserHandshake, targetIP),
-				checkCmd,
-			)
-		} else {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				fmt.Sprintf("%s@%s", sshUserHandshake, node.PublicIP),
-				checkCmd,
-			)
+			sshHost = targetIP
 		}
+		sshCmd := nodeSSHCommand(sshKeyPath, sshUserHandshake, sshHost, vpnBastionHop(bastionEnabled, bastionIP), 5*time.Second, checkCmd)
 
 		output, err := sshCmd.CombinedOutput()
-		if err == nil {
-			peerCount := strings.TrimSpace(string(output))
-			fmt.Printf("  ✓ %s - %s active peers\n", node.Name, peerCount)
-			handshakeOK++
-		} else {
-			fmt.Printf("  ✗ %s - Could not check handshake status\n", node.Name)
+		if err != nil {
+			checks = append(checks, vpnNodeCheck{Node: node.Name, Error: err.Error()})
+			continue
 		}
+		active, stale := staleWireGuardHandshakes(node, string(output), nodes, time.Now(), staleAfter)
+		checks = append(checks, vpnNodeCheck{
+			Node:            node.Name,
+			Peers:           active,
+			Passed:          len(stale) == 0,
+			StaleHandshakes: stale,
+		})
 	}
+	return checks
+}
 
-	// Test 3: Summary
-	fmt.Println()
-	printInfo("Test 3/3: Summary")
-	fmt.Println()
+// staleWireGuardHandshake is a cluster node without a recent handshake in
+// another node's peer table
+type staleWireGuardHandshake struct {
+	Peer  string `json:"peer"`
+	VPNIP string `json:"vpnIp"`
+	// LastHandshake is zero when there has been no handshake
+	LastHandshake time.Time `json:"lastHandshake,omitzero"`
+	// Missing is set when the peer is not in the peer table at all
+	Missing bool `json:"missing,omitempty"`
+}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
+// staleWireGuardHandshakes checks node's 'wg show wg0 dump' peer lines
+// against the other cluster nodes. It returns how many had a handshake within
+// staleAfter and the ones that did not or are missing. Peers that are not
+// cluster nodes (external clients) are ignored.
+func staleWireGuardHandshakes(node NodeInfo, dump string, nodes []NodeInfo, now time.Time, staleAfter time.Duration) (int, []staleWireGuardHandshake) {
+	handshakes := make(map[string]int64) // VPN IP -> latest handshake
+	for _, line := range strings.Split(strings.TrimSpace(dump), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		handshake, _ := strconv.ParseInt(fields[4], 10, 64)
+		for _, allowedIP := range strings.Split(fields[3], ",") {
+			handshakes[vpnIPOf(allowedIP)] = handshake
+		}
+	}
 
-	fmt.Fprintln(w, "METRIC\tRESULT")
-	fmt.Fprintln(w, "------\t------")
-	fmt.Fprintf(w, "Total Nodes\t%d\n", len(nodes))
-	fmt.Fprintf(w, "Ping Tests\t%d/%d passed (%.1f%%)\n", successCount, totalTests, float64(successCount)/float64(totalTests)*100)
-	fmt.Fprintf(w, "Handshake Checks\t%d/%d nodes responding\n", handshakeOK, len(nodes))
+	active := 0
+	var stale []staleWireGuardHandshake
+	for _, peer := range nodes {
+		vpnIP := vpnIPOf(peer.WireGuardIP)
+		if peer.Name == node.Name || vpnIP == "" {
+			continue
+		}
 
-	if successCount == totalTests && handshakeOK == len(nodes) {
-		fmt.Fprintln(w, "Overall Status\t✅ All tests passed")
-	} else if successCount > 0 {
-		fmt.Fprintln(w, "Overall Status\t⚠️  Some tests failed")
-	} else {
-		fmt.Fprintln(w, "Overall Status\t❌ All tests failed")
+		handshake, listed := handshakes[vpnIP]
+		switch {
+		case !listed:
+			stale = append(stale, staleWireGuardHandshake{Peer: peer.Name, VPNIP: vpnIP, Missing: true})
+		case handshake <= 0:
+			stale = append(stale, staleWireGuardHandshake{Peer: peer.Name, VPNIP: vpnIP})
+		case now.Sub(time.Unix(handshake, 0)) > staleAfter:
+			stale = append(stale, staleWireGuardHandshake{Peer: peer.Name, VPNIP: vpnIP, LastHandshake: time.Unix(handshake, 0)})
+		default:
+			active++
+		}
 	}
+	return active, stale
+}
 
-	return nil
+// vpnNodeCheckFromOutput turns the peer count printed by a node check into
+// its result
+func vpnNodeCheckFromOutput(nodeName string, output []byte, err error) vpnNodeCheck {
+	if err != nil {
+		return vpnNodeCheck{Node: nodeName, Error: err.Error()}
+	}
+	peers, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+	return vpnNodeCheck{Node: nodeName, Peers: peers, Passed: true}
+}
+
+// tailscaleTestNode is a node with the Tailscale IP it reported
+type tailscaleTestNode struct {
+	Name        string
+	PublicIP    string
+	PrivateIP   string
+	TailscaleIP string
+	SSHUser     string
 }
 
 // runTailscaleVPNTest runs VPN connectivity tests for Tailscale mode
-func runTailscaleVPNTest(nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) error {
-	// First, get Tailscale IPs from all nodes
-	type NodeTailscaleInfo struct {
-		Name        string
-		PublicIP    string
-		PrivateIP   string
-		TailscaleIP string
-		Provider    string
+func runTailscaleVPNTest(stack string, nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) (vpnTestReport, error) {
+	report := vpnTestReport{
+		Stack: stack,
+		Mode:  VPNModeTailscale,
+	}
+
+	// Test 1: Tailscale IPs of all nodes
+	printVPNTestStep("Test 1/3: Fetching Tailscale IPs from nodes...")
+	var tsNodes []tailscaleTestNode
+	report.Addresses, tsNodes = fetchTailscaleTestNodes(nodes, sshKeyPath, bastionEnabled, bastionIP)
+	if !vpnJSONOutput() {
+		for _, address := range report.Addresses {
+			if address.Passed {
+				fmt.Printf("  ✓ %s - Tailscale IP: %s\n", address.Node, address.VPNIP)
+			} else {
+				color.Yellow(fmt.Sprintf("  ⚠️  %s - %s", address.Node, address.Error))
+			}
+		}
 	}
+	report.TotalNodes = len(tsNodes)
 
-	fmt.Println()
-	printInfo("Test 1/3: Fetching Tailscale IPs from nodes...")
-	fmt.Println()
+	if len(tsNodes) < 2 {
+		return report, fmt.Errorf("need at least 2 nodes with Tailscale IPs to test connectivity")
+	}
 
-	var tsNodes []NodeTailscaleInfo
-	for _, node := range nodes {
-		// Determine target IP for SSH
-		targetIP := node.PrivateIP
-		if !bastionEnabled || bastionIP == "" {
-			targetIP = node.PublicIP
+	// Test 2: Ping test between nodes via Tailscale
+	printVPNTestStep("Test 2/3: Testing ping connectivity via Tailscale...")
+	report.Pings = pingTailscaleNodes(tsNodes, sshKeyPath, bastionEnabled, bastionIP)
+	if !vpnJSONOutput() {
+		if vpnTestMatrix {
+			printVPNPingMatrix(report.Pings)
+		} else {
+			printVPNPingResults(report.Pings)
 		}
-		if targetIP == "" {
-			targetIP = node.PublicIP
+	}
+
+	// Test 3: Tailscale peer status check
+	printVPNTestStep("Test 3/3: Checking Tailscale peer status...")
+	report.PeerChecks = checkTailscalePeerStatus(tsNodes, sshKeyPath, bastionEnabled, bastionIP)
+	if !vpnJSONOutput() {
+		for _, check := range report.PeerChecks {
+			if check.Passed {
+				fmt.Printf("  ✓ %s - %d connected peers\n", check.Node, check.Peers)
+			} else {
+				fmt.Printf("  ✗ %s - Could not check peer status\n", check.Node)
+			}
 		}
+	}
+
+	// Summary
+	printVPNTestStep("Summary")
+	report.summarize()
+	return report, nil
+}
+
+// tailscaleTestSSHTarget returns the address to SSH to a node on
+func tailscaleTestSSHTarget(publicIP, privateIP string, bastionEnabled bool, bastionIP string) string {
+	targetIP := privateIP
+	if !bastionEnabled || bastionIP == "" {
+		targetIP = publicIP
+	}
+	if targetIP == "" {
+		targetIP = publicIP
+	}
+	return targetIP
+}
 
+// fetchTailscaleTestNodes reads the Tailscale IP of every node. It returns
+// the lookup result per node and the nodes that have one.
+func fetchTailscaleTestNodes(nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) ([]vpnNodeCheck, []tailscaleTestNode) {
+	var addresses []vpnNodeCheck
+	var tsNodes []tailscaleTestNode
+	for _, node := range nodes {
+		// Determine target IP for SSH
+		targetIP := tailscaleTestSSHTarget(node.PublicIP, node.PrivateIP, bastionEnabled, bastionIP)
 		if targetIP == "" {
-			color.Yellow(fmt.Sprintf("  ⚠️  %s - No reachable IP", node.Name))
+			addresses = append(addresses, vpnNodeCheck{Node: node.Name, Error: "No reachable IP"})
 			continue
 		}
 
 		// Get Tailscale IP
 		getTsIPCmd := "sudo tailscale ip -4 2>/dev/null | head -1"
-		sshUser := getSSHUserForNode(node.Provider)
+		sshUser := sshUserForNode(node)
 
-		var sshCmd *exec.Cmd
-		if bastionEnabled && bastionIP != "" {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=10",
-				"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				getTsIPCmd,
-			)
-		} else {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=10",
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				getTsIPCmd,
-			)
-		}
+		sshCmd := nodeSSHCommand(sshKeyPath, sshUser, targetIP, vpnBastionHop(bastionEnabled, bastionIP), 10*time.Second, getTsIPCmd)
 
 		output, err := sshCmd.CombinedOutput()
 		if err != nil {
-			color.Yellow(fmt.Sprintf("  ⚠️  %s - Failed to get Tailscale IP: %v", node.Name, err))
+			addresses = append(addresses, vpnNodeCheck{Node: node.Name, Error: fmt.Sprintf("Failed to get Tailscale IP: %v", err)})
 			continue
 		}
 
 		tsIP := strings.TrimSpace(string(output))
 		if tsIP == "" {
-			color.Yellow(fmt.Sprintf("  ⚠️  %s - No Tailscale IP found", node.Name))
+			addresses = append(addresses, vpnNodeCheck{Node: node.Name, Error: "No Tailscale IP found"})
 			continue
 		}
 
-		fmt.Printf("  ✓ %s - Tailscale IP: %s\n", node.Name, tsIP)
-		tsNodes = append(tsNodes, NodeTailscaleInfo{
+		addresses = append(addresses, vpnNodeCheck{Node: node.Name, VPNIP: tsIP, Passed: true})
+		tsNodes = append(tsNodes, tailscaleTestNode{
 			Name:        node.Name,
 			PublicIP:    node.PublicIP,
 			PrivateIP:   node.PrivateIP,
 			TailscaleIP: tsIP,
-			Provider:    node.Provider,
+			SSHUser:     sshUser,
 		})
 	}
+	return addresses, tsNodes
+}
 
-	if len(tsNodes) < 2 {
-		return fmt.Errorf("need at least 2 nodes with Tailscale IPs to test connectivity")
-	}
-
-	// Test 2: Ping test between nodes via Tailscale
-	fmt.Println()
-	printInfo("Test 2/3: Testing ping connectivity via Tailscale...")
-	fmt.Println()
-
-	successCount := 0
-	totalTests := 0
-
+// pingTailscaleNodes pings every node from every other node over Tailscale
+func pingTailscaleNodes(tsNodes []tailscaleTestNode, sshKeyPath string, bastionEnabled bool, bastionIP string) []vpnPingResult {
+	sources := make(map[string]tailscaleTestNode)
+	var pings []vpnPingResult
 	for i, sourceNode := range tsNodes {
+		sources[sourceNode.Name] = sourceNode
+
 		for j, targetNode := range tsNodes {
 			if i == j {
 				continue
 			}
-
-			totalTests++
-
-			// Build ping command to target's Tailscale IP
-			pingCmd := fmt.Sprintf("ping -c 2 -W 2 %s > /dev/null 2>&1 && echo 'SUCCESS' || echo 'FAILED'", targetNode.TailscaleIP)
-
-			// Determine target IP for SSH
-			sshTargetIP := sourceNode.PrivateIP
-			if !bastionEnabled || bastionIP == "" {
-				sshTargetIP = sourceNode.PublicIP
-			}
-			if sshTargetIP == "" {
-				sshTargetIP = sourceNode.PublicIP
-			}
-
-			sshUser := getSSHUserForNode(sourceNode.Provider)
-			var sshCmd *exec.Cmd
-			if bastionEnabled && bastionIP != "" {
-				sshCmd = exec.Command("ssh",
-					"-q",
-					"-i", sshKeyPath,
-					"-o", "StrictHostKeyChecking=accept-new",
-					"-o", "UserKnownHostsFile=/dev/null",
-					"-o", "ConnectTimeout=5",
-					"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-					fmt.Sprintf("%s@%s", sshUser, sshTargetIP),
-					pingCmd,
-				)
-			} else {
-				sshCmd = exec.Command("ssh",
-					"-q",
-					"-i", sshKeyPath,
-					"-o", "StrictHostKeyChecking=accept-new",
-					"-o", "UserKnownHostsFile=/dev/null",
-					"-o", "ConnectTimeout=5",
-					fmt.Sprintf("%s@%s", sshUser, sshTargetIP),
-					pingCmd,
-				)
-			}
-
-			output, err := sshCmd.CombinedOutput()
-			result := strings.TrimSpace(string(output))
-
-			if err == nil && result == "SUCCESS" {
-				fmt.Printf("  ✓ %s → %s (%s)\n", sourceNode.Name, targetNode.Name, targetNode.TailscaleIP)
-				successCount++
-			} else {
-				fmt.Printf("  ✗ %s → %s (%s) - Failed\n", sourceNode.Name, targetNode.Name, targetNode.TailscaleIP)
-			}
+			pings = append(pings, vpnPingResult{
+				Source:   sourceNode.Name,
+				Target:   targetNode.Name,
+				TargetIP: targetNode.TailscaleIP,
+			})
 		}
 	}
 
-	// Test 3: Tailscale peer status check
-	fmt.Println()
-	printInfo("Test 3/3: Checking Tailscale peer status...")
-	fmt.Println()
+	return collectVPNPings(pings, vpnTestConcurrency, func(ping vpnPingResult) (string, error) {
+		sourceNode := sources[ping.Source]
 
-	peerStatusOK := 0
-	for _, node := range tsNodes {
 		// Determine target IP for SSH
-		sshTargetIP := node.PrivateIP
-		if !bastionEnabled || bastionIP == "" {
-			sshTargetIP = node.PublicIP
-		}
-		if sshTargetIP == "" {
-			sshTargetIP = node.PublicIP
-		}
-
-		// Get peer count from tailscale status
-		checkCmd := "sudo tailscale status --json 2>/dev/null | jq '.Peer | length' 2>/dev/null || echo '0'"
-		sshUser := getSSHUserForNode(node.Provider)
+		sshTargetIP := tailscaleTestSSHTarget(sourceNode.PublicIP, sourceNode.PrivateIP, bastionEnabled, bastionIP)
 
-		var sshCmd *exec.Cmd
-		if bastionEnabled && bastionIP != "" {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-				fmt.Sprintf("%s@%s", sshUser, sshTargetIP),
-				checkCmd,
-			)
-		} else {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=5",
-				fmt.Sprintf("%s@%s", sshUser, sshTargetIP),
-				checkCmd,
-			)
-		}
+		sshUser := sourceNode.SSHUser
+		sshCmd := nodeSSHCommand(sshKeyPath, sshUser, sshTargetIP, vpnBastionHop(bastionEnabled, bastionIP), 5*time.Second, vpnPingCommand(ping.TargetIP))
 
 		output, err := sshCmd.CombinedOutput()
-		if err == nil {
-			peerCount := strings.TrimSpace(string(output))
-			fmt.Printf("  ✓ %s - %s connected peers\n", node.Name, peerCount)
-			peerStatusOK++
-		} else {
-			fmt.Printf("  ✗ %s - Could not check peer status\n", node.Name)
-		}
-	}
-
-	// Summary
-	fmt.Println()
-	printInfo("Summary")
-	fmt.Println()
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
-
-	fmt.Fprintln(w, "METRIC\tRESULT")
-	fmt.Fprintln(w, "------\t------")
-	fmt.Fprintln(w, "VPN Mode\tTailscale (Headscale)")
-	fmt.Fprintf(w, "Total Nodes\t%d\n", len(tsNodes))
-
-	passRate := float64(0)
-	if totalTests > 0 {
-		passRate = float64(successCount) / float64(totalTests) * 100
-	}
-	fmt.Fprintf(w, "Ping Tests\t%d/%d passed (%.1f%%)\n", successCount, totalTests, passRate)
-	fmt.Fprintf(w, "Peer Status Checks\t%d/%d nodes responding\n", peerStatusOK, len(tsNodes))
-
-	if successCount == totalTests && peerStatusOK == len(tsNodes) {
-		fmt.Fprintln(w, "Overall Status\t✅ All tests passed")
-	} else if successCount > 0 {
-		fmt.Fprintln(w, "Overall Status\t⚠️  Some tests failed")
-	} else {
-		fmt.Fprintln(w, "Overall Status\t❌ All tests failed")
-	}
-
-	return nil
+		return string(output), err
+	})
 }
 
-func printVPNStatusTable(outputs auto.OutputMap, nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
-
-	// Detect VPN mode
-	vpnMode, cfg := detectVPNMode(outputs)
-
-	color.New(color.Bold).Fprintln(w, "METRIC\tVALUE")
-	fmt.Fprintln(w, "------\t-----")
-
-	if vpnMode == VPNModeTailscale {
-		printTailscaleStatus(w, outputs, cfg, nodes, sshKeyPath, bastionEnabled, bastionIP)
-	} else {
-		printWireGuardStatusTable(w, outputs, cfg, nodes)
-	}
-}
+// checkTailscalePeerStatus counts the tailnet peers every node sees
+func checkTailscalePeerStatus(tsNodes []tailscaleTestNode, sshKeyPath string, bastionEnabled bool, bastionIP string) []vpnNodeCheck {
+	checks := []vpnNodeCheck{}
+	for _, node := range tsNodes {
+		// Determine target IP for SSH
+		sshTargetIP := tailscaleTestSSHTarget(node.PublicIP, node.PrivateIP, bastionEnabled, bastionIP)
 
-// printTailscaleStatus prints Tailscale-specific status information
-func printTailscaleStatus(w *tabwriter.Writer, outputs auto.OutputMap, cfg *config.ClusterConfig, nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) {
-	fmt.Fprintln(w, "VPN Mode\tTailscale (Headscale)")
+		// Get peer count from tailscale status
+		checkCmd := "sudo tailscale status --json 2>/dev/null | jq '.Peer | length' 2>/dev/null || echo '0'"
+		sshUser := node.SSHUser
 
-	// Get Headscale URL from config or outputs
-	headscaleURL := ""
-	if cfg != nil && cfg.Network.Tailscale != nil {
-		headscaleURL = cfg.Network.Tailscale.HeadscaleURL
-	}
-	if headscaleURL == "" {
-		if urlOutput, ok := outputs["headscaleUrl"]; ok {
-			if url, ok := urlOutput.Value.(string); ok {
-				headscaleURL = url
-			}
-		}
-	}
-	if headscaleURL != "" {
-		fmt.Fprintf(w, "Coordination Server\t%s\n", headscaleURL)
-	}
+		sshCmd := nodeSSHCommand(sshKeyPath, sshUser, sshTargetIP, vpnBastionHop(bastionEnabled, bastionIP), 5*time.Second, checkCmd)
 
-	// Get Tailscale status from first reachable node
-	if len(nodes) > 0 {
-		status, peers := getTailscaleStatusFromNode(nodes, sshKeyPath, bastionEnabled, bastionIP)
-		if status != nil {
-			fmt.Fprintf(w, "Total Nodes\t%d\n", len(peers)+1) // +1 for self
-			fmt.Fprintf(w, "Connected Peers\t%d\n", countOnlinePeers(peers))
-			fmt.Fprintf(w, "VPN Subnet\t100.64.0.0/10\n")
-
-			// Determine overall status
-			onlineCount := countOnlinePeers(peers)
-			if onlineCount == len(peers) {
-				fmt.Fprintln(w, "Status\t✅ All peers connected")
-			} else if onlineCount > 0 {
-				fmt.Fprintf(w, "Status\t⚠️  %d/%d peers connected\n", onlineCount, len(peers))
-			} else {
-				fmt.Fprintln(w, "Status\t❌ No peers connected")
-			}
-		} else {
-			fmt.Fprintf(w, "Total Nodes\t%d\n", len(nodes))
-			fmt.Fprintln(w, "Status\t⚠️  Unable to fetch live status")
-		}
-	} else {
-		fmt.Fprintln(w, "Total Nodes\t0")
-		fmt.Fprintln(w, "Status\t⚠️  No nodes found")
+		output, err := sshCmd.CombinedOutput()
+		checks = append(checks, vpnNodeCheckFromOutput(node.Name, output, err))
 	}
+	return checks
 }
 
-// printWireGuardStatusTable prints WireGuard-specific status information
-func printWireGuardStatusTable(w *tabwriter.Writer, outputs auto.OutputMap, cfg *config.ClusterConfig, nodes []NodeInfo) {
-	fmt.Fprintln(w, "VPN Mode\tWireGuard Mesh")
-
-	nodeCount := len(nodes)
-	if nodeCount == 0 {
-		nodeCount = 6 // Fallback
-	}
-
-	// Calculate tunnels (full mesh: n*(n-1)/2)
-	tunnelCount := nodeCount * (nodeCount - 1) / 2
-
-	vpnSubnet := "10.8.0.0/24"
-	if cfg != nil && cfg.Network.WireGuard != nil && cfg.Network.WireGuard.SubnetCIDR != "" {
-		vpnSubnet = cfg.Network.WireGuard.SubnetCIDR
-	}
-
-	fmt.Fprintf(w, "Total Nodes\t%d\n", nodeCount)
-	fmt.Fprintf(w, "Total Tunnels\t%d\n", tunnelCount)
-	fmt.Fprintf(w, "VPN Subnet\t%s\n", vpnSubnet)
-	fmt.Fprintln(w, "Status\t✅ All tunnels active")
+// vpnLiveStatus is the mesh state read from a node. WireGuard peers are keyed
+// by name and online when they had a recent handshake.
+type vpnLiveStatus struct {
+	tailscalePeers []TailscalePeerInfo
+	wireGuardPeers map[string]bool
 }
 
 // getTailscaleStatusFromNode fetches Tailscale status from the first reachable node
@@ -1396,31 +1456,9 @@ func getTailscaleStatusFromNode(nodes []NodeInfo, sshKeyPath string, bastionEnab
 
 		// Build SSH command to get Tailscale status
 		statusCmd := "sudo tailscale status --json 2>/dev/null"
-		sshUser := getSSHUserForNode(node.Provider)
+		sshUser := sshUserForNode(node)
 
-		var sshCmd *exec.Cmd
-		if bastionEnabled && bastionIP != "" {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=10",
-				"-o", fmt.Sprintf("ProxyCommand=ssh -q -i %s -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=/dev/null -W %%h:%%p root@%s", sshKeyPath, bastionIP),
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				statusCmd,
-			)
-		} else {
-			sshCmd = exec.Command("ssh",
-				"-q",
-				"-i", sshKeyPath,
-				"-o", "StrictHostKeyChecking=accept-new",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "ConnectTimeout=10",
-				fmt.Sprintf("%s@%s", sshUser, targetIP),
-				statusCmd,
-			)
-		}
+		sshCmd := nodeSSHCommand(sshKeyPath, sshUser, targetIP, vpnBastionHop(bastionEnabled, bastionIP), 10*time.Second, statusCmd)
 
 		output, err := sshCmd.CombinedOutput()
 		if err != nil {
@@ -1493,6 +1531,11 @@ func parseTailscalePeers(status map[string]interface{}) []TailscalePeerInfo {
 				peer.LastSeen = t
 			}
 		}
+		if lastHandshakeStr, ok := peerInfo["LastHandshake"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, lastHandshakeStr); err == nil && t.Year() > 1 {
+				peer.LastHandshake = t
+			}
+		}
 
 		peers = append(peers, peer)
 	}
@@ -1537,6 +1580,12 @@ func runVPNJoin(cmd *cobra.Command, args []string) error {
 
 	printHeader(fmt.Sprintf("🔗 Joining VPN - Stack: %s", stack))
 
+	unlock, err := lockStack(ctx, stack, "vpn-join")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Create workspace with S3 support
 	workspace, err := createWorkspaceWithS3Support(ctx)
 	if err != nil {
@@ -1601,6 +1650,7 @@ func runVPNJoin(cmd *cobra.Command, args []string) error {
 
 	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
 		SSHKeyPath:     sshKeyPath,
+		PeerStore:      newVPNPeerStore(ctx),
 		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
 		ConnectTimeout: 30 * time.Second,
 	})
@@ -1649,6 +1699,15 @@ func runVPNJoin(cmd *cobra.Command, args []string) error {
 		printInfo(fmt.Sprintf("Using VPN IP: %s", vpnJoinIP))
 	}
 
+	// Keys from an earlier join of this machine are replaced on every node
+	previousKeys, err := supersededPeerKeys(vpnMgr, stack, vpnJoinIP, vpnJoinLabel)
+	if err != nil {
+		return fmt.Errorf("failed to read peer registry: %w", err)
+	}
+	if len(previousKeys) > 0 {
+		printInfo(fmt.Sprintf("Replacing %d previous registration(s) of this peer", len(previousKeys)))
+	}
+
 	// STEP 3: Generate WireGuard keypair
 	fmt.Println()
 	printInfo("Step 3/5: Generating WireGuard keypair...")
@@ -1673,91 +1732,35 @@ func runVPNJoin(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Use the robust connection manager for adding peers
-	connMgr := vpnMgr.GetConnectionManager()
-	configMgr := vpnMgr.GetConfigManager()
-	successCount := 0
-	failCount := 0
+	// Generate one preshared key per node when the cluster opts in
+	presharedKeys, err := clusterPresharedKeys(outputs, nodes)
+	if err != nil {
+		return err
+	}
 
-	peerConfig := vpn.PeerConfig{
+	keepalive := clientKeepalive(outputs)
+	connMgr := vpnMgr.GetConnectionManager()
+	successCount := addPeerToNodes(ctx, vpnMgr, nodes, vpn.PeerConfig{
 		PublicKey:  publicKey,
 		AllowedIPs: []string{vpnJoinIP + "/32"},
-		Keepalive:  25,
+		Keepalive:  keepalive,
 		Label:      vpnJoinLabel,
-	}
-
-	for i, node := range nodes {
-		printInfo(fmt.Sprintf("  [%d/%d] Adding peer to %s...", i+1, len(nodes), node.Name))
-
-		// Determine target IP based on connectivity:
-		// - If bastion is enabled: connect through bastion to VPN IP (bastion is inside the mesh)
-		// - If no bastion: connect directly to public IP (we're outside the mesh)
-		var targetIP string
-		if bastionEnabled && bastionIP != "" {
-			// Through bastion: prefer VPN IP, then private IP
-			targetIP = node.WireGuardIP
-			if targetIP == "" {
-				targetIP = node.PrivateIP
-			}
-		} else {
-			// Direct connection: must use public IP (we're outside the VPN)
-			targetIP = node.PublicIP
-		}
-
-		if targetIP == "" {
-			color.Yellow(fmt.Sprintf("  ⚠️  No reachable IP for %s, skipping", node.Name))
-			failCount++
-			continue
-		}
-
-		// Connect with retry
-		connCfg := vpn.ConnectionConfig{
-			Host:        targetIP,
-			User:        getSSHUserForNode(node.Provider),
-			UseBastion:  bastionEnabled && bastionIP != "",
-			BastionHost: bastionIP,
-			BastionUser: "root",
-			Timeout:     30 * time.Second,
-		}
-
-		conn, err := connMgr.Connect(ctx, connCfg)
-		if err != nil {
-			color.Yellow(fmt.Sprintf("  ⚠️  Failed to connect to %s: %v", node.Name, err))
-			failCount++
-			continue
-		}
-
-		// Add peer using ConfigManager (uses wg set - atomic operation)
-		if err := configMgr.AddPeer(ctx, conn, peerConfig); err != nil {
-			color.Yellow(fmt.Sprintf("  ⚠️  Failed to add peer to %s: %v", node.Name, err))
-			conn.Close()
-			failCount++
-			continue
-		}
-
-		conn.Close()
-		successCount++
-		printSuccess(fmt.Sprintf("  ✓ Added peer to %s", node.Name))
-
-		// Small delay between nodes
-		if bastionEnabled && i < len(nodes)-1 {
-			time.Sleep(1 * time.Second)
-		}
-	}
+	}, presharedKeys, previousKeys, bastionEnabled, bastionIP)
 
 	if successCount == 0 {
 		return fmt.Errorf("failed to add peer to any cluster node")
 	}
 
-	// Register peer in local registry
+	// Register peer in the shared registry
 	registeredPeer := vpn.RegisteredPeer{
-		PublicKey:  publicKey,
-		VPNIP:      vpnJoinIP,
-		Label:      vpnJoinLabel,
-		AllowedIPs: []string{vpnJoinIP + "/32"},
+		PublicKey:     publicKey,
+		VPNIP:         vpnJoinIP,
+		Label:         vpnJoinLabel,
+		AllowedIPs:    []string{vpnJoinIP + "/32"},
+		PresharedKeys: presharedKeys,
 	}
-	if err := vpnMgr.GetPeerRegistry().Register(stack, registeredPeer); err != nil {
-		color.Yellow(fmt.Sprintf("  ⚠️  Failed to register peer locally: %v", err))
+	if _, err := vpnMgr.Register(stack, registeredPeer); err != nil {
+		color.Yellow(fmt.Sprintf("  ⚠️  Failed to register peer: %v", err))
 	}
 
 	// STEP 5: Discover existing peers and generate client config
@@ -1765,70 +1768,10 @@ func runVPNJoin(cmd *cobra.Command, args []string) error {
 	printInfo("Step 5/5: Generating client configuration...")
 
 	// Fetch existing peers from cluster
-	var existingPeers []VPNPeerInfo
-	if len(nodes) > 0 {
-		firstNode := nodes[0]
-		targetIP := firstNode.WireGuardIP
-		if targetIP == "" {
-			targetIP = firstNode.PrivateIP
-			if targetIP == "" {
-				targetIP = firstNode.PublicIP
-			}
-		}
-
-		connCfg := vpn.ConnectionConfig{
-			Host:        targetIP,
-			User:        getSSHUserForNode(firstNode.Provider),
-			UseBastion:  bastionEnabled && bastionIP != "",
-			BastionHost: bastionIP,
-			BastionUser: "root",
-		}
-
-		if conn, err := connMgr.Connect(ctx, connCfg); err == nil {
-			listScript := `sudo wg show wg0 dump | tail -n +2 | while IFS=$'\t' read -r pubkey _ endpoint allowed_ips _; do
-				first_ip=$(echo "$allowed_ips" | cut -d, -f1 | cut -d/ -f1)
-				if [ -n "$first_ip" ] && [ "$first_ip" != "(none)" ]; then
-					echo "$pubkey|$first_ip"
-				fi
-			done`
-
-			if output, err := conn.Execute(listScript); err == nil {
-				lines := strings.Split(strings.TrimSpace(output), "\n")
-				for _, line := range lines {
-					if line == "" {
-						continue
-					}
-					parts := strings.Split(line, "|")
-					if len(parts) == 2 {
-						peerIP := strings.TrimSpace(parts[1])
-						peerKey := strings.TrimSpace(parts[0])
-						if peerIP != "" && peerIP != "(none)" {
-							// Filter cluster nodes (10.8.0.10-99)
-							if strings.HasPrefix(peerIP, "10.8.0.") {
-								ipParts := strings.Split(peerIP, ".")
-								if len(ipParts) == 4 {
-									var lastOctet int
-									if _, err := fmt.Sscanf(ipParts[3], "%d", &lastOctet); err == nil {
-										if lastOctet >= 10 && lastOctet < 100 {
-											continue
-										}
-									}
-								}
-							}
-							existingPeers = append(existingPeers, VPNPeerInfo{
-								PublicKey:  peerKey,
-								VPNAddress: peerIP,
-							})
-						}
-					}
-				}
-			}
-			conn.Close()
-		}
-	}
+	existingPeers := listExternalVPNPeers(ctx, connMgr, nodes, bastionEnabled, bastionIP)
 
 	// Generate client config
-	clientConfig := generateClientConfig(privateKey, vpnJoinIP, vpnJoinLabel, nodes, existingPeers, sshKeyPath, bastionEnabled, bastionIP)
+	clientConfig := generateClientConfig(privateKey, vpnJoinIP, vpnJoinLabel, nodes, existingPeers, presharedKeys, loadAdvertisedRoutes(stack), keepalive, sshKeyPath, bastionEnabled, bastionIP)
 
 	configPath := "./wg0-client.conf"
 	if err := os.WriteFile(configPath, []byte(clientConfig), 0600); err != nil {
@@ -2013,9 +1956,17 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 
 	printHeader(fmt.Sprintf("👋 Leaving VPN - Stack: %s", stack))
 
+	unlock, err := lockStack(ctx, stack, "vpn-leave")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Determine which peer to remove
 	var targetVPNIP string
-	if vpnLeaveIP != "" {
+	if vpnLeaveLabel != "" {
+		printInfo(fmt.Sprintf("Removing peer with label: %s", vpnLeaveLabel))
+	} else if vpnLeaveIP != "" {
 		targetVPNIP = vpnLeaveIP
 		printInfo(fmt.Sprintf("Removing peer with VPN IP: %s", targetVPNIP))
 	} else {
@@ -2083,6 +2034,7 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 	// Initialize VPN Manager
 	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
 		SSHKeyPath:     sshKeyPath,
+		PeerStore:      newVPNPeerStore(ctx),
 		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
 		ConnectTimeout: 30 * time.Second,
 	})
@@ -2096,13 +2048,21 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 
 	var peerPublicKey string
 
-	// Try to find in local registry
+	if vpnLeaveLabel != "" {
+		peer, err := vpnMgr.GetPeerByLabel(stack, vpnLeaveLabel)
+		if err != nil {
+			return err
+		}
+		targetVPNIP = peer.VPNIP
+	}
+
+	// Try to find in the peer registry
 	if peer, err := vpnMgr.GetPeerRegistry().GetByIP(stack, targetVPNIP); err == nil {
 		peerPublicKey = peer.PublicKey
-		printInfo(fmt.Sprintf("Found peer in registry: %s...", peerPublicKey[:16]))
+		printInfo(fmt.Sprintf("Found peer in registry: %s... (%s)", peerPublicKey[:16], targetVPNIP))
 	} else {
 		// Query cluster for public key
-		printInfo("Peer not in local registry, querying cluster...")
+		printInfo("Peer not in registry, querying cluster...")
 
 		if len(nodes) > 0 {
 			firstNode := nodes[0]
@@ -2119,7 +2079,7 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 
 			connCfg := vpn.ConnectionConfig{
 				Host:        nodeIP,
-				User:        getSSHUserForNode(firstNode.Provider),
+				User:        sshUserForNode(firstNode),
 				UseBastion:  bastionEnabled && bastionIP != "",
 				BastionHost: bastionIP,
 				BastionUser: "root",
@@ -2175,7 +2135,7 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 
 		connCfg := vpn.ConnectionConfig{
 			Host:        nodeIP,
-			User:        getSSHUserForNode(node.Provider),
+			User:        sshUserForNode(node),
 			UseBastion:  bastionEnabled && bastionIP != "",
 			BastionHost: bastionIP,
 			BastionUser: "root",
@@ -2205,11 +2165,15 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	printInfo("Step 3/3: Cleanup...")
 
-	// Remove from local registry
-	if err := vpnMgr.GetPeerRegistry().Unregister(stack, peerPublicKey); err != nil {
-		color.Yellow(fmt.Sprintf("  ⚠️  Failed to remove from registry: %v", err))
+	// Keep partially removed peers registered so 'vpn leave' can be retried
+	if successCount == len(nodes) {
+		if _, err := vpnMgr.RemoveByIP(stack, targetVPNIP); err == nil {
+			printSuccess("  ✓ Removed from peer registry")
+		} else {
+			printInfo("  Peer was not in the registry")
+		}
 	} else {
-		printSuccess("  ✓ Removed from local registry")
+		printInfo("  Peer kept in the registry until it is removed from every node")
 	}
 
 	// Summary
@@ -2224,7 +2188,7 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 	}
 
 	// If removing local machine, try to stop WireGuard
-	if vpnLeaveIP == "" {
+	if vpnLeaveIP == "" && vpnLeaveLabel == "" {
 		fmt.Println()
 		printInfo("Stopping local WireGuard interface...")
 
@@ -2240,135 +2204,641 @@ func runVPNLeave(cmd *cobra.Command, args []string) error {
 			return nil
 		}
 
-		if output, err := stopCmd.CombinedOutput(); err != nil {
-			color.Yellow(fmt.Sprintf("⚠️  Failed to stop WireGuard: %v", err))
-			color.Yellow(fmt.Sprintf("Output: %s", string(output)))
-			printWireGuardStopInstructions(targetVPNIP)
-		} else {
-			printSuccess("✓ WireGuard interface stopped successfully!")
-			fmt.Println()
-			color.Cyan("To remove WireGuard configuration:")
-			fmt.Println("  sudo rm /etc/wireguard/wg0.conf")
-		}
-	} else {
-		printWireGuardStopInstructions(targetVPNIP)
-	}
+		if output, err := stopCmd.CombinedOutput(); err != nil {
+			color.Yellow(fmt.Sprintf("⚠️  Failed to stop WireGuard: %v", err))
+			color.Yellow(fmt.Sprintf("Output: %s", string(output)))
+			printWireGuardStopInstructions(targetVPNIP)
+		} else {
+			printSuccess("✓ WireGuard interface stopped successfully!")
+			fmt.Println()
+			color.Cyan("To remove WireGuard configuration:")
+			fmt.Println("  sudo rm /etc/wireguard/wg0.conf")
+		}
+	} else {
+		printWireGuardStopInstructions(targetVPNIP)
+	}
+
+	// Record operation
+	details := fmt.Sprintf("Removed peer %s from %d/%d nodes", targetVPNIP, successCount, len(nodes))
+	status := "success"
+	if successCount < len(nodes) {
+		status = "partial"
+	}
+	operations.RecordVPNOperation(stack, "leave", "", "", status, details, len(nodes), time.Since(startTime), nil)
+
+	return nil
+}
+
+// printWireGuardStopInstructions prints instructions for stopping WireGuard
+func printWireGuardStopInstructions(vpnIP string) {
+	fmt.Println()
+	color.Cyan(fmt.Sprintf("To stop WireGuard on the removed machine (%s):", vpnIP))
+	fmt.Println("  sudo wg-quick down wg0")
+	fmt.Println("  sudo rm /etc/wireguard/wg0.conf")
+}
+
+func runVPNClientConfig(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	// Require a valid stack
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("📱 Generate Client Config - Stack: %s", stack))
+
+	// Create workspace with S3 support
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	// Use fully qualified stack name for S3 backend
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	// Parse nodes
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes found in stack")
+	}
+
+	if vpnConfigPeer != "" {
+		return runSinglePeerClientConfig(ctx, stack, outputs, nodes)
+	}
+
+	unlock, err := lockStack(ctx, stack, "vpn-client-config")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastionEnabled := bastionIP != ""
+
+	fmt.Println()
+	printInfo(fmt.Sprintf("Generating config for %d peer(s)", len(nodes)))
+
+	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
+		SSHKeyPath:     sshKeyPath,
+		PeerStore:      newVPNPeerStore(ctx),
+		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
+		ConnectTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize VPN manager: %w", err)
+	}
+
+	clientIP, err := assignClientVPNIP(vpnMgr, stack, vpnConfigLabel, "10.8.0.0/24")
+	if err != nil {
+		return err
+	}
+	printInfo(fmt.Sprintf("Client VPN IP: %s", clientIP))
+
+	previousKeys, err := supersededPeerKeys(vpnMgr, stack, clientIP, vpnConfigLabel)
+	if err != nil {
+		return fmt.Errorf("failed to read peer registry: %w", err)
+	}
+
+	// The private key is generated here and only written to the config;
+	// the nodes get the public key
+	privateKey, publicKey, err := generateWireGuardKeypair()
+	if err != nil {
+		return fmt.Errorf("failed to generate keypair: %w", err)
+	}
+
+	presharedKeys, err := clusterPresharedKeys(outputs, nodes)
+	if err != nil {
+		return err
+	}
+
+	keepalive := clientKeepalive(outputs)
+	successCount := addPeerToNodes(ctx, vpnMgr, nodes, vpn.PeerConfig{
+		PublicKey:  publicKey,
+		AllowedIPs: []string{clientIP + "/32"},
+		Keepalive:  keepalive,
+		Label:      vpnConfigLabel,
+	}, presharedKeys, previousKeys, bastionEnabled, bastionIP)
+	if successCount == 0 {
+		return fmt.Errorf("failed to add peer to any cluster node")
+	}
+
+	if _, err := vpnMgr.Register(stack, vpn.RegisteredPeer{
+		PublicKey:     publicKey,
+		VPNIP:         clientIP,
+		Label:         vpnConfigLabel,
+		AllowedIPs:    []string{clientIP + "/32"},
+		PresharedKeys: presharedKeys,
+	}); err != nil {
+		color.Yellow(fmt.Sprintf("  ⚠️  Failed to register peer: %v", err))
+	}
+
+	var otherClients []VPNPeerInfo
+	for _, peer := range listExternalVPNPeers(ctx, vpnMgr.GetConnectionManager(), nodes, bastionEnabled, bastionIP) {
+		if peer.VPNAddress != clientIP {
+			otherClients = append(otherClients, peer)
+		}
+	}
+
+	clientConfig := generateClientConfig(privateKey, clientIP, vpnConfigLabel, nodes, otherClients, presharedKeys, loadAdvertisedRoutes(stack), keepalive, sshKeyPath, bastionEnabled, bastionIP)
+	configPath, err := writeClientConfig(vpnConfigOutput, clientConfig, vpnConfigQR)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printInfo(fmt.Sprintf("Registered client %s on %d/%d nodes", clientIP, successCount, len(nodes)))
+	printClientConfigInstructions(configPath)
+
+	return nil
+}
+
+// runSinglePeerClientConfig registers a client on one hub node and writes a
+// config that reaches the whole VPN subnet through that node
+func runSinglePeerClientConfig(ctx context.Context, stack string, outputs auto.OutputMap, nodes []NodeInfo) error {
+	hub, err := findVPNPeerNode(nodes, vpnConfigPeer)
+	if err != nil {
+		return err
+	}
+
+	vpnSubnet := "10.8.0.0/24"
+	usePresharedKeys := false
+	if _, clusterCfg := detectVPNMode(outputs); clusterCfg != nil && clusterCfg.Network.WireGuard != nil {
+		if clusterCfg.Network.WireGuard.SubnetCIDR != "" {
+			vpnSubnet = clusterCfg.Network.WireGuard.SubnetCIDR
+		}
+		usePresharedKeys = clusterCfg.Network.WireGuard.UsePresharedKeys
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastionEnabled := bastionIP != ""
+
+	fmt.Println()
+	printInfo(fmt.Sprintf("Entry point: %s (%s, VPN IP %s)", hub.Name, hub.PublicIP, hub.WireGuardIP))
+
+	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
+		SSHKeyPath:     sshKeyPath,
+		PeerStore:      newVPNPeerStore(ctx),
+		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
+		ConnectTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize VPN manager: %w", err)
+	}
+
+	clientIP, err := assignClientVPNIP(vpnMgr, stack, vpnConfigLabel, vpnSubnet)
+	if err != nil {
+		return err
+	}
+	printInfo(fmt.Sprintf("Client VPN IP: %s", clientIP))
+
+	privateKey, publicKey, err := generateWireGuardKeypair()
+	if err != nil {
+		return fmt.Errorf("failed to generate keypair: %w", err)
+	}
+
+	hubPublicKey, err := fetchNodePublicKey(hub, sshKeyPath, bastionEnabled, bastionIP)
+	if err != nil {
+		return fmt.Errorf("failed to fetch public key from %s: %w", hub.Name, err)
+	}
+
+	var presharedKeys map[string]string
+	if usePresharedKeys {
+		presharedKeys, err = vpn.EnsurePresharedKeys([]string{hub.Name}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Register the client on the hub and let it route for the client
+	targetIP := hub.PublicIP
+	if bastionEnabled {
+		targetIP = hub.WireGuardIP
+	}
+	conn, err := vpnMgr.GetConnectionManager().Connect(ctx, vpn.ConnectionConfig{
+		Host:        targetIP,
+		User:        sshUserForNode(hub),
+		UseBastion:  bastionEnabled,
+		BastionHost: bastionIP,
+		BastionUser: "root",
+		Timeout:     30 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", hub.Name, err)
+	}
+	defer conn.Close()
+
+	keepalive := clientKeepalive(outputs)
+	if err := vpnMgr.GetConfigManager().AddPeer(ctx, conn, vpn.PeerConfig{
+		PublicKey:    publicKey,
+		AllowedIPs:   []string{clientIP + "/32"},
+		Keepalive:    keepalive,
+		Label:        vpnConfigLabel,
+		PresharedKey: presharedKeys[hub.Name],
+	}); err != nil {
+		return fmt.Errorf("failed to add peer to %s: %w", hub.Name, err)
+	}
+	if _, err := conn.Execute(hubRoutingScript(clientIP)); err != nil {
+		return fmt.Errorf("failed to enable routing on %s: %w", hub.Name, err)
+	}
+	printSuccess(fmt.Sprintf("Registered client on %s", hub.Name))
+
+	superseded, err := vpnMgr.Register(stack, vpn.RegisteredPeer{
+		PublicKey:     publicKey,
+		VPNIP:         clientIP,
+		Label:         vpnConfigLabel,
+		AllowedIPs:    []string{clientIP + "/32"},
+		PresharedKeys: presharedKeys,
+	})
+	if err != nil {
+		color.Yellow(fmt.Sprintf("  ⚠️  Failed to register peer: %v", err))
+	}
+	for _, previous := range superseded {
+		if err := vpnMgr.GetConfigManager().RemovePeer(ctx, conn, previous.PublicKey); err != nil {
+			color.Yellow(fmt.Sprintf("  ⚠️  Failed to remove previous key from %s: %v", hub.Name, err))
+		}
+	}
+
+	routes := loadAdvertisedRoutes(stack)
+	clientConfig := generateSinglePeerClientConfig(privateKey, clientIP, vpnConfigLabel, hub, hubPublicKey, presharedKeys[hub.Name], vpnSubnet, routes, keepalive)
+
+	configPath, err := writeClientConfig(vpnConfigOutput, clientConfig, vpnConfigQR)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	printInfo(fmt.Sprintf("All traffic to %s is routed through %s", vpnSubnet, hub.Name))
+	for _, route := range routes {
+		printInfo(fmt.Sprintf("Route %s via %s", route.CIDR, route.Via))
+	}
+	printClientConfigInstructions(configPath)
+
+	return nil
+}
+
+// assignClientVPNIP returns the IP registered for label, so regenerated
+// configs stay stable, or the next free IP in subnet above the node range
+func assignClientVPNIP(vpnMgr *vpn.Manager, stack, label, subnet string) (string, error) {
+	if label != "" {
+		if existingPeer, err := vpnMgr.GetPeerByLabel(stack, label); err == nil {
+			return existingPeer.VPNIP, nil
+		}
+	}
+
+	var reservedIPs []string
+	for i := 1; i < 100; i++ {
+		reservedIPs = append(reservedIPs, fmt.Sprintf("10.8.0.%d", i))
+	}
+	clientIP, err := vpnMgr.GetPeerRegistry().NextAvailableIP(stack, subnet, reservedIPs)
+	if err != nil {
+		return "", fmt.Errorf("failed to assign VPN IP: %w", err)
+	}
+	return clientIP, nil
+}
+
+// writeClientConfig saves a client config to path, ./wg0-client.conf by
+// default. A .png path gets the config as a QR code image instead of text;
+// with showQR the QR code is also printed to the terminal. Both hold the
+// private key, so the file is only readable by the user.
+func writeClientConfig(path, clientConfig string, showQR bool) (string, error) {
+	if path == "" {
+		path = "./wg0-client.conf"
+	}
+	asPNG := strings.EqualFold(filepath.Ext(path), ".png")
+
+	var code *qrcode.Code
+	if showQR || asPNG {
+		var err error
+		if code, err = qrcode.Encode([]byte(clientConfig)); err != nil {
+			return "", fmt.Errorf("failed to encode client config as QR code: %w", err)
+		}
+	}
+
+	if asPNG {
+		var buf bytes.Buffer
+		if err := code.WritePNG(&buf, 8); err != nil {
+			return "", fmt.Errorf("failed to render QR code: %w", err)
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+			return "", fmt.Errorf("failed to write QR code image: %w", err)
+		}
+		printSuccess(fmt.Sprintf("Client configuration QR code saved to: %s", path))
+	} else {
+		if err := os.WriteFile(path, []byte(clientConfig), 0600); err != nil {
+			return "", fmt.Errorf("failed to write config file: %w", err)
+		}
+		printSuccess(fmt.Sprintf("Client configuration saved to: %s", path))
+	}
+
+	if showQR {
+		fmt.Println()
+		color.Cyan("Scan with the WireGuard mobile app (Add tunnel → Create from QR code):")
+		fmt.Println()
+		fmt.Print(code.Terminal())
+	}
+	return path, nil
+}
+
+// printClientConfigInstructions prints how to use a config written by
+// writeClientConfig
+func printClientConfigInstructions(configPath string) {
+	if strings.EqualFold(filepath.Ext(configPath), ".png") {
+		color.Cyan("To import the configuration on a phone:")
+		fmt.Println()
+		fmt.Println("  1. Install the WireGuard app: https://www.wireguard.com/install/")
+		fmt.Printf("  2. Add tunnel → Create from QR code, and scan %s\n", configPath)
+		fmt.Println("  3. Delete the image once imported; it contains the private key")
+		return
+	}
+	printVPNInstallInstructions(configPath)
+}
+
+// newVPNPeerStore returns the stack backend's store, where the peer registry
+// is shared between machines. On error the registry stays local.
+func newVPNPeerStore(ctx context.Context) operations.LockStore {
+	_ = common.LoadSavedConfig()
+
+	store, err := operations.NewLockStoreForBackend(ctx, os.Getenv("PULUMI_BACKEND_URL"))
+	if err != nil {
+		printWarning(fmt.Sprintf("Using the local peer registry: %v", err))
+		return nil
+	}
+	return store
+}
+
+// supersededPeerKeys returns the keys of registered peers a join with vpnIP
+// and label replaces, matching what the registry drops on Register
+func supersededPeerKeys(vpnMgr *vpn.Manager, stack, vpnIP, label string) ([]string, error) {
+	peers, err := vpnMgr.ListPeers(stack)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, peer := range peers {
+		if peer.VPNIP == vpnIP || (label != "" && peer.Label == label) {
+			keys = append(keys, peer.PublicKey)
+		}
+	}
+	return keys, nil
+}
+
+// clusterPresharedKeys returns one new preshared key per node when the
+// cluster opts in to preshared keys, and nil otherwise
+func clusterPresharedKeys(outputs auto.OutputMap, nodes []NodeInfo) (map[string]string, error) {
+	_, clusterCfg := detectVPNMode(outputs)
+	if clusterCfg == nil || clusterCfg.Network.WireGuard == nil || !clusterCfg.Network.WireGuard.UsePresharedKeys {
+		return nil, nil
+	}
+
+	nodeNames := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	presharedKeys, err := vpn.EnsurePresharedKeys(nodeNames, nil)
+	if err != nil {
+		return nil, err
+	}
+	printInfo("Using preshared keys for all tunnels")
+	return presharedKeys, nil
+}
+
+// clientKeepalive returns the PersistentKeepalive for client tunnels: the
+// cluster's configured value, otherwise the default, since clients are
+// usually behind NAT
+func clientKeepalive(outputs auto.OutputMap) int {
+	var wgCfg *config.WireGuardConfig
+	if _, clusterCfg := detectVPNMode(outputs); clusterCfg != nil {
+		wgCfg = clusterCfg.Network.WireGuard
+	}
+	return wgCfg.Keepalive(true)
+}
+
+// keepaliveLine returns the PersistentKeepalive line for a [Peer] block, or ""
+// when keepalive is 0
+func keepaliveLine(keepalive int) string {
+	if keepalive <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("PersistentKeepalive = %d\n", keepalive)
+}
+
+// addPeerToNodes adds peer to every cluster node, with the node's preshared
+// key, and removes previousKeys from them. It returns how many nodes took the
+// peer; failures are reported and skipped.
+func addPeerToNodes(ctx context.Context, vpnMgr *vpn.Manager, nodes []NodeInfo, peer vpn.PeerConfig, presharedKeys map[string]string, previousKeys []string, bastionEnabled bool, bastionIP string) int {
+	// Use the robust connection manager for adding peers
+	connMgr := vpnMgr.GetConnectionManager()
+	configMgr := vpnMgr.GetConfigManager()
+	successCount := 0
+
+	for i, node := range nodes {
+		printInfo(fmt.Sprintf("  [%d/%d] Adding peer to %s...", i+1, len(nodes), node.Name))
+
+		peerConfig := peer
+		peerConfig.PresharedKey = presharedKeys[node.Name]
+
+		// Determine target IP based on connectivity:
+		// - If bastion is enabled: connect through bastion to VPN IP (bastion is inside the mesh)
+		// - If no bastion: connect directly to public IP (we're outside the mesh)
+		var targetIP string
+		if bastionEnabled && bastionIP != "" {
+			// Through bastion: prefer VPN IP, then private IP
+			targetIP = node.WireGuardIP
+			if targetIP == "" {
+				targetIP = node.PrivateIP
+			}
+		} else {
+			// Direct connection: must use public IP (we're outside the VPN)
+			targetIP = node.PublicIP
+		}
+
+		if targetIP == "" {
+			color.Yellow(fmt.Sprintf("  ⚠️  No reachable IP for %s, skipping", node.Name))
+			continue
+		}
+
+		// Connect with retry
+		connCfg := vpn.ConnectionConfig{
+			Host:        targetIP,
+			User:        sshUserForNode(node),
+			UseBastion:  bastionEnabled && bastionIP != "",
+			BastionHost: bastionIP,
+			BastionUser: "root",
+			Timeout:     30 * time.Second,
+		}
+
+		conn, err := connMgr.Connect(ctx, connCfg)
+		if err != nil {
+			color.Yellow(fmt.Sprintf("  ⚠️  Failed to connect to %s: %v", node.Name, err))
+			continue
+		}
+
+		// Add peer using ConfigManager (uses wg set - atomic operation)
+		if err := configMgr.AddPeer(ctx, conn, peerConfig); err != nil {
+			color.Yellow(fmt.Sprintf("  ⚠️  Failed to add peer to %s: %v", node.Name, err))
+			conn.Close()
+			continue
+		}
+		for _, previousKey := range previousKeys {
+			if err := configMgr.RemovePeer(ctx, conn, previousKey); err != nil {
+				color.Yellow(fmt.Sprintf("  ⚠️  Failed to remove previous key from %s: %v", node.Name, err))
+			}
+		}
+
+		conn.Close()
+		successCount++
+		printSuccess(fmt.Sprintf("  ✓ Added peer to %s", node.Name))
 
-	// Record operation
-	details := fmt.Sprintf("Removed peer %s from %d/%d nodes", targetVPNIP, successCount, len(nodes))
-	status := "success"
-	if successCount < len(nodes) {
-		status = "partial"
+		// Small delay between nodes
+		if bastionEnabled && i < len(nodes)-1 {
+			time.Sleep(1 * time.Second)
+		}
 	}
-	operations.RecordVPNOperation(stack, "leave", "", "", status, details, len(nodes), time.Since(startTime), nil)
-
-	return nil
-}
 
-// printWireGuardStopInstructions prints instructions for stopping WireGuard
-func printWireGuardStopInstructions(vpnIP string) {
-	fmt.Println()
-	color.Cyan(fmt.Sprintf("To stop WireGuard on the removed machine (%s):", vpnIP))
-	fmt.Println("  sudo wg-quick down wg0")
-	fmt.Println("  sudo rm /etc/wireguard/wg0.conf")
+	return successCount
 }
 
-func runVPNClientConfig(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-
-	// Require a valid stack
-	stack, err := RequireStack(args)
-	if err != nil {
-		return err
-	}
-
-	printHeader(fmt.Sprintf("📱 Generate Client Config - Stack: %s", stack))
+// listExternalVPNPeers returns the peers of the first node that are not
+// cluster nodes, so a new client can reach the other clients directly
+func listExternalVPNPeers(ctx context.Context, connMgr *vpn.ConnectionManager, nodes []NodeInfo, bastionEnabled bool, bastionIP string) []VPNPeerInfo {
+	var existingPeers []VPNPeerInfo
+	if len(nodes) > 0 {
+		firstNode := nodes[0]
+		targetIP := firstNode.WireGuardIP
+		if targetIP == "" {
+			targetIP = firstNode.PrivateIP
+			if targetIP == "" {
+				targetIP = firstNode.PublicIP
+			}
+		}
 
-	// Create workspace with S3 support
-	workspace, err := createWorkspaceWithS3Support(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to create workspace: %w", err)
-	}
+		connCfg := vpn.ConnectionConfig{
+			Host:        targetIP,
+			User:        sshUserForNode(firstNode),
+			UseBastion:  bastionEnabled && bastionIP != "",
+			BastionHost: bastionIP,
+			BastionUser: "root",
+		}
 
-	// Use fully qualified stack name for S3 backend
-	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
-	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
-	if err != nil {
-		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
-	}
+		if conn, err := connMgr.Connect(ctx, connCfg); err == nil {
+			listScript := `sudo wg show wg0 dump | tail -n +2 | while IFS=$'\t' read -r pubkey _ endpoint allowed_ips _; do
+				first_ip=$(echo "$allowed_ips" | cut -d, -f1 | cut -d/ -f1)
+				if [ -n "$first_ip" ] && [ "$first_ip" != "(none)" ]; then
+					echo "$pubkey|$first_ip"
+				fi
+			done`
 
-	outputs, err := s.Outputs(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get stack outputs: %w", err)
+			if output, err := conn.Execute(listScript); err == nil {
+				lines := strings.Split(strings.TrimSpace(output), "\n")
+				for _, line := range lines {
+					if line == "" {
+						continue
+					}
+					parts := strings.Split(line, "|")
+					if len(parts) == 2 {
+						peerIP := strings.TrimSpace(parts[1])
+						peerKey := strings.TrimSpace(parts[0])
+						if peerIP != "" && peerIP != "(none)" {
+							// Filter cluster nodes (10.8.0.10-99)
+							if strings.HasPrefix(peerIP, "10.8.0.") {
+								ipParts := strings.Split(peerIP, ".")
+								if len(ipParts) == 4 {
+									var lastOctet int
+									if _, err := fmt.Sscanf(ipParts[3], "%d", &lastOctet); err == nil {
+										if lastOctet >= 10 && lastOctet < 100 {
+											continue
+										}
+									}
+								}
+							}
+							existingPeers = append(existingPeers, VPNPeerInfo{
+								PublicKey:  peerKey,
+								VPNAddress: peerIP,
+							})
+						}
+					}
+				}
+			}
+			conn.Close()
+		}
 	}
 
-	// Parse nodes
-	nodes, err := ParseNodeOutputs(outputs)
-	if err != nil {
-		return fmt.Errorf("failed to parse nodes: %w", err)
-	}
+	return existingPeers
+}
 
-	if len(nodes) == 0 {
-		return fmt.Errorf("no nodes found in stack")
+// findVPNPeerNode returns the named node, which must have a WireGuard IP to act as a hub
+func findVPNPeerNode(nodes []NodeInfo, name string) (NodeInfo, error) {
+	for _, node := range nodes {
+		if node.Name != name {
+			continue
+		}
+		if node.WireGuardIP == "" {
+			return NodeInfo{}, fmt.Errorf("node '%s' has no WireGuard IP", name)
+		}
+		return node, nil
 	}
+	return NodeInfo{}, fmt.Errorf("node '%s' not found in stack", name)
+}
 
-	fmt.Println()
-	printInfo(fmt.Sprintf("Generating config for %d peer(s)", len(nodes)))
+// hubRoutingScript lets the hub forward a client's traffic into the mesh. The
+// client is masqueraded so other nodes answer the hub, whose IP they route.
+func hubRoutingScript(clientIP string) string {
+	return fmt.Sprintf(`set -e
+sudo sysctl -qw net.ipv4.ip_forward=1
+sudo iptables -C FORWARD -i wg0 -o wg0 -j ACCEPT 2>/dev/null || sudo iptables -A FORWARD -i wg0 -o wg0 -j ACCEPT
+sudo iptables -t nat -C POSTROUTING -s %[1]s/32 -o wg0 -j MASQUERADE 2>/dev/null || sudo iptables -t nat -A POSTROUTING -s %[1]s/32 -o wg0 -j MASQUERADE
+`, clientIP)
+}
 
-	if vpnConfigOutput != "" {
-		printInfo(fmt.Sprintf("Output file: %s", vpnConfigOutput))
+// generateSinglePeerClientConfig generates a client config with the hub as its
+// only peer, covering the whole VPN subnet and every advertised route, which
+// the hub forwards to their routers over the mesh
+func generateSinglePeerClientConfig(privateKey, clientIP, peerLabel string, hub NodeInfo, hubPublicKey, presharedKey, vpnSubnet string, routes []vpn.AdvertisedRoute, keepalive int) string {
+	labelComment := ""
+	if peerLabel != "" {
+		labelComment = fmt.Sprintf("# Peer Label: %s\n", peerLabel)
 	}
 
-	if vpnConfigQR {
-		printInfo("QR code generation enabled")
+	pskLine := ""
+	if presharedKey != "" {
+		pskLine = fmt.Sprintf("PresharedKey = %s\n", presharedKey)
 	}
 
-	fmt.Println()
-	color.Yellow("⚠️  Client config generation will be implemented in next phase")
-	color.Cyan("\nWhat will be implemented:")
-	fmt.Println("  • Generate new WireGuard keypair")
-	fmt.Println("  • Create [Interface] section with private key and VPN IP")
-	fmt.Println("  • Create [Peer] sections for all cluster nodes")
-	fmt.Println("  • Save to file (default: ./wg0.conf)")
-	if vpnConfigQR {
-		fmt.Println("  • Generate QR code using 'qrencode' for mobile import")
+	allowedIPs := []string{vpnSubnet}
+	for _, route := range routes {
+		allowedIPs = append(allowedIPs, route.CIDR)
 	}
 
-	fmt.Println()
-	color.Cyan("Example output format:")
-	fmt.Print(`
-[Interface]
-PrivateKey = <generated-private-key>
-Address = 10.8.0.100/24
+	return fmt.Sprintf(`[Interface]
+# WireGuard Client Configuration
+# Generated by sloth-kubernetes CLI
+%sPrivateKey = %s
+Address = %s/32
 DNS = 1.1.1.1
 
 [Peer]
-PublicKey = <master-1-public-key>
-Endpoint = 167.71.1.1:51820
-AllowedIPs = 10.8.0.10/32
-
-[Peer]
-PublicKey = <worker-1-public-key>
-Endpoint = 172.236.1.1:51820
-AllowedIPs = 10.8.0.11/32
-`)
-
-	return nil
-}
-
-// getSSHUserForNode returns the correct SSH username based on node provider
-// Azure uses "azureuser", AWS/GCP use "ubuntu", others use "root"
-func getSSHUserForNode(provider string) string {
-	switch provider {
-	case "azure":
-		return "azureuser"
-	case "aws":
-		return "ubuntu"
-	case "gcp":
-		return "ubuntu"
-	default:
-		return "root" // DigitalOcean, Linode, and others use "root"
-	}
+# %s (%s) - single entry point
+PublicKey = %s
+%sEndpoint = %s:51820
+AllowedIPs = %s
+%s`, labelComment, privateKey, clientIP, hub.Name, hub.Provider, hubPublicKey, pskLine, hub.PublicIP, strings.Join(allowedIPs, ", "), keepaliveLine(keepalive))
 }
 
 // generateWireGuardKeypair generates a WireGuard private/public keypair
@@ -2397,7 +2867,8 @@ func generateWireGuardKeypair() (privateKey string, publicKey string, err error)
 
 // generatePeerAddScript creates a bash script to add a peer to WireGuard config
 // It uses escaped echo commands to write the configuration safely
-func generatePeerAddScript(peerIP string, peerPublicKey string, peerLabel string) string {
+// A keepalive of 0 leaves PersistentKeepalive out of the peer
+func generatePeerAddScript(peerIP string, peerPublicKey string, peerLabel string, keepalive int) string {
 	comment := "Client joined via CLI"
 	if peerLabel != "" {
 		comment = fmt.Sprintf("Peer: %s", peerLabel)
@@ -2408,6 +2879,11 @@ func generatePeerAddScript(peerIP string, peerPublicKey string, peerLabel string
 	peerPublicKey = strings.ReplaceAll(peerPublicKey, "'", "'\\''")
 	peerIP = strings.ReplaceAll(peerIP, "'", "'\\''")
 
+	keepaliveEcho := ""
+	if keepalive > 0 {
+		keepaliveEcho = fmt.Sprintf("echo \"PersistentKeepalive = %d\" | sudo tee -a /etc/wireguard/wg0.conf >/dev/null\n", keepalive)
+	}
+
 	// Use escaped echo commands with single quotes to write configuration safely
 	// Single quotes prevent any shell expansion, and we escape any single quotes in the values
 	return fmt.Sprintf(`
@@ -2456,13 +2932,12 @@ echo "[Peer]" | sudo tee -a /etc/wireguard/wg0.conf >/dev/null
 echo "# %s" | sudo tee -a /etc/wireguard/wg0.conf >/dev/null
 echo "PublicKey = %s" | sudo tee -a /etc/wireguard/wg0.conf >/dev/null
 echo "AllowedIPs = %s/32" | sudo tee -a /etc/wireguard/wg0.conf >/dev/null
-echo "PersistentKeepalive = 25" | sudo tee -a /etc/wireguard/wg0.conf >/dev/null
-
+%s
 # Step 3: Reload WireGuard configuration
 echo "Reloading WireGuard..."
 sudo wg-quick strip wg0 | sudo wg syncconf wg0 /dev/stdin
 echo "Peer added and WireGuard reloaded successfully!"
-`, comment, peerPublicKey, peerIP)
+`, comment, peerPublicKey, peerIP, keepaliveEcho)
 }
 
 // fetchNodePublicKey fetches the WireGuard public key from a node via SSH
@@ -2477,7 +2952,7 @@ func fetchNodePublicKey(node NodeInfo, sshKeyPath string, bastionEnabled bool, b
 	}
 
 	// Build SSH command with sudo for permission and retry for connection issues
-	sshUser := getSSHUserForNode(node.Provider)
+	sshUser := sshUserForNode(node)
 
 	// Try up to 3 times to handle transient SSH connection issues
 	var output []byte
@@ -2535,8 +3010,10 @@ func fetchNodePublicKey(node NodeInfo, sshKeyPath string, bastionEnabled bool, b
 	return publicKey, nil
 }
 
-// generateClientConfig generates a complete WireGuard client configuration
-func generateClientConfig(privateKey string, clientIP string, peerLabel string, nodes []NodeInfo, existingPeers []VPNPeerInfo, sshKeyPath string, bastionEnabled bool, bastionIP string) string {
+// generateClientConfig generates a complete WireGuard client configuration.
+// presharedKeys maps node name to the PSK shared with that node and may be nil.
+// Every peer gets PersistentKeepalive = keepalive seconds; 0 leaves it out.
+func generateClientConfig(privateKey string, clientIP string, peerLabel string, nodes []NodeInfo, existingPeers []VPNPeerInfo, presharedKeys map[string]string, routes []vpn.AdvertisedRoute, keepalive int, sshKeyPath string, bastionEnabled bool, bastionIP string) string {
 	labelComment := ""
 	if peerLabel != "" {
 		labelComment = fmt.Sprintf("# Peer Label: %s\n", peerLabel)
@@ -2569,14 +3046,20 @@ DNS = 1.1.1.1
 			publicKey = "<PUBLIC_KEY_PLACEHOLDER>"
 		}
 
+		pskLine := ""
+		if psk := presharedKeys[node.Name]; psk != "" {
+			pskLine = fmt.Sprintf("PresharedKey = %s\n", psk)
+		}
+
+		allowedIPs := append([]string{node.WireGuardIP + "/32", "10.0.0.0/8"}, vpn.RoutesVia(routes, node.Name)...)
+
 		config += fmt.Sprintf(`
 [Peer]
 # %s (%s)
 PublicKey = %s
-Endpoint = %s:51820
-AllowedIPs = %s/32, 10.0.0.0/8
-PersistentKeepalive = 25
-`, node.Name, node.Provider, publicKey, node.PublicIP, node.WireGuardIP)
+%sEndpoint = %s:51820
+AllowedIPs = %s
+%s`, node.Name, node.Provider, publicKey, pskLine, node.PublicIP, strings.Join(allowedIPs, ", "), keepaliveLine(keepalive))
 	}
 
 	// Add existing VPN clients as peers for full mesh
@@ -2591,8 +3074,7 @@ PersistentKeepalive = 25
 PublicKey = %s
 Endpoint = %s:51820
 AllowedIPs = %s/32, 192.168.0.0/16
-PersistentKeepalive = 25
-`, peer.PublicKey, bastionIP, peer.VPNAddress)
+%s`, peer.PublicKey, bastionIP, peer.VPNAddress, keepaliveLine(keepalive))
 		} else {
 			// Regular external VPN client without endpoint
 			config += fmt.Sprintf(`
@@ -2600,8 +3082,7 @@ PersistentKeepalive = 25
 # External VPN Client
 PublicKey = %s
 AllowedIPs = %s/32
-PersistentKeepalive = 25
-`, peer.PublicKey, peer.VPNAddress)
+%s`, peer.PublicKey, peer.VPNAddress, keepaliveLine(keepalive))
 		}
 	}
 
@@ -2649,9 +3130,11 @@ func formatBytes(bytes int64) string {
 		float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-// runVPNConnect connects the local machine to the Tailscale VPN mesh
+// runVPNConnect connects the local machine to the Tailscale or WireGuard VPN mesh
 func runVPNConnect(cmd *cobra.Command, args []string) error {
-	ctx, cancel := context.WithCancel(context.Background())
+	// SIGINT/SIGTERM cancel the connection at any point, and tear it down
+	// once it is up
+	ctx, cancel := signal.NotifyContext(context.Background(), signalInterrupt, signalTerminate)
 	defer cancel()
 
 	// Require a valid stack
@@ -2660,13 +3143,21 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Handle daemon mode - spawn background process
-	if vpnConnectDaemon && !vpnConnectInternalDaemon {
-		// Check if already running
-		if tailscale.IsDaemonRunning(stack) {
-			return fmt.Errorf("VPN daemon is already running for stack '%s'. Use 'vpn disconnect' first", stack)
-		}
+	if vpnConnectStatus {
+		return runVPNConnectStatus(ctx, stack)
+	}
+
+	// Internal daemon mode - run silently
+	if vpnConnectInternalDaemon {
+		return runVPNConnectDaemon(ctx, stack)
+	}
+
+	if err := ensureNoVPNConnection(stack, vpnConnectForce); err != nil {
+		return err
+	}
 
+	// Handle daemon mode - spawn background process
+	if vpnConnectDaemon {
 		printHeader(fmt.Sprintf("🔌 VPN Connect (Daemon) - Stack: %s", stack))
 		fmt.Println()
 
@@ -2680,6 +3171,12 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 		if vpnConnectHostname != "" {
 			daemonArgs = append(daemonArgs, "--hostname", vpnConnectHostname)
 		}
+		if vpnConnectUserspace {
+			daemonArgs = append(daemonArgs, "--userspace")
+		}
+		if vpnConnectExitNode != "" {
+			daemonArgs = append(daemonArgs, "--exit-node", vpnConnectExitNode)
+		}
 
 		daemonCmd := exec.Command(execPath, daemonArgs...)
 		daemonCmd.Stdout = nil
@@ -2697,21 +3194,48 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 
 		daemonPid := daemonCmd.Process.Pid
 
+		// Record the daemon right away so a concurrent connect refuses
+		if err := tailscale.SaveDaemonState(stack, tailscale.DaemonState{
+			PID:       daemonPid,
+			Mode:      tailscale.ModeDaemon,
+			Hostname:  vpnConnectHostname,
+			StartedAt: time.Now(),
+		}); err != nil {
+			printWarning(fmt.Sprintf("Failed to save daemon state: %v", err))
+		}
+
+		// Reap the daemon if it dies while we wait, so it is not seen as running
+		exited := make(chan error, 1)
+		go func() { exited <- daemonCmd.Wait() }()
+
 		// Wait for connection to establish and check periodically
 		printInfo("Waiting for VPN connection to establish...")
+		waitSeconds := 10
+		if vpnConnectExitNode != "" {
+			// Setting up and switching to the exit node takes longer
+			waitSeconds += int(exitNodeTimeout / time.Second)
+		}
 		connected := false
-		for i := 0; i < 10; i++ {
-			time.Sleep(1 * time.Second)
-			// Check if process is still running (platform-specific)
-			if !isProcessRunning(daemonPid) {
-				// Process died
+		for i := 0; i < waitSeconds; i++ {
+			select {
+			case err := <-exited:
+				tailscale.RemoveDaemonState(stack)
 				printWarning("VPN daemon process exited unexpectedly")
+				if err != nil {
+					return fmt.Errorf("daemon process exited: %w", err)
+				}
 				return fmt.Errorf("daemon process exited")
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(1 * time.Second):
 			}
-			// Check if PID file exists (means connection was successful)
+			// Ready once the health endpoint reports a tailnet connection
 			if tailscale.IsDaemonRunning(stack) {
-				connected = true
-				break
+				health, err := tailscale.QueryHealth(ctx, tailscale.GetHealthSocket(stack))
+				if err == nil && health.Healthy {
+					connected = true
+					break
+				}
 			}
 		}
 
@@ -2728,17 +3252,12 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 		} else {
 			// Process is running but not yet connected - might still be connecting
 			printWarning(fmt.Sprintf("VPN daemon started (PID: %d) but connection may still be establishing", daemonPid))
-			fmt.Println("  Check status with 'sloth vpn status " + stack + "'")
+			fmt.Println("  Check status with 'sloth vpn connect " + stack + " --status'")
 		}
 
 		return nil
 	}
 
-	// Internal daemon mode - run silently
-	if vpnConnectInternalDaemon {
-		return runVPNConnectDaemon(ctx, stack)
-	}
-
 	printHeader(fmt.Sprintf("🔌 VPN Connect - Stack: %s", stack))
 
 	// Create workspace with S3 support
@@ -2762,8 +3281,12 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 
 	// Check VPN mode
 	vpnMode, clusterConfig := detectVPNMode(outputs)
-	if vpnMode != VPNModeTailscale {
-		return fmt.Errorf("'vpn connect' only works with Tailscale mode. This stack uses WireGuard. Use 'vpn join' instead")
+	if vpnMode == VPNModeWireGuard {
+		if vpnConnectExitNode != "" {
+			return fmt.Errorf("--exit-node is only supported in Tailscale mode")
+		}
+		fmt.Println()
+		return runVPNConnectWireGuard(ctx, stack, outputs)
 	}
 
 	fmt.Println()
@@ -2810,6 +3333,14 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 	printInfo(fmt.Sprintf("Headscale URL: %s", headscaleURL))
 	printInfo(fmt.Sprintf("Namespace: %s", namespace))
 
+	// Make sure the exit node is advertised and approved before joining
+	if vpnConnectExitNode != "" {
+		if err := prepareExitNode(ctx, stack, outputs, vpnConnectExitNode, printInfo); err != nil {
+			return err
+		}
+		printSuccess(fmt.Sprintf("%s is an approved exit node", vpnConnectExitNode))
+	}
+
 	// Generate auth key via Headscale API
 	printInfo("Generating ephemeral auth key...")
 
@@ -2846,6 +3377,17 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create embedded client: %w", err)
 	}
 
+	// Record this process so a second connect refuses and disconnect can stop it
+	if err := tailscale.SaveDaemonState(stack, tailscale.DaemonState{
+		PID:       os.Getpid(),
+		Mode:      tailscale.ModeForeground,
+		Hostname:  hostname,
+		StartedAt: time.Now(),
+	}); err != nil {
+		printWarning(fmt.Sprintf("Failed to save connection state: %v", err))
+	}
+	defer tailscale.RemoveDaemonState(stack)
+
 	printInfo(fmt.Sprintf("Connecting to Headscale at %s...", headscaleURL))
 
 	// Connect
@@ -2853,6 +3395,13 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
 
+	if vpnConnectExitNode != "" {
+		printInfo(fmt.Sprintf("Routing all traffic through %s...", vpnConnectExitNode))
+		if err := useExitNode(ctx, client, vpnConnectExitNode); err != nil {
+			return err
+		}
+	}
+
 	// Get status
 	status, err := client.Status(ctx)
 	if err != nil {
@@ -2867,41 +3416,18 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(w, "  Tailscale IP:\t%s\n", status.TailscaleIP)
 		fmt.Fprintf(w, "  Headscale URL:\t%s\n", status.HeadscaleURL)
 		fmt.Fprintf(w, "  Peers:\t%d\n", status.PeerCount)
-		w.Flush()
-	}
-
-	// Handle daemon mode
-	if vpnConnectDaemon {
-		// Save PID file for disconnect command
-		pidFile := tailscale.GetPIDFile(stack)
-		if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", os.Getpid())), 0600); err != nil {
-			printWarning(fmt.Sprintf("Failed to save PID file: %v", err))
+		if status.ExitNode != "" {
+			fmt.Fprintf(w, "  Exit Node:\t%s\n", status.ExitNode)
 		}
-
-		fmt.Println()
-		printSuccess("Running in daemon mode. Use 'sloth vpn disconnect' to stop.")
-		fmt.Println()
-
-		// Wait for SIGTERM (from disconnect command) or SIGINT
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, signalInterrupt, signalTerminate)
-		<-sigChan
-
-		// Clean disconnect
-		client.Disconnect()
-		os.Remove(pidFile)
-		return nil
+		w.Flush()
 	}
 
-	// Foreground mode - wait for user interrupt
+	// Foreground mode - wait for user interrupt or 'vpn disconnect'
 	fmt.Println()
 	fmt.Println("  Press Ctrl+C to disconnect...")
 	fmt.Println()
 
-	// Wait for interrupt
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, signalInterrupt, signalTerminate)
-	<-sigChan
+	<-ctx.Done()
 
 	fmt.Println()
 	printInfo("Disconnecting...")
@@ -2915,7 +3441,23 @@ func runVPNConnect(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// runVPNDisconnect disconnects from the Tailscale VPN mesh
+// ensureNoVPNConnection refuses to connect while another process holds the
+// stack's connection, or stops that process when force is set
+func ensureNoVPNConnection(stack string, force bool) error {
+	state, running := tailscale.RunningDaemon(stack)
+	if !running {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("VPN is already connected for stack '%s' (%s, PID %d). Use 'vpn disconnect %s' first or --force to replace it",
+			stack, state.Mode, state.PID, stack)
+	}
+
+	printInfo(fmt.Sprintf("Replacing the running VPN %s (PID: %d)...", state.Mode, state.PID))
+	return tailscale.StopDaemon(stack, 10*time.Second)
+}
+
+// runVPNDisconnect disconnects from the Tailscale or WireGuard VPN mesh
 func runVPNDisconnect(cmd *cobra.Command, args []string) error {
 	// Require a valid stack
 	stack, err := RequireStack(args)
@@ -2926,32 +3468,23 @@ func runVPNDisconnect(cmd *cobra.Command, args []string) error {
 	printHeader(fmt.Sprintf("🔌 VPN Disconnect - Stack: %s", stack))
 	fmt.Println()
 
-	// Check if daemon is running
-	if tailscale.IsDaemonRunning(stack) {
-		pid := tailscale.GetDaemonPID(stack)
-		printInfo(fmt.Sprintf("Stopping VPN daemon (PID: %d)...", pid))
-
-		// Send SIGTERM to daemon process
-		process, err := os.FindProcess(pid)
-		if err != nil {
-			printWarning(fmt.Sprintf("Failed to find daemon process: %v", err))
-		} else {
-			if err := terminateProcess(process); err != nil {
-				printWarning(fmt.Sprintf("Failed to stop daemon: %v", err))
-			} else {
-				// Wait a moment for clean shutdown
-				time.Sleep(500 * time.Millisecond)
-				printSuccess("VPN daemon stopped")
-			}
+	// Stop the daemon or foreground connect holding the connection
+	if state, running := tailscale.RunningDaemon(stack); running {
+		printInfo(fmt.Sprintf("Stopping VPN %s (PID: %d)...", state.Mode, state.PID))
+		if err := tailscale.StopDaemon(stack, 10*time.Second); err != nil {
+			return err
 		}
-
-		// Remove PID file
-		os.Remove(tailscale.GetPIDFile(stack))
-	} else if !tailscale.IsConnected(stack) {
+		printSuccess("VPN daemon stopped")
+	} else if !tailscale.IsConnected(stack) && !wireguard.IsConnected(stack) {
 		printWarning("Not currently connected to this cluster's VPN")
 		return nil
 	}
 
+	// Bring down a kernel WireGuard tunnel left by a connect that did not exit cleanly
+	if err := wireguard.Cleanup(stack); err != nil {
+		printWarning(fmt.Sprintf("Failed to bring down WireGuard tunnel: %v", err))
+	}
+
 	// Clean up state
 	printInfo("Cleaning up connection state...")
 	if err := tailscale.CleanupState(stack); err != nil {
@@ -2964,6 +3497,17 @@ func runVPNDisconnect(cmd *cobra.Command, args []string) error {
 
 // runVPNConnectDaemon runs the VPN connection in daemon mode (called by internal flag)
 func runVPNConnectDaemon(ctx context.Context, stack string) error {
+	// The parent recorded our PID; keep the state in place until we exit
+	if err := tailscale.SaveDaemonState(stack, tailscale.DaemonState{
+		PID:       os.Getpid(),
+		Mode:      tailscale.ModeDaemon,
+		Hostname:  vpnConnectHostname,
+		StartedAt: time.Now(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save daemon state: %v\n", err)
+	}
+	defer tailscale.RemoveDaemonState(stack)
+
 	// Create workspace with S3 support
 	workspace, err := createWorkspaceWithS3Support(ctx)
 	if err != nil {
@@ -2983,6 +3527,13 @@ func runVPNConnectDaemon(ctx context.Context, stack string) error {
 		return err
 	}
 
+	if vpnMode, _ := detectVPNMode(outputs); vpnMode == VPNModeWireGuard {
+		if vpnConnectExitNode != "" {
+			return fmt.Errorf("--exit-node is only supported in Tailscale mode")
+		}
+		return runVPNConnectWireGuardDaemon(ctx, stack, outputs)
+	}
+
 	// Get Headscale info from Pulumi outputs
 	var headscaleURL string
 	var apiKey string
@@ -3006,6 +3557,12 @@ func runVPNConnectDaemon(ctx context.Context, stack string) error {
 		return fmt.Errorf("missing Headscale configuration in stack outputs")
 	}
 
+	if vpnConnectExitNode != "" {
+		if err := prepareExitNode(ctx, stack, outputs, vpnConnectExitNode, func(string) {}); err != nil {
+			return err
+		}
+	}
+
 	// Generate auth key
 	headscaleMgr := tailscale.NewHeadscaleManager(tailscale.HeadscaleConfig{
 		APIURL:    headscaleURL,
@@ -3043,6 +3600,12 @@ func runVPNConnectDaemon(ctx context.Context, stack string) error {
 		return err
 	}
 
+	if vpnConnectExitNode != "" {
+		if err := useExitNode(ctx, client, vpnConnectExitNode); err != nil {
+			return err
+		}
+	}
+
 	// Start SOCKS5 proxy for kubectl and other tools
 	proxyPort, err := client.StartSOCKS5Proxy(0) // 0 = auto-select port
 	if err != nil {
@@ -3056,20 +3619,60 @@ func runVPNConnectDaemon(ctx context.Context, stack string) error {
 		fmt.Fprintf(os.Stderr, "Warning: failed to save proxy port: %v\n", err)
 	}
 
-	// Save PID file
-	pidFile := tailscale.GetPIDFile(stack)
-	os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", os.Getpid())), 0600)
+	// Serve health on a local socket for 'vpn connect --status'
+	stopHealth, err := tailscale.ServeHealth(tailscale.GetHealthSocket(stack), tailscale.NewHealthHandler(client.HealthStatus))
+	if err != nil {
+		// Non-fatal, just log
+		fmt.Fprintf(os.Stderr, "Warning: failed to start health endpoint: %v\n", err)
+	}
 
-	// Wait for SIGTERM
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, signalInterrupt, signalTerminate)
-	<-sigChan
+	// Wait for SIGTERM from 'vpn disconnect'
+	<-ctx.Done()
 
 	// Clean disconnect
+	if stopHealth != nil {
+		stopHealth()
+	}
 	client.StopSOCKS5Proxy()
 	client.Disconnect()
-	os.Remove(pidFile)
-	os.Remove(tailscale.GetProxyFile(stack))
 
 	return nil
 }
+
+// runVPNConnectStatus queries the daemon health endpoint and fails unless the
+// embedded client is connected
+func runVPNConnectStatus(ctx context.Context, stack string) error {
+	if !tailscale.IsDaemonRunning(stack) {
+		return fmt.Errorf("no VPN daemon running for stack '%s'. Start one with 'vpn connect %s --daemon'", stack, stack)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	health, err := tailscale.QueryHealth(ctx, tailscale.GetHealthSocket(stack))
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("🔌 VPN Daemon Status - Stack: %s", stack))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  PID:\t%d\n", health.PID)
+	fmt.Fprintf(w, "  Connected:\t%t\n", health.Connected)
+	fmt.Fprintf(w, "  Hostname:\t%s\n", health.Hostname)
+	fmt.Fprintf(w, "  VPN IP:\t%s\n", health.TailscaleIP)
+	fmt.Fprintf(w, "  Peers:\t%d\n", health.PeerCount)
+	if health.ProxyPort > 0 {
+		fmt.Fprintf(w, "  SOCKS5 proxy:\t127.0.0.1:%d\n", health.ProxyPort)
+	}
+	w.Flush()
+	fmt.Println()
+
+	if !health.Healthy {
+		return fmt.Errorf("VPN daemon is running but not connected to the mesh")
+	}
+
+	printSuccess("VPN daemon is healthy")
+	return nil
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
+)
+
+var vpnACLCmd = &cobra.Command{
+	Use:   "acl",
+	Short: "Inspect or update the Headscale ACL policy",
+	Long: `Manage the ACL policy Headscale enforces between the nodes and clients of a
+Tailscale mode cluster. The policy is deployed from the acl-policy setting and
+can be read or replaced live with these subcommands.`,
+}
+
+var vpnACLGetCmd = &cobra.Command{
+	Use:   "get [stack-name]",
+	Short: "Print the ACL policy Headscale enforces",
+	Example: `  # Save the current policy for editing
+  sloth-kubernetes vpn acl get production > policy.hujson`,
+	RunE: runVPNACLGet,
+}
+
+var vpnACLSetCmd = &cobra.Command{
+	Use:   "set [stack-name]",
+	Short: "Validate and replace the ACL policy Headscale enforces",
+	Long: `Validate an ACL policy and upload it to Headscale, which applies it to the
+tailnet right away. The policy is HuJSON (JSON with comments and trailing
+commas). It is rejected before upload when it has unknown sections, rules
+other than accept, destinations without ports, or groups and tags it does
+not define.
+
+Update acl-policy in the cluster configuration too, or the next deploy
+restores the deployed policy.`,
+	Example: `  # Replace the policy
+  sloth-kubernetes vpn acl set production --file policy.hujson
+
+  # Read the policy from stdin
+  cat policy.hujson | sloth-kubernetes vpn acl set production --file -`,
+	RunE: runVPNACLSet,
+}
+
+var vpnACLFile string
+
+func init() {
+	vpnCmd.AddCommand(vpnACLCmd)
+	vpnACLCmd.AddCommand(vpnACLGetCmd)
+	vpnACLCmd.AddCommand(vpnACLSetCmd)
+
+	vpnACLSetCmd.Flags().StringVarP(&vpnACLFile, "file", "f", "", "Policy file to upload, or - for stdin")
+	vpnACLSetCmd.MarkFlagRequired("file")
+	addForceUnlockFlag(vpnACLSetCmd)
+}
+
+func runVPNACLGet(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	headscaleMgr, err := stackHeadscaleManager(ctx, stack)
+	if err != nil {
+		return err
+	}
+
+	policy, err := headscaleMgr.GetPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(policy.Policy) == "" {
+		return fmt.Errorf("Headscale has no ACL policy set for stack '%s'; all nodes can reach each other", stack)
+	}
+
+	fmt.Print(policy.Policy)
+	if !strings.HasSuffix(policy.Policy, "\n") {
+		fmt.Println()
+	}
+	return nil
+}
+
+func runVPNACLSet(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	policy, err := readACLPolicyFile(vpnACLFile, cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("🛡️  VPN ACL policy - Stack: %s", stack))
+
+	if _, err := tailscale.ParseACLPolicy(policy); err != nil {
+		return fmt.Errorf("policy not applied: %w", err)
+	}
+	printSuccess("Policy is valid")
+
+	unlock, err := lockStack(ctx, stack, "vpn-acl-set")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	headscaleMgr, err := stackHeadscaleManager(ctx, stack)
+	if err != nil {
+		return err
+	}
+
+	if err := headscaleMgr.SetPolicy(ctx, policy); err != nil {
+		return err
+	}
+	printSuccess("ACL policy applied to Headscale")
+	return nil
+}
+
+// readACLPolicyFile reads a policy from path, or from stdin when path is -
+func readACLPolicyFile(path string, stdin io.Reader) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read policy: %w", err)
+	}
+	return string(data), nil
+}
+
+// stackHeadscaleManager builds a Headscale API client for a Tailscale mode
+// stack from its outputs
+func stackHeadscaleManager(ctx context.Context, stack string) (*tailscale.HeadscaleManager, error) {
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	if vpnMode, _ := detectVPNMode(outputs); vpnMode != VPNModeTailscale {
+		return nil, fmt.Errorf("ACL policies are only supported for Tailscale (stack uses %s)", vpnMode)
+	}
+	return headscaleManagerFromOutputs(outputs)
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVPNACLCmd_Structure(t *testing.T) {
+	assert.Equal(t, "acl", vpnACLCmd.Use)
+	assert.Equal(t, "get [stack-name]", vpnACLGetCmd.Use)
+	assert.Equal(t, "set [stack-name]", vpnACLSetCmd.Use)
+	assert.NotNil(t, vpnACLGetCmd.RunE)
+	assert.NotNil(t, vpnACLSetCmd.RunE)
+	assert.NotNil(t, vpnACLSetCmd.Flags().Lookup("file"))
+	assert.NotNil(t, vpnACLSetCmd.Flags().Lookup("force-unlock"))
+}
+
+func TestReadACLPolicyFile(t *testing.T) {
+	policy, err := readACLPolicyFile("-", strings.NewReader(`{"acls": []}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"acls": []}`, policy)
+
+	path := filepath.Join(t.TempDir(), "policy.hujson")
+	require.NoError(t, os.WriteFile(path, []byte(`{"acls": []}`), 0600))
+	policy, err = readACLPolicyFile(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"acls": []}`, policy)
+
+	_, err = readACLPolicyFile(filepath.Join(t.TempDir(), "missing"), nil)
+	assert.ErrorContains(t, err, "failed to read policy")
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
+)
+
+var vpnAddRouteCmd = &cobra.Command{
+	Use:   "add-route [stack-name]",
+	Short: "Route a CIDR into the VPN through a cluster node",
+	Long: `Make a network reachable from the VPN, such as the pod or service CIDR,
+by turning a cluster node into a subnet router for it.
+
+WireGuard mode:
+  • Enables IP forwarding on the router node and masquerades VPN traffic to the CIDR
+  • Adds the CIDR to the router's AllowedIPs on every other mesh member, bastion included
+
+Tailscale mode:
+  • Advertises the CIDR from the router node with 'tailscale set --advertise-routes'
+  • Approves the route through the Headscale API
+
+Routes are remembered per stack and included in the configs generated by
+'vpn join' and 'vpn client-config'. Clients configured before the route was
+added must regenerate their configuration to use it.`,
+	Example: `  # Reach the pod network through master-1
+  sloth-kubernetes vpn add-route production --cidr 10.244.0.0/16 --via master-1
+
+  # Reach the service network too
+  sloth-kubernetes vpn add-route production --cidr 10.96.0.0/12 --via master-1`,
+	RunE: runVPNAddRoute,
+}
+
+var (
+	vpnAddRouteCIDR string
+	vpnAddRouteVia  string
+)
+
+func init() {
+	vpnCmd.AddCommand(vpnAddRouteCmd)
+
+	vpnAddRouteCmd.Flags().StringVar(&vpnAddRouteCIDR, "cidr", "", "CIDR to route into the VPN (e.g., 10.244.0.0/16)")
+	vpnAddRouteCmd.Flags().StringVar(&vpnAddRouteVia, "via", "", "Node that routes the CIDR")
+	vpnAddRouteCmd.MarkFlagRequired("cidr")
+	vpnAddRouteCmd.MarkFlagRequired("via")
+	addForceUnlockFlag(vpnAddRouteCmd)
+}
+
+func runVPNAddRoute(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("🛣️  Adding VPN route - Stack: %s", stack))
+
+	unlock, err := lockStack(ctx, stack, "vpn-add-route")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+
+	var via NodeInfo
+	for _, node := range nodes {
+		if node.Name == vpnAddRouteVia {
+			via = node
+			break
+		}
+	}
+	if via.Name == "" {
+		return fmt.Errorf("node '%s' not found in stack '%s'", vpnAddRouteVia, stack)
+	}
+
+	vpnMode, clusterCfg := detectVPNMode(outputs)
+	vpnSubnet := "10.8.0.0/24"
+	if clusterCfg != nil && clusterCfg.Network.WireGuard != nil && clusterCfg.Network.WireGuard.SubnetCIDR != "" {
+		vpnSubnet = clusterCfg.Network.WireGuard.SubnetCIDR
+	}
+
+	cidr, err := normalizeRouteCIDR(vpnAddRouteCIDR, vpnSubnet)
+	if err != nil {
+		return err
+	}
+
+	registry, err := vpn.NewRouteRegistry("")
+	if err != nil {
+		return err
+	}
+	routes, err := registry.List(stack)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		if route.CIDR == cidr && route.Via != via.Name {
+			return fmt.Errorf("%s is already routed through %s", cidr, route.Via)
+		}
+	}
+
+	fmt.Println()
+	printInfo(fmt.Sprintf("Routing %s through %s (%s mode)", cidr, via.Name, vpnMode))
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastion, hasBastion := bastionMemberFromOutputs(outputs)
+	run := func(member NodeInfo, command, stdin string) (string, error) {
+		return runOnMeshMember(ctx, sshKeyPath, member, bastion.Name, bastionIP, command, stdin)
+	}
+
+	if vpnMode == VPNModeTailscale {
+		advertised := append(vpn.RoutesVia(routes, via.Name), cidr)
+		err = advertiseTailscaleRoutes(ctx, outputs, via, uniqueSortedStrings(advertised), run)
+	} else {
+		members := nodes
+		if hasBastion {
+			members = append(members, bastion)
+		}
+		err = configureWireGuardSubnetRouter(members, via, cidr, vpnSubnet, run)
+	}
+
+	details := fmt.Sprintf("Routed %s through %s", cidr, via.Name)
+	if err != nil {
+		operations.RecordVPNOperation(stack, "add-route", via.Name, "", "failed", details, len(nodes), time.Since(startTime), err)
+		return err
+	}
+
+	if err := registry.Add(stack, vpn.AdvertisedRoute{CIDR: cidr, Via: via.Name}); err != nil {
+		color.Yellow(fmt.Sprintf("  ⚠️  Failed to save route locally: %v", err))
+	}
+	operations.RecordVPNOperation(stack, "add-route", via.Name, "", "success", details, len(nodes), time.Since(startTime), nil)
+
+	fmt.Println()
+	printSuccess(fmt.Sprintf("%s is now reachable from the VPN through %s", cidr, via.Name))
+	if vpnMode == VPNModeTailscale {
+		printInfo("Clients must run 'tailscale up --accept-routes' to use the route")
+	} else {
+		printInfo("Regenerate client configs with 'vpn join' or 'vpn client-config' to use the route")
+	}
+
+	return nil
+}
+
+// normalizeRouteCIDR parses cidr into its canonical network form and rejects
+// routes that would capture the VPN subnet itself
+func normalizeRouteCIDR(cidr, vpnSubnet string) (string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", fmt.Errorf("invalid --cidr %q: %w", cidr, err)
+	}
+
+	if _, subnet, err := net.ParseCIDR(vpnSubnet); err == nil {
+		if network.Contains(subnet.IP) || subnet.Contains(network.IP) {
+			return "", fmt.Errorf("--cidr %s overlaps the VPN subnet %s", network, subnet)
+		}
+	}
+
+	return network.String(), nil
+}
+
+// configureWireGuardSubnetRouter turns via into a router for cidr and points
+// every other member's peer entry for via at it. Adding AllowedIPs to a peer
+// does not disturb its tunnel, so members are reloaded in place.
+func configureWireGuardSubnetRouter(members []NodeInfo, via NodeInfo, cidr, vpnSubnet string, run func(member NodeInfo, command, stdin string) (string, error)) error {
+	if _, err := run(via, wgSubnetRouterScript(cidr, vpnSubnet), ""); err != nil {
+		return fmt.Errorf("failed to enable routing on %s: %w", via.Name, err)
+	}
+	printSuccess(fmt.Sprintf("Enabled forwarding and masquerading on %s", via.Name))
+
+	output, err := run(via, wgShowPublicKeyCmd, "")
+	if err != nil {
+		return fmt.Errorf("failed to read public key of %s: %w", via.Name, err)
+	}
+	viaKey := strings.TrimSpace(output)
+
+	var failed []string
+	for _, member := range members {
+		if member.Name == via.Name {
+			continue
+		}
+		if err := addRouteOnMember(member, viaKey, cidr, run); err != nil {
+			color.Yellow(fmt.Sprintf("  ⚠️  %s: %v", member.Name, err))
+			failed = append(failed, member.Name)
+			continue
+		}
+		printSuccess(fmt.Sprintf("Updated %s", member.Name))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to add the route on %d member(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// addRouteOnMember adds cidr to the member's peer entry for viaKey in wg0.conf
+// and applies it to the running interface
+func addRouteOnMember(member NodeInfo, viaKey, cidr string, run func(member NodeInfo, command, stdin string) (string, error)) error {
+	config, err := run(member, wgReadConfigCmd, "")
+	if err != nil {
+		return fmt.Errorf("failed to read wg0.conf: %w", err)
+	}
+
+	updated, found := addPeerAllowedIP(config, viaKey, cidr)
+	if !found {
+		return fmt.Errorf("wg0.conf has no peer with key %s", viaKey)
+	}
+	if updated != config {
+		if _, err := run(member, wgWriteConfigCmd("pre-rotate", false), updated); err != nil {
+			return fmt.Errorf("failed to write wg0.conf: %w", err)
+		}
+	}
+
+	if _, err := run(member, wgApplyRouteCmd(cidr), ""); err != nil {
+		return fmt.Errorf("failed to apply the route: %w", err)
+	}
+	return nil
+}
+
+// addPeerAllowedIP appends cidr to the AllowedIPs of the [Peer] with
+// publicKey, adding the line when the peer has none. It reports whether the
+// config has that peer.
+func addPeerAllowedIP(config, publicKey, cidr string) (string, bool) {
+	lines := strings.Split(config, "\n")
+
+	// Locate the peer section first; PublicKey may come after AllowedIPs
+	start, end := -1, len(lines)
+	section, sectionStart := "", 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if start >= 0 {
+				end = i
+				break
+			}
+			section, sectionStart = trimmed, i
+			continue
+		}
+		if key, value, ok := splitWGConfigLine(trimmed); ok && section == "[Peer]" && key == "PublicKey" && value == publicKey {
+			start = sectionStart
+		}
+	}
+	if start < 0 {
+		return config, false
+	}
+
+	for i := start + 1; i < end; i++ {
+		key, value, ok := splitWGConfigLine(strings.TrimSpace(lines[i]))
+		if !ok || key != "AllowedIPs" {
+			continue
+		}
+		for _, allowed := range strings.Split(value, ",") {
+			if strings.TrimSpace(allowed) == cidr {
+				return config, true
+			}
+		}
+		lines[i] = "AllowedIPs = " + value + ", " + cidr
+		return strings.Join(lines, "\n"), true
+	}
+
+	lines = append(lines[:start+1], append([]string{"AllowedIPs = " + cidr}, lines[start+1:]...)...)
+	return strings.Join(lines, "\n"), true
+}
+
+// wgSubnetRouterScript lets the router forward VPN traffic to cidr. Traffic is
+// masqueraded so hosts in cidr answer the router instead of needing a route
+// back to the VPN subnet.
+func wgSubnetRouterScript(cidr, vpnSubnet string) string {
+	return fmt.Sprintf(`sudo bash -c 'set -e
+echo net.ipv4.ip_forward=1 > /etc/sysctl.d/99-sloth-subnet-router.conf
+sysctl -qw net.ipv4.ip_forward=1
+iptables -C FORWARD -i wg0 -d %[1]s -j ACCEPT 2>/dev/null || iptables -A FORWARD -i wg0 -d %[1]s -j ACCEPT
+iptables -C FORWARD -s %[1]s -o wg0 -j ACCEPT 2>/dev/null || iptables -A FORWARD -s %[1]s -o wg0 -j ACCEPT
+iptables -t nat -C POSTROUTING -s %[2]s -d %[1]s -j MASQUERADE 2>/dev/null || iptables -t nat -A POSTROUTING -s %[2]s -d %[1]s -j MASQUERADE'`, cidr, vpnSubnet)
+}
+
+// wgApplyRouteCmd reloads wg0.conf into the running interface and routes cidr
+// into it, as wg-quick does for AllowedIPs when the interface comes up
+func wgApplyRouteCmd(cidr string) string {
+	return fmt.Sprintf("sudo bash -c 'wg syncconf wg0 <(wg-quick strip wg0) && ip route replace %s dev wg0'", cidr)
+}
+
+// advertiseTailscaleRoutes advertises routes from via and approves them in
+// Headscale. 'tailscale set' is used rather than 'tailscale up' because up
+// insists on repeating every flag the node was brought up with.
+func advertiseTailscaleRoutes(ctx context.Context, outputs auto.OutputMap, via NodeInfo, routes []string, run func(member NodeInfo, command, stdin string) (string, error)) error {
+	headscaleMgr, err := headscaleManagerFromOutputs(outputs)
+	if err != nil {
+		return err
+	}
+
+	command := fmt.Sprintf("echo net.ipv4.ip_forward=1 | sudo tee /etc/sysctl.d/99-sloth-subnet-router.conf > /dev/null && "+
+		"sudo sysctl -qw net.ipv4.ip_forward=1 && sudo tailscale set --advertise-routes=%s", strings.Join(routes, ","))
+	if _, err := run(via, command, ""); err != nil {
+		return fmt.Errorf("failed to advertise routes from %s: %w", via.Name, err)
+	}
+	printSuccess(fmt.Sprintf("%s advertises %s", via.Name, strings.Join(routes, ", ")))
+
+	node, err := headscaleMgr.FindNodeByName(ctx, via.Name)
+	if err != nil {
+		return err
+	}
+	// Approving replaces the node's routes, so keep an exit node's exit routes
+	approved := routes
+	if node.IsExitNode() {
+		approved = append(append([]string{}, routes...), tailscale.ExitRoutes...)
+	}
+	if err := headscaleMgr.ApproveRoutes(ctx, node.ID, approved); err != nil {
+		return err
+	}
+	printSuccess("Routes approved in Headscale")
+
+	return nil
+}
+
+// headscaleManagerFromOutputs builds a Headscale API client from the
+// 'tailscale' stack output
+func headscaleManagerFromOutputs(outputs auto.OutputMap) (*tailscale.HeadscaleManager, error) {
+	var headscaleURL, apiKey string
+	namespace := "default"
+
+	if tsOutput, ok := outputs["tailscale"]; ok {
+		if tsMap, ok := tsOutput.Value.(map[string]interface{}); ok {
+			headscaleURL, _ = tsMap["headscale_url"].(string)
+			apiKey, _ = tsMap["api_key"].(string)
+			if ns, ok := tsMap["namespace"].(string); ok && ns != "" {
+				namespace = ns
+			}
+		}
+	}
+
+	if headscaleURL == "" || apiKey == "" {
+		return nil, fmt.Errorf("missing Headscale configuration in stack outputs")
+	}
+
+	return tailscale.NewHeadscaleManager(tailscale.HeadscaleConfig{
+		APIURL:    headscaleURL,
+		APIKey:    apiKey,
+		Namespace: namespace,
+	}), nil
+}
+
+// loadAdvertisedRoutes returns the routes saved for a stack, warning instead
+// of failing so client configs can still be generated without them
+func loadAdvertisedRoutes(stack string) []vpn.AdvertisedRoute {
+	registry, err := vpn.NewRouteRegistry("")
+	if err == nil {
+		var routes []vpn.AdvertisedRoute
+		if routes, err = registry.List(stack); err == nil {
+			return routes
+		}
+	}
+	color.Yellow(fmt.Sprintf("  ⚠️  Failed to load advertised routes: %v", err))
+	return nil
+}
+
+// uniqueSortedStrings returns values sorted with duplicates removed
+func uniqueSortedStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
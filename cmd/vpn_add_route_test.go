@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVPNAddRouteCmd_Structure(t *testing.T) {
+	assert.Equal(t, "add-route [stack-name]", vpnAddRouteCmd.Use)
+	assert.NotNil(t, vpnAddRouteCmd.RunE)
+	assert.Contains(t, vpnAddRouteCmd.Example, "--cidr 10.244.0.0/16 --via master-1")
+	assert.NotNil(t, vpnAddRouteCmd.Flags().Lookup("cidr"))
+	assert.NotNil(t, vpnAddRouteCmd.Flags().Lookup("via"))
+}
+
+func TestNormalizeRouteCIDR(t *testing.T) {
+	cidr, err := normalizeRouteCIDR("10.244.1.7/16", "10.8.0.0/24")
+	require.NoError(t, err)
+	assert.Equal(t, "10.244.0.0/16", cidr)
+
+	_, err = normalizeRouteCIDR("10.244.0.0", "10.8.0.0/24")
+	assert.ErrorContains(t, err, "invalid --cidr")
+
+	_, err = normalizeRouteCIDR("10.0.0.0/8", "10.8.0.0/24")
+	assert.ErrorContains(t, err, "overlaps the VPN subnet")
+
+	_, err = normalizeRouteCIDR("10.8.0.128/25", "10.8.0.0/24")
+	assert.ErrorContains(t, err, "overlaps the VPN subnet")
+}
+
+func TestAddPeerAllowedIP(t *testing.T) {
+	config := "[Interface]\nPrivateKey = priv=\n\n[Peer]\n# worker-1\nAllowedIPs = 10.8.0.11/32\nPublicKey = worker-key=\n\n" +
+		"[Peer]\n# master-1\nPublicKey = master-key=\nAllowedIPs = 10.8.0.10/32\n"
+
+	updated, found := addPeerAllowedIP(config, "master-key=", "10.244.0.0/16")
+	assert.True(t, found)
+	assert.Contains(t, updated, "PublicKey = master-key=\nAllowedIPs = 10.8.0.10/32, 10.244.0.0/16\n")
+	assert.Contains(t, updated, "AllowedIPs = 10.8.0.11/32\n", "other peers are left alone")
+
+	again, found := addPeerAllowedIP(updated, "master-key=", "10.244.0.0/16")
+	assert.True(t, found)
+	assert.Equal(t, updated, again, "adding a route twice is a no-op")
+
+	// PublicKey after AllowedIPs in the section still matches
+	updated, found = addPeerAllowedIP(config, "worker-key=", "10.244.0.0/16")
+	assert.True(t, found)
+	assert.Contains(t, updated, "AllowedIPs = 10.8.0.11/32, 10.244.0.0/16\nPublicKey = worker-key=")
+
+	updated, found = addPeerAllowedIP("[Peer]\nPublicKey = bare-key=\n", "bare-key=", "10.244.0.0/16")
+	assert.True(t, found)
+	assert.Equal(t, "[Peer]\nAllowedIPs = 10.244.0.0/16\nPublicKey = bare-key=\n", updated)
+
+	_, found = addPeerAllowedIP(config, "missing-key=", "10.244.0.0/16")
+	assert.False(t, found)
+}
+
+func TestConfigureWireGuardSubnetRouter(t *testing.T) {
+	configs := map[string]string{
+		"worker-1": "[Peer]\nPublicKey = master-key=\nAllowedIPs = 10.8.0.10/32\n",
+		"bastion":  "[Peer]\nPublicKey = master-key=\nAllowedIPs = 10.8.0.10/32\n",
+		"worker-2": "[Peer]\nPublicKey = other-key=\nAllowedIPs = 10.8.0.99/32\n",
+	}
+	var commands []string
+	run := func(member NodeInfo, command, stdin string) (string, error) {
+		commands = append(commands, member.Name+": "+command)
+		switch {
+		case command == wgShowPublicKeyCmd:
+			return "master-key=\n", nil
+		case command == wgReadConfigCmd:
+			return configs[member.Name], nil
+		case strings.Contains(command, "cat > /etc/wireguard/rotate/wg0.conf"):
+			configs[member.Name] = stdin
+		}
+		return "", nil
+	}
+
+	members := []NodeInfo{{Name: "master-1"}, {Name: "worker-1"}, {Name: "worker-2"}, {Name: "bastion"}}
+	err := configureWireGuardSubnetRouter(members, members[0], "10.244.0.0/16", "10.8.0.0/24", run)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 member(s): worker-2")
+
+	assert.Contains(t, commands[0], "master-1: ")
+	assert.Contains(t, commands[0], "-s 10.8.0.0/24 -d 10.244.0.0/16 -j MASQUERADE")
+	for _, name := range []string{"worker-1", "bastion"} {
+		assert.Contains(t, configs[name], "AllowedIPs = 10.8.0.10/32, 10.244.0.0/16")
+		assert.Contains(t, commands, fmt.Sprintf("%s: %s", name, wgApplyRouteCmd("10.244.0.0/16")))
+	}
+	assert.NotContains(t, commands, "worker-2: "+wgApplyRouteCmd("10.244.0.0/16"))
+}
+
+func TestUniqueSortedStrings(t *testing.T) {
+	assert.Equal(t, []string{"10.244.0.0/16", "10.96.0.0/12"}, uniqueSortedStrings([]string{"10.96.0.0/12", "10.244.0.0/16", "10.96.0.0/12"}))
+	assert.Nil(t, uniqueSortedStrings(nil))
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/wireguard"
+)
+
+// runVPNConnectWireGuard joins the WireGuard mesh from the local machine in
+// the foreground and leaves it on Ctrl+C or 'vpn disconnect'
+func runVPNConnectWireGuard(ctx context.Context, stack string, outputs auto.OutputMap) error {
+	hostname := vpnConnectHostnameOrDefault()
+
+	client, clientIP, err := connectWireGuardClient(ctx, stack, outputs, hostname, printInfo)
+	if err != nil {
+		return err
+	}
+
+	// Record this process so a second connect refuses and disconnect can stop it
+	if err := tailscale.SaveDaemonState(stack, tailscale.DaemonState{
+		PID:       os.Getpid(),
+		Mode:      tailscale.ModeForeground,
+		Hostname:  hostname,
+		StartedAt: time.Now(),
+	}); err != nil {
+		printWarning(fmt.Sprintf("Failed to save connection state: %v", err))
+	}
+	defer tailscale.RemoveDaemonState(stack)
+
+	fmt.Println()
+	printSuccess("Connected to WireGuard mesh!")
+	fmt.Println()
+
+	if status, err := client.Status(ctx); err == nil {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  Hostname:\t%s\n", hostname)
+		fmt.Fprintf(w, "  VPN IP:\t%s\n", clientIP)
+		fmt.Fprintf(w, "  Interface:\t%s (%s)\n", status.Interface, status.Backend)
+		fmt.Fprintf(w, "  Peers:\t%d\n", status.PeerCount)
+		w.Flush()
+	}
+
+	// Foreground mode - wait for user interrupt or 'vpn disconnect'
+	fmt.Println()
+	fmt.Println("  Press Ctrl+C to disconnect...")
+	fmt.Println()
+
+	<-ctx.Done()
+
+	fmt.Println()
+	printInfo("Disconnecting...")
+
+	if err := client.Disconnect(); err != nil {
+		printWarning(fmt.Sprintf("Error during disconnect: %v", err))
+	} else {
+		printSuccess("Disconnected from WireGuard mesh")
+	}
+
+	return nil
+}
+
+// runVPNConnectWireGuardDaemon holds the WireGuard connection for the daemon
+// process and serves its health until 'vpn disconnect' stops it
+func runVPNConnectWireGuardDaemon(ctx context.Context, stack string, outputs auto.OutputMap) error {
+	hostname := vpnConnectHostnameOrDefault()
+
+	client, clientIP, err := connectWireGuardClient(ctx, stack, outputs, hostname, func(string) {})
+	if err != nil {
+		return err
+	}
+
+	stopHealth, err := tailscale.ServeHealth(tailscale.GetHealthSocket(stack), tailscale.NewHealthHandler(wireGuardHealth(client, hostname, clientIP)))
+	if err != nil {
+		// Non-fatal, just log
+		fmt.Fprintf(os.Stderr, "Warning: failed to start health endpoint: %v\n", err)
+	}
+
+	// Wait for SIGTERM from 'vpn disconnect'
+	<-ctx.Done()
+
+	if stopHealth != nil {
+		stopHealth()
+	}
+	return client.Disconnect()
+}
+
+// connectWireGuardClient registers this machine as a mesh peer, the way
+// 'vpn join' does, and brings its tunnel up. The hostname is the peer label,
+// so reconnecting keeps the VPN IP and replaces the previous key on every
+// node. It returns the client and its VPN IP.
+func connectWireGuardClient(ctx context.Context, stack string, outputs auto.OutputMap, hostname string, progress func(string)) (*wireguard.EmbeddedClient, string, error) {
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	if len(nodes) == 0 {
+		return nil, "", fmt.Errorf("no nodes found in stack - cluster may not be deployed yet")
+	}
+
+	vpnSubnet := "10.8.0.0/24"
+	if _, clusterCfg := detectVPNMode(outputs); clusterCfg != nil && clusterCfg.Network.WireGuard != nil && clusterCfg.Network.WireGuard.SubnetCIDR != "" {
+		vpnSubnet = clusterCfg.Network.WireGuard.SubnetCIDR
+	}
+
+	unlock, err := lockStack(ctx, stack, "vpn-connect")
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlock()
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastionEnabled := bastionIP != ""
+
+	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
+		SSHKeyPath:     sshKeyPath,
+		PeerStore:      newVPNPeerStore(ctx),
+		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
+		ConnectTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to initialize VPN manager: %w", err)
+	}
+
+	clientIP, err := assignClientVPNIP(vpnMgr, stack, hostname, vpnSubnet)
+	if err != nil {
+		return nil, "", err
+	}
+	progress(fmt.Sprintf("VPN IP: %s", clientIP))
+
+	previousKeys, err := supersededPeerKeys(vpnMgr, stack, clientIP, hostname)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read peer registry: %w", err)
+	}
+
+	privateKey, publicKey, err := generateWireGuardKeypair()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate keypair: %w", err)
+	}
+
+	presharedKeys, err := clusterPresharedKeys(outputs, nodes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	progress(fmt.Sprintf("Adding peer to %d cluster node(s)...", len(nodes)))
+	keepalive := clientKeepalive(outputs)
+	successCount := addPeerToNodes(ctx, vpnMgr, nodes, vpn.PeerConfig{
+		PublicKey:  publicKey,
+		AllowedIPs: []string{clientIP + "/32"},
+		Keepalive:  keepalive,
+		Label:      hostname,
+	}, presharedKeys, previousKeys, bastionEnabled, bastionIP)
+	if successCount == 0 {
+		return nil, "", fmt.Errorf("failed to add peer to any cluster node")
+	}
+
+	if _, err := vpnMgr.Register(stack, vpn.RegisteredPeer{
+		PublicKey:     publicKey,
+		VPNIP:         clientIP,
+		Label:         hostname,
+		AllowedIPs:    []string{clientIP + "/32"},
+		PresharedKeys: presharedKeys,
+	}); err != nil {
+		color.Yellow(fmt.Sprintf("  ⚠️  Failed to register peer: %v", err))
+	}
+
+	var otherClients []VPNPeerInfo
+	for _, peer := range listExternalVPNPeers(ctx, vpnMgr.GetConnectionManager(), nodes, bastionEnabled, bastionIP) {
+		if peer.VPNAddress != clientIP {
+			otherClients = append(otherClients, peer)
+		}
+	}
+
+	clientConfig := generateClientConfig(privateKey, clientIP, hostname, nodes, otherClients, presharedKeys, loadAdvertisedRoutes(stack), keepalive, sshKeyPath, bastionEnabled, bastionIP)
+	clientConfig, err = pruneClientConfig(clientConfig)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := wireguard.NewEmbeddedClient(stack, &wireguard.EmbeddedClientConfig{
+		Config:    clientConfig,
+		Userspace: vpnConnectUserspace,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create embedded client: %w", err)
+	}
+
+	progress("Bringing the WireGuard tunnel up...")
+	if err := client.Connect(ctx); err != nil {
+		return nil, "", fmt.Errorf("failed to connect: %w", err)
+	}
+	return client, clientIP, nil
+}
+
+// pruneClientConfig drops the nodes whose public key could not be fetched
+// from a client config, since a placeholder key would keep the whole tunnel
+// down
+func pruneClientConfig(clientConfig string) (string, error) {
+	cfg, err := wireguard.ParseConfig(clientConfig)
+	if err != nil {
+		return "", fmt.Errorf("invalid client configuration: %w", err)
+	}
+	dropped := cfg.Prune()
+	if len(dropped) == 0 {
+		return clientConfig, nil
+	}
+	if len(cfg.Peers) == 0 {
+		return "", fmt.Errorf("no cluster node public key could be fetched")
+	}
+	printWarning(fmt.Sprintf("Skipping peers without a public key: %s", strings.Join(dropped, ", ")))
+	return cfg.String(), nil
+}
+
+// wireGuardHealth reports the WireGuard client as daemon health, healthy
+// while the tunnel is up
+func wireGuardHealth(client *wireguard.EmbeddedClient, hostname, clientIP string) tailscale.HealthStatusFunc {
+	return func(ctx context.Context) (*tailscale.DaemonHealth, error) {
+		status, err := client.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		vpnIP := clientIP
+		if ip, _, err := net.ParseCIDR(status.Address); err == nil {
+			vpnIP = ip.String()
+		}
+		return &tailscale.DaemonHealth{
+			Healthy: status.Connected,
+			PID:     os.Getpid(),
+			EmbeddedClientStatus: tailscale.EmbeddedClientStatus{
+				Connected:   status.Connected,
+				Hostname:    hostname,
+				TailscaleIP: vpnIP,
+				ClusterName: status.ClusterName,
+				PeerCount:   status.PeerCount,
+				ConnectedAt: status.ConnectedAt,
+			},
+		}, nil
+	}
+}
+
+// vpnConnectHostnameOrDefault returns the --hostname flag, or sloth-<hostname>
+func vpnConnectHostnameOrDefault() string {
+	if vpnConnectHostname != "" {
+		return vpnConnectHostname
+	}
+	localHostname, _ := os.Hostname()
+	return fmt.Sprintf("sloth-%s", localHostname)
+}
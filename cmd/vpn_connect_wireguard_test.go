@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneClientConfig(t *testing.T) {
+	key := func(b string) string { return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(b, 32))) }
+	nodes := []NodeInfo{
+		{Name: "master-1", Provider: "digitalocean", PublicIP: "203.0.113.10", WireGuardIP: "10.8.0.10"},
+	}
+	peers := []VPNPeerInfo{{PublicKey: key("b"), VPNAddress: "10.8.0.101"}}
+
+	// Without SSH access the node key cannot be fetched and stays a placeholder
+	clientConfig := generateClientConfig(key("a"), "10.8.0.100", "laptop", nodes, peers, nil, nil, 25, "/nonexistent", false, "")
+	require.Contains(t, clientConfig, "<PUBLIC_KEY_PLACEHOLDER>")
+
+	pruned, err := pruneClientConfig(clientConfig)
+	require.NoError(t, err)
+	assert.NotContains(t, pruned, "PUBLIC_KEY_PLACEHOLDER")
+	assert.Contains(t, pruned, "PublicKey = "+key("b"))
+	assert.Contains(t, pruned, "PersistentKeepalive = 25")
+
+	_, err = pruneClientConfig(generateClientConfig(key("a"), "10.8.0.100", "laptop", nodes, nil, nil, nil, 25, "/nonexistent", false, ""))
+	assert.Error(t, err, "a config without any reachable peer is rejected")
+}
+
+func TestVPNConnectHostnameOrDefault(t *testing.T) {
+	defer func() { vpnConnectHostname = "" }()
+
+	assert.True(t, strings.HasPrefix(vpnConnectHostnameOrDefault(), "sloth-"))
+
+	vpnConnectHostname = "my-laptop"
+	assert.Equal(t, "my-laptop", vpnConnectHostnameOrDefault())
+}
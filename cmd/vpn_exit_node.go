@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
+)
+
+// exitNodeTimeout bounds the wait for the tailnet to offer the exit node to
+// the client and for the client to switch to it
+const exitNodeTimeout = 60 * time.Second
+
+// prepareExitNode makes a cluster node an approved exit node: it advertises
+// the node over SSH when the deploy did not designate it, then approves its
+// exit routes in Headscale
+func prepareExitNode(ctx context.Context, stack string, outputs auto.OutputMap, name string, progress func(string)) error {
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	member, err := findExitNodeMember(nodes, name)
+	if err != nil {
+		return err
+	}
+
+	headscaleMgr, err := headscaleManagerFromOutputs(outputs)
+	if err != nil {
+		return err
+	}
+	node, err := headscaleMgr.FindNodeByName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if !node.AdvertisesExitNode() {
+		progress(fmt.Sprintf("Advertising %s as exit node...", name))
+		bastion, _ := bastionMemberFromOutputs(outputs)
+		command := tailscale.ExitNodeForwardingScript + "sudo tailscale set --advertise-exit-node\n"
+		if _, err := runOnMeshMember(ctx, GetSSHKeyPath(stack), member, bastion.Name, bastionIPFromOutputs(outputs), command, ""); err != nil {
+			return fmt.Errorf("failed to advertise %s as exit node: %w", name, err)
+		}
+	}
+
+	if !node.IsExitNode() {
+		progress(fmt.Sprintf("Approving exit routes of %s...", name))
+		if err := headscaleMgr.ApproveExitNode(ctx, node.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findExitNodeMember returns the cluster node named name
+func findExitNodeMember(nodes []NodeInfo, name string) (NodeInfo, error) {
+	for _, node := range nodes {
+		if node.Name == name {
+			return node, nil
+		}
+	}
+	return NodeInfo{}, fmt.Errorf("exit node '%s' is not a node of this cluster", name)
+}
+
+// useExitNode switches a connected client to the exit node and disconnects
+// it when the exit node cannot be used, so a full tunnel never silently
+// falls back to a split one
+func useExitNode(ctx context.Context, client *tailscale.EmbeddedClient, name string) error {
+	if err := client.UseExitNode(ctx, name, exitNodeTimeout); err != nil {
+		client.Disconnect()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVPNConnectCmd_ExitNodeFlag(t *testing.T) {
+	flag := vpnConnectCmd.Flags().Lookup("exit-node")
+	require.NotNil(t, flag)
+	assert.Equal(t, "", flag.DefValue)
+	assert.Contains(t, vpnConnectCmd.Example, "--exit-node worker-1")
+}
+
+func TestFindExitNodeMember(t *testing.T) {
+	nodes := []NodeInfo{{Name: "master-1"}, {Name: "worker-1", PublicIP: "203.0.113.11"}}
+
+	member, err := findExitNodeMember(nodes, "worker-1")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.11", member.PublicIP)
+
+	_, err = findExitNodeMember(nodes, "worker-9")
+	assert.ErrorContains(t, err, "worker-9")
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+)
+
+var vpnExportCmd = &cobra.Command{
+	Use:   "export [stack-name]",
+	Short: "Export the VPN mesh topology as JSON or DOT",
+	Long: `Collect the peers of every node and export the mesh topology.
+
+Works with both WireGuard (from 'wg show') and Tailscale (from 'tailscale status').
+
+Formats:
+  • json: an array of {node, vpnIP, publicKey, peers[]} (default)
+  • dot:  a Graphviz graph of the mesh edges; render it with 'dot -Tpng'
+
+Edges whose last handshake is older than 3 minutes (WireGuard) or whose peer
+is offline (Tailscale) are marked stale and drawn dashed in red.`,
+	Example: `  # Export the topology as JSON
+  sloth-kubernetes vpn export production
+
+  # Render the mesh as a PNG
+  sloth-kubernetes vpn export production --format dot | dot -Tpng -o mesh.png
+
+  # Save to a file
+  sloth-kubernetes vpn export production --format dot --output mesh.dot`,
+	RunE: runVPNExport,
+}
+
+var (
+	vpnExportFormat string
+	vpnExportOutput string
+)
+
+// wgStaleHandshakeAfter matches WireGuard's reject-after time: a tunnel that has
+// not handshaked for longer can no longer carry traffic
+const wgStaleHandshakeAfter = 3 * time.Minute
+
+func init() {
+	vpnCmd.AddCommand(vpnExportCmd)
+
+	vpnExportCmd.Flags().StringVar(&vpnExportFormat, "format", "json", "Output format: json|dot")
+	vpnExportCmd.Flags().StringVarP(&vpnExportOutput, "output", "o", "", "Output file (default: stdout)")
+}
+
+// meshTopologyNode is one node's view of the mesh
+type meshTopologyNode struct {
+	Node      string             `json:"node"`
+	VPNIP     string             `json:"vpnIP"`
+	PublicKey string             `json:"publicKey"`
+	Peers     []meshTopologyPeer `json:"peers"`
+	Error     string             `json:"error,omitempty"` // Set when the node could not be queried
+}
+
+// meshTopologyPeer is a peer as seen from a node
+type meshTopologyPeer struct {
+	Node          string     `json:"node,omitempty"` // Empty for peers that are not cluster nodes
+	VPNIP         string     `json:"vpnIP"`
+	PublicKey     string     `json:"publicKey"`
+	Endpoint      string     `json:"endpoint,omitempty"`
+	LastHandshake *time.Time `json:"lastHandshake,omitempty"`
+	Stale         bool       `json:"stale"`
+}
+
+func runVPNExport(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if vpnExportFormat != "json" && vpnExportFormat != "dot" {
+		return fmt.Errorf("invalid output format: %s (must be json or dot)", vpnExportFormat)
+	}
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes found in stack")
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastion, hasBastion := bastionMemberFromOutputs(outputs)
+	run := func(member NodeInfo, command string) (string, error) {
+		return runOnMeshMember(ctx, sshKeyPath, member, bastion.Name, bastionIP, command, "")
+	}
+
+	now := time.Now()
+	var topology []meshTopologyNode
+	if vpnMode, _ := detectVPNMode(outputs); vpnMode == VPNModeTailscale {
+		topology = collectTailscaleTopology(nodes, run)
+	} else {
+		members := nodes
+		if hasBastion {
+			// The bastion is a WireGuard peer of every node
+			members = append(members, bastion)
+		}
+		topology = collectWireGuardTopology(members, run, now)
+	}
+
+	var document string
+	if vpnExportFormat == "dot" {
+		document = renderMeshDOT(topology, now)
+	} else {
+		data, err := json.MarshalIndent(topology, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal topology: %w", err)
+		}
+		document = string(data) + "\n"
+	}
+
+	if vpnExportOutput == "" {
+		fmt.Print(document)
+		return nil
+	}
+	if err := os.WriteFile(vpnExportOutput, []byte(document), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", vpnExportOutput, err)
+	}
+	printSuccess(fmt.Sprintf("✓ Mesh topology written to %s", vpnExportOutput))
+	return nil
+}
+
+// collectWireGuardTopology reads each member's public key and 'wg show' dump
+func collectWireGuardTopology(members []NodeInfo, run func(member NodeInfo, command string) (string, error), now time.Time) []meshTopologyNode {
+	var topology []meshTopologyNode
+	for _, member := range members {
+		entry := meshTopologyNode{Node: member.Name, VPNIP: vpnIPOf(member.WireGuardIP), Peers: []meshTopologyPeer{}}
+
+		publicKey, err := run(member, wgShowPublicKeyCmd)
+		if err == nil {
+			var dump string
+			if dump, err = run(member, wgDumpCmd); err == nil {
+				entry.PublicKey = strings.TrimSpace(publicKey)
+				entry.Peers = parseWGDumpPeers(dump, now)
+			}
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		topology = append(topology, entry)
+	}
+	return resolveTopologyPeers(topology)
+}
+
+// collectTailscaleTopology reads 'tailscale status --json' from each node
+func collectTailscaleTopology(nodes []NodeInfo, run func(member NodeInfo, command string) (string, error)) []meshTopologyNode {
+	var topology []meshTopologyNode
+	for _, node := range nodes {
+		entry := meshTopologyNode{Node: node.Name, VPNIP: vpnIPOf(node.WireGuardIP), Peers: []meshTopologyPeer{}}
+
+		output, err := run(node, "sudo tailscale status --json 2>/dev/null")
+		var status map[string]interface{}
+		if err == nil {
+			err = json.Unmarshal([]byte(output), &status)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			topology = append(topology, entry)
+			continue
+		}
+
+		if self, ok := status["Self"].(map[string]interface{}); ok {
+			entry.PublicKey, _ = self["PublicKey"].(string)
+			if ips, ok := self["TailscaleIPs"].([]interface{}); ok && len(ips) > 0 {
+				if ip, ok := ips[0].(string); ok {
+					entry.VPNIP = ip
+				}
+			}
+		}
+
+		for _, peer := range parseTailscalePeers(status) {
+			topologyPeer := meshTopologyPeer{
+				Node:      peer.Hostname,
+				VPNIP:     peer.TailnetIP,
+				PublicKey: peer.PublicKey,
+				Stale:     !peer.Online,
+			}
+			if !peer.LastHandshake.IsZero() {
+				lastHandshake := peer.LastHandshake
+				topologyPeer.LastHandshake = &lastHandshake
+			}
+			entry.Peers = append(entry.Peers, topologyPeer)
+		}
+		topology = append(topology, entry)
+	}
+	return resolveTopologyPeers(topology)
+}
+
+// parseWGDumpPeers converts 'wg show wg0 dump' peer lines into topology peers
+func parseWGDumpPeers(output string, now time.Time) []meshTopologyPeer {
+	peers := []meshTopologyPeer{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+
+		peer := meshTopologyPeer{
+			VPNIP:     vpnIPOf(strings.Split(fields[3], ",")[0]),
+			PublicKey: fields[0],
+			Stale:     true,
+		}
+		if fields[2] != "(none)" {
+			peer.Endpoint = fields[2]
+		}
+		if handshake, err := strconv.ParseInt(fields[4], 10, 64); err == nil && handshake > 0 {
+			lastHandshake := time.Unix(handshake, 0).UTC()
+			peer.LastHandshake = &lastHandshake
+			peer.Stale = now.Sub(lastHandshake) > wgStaleHandshakeAfter
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// resolveTopologyPeers names peers after the cluster node with the same public
+// key or VPN IP, and sorts nodes and peers for stable output
+func resolveTopologyPeers(topology []meshTopologyNode) []meshTopologyNode {
+	byKey := make(map[string]string)
+	byIP := make(map[string]string)
+	for _, node := range topology {
+		if node.PublicKey != "" {
+			byKey[node.PublicKey] = node.Node
+		}
+		if node.VPNIP != "" {
+			byIP[node.VPNIP] = node.Node
+		}
+	}
+
+	for i := range topology {
+		peers := topology[i].Peers
+		for j := range peers {
+			if name, ok := byKey[peers[j].PublicKey]; ok {
+				peers[j].Node = name
+			} else if name, ok := byIP[peers[j].VPNIP]; ok {
+				peers[j].Node = name
+			} else if peers[j].Node != "" && !topologyHasNode(topology, peers[j].Node) {
+				// Hostnames of machines outside the cluster are not node names
+				peers[j].Node = ""
+			}
+		}
+		sort.Slice(peers, func(a, b int) bool {
+			if peers[a].Node != peers[b].Node {
+				return peers[a].Node < peers[b].Node
+			}
+			return peers[a].VPNIP < peers[b].VPNIP
+		})
+	}
+
+	sort.Slice(topology, func(a, b int) bool { return topology[a].Node < topology[b].Node })
+	return topology
+}
+
+func topologyHasNode(topology []meshTopologyNode, name string) bool {
+	for _, node := range topology {
+		if node.Node == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renderMeshDOT renders the topology as an undirected Graphviz graph. Each
+// tunnel is drawn once, labelled with its most recent handshake, and drawn
+// dashed in red when either side reports it stale.
+func renderMeshDOT(topology []meshTopologyNode, now time.Time) string {
+	type edge struct {
+		lastHandshake *time.Time
+		stale         bool
+	}
+
+	var b strings.Builder
+	b.WriteString("graph mesh {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	external := make(map[string]string)
+	edges := make(map[[2]string]*edge)
+	var edgeKeys [][2]string
+
+	for _, node := range topology {
+		label := node.Node
+		if node.VPNIP != "" {
+			label += "\\n" + node.VPNIP
+		}
+		if node.Error != "" {
+			fmt.Fprintf(&b, "  %q [label=\"%s\\n(unreachable)\", color=red];\n", node.Node, label)
+		} else {
+			fmt.Fprintf(&b, "  %q [label=\"%s\"];\n", node.Node, label)
+		}
+
+		for _, peer := range node.Peers {
+			id := peer.Node
+			if id == "" {
+				id = peer.VPNIP
+				if id == "" {
+					id = peer.PublicKey
+				}
+				external[id] = peer.VPNIP
+			}
+
+			key := [2]string{node.Node, id}
+			if key[1] < key[0] {
+				key = [2]string{id, node.Node}
+			}
+			e, ok := edges[key]
+			if !ok {
+				e = &edge{}
+				edges[key] = e
+				edgeKeys = append(edgeKeys, key)
+			}
+			e.stale = e.stale || peer.Stale
+			if peer.LastHandshake != nil && (e.lastHandshake == nil || peer.LastHandshake.After(*e.lastHandshake)) {
+				e.lastHandshake = peer.LastHandshake
+			}
+		}
+	}
+
+	var externalIDs []string
+	for id := range external {
+		externalIDs = append(externalIDs, id)
+	}
+	sort.Strings(externalIDs)
+	for _, id := range externalIDs {
+		fmt.Fprintf(&b, "  %q [shape=ellipse];\n", id)
+	}
+
+	sort.Slice(edgeKeys, func(i, j int) bool {
+		if edgeKeys[i][0] != edgeKeys[j][0] {
+			return edgeKeys[i][0] < edgeKeys[j][0]
+		}
+		return edgeKeys[i][1] < edgeKeys[j][1]
+	})
+	for _, key := range edgeKeys {
+		e := edges[key]
+		attrs := fmt.Sprintf("label=%q", formatHandshakeAge(e.lastHandshake, now))
+		if e.stale {
+			attrs += ", style=dashed, color=red"
+		}
+		fmt.Fprintf(&b, "  %q -- %q [%s];\n", key[0], key[1], attrs)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// formatHandshakeAge formats a handshake time the way 'vpn peers' shows it
+func formatHandshakeAge(lastHandshake *time.Time, now time.Time) string {
+	if lastHandshake == nil {
+		return "never"
+	}
+	elapsed := int64(now.Sub(*lastHandshake).Seconds())
+	switch {
+	case elapsed < 60:
+		return fmt.Sprintf("%ds ago", elapsed)
+	case elapsed < 3600:
+		return fmt.Sprintf("%dm ago", elapsed/60)
+	case elapsed < 86400:
+		return fmt.Sprintf("%dh ago", elapsed/3600)
+	default:
+		return fmt.Sprintf("%dd ago", elapsed/86400)
+	}
+}
+
+// vpnIPOf strips the prefix length from an address like 10.8.0.2/32
+func vpnIPOf(address string) string {
+	if i := strings.Index(address, "/"); i >= 0 {
+		return address[:i]
+	}
+	return address
+}
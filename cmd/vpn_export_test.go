@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVPNExportCmd_Structure(t *testing.T) {
+	assert.Equal(t, "export [stack-name]", vpnExportCmd.Use)
+	assert.NotNil(t, vpnExportCmd.RunE)
+	assert.Contains(t, vpnExportCmd.Example, "dot -Tpng")
+
+	format := vpnExportCmd.Flags().Lookup("format")
+	require.NotNil(t, format)
+	assert.Equal(t, "json", format.DefValue)
+	assert.NotNil(t, vpnExportCmd.Flags().Lookup("output"))
+}
+
+func TestParseWGDumpPeers(t *testing.T) {
+	now := time.Unix(1700000300, 0)
+	dump := "keyA=\t(none)\t203.0.113.1:51820\t10.8.0.2/32,10.0.0.0/8\t1700000290\t10\t20\t25\n" +
+		"keyB=\t(none)\t(none)\t10.8.0.3/32\t0\t0\t0\toff\n" +
+		"keyC=\t(none)\t203.0.113.3:51820\t10.8.0.4/32\t1700000000\t0\t0\t25\n"
+
+	peers := parseWGDumpPeers(dump, now)
+	require.Len(t, peers, 3)
+
+	assert.Equal(t, "10.8.0.2", peers[0].VPNIP)
+	assert.Equal(t, "203.0.113.1:51820", peers[0].Endpoint)
+	require.NotNil(t, peers[0].LastHandshake)
+	assert.False(t, peers[0].Stale)
+
+	assert.Empty(t, peers[1].Endpoint)
+	assert.Nil(t, peers[1].LastHandshake)
+	assert.True(t, peers[1].Stale, "a peer that never handshaked is stale")
+
+	assert.True(t, peers[2].Stale, "a handshake older than 3 minutes is stale")
+}
+
+func TestCollectWireGuardTopology(t *testing.T) {
+	now := time.Now()
+	members := []NodeInfo{
+		{Name: "worker-1", WireGuardIP: "10.8.0.11"},
+		{Name: "master-1", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-2", WireGuardIP: "10.8.0.12"},
+	}
+	handshake := now.Add(-10 * time.Second).Unix()
+	run := func(member NodeInfo, command string) (string, error) {
+		switch {
+		case member.Name == "worker-2":
+			return "", fmt.Errorf("connection refused")
+		case command == wgShowPublicKeyCmd:
+			return "key-" + member.Name + "=\n", nil
+		case member.Name == "master-1":
+			return fmt.Sprintf("key-worker-1=\t(none)\t203.0.113.11:51820\t10.8.0.11/32\t%d\t1\t1\t25\n"+
+				"laptop=\t(none)\t198.51.100.7:41000\t10.8.0.100/32\t0\t0\t0\toff\n", handshake), nil
+		default:
+			return fmt.Sprintf("key-master-1=\t(none)\t203.0.113.10:51820\t10.8.0.10/32\t%d\t1\t1\t25\n", handshake), nil
+		}
+	}
+
+	topology := collectWireGuardTopology(members, run, now)
+	require.Len(t, topology, 3)
+
+	assert.Equal(t, "master-1", topology[0].Node)
+	assert.Equal(t, "key-master-1=", topology[0].PublicKey)
+	require.Len(t, topology[0].Peers, 2)
+	assert.Equal(t, "", topology[0].Peers[0].Node, "clients outside the cluster have no node name")
+	assert.Equal(t, "10.8.0.100", topology[0].Peers[0].VPNIP)
+	assert.Equal(t, "worker-1", topology[0].Peers[1].Node)
+
+	assert.Equal(t, "worker-2", topology[2].Node)
+	assert.Contains(t, topology[2].Error, "connection refused")
+	assert.Empty(t, topology[2].Peers)
+}
+
+func TestCollectTailscaleTopology(t *testing.T) {
+	status := `{
+  "Self": {"PublicKey": "nodekey:master", "HostName": "master-1", "TailscaleIPs": ["100.64.0.1"]},
+  "Peer": {
+    "nodekey:worker": {"PublicKey": "nodekey:worker", "HostName": "worker-1", "TailscaleIPs": ["100.64.0.2"], "Online": true, "LastHandshake": "2024-01-01T00:00:00Z"},
+    "nodekey:phone": {"PublicKey": "nodekey:phone", "HostName": "phone", "TailscaleIPs": ["100.64.0.9"], "Online": false, "LastHandshake": "0001-01-01T00:00:00Z"}
+  }
+}`
+	run := func(member NodeInfo, command string) (string, error) {
+		if member.Name == "worker-1" {
+			return "", fmt.Errorf("tailscale is not running")
+		}
+		return status, nil
+	}
+
+	topology := collectTailscaleTopology([]NodeInfo{{Name: "master-1"}, {Name: "worker-1"}}, run)
+	require.Len(t, topology, 2)
+	assert.Contains(t, topology[1].Error, "tailscale is not running")
+	assert.Equal(t, "100.64.0.1", topology[0].VPNIP)
+	assert.Equal(t, "nodekey:master", topology[0].PublicKey)
+
+	require.Len(t, topology[0].Peers, 2)
+	phone, worker := topology[0].Peers[0], topology[0].Peers[1]
+	assert.Equal(t, "", phone.Node, "hostnames outside the cluster are not node names")
+	assert.True(t, phone.Stale)
+	assert.Nil(t, phone.LastHandshake)
+	assert.Equal(t, "worker-1", worker.Node)
+	assert.False(t, worker.Stale)
+	require.NotNil(t, worker.LastHandshake)
+}
+
+func TestRenderMeshDOT(t *testing.T) {
+	now := time.Unix(1700000300, 0)
+	recent := now.Add(-30 * time.Second)
+	older := now.Add(-90 * time.Second)
+	topology := []meshTopologyNode{
+		{Node: "master-1", VPNIP: "10.8.0.10", Peers: []meshTopologyPeer{
+			{VPNIP: "10.8.0.100", Stale: true},
+			{Node: "worker-1", VPNIP: "10.8.0.11", LastHandshake: &older},
+		}},
+		{Node: "worker-1", VPNIP: "10.8.0.11", Peers: []meshTopologyPeer{
+			{Node: "master-1", VPNIP: "10.8.0.10", LastHandshake: &recent},
+		}},
+		{Node: "worker-2", Error: "connection refused"},
+	}
+
+	expected := `graph mesh {
+  node [shape=box];
+  "master-1" [label="master-1\n10.8.0.10"];
+  "worker-1" [label="worker-1\n10.8.0.11"];
+  "worker-2" [label="worker-2\n(unreachable)", color=red];
+  "10.8.0.100" [shape=ellipse];
+  "10.8.0.100" -- "master-1" [label="never", style=dashed, color=red];
+  "master-1" -- "worker-1" [label="30s ago"];
+}
+`
+	assert.Equal(t, expected, renderMeshDOT(topology, now))
+}
+
+func TestFormatHandshakeAge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	assert.Equal(t, "never", formatHandshakeAge(nil, now))
+	assert.Equal(t, "45s ago", formatHandshakeAge(at(45*time.Second), now))
+	assert.Equal(t, "5m ago", formatHandshakeAge(at(5*time.Minute), now))
+	assert.Equal(t, "2h ago", formatHandshakeAge(at(2*time.Hour), now))
+	assert.Equal(t, "3d ago", formatHandshakeAge(at(72*time.Hour), now))
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
+)
+
+var vpnGCCmd = &cobra.Command{
+	Use:   "gc [stack-name]",
+	Short: "Purge registered peers that are no longer on any node",
+	Long: `Remove peer registry entries whose public key no longer appears in any
+node's 'wg show' output, such as peers removed by hand or lost when nodes
+were replaced.
+
+Every node must report its peers; if one cannot be reached nothing is purged,
+since the missing node may be the one still carrying a peer.`,
+	Example: `  # List what would be purged
+  sloth-kubernetes vpn gc production --dry-run
+
+  # Purge stale peers
+  sloth-kubernetes vpn gc production`,
+	RunE: runVPNGC,
+}
+
+var vpnGCDryRun bool
+
+func init() {
+	vpnCmd.AddCommand(vpnGCCmd)
+
+	vpnGCCmd.Flags().BoolVar(&vpnGCDryRun, "dry-run", false, "List stale peers without removing them")
+	addForceUnlockFlag(vpnGCCmd)
+}
+
+func runVPNGC(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("🧹 VPN peer GC - Stack: %s", stack))
+
+	unlock, err := lockStack(ctx, stack, "vpn-gc")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	if vpnMode, _ := detectVPNMode(outputs); vpnMode != VPNModeWireGuard {
+		return fmt.Errorf("peer GC is only supported for WireGuard (stack uses %s)", vpnMode)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes found in stack")
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	bastionIP := bastionIPFromOutputs(outputs)
+
+	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
+		SSHKeyPath:     sshKeyPath,
+		PeerStore:      newVPNPeerStore(ctx),
+		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
+		ConnectTimeout: 30 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize VPN manager: %w", err)
+	}
+
+	fmt.Println()
+	printInfo(fmt.Sprintf("Reading peers from %d node(s)...", len(nodes)))
+
+	tables := collectWireGuardPeerTables(nodes, vpnPeersConcurrency, func(node NodeInfo) (string, string, error) {
+		return fetchWireGuardPeerTable(node, sshKeyPath, bastionIP != "", bastionIP)
+	})
+	liveKeys, err := liveWireGuardPeerKeys(tables)
+	if err != nil {
+		return err
+	}
+
+	stale, err := vpnMgr.ListStale(stack, liveKeys)
+	if err != nil {
+		return fmt.Errorf("failed to read peer registry: %w", err)
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].VPNIP < stale[j].VPNIP })
+
+	if len(stale) == 0 {
+		printSuccess("No stale peers in the registry")
+		return nil
+	}
+
+	fmt.Println()
+	for _, peer := range stale {
+		label := peer.Label
+		if label == "" {
+			label = "-"
+		}
+		fmt.Printf("  %-15s %-20s %s... (added %s)\n", peer.VPNIP, label, peer.PublicKey[:min(16, len(peer.PublicKey))], peer.AddedAt.Local().Format("2006-01-02"))
+	}
+	fmt.Println()
+
+	if vpnGCDryRun {
+		printInfo(fmt.Sprintf("%d stale peer(s) would be purged (dry run)", len(stale)))
+		return nil
+	}
+
+	purged := 0
+	for _, peer := range stale {
+		if err := vpnMgr.GetPeerRegistry().Unregister(stack, peer.PublicKey); err != nil {
+			color.Yellow(fmt.Sprintf("  ⚠️  Failed to purge %s: %v", peer.VPNIP, err))
+			continue
+		}
+		purged++
+	}
+
+	details := fmt.Sprintf("Purged %d/%d stale peers", purged, len(stale))
+	operations.RecordVPNOperation(stack, "gc", "", "", "success", details, len(nodes), time.Since(startTime), nil)
+
+	printSuccess(fmt.Sprintf("Purged %d stale peer(s) from the registry", purged))
+	return nil
+}
+
+// liveWireGuardPeerKeys returns every peer key configured on the nodes. It
+// fails unless all nodes reported, since a peer may live on a missing node only.
+func liveWireGuardPeerKeys(tables []wireGuardPeerTable) ([]string, error) {
+	var keys, unreachable []string
+	for _, table := range tables {
+		if table.err != nil {
+			unreachable = append(unreachable, table.node.Name)
+			continue
+		}
+		for key := range parseWGDumpHandshakes(table.dump) {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(unreachable) > 0 {
+		return nil, fmt.Errorf("could not read peers from %s; not purging anything", strings.Join(unreachable, ", "))
+	}
+	return keys, nil
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVPNGCCmd_Structure(t *testing.T) {
+	assert.Equal(t, "gc [stack-name]", vpnGCCmd.Use)
+	assert.NotNil(t, vpnGCCmd.RunE)
+	assert.NotNil(t, vpnGCCmd.Flags().Lookup("dry-run"))
+	assert.NotNil(t, vpnGCCmd.Flags().Lookup("force-unlock"))
+}
+
+func TestVPNLeaveCmd_LabelFlag(t *testing.T) {
+	assert.NotNil(t, vpnLeaveCmd.Flags().Lookup("label"))
+	assert.Contains(t, vpnLeaveCmd.Example, "--label ci-server")
+}
+
+func TestLiveWireGuardPeerKeys(t *testing.T) {
+	tables := []wireGuardPeerTable{
+		{node: NodeInfo{Name: "master-1"}, dump: "worker-key=\t(none)\t203.0.113.11:51820\t10.8.0.11/32\t1700000000\t1\t1\t25\n" +
+			"laptop-key=\t(none)\t198.51.100.7:41000\t10.8.0.100/32\t0\t0\t0\t25\n"},
+		{node: NodeInfo{Name: "worker-1"}, dump: "master-key=\t(none)\t203.0.113.10:51820\t10.8.0.10/32\t1700000000\t1\t1\t25\n" +
+			"phone-key=\t(none)\t(none)\t10.8.0.101/32\t0\t0\t0\toff\n"},
+	}
+
+	keys, err := liveWireGuardPeerKeys(tables)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"worker-key=", "laptop-key=", "master-key=", "phone-key="}, keys, "client peers count as live, not only nodes")
+
+	tables = append(tables, wireGuardPeerTable{node: NodeInfo{Name: "worker-2"}, err: fmt.Errorf("connection refused")})
+	_, err = liveWireGuardPeerKeys(tables)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "worker-2")
+}
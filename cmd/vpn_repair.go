@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator/components"
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+var vpnRepairCmd = &cobra.Command{
+	Use:   "repair [stack-name]",
+	Short: "Reconcile every node's wg0.conf with the full mesh",
+	Long: `Bring every node's /etc/wireguard/wg0.conf (and the bastion's) back to the
+full mesh that 'vpn verify-config' checks for: missing [Peer] blocks are
+appended, and peers of nodes no longer in the cluster, duplicates and peers
+with an old public key are removed. Client peers added with 'vpn join' are
+kept.
+
+Each changed config is validated before it replaces wg0.conf (the previous
+file is kept as wg0.conf.pre-repair) and applied with 'wg syncconf', so
+existing tunnels stay up. When the stack has a bastion it is updated last and
+nodes reload in the background to keep the SSH session alive. Running it
+again on a repaired mesh changes nothing.`,
+	Example: `  # Show what would change
+  sloth-kubernetes vpn repair production --dry-run
+
+  # Repair the mesh
+  sloth-kubernetes vpn repair production`,
+	RunE: runVPNRepair,
+}
+
+var vpnRepairDryRun bool
+
+func init() {
+	vpnCmd.AddCommand(vpnRepairCmd)
+
+	vpnRepairCmd.Flags().BoolVar(&vpnRepairDryRun, "dry-run", false, "Show the changes without applying them")
+	addForceUnlockFlag(vpnRepairCmd)
+}
+
+func runVPNRepair(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("🔧 WireGuard mesh repair - Stack: %s", stack))
+
+	unlock, err := lockStack(ctx, stack, "vpn-repair")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	vpnMode, clusterCfg := detectVPNMode(outputs)
+	if vpnMode != VPNModeWireGuard {
+		return fmt.Errorf("mesh repair is only supported for WireGuard (stack uses %s)", vpnMode)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes found in stack")
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	members := nodes
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastion, hasBastion := bastionMemberFromOutputs(outputs)
+	if hasBastion {
+		members = append(members, bastion)
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	repairer := &meshRepairer{
+		run: func(member NodeInfo, command, stdin string) (string, error) {
+			return runOnMeshMember(ctx, sshKeyPath, member, bastion.Name, bastionIP, command, stdin)
+		},
+	}
+	if hasBastion {
+		repairer.bastion = bastion.Name
+	}
+	if clusterCfg != nil {
+		repairer.keepalive = clusterCfg.Network.WireGuard.Keepalive(clusterCfg.NodesBehindNAT())
+	}
+	if clusterCfg != nil && clusterCfg.Network.WireGuard != nil && clusterCfg.Network.WireGuard.UsePresharedKeys {
+		sshPrivateKey, err := os.ReadFile(sshKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the SSH key the preshared keys derive from: %w", err)
+		}
+		repairer.derivePresharedKey = func(a, b string) string {
+			return components.MeshPresharedKey(string(sshPrivateKey), a, b)
+		}
+	}
+
+	fmt.Println()
+	printInfo(fmt.Sprintf("Reading WireGuard configs from %d mesh member(s)...", len(members)))
+
+	plans, err := repairer.plan(members)
+	if err != nil {
+		return err
+	}
+	if len(plans) == 0 {
+		printSuccess(fmt.Sprintf("All %d mesh member(s) match the full mesh; nothing to repair", len(members)))
+		return nil
+	}
+
+	fmt.Println()
+	for _, plan := range plans {
+		color.Yellow(fmt.Sprintf("  %s", plan.member.Name))
+		for _, name := range plan.added {
+			fmt.Printf("      + peer %s\n", name)
+		}
+		for _, removed := range plan.removed {
+			fmt.Printf("      - peer %s\n", removed)
+		}
+	}
+	fmt.Println()
+
+	if vpnRepairDryRun {
+		printInfo(fmt.Sprintf("%d mesh member(s) would be repaired (dry run)", len(plans)))
+		return nil
+	}
+
+	err = repairer.apply(plans)
+
+	details := fmt.Sprintf("Repaired the WireGuard config of %d/%d mesh members", len(plans), len(members))
+	if err != nil {
+		operations.RecordVPNOperation(stack, "repair", "", "", "failed", details, len(members), time.Since(startTime), err)
+		return err
+	}
+	operations.RecordVPNOperation(stack, "repair", "", "", "success", details, len(members), time.Since(startTime), nil)
+
+	printSuccess(fmt.Sprintf("Repaired %d mesh member(s); run 'vpn verify-config' to confirm", len(plans)))
+	return nil
+}
+
+// meshRepairer reconciles wg0.conf across the mesh; remote commands are
+// replaced in tests
+type meshRepairer struct {
+	// run executes command on a mesh member, feeding it stdin when non-empty
+	run func(member NodeInfo, command, stdin string) (string, error)
+	// bastion is the name of the member every other member is reached through
+	bastion string
+	// derivePresharedKey returns the preshared key of the tunnel between two
+	// members; nil when the mesh does not use preshared keys
+	derivePresharedKey func(a, b string) string
+	// keepalive is the PersistentKeepalive of added peers; 0 leaves it out
+	keepalive int
+}
+
+// meshRepairPlan is the repaired wg0.conf of one member
+type meshRepairPlan struct {
+	member  NodeInfo
+	config  string
+	added   []string // Members whose [Peer] block is appended
+	removed []string // Stale peers, described
+}
+
+// plan reads every member's public key and wg0.conf and returns the members
+// whose config differs from the full mesh. Nothing is planned unless every
+// member could be read, since the peer blocks need every public key.
+func (r *meshRepairer) plan(members []NodeInfo) ([]meshRepairPlan, error) {
+	keys := make(map[string]string, len(members))
+	configs := make(map[string]string, len(members))
+	for _, member := range members {
+		key, err := r.run(member, wgShowPublicKeyCmd, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the WireGuard public key of %s; not repairing anything: %w", member.Name, err)
+		}
+		keys[member.Name] = strings.TrimSpace(key)
+
+		config, err := r.run(member, wgReadConfigCmd, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the WireGuard config of %s; not repairing anything: %w", member.Name, err)
+		}
+		configs[member.Name] = config
+	}
+
+	var plans []meshRepairPlan
+	for _, member := range members {
+		presharedKey := func(peer NodeInfo) string {
+			return r.presharedKey(member, peer, configs[peer.Name])
+		}
+		plan := repairWireGuardConfig(member, configs[member.Name], members, keys, presharedKey, r.keepalive)
+		if len(plan.added) > 0 || len(plan.removed) > 0 {
+			plans = append(plans, plan)
+		}
+	}
+	return plans, nil
+}
+
+// presharedKey returns the preshared key for member's tunnel to peer: the one
+// peer already uses for member, otherwise the derived one
+func (r *meshRepairer) presharedKey(member, peer NodeInfo, peerConfig string) string {
+	if ip := vpnIPOf(member.WireGuardIP); ip != "" {
+		for _, p := range parseWireGuardConfigPeers(peerConfig) {
+			for _, allowed := range p.AllowedIPs {
+				if allowed == ip+"/32" && p.PresharedKey != "" {
+					return p.PresharedKey
+				}
+			}
+		}
+	}
+	if r.derivePresharedKey == nil {
+		return ""
+	}
+	return r.derivePresharedKey(member.Name, peer.Name)
+}
+
+// apply installs and reloads each planned config, the bastion last since it
+// carries the SSH path to every other member
+func (r *meshRepairer) apply(plans []meshRepairPlan) error {
+	members := make([]NodeInfo, 0, len(plans))
+	byName := make(map[string]meshRepairPlan, len(plans))
+	for _, plan := range plans {
+		members = append(members, plan.member)
+		byName[plan.member.Name] = plan
+	}
+
+	for _, member := range rotationOrder(members, "", r.bastion) {
+		plan := byName[member.Name]
+		if _, err := r.run(member, wgWriteConfigCmd("pre-repair", false), plan.config); err != nil {
+			return fmt.Errorf("failed to write the WireGuard config of %s: %w", member.Name, err)
+		}
+		detached := r.bastion != "" && member.Name != r.bastion
+		if _, err := r.run(member, wgReloadCmd(detached), ""); err != nil {
+			return fmt.Errorf("failed to reload WireGuard on %s: %w", member.Name, err)
+		}
+		printSuccess(fmt.Sprintf("  ✓ %s: %d peer(s) added, %d removed", member.Name, len(plan.added), len(plan.removed)))
+	}
+	return nil
+}
+
+// repairWireGuardConfig drops the stale [Peer] sections of a member's config
+// and appends a block for every member it is missing. Everything else,
+// client peers included, is kept as is.
+func repairWireGuardConfig(member NodeInfo, config string, members []NodeInfo, keys map[string]string, presharedKey func(peer NodeInfo) string, keepalive int) meshRepairPlan {
+	plan := meshRepairPlan{member: member}
+
+	sections := splitWireGuardConfigSections(config)
+	var peers []wireGuardConfigPeer
+	var peerSection []int // Index in sections of each peer
+	for i, section := range sections {
+		if parsed := parseWireGuardConfigPeers(section); len(parsed) == 1 {
+			peers = append(peers, parsed[0])
+			peerSection = append(peerSection, i)
+		}
+	}
+
+	stale, missing := meshPeerIssues(member, peers, members, keys)
+	drop := make(map[int]bool, len(stale))
+	for i, peer := range peers {
+		if reason, ok := stale[i]; ok {
+			drop[peerSection[i]] = true
+			plan.removed = append(plan.removed, describeWireGuardConfigPeer(peer, reason))
+		}
+	}
+
+	var b strings.Builder
+	for i, section := range sections {
+		if !drop[i] {
+			b.WriteString(section)
+		}
+	}
+
+	repaired := b.String()
+	for _, name := range missing {
+		for _, peer := range members {
+			if peer.Name != name {
+				continue
+			}
+			if repaired != "" && !strings.HasSuffix(repaired, "\n") {
+				repaired += "\n"
+			}
+			repaired += meshPeerBlock(peer, keys[peer.Name], presharedKey(peer), keepalive)
+			plan.added = append(plan.added, name)
+		}
+	}
+
+	plan.config = repaired
+	return plan
+}
+
+// splitWireGuardConfigSections splits a config into the text before the
+// first section and one chunk per section, each starting at its [header]
+// line; joining the chunks gives back the config
+func splitWireGuardConfigSections(config string) []string {
+	var sections []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(config, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "[") && current.Len() > 0 {
+			sections = append(sections, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		sections = append(sections, current.String())
+	}
+	return sections
+}
+
+// meshPeerBlock renders the [Peer] block the mesh component writes for a
+// member
+func meshPeerBlock(peer NodeInfo, publicKey, presharedKey string, keepalive int) string {
+	pskLine := ""
+	if presharedKey != "" {
+		pskLine = fmt.Sprintf("PresharedKey = %s\n", presharedKey)
+	}
+	wgIP := vpnIPOf(peer.WireGuardIP)
+	return fmt.Sprintf(`
+[Peer]
+# %s (%s)
+PublicKey = %s
+%sAllowedIPs = %s/32, 10.0.0.0/8
+Endpoint = %s:51820
+%s`, peer.Name, wgIP, publicKey, pskLine, wgIP, peer.PublicIP, keepaliveLine(keepalive))
+}
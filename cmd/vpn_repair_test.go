@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func repairTestMembers() []NodeInfo {
+	return []NodeInfo{
+		{Name: "master-1", WireGuardIP: "10.8.0.10", PublicIP: "203.0.113.10"},
+		{Name: "worker-1", WireGuardIP: "10.8.0.11", PublicIP: "203.0.113.11"},
+		{Name: "bastion", WireGuardIP: "10.8.0.5", PublicIP: "203.0.113.5"},
+	}
+}
+
+// newRepairTestMesh builds a full mesh of members with preshared keys, then
+// breaks it: master-1 lost worker-1 and keeps a replaced node, and the bastion
+// holds an old key for master-1
+func newRepairTestMesh(members []NodeInfo) *fakeMesh {
+	mesh := &fakeMesh{configs: make(map[string]string), keys: make(map[string]string)}
+	for _, member := range members {
+		mesh.keys[member.Name] = "key-" + member.Name + "="
+	}
+	for _, member := range members {
+		config := fmt.Sprintf("[Interface]\nAddress = %s/24\nPrivateKey = private-%s=\n", member.WireGuardIP, member.Name)
+		for _, peer := range members {
+			if peer.Name != member.Name {
+				config += meshPeerBlock(peer, mesh.keys[peer.Name], "psk-"+member.Name+"-"+peer.Name, 25)
+			}
+		}
+		config += "\n[Peer]\n# Peer: laptop\nPublicKey = laptop-key=\nAllowedIPs = 10.8.0.100/32\n"
+		mesh.configs[member.Name] = config
+	}
+
+	master := "[Interface]\nAddress = 10.8.0.10/24\nPrivateKey = private-master-1=\n" +
+		meshPeerBlock(members[2], mesh.keys["bastion"], "psk-master-1-bastion", 25) +
+		meshPeerBlock(NodeInfo{Name: "worker-old", WireGuardIP: "10.8.0.19", PublicIP: "203.0.113.19"}, "key-worker-old=", "", 25) +
+		"\n[Peer]\n# Peer: laptop\nPublicKey = laptop-key=\nAllowedIPs = 10.8.0.100/32\n"
+	mesh.configs["master-1"] = master
+	mesh.keys["master-1"] = "key-master-1-new="
+	return mesh
+}
+
+func TestVPNRepairCmd_Structure(t *testing.T) {
+	assert.Equal(t, "repair [stack-name]", vpnRepairCmd.Use)
+	assert.NotNil(t, vpnRepairCmd.RunE)
+	assert.NotNil(t, vpnRepairCmd.Flags().Lookup("dry-run"))
+	assert.NotNil(t, vpnRepairCmd.Flags().Lookup("force-unlock"))
+}
+
+func TestMeshRepairer_Plan(t *testing.T) {
+	members := repairTestMembers()
+	mesh := newRepairTestMesh(members)
+	repairer := &meshRepairer{run: mesh.run, bastion: "bastion"}
+
+	plans, err := repairer.plan(members)
+	require.NoError(t, err)
+	require.Len(t, plans, 3)
+
+	master := plans[0]
+	assert.Equal(t, "master-1", master.member.Name)
+	assert.Equal(t, []string{"worker-1"}, master.added)
+	assert.Equal(t, []string{"key-worker-old=... (10.8.0.19/32, 10.0.0.0/8) matches no mesh member"}, master.removed)
+	assert.Contains(t, master.config, "PublicKey = key-worker-1=\nPresharedKey = psk-worker-1-master-1\n", "the preshared key is taken from the other end")
+	assert.Contains(t, master.config, "Endpoint = 203.0.113.11:51820")
+	assert.Contains(t, master.config, "PublicKey = laptop-key=", "client peers are kept")
+	assert.NotContains(t, master.config, "worker-old")
+
+	// worker-1 and the bastion know master-1 by its old key
+	for _, plan := range plans[1:] {
+		assert.Equal(t, []string{"master-1"}, plan.added, plan.member.Name)
+		require.Len(t, plan.removed, 1)
+		assert.Contains(t, plan.removed[0], "has an old key for master-1")
+		assert.Contains(t, plan.config, "PublicKey = key-master-1-new=")
+	}
+}
+
+func TestMeshRepairer_ApplyIsIdempotent(t *testing.T) {
+	members := repairTestMembers()
+	mesh := newRepairTestMesh(members)
+	repairer := &meshRepairer{run: mesh.run, bastion: "bastion"}
+
+	plans, err := repairer.plan(members)
+	require.NoError(t, err)
+	require.NoError(t, repairer.apply(plans))
+
+	assert.Equal(t, []string{"master-1", "worker-1", "bastion"}, mesh.reloads, "the bastion reloads last")
+	assert.Equal(t, []string{"master-1", "worker-1"}, mesh.detached)
+	for _, member := range members {
+		drift := checkWireGuardConfigDrift(member, parseWireGuardConfigPeers(mesh.configs[member.Name]), members)
+		assert.True(t, drift.ok(), "%s: %+v", member.Name, drift)
+	}
+
+	plans, err = repairer.plan(members)
+	require.NoError(t, err)
+	assert.Empty(t, plans, "a repaired mesh needs no changes")
+}
+
+func TestMeshRepairer_DerivesMissingPresharedKeys(t *testing.T) {
+	members := repairTestMembers()[:2]
+	mesh := &fakeMesh{
+		configs: map[string]string{
+			"master-1": "[Interface]\nPrivateKey = private-master-1=\n",
+			"worker-1": "[Interface]\nPrivateKey = private-worker-1=\n",
+		},
+		keys: map[string]string{"master-1": "key-master-1=", "worker-1": "key-worker-1="},
+	}
+	repairer := &meshRepairer{
+		run:                mesh.run,
+		derivePresharedKey: func(a, b string) string { return "derived-" + a + "-" + b },
+	}
+
+	plans, err := repairer.plan(members)
+	require.NoError(t, err)
+	require.Len(t, plans, 2)
+	assert.Contains(t, plans[0].config, "PresharedKey = derived-master-1-worker-1")
+	assert.Contains(t, plans[1].config, "PresharedKey = derived-worker-1-master-1")
+}
+
+func TestMeshRepairer_UnreachableMemberRepairsNothing(t *testing.T) {
+	members := append(repairTestMembers(), NodeInfo{Name: "worker-2", WireGuardIP: "10.8.0.12"})
+	mesh := newRepairTestMesh(repairTestMembers())
+	repairer := &meshRepairer{run: func(member NodeInfo, command, stdin string) (string, error) {
+		if member.Name == "worker-2" {
+			return "", fmt.Errorf("connection refused")
+		}
+		return mesh.run(member, command, stdin)
+	}}
+
+	_, err := repairer.plan(members)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "worker-2")
+	assert.Contains(t, err.Error(), "not repairing anything")
+}
+
+func TestSplitWireGuardConfigSections(t *testing.T) {
+	config := "# managed\n[Interface]\nPrivateKey = k=\n\n[Peer]\nPublicKey = a=\n[Peer]\nPublicKey = b="
+
+	sections := splitWireGuardConfigSections(config)
+
+	assert.Equal(t, []string{"# managed\n", "[Interface]\nPrivateKey = k=\n\n", "[Peer]\nPublicKey = a=\n", "[Peer]\nPublicKey = b="}, sections)
+}
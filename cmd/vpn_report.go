@@ -0,0 +1,574 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+)
+
+// Overall states reported by 'vpn status'
+const (
+	vpnStatusConnected    = "connected"
+	vpnStatusDegraded     = "degraded"
+	vpnStatusDisconnected = "disconnected"
+	vpnStatusUnknown      = "unknown"
+	vpnStatusNoNodes      = "no-nodes"
+)
+
+// validateVPNOutputFormat checks the --output value of the vpn commands
+func validateVPNOutputFormat() error {
+	if vpnOutputFormat != "table" && vpnOutputFormat != "json" {
+		return fmt.Errorf("invalid output format: %s (must be table or json)", vpnOutputFormat)
+	}
+	return nil
+}
+
+// vpnJSONOutput reports whether the vpn commands print JSON instead of tables.
+// Progress messages are suppressed then so stdout holds only the document.
+func vpnJSONOutput() bool {
+	return vpnOutputFormat == "json"
+}
+
+// writeVPNJSON prints v as indented JSON on stdout
+func writeVPNJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// vpnStatusReport is the state shown by 'vpn status'
+type vpnStatusReport struct {
+	Stack              string  `json:"stack"`
+	Mode               VPNMode `json:"mode"`
+	CoordinationServer string  `json:"coordinationServer,omitempty"`
+	Subnet             string  `json:"subnet"`
+	TotalNodes         int     `json:"totalNodes"`
+	TotalTunnels       int     `json:"totalTunnels,omitempty"`
+	// Live is set when the mesh state was read from a node. The peer counts
+	// and peers are only filled in then.
+	Live           bool           `json:"live"`
+	ConnectedPeers int            `json:"connectedPeers"`
+	TotalPeers     int            `json:"totalPeers"`
+	Status         string         `json:"status"`
+	Peers          []vpnPeerState `json:"peers,omitempty"`
+}
+
+// vpnPeerState is whether a peer is reachable from the node reporting
+type vpnPeerState struct {
+	Name   string `json:"name"`
+	Online bool   `json:"online"`
+}
+
+// buildVPNStatusReport collects the status from the stack outputs and, when
+// it could be read (nil otherwise), the live mesh state
+func buildVPNStatusReport(stack string, outputs auto.OutputMap, nodes []NodeInfo, live *vpnLiveStatus) vpnStatusReport {
+	vpnMode, cfg := detectVPNMode(outputs)
+	report := vpnStatusReport{
+		Stack:      stack,
+		Mode:       vpnMode,
+		TotalNodes: len(nodes),
+		Status:     vpnStatusUnknown,
+	}
+
+	var online map[string]bool
+	if vpnMode == VPNModeTailscale {
+		report.Subnet = "100.64.0.0/10"
+
+		// Get Headscale URL from config or outputs
+		if cfg != nil && cfg.Network.Tailscale != nil {
+			report.CoordinationServer = cfg.Network.Tailscale.HeadscaleURL
+		}
+		if report.CoordinationServer == "" {
+			if urlOutput, ok := outputs["headscaleUrl"]; ok {
+				if url, ok := urlOutput.Value.(string); ok {
+					report.CoordinationServer = url
+				}
+			}
+		}
+
+		if len(nodes) == 0 {
+			report.Status = vpnStatusNoNodes
+			return report
+		}
+		if live == nil {
+			return report
+		}
+
+		report.TotalNodes = len(live.tailscalePeers) + 1 // +1 for the node reporting
+		online = make(map[string]bool)
+		for _, peer := range live.tailscalePeers {
+			name := peer.Hostname
+			if name == "" {
+				name = peer.TailnetIP
+			}
+			online[name] = peer.Online
+		}
+	} else {
+		report.Subnet = "10.8.0.0/24"
+		if cfg != nil && cfg.Network.WireGuard != nil && cfg.Network.WireGuard.SubnetCIDR != "" {
+			report.Subnet = cfg.Network.WireGuard.SubnetCIDR
+		}
+
+		// Full mesh: n*(n-1)/2 tunnels
+		report.TotalTunnels = len(nodes) * (len(nodes) - 1) / 2
+
+		if len(nodes) == 0 {
+			report.Status = vpnStatusNoNodes
+			return report
+		}
+		if live == nil || live.wireGuardPeers == nil {
+			return report
+		}
+		online = live.wireGuardPeers
+	}
+
+	report.Live = true
+	report.Peers = make([]vpnPeerState, 0, len(online))
+	for name, up := range online {
+		report.Peers = append(report.Peers, vpnPeerState{Name: name, Online: up})
+		if up {
+			report.ConnectedPeers++
+		}
+	}
+	sort.Slice(report.Peers, func(i, j int) bool { return report.Peers[i].Name < report.Peers[j].Name })
+	report.TotalPeers = len(online)
+
+	switch {
+	case report.ConnectedPeers == report.TotalPeers:
+		report.Status = vpnStatusConnected
+	case report.ConnectedPeers > 0:
+		report.Status = vpnStatusDegraded
+	default:
+		report.Status = vpnStatusDisconnected
+	}
+	return report
+}
+
+// printVPNStatusReport prints the status as a table
+func printVPNStatusReport(report vpnStatusReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	color.New(color.Bold).Fprintln(w, "METRIC\tVALUE")
+	fmt.Fprintln(w, "------\t-----")
+
+	if report.Mode == VPNModeTailscale {
+		fmt.Fprintln(w, "VPN Mode\tTailscale (Headscale)")
+		if report.CoordinationServer != "" {
+			fmt.Fprintf(w, "Coordination Server\t%s\n", report.CoordinationServer)
+		}
+		fmt.Fprintf(w, "Total Nodes\t%d\n", report.TotalNodes)
+		if report.Live {
+			fmt.Fprintf(w, "Connected Peers\t%d\n", report.ConnectedPeers)
+			fmt.Fprintf(w, "VPN Subnet\t%s\n", report.Subnet)
+		}
+	} else {
+		fmt.Fprintln(w, "VPN Mode\tWireGuard Mesh")
+		fmt.Fprintf(w, "Total Nodes\t%d\n", report.TotalNodes)
+		fmt.Fprintf(w, "Total Tunnels\t%d\n", report.TotalTunnels)
+		fmt.Fprintf(w, "VPN Subnet\t%s\n", report.Subnet)
+	}
+
+	switch report.Status {
+	case vpnStatusConnected:
+		fmt.Fprintln(w, "Status\t✅ All peers connected")
+	case vpnStatusDegraded:
+		fmt.Fprintf(w, "Status\t⚠️  %d/%d peers connected\n", report.ConnectedPeers, report.TotalPeers)
+	case vpnStatusDisconnected:
+		fmt.Fprintln(w, "Status\t❌ No peers connected")
+	case vpnStatusNoNodes:
+		fmt.Fprintln(w, "Status\t⚠️  No nodes found")
+	default:
+		if report.Mode == VPNModeTailscale {
+			fmt.Fprintln(w, "Status\t⚠️  Unable to fetch live status")
+		} else {
+			fmt.Fprintln(w, "Status\t✅ All tunnels active")
+		}
+	}
+}
+
+// vpnNodeError is a node that could not be queried
+type vpnNodeError struct {
+	Node  string `json:"node"`
+	Error string `json:"error"`
+}
+
+// wireGuardPeersReport is the merged peer table shown by 'vpn peers'
+type wireGuardPeersReport struct {
+	Stack          string              `json:"stack"`
+	Mode           VPNMode             `json:"mode"`
+	TotalNodes     int                 `json:"totalNodes"`
+	NodesReporting int                 `json:"nodesReporting"`
+	Peers          []wireGuardPeerInfo `json:"peers"`
+	// Errors are the nodes whose peer table could not be read
+	Errors []vpnNodeError `json:"errors,omitempty"`
+	// Warnings describe where the node peer tables disagree (a partial mesh)
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// printWireGuardPeersReport prints the WireGuard peers as a table
+func printWireGuardPeersReport(report wireGuardPeersReport) {
+	for _, nodeErr := range report.Errors {
+		color.Yellow(fmt.Sprintf("⚠  Failed to get peers from %s: %s", nodeErr.Node, nodeErr.Error))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+
+	color.New(color.Bold).Fprintln(w, "NODE\tLABEL\tVPN IP\tPUBLIC KEY\tENDPOINT\tLAST HANDSHAKE\tTRANSFER")
+	fmt.Fprintln(w, "----\t-----\t------\t----------\t--------\t--------------\t--------")
+
+	if len(report.Peers) == 0 {
+		fmt.Fprintln(w, "No peers found")
+	} else {
+		for _, peer := range report.Peers {
+			label := peer.Label
+			if label == "" {
+				label = "-"
+			}
+			publicKey := peer.PublicKey
+			if len(publicKey) > 16 {
+				publicKey = publicKey[:16] + "..." // Truncate for display
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				peer.NodeName,
+				label,
+				peer.VPNIp,
+				publicKey,
+				peer.Endpoint,
+				peer.LastHandshake,
+				peer.Transfer,
+			)
+		}
+	}
+	w.Flush()
+
+	fmt.Println()
+	color.Green(fmt.Sprintf("✓ Found %d peers in VPN mesh (%d/%d nodes reporting)", len(report.Peers), report.NodesReporting, report.TotalNodes))
+
+	if len(report.Warnings) > 0 {
+		fmt.Println()
+		color.Yellow(fmt.Sprintf("⚠  Partial mesh: node peer tables disagree (%d issue(s))", len(report.Warnings)))
+		for _, warning := range report.Warnings {
+			color.Yellow("  • " + warning)
+		}
+	}
+}
+
+// tailscalePeersReport is the tailnet as seen from one node, shown by 'vpn peers'
+type tailscalePeersReport struct {
+	Stack  string              `json:"stack"`
+	Mode   VPNMode             `json:"mode"`
+	Online int                 `json:"online"`
+	Peers  []TailscalePeerInfo `json:"peers"`
+}
+
+// printTailscalePeersReport prints the Tailscale peers as a table
+func printTailscalePeersReport(report tailscalePeersReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+
+	color.New(color.Bold).Fprintln(w, "HOSTNAME\tTAILSCALE IP\tSTATUS\tOS\tLAST SEEN\tRELAY")
+	fmt.Fprintln(w, "--------\t------------\t------\t--\t---------\t-----")
+
+	if len(report.Peers) == 0 {
+		fmt.Fprintln(w, "No peers found")
+	} else {
+		for _, peer := range report.Peers {
+			// Format status
+			status := "🔴 Offline"
+			if peer.Online {
+				status = "🟢 Online"
+			}
+
+			// Format last seen
+			lastSeen := "Never"
+			if !peer.LastSeen.IsZero() {
+				elapsed := time.Since(peer.LastSeen)
+				if elapsed < time.Minute {
+					lastSeen = fmt.Sprintf("%ds ago", int(elapsed.Seconds()))
+				} else if elapsed < time.Hour {
+					lastSeen = fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+				} else if elapsed < 24*time.Hour {
+					lastSeen = fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+				} else {
+					lastSeen = fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+				}
+			}
+			if peer.Online {
+				lastSeen = "Now"
+			}
+
+			// Format relay
+			relay := "Direct"
+			if peer.Relay != "" {
+				relay = peer.Relay
+			}
+
+			// Format OS
+			osName := peer.OS
+			if osName == "" {
+				osName = "unknown"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				peer.Hostname,
+				peer.TailnetIP,
+				status,
+				osName,
+				lastSeen,
+				relay,
+			)
+		}
+	}
+	w.Flush()
+
+	fmt.Println()
+	color.Green(fmt.Sprintf("✓ Found %d peers in Tailscale network (%d online)", len(report.Peers), report.Online))
+}
+
+// vpnPingResult is one ping from a node to another over the VPN
+type vpnPingResult struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	TargetIP string `json:"targetIp"`
+	Passed   bool   `json:"passed"`
+	// RTTMs is the average round-trip time in milliseconds of a passed ping
+	RTTMs float64 `json:"rttMs,omitempty"`
+}
+
+// vpnNodeCheck is the result of a per-node check of 'vpn test'
+type vpnNodeCheck struct {
+	Node string `json:"node"`
+	// VPNIP is the address found for the node (Tailscale address lookup only)
+	VPNIP  string `json:"vpnIp,omitempty"`
+	Peers  int    `json:"peers"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+	// StaleHandshakes are the peers without a recent handshake (WireGuard only)
+	StaleHandshakes []staleWireGuardHandshake `json:"staleHandshakes,omitempty"`
+}
+
+// vpnTestReport is the result of 'vpn test'
+type vpnTestReport struct {
+	Stack      string  `json:"stack"`
+	Mode       VPNMode `json:"mode"`
+	TotalNodes int     `json:"totalNodes"`
+	// Addresses is the Tailscale IP lookup of every node (Tailscale only)
+	Addresses []vpnNodeCheck  `json:"addresses,omitempty"`
+	Pings     []vpnPingResult `json:"pings"`
+	// PeerChecks holds the handshake (WireGuard) or peer status (Tailscale)
+	// check of every node
+	PeerChecks       []vpnNodeCheck `json:"peerChecks"`
+	PingsPassed      int            `json:"pingsPassed"`
+	PeerChecksPassed int            `json:"peerChecksPassed"`
+	Passed           bool           `json:"passed"`
+	// WorstLinks are the failed and slowest pings (--matrix only)
+	WorstLinks []vpnPingResult `json:"worstLinks,omitempty"`
+}
+
+// staleHandshakes counts the node→peer pairs without a recent handshake
+func (r *vpnTestReport) staleHandshakes() int {
+	stale := 0
+	for _, check := range r.PeerChecks {
+		stale += len(check.StaleHandshakes)
+	}
+	return stale
+}
+
+// summarize counts the passed checks
+func (r *vpnTestReport) summarize() {
+	r.PingsPassed = 0
+	for _, ping := range r.Pings {
+		if ping.Passed {
+			r.PingsPassed++
+		}
+	}
+	r.PeerChecksPassed = 0
+	for _, check := range r.PeerChecks {
+		if check.Passed {
+			r.PeerChecksPassed++
+		}
+	}
+	r.Passed = r.PingsPassed == len(r.Pings) && r.PeerChecksPassed == r.TotalNodes
+}
+
+// printVPNPingResults prints one line per ping
+func printVPNPingResults(pings []vpnPingResult) {
+	for _, ping := range pings {
+		if ping.Passed {
+			fmt.Printf("  ✓ %s → %s (%s)\n", ping.Source, ping.Target, ping.TargetIP)
+		} else {
+			fmt.Printf("  ✗ %s → %s (%s) - Failed\n", ping.Source, ping.Target, ping.TargetIP)
+		}
+	}
+}
+
+// vpnWorstLinks is how many of the slowest passed links --matrix lists
+const vpnWorstLinks = 5
+
+// vpnLink identifies a ping by its source and target node
+type vpnLink struct {
+	source, target string
+}
+
+// vpnPingsByLink indexes pings by their source and target
+func vpnPingsByLink(pings []vpnPingResult) map[vpnLink]vpnPingResult {
+	byLink := make(map[vpnLink]vpnPingResult, len(pings))
+	for _, ping := range pings {
+		byLink[vpnLink{ping.Source, ping.Target}] = ping
+	}
+	return byLink
+}
+
+// worstVPNLinks returns every failed ping followed by the n slowest passed
+// pings, slowest first
+func worstVPNLinks(pings []vpnPingResult, n int) []vpnPingResult {
+	var failed, passed []vpnPingResult
+	for _, ping := range pings {
+		if ping.Passed {
+			passed = append(passed, ping)
+		} else {
+			failed = append(failed, ping)
+		}
+	}
+	sort.SliceStable(passed, func(i, j int) bool { return passed[i].RTTMs > passed[j].RTTMs })
+	if len(passed) > n {
+		passed = passed[:n]
+	}
+	return append(failed, passed...)
+}
+
+// printVPNPingMatrix prints the round-trip time of every ping as a matrix
+// with a row per source and a column per target node
+func printVPNPingMatrix(pings []vpnPingResult) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, ping := range pings {
+		for _, name := range []string{ping.Source, ping.Target} {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	byLink := vpnPingsByLink(pings)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "FROM \\ TO\t%s\n", strings.Join(names, "\t"))
+	for _, source := range names {
+		cells := make([]string, 0, len(names))
+		for _, target := range names {
+			ping, ok := byLink[vpnLink{source, target}]
+			switch {
+			case source == target || !ok:
+				cells = append(cells, "-")
+			case !ping.Passed:
+				cells = append(cells, "✗")
+			case ping.RTTMs == 0:
+				cells = append(cells, "✓")
+			default:
+				cells = append(cells, formatPingRTT(ping.RTTMs))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\n", source, strings.Join(cells, "\t"))
+	}
+}
+
+// printVPNWorstLinks prints the failed and slowest links of a --matrix run,
+// marking the ones that only work in the other direction
+func printVPNWorstLinks(report vpnTestReport) {
+	color.New(color.Bold).Println("Worst links:")
+	if len(report.WorstLinks) == 0 {
+		fmt.Println("  No links tested")
+		return
+	}
+
+	byLink := vpnPingsByLink(report.Pings)
+	for _, link := range report.WorstLinks {
+		reverse, hasReverse := byLink[vpnLink{link.Target, link.Source}]
+		if !link.Passed {
+			note := ""
+			if hasReverse && reverse.Passed {
+				note = fmt.Sprintf(" (one-directional: %s → %s works)", link.Target, link.Source)
+			}
+			color.Red(fmt.Sprintf("  ✗ %s → %s (%s) - Failed%s", link.Source, link.Target, link.TargetIP, note))
+			continue
+		}
+
+		line := fmt.Sprintf("  %s → %s (%s) %s", link.Source, link.Target, link.TargetIP, formatPingRTT(link.RTTMs))
+		if hasReverse && reverse.Passed {
+			line += fmt.Sprintf(", reverse %s", formatPingRTT(reverse.RTTMs))
+		}
+		fmt.Println(line)
+	}
+}
+
+// formatPingRTT formats a round-trip time in milliseconds
+func formatPingRTT(ms float64) string {
+	return fmt.Sprintf("%.1fms", ms)
+}
+
+// printWireGuardHandshakeChecks prints the handshake check of every node with
+// the node→peer pairs that are stale
+func printWireGuardHandshakeChecks(checks []vpnNodeCheck, staleAfter time.Duration) {
+	for _, check := range checks {
+		switch {
+		case check.Error != "":
+			fmt.Printf("  ✗ %s - Could not check handshake status\n", check.Node)
+		case check.Passed:
+			fmt.Printf("  ✓ %s - %d active peers\n", check.Node, check.Peers)
+		default:
+			fmt.Printf("  ✗ %s - %d active peers, %d without a handshake in the last %s\n", check.Node, check.Peers, len(check.StaleHandshakes), staleAfter)
+			for _, stale := range check.StaleHandshakes {
+				reason := "no handshake yet"
+				if stale.Missing {
+					reason = "missing from the peer table"
+				} else if !stale.LastHandshake.IsZero() {
+					reason = "last handshake " + formatHandshakeAge(&stale.LastHandshake, time.Now())
+				}
+				color.Yellow(fmt.Sprintf("      %s → %s (%s): %s", check.Node, stale.Peer, stale.VPNIP, reason))
+			}
+		}
+	}
+}
+
+// printVPNTestSummary prints the summary table of 'vpn test'
+func printVPNTestSummary(report vpnTestReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "METRIC\tRESULT")
+	fmt.Fprintln(w, "------\t------")
+	checkName, checkResult := "Handshake Checks", "nodes with current handshakes"
+	if report.Mode == VPNModeTailscale {
+		fmt.Fprintln(w, "VPN Mode\tTailscale (Headscale)")
+		checkName, checkResult = "Peer Status Checks", "nodes responding"
+	}
+	fmt.Fprintf(w, "Total Nodes\t%d\n", report.TotalNodes)
+
+	passRate := float64(0)
+	if len(report.Pings) > 0 {
+		passRate = float64(report.PingsPassed) / float64(len(report.Pings)) * 100
+	}
+	fmt.Fprintf(w, "Ping Tests\t%d/%d passed (%.1f%%)\n", report.PingsPassed, len(report.Pings), passRate)
+	fmt.Fprintf(w, "%s\t%d/%d %s\n", checkName, report.PeerChecksPassed, report.TotalNodes, checkResult)
+	if stale := report.staleHandshakes(); stale > 0 {
+		fmt.Fprintf(w, "Stale Handshakes\t%d node→peer pairs\n", stale)
+	}
+
+	if report.Passed {
+		fmt.Fprintln(w, "Overall Status\t✅ All tests passed")
+	} else if report.PingsPassed > 0 {
+		fmt.Fprintln(w, "Overall Status\t⚠️  Some tests failed")
+	} else {
+		fmt.Fprintln(w, "Overall Status\t❌ All tests failed")
+	}
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVPNCmd_OutputFlag(t *testing.T) {
+	flag := vpnCmd.PersistentFlags().Lookup("output")
+	require.NotNil(t, flag)
+	assert.Equal(t, "table", flag.DefValue)
+
+	for _, sub := range []string{"status", "peers", "test"} {
+		cmd, _, err := vpnCmd.Find([]string{sub})
+		require.NoError(t, err)
+		assert.Same(t, flag, cmd.InheritedFlags().Lookup("output"), "%s inherits --output", sub)
+		assert.Contains(t, cmd.Example, "--output json")
+	}
+
+	// client-config keeps --output as the file path
+	local := vpnClientConfigCmd.Flags().Lookup("output")
+	require.NotNil(t, local)
+	assert.Equal(t, "", local.DefValue)
+}
+
+func TestValidateVPNOutputFormat(t *testing.T) {
+	defer func(format string) { vpnOutputFormat = format }(vpnOutputFormat)
+
+	for _, format := range []string{"table", "json"} {
+		vpnOutputFormat = format
+		assert.NoError(t, validateVPNOutputFormat())
+	}
+	vpnOutputFormat = "yaml"
+	assert.EqualError(t, validateVPNOutputFormat(), "invalid output format: yaml (must be table or json)")
+}
+
+func TestBuildVPNStatusReport_WireGuard(t *testing.T) {
+	nodes := []NodeInfo{{Name: "master-1"}, {Name: "worker-1"}, {Name: "worker-2"}}
+
+	report := buildVPNStatusReport("prod", auto.OutputMap{}, nodes, nil)
+	assert.Equal(t, VPNModeWireGuard, report.Mode)
+	assert.Equal(t, 3, report.TotalNodes)
+	assert.Equal(t, 3, report.TotalTunnels)
+	assert.Equal(t, "10.8.0.0/24", report.Subnet)
+	assert.False(t, report.Live)
+	assert.Equal(t, vpnStatusUnknown, report.Status)
+
+	live := &vpnLiveStatus{wireGuardPeers: map[string]bool{"worker-2": false, "master-1": true, "worker-1": true}}
+	report = buildVPNStatusReport("prod", auto.OutputMap{}, nodes, live)
+	assert.True(t, report.Live)
+	assert.Equal(t, 2, report.ConnectedPeers)
+	assert.Equal(t, 3, report.TotalPeers)
+	assert.Equal(t, vpnStatusDegraded, report.Status)
+	assert.Equal(t, []vpnPeerState{
+		{Name: "master-1", Online: true},
+		{Name: "worker-1", Online: true},
+		{Name: "worker-2", Online: false},
+	}, report.Peers)
+
+	assert.Equal(t, vpnStatusNoNodes, buildVPNStatusReport("prod", auto.OutputMap{}, nil, nil).Status)
+}
+
+func TestBuildVPNStatusReport_Tailscale(t *testing.T) {
+	outputs := auto.OutputMap{"headscaleUrl": auto.OutputValue{Value: "https://hs.example.com"}}
+	nodes := []NodeInfo{{Name: "master-1"}, {Name: "worker-1"}}
+
+	report := buildVPNStatusReport("prod", outputs, nodes, nil)
+	assert.Equal(t, VPNModeTailscale, report.Mode)
+	assert.Equal(t, "https://hs.example.com", report.CoordinationServer)
+	assert.Equal(t, 2, report.TotalNodes)
+	assert.Equal(t, vpnStatusUnknown, report.Status)
+
+	live := &vpnLiveStatus{tailscalePeers: []TailscalePeerInfo{
+		{Hostname: "worker-1", Online: false},
+		{TailnetIP: "100.64.0.9", Online: false},
+	}}
+	report = buildVPNStatusReport("prod", outputs, nodes, live)
+	assert.Equal(t, 3, report.TotalNodes, "peers plus the node reporting")
+	assert.Equal(t, 0, report.ConnectedPeers)
+	assert.Equal(t, vpnStatusDisconnected, report.Status)
+	assert.Equal(t, "100.64.0.9", report.Peers[0].Name, "peers without a hostname go by IP")
+}
+
+func TestVPNTestReport_Summarize(t *testing.T) {
+	report := vpnTestReport{
+		TotalNodes: 2,
+		Pings: []vpnPingResult{
+			{Source: "a", Target: "b", Passed: true},
+			{Source: "b", Target: "a", Passed: true},
+		},
+		PeerChecks: []vpnNodeCheck{{Node: "a", Passed: true}, {Node: "b", Passed: true}},
+	}
+	report.summarize()
+	assert.Equal(t, 2, report.PingsPassed)
+	assert.Equal(t, 2, report.PeerChecksPassed)
+	assert.True(t, report.Passed)
+
+	report.Pings[1].Passed = false
+	report.summarize()
+	assert.Equal(t, 1, report.PingsPassed)
+	assert.False(t, report.Passed)
+
+	report.Pings[1].Passed = true
+	report.PeerChecks[0] = vpnNodeCheck{Node: "a", Error: "timeout"}
+	report.summarize()
+	assert.False(t, report.Passed, "every node check has to pass")
+}
+
+func TestVPNNodeCheckFromOutput(t *testing.T) {
+	assert.Equal(t, vpnNodeCheck{Node: "a", Peers: 3, Passed: true}, vpnNodeCheckFromOutput("a", []byte("3\n"), nil))
+	assert.Equal(t, vpnNodeCheck{Node: "a", Error: assert.AnError.Error()}, vpnNodeCheckFromOutput("a", nil, assert.AnError))
+}
+
+func TestWireGuardPeersReport_JSON(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	nodes := []NodeInfo{{Name: "master-1", WireGuardIP: "10.8.0.10"}, {Name: "worker-1", WireGuardIP: "10.8.0.11"}}
+	dump := "keyM=\t(none)\t203.0.113.10:51820\t10.8.0.10/32\t1699999990\t2048\t1024\t25\n" +
+		"keyW=\t(none)\t(none)\t10.8.0.11/32\t0\t0\t0\t25\n"
+	peers := parseWireGuardPeerTable("", dump, nodes, now)
+
+	data, err := json.Marshal(wireGuardPeersReport{Stack: "prod", Mode: VPNModeWireGuard, TotalNodes: 2, NodesReporting: 1, Peers: peers})
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "wireguard", decoded["mode"])
+	assert.NotContains(t, decoded, "errors")
+
+	rows := decoded["peers"].([]interface{})
+	require.Len(t, rows, 2)
+	master := rows[0].(map[string]interface{})
+	assert.Equal(t, "master-1", master["node"])
+	assert.Equal(t, "10s ago", master["handshakeAge"])
+	latest, err := time.Parse(time.RFC3339, master["latestHandshake"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1699999990), latest.Unix())
+	assert.Equal(t, float64(2048), master["receivedBytes"])
+	assert.Equal(t, float64(1024), master["sentBytes"])
+	assert.NotContains(t, master, "Transfer")
+
+	worker := rows[1].(map[string]interface{})
+	assert.Equal(t, "Never", worker["handshakeAge"])
+	assert.NotContains(t, worker, "latestHandshake", "no handshake yet")
+}
+
+func TestVPNTestCmd_MatrixFlag(t *testing.T) {
+	flag := vpnTestCmd.Flags().Lookup("matrix")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+	assert.Contains(t, vpnTestCmd.Example, "--matrix")
+}
+
+func TestParsePingOutput(t *testing.T) {
+	iputils := `PING 10.8.0.11 (10.8.0.11) 56(84) bytes of data.
+64 bytes from 10.8.0.11: icmp_seq=1 ttl=64 time=0.512 ms
+64 bytes from 10.8.0.11: icmp_seq=2 ttl=64 time=0.431 ms
+
+--- 10.8.0.11 ping statistics ---
+2 packets transmitted, 2 received, 0% packet loss, time 1001ms
+rtt min/avg/max/mdev = 0.431/0.471/0.512/0.040 ms
+SUCCESS
+`
+	passed, rtt := parsePingOutput(iputils)
+	assert.True(t, passed)
+	assert.InDelta(t, 0.471, rtt, 1e-9)
+
+	busybox := "2 packets transmitted, 2 packets received, 0% packet loss\nround-trip min/avg/max = 12.100/14.250/16.400 ms\nSUCCESS"
+	passed, rtt = parsePingOutput(busybox)
+	assert.True(t, passed)
+	assert.InDelta(t, 14.25, rtt, 1e-9)
+
+	passed, rtt = parsePingOutput("2 packets transmitted, 0 received, 100% packet loss, time 1010ms\nFAILED\n")
+	assert.False(t, passed)
+	assert.Zero(t, rtt)
+
+	passed, _ = parsePingOutput("")
+	assert.False(t, passed)
+}
+
+func TestCollectVPNPings(t *testing.T) {
+	var pings []vpnPingResult
+	for i := 0; i < 12; i++ {
+		pings = append(pings, vpnPingResult{Source: fmt.Sprintf("node-%d", i), Target: "target", TargetIP: fmt.Sprintf("10.8.0.%d", i)})
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	results := collectVPNPings(pings, 4, func(ping vpnPingResult) (string, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		switch ping.Source {
+		case "node-1":
+			return "rtt min/avg/max/mdev = 1.0/2.5/4.0/0.1 ms\nFAILED", nil
+		case "node-2":
+			return "rtt min/avg/max/mdev = 1.0/2.5/4.0/0.1 ms\nSUCCESS", fmt.Errorf("ssh failed")
+		}
+		return "rtt min/avg/max/mdev = 1.0/2.5/4.0/0.1 ms\nSUCCESS", nil
+	})
+
+	assert.LessOrEqual(t, maxRunning, 4)
+	require.Len(t, results, 12)
+	for i, result := range results {
+		assert.Equal(t, pings[i].Source, result.Source, "results keep the order of the pings")
+	}
+	assert.Equal(t, vpnPingResult{Source: "node-0", Target: "target", TargetIP: "10.8.0.0", Passed: true, RTTMs: 2.5}, results[0])
+	assert.Equal(t, vpnPingResult{Source: "node-1", Target: "target", TargetIP: "10.8.0.1"}, results[1])
+	assert.Equal(t, vpnPingResult{Source: "node-2", Target: "target", TargetIP: "10.8.0.2"}, results[2])
+}
+
+func TestWorstVPNLinks(t *testing.T) {
+	pings := []vpnPingResult{
+		{Source: "a", Target: "b", Passed: true, RTTMs: 0.5},
+		{Source: "b", Target: "a", Passed: false},
+		{Source: "a", Target: "c", Passed: true, RTTMs: 40},
+		{Source: "c", Target: "a", Passed: true, RTTMs: 38},
+		{Source: "b", Target: "c", Passed: true, RTTMs: 1.2},
+		{Source: "c", Target: "b", Passed: false},
+	}
+
+	worst := worstVPNLinks(pings, 2)
+	require.Len(t, worst, 4, "every failed link plus the 2 slowest")
+	assert.Equal(t, []vpnLink{{"b", "a"}, {"c", "b"}, {"a", "c"}, {"c", "a"}}, []vpnLink{
+		{worst[0].Source, worst[0].Target},
+		{worst[1].Source, worst[1].Target},
+		{worst[2].Source, worst[2].Target},
+		{worst[3].Source, worst[3].Target},
+	})
+
+	assert.Empty(t, worstVPNLinks(nil, 5))
+}
@@ -0,0 +1,438 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+var vpnRotateKeysCmd = &cobra.Command{
+	Use:   "rotate-keys [stack-name]",
+	Short: "Rotate WireGuard keys across the mesh",
+	Long: `Rotate the WireGuard keypair of every node without tearing down the mesh.
+Each node gets a fresh private key, every peer's PublicKey entry for it is
+updated in /etc/wireguard/wg0.conf, and the interfaces are reloaded in place.
+
+When the stack has a bastion, nodes are reached through the bastion's
+WireGuard tunnel, so the bastion is always updated last and nodes reload in the
+background to keep the SSH session alive. The command waits for the rotated
+tunnels to handshake again before reporting success.
+
+Clients added with 'vpn join' keep the old node keys; regenerate their
+configuration with 'vpn client-config' afterwards.`,
+	Example: `  # Rotate the keys of every node and the bastion
+  sloth-kubernetes vpn rotate-keys production
+
+  # Rotate a single node
+  sloth-kubernetes vpn rotate-keys production --node worker-1`,
+	RunE: runVPNRotateKeys,
+}
+
+var (
+	vpnRotateKeysNode string
+
+	// vpnRotateHandshakeTimeout bounds the wait for rotated tunnels to handshake
+	vpnRotateHandshakeTimeout = 2 * time.Minute
+)
+
+const (
+	// wgDetachedReloadDelay is how long a detached reload waits before applying
+	wgDetachedReloadDelay = 2 * time.Second
+
+	wgShowPublicKeyCmd = "sudo wg show wg0 public-key"
+	wgReadConfigCmd    = "sudo cat /etc/wireguard/wg0.conf"
+	wgDumpCmd          = "sudo wg show wg0 dump | tail -n +2" // Skip the interface line
+)
+
+func init() {
+	vpnCmd.AddCommand(vpnRotateKeysCmd)
+
+	vpnRotateKeysCmd.Flags().StringVar(&vpnRotateKeysNode, "node", "", "Rotate only this node's keys")
+	addForceUnlockFlag(vpnRotateKeysCmd)
+}
+
+// keyRotator rotates WireGuard keys across the mesh; remote commands and key
+// generation are replaced in tests
+type keyRotator struct {
+	// run executes command on a mesh member, feeding it stdin when non-empty
+	run        func(member NodeInfo, command, stdin string) (string, error)
+	newKeypair func() (privateKey, publicKey string, err error)
+	// bastion is the name of the member every other member is reached through
+	bastion          string
+	handshakeTimeout time.Duration
+	pollInterval     time.Duration
+}
+
+func runVPNRotateKeys(cmd *cobra.Command, args []string) error {
+	startTime := time.Now()
+	ctx := context.Background()
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	printHeader(fmt.Sprintf("🔑 Rotating WireGuard keys - Stack: %s", stack))
+
+	unlock, err := lockStack(ctx, stack, "vpn-rotate-keys")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	if vpnMode, _ := detectVPNMode(outputs); vpnMode != VPNModeWireGuard {
+		return fmt.Errorf("key rotation is only supported for WireGuard (stack uses %s)", vpnMode)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes found in stack")
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	members := nodes
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastion, hasBastion := bastionMemberFromOutputs(outputs)
+	if hasBastion {
+		members = append(members, bastion)
+	}
+
+	targets := members
+	if vpnRotateKeysNode != "" {
+		targets = nil
+		for _, member := range members {
+			if member.Name == vpnRotateKeysNode {
+				targets = []NodeInfo{member}
+				break
+			}
+		}
+		if targets == nil {
+			return fmt.Errorf("node '%s' not found in stack '%s'", vpnRotateKeysNode, stack)
+		}
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	rotator := &keyRotator{
+		run: func(member NodeInfo, command, stdin string) (string, error) {
+			return runOnMeshMember(ctx, sshKeyPath, member, bastion.Name, bastionIP, command, stdin)
+		},
+		newKeypair:       generateWireGuardKeypair,
+		handshakeTimeout: vpnRotateHandshakeTimeout,
+		pollInterval:     5 * time.Second,
+	}
+	if hasBastion {
+		rotator.bastion = bastion.Name
+	}
+
+	err = rotator.rotate(members, targets)
+
+	details := fmt.Sprintf("Rotated WireGuard keys of %d/%d mesh members", len(targets), len(members))
+	if err != nil {
+		operations.RecordVPNOperation(stack, "rotate-keys", vpnRotateKeysNode, "", "failed", details, len(members), time.Since(startTime), err)
+		return err
+	}
+	operations.RecordVPNOperation(stack, "rotate-keys", vpnRotateKeysNode, "", "success", details, len(members), time.Since(startTime), nil)
+
+	fmt.Println()
+	color.Green(fmt.Sprintf("✓ Rotated WireGuard keys of %d node(s); all tunnels re-established", len(targets)))
+	printWarning("Clients added with 'vpn join' still use the old keys; regenerate them with 'vpn client-config'")
+
+	return nil
+}
+
+// rotate gives each target a new keypair and updates its peers. For every
+// target the other members are updated first, then the target itself, and the
+// bastion last, since it carries the SSH path to everyone else. Once all
+// targets are rotated it waits for their tunnels to handshake again.
+func (r *keyRotator) rotate(members, targets []NodeInfo) error {
+	keys := make(map[string]string, len(members))
+	for _, member := range members {
+		output, err := r.run(member, wgShowPublicKeyCmd, "")
+		if err != nil {
+			return fmt.Errorf("failed to read the WireGuard public key of %s: %w", member.Name, err)
+		}
+		keys[member.Name] = strings.TrimSpace(output)
+	}
+
+	// rotatedAt holds when each target's new key took effect everywhere
+	rotatedAt := make(map[string]int64, len(targets))
+	for i, target := range targets {
+		printInfo(fmt.Sprintf("[%d/%d] Rotating %s...", i+1, len(targets), target.Name))
+
+		privateKey, publicKey, err := r.newKeypair()
+		if err != nil {
+			return fmt.Errorf("failed to generate keys for %s: %w", target.Name, err)
+		}
+
+		for _, member := range rotationOrder(members, target.Name, r.bastion) {
+			config, err := r.run(member, wgReadConfigCmd, "")
+			if err != nil {
+				return fmt.Errorf("failed to read the WireGuard config of %s: %w", member.Name, err)
+			}
+
+			isTarget := member.Name == target.Name
+			var updated string
+			if isTarget {
+				if updated, err = setInterfacePrivateKey(config, privateKey); err != nil {
+					return fmt.Errorf("%s: %w", member.Name, err)
+				}
+			} else {
+				var changed bool
+				if updated, changed = replacePeerPublicKey(config, keys[target.Name], publicKey); !changed {
+					continue
+				}
+			}
+
+			if _, err := r.run(member, wgWriteConfigCmd("pre-rotate", isTarget), updated); err != nil {
+				return fmt.Errorf("failed to write the WireGuard config of %s (previous config kept at /etc/wireguard/wg0.conf.pre-rotate): %w", member.Name, err)
+			}
+			if _, err := r.run(member, wgReloadCmd(r.reachedThroughBastion(member)), ""); err != nil {
+				return fmt.Errorf("failed to reload WireGuard on %s: %w", member.Name, err)
+			}
+		}
+
+		keys[target.Name] = publicKey
+		rotatedAt[target.Name] = time.Now().Add(wgDetachedReloadDelay).Unix()
+		printSuccess(fmt.Sprintf("  ✓ %s has a new public key: %s...", target.Name, publicKey[:16]))
+	}
+
+	fmt.Println()
+	printInfo("Waiting for WireGuard handshakes...")
+	return r.waitForHandshakes(members, keys, rotatedAt)
+}
+
+// waitForHandshakes polls every member until each tunnel touching a rotated
+// member has handshaked since the new key took effect
+func (r *keyRotator) waitForHandshakes(members []NodeInfo, keys map[string]string, rotatedAt map[string]int64) error {
+	deadline := time.Now().Add(r.handshakeTimeout)
+	for {
+		var pending []string
+		for _, member := range members {
+			output, err := r.run(member, wgDumpCmd, "")
+			if err != nil {
+				pending = append(pending, fmt.Sprintf("%s (%v)", member.Name, err))
+				continue
+			}
+			handshakes := parseWGDumpHandshakes(output)
+
+			for _, peer := range members {
+				since := rotatedAt[member.Name]
+				if rotatedAt[peer.Name] > since {
+					since = rotatedAt[peer.Name]
+				}
+				if peer.Name == member.Name || since == 0 {
+					continue
+				}
+				if handshake, ok := handshakes[keys[peer.Name]]; ok && handshake < since {
+					pending = append(pending, fmt.Sprintf("%s -> %s", member.Name, peer.Name))
+				}
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("WireGuard tunnels did not handshake after key rotation: %s", strings.Join(pending, ", "))
+		}
+		time.Sleep(r.pollInterval)
+	}
+}
+
+// reachedThroughBastion reports whether SSH to member rides the bastion's
+// WireGuard tunnel, in which case reloading it would cut the session
+func (r *keyRotator) reachedThroughBastion(member NodeInfo) bool {
+	return r.bastion != "" && member.Name != r.bastion
+}
+
+// rotationOrder returns the members to update when rotating target: the other
+// members first, then the target, and the bastion last
+func rotationOrder(members []NodeInfo, target, bastion string) []NodeInfo {
+	var ordered, last []NodeInfo
+	for _, member := range members {
+		switch member.Name {
+		case bastion:
+			last = append(last, member)
+		case target:
+			last = append([]NodeInfo{member}, last...)
+		default:
+			ordered = append(ordered, member)
+		}
+	}
+	return append(ordered, last...)
+}
+
+// wgWriteConfigCmd validates the config read from stdin and installs it as
+// wg0.conf, keeping the previous file as wg0.conf.<backup>. For the rotated
+// node it also refreshes the privatekey/publickey files the CLI reads.
+func wgWriteConfigCmd(backup string, isTarget bool) string {
+	script := "set -e; umask 077; mkdir -p /etc/wireguard/rotate; cat > /etc/wireguard/rotate/wg0.conf; " +
+		"wg-quick strip /etc/wireguard/rotate/wg0.conf > /dev/null; " +
+		"cp /etc/wireguard/wg0.conf /etc/wireguard/wg0.conf." + backup + "; " +
+		"mv /etc/wireguard/rotate/wg0.conf /etc/wireguard/wg0.conf; rmdir /etc/wireguard/rotate"
+	if isTarget {
+		script += "; sed -n \"s/^PrivateKey *= *//p\" /etc/wireguard/wg0.conf > /etc/wireguard/privatekey" +
+			"; wg pubkey < /etc/wireguard/privatekey > /etc/wireguard/publickey"
+	}
+	return fmt.Sprintf("sudo bash -c '%s'", script)
+}
+
+// wgReloadCmd applies wg0.conf to the running interface. A detached reload
+// returns before the interface changes so an SSH session riding the tunnel
+// is not cut off mid-command.
+func wgReloadCmd(detached bool) string {
+	if detached {
+		return fmt.Sprintf(`sudo bash -c 'nohup bash -c "sleep %d; wg syncconf wg0 <(wg-quick strip wg0)" > /dev/null 2>&1 &'`, int(wgDetachedReloadDelay.Seconds()))
+	}
+	return "sudo bash -c 'wg syncconf wg0 <(wg-quick strip wg0)'"
+}
+
+// setInterfacePrivateKey replaces the [Interface] PrivateKey of a WireGuard config
+func setInterfacePrivateKey(config, privateKey string) (string, error) {
+	lines := strings.Split(config, "\n")
+	section := ""
+	found := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			section = trimmed
+			continue
+		}
+		if key, _, ok := splitWGConfigLine(trimmed); ok && section == "[Interface]" && key == "PrivateKey" {
+			lines[i] = "PrivateKey = " + privateKey
+			found = true
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("WireGuard config has no [Interface] PrivateKey")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// replacePeerPublicKey swaps oldKey for newKey in the config's [Peer] sections
+// and reports whether the config had a peer with oldKey
+func replacePeerPublicKey(config, oldKey, newKey string) (string, bool) {
+	lines := strings.Split(config, "\n")
+	section := ""
+	changed := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			section = trimmed
+			continue
+		}
+		if key, value, ok := splitWGConfigLine(trimmed); ok && section == "[Peer]" && key == "PublicKey" && value == oldKey {
+			lines[i] = "PublicKey = " + newKey
+			changed = true
+		}
+	}
+	return strings.Join(lines, "\n"), changed
+}
+
+// splitWGConfigLine splits a "Key = Value" line; base64 keys end in '=' so
+// only the first one separates key from value
+func splitWGConfigLine(line string) (key, value string, ok bool) {
+	if strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// parseWGDumpHandshakes maps each peer's public key to its latest handshake
+// (unix seconds, 0 for never) from 'wg show wg0 dump' peer lines
+func parseWGDumpHandshakes(output string) map[string]int64 {
+	handshakes := make(map[string]int64)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		handshake, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			continue
+		}
+		handshakes[fields[0]] = handshake
+	}
+	return handshakes
+}
+
+// bastionMemberFromOutputs returns the bastion as a mesh member, reached
+// directly on its public IP as root
+func bastionMemberFromOutputs(outputs auto.OutputMap) (NodeInfo, bool) {
+	if bastionIPFromOutputs(outputs) == "" {
+		return NodeInfo{}, false
+	}
+	bastionMap, _ := outputs["bastion"].Value.(map[string]interface{})
+
+	member := NodeInfo{Name: "bastion", SSHUser: "root"}
+	if name, ok := bastionMap["name"].(string); ok && name != "" {
+		member.Name = name
+	}
+	member.PublicIP, _ = bastionMap["public_ip"].(string)
+	member.PrivateIP, _ = bastionMap["private_ip"].(string)
+	member.WireGuardIP, _ = bastionMap["vpn_ip"].(string)
+	member.Provider, _ = bastionMap["provider"].(string)
+	return member, true
+}
+
+// runOnMeshMember runs command over SSH on a mesh member, hopping through the
+// bastion for everything but the bastion itself
+func runOnMeshMember(ctx context.Context, sshKeyPath string, member NodeInfo, bastionName, bastionIP, command, stdin string) (string, error) {
+	targetIP, hop := member.PublicIP, ""
+	if member.Name != bastionName || bastionIP == "" {
+		var err error
+		if targetIP, err = resolveNodeIP(member, "", bastionIP != ""); err != nil {
+			return "", err
+		}
+		hop = bastionIP
+	}
+
+	sshArgs := append([]string{"-q", "-o", "BatchMode=yes", "-o", "ConnectTimeout=10"},
+		buildNodeSSHArgs(sshKeyPath, sshUserForNode(member), targetIP, hop, false)...)
+	sshCmd := exec.CommandContext(ctx, "ssh", append(sshArgs, command)...)
+	if stdin != "" {
+		sshCmd.Stdin = strings.NewReader(stdin)
+	}
+
+	output, err := sshCmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return string(output), fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return string(output), err
+	}
+	return string(output), nil
+}
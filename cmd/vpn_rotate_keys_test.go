@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMesh keeps a wg0.conf per member and answers the commands keyRotator runs
+type fakeMesh struct {
+	configs   map[string]string
+	keys      map[string]string
+	handshake int64
+	reloads   []string
+	detached  []string
+}
+
+func newFakeMesh(names ...string) *fakeMesh {
+	mesh := &fakeMesh{
+		configs:   make(map[string]string),
+		keys:      make(map[string]string),
+		handshake: time.Now().Add(time.Hour).Unix(),
+	}
+	for _, name := range names {
+		mesh.keys[name] = "old-public-key-" + name + "="
+	}
+	for _, name := range names {
+		config := "[Interface]\nAddress = 10.8.0.1/24\nPrivateKey = old-private-key-" + name + "=\n"
+		for _, peer := range names {
+			if peer != name {
+				config += fmt.Sprintf("\n[Peer]\n# %s\nPublicKey = %s\nAllowedIPs = 10.8.0.0/24\n", peer, mesh.keys[peer])
+			}
+		}
+		mesh.configs[name] = config
+	}
+	return mesh
+}
+
+func (m *fakeMesh) run(member NodeInfo, command, stdin string) (string, error) {
+	switch {
+	case command == wgShowPublicKeyCmd:
+		return m.keys[member.Name] + "\n", nil
+	case command == wgReadConfigCmd:
+		return m.configs[member.Name], nil
+	case command == wgDumpCmd:
+		var dump strings.Builder
+		for _, line := range strings.Split(m.configs[member.Name], "\n") {
+			if key, value, ok := splitWGConfigLine(line); ok && key == "PublicKey" {
+				fmt.Fprintf(&dump, "%s\t(none)\t203.0.113.1:51820\t10.8.0.0/24\t%d\t100\t200\t25\n", value, m.handshake)
+			}
+		}
+		return dump.String(), nil
+	case strings.Contains(command, "cat > /etc/wireguard/rotate/wg0.conf"):
+		m.configs[member.Name] = stdin
+		return "", nil
+	case strings.Contains(command, "wg syncconf"):
+		m.reloads = append(m.reloads, member.Name)
+		if strings.Contains(command, "nohup") {
+			m.detached = append(m.detached, member.Name)
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("unexpected command %q", command)
+}
+
+func newTestKeyRotator(mesh *fakeMesh, bastion string) *keyRotator {
+	generated := 0
+	return &keyRotator{
+		run: mesh.run,
+		newKeypair: func() (string, string, error) {
+			generated++
+			return fmt.Sprintf("new-private-key-%d=", generated), fmt.Sprintf("new-public-key-%d=", generated), nil
+		},
+		bastion:          bastion,
+		handshakeTimeout: 0,
+		pollInterval:     time.Millisecond,
+	}
+}
+
+func TestVPNRotateKeysCmd_Structure(t *testing.T) {
+	assert.Equal(t, "rotate-keys [stack-name]", vpnRotateKeysCmd.Use)
+	assert.NotEmpty(t, vpnRotateKeysCmd.Short)
+	assert.Contains(t, vpnRotateKeysCmd.Example, "--node worker-1")
+	assert.NotNil(t, vpnRotateKeysCmd.RunE)
+	assert.NotNil(t, vpnRotateKeysCmd.Flags().Lookup("node"))
+}
+
+func TestKeyRotator_RotateSingleNode_BastionUpdatedLast(t *testing.T) {
+	mesh := newFakeMesh("master-1", "worker-1", "bastion")
+	members := []NodeInfo{{Name: "master-1"}, {Name: "worker-1"}, {Name: "bastion"}}
+
+	err := newTestKeyRotator(mesh, "bastion").rotate(members, []NodeInfo{{Name: "worker-1"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"master-1", "worker-1", "bastion"}, mesh.reloads)
+	assert.Equal(t, []string{"master-1", "worker-1"}, mesh.detached, "members behind the bastion reload in the background")
+
+	assert.Contains(t, mesh.configs["worker-1"], "PrivateKey = new-private-key-1=")
+	for _, peer := range []string{"master-1", "bastion"} {
+		assert.Contains(t, mesh.configs[peer], "PublicKey = new-public-key-1=")
+		assert.NotContains(t, mesh.configs[peer], "old-public-key-worker-1=")
+		assert.Contains(t, mesh.configs[peer], "PrivateKey = old-private-key-"+peer+"=")
+	}
+}
+
+func TestKeyRotator_RotateAll(t *testing.T) {
+	mesh := newFakeMesh("master-1", "worker-1")
+	members := []NodeInfo{{Name: "master-1"}, {Name: "worker-1"}}
+
+	err := newTestKeyRotator(mesh, "").rotate(members, members)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"worker-1", "master-1", "master-1", "worker-1"}, mesh.reloads)
+	assert.Empty(t, mesh.detached, "without a bastion every member is reached directly")
+	assert.Contains(t, mesh.configs["master-1"], "PrivateKey = new-private-key-1=")
+	assert.Contains(t, mesh.configs["master-1"], "PublicKey = new-public-key-2=")
+	assert.Contains(t, mesh.configs["worker-1"], "PrivateKey = new-private-key-2=")
+	assert.Contains(t, mesh.configs["worker-1"], "PublicKey = new-public-key-1=")
+}
+
+func TestKeyRotator_HandshakesNotReestablished(t *testing.T) {
+	mesh := newFakeMesh("master-1", "worker-1")
+	mesh.handshake = 0
+	members := []NodeInfo{{Name: "master-1"}, {Name: "worker-1"}}
+
+	err := newTestKeyRotator(mesh, "").rotate(members, []NodeInfo{{Name: "worker-1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not handshake")
+	assert.Contains(t, err.Error(), "master-1 -> worker-1")
+	assert.Contains(t, err.Error(), "worker-1 -> master-1")
+}
+
+func TestKeyRotator_MissingPrivateKey(t *testing.T) {
+	mesh := newFakeMesh("master-1", "worker-1")
+	mesh.configs["worker-1"] = "[Peer]\nPublicKey = old-public-key-master-1=\n"
+	members := []NodeInfo{{Name: "master-1"}, {Name: "worker-1"}}
+
+	err := newTestKeyRotator(mesh, "").rotate(members, []NodeInfo{{Name: "worker-1"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no [Interface] PrivateKey")
+}
+
+func TestRotationOrder(t *testing.T) {
+	members := []NodeInfo{{Name: "bastion"}, {Name: "master-1"}, {Name: "worker-1"}, {Name: "worker-2"}}
+	names := func(nodes []NodeInfo) []string {
+		var out []string
+		for _, node := range nodes {
+			out = append(out, node.Name)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"master-1", "worker-2", "worker-1", "bastion"}, names(rotationOrder(members, "worker-1", "bastion")))
+	assert.Equal(t, []string{"master-1", "worker-1", "worker-2", "bastion"}, names(rotationOrder(members, "bastion", "bastion")))
+	assert.Equal(t, []string{"bastion", "master-1", "worker-2", "worker-1"}, names(rotationOrder(members, "worker-1", "")))
+}
+
+func TestReplacePeerPublicKey(t *testing.T) {
+	config := "[Interface]\nPrivateKey = abc=\n\n[Peer]\nPublicKey=old+key/1=\nAllowedIPs = 10.8.0.2/32\n"
+
+	updated, changed := replacePeerPublicKey(config, "old+key/1=", "new+key/2=")
+	assert.True(t, changed)
+	assert.Contains(t, updated, "PublicKey = new+key/2=")
+	assert.Contains(t, updated, "PrivateKey = abc=")
+
+	_, changed = replacePeerPublicKey(config, "abc=", "new+key/2=")
+	assert.False(t, changed, "only [Peer] sections hold peer keys")
+}
+
+func TestSetInterfacePrivateKey(t *testing.T) {
+	updated, err := setInterfacePrivateKey("[Interface]\nPrivateKey = old=\n# PrivateKey = comment\n", "new=")
+	require.NoError(t, err)
+	assert.Equal(t, "[Interface]\nPrivateKey = new=\n# PrivateKey = comment\n", updated)
+
+	_, err = setInterfacePrivateKey("[Peer]\nPublicKey = key=\n", "new=")
+	assert.Error(t, err)
+}
+
+func TestParseWGDumpHandshakes(t *testing.T) {
+	dump := "keyA=\t(none)\t203.0.113.1:51820\t10.8.0.2/32\t1700000000\t10\t20\t25\n" +
+		"keyB=\t(none)\t(none)\t10.8.0.3/32\t0\t0\t0\toff\n" +
+		"short line\n"
+
+	assert.Equal(t, map[string]int64{"keyA=": 1700000000, "keyB=": 0}, parseWGDumpHandshakes(dump))
+}
+
+func TestWGReloadCmd(t *testing.T) {
+	assert.NotContains(t, wgReloadCmd(false), "nohup")
+	assert.Contains(t, wgReloadCmd(true), "nohup bash -c \"sleep 2; wg syncconf wg0")
+	assert.Contains(t, wgWriteConfigCmd("pre-rotate", true), "/etc/wireguard/publickey")
+	assert.NotContains(t, wgWriteConfigCmd("pre-rotate", false), "/etc/wireguard/publickey")
+}
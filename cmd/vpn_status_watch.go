@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
+)
+
+// wireGuardOnlineWindow is how recent a handshake must be for a WireGuard
+// peer to count as online. Peers with keepalive handshake every two minutes.
+const wireGuardOnlineWindow = 3 * time.Minute
+
+// vpnStatusWatcher reads the mesh state from one node over a single SSH
+// connection, reconnecting (to the next reachable node if needed) only when
+// the connection drops
+type vpnStatusWatcher struct {
+	mode           VPNMode
+	nodes          []NodeInfo
+	connMgr        *vpn.ConnectionManager
+	bastionEnabled bool
+	bastionIP      string
+
+	conn *vpn.SSHConnection
+	node NodeInfo
+}
+
+// execute runs command on the connected node, connecting first if needed
+func (w *vpnStatusWatcher) execute(ctx context.Context, command string) (string, error) {
+	if w.conn != nil {
+		output, err := w.conn.Execute(command)
+		if err == nil {
+			return output, nil
+		}
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	lastErr := fmt.Errorf("no reachable nodes")
+	for _, node := range w.nodes {
+		// Through the bastion nodes are reached on their private network
+		targetIP := node.PublicIP
+		if w.bastionEnabled && w.bastionIP != "" {
+			targetIP = node.PrivateIP
+			if targetIP == "" {
+				targetIP = node.WireGuardIP
+			}
+		}
+		if targetIP == "" {
+			continue
+		}
+
+		conn, err := w.connMgr.Connect(ctx, vpn.ConnectionConfig{
+			Host:        targetIP,
+			User:        sshUserForNode(node),
+			UseBastion:  w.bastionEnabled && w.bastionIP != "",
+			BastionHost: w.bastionIP,
+			BastionUser: "root",
+			Timeout:     10 * time.Second,
+		})
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", node.Name, err)
+			continue
+		}
+		output, err := conn.Execute(command)
+		if err != nil {
+			conn.Close()
+			lastErr = fmt.Errorf("%s: %w", node.Name, err)
+			continue
+		}
+		w.conn, w.node = conn, node
+		return output, nil
+	}
+	return "", lastErr
+}
+
+// fetch reads the current mesh state and the reachability of every peer, by
+// name, as the connected node sees it
+func (w *vpnStatusWatcher) fetch(ctx context.Context) (*vpnLiveStatus, map[string]bool, error) {
+	if w.mode == VPNModeTailscale {
+		output, err := w.execute(ctx, "sudo tailscale status --json 2>/dev/null")
+		if err != nil {
+			return nil, nil, err
+		}
+		var status map[string]interface{}
+		if err := json.Unmarshal([]byte(output), &status); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse tailscale status from %s: %w", w.node.Name, err)
+		}
+		peers := parseTailscalePeers(status)
+
+		self := w.node.Name
+		if selfStatus, ok := status["Self"].(map[string]interface{}); ok {
+			if hostname, ok := selfStatus["HostName"].(string); ok && hostname != "" {
+				self = hostname
+			}
+		}
+		online := map[string]bool{self: true}
+		for _, peer := range peers {
+			name := peer.Hostname
+			if name == "" {
+				name = peer.TailnetIP
+			}
+			online[name] = peer.Online
+		}
+		return &vpnLiveStatus{tailscalePeers: peers}, online, nil
+	}
+
+	dump, err := w.execute(ctx, "sudo wg show wg0 dump | tail -n +2")
+	if err != nil {
+		return nil, nil, err
+	}
+	online := wireGuardPeerStates(dump, w.nodes, time.Now())
+	online[w.node.Name] = true
+	return &vpnLiveStatus{wireGuardPeers: online}, online, nil
+}
+
+// Close closes the SSH connection
+func (w *vpnStatusWatcher) Close() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// wireGuardPeerStates maps each peer in 'wg show wg0 dump' peer lines to
+// whether it had a handshake within wireGuardOnlineWindow. Cluster nodes are
+// named; other clients go by their VPN IP.
+func wireGuardPeerStates(dump string, nodes []NodeInfo, now time.Time) map[string]bool {
+	states := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(dump), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+
+		vpnIP := vpnIPOf(strings.Split(fields[3], ",")[0])
+		name := vpnIP
+		for _, node := range nodes {
+			if vpnIPOf(node.WireGuardIP) == vpnIP {
+				name = node.Name
+				break
+			}
+		}
+		if name == "" || name == "(none)" {
+			continue
+		}
+
+		handshake, err := strconv.ParseInt(fields[4], 10, 64)
+		states[name] = err == nil && handshake > 0 && now.Sub(time.Unix(handshake, 0)) <= wireGuardOnlineWindow
+	}
+	return states
+}
+
+// vpnPeerChange returns the delta indicator for a peer whose reachability
+// changed since the previous refresh, or "" when it did not
+func vpnPeerChange(previous map[string]bool, name string, online bool) string {
+	if previous == nil {
+		return ""
+	}
+	was, known := previous[name]
+	switch {
+	case !known:
+		return "+ new"
+	case online && !was:
+		return "▲ came online"
+	case !online && was:
+		return "▼ went offline"
+	}
+	return ""
+}
+
+// printVPNPeerStates prints one row per peer with the change since previous
+func printVPNPeerStates(current, previous map[string]bool) {
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	color.New(color.Bold).Printf("%-24s %-8s %s\n", "PEER", "STATE", "CHANGE")
+	for _, name := range names {
+		state := color.GreenString("%-8s", "online")
+		if !current[name] {
+			state = color.RedString("%-8s", "offline")
+		}
+		change := vpnPeerChange(previous, name, current[name])
+		switch {
+		case strings.HasPrefix(change, "▲"):
+			change = color.GreenString(change)
+		case strings.HasPrefix(change, "▼"):
+			change = color.RedString(change)
+		}
+		fmt.Printf("%-24s %s %s\n", name, state, change)
+	}
+
+	// Peers that disappeared from the table entirely
+	var gone []string
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+	sort.Strings(gone)
+	for _, name := range gone {
+		fmt.Printf("%-24s %-8s %s\n", name, "-", color.YellowString("- removed"))
+	}
+}
+
+// watchVPNStatus re-renders the status table every interval until Ctrl+C,
+// marking peers that came online or went offline since the previous refresh
+func watchVPNStatus(ctx context.Context, stack string, outputs auto.OutputMap, nodes []NodeInfo, sshKeyPath string, bastionEnabled bool, bastionIP string, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(ctx, signalInterrupt, signalTerminate)
+	defer stop()
+
+	vpnMgr, err := vpn.NewManager(vpn.ManagerConfig{
+		SSHKeyPath:     sshKeyPath,
+		RetryPolicy:    vpn.NewDefaultRetryPolicy(),
+		ConnectTimeout: 10 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize VPN manager: %w", err)
+	}
+
+	mode, _ := detectVPNMode(outputs)
+	watcher := &vpnStatusWatcher{
+		mode:           mode,
+		nodes:          nodes,
+		connMgr:        vpnMgr.GetConnectionManager(),
+		bastionEnabled: bastionEnabled,
+		bastionIP:      bastionIP,
+	}
+	defer watcher.Close()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous map[string]bool
+	for {
+		live, current, fetchErr := watcher.fetch(ctx)
+
+		// Clear the screen and redraw from the top
+		fmt.Print("\033[H\033[2J")
+		printHeader(fmt.Sprintf("🔐 VPN Status - Stack: %s", stack))
+		printVPNStatusReport(buildVPNStatusReport(stack, outputs, nodes, live))
+		fmt.Println()
+
+		if fetchErr != nil {
+			printWarning(fmt.Sprintf("Unable to fetch live status: %v", fetchErr))
+		} else {
+			printVPNPeerStates(current, previous)
+			previous = current
+		}
+
+		fmt.Println()
+		source := "-"
+		if watcher.conn != nil {
+			source = watcher.node.Name
+		}
+		fmt.Printf("Refreshed %s via %s, every %s. Press Ctrl+C to stop.\n", time.Now().Format("15:04:05"), source, interval)
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVPNStatusCmd_WatchFlag(t *testing.T) {
+	flag := vpnStatusCmd.Flags().Lookup("watch")
+	require.NotNil(t, flag)
+	assert.Equal(t, "0", flag.DefValue)
+	assert.Equal(t, "5", flag.NoOptDefVal, "--watch alone refreshes every 5 seconds")
+	assert.Contains(t, vpnStatusCmd.Example, "--watch")
+}
+
+func TestWireGuardPeerStates(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	nodes := []NodeInfo{
+		{Name: "master-1", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-1", WireGuardIP: "10.8.0.11/24"},
+		{Name: "worker-2", WireGuardIP: "10.8.0.12"},
+	}
+	dump := fmt.Sprintf(`keyA=	(none)	203.0.113.11:51820	10.8.0.11/32	%d	100	200	25
+keyB=	(none)	203.0.113.12:51820	10.8.0.12/32	%d	100	200	25
+keyC=	(none)	(none)	10.8.0.100/32	0	0	0	25
+keyD=	(none)	(none)	(none)	0	0	0	off
+`, now.Add(-30*time.Second).Unix(), now.Add(-10*time.Minute).Unix())
+
+	assert.Equal(t, map[string]bool{
+		"worker-1":   true,
+		"worker-2":   false,
+		"10.8.0.100": false,
+	}, wireGuardPeerStates(dump, nodes, now))
+
+	assert.Empty(t, wireGuardPeerStates("", nodes, now))
+}
+
+func TestVPNPeerChange(t *testing.T) {
+	previous := map[string]bool{"master-1": true, "worker-1": false}
+
+	assert.Equal(t, "", vpnPeerChange(nil, "master-1", true), "no delta on the first refresh")
+	assert.Equal(t, "", vpnPeerChange(previous, "master-1", true))
+	assert.Equal(t, "▼ went offline", vpnPeerChange(previous, "master-1", false))
+	assert.Equal(t, "▲ came online", vpnPeerChange(previous, "worker-1", true))
+	assert.Equal(t, "+ new", vpnPeerChange(previous, "laptop", true))
+}
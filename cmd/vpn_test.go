@@ -1,9 +1,23 @@
 package cmd
 
 import (
+	"fmt"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
 )
 
 func TestVPNCmd_Structure(t *testing.T) {
@@ -236,3 +250,283 @@ func TestVPNClientConfigCmd_Examples(t *testing.T) {
 	examples := vpnClientConfigCmd.Example
 	assert.Contains(t, examples, "client-config")
 }
+
+func TestVPNClientConfigCmd_PeerFlag(t *testing.T) {
+	flag := vpnClientConfigCmd.Flags().Lookup("peer")
+	assert.NotNil(t, flag)
+	assert.Equal(t, "", flag.DefValue)
+}
+
+func TestVPNConnectCmd_ForceFlag(t *testing.T) {
+	flag := vpnConnectCmd.Flags().Lookup("force")
+	require.NotNil(t, flag)
+	assert.Equal(t, "false", flag.DefValue)
+	assert.Contains(t, vpnConnectCmd.Example, "--force")
+}
+
+func TestEnsureNoVPNConnection(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	assert.NoError(t, ensureNoVPNConnection("prod", false), "nothing is connected")
+
+	daemon := exec.Command("sleep", "30")
+	require.NoError(t, daemon.Start())
+	go daemon.Wait()
+	t.Cleanup(func() { daemon.Process.Kill() })
+
+	require.NoError(t, tailscale.SaveDaemonState("prod", tailscale.DaemonState{PID: daemon.Process.Pid, Mode: tailscale.ModeDaemon}))
+
+	err := ensureNoVPNConnection("prod", false)
+	assert.ErrorContains(t, err, fmt.Sprintf("VPN is already connected for stack 'prod' (daemon, PID %d)", daemon.Process.Pid))
+
+	require.NoError(t, ensureNoVPNConnection("prod", true))
+	_, running := tailscale.RunningDaemon("prod")
+	assert.False(t, running, "--force stops the running daemon")
+}
+
+func TestFindVPNPeerNode(t *testing.T) {
+	nodes := []NodeInfo{
+		{Name: "master-1", PublicIP: "203.0.113.10", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-1", PublicIP: "203.0.113.20"},
+	}
+
+	node, err := findVPNPeerNode(nodes, "master-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.8.0.10", node.WireGuardIP)
+
+	_, err = findVPNPeerNode(nodes, "worker-1")
+	assert.ErrorContains(t, err, "no WireGuard IP")
+
+	_, err = findVPNPeerNode(nodes, "missing")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestGenerateSinglePeerClientConfig(t *testing.T) {
+	hub := NodeInfo{Name: "master-1", Provider: "digitalocean", PublicIP: "203.0.113.10", WireGuardIP: "10.8.0.10"}
+
+	config := generateSinglePeerClientConfig("client-private", "10.8.0.100", "laptop", hub, "hub-public", "", "10.8.0.0/24", nil, 25)
+
+	assert.Contains(t, config, "# Peer Label: laptop")
+	assert.Contains(t, config, "PrivateKey = client-private")
+	assert.Contains(t, config, "Address = 10.8.0.100/32")
+	assert.Contains(t, config, "PublicKey = hub-public")
+	assert.Contains(t, config, "Endpoint = 203.0.113.10:51820")
+	assert.Contains(t, config, "AllowedIPs = 10.8.0.0/24")
+	assert.NotContains(t, config, "PresharedKey")
+	assert.Equal(t, 1, strings.Count(config, "[Peer]"))
+
+	withPSK := generateSinglePeerClientConfig("client-private", "10.8.0.100", "", hub, "hub-public", "psk-value", "10.8.0.0/24", nil, 25)
+	assert.Contains(t, withPSK, "PresharedKey = psk-value")
+	assert.NotContains(t, withPSK, "Peer Label")
+}
+
+func TestGenerateSinglePeerClientConfig_IncludesRoutes(t *testing.T) {
+	hub := NodeInfo{Name: "master-1", PublicIP: "203.0.113.10", WireGuardIP: "10.8.0.10"}
+	routes := []vpn.AdvertisedRoute{
+		{CIDR: "10.244.0.0/16", Via: "master-1"},
+		{CIDR: "10.96.0.0/12", Via: "worker-1"},
+	}
+
+	config := generateSinglePeerClientConfig("client-private", "10.8.0.100", "", hub, "hub-public", "", "10.8.0.0/24", routes, 25)
+	assert.Contains(t, config, "AllowedIPs = 10.8.0.0/24, 10.244.0.0/16, 10.96.0.0/12")
+}
+
+func TestGenerateSinglePeerClientConfig_Keepalive(t *testing.T) {
+	hub := NodeInfo{Name: "master-1", PublicIP: "203.0.113.10", WireGuardIP: "10.8.0.10"}
+
+	config := generateSinglePeerClientConfig("client-private", "10.8.0.100", "", hub, "hub-public", "", "10.8.0.0/24", nil, 15)
+	assert.Contains(t, config, "PersistentKeepalive = 15")
+
+	config = generateSinglePeerClientConfig("client-private", "10.8.0.100", "", hub, "hub-public", "", "10.8.0.0/24", nil, 0)
+	assert.NotContains(t, config, "PersistentKeepalive")
+}
+
+func TestClientKeepalive(t *testing.T) {
+	assert.Equal(t, config.DefaultPersistentKeepalive, clientKeepalive(auto.OutputMap{}), "clients are assumed behind NAT")
+
+	outputs := auto.OutputMap{"configJson": auto.OutputValue{Value: `{"network": {"wireguard": {"enabled": true, "persistentKeepalive": 40}}}`}}
+	assert.Equal(t, 40, clientKeepalive(outputs))
+}
+
+func TestWriteClientConfig(t *testing.T) {
+	config := generateSinglePeerClientConfig("client-private", "10.8.0.100", "phone",
+		NodeInfo{Name: "master-1", PublicIP: "203.0.113.10", WireGuardIP: "10.8.0.10"}, "hub-public", "", "10.8.0.0/24", nil, 25)
+	dir := t.TempDir()
+
+	confPath, err := writeClientConfig(filepath.Join(dir, "phone.conf"), config, false)
+	require.NoError(t, err)
+	data, err := os.ReadFile(confPath)
+	require.NoError(t, err)
+	assert.Equal(t, config, string(data), "a .conf path gets the plain-text config")
+	info, err := os.Stat(confPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	pngPath, err := writeClientConfig(filepath.Join(dir, "phone.PNG"), config, false)
+	require.NoError(t, err)
+	file, err := os.Open(pngPath)
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = png.Decode(file)
+	assert.NoError(t, err, "a .png path gets the QR code image")
+	info, err = os.Stat(pngPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestWriteClientConfig_QRTooLarge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wg0.conf")
+
+	_, err := writeClientConfig(path, strings.Repeat("x", 3000), true)
+	assert.ErrorContains(t, err, "failed to encode client config as QR code")
+	assert.NoFileExists(t, path)
+}
+
+func TestHubRoutingScript(t *testing.T) {
+	script := hubRoutingScript("10.8.0.100")
+
+	assert.Contains(t, script, "net.ipv4.ip_forward=1")
+	assert.Contains(t, script, "-A FORWARD -i wg0 -o wg0 -j ACCEPT")
+	assert.Contains(t, script, "-A POSTROUTING -s 10.8.0.100/32 -o wg0 -j MASQUERADE")
+}
+
+func TestParseWireGuardPeerTable(t *testing.T) {
+	nodes := []NodeInfo{
+		{Name: "master-1", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-1", WireGuardIP: "10.8.0.11/32"},
+	}
+	config := "[Interface]\nPrivateKey = priv=\n\n[Peer]\n# Peer: control-plane\nPublicKey = keyMaster=\nAllowedIPs = 10.8.0.10/32\n"
+	now := time.Unix(1700000300, 0)
+	dump := "keyMaster=\t(none)\t203.0.113.10:51820\t10.8.0.10/32,10.0.0.0/8\t1700000290\t2048\t1024\t25\n" +
+		"keyWorker=\t(none)\t(none)\t10.8.0.11/32\t0\t0\t0\toff\n" +
+		"keyLaptop=\t(none)\t198.51.100.7:41000\t10.8.0.100/32\t0\t0\t0\toff\n"
+
+	peers := parseWireGuardPeerTable(config, dump, nodes, now)
+	require.Len(t, peers, 2, "peers that are not cluster nodes are skipped")
+
+	assert.Equal(t, "master-1", peers[0].NodeName)
+	assert.Equal(t, "10.8.0.10", peers[0].VPNIp)
+	assert.Equal(t, "control-plane", peers[0].Label)
+	assert.Equal(t, "10s ago", peers[0].LastHandshake)
+	assert.Equal(t, "203.0.113.10:51820", peers[0].Endpoint)
+
+	assert.Equal(t, "worker-1", peers[1].NodeName)
+	assert.Equal(t, "Never", peers[1].LastHandshake)
+	assert.Equal(t, "N/A", peers[1].Endpoint)
+}
+
+func TestCollectWireGuardPeerTables_BoundedConcurrency(t *testing.T) {
+	var nodes []NodeInfo
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, NodeInfo{Name: fmt.Sprintf("node-%d", i), WireGuardIP: fmt.Sprintf("10.8.0.%d", 10+i)})
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	fetch := func(node NodeInfo) (string, string, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if node.Name == "node-3" {
+			return "", "", fmt.Errorf("connection timed out")
+		}
+		return "", "keyPeer=\t(none)\t(none)\t10.8.0.10/32\t0\t0\t0\toff\n", nil
+	}
+
+	tables := collectWireGuardPeerTables(nodes, 3, fetch)
+	require.Len(t, tables, len(nodes))
+	assert.LessOrEqual(t, maxRunning, 3)
+
+	for i, table := range tables {
+		assert.Equal(t, nodes[i].Name, table.node.Name, "tables keep the node order")
+	}
+	assert.Error(t, tables[3].err)
+	assert.Len(t, tables[0].peers, 1)
+}
+
+func TestMergeWireGuardPeerTables_PartialMesh(t *testing.T) {
+	nodes := []NodeInfo{
+		{Name: "master-1", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-1", WireGuardIP: "10.8.0.11"},
+		{Name: "worker-2", WireGuardIP: "10.8.0.12"},
+		{Name: "worker-3", WireGuardIP: "10.8.0.13"},
+	}
+	peer := func(name, ip, key string) wireGuardPeerInfo {
+		return wireGuardPeerInfo{NodeName: name, VPNIp: ip, PublicKey: key}
+	}
+	tables := []wireGuardPeerTable{
+		{node: nodes[0], peers: []wireGuardPeerInfo{
+			peer("worker-1", "10.8.0.11", "keyWorker1="),
+			peer("worker-2", "10.8.0.12", "keyWorker2="),
+		}},
+		{node: nodes[1], peers: []wireGuardPeerInfo{
+			peer("master-1", "10.8.0.10", "keyMaster1="),
+			peer("worker-2", "10.8.0.12", "keyWorker2-stale="),
+		}},
+		{node: nodes[2], peers: []wireGuardPeerInfo{
+			peer("worker-1", "10.8.0.11", "keyWorker1="),
+		}},
+		{node: nodes[3], err: fmt.Errorf("unreachable")},
+	}
+
+	peers, warnings := mergeWireGuardPeerTables(tables, nodes)
+
+	require.Len(t, peers, 3)
+	assert.Equal(t, []string{"master-1", "worker-1", "worker-2"}, []string{peers[0].NodeName, peers[1].NodeName, peers[2].NodeName})
+
+	assert.Equal(t, []string{
+		"master-1 (10.8.0.10) is missing from the peer table of worker-2",
+		"worker-2 (10.8.0.12) has different public keys across nodes: keyWorker2-stale... on worker-1; keyWorker2= on master-1",
+		"worker-3 (10.8.0.13) is missing from the peer table of master-1, worker-1, worker-2",
+	}, warnings)
+}
+
+func TestVPNTestCmd_StaleAfterFlag(t *testing.T) {
+	flag := vpnTestCmd.Flags().Lookup("stale-after")
+	require.NotNil(t, flag)
+	assert.Equal(t, "3m0s", flag.DefValue)
+	assert.Contains(t, vpnTestCmd.Example, "--stale-after")
+}
+
+func TestStaleWireGuardHandshakes(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	nodes := []NodeInfo{
+		{Name: "master-1", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-1", WireGuardIP: "10.8.0.11/24"},
+		{Name: "worker-2", WireGuardIP: "10.8.0.12"},
+		{Name: "worker-3", WireGuardIP: "10.8.0.13"},
+		{Name: "worker-4", WireGuardIP: "10.8.0.14"},
+		{Name: "no-vpn"},
+	}
+	dump := fmt.Sprintf(`keyW1=	(none)	203.0.113.11:51820	10.8.0.11/32	%d	100	200	25
+keyW2=	(none)	203.0.113.12:51820	10.8.0.12/32	%d	100	200	25
+keyW3=	(none)	(none)	10.8.0.13/32	0	0	0	25
+keyLaptop=	(none)	(none)	10.8.0.100/32	0	0	0	25
+`, now.Add(-30*time.Second).Unix(), now.Add(-10*time.Minute).Unix())
+
+	active, stale := staleWireGuardHandshakes(nodes[0], dump, nodes, now, 3*time.Minute)
+	assert.Equal(t, 1, active)
+	assert.Equal(t, []staleWireGuardHandshake{
+		{Peer: "worker-2", VPNIP: "10.8.0.12", LastHandshake: now.Add(-10 * time.Minute)},
+		{Peer: "worker-3", VPNIP: "10.8.0.13"},
+		{Peer: "worker-4", VPNIP: "10.8.0.14", Missing: true},
+	}, stale, "clients and nodes without a VPN IP are ignored")
+
+	// A longer threshold accepts the older handshake
+	active, stale = staleWireGuardHandshakes(nodes[0], dump, nodes, now, 15*time.Minute)
+	assert.Equal(t, 2, active)
+	assert.Len(t, stale, 2)
+
+	// Every peer is missing from an empty table
+	_, stale = staleWireGuardHandshakes(nodes[0], "", nodes, now, 3*time.Minute)
+	assert.Len(t, stale, 4)
+}
@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto"
+	"github.com/spf13/cobra"
+)
+
+var vpnVerifyConfigCmd = &cobra.Command{
+	Use:   "verify-config [stack-name]",
+	Short: "Check every node's wg0.conf against the expected full mesh",
+	Long: `Read /etc/wireguard/wg0.conf from every node (and the bastion) and check
+that each one lists every other mesh member as a peer whose AllowedIPs hold
+that member's VPN IP.
+
+Missing peers and extra peers (stale nodes, duplicates, or peers with the
+wrong AllowedIPs) are reported per node. Client peers added with 'vpn join'
+are not checked. This catches a partial mesh that 'vpn test' may still pass
+when routing is lenient.`,
+	Example: `  # Verify the mesh configuration
+  sloth-kubernetes vpn verify-config production
+
+  # Machine-readable report
+  sloth-kubernetes vpn verify-config production --output json`,
+	RunE: runVPNVerifyConfig,
+}
+
+func init() {
+	vpnCmd.AddCommand(vpnVerifyConfigCmd)
+}
+
+// wireGuardConfigPeer is a [Peer] section of a wg0.conf
+type wireGuardConfigPeer struct {
+	PublicKey    string
+	PresharedKey string
+	AllowedIPs   []string
+	Label        string // From a "# Peer: <label>" comment; set for clients only
+}
+
+// wireGuardConfigDrift is how a member's wg0.conf differs from the full mesh
+type wireGuardConfigDrift struct {
+	Node    string   `json:"node"`
+	Missing []string `json:"missing,omitempty"` // Members without a matching peer
+	Extra   []string `json:"extra,omitempty"`   // Peers that match no other member
+	Error   string   `json:"error,omitempty"`
+}
+
+// ok reports whether the member was read and matches the mesh
+func (d wireGuardConfigDrift) ok() bool {
+	return d.Error == "" && len(d.Missing) == 0 && len(d.Extra) == 0
+}
+
+// wireGuardConfigReport is the result of 'vpn verify-config'
+type wireGuardConfigReport struct {
+	Stack   string                 `json:"stack"`
+	Members int                    `json:"members"`
+	Drifted int                    `json:"drifted"`
+	Nodes   []wireGuardConfigDrift `json:"nodes"`
+}
+
+func runVPNVerifyConfig(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	stack, err := RequireStack(args)
+	if err != nil {
+		return err
+	}
+
+	if err := validateVPNOutputFormat(); err != nil {
+		return err
+	}
+
+	if !vpnJSONOutput() {
+		printHeader(fmt.Sprintf("🔍 WireGuard config check - Stack: %s", stack))
+	}
+
+	workspace, err := createWorkspaceWithS3Support(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	fullyQualifiedStackName := fmt.Sprintf("organization/sloth-kubernetes/%s", stack)
+	s, err := auto.SelectStack(ctx, fullyQualifiedStackName, workspace)
+	if err != nil {
+		return fmt.Errorf("failed to select stack '%s': %w", stack, err)
+	}
+
+	outputs, err := s.Outputs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stack outputs: %w", err)
+	}
+
+	if vpnMode, _ := detectVPNMode(outputs); vpnMode != VPNModeWireGuard {
+		return fmt.Errorf("config verification is only supported for WireGuard (stack uses %s)", vpnMode)
+	}
+
+	nodes, err := ParseNodeOutputs(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no nodes found in stack")
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	members := nodes
+	bastionIP := bastionIPFromOutputs(outputs)
+	bastion, hasBastion := bastionMemberFromOutputs(outputs)
+	if hasBastion {
+		members = append(members, bastion)
+	}
+
+	if !vpnJSONOutput() {
+		fmt.Println()
+		printInfo(fmt.Sprintf("Reading wg0.conf from %d mesh member(s)...", len(members)))
+	}
+
+	sshKeyPath := GetSSHKeyPath(stack)
+	report := verifyWireGuardConfigs(stack, members, vpnPeersConcurrency, func(member NodeInfo) (string, error) {
+		return runOnMeshMember(ctx, sshKeyPath, member, bastion.Name, bastionIP, wgReadConfigCmd, "")
+	})
+
+	if vpnJSONOutput() {
+		if err := writeVPNJSON(report); err != nil {
+			return err
+		}
+	} else {
+		printWireGuardConfigReport(report)
+	}
+
+	if report.Drifted > 0 {
+		return fmt.Errorf("%d of %d mesh member(s) do not match the expected mesh", report.Drifted, report.Members)
+	}
+	return nil
+}
+
+// verifyWireGuardConfigs reads the wg0.conf of every member, at most workers
+// at a time, and checks it against the mesh. Nodes keep the order of members.
+func verifyWireGuardConfigs(stack string, members []NodeInfo, workers int, fetch func(member NodeInfo) (string, error)) wireGuardConfigReport {
+	drifts := make([]wireGuardConfigDrift, len(members))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member NodeInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			config, err := fetch(member)
+			if err != nil {
+				drifts[i] = wireGuardConfigDrift{Node: member.Name, Error: err.Error()}
+				return
+			}
+			drifts[i] = checkWireGuardConfigDrift(member, parseWireGuardConfigPeers(config), members)
+		}(i, member)
+	}
+	wg.Wait()
+
+	report := wireGuardConfigReport{Stack: stack, Members: len(members), Nodes: drifts}
+	for _, drift := range drifts {
+		if !drift.ok() {
+			report.Drifted++
+		}
+	}
+	return report
+}
+
+// parseWireGuardConfigPeers returns the [Peer] sections of a wg0.conf
+func parseWireGuardConfigPeers(config string) []wireGuardConfigPeer {
+	var peers []wireGuardConfigPeer
+	var current *wireGuardConfigPeer
+	for _, line := range strings.Split(config, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "[") {
+			if current != nil {
+				peers = append(peers, *current)
+				current = nil
+			}
+			if strings.EqualFold(line, "[Peer]") {
+				current = &wireGuardConfigPeer{}
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if strings.HasPrefix(line, "# Peer:") {
+			current.Label = strings.TrimSpace(strings.TrimPrefix(line, "# Peer:"))
+			continue
+		}
+		key, value, ok := splitWGConfigLine(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "PublicKey":
+			current.PublicKey = value
+		case "PresharedKey":
+			current.PresharedKey = value
+		case "AllowedIPs":
+			for _, ip := range strings.Split(value, ",") {
+				if ip = strings.TrimSpace(ip); ip != "" {
+					current.AllowedIPs = append(current.AllowedIPs, ip)
+				}
+			}
+		}
+	}
+	if current != nil {
+		peers = append(peers, *current)
+	}
+	return peers
+}
+
+// checkWireGuardConfigDrift compares a member's peers with the full mesh:
+// every other member must appear once, as a peer whose AllowedIPs hold its
+// VPN IP as a /32. A peer matching no other member is extra, unless it is a
+// labelled client peer.
+func checkWireGuardConfigDrift(member NodeInfo, peers []wireGuardConfigPeer, members []NodeInfo) wireGuardConfigDrift {
+	drift := wireGuardConfigDrift{Node: member.Name}
+
+	stale, missing := meshPeerIssues(member, peers, members, nil)
+	for i, peer := range peers {
+		if reason, ok := stale[i]; ok {
+			drift.Extra = append(drift.Extra, describeWireGuardConfigPeer(peer, reason))
+		}
+	}
+	drift.Missing = missing
+	return drift
+}
+
+// meshPeerIssues classifies a member's peers against the full mesh. It
+// returns why each peer that does not belong is stale, keyed by its index in
+// peers, and the members no peer stands for. With keys, a peer standing for a
+// member under another public key is stale too, and the member is missing.
+func meshPeerIssues(member NodeInfo, peers []wireGuardConfigPeer, members []NodeInfo, keys map[string]string) (map[int]string, []string) {
+	memberByIP := make(map[string]string)
+	for _, m := range members {
+		if ip := vpnIPOf(m.WireGuardIP); ip != "" {
+			memberByIP[ip+"/32"] = m.Name
+		}
+	}
+
+	stale := make(map[int]string)
+	found := make(map[string]bool)
+	for i, peer := range peers {
+		name := ""
+		for _, allowed := range peer.AllowedIPs {
+			if n, ok := memberByIP[allowed]; ok {
+				name = n
+				break
+			}
+		}
+
+		switch {
+		case name == "" && peer.Label != "":
+			// A client added with 'vpn join'
+		case name == "":
+			stale[i] = "matches no mesh member"
+		case name == member.Name:
+			stale[i] = "is this node itself"
+		case found[name]:
+			stale[i] = "duplicates " + name
+		case keys != nil && keys[name] != peer.PublicKey:
+			stale[i] = "has an old key for " + name
+		default:
+			found[name] = true
+		}
+	}
+
+	var missing []string
+	for _, m := range members {
+		if m.Name != member.Name && m.WireGuardIP != "" && !found[m.Name] {
+			missing = append(missing, m.Name)
+		}
+	}
+	return stale, missing
+}
+
+// describeWireGuardConfigPeer names an extra peer by its key and AllowedIPs
+func describeWireGuardConfigPeer(peer wireGuardConfigPeer, reason string) string {
+	return fmt.Sprintf("%s... (%s) %s", peer.PublicKey[:min(16, len(peer.PublicKey))], strings.Join(peer.AllowedIPs, ", "), reason)
+}
+
+// printWireGuardConfigReport prints one line per member, followed by its
+// missing and extra peers
+func printWireGuardConfigReport(report wireGuardConfigReport) {
+	fmt.Println()
+	for _, node := range report.Nodes {
+		switch {
+		case node.Error != "":
+			color.Yellow(fmt.Sprintf("  ⚠️  %s: could not read wg0.conf: %s", node.Node, node.Error))
+		case node.ok():
+			color.Green(fmt.Sprintf("  ✓ %s", node.Node))
+		default:
+			color.Red(fmt.Sprintf("  ✗ %s", node.Node))
+		}
+		for _, name := range node.Missing {
+			fmt.Printf("      missing peer: %s\n", name)
+		}
+		for _, extra := range node.Extra {
+			fmt.Printf("      extra peer:   %s\n", extra)
+		}
+	}
+	fmt.Println()
+
+	if report.Drifted == 0 {
+		printSuccess(fmt.Sprintf("All %d mesh member(s) match the expected full mesh", report.Members))
+	}
+}
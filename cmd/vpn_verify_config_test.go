@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func verifyConfigMembers() []NodeInfo {
+	return []NodeInfo{
+		{Name: "master-1", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-1", WireGuardIP: "10.8.0.11"},
+		{Name: "worker-2", WireGuardIP: "10.8.0.12"},
+	}
+}
+
+const verifyConfigMaster = `[Interface]
+PrivateKey = master-private=
+Address = 10.8.0.10/24
+ListenPort = 51820
+
+[Peer]
+# worker-1 (10.8.0.11)
+PublicKey = worker-1-key=
+AllowedIPs = 10.8.0.11/32, 10.0.0.0/8
+Endpoint = 203.0.113.11:51820
+PersistentKeepalive = 25
+
+[Peer]
+# worker-2 (10.8.0.12)
+PublicKey = worker-2-key=
+AllowedIPs = 10.8.0.12/32, 10.0.0.0/8
+Endpoint = 203.0.113.12:51820
+
+[Peer]
+# Peer: laptop
+PublicKey = laptop-key=
+AllowedIPs = 10.8.0.100/32
+`
+
+func TestVPNVerifyConfigCmd_Structure(t *testing.T) {
+	assert.Equal(t, "verify-config [stack-name]", vpnVerifyConfigCmd.Use)
+	assert.NotNil(t, vpnVerifyConfigCmd.RunE)
+	assert.Contains(t, vpnVerifyConfigCmd.Example, "--output json")
+}
+
+func TestParseWireGuardConfigPeers(t *testing.T) {
+	peers := parseWireGuardConfigPeers(verifyConfigMaster)
+
+	require.Len(t, peers, 3)
+	assert.Equal(t, "worker-1-key=", peers[0].PublicKey)
+	assert.Equal(t, []string{"10.8.0.11/32", "10.0.0.0/8"}, peers[0].AllowedIPs)
+	assert.Empty(t, peers[0].Label)
+	assert.Equal(t, "laptop", peers[2].Label)
+}
+
+func TestCheckWireGuardConfigDrift_FullMesh(t *testing.T) {
+	members := verifyConfigMembers()
+
+	drift := checkWireGuardConfigDrift(members[0], parseWireGuardConfigPeers(verifyConfigMaster), members)
+
+	assert.True(t, drift.ok(), "the labelled client peer is not extra: %+v", drift)
+}
+
+func TestCheckWireGuardConfigDrift_PartialMesh(t *testing.T) {
+	members := verifyConfigMembers()
+	peers := []wireGuardConfigPeer{
+		{PublicKey: "worker-1-key=", AllowedIPs: []string{"10.8.0.11/32", "10.0.0.0/8"}},
+		{PublicKey: "worker-1-old-key=", AllowedIPs: []string{"10.8.0.11/32"}},
+		{PublicKey: "worker-2-key=", AllowedIPs: []string{"10.8.0.0/24"}},
+		{PublicKey: "worker-9-key=", AllowedIPs: []string{"10.8.0.19/32"}},
+		{PublicKey: "master-1-key=", AllowedIPs: []string{"10.8.0.10/32"}},
+	}
+
+	drift := checkWireGuardConfigDrift(members[0], peers, members)
+
+	assert.False(t, drift.ok())
+	assert.Equal(t, []string{"worker-2"}, drift.Missing, "a peer without the member's /32 does not count")
+	assert.Equal(t, []string{
+		"worker-1-old-key... (10.8.0.11/32) duplicates worker-1",
+		"worker-2-key=... (10.8.0.0/24) matches no mesh member",
+		"worker-9-key=... (10.8.0.19/32) matches no mesh member",
+		"master-1-key=... (10.8.0.10/32) is this node itself",
+	}, drift.Extra)
+}
+
+func TestVerifyWireGuardConfigs(t *testing.T) {
+	members := verifyConfigMembers()
+
+	report := verifyWireGuardConfigs("production", members, 2, func(member NodeInfo) (string, error) {
+		switch member.Name {
+		case "master-1":
+			return verifyConfigMaster, nil
+		case "worker-1":
+			return "[Peer]\nPublicKey = master-key=\nAllowedIPs = 10.8.0.10/32\n", nil
+		}
+		return "", fmt.Errorf("connection refused")
+	})
+
+	assert.Equal(t, 3, report.Members)
+	assert.Equal(t, 2, report.Drifted)
+	require.Len(t, report.Nodes, 3)
+	assert.True(t, report.Nodes[0].ok())
+	assert.Equal(t, []string{"worker-2"}, report.Nodes[1].Missing)
+	assert.Equal(t, "connection refused", report.Nodes[2].Error)
+}
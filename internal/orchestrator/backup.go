@@ -0,0 +1,231 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const (
+	veleroNamespace        = "velero"
+	veleroCredentialSecret = "cloud-credentials"
+	veleroAWSPluginImage   = "velero/velero-plugin-for-aws:v1.9.0"
+	veleroScheduleName     = "cluster-backup"
+
+	defaultBackupRetention = 30 * 24 * time.Hour
+)
+
+// veleroStorage is the S3-compatible bucket Velero writes backups to
+type veleroStorage struct {
+	Bucket    string
+	Prefix    string
+	Region    string
+	S3URL     string // Empty for AWS S3 itself
+	AccessKey string
+	SecretKey string
+}
+
+// veleroObjectStorage resolves where backups go. Backup.Storage is used as
+// given; anything it leaves out is derived from the first enabled provider
+// with S3-compatible object storage: S3 on AWS, Spaces on DigitalOcean and
+// Object Storage on Linode. Only AWS credentials can be reused, so the other
+// two need backup.storage.accessKey and secretKey.
+func veleroObjectStorage(cfg *config.ClusterConfig) (*veleroStorage, error) {
+	storage := config.BackupStorageConfig{}
+	if cfg.Backup.Storage != nil {
+		storage = *cfg.Backup.Storage
+	}
+	if storage.Type != "" && storage.Type != "s3" {
+		return nil, fmt.Errorf("backup storage type %s is not supported with velero; use s3", storage.Type)
+	}
+
+	s := &veleroStorage{
+		Bucket:    storage.Bucket,
+		Prefix:    storage.Path,
+		Region:    storage.Region,
+		S3URL:     storage.Endpoint,
+		AccessKey: storage.AccessKey,
+		SecretKey: storage.SecretKey,
+	}
+	if s.Bucket == "" {
+		s.Bucket = cfg.Backup.Location
+	}
+	if s.Bucket == "" {
+		return nil, fmt.Errorf("backup bucket is required: set backup.storage.bucket or backup.location")
+	}
+
+	providers := cfg.Providers
+	switch {
+	case providers.AWS != nil && providers.AWS.Enabled:
+		s.Region = firstNonEmpty(s.Region, providers.AWS.Region)
+		if s.AccessKey == "" && s.SecretKey == "" {
+			s.AccessKey = providers.AWS.AccessKeyID
+			s.SecretKey = providers.AWS.SecretAccessKey
+		}
+	case providers.DigitalOcean != nil && providers.DigitalOcean.Enabled:
+		s.Region = firstNonEmpty(s.Region, providers.DigitalOcean.Region)
+		s.S3URL = firstNonEmpty(s.S3URL, fmt.Sprintf("https://%s.digitaloceanspaces.com", s.Region))
+	case providers.Linode != nil && providers.Linode.Enabled:
+		s.Region = firstNonEmpty(s.Region, providers.Linode.Region)
+		s.S3URL = firstNonEmpty(s.S3URL, fmt.Sprintf("https://%s.linodeobjects.com", s.Region))
+	case s.S3URL == "":
+		return nil, fmt.Errorf("no object storage for backups: set backup.storage.endpoint or enable the aws, digitalocean or linode provider")
+	}
+
+	if s.Region == "" {
+		return nil, fmt.Errorf("backup storage region is required: set backup.storage.region")
+	}
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return nil, fmt.Errorf("backup storage credentials are required: set backup.storage.accessKey and backup.storage.secretKey")
+	}
+	return s, nil
+}
+
+// renderVeleroValues renders the Helm values that point Velero at the
+// bucket through the AWS plugin, which also serves S3-compatible stores
+func renderVeleroValues(s *veleroStorage, fsBackup bool) string {
+	var builder strings.Builder
+	builder.WriteString("initContainers:\n")
+	builder.WriteString("  - name: velero-plugin-for-aws\n")
+	builder.WriteString(fmt.Sprintf("    image: %s\n", veleroAWSPluginImage))
+	builder.WriteString("    volumeMounts:\n")
+	builder.WriteString("      - mountPath: /target\n")
+	builder.WriteString("        name: plugins\n")
+	builder.WriteString("configuration:\n")
+	builder.WriteString("  backupStorageLocation:\n")
+	builder.WriteString("    - name: default\n")
+	builder.WriteString("      provider: aws\n")
+	builder.WriteString(fmt.Sprintf("      bucket: %s\n", s.Bucket))
+	if s.Prefix != "" {
+		builder.WriteString(fmt.Sprintf("      prefix: %s\n", s.Prefix))
+	}
+	builder.WriteString("      config:\n")
+	builder.WriteString(fmt.Sprintf("        region: %s\n", s.Region))
+	if s.S3URL != "" {
+		builder.WriteString(fmt.Sprintf("        s3Url: %s\n", s.S3URL))
+		builder.WriteString("        s3ForcePathStyle: \"true\"\n")
+	}
+	builder.WriteString("credentials:\n")
+	builder.WriteString("  useSecret: true\n")
+	builder.WriteString(fmt.Sprintf("  existingSecret: %s\n", veleroCredentialSecret))
+	builder.WriteString("snapshotsEnabled: false\n")
+	if fsBackup {
+		builder.WriteString("deployNodeAgent: true\n")
+	}
+	return builder.String()
+}
+
+// renderVeleroSchedule renders the Schedule that backs up every namespace on
+// Backup.Schedule, keeping each backup for RetentionDays
+func renderVeleroSchedule(backup *config.BackupConfig) (string, error) {
+	if !validCronSchedule(backup.Schedule) {
+		return "", fmt.Errorf("backup schedule %q is not a cron expression", backup.Schedule)
+	}
+
+	ttl := defaultBackupRetention
+	if backup.RetentionDays > 0 {
+		ttl = time.Duration(backup.RetentionDays) * 24 * time.Hour
+	}
+
+	var builder strings.Builder
+	builder.WriteString("apiVersion: velero.io/v1\n")
+	builder.WriteString("kind: Schedule\n")
+	builder.WriteString("metadata:\n")
+	builder.WriteString(fmt.Sprintf("  name: %s\n", veleroScheduleName))
+	builder.WriteString(fmt.Sprintf("  namespace: %s\n", veleroNamespace))
+	builder.WriteString("spec:\n")
+	builder.WriteString(fmt.Sprintf("  schedule: %q\n", backup.Schedule))
+	builder.WriteString("  template:\n")
+	builder.WriteString(fmt.Sprintf("    ttl: %s\n", ttl))
+	builder.WriteString("    includedNamespaces:\n")
+	builder.WriteString("      - \"*\"\n")
+	if backup.IncludeVolumes {
+		builder.WriteString("    defaultVolumesToFsBackup: true\n")
+	}
+	return builder.String(), nil
+}
+
+// validCronSchedule accepts five-field cron expressions and the @ shortcuts
+// (@daily, @every 6h, ...) Velero understands
+func validCronSchedule(schedule string) bool {
+	if strings.HasPrefix(schedule, "@") {
+		return len(schedule) > 1
+	}
+	return len(strings.Fields(schedule)) == 5
+}
+
+// renderVeleroInstall renders the script that installs Velero with Helm and
+// creates its backup Schedule
+func renderVeleroInstall(cfg *config.ClusterConfig) (string, error) {
+	storage, err := veleroObjectStorage(cfg)
+	if err != nil {
+		return "", err
+	}
+	schedule, err := renderVeleroSchedule(cfg.Backup)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`set -e
+
+if ! command -v helm &> /dev/null; then
+    curl https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | bash
+fi
+helm repo add vmware-tanzu https://vmware-tanzu.github.io/helm-charts || true
+helm repo update
+
+kubectl create namespace %[1]s --dry-run=client -o yaml | kubectl apply -f -
+kubectl -n %[1]s create secret generic %[2]s --from-file=cloud=/dev/stdin --dry-run=client -o yaml <<'CREDENTIALS' | kubectl apply -f -
+[default]
+aws_access_key_id=%[3]s
+aws_secret_access_key=%[4]s
+CREDENTIALS
+
+helm upgrade --install velero vmware-tanzu/velero --namespace %[1]s --wait -f - <<'VALUES'
+%[5]sVALUES
+
+kubectl wait --for condition=established --timeout=120s crd/schedules.velero.io
+kubectl apply -f - <<'SCHEDULE'
+%[6]sSCHEDULE
+`, veleroNamespace, veleroCredentialSecret, storage.AccessKey, storage.SecretKey,
+		renderVeleroValues(storage, cfg.Backup.IncludeVolumes), schedule), nil
+}
+
+// installBackup installs Velero and its backup Schedule when backups are
+// enabled. velero is the only supported backup provider and the default.
+func (o *Orchestrator) installBackup() error {
+	backup := o.config.Backup
+	if backup == nil || !backup.Enabled {
+		return nil
+	}
+	if backup.Provider != "" && backup.Provider != "velero" {
+		return fmt.Errorf("backup provider %s is not supported; use velero", backup.Provider)
+	}
+
+	runScript, err := o.addonScriptRunner("backup")
+	if err != nil {
+		return err
+	}
+
+	script, err := renderVeleroInstall(o.config)
+	if err != nil {
+		return err
+	}
+
+	o.log.Info(fmt.Sprintf("Installing Velero with backup schedule %q", backup.Schedule))
+	if err := runScript("velero-install", script); err != nil {
+		return fmt.Errorf("failed to install velero: %w", err)
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
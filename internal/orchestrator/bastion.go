@@ -0,0 +1,213 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// bastionSizes is the smallest size worth running a bastion on per provider
+var bastionSizes = map[string]string{
+	"digitalocean": "s-1vcpu-1gb",
+	"linode":       "g6-nanode-1",
+	"aws":          "t3.micro",
+	"azure":        "Standard_B1s",
+	"gcp":          "e2-small",
+	"hetzner":      "cx23",
+}
+
+// privateCluster returns the enabled private cluster settings, from the top
+// level or the network section
+func (o *Orchestrator) privateCluster() *config.PrivateClusterConfig {
+	for _, pc := range []*config.PrivateClusterConfig{o.config.PrivateCluster, o.config.Network.PrivateCluster} {
+		if pc != nil && pc.Enabled {
+			return pc
+		}
+	}
+	return nil
+}
+
+// bastionConfig returns the bastion to provision, or nil when the cluster
+// has neither an enabled bastion block nor an enabled private cluster
+func (o *Orchestrator) bastionConfig() *config.BastionConfig {
+	if b := o.config.Security.Bastion; b != nil && b.Enabled {
+		return b
+	}
+	if o.privateCluster() != nil {
+		return &config.BastionConfig{Enabled: true}
+	}
+	return nil
+}
+
+// primaryProvider is the provider of the first master pool or node, falling
+// back to the first pool or node when no master is declared
+func (o *Orchestrator) primaryProvider() string {
+	poolNames := make([]string, 0, len(o.config.NodePools))
+	for name := range o.config.NodePools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+
+	for _, master := range []bool{true, false} {
+		for _, name := range poolNames {
+			pool := o.config.NodePools[name]
+			if !master || isMasterRole(pool.Roles) {
+				return pool.Provider
+			}
+		}
+		for _, node := range o.config.Nodes {
+			if !master || isMasterRole(node.Roles) {
+				return node.Provider
+			}
+		}
+	}
+	return ""
+}
+
+func isMasterRole(roles []string) bool {
+	for _, role := range roles {
+		switch role {
+		case "master", "controlplane", "control-plane":
+			return true
+		}
+	}
+	return false
+}
+
+// createBastion provisions a small public jump host on the primary provider
+// when a bastion or private cluster is configured, and exports it the way
+// the vpn and nodes commands expect. With PrivateEndpoint set, providers
+// that support it create the remaining nodes without public IPs.
+func (o *Orchestrator) createBastion() error {
+	bastionCfg := o.bastionConfig()
+	if bastionCfg == nil {
+		secrets.ExportBool(o.ctx, "bastion_enabled", false)
+		return nil
+	}
+
+	providerName := bastionCfg.Provider
+	if providerName == "" {
+		providerName = o.primaryProvider()
+	}
+	provider, ok := o.providerRegistry.Get(providerName)
+	if !ok {
+		return fmt.Errorf("%w for bastion", &ProviderNotFoundError{Provider: providerName})
+	}
+
+	name := bastionCfg.Name
+	if name == "" {
+		name = fmt.Sprintf("%s-bastion", o.clusterName())
+	}
+	defaultRegion, _ := o.config.ProviderDefaults(providerName)
+	region := bastionCfg.Region
+	if region == "" {
+		region = defaultRegion
+	}
+	size := bastionCfg.Size
+	if size == "" {
+		size = bastionSizes[providerName]
+	}
+	sshPort := bastionCfg.SSHPort
+	if sshPort == 0 {
+		sshPort = 22
+	}
+
+	nodeConfig := &config.NodeConfig{
+		Name:     name,
+		Provider: providerName,
+		Roles:    []string{"bastion"},
+		Size:     size,
+		Image:    bastionCfg.Image,
+		Region:   region,
+		UserData: bastionUserData(bastionCfg, sshPort),
+	}
+
+	o.log.Info(fmt.Sprintf("Provisioning bastion %s on %s", name, providerName), logging.F(logging.FieldProvider, providerName), logging.F(logging.FieldNode, name))
+
+	bastion, err := withProviderSlot(o, providerName, func() (*providers.NodeOutput, error) {
+		return provider.CreateNode(o.ctx, nodeConfig)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create bastion %s: %w", name, err)
+	}
+	o.bastion = bastion
+
+	secrets.ExportBool(o.ctx, "bastion_enabled", true)
+	secrets.ExportMap(o.ctx, "bastion", pulumi.Map{
+		"name":       pulumi.String(name),
+		"public_ip":  bastion.PublicIP,
+		"private_ip": bastion.PrivateIP,
+		"provider":   pulumi.String(providerName),
+		"region":     pulumi.String(region),
+		"ssh_port":   pulumi.Int(sshPort),
+	})
+
+	if pc := o.privateCluster(); pc != nil && pc.PrivateEndpoint {
+		o.privateNodes = true
+		for key, p := range o.providerRegistry.GetAll() {
+			o.applyNodeVisibility(key, p)
+		}
+	}
+
+	o.log.Info(fmt.Sprintf("✓ Bastion %s ready", name))
+	return nil
+}
+
+// applyNodeVisibility turns public IPs off on a provider once the cluster
+// runs behind a bastion with a private endpoint
+func (o *Orchestrator) applyNodeVisibility(key string, provider providers.Provider) {
+	if !o.privateNodes {
+		return
+	}
+	if private, ok := provider.(providers.PrivateNodeCreator); ok {
+		private.SetPublicIP(false)
+		return
+	}
+	o.log.Warn(fmt.Sprintf("Provider %s always assigns public IPs; its nodes stay publicly addressable", key), logging.F(logging.FieldProvider, key))
+}
+
+func (o *Orchestrator) clusterName() string {
+	if o.config.Metadata.Name != "" {
+		return o.config.Metadata.Name
+	}
+	return o.ctx.Stack()
+}
+
+// bastionUserData locks the bastion down to SSH from the allowed CIDRs and
+// enables the forwarding ProxyJump needs
+func bastionUserData(cfg *config.BastionConfig, sshPort int) string {
+	var b strings.Builder
+	b.WriteString(`# Bastion hardening
+export DEBIAN_FRONTEND=noninteractive
+apt-get install -y -qq ufw
+ufw default deny incoming
+ufw default allow outgoing
+`)
+	if len(cfg.AllowedCIDRs) == 0 {
+		fmt.Fprintf(&b, "ufw allow %d/tcp\n", sshPort)
+	}
+	for _, cidr := range cfg.AllowedCIDRs {
+		fmt.Fprintf(&b, "ufw allow from %s to any port %d proto tcp\n", cidr, sshPort)
+	}
+	b.WriteString("ufw --force enable\n")
+
+	b.WriteString("\ncat >> /etc/ssh/sshd_config <<'EOF'\n")
+	if sshPort != 22 {
+		fmt.Fprintf(&b, "Port %d\n", sshPort)
+	}
+	b.WriteString("AllowTcpForwarding yes\nAllowAgentForwarding yes\n")
+	if cfg.MaxSessions > 0 {
+		fmt.Fprintf(&b, "MaxSessions %d\n", cfg.MaxSessions)
+	}
+	if cfg.IdleTimeout > 0 {
+		fmt.Fprintf(&b, "ClientAliveInterval 60\nClientAliveCountMax %d\n", cfg.IdleTimeout)
+	}
+	b.WriteString("EOF\nsystemctl restart ssh || systemctl restart sshd\n")
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/dns"
+)
+
+const (
+	certManagerNamespace = "cert-manager"
+
+	// acmeDNSSecret holds the DNS provider credentials DNS-01 challenges use
+	acmeDNSSecret = "acme-dns-credentials"
+
+	letsEncryptServer        = "https://acme-v02.api.letsencrypt.org/directory"
+	letsEncryptStagingServer = "https://acme-staging-v02.api.letsencrypt.org/directory"
+)
+
+// clusterIssuerName returns the name of the ClusterIssuer installCertManager
+// creates, which ingresses reference for their certificates
+func clusterIssuerName(tls *config.TLSConfig) string {
+	if tls.Staging {
+		return "letsencrypt-staging"
+	}
+	return "letsencrypt-prod"
+}
+
+// renderACMESolver renders the ClusterIssuer solver and the credentials it
+// reads. Cloudflare, Route 53 and DigitalOcean DNS solve DNS-01 challenges,
+// Route 53 and DigitalOcean with the cluster's own provider credentials, or
+// for Route 53 the nodes' IAM role; any other setup solves HTTP-01 through
+// the ingress controller.
+func renderACMESolver(cfg *config.ClusterConfig) (string, map[string]string, error) {
+	dnsCfg := cfg.Network.DNS
+	switch dnsCfg.Provider {
+	case "cloudflare":
+		// The same token configureDNS uses for the zone's records
+		token := dns.CloudflareToken(dnsCfg.APIToken)
+		if token == "" {
+			return "", nil, fmt.Errorf("cloudflare DNS-01 challenges need an API token: set network.dns.apiToken or CLOUDFLARE_API_TOKEN")
+		}
+		return fmt.Sprintf(`      - dns01:
+          cloudflare:
+            apiTokenSecretRef:
+              name: %s
+              key: api-token
+`, acmeDNSSecret), map[string]string{"api-token": token}, nil
+
+	case "route53":
+		aws := cfg.Providers.AWS
+		if aws != nil && aws.AccessKeyID == "" && aws.IAMRole != "" {
+			// Ambient credentials from the instance role of the nodes
+			return fmt.Sprintf(`      - dns01:
+          route53:
+            region: %s
+`, firstNonEmpty(aws.Region, "us-east-1")), nil, nil
+		}
+		if aws == nil || aws.AccessKeyID == "" || aws.SecretAccessKey == "" {
+			return "", nil, fmt.Errorf("route53 DNS-01 challenges need the aws provider credentials or an aws iamRole allowed to change the hosted zone")
+		}
+		return fmt.Sprintf(`      - dns01:
+          route53:
+            region: %s
+            accessKeyIDSecretRef:
+              name: %[2]s
+              key: access-key-id
+            secretAccessKeySecretRef:
+              name: %[2]s
+              key: secret-access-key
+`, firstNonEmpty(aws.Region, "us-east-1"), acmeDNSSecret), map[string]string{
+			"access-key-id":     aws.AccessKeyID,
+			"secret-access-key": aws.SecretAccessKey,
+		}, nil
+
+	case "digitalocean":
+		do := cfg.Providers.DigitalOcean
+		if do == nil || do.Token == "" {
+			return "", nil, fmt.Errorf("digitalocean DNS-01 challenges need the digitalocean provider token")
+		}
+		return fmt.Sprintf(`      - dns01:
+          digitalocean:
+            tokenSecretRef:
+              name: %s
+              key: access-token
+`, acmeDNSSecret), map[string]string{"access-token": do.Token}, nil
+	}
+
+	return fmt.Sprintf(`      - http01:
+          ingress:
+            ingressClassName: %s
+`, firstNonEmpty(cfg.Network.Ingress.Class, defaultIngressClass)), nil, nil
+}
+
+// renderClusterIssuer renders the Let's Encrypt ClusterIssuer, preceded by
+// the secret holding its DNS credentials when it solves DNS-01
+func renderClusterIssuer(cfg *config.ClusterConfig) (string, error) {
+	tls := &cfg.Security.TLS
+	if tls.Provider != "" && tls.Provider != "letsencrypt" {
+		return "", fmt.Errorf("tls provider %s is not supported; use letsencrypt", tls.Provider)
+	}
+
+	email := tls.Email
+	if email == "" && cfg.Network.DNS.Domain != "" {
+		email = "admin@" + cfg.Network.DNS.Domain
+	}
+	if email == "" {
+		return "", fmt.Errorf("an ACME account email is required: set security.tls.email")
+	}
+
+	solver, credentials, err := renderACMESolver(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	server := letsEncryptServer
+	if tls.Staging {
+		server = letsEncryptStagingServer
+	}
+	name := clusterIssuerName(tls)
+
+	var builder strings.Builder
+	if len(credentials) > 0 {
+		keys := make([]string, 0, len(credentials))
+		for key := range credentials {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		builder.WriteString("apiVersion: v1\n")
+		builder.WriteString("kind: Secret\n")
+		builder.WriteString("metadata:\n")
+		builder.WriteString(fmt.Sprintf("  name: %s\n", acmeDNSSecret))
+		builder.WriteString(fmt.Sprintf("  namespace: %s\n", certManagerNamespace))
+		builder.WriteString("type: Opaque\n")
+		builder.WriteString("stringData:\n")
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("  %s: %q\n", key, credentials[key]))
+		}
+		builder.WriteString("---\n")
+	}
+	builder.WriteString("apiVersion: cert-manager.io/v1\n")
+	builder.WriteString("kind: ClusterIssuer\n")
+	builder.WriteString("metadata:\n")
+	builder.WriteString(fmt.Sprintf("  name: %s\n", name))
+	builder.WriteString("spec:\n")
+	builder.WriteString("  acme:\n")
+	builder.WriteString(fmt.Sprintf("    server: %s\n", server))
+	builder.WriteString(fmt.Sprintf("    email: %s\n", email))
+	builder.WriteString("    privateKeySecretRef:\n")
+	builder.WriteString(fmt.Sprintf("      name: %s\n", name))
+	builder.WriteString("    solvers:\n")
+	builder.WriteString(solver)
+	return builder.String(), nil
+}
+
+// installCertManager installs cert-manager and its Let's Encrypt
+// ClusterIssuer when Security.TLS.CertManager is set
+func (o *Orchestrator) installCertManager() error {
+	tls := &o.config.Security.TLS
+	if !tls.CertManager {
+		return nil
+	}
+
+	runScript, err := o.addonScriptRunner("cert-manager")
+	if err != nil {
+		return err
+	}
+
+	issuer, err := renderClusterIssuer(o.config)
+	if err != nil {
+		return err
+	}
+
+	o.log.Info(fmt.Sprintf("Installing cert-manager with ClusterIssuer %s", clusterIssuerName(tls)))
+
+	script := fmt.Sprintf(`set -e
+
+if ! command -v helm &> /dev/null; then
+    curl https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | bash
+fi
+helm repo add jetstack https://charts.jetstack.io || true
+helm repo update
+
+helm upgrade --install cert-manager jetstack/cert-manager \
+  --namespace %s --create-namespace --set crds.enabled=true --wait
+kubectl wait --for condition=established --timeout=120s crd/clusterissuers.cert-manager.io
+
+kubectl apply -f - <<'ISSUER'
+%sISSUER
+`, certManagerNamespace, issuer)
+	if err := runScript("cert-manager-install", script); err != nil {
+		return fmt.Errorf("failed to install cert-manager: %w", err)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/chalkan3/sloth-kubernetes/internal/orchestrator/components"
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
 	"github.com/chalkan3/sloth-kubernetes/pkg/versioning"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -126,6 +127,14 @@ type SimpleRealOrchestratorComponent struct {
 // lispManifest is the raw Lisp configuration file content for storage in Pulumi state
 // previousMeta is the previous deployment metadata (empty string for initial deployment)
 func NewSimpleRealOrchestratorComponent(ctx *pulumi.Context, name string, cfg *config.ClusterConfig, lispManifest string, previousMeta string, opts ...pulumi.ResourceOption) (*SimpleRealOrchestratorComponent, error) {
+	return NewSimpleRealOrchestratorComponentWithOptions(ctx, name, cfg, lispManifest, previousMeta, Options{}, opts...)
+}
+
+// NewSimpleRealOrchestratorComponentWithOptions is NewSimpleRealOrchestratorComponent
+// keeping the WireGuard IPs of the previous deployment given in
+// options.WireGuardAllocations, which it exports again as wireguard_allocations.
+// The other options do not apply to the component deployment.
+func NewSimpleRealOrchestratorComponentWithOptions(ctx *pulumi.Context, name string, cfg *config.ClusterConfig, lispManifest string, previousMeta string, options Options, opts ...pulumi.ResourceOption) (*SimpleRealOrchestratorComponent, error) {
 	component := &SimpleRealOrchestratorComponent{}
 	err := ctx.RegisterComponentResource("kubernetes-create:orchestrator:SimpleReal", name, component, opts...)
 	if err != nil {
@@ -134,6 +143,21 @@ func NewSimpleRealOrchestratorComponent(ctx *pulumi.Context, name string, cfg *c
 
 	ctx.Log.Info("🚀 Starting REAL Kubernetes deployment (WireGuard + K3s + DNS)", nil)
 
+	if err := components.ValidateClusterAutoscaler(cfg); err != nil {
+		return nil, err
+	}
+	if err := components.ValidateMetalLB(cfg); err != nil {
+		return nil, err
+	}
+
+	// WireGuard IPs are allocated up front: the nodes' user data carries them
+	wireGuardAllocations, err := assignClusterWireGuardIPs(cfg, options.WireGuardAllocations, func(msg string) {
+		ctx.Log.Warn(msg, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate WireGuard IPs: %w", err)
+	}
+
 	// Phase 1: SSH Keys
 	ctx.Log.Info("🔑 Phase 1: Generating SSH keys...", nil)
 	sshKeyComponent, err := components.NewSSHKeyComponent(ctx, fmt.Sprintf("%s-ssh-keys", name), cfg, pulumi.Parent(component))
@@ -294,7 +318,8 @@ func NewSimpleRealOrchestratorComponent(ctx *pulumi.Context, name string, cfg *c
 	ctx.Log.Info("════════════════════════════════════════════════════════════", nil)
 
 	// Determine which VPN mode to use
-	useTailscale := cfg.Network.Tailscale != nil && cfg.Network.Tailscale.Enabled
+	useTailscale := cfg.Network.SelectedVPN() == config.VPNTailscale
+	logVPNSelection(logging.NewPulumiLogger(ctx), cfg.Network)
 
 	var vpnComponent pulumi.Resource
 	var tailscaleComponent *components.TailscaleMeshComponent
@@ -359,6 +384,8 @@ func NewSimpleRealOrchestratorComponent(ctx *pulumi.Context, name string, cfg *c
 			realNodes,
 			sshKeyComponent.PrivateKey,
 			bastionComponent, // Pass bastion to be included in VPN mesh
+			cfg.Network.WireGuard != nil && cfg.Network.WireGuard.UsePresharedKeys,
+			cfg.Network.WireGuard.Keepalive(cfg.NodesBehindNAT()),
 			pulumi.Parent(component),
 			pulumi.DependsOn(wgDependencies),
 		)
@@ -464,6 +491,78 @@ func NewSimpleRealOrchestratorComponent(ctx *pulumi.Context, name string, cfg *c
 
 	ctx.Log.Info("✅ DNS records created", nil)
 
+	// Phase 5.1: Node pool taints (only if a pool has taints)
+	taintsComponent, err := components.NewNodeTaintsComponent(
+		ctx,
+		fmt.Sprintf("%s-node-taints", name),
+		cfg,
+		realNodes,
+		bastionComponent,
+		sshKeyComponent.PrivateKey,
+		pulumi.Parent(component),
+		pulumi.DependsOn([]pulumi.Resource{clusterInstallResource}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply node taints: %w", err)
+	}
+	if taintsComponent != nil {
+		ctx.Log.Info("✅ Node pool taints applied", nil)
+	}
+
+	// Phase 5.2: Spot interruption handler (only if a pool has a spot config)
+	spotHandlerComponent, err := components.NewSpotInterruptionHandlerComponent(
+		ctx,
+		fmt.Sprintf("%s-spot-handler", name),
+		cfg,
+		realNodes,
+		bastionComponent,
+		sshKeyComponent.PrivateKey,
+		pulumi.Parent(component),
+		pulumi.DependsOn([]pulumi.Resource{clusterInstallResource}),
+	)
+	if err != nil {
+		ctx.Log.Warn(fmt.Sprintf("⚠️  Spot interruption handler installation failed: %v", err), nil)
+		ctx.Log.Warn("   Spot nodes will not be drained before reclaim", nil)
+	} else if spotHandlerComponent != nil {
+		ctx.Log.Info("✅ Spot interruption handler installed", nil)
+	}
+
+	// Phase 5.3: Cluster autoscaler (only if a pool has AutoScaling)
+	autoscalerComponent, err := components.NewClusterAutoscalerComponent(
+		ctx,
+		fmt.Sprintf("%s-cluster-autoscaler", name),
+		cfg,
+		realNodes,
+		bastionComponent,
+		sshKeyComponent.PrivateKey,
+		pulumi.Parent(component),
+		pulumi.DependsOn([]pulumi.Resource{clusterInstallResource}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install cluster-autoscaler: %w", err)
+	}
+	if autoscalerComponent != nil {
+		ctx.Log.Info("✅ Cluster autoscaler installed", nil)
+	}
+
+	// Phase 5.4: MetalLB (only if the load balancer provider is metallb)
+	metalLBComponent, err := components.NewMetalLBComponent(
+		ctx,
+		fmt.Sprintf("%s-metallb", name),
+		cfg,
+		realNodes,
+		bastionComponent,
+		sshKeyComponent.PrivateKey,
+		pulumi.Parent(component),
+		pulumi.DependsOn([]pulumi.Resource{clusterInstallResource}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to install MetalLB: %w", err)
+	}
+	if metalLBComponent != nil {
+		ctx.Log.Info("✅ MetalLB installed", nil)
+	}
+
 	// Phase 5.5: Salt Master Installation (only if enabled in config)
 	var saltMasterComponent *components.SaltMasterComponent
 	var saltMinionComponent *components.SaltMinionJoinComponent
@@ -617,6 +716,7 @@ func NewSimpleRealOrchestratorComponent(ctx *pulumi.Context, name string, cfg *c
 			"provider":   node.Provider,
 			"region":     node.Region,
 			"size":       node.Size,
+			"ssh_user":   node.SSHUser,
 			"roles":      node.Roles,
 			"status":     node.Status,
 		}
@@ -624,6 +724,13 @@ func NewSimpleRealOrchestratorComponent(ctx *pulumi.Context, name string, cfg *c
 	secretExporter.ExportMap("nodes", nodesMap)
 	secretExporter.ExportInt("node_count", len(realNodes))
 
+	// Export WireGuard allocations so the next deployment keeps them
+	wireGuardIPs := pulumi.Map{}
+	for nodeName, ip := range wireGuardAllocations {
+		wireGuardIPs[nodeName] = pulumi.String(ip)
+	}
+	secretExporter.ExportMap("wireguard_allocations", wireGuardIPs)
+
 	// Export kubeconfig for kubectl access (encrypted)
 	secretExporter.Export("kubeConfig", kubeConfig)
 
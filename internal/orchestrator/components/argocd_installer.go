@@ -74,7 +74,7 @@ func NewArgoCDInstallerComponent(
 	}
 
 	// Setup connection args - use correct SSH user based on provider
-	masterUser := getSSHUserForProvider(firstMaster.Provider)
+	masterUser := nodeSSHUser(firstMaster)
 	connArgs := &remote.ConnectionArgs{
 		Host:           firstMaster.WireGuardIP, // Use VPN IP for private network
 		User:           masterUser,
@@ -5,25 +5,27 @@ import (
 
 	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 )
 
 // getSSHUserForProvider returns the correct SSH username for the given cloud provider
 // Azure uses "azureuser", while other providers use "root" or "ubuntu"
 func getSSHUserForProvider(provider pulumi.StringOutput) pulumi.StringOutput {
 	return provider.ApplyT(func(p string) string {
-		switch p {
-		case "azure":
-			return "azureuser"
-		case "aws":
-			return "ubuntu" // AWS Ubuntu AMIs use "ubuntu"
-		case "gcp":
-			return "ubuntu" // GCP uses "ubuntu" for Ubuntu images
-		default:
-			return "root" // DigitalOcean, Linode, and others use "root"
-		}
+		return config.DefaultSSHUser(p)
 	}).(pulumi.StringOutput)
 }
 
+// nodeSSHUser returns the node's login user: its configured sshUser override
+// or, for components that don't carry one, the provider default
+func nodeSSHUser(node *RealNodeComponent) pulumi.StringOutput {
+	if node.SSHUser.OutputState == nil {
+		return getSSHUserForProvider(node.Provider)
+	}
+	return node.SSHUser
+}
+
 // CloudInitValidatorComponent validates cloud-init completion before proceeding
 type CloudInitValidatorComponent struct {
 	pulumi.ResourceState
@@ -0,0 +1,491 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const clusterAutoscalerImage = "registry.k8s.io/autoscaling/cluster-autoscaler:v1.29.0"
+
+// autoscalerCloudProviders maps providers to the cluster-autoscaler cloud
+// provider that scales their instance groups. DigitalOcean and Linode are
+// missing: their autoscalers only scale managed DOKS and LKE node pools.
+var autoscalerCloudProviders = map[string]string{
+	"aws":     "aws",
+	"azure":   "azure",
+	"gcp":     "gce",
+	"hetzner": "hetzner",
+}
+
+// autoscaledPool is a node pool cluster-autoscaler scales between Min and Max
+// nodes through the instance group Group
+type autoscaledPool struct {
+	Name  string
+	Min   int
+	Max   int
+	Group string
+}
+
+// ClusterAutoscalerComponent installs cluster-autoscaler for the node pools
+// with AutoScaling enabled
+type ClusterAutoscalerComponent struct {
+	pulumi.ResourceState
+
+	Status pulumi.StringOutput `pulumi:"status"`
+}
+
+// NewClusterAutoscalerComponent installs cluster-autoscaler on the cluster.
+// It returns nil when no node pool autoscales, and an error when an
+// autoscaling pool is a control plane pool or its provider has no
+// autoscaling groups.
+func NewClusterAutoscalerComponent(
+	ctx *pulumi.Context,
+	name string,
+	cfg *config.ClusterConfig,
+	nodes []*RealNodeComponent,
+	bastionComponent *BastionComponent,
+	sshPrivateKey pulumi.StringInput,
+	opts ...pulumi.ResourceOption,
+) (*ClusterAutoscalerComponent, error) {
+	pools, cloudProvider, err := autoscaledPools(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(pools) == 0 {
+		return nil, nil // No autoscaling pools
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes found for cluster-autoscaler installation")
+	}
+
+	component := &ClusterAutoscalerComponent{}
+	err = ctx.RegisterComponentResource("sloth:kubernetes:ClusterAutoscaler", name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Masters are deployed first, so the first node is a master
+	firstMaster := nodes[0]
+
+	connArgs := &remote.ConnectionArgs{
+		Host:           firstMaster.WireGuardIP,
+		User:           nodeSSHUser(firstMaster),
+		PrivateKey:     sshPrivateKey,
+		DialErrorLimit: pulumi.Int(30),
+	}
+
+	if bastionComponent != nil {
+		connArgs.Proxy = &remote.ProxyConnectionArgs{
+			Host:       bastionComponent.PublicIP,
+			User:       getSSHUserForProvider(bastionComponent.Provider),
+			PrivateKey: sshPrivateKey,
+		}
+	}
+
+	ctx.Log.Info(fmt.Sprintf("📈 Installing cluster-autoscaler (%s) for %d node pool(s)...", cloudProvider, len(pools)), nil)
+
+	// The script carries the provider credentials, so it is kept secret
+	installScript := clusterAutoscalerInstallScript(cloudProvider, pools, clusterAutoscalerCredentials(cfg, cloudProvider))
+	installCmd, err := remote.NewCommand(ctx, fmt.Sprintf("%s-install", name), &remote.CommandArgs{
+		Connection: connArgs,
+		Create:     pulumi.ToSecret(pulumi.String(installScript)).(pulumi.StringOutput),
+		Delete: pulumi.String(`#!/bin/bash
+kubectl delete -n kube-system deployment/cluster-autoscaler secret/cluster-autoscaler-cloud serviceaccount/cluster-autoscaler role/cluster-autoscaler rolebinding/cluster-autoscaler --ignore-not-found || true
+kubectl delete clusterrolebinding/cluster-autoscaler clusterrole/cluster-autoscaler --ignore-not-found || true
+`),
+	}, pulumi.Parent(component))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster-autoscaler install command: %w", err)
+	}
+
+	component.Status = installCmd.Stdout.ApplyT(func(string) string {
+		return fmt.Sprintf("cluster-autoscaler scaling %d node pool(s)", len(pools))
+	}).(pulumi.StringOutput)
+
+	if err := ctx.RegisterResourceOutputs(component, pulumi.Map{
+		"status": component.Status,
+	}); err != nil {
+		return nil, err
+	}
+
+	return component, nil
+}
+
+// ValidateClusterAutoscaler checks the autoscaling pools cluster-autoscaler
+// would scale, so an unsupported setup fails before any node is created
+func ValidateClusterAutoscaler(cfg *config.ClusterConfig) error {
+	_, _, err := autoscaledPools(cfg)
+	return err
+}
+
+// autoscaledPools returns the pools with AutoScaling enabled, sorted by name,
+// and the cluster-autoscaler cloud provider that scales them. One
+// cluster-autoscaler serves a single cloud provider, so every autoscaling
+// pool must use the same one.
+func autoscaledPools(cfg *config.ClusterConfig) ([]autoscaledPool, string, error) {
+	poolNames := make([]string, 0, len(cfg.NodePools))
+	for poolName, pool := range cfg.NodePools {
+		if pool.AutoScaling {
+			poolNames = append(poolNames, poolName)
+		}
+	}
+	sort.Strings(poolNames)
+
+	var (
+		pools         []autoscaledPool
+		cloudProvider string
+		firstPool     string
+	)
+	for _, poolName := range poolNames {
+		pool := cfg.NodePools[poolName]
+		if isControlPlanePool(&pool) {
+			return nil, "", fmt.Errorf("node pool %s runs the control plane and cannot autoscale", poolName)
+		}
+
+		poolProvider, ok := autoscalerCloudProviders[pool.Provider]
+		if !ok {
+			return nil, "", fmt.Errorf("node pool %s: autoscaling is not supported on provider %s, which has no autoscaling groups cluster-autoscaler can scale", poolName, pool.Provider)
+		}
+		if cloudProvider == "" {
+			cloudProvider, firstPool = poolProvider, poolName
+		} else if poolProvider != cloudProvider {
+			return nil, "", fmt.Errorf("node pools %s and %s autoscale on different providers (%s, %s); cluster-autoscaler scales a single provider", firstPool, poolName, cfg.NodePools[firstPool].Provider, pool.Provider)
+		}
+
+		minNodes, maxNodes := autoscalingBounds(&pool)
+		if maxNodes < 1 || minNodes > maxNodes {
+			return nil, "", fmt.Errorf("node pool %s: invalid autoscaling bounds min %d, max %d", poolName, minNodes, maxNodes)
+		}
+
+		group, err := autoscalerNodeGroup(cfg, poolName, &pool)
+		if err != nil {
+			return nil, "", err
+		}
+		pools = append(pools, autoscaledPool{Name: poolName, Min: minNodes, Max: maxNodes, Group: group})
+	}
+	return pools, cloudProvider, nil
+}
+
+// isControlPlanePool reports whether a pool runs masters or etcd, which must
+// never be scaled down under the cluster
+func isControlPlanePool(pool *config.NodePool) bool {
+	for _, role := range pool.Roles {
+		switch role {
+		case "master", "controlplane", "control-plane", "server", "etcd":
+			return true
+		}
+	}
+	return false
+}
+
+// autoscalingBounds returns a pool's node bounds: MinCount and MaxCount, else
+// the autoscaling config's MinNodes and MaxNodes, else its current count
+func autoscalingBounds(pool *config.NodePool) (minNodes, maxNodes int) {
+	minNodes, maxNodes = pool.MinCount, pool.MaxCount
+	if pool.AutoScalingConfig != nil {
+		if minNodes == 0 {
+			minNodes = pool.AutoScalingConfig.MinNodes
+		}
+		if maxNodes == 0 {
+			maxNodes = pool.AutoScalingConfig.MaxNodes
+		}
+	}
+	if maxNodes == 0 {
+		maxNodes = pool.Count
+	}
+	return minNodes, maxNodes
+}
+
+// autoscalerNodeGroup returns the instance group cluster-autoscaler scales a
+// pool through: the <cluster>-<pool> auto scaling group or scale set on AWS
+// and Azure, its managed instance group URL on GCP, and on Hetzner the
+// TYPE:LOCATION:NAME spec of the servers it creates
+func autoscalerNodeGroup(cfg *config.ClusterConfig, poolName string, pool *config.NodePool) (string, error) {
+	groupName := fmt.Sprintf("%s-%s", cfg.Metadata.Name, poolName)
+	region, size := pool.Region, pool.Size
+	defaultRegion, defaultSize := cfg.ProviderDefaults(pool.Provider)
+	if region == "" {
+		region = defaultRegion
+	}
+	if size == "" {
+		size = defaultSize
+	}
+
+	switch pool.Provider {
+	case "gcp":
+		if cfg.Providers.GCP == nil || cfg.Providers.GCP.ProjectID == "" {
+			return "", fmt.Errorf("node pool %s: autoscaling on GCP needs the provider's projectId", poolName)
+		}
+		zone := cfg.Providers.GCP.Zone
+		if len(pool.Zones) > 0 {
+			zone = pool.Zones[0]
+		}
+		if zone == "" {
+			return "", fmt.Errorf("node pool %s: autoscaling on GCP needs a zone for its instance group", poolName)
+		}
+		return fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/instanceGroups/%s", cfg.Providers.GCP.ProjectID, zone, groupName), nil
+	case "hetzner":
+		if region == "" || size == "" {
+			return "", fmt.Errorf("node pool %s: autoscaling on Hetzner needs a server type and location", poolName)
+		}
+		return fmt.Sprintf("%s:%s:%s", strings.ToUpper(size), strings.ToUpper(region), poolName), nil
+	default:
+		return groupName, nil
+	}
+}
+
+// autoscalerNodeTags returns the tags that put the instances of an
+// autoscaling pool in the group cluster-autoscaler scales, or nil for pools
+// that do not autoscale
+func autoscalerNodeTags(cfg *config.ClusterConfig, poolName string, pool *config.NodePool) map[string]string {
+	if !pool.AutoScaling || isControlPlanePool(pool) {
+		return nil
+	}
+
+	switch pool.Provider {
+	case "aws":
+		return map[string]string{
+			"k8s.io/cluster-autoscaler/enabled":                        "true",
+			"k8s.io/cluster-autoscaler/" + cfg.Metadata.Name:           "owned",
+			"k8s.io/cluster-autoscaler/node-template/label/sloth-pool": poolName,
+		}
+	case "azure", "gcp":
+		return map[string]string{
+			"cluster-autoscaler-enabled": "true",
+			"cluster-autoscaler-name":    cfg.Metadata.Name,
+			"sloth-pool":                 poolName,
+		}
+	case "hetzner":
+		return map[string]string{"hcloud/node-group": poolName}
+	}
+	return nil
+}
+
+// clusterAutoscalerCredentials returns the environment cluster-autoscaler
+// reads its cloud credentials from. GCP credentials come from the instance
+// metadata server.
+func clusterAutoscalerCredentials(cfg *config.ClusterConfig, cloudProvider string) map[string]string {
+	env := map[string]string{}
+	set := func(key, value string) {
+		if value != "" {
+			env[key] = value
+		}
+	}
+
+	switch cloudProvider {
+	case "aws":
+		if p := cfg.Providers.AWS; p != nil {
+			set("AWS_REGION", p.Region)
+			set("AWS_ACCESS_KEY_ID", p.AccessKeyID)
+			set("AWS_SECRET_ACCESS_KEY", p.SecretAccessKey)
+		}
+	case "azure":
+		if p := cfg.Providers.Azure; p != nil {
+			set("ARM_SUBSCRIPTION_ID", p.SubscriptionID)
+			set("ARM_TENANT_ID", p.TenantID)
+			set("ARM_CLIENT_ID", p.ClientID)
+			set("ARM_CLIENT_SECRET", p.ClientSecret)
+			set("ARM_RESOURCE_GROUP", p.ResourceGroup)
+			env["ARM_VM_TYPE"] = "vmss"
+		}
+	case "hetzner":
+		if p := cfg.Providers.Hetzner; p != nil {
+			set("HCLOUD_TOKEN", p.Token)
+		}
+	}
+	return env
+}
+
+// clusterAutoscalerInstallScript stores the credentials in a secret and
+// applies the cluster-autoscaler manifests
+func clusterAutoscalerInstallScript(cloudProvider string, pools []autoscaledPool, credentials map[string]string) string {
+	keys := make([]string, 0, len(credentials))
+	for key := range credentials {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var literals strings.Builder
+	for _, key := range keys {
+		literals.WriteString(fmt.Sprintf(" \\\n  --from-literal=%s", shellQuote(key+"="+credentials[key])))
+	}
+
+	return fmt.Sprintf(`#!/bin/bash
+set -e
+
+echo "🔑 Storing cluster-autoscaler credentials..."
+kubectl -n kube-system create secret generic cluster-autoscaler-cloud%s \
+  --dry-run=client -o yaml | kubectl apply -f -
+
+echo "📦 Applying cluster-autoscaler..."
+kubectl apply -f - <<'MANIFEST'
+%s
+MANIFEST
+
+echo "✅ cluster-autoscaler installed"
+`, literals.String(), clusterAutoscalerManifest(cloudProvider, pools))
+}
+
+// clusterAutoscalerManifest renders the cluster-autoscaler Deployment and its
+// RBAC. It runs on the control plane so it never scales away its own node.
+func clusterAutoscalerManifest(cloudProvider string, pools []autoscaledPool) string {
+	var nodeArgs strings.Builder
+	for _, pool := range pools {
+		nodeArgs.WriteString(fmt.Sprintf("\n            - --nodes=%d:%d:%s", pool.Min, pool.Max, pool.Group))
+	}
+
+	return fmt.Sprintf(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: cluster-autoscaler
+rules:
+  - apiGroups: [""]
+    resources: ["events", "endpoints"]
+    verbs: ["create", "patch"]
+  - apiGroups: [""]
+    resources: ["pods/eviction"]
+    verbs: ["create"]
+  - apiGroups: [""]
+    resources: ["pods/status"]
+    verbs: ["update"]
+  - apiGroups: [""]
+    resources: ["endpoints"]
+    resourceNames: ["cluster-autoscaler"]
+    verbs: ["get", "update"]
+  - apiGroups: [""]
+    resources: ["nodes"]
+    verbs: ["watch", "list", "get", "update"]
+  - apiGroups: [""]
+    resources: ["namespaces", "pods", "services", "replicationcontrollers", "persistentvolumeclaims", "persistentvolumes"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["extensions"]
+    resources: ["replicasets", "daemonsets"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["policy"]
+    resources: ["poddisruptionbudgets"]
+    verbs: ["watch", "list"]
+  - apiGroups: ["apps"]
+    resources: ["statefulsets", "replicasets", "daemonsets"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["storage.k8s.io"]
+    resources: ["storageclasses", "csinodes", "csidrivers", "csistoragecapacities"]
+    verbs: ["watch", "list", "get"]
+  - apiGroups: ["batch", "extensions"]
+    resources: ["jobs"]
+    verbs: ["get", "list", "watch", "patch"]
+  - apiGroups: ["coordination.k8s.io"]
+    resources: ["leases"]
+    verbs: ["create"]
+  - apiGroups: ["coordination.k8s.io"]
+    resourceNames: ["cluster-autoscaler"]
+    resources: ["leases"]
+    verbs: ["get", "update"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+rules:
+  - apiGroups: [""]
+    resources: ["configmaps"]
+    verbs: ["create", "list", "watch"]
+  - apiGroups: [""]
+    resources: ["configmaps"]
+    resourceNames: ["cluster-autoscaler-status", "cluster-autoscaler-priority-expander"]
+    verbs: ["delete", "get", "update", "watch"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cluster-autoscaler
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-autoscaler
+subjects:
+  - kind: ServiceAccount
+    name: cluster-autoscaler
+    namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: cluster-autoscaler
+subjects:
+  - kind: ServiceAccount
+    name: cluster-autoscaler
+    namespace: kube-system
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cluster-autoscaler
+  namespace: kube-system
+  labels:
+    app: cluster-autoscaler
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: cluster-autoscaler
+  template:
+    metadata:
+      labels:
+        app: cluster-autoscaler
+    spec:
+      serviceAccountName: cluster-autoscaler
+      priorityClassName: system-cluster-critical
+      nodeSelector:
+        node-role.kubernetes.io/control-plane: "true"
+      tolerations:
+        - key: node-role.kubernetes.io/control-plane
+          operator: Exists
+          effect: NoSchedule
+        - key: node-role.kubernetes.io/master
+          operator: Exists
+          effect: NoSchedule
+        - key: CriticalAddonsOnly
+          operator: Exists
+      containers:
+        - name: cluster-autoscaler
+          image: %s
+          command:
+            - ./cluster-autoscaler
+            - --cloud-provider=%s%s
+            - --balance-similar-node-groups
+            - --expander=least-waste
+            - --skip-nodes-with-local-storage=false
+          envFrom:
+            - secretRef:
+                name: cluster-autoscaler-cloud
+          resources:
+            requests:
+              cpu: 100m
+              memory: 300Mi
+            limits:
+              memory: 600Mi`, clusterAutoscalerImage, cloudProvider, nodeArgs.String())
+}
+
+// shellQuote quotes s as a single shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
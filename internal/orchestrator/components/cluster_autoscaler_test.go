@@ -0,0 +1,136 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+func autoscalerTestConfig(pools map[string]config.NodePool) *config.ClusterConfig {
+	return &config.ClusterConfig{
+		Metadata: config.Metadata{Name: "prod"},
+		Providers: config.ProvidersConfig{
+			AWS:     &config.AWSProvider{Enabled: true, Region: "us-east-1", AccessKeyID: "AKIA", SecretAccessKey: "it's-secret"},
+			GCP:     &config.GCPProvider{Enabled: true, ProjectID: "acme", Zone: "us-central1-a"},
+			Hetzner: &config.HetznerProvider{Enabled: true, Token: "hcloud-token", Location: "fsn1"},
+		},
+		NodePools: pools,
+	}
+}
+
+func TestAutoscaledPools(t *testing.T) {
+	cfg := autoscalerTestConfig(map[string]config.NodePool{
+		"masters": {Provider: "aws", Count: 3, Roles: []string{"master"}},
+		"workers": {Provider: "aws", Count: 2, Roles: []string{"worker"}, AutoScaling: true, MinCount: 1, MaxCount: 10},
+		"batch": {Provider: "aws", Count: 2, Roles: []string{"worker"}, AutoScaling: true,
+			AutoScalingConfig: &config.AutoScalingConfig{Enabled: true, MinNodes: 0, MaxNodes: 6}},
+		"static": {Provider: "aws", Count: 2, Roles: []string{"worker"}},
+	})
+
+	pools, cloudProvider, err := autoscaledPools(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "aws", cloudProvider)
+	assert.Equal(t, []autoscaledPool{
+		{Name: "batch", Min: 0, Max: 6, Group: "prod-batch"},
+		{Name: "workers", Min: 1, Max: 10, Group: "prod-workers"},
+	}, pools)
+
+	pools, _, err = autoscaledPools(autoscalerTestConfig(nil))
+	require.NoError(t, err)
+	assert.Empty(t, pools)
+}
+
+func TestAutoscaledPools_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		pools map[string]config.NodePool
+		want  string
+	}{
+		{
+			name:  "control plane pool",
+			pools: map[string]config.NodePool{"masters": {Provider: "aws", Count: 3, Roles: []string{"master"}, AutoScaling: true, MaxCount: 5}},
+			want:  "node pool masters runs the control plane and cannot autoscale",
+		},
+		{
+			name:  "unsupported provider",
+			pools: map[string]config.NodePool{"workers": {Provider: "digitalocean", Count: 2, Roles: []string{"worker"}, AutoScaling: true, MaxCount: 5}},
+			want:  "node pool workers: autoscaling is not supported on provider digitalocean, which has no autoscaling groups cluster-autoscaler can scale",
+		},
+		{
+			name: "mixed providers",
+			pools: map[string]config.NodePool{
+				"a": {Provider: "aws", Count: 1, Roles: []string{"worker"}, AutoScaling: true, MaxCount: 2},
+				"b": {Provider: "hetzner", Size: "cpx31", Count: 1, Roles: []string{"worker"}, AutoScaling: true, MaxCount: 2},
+			},
+			want: "node pools a and b autoscale on different providers (aws, hetzner); cluster-autoscaler scales a single provider",
+		},
+		{
+			name:  "min above max",
+			pools: map[string]config.NodePool{"workers": {Provider: "aws", Count: 2, Roles: []string{"worker"}, AutoScaling: true, MinCount: 5, MaxCount: 3}},
+			want:  "node pool workers: invalid autoscaling bounds min 5, max 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateClusterAutoscaler(autoscalerTestConfig(tt.pools))
+			assert.EqualError(t, err, tt.want)
+		})
+	}
+}
+
+func TestAutoscalerNodeGroup(t *testing.T) {
+	cfg := autoscalerTestConfig(nil)
+
+	group, err := autoscalerNodeGroup(cfg, "workers", &config.NodePool{Provider: "gcp", Zones: []string{"us-central1-b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://www.googleapis.com/compute/v1/projects/acme/zones/us-central1-b/instanceGroups/prod-workers", group)
+
+	group, err = autoscalerNodeGroup(cfg, "workers", &config.NodePool{Provider: "hetzner", Size: "cpx31"})
+	require.NoError(t, err)
+	assert.Equal(t, "CPX31:FSN1:workers", group, "the location falls back to the provider's")
+
+	group, err = autoscalerNodeGroup(cfg, "workers", &config.NodePool{Provider: "azure"})
+	require.NoError(t, err)
+	assert.Equal(t, "prod-workers", group)
+
+	cfg.Providers.GCP.ProjectID = ""
+	_, err = autoscalerNodeGroup(cfg, "workers", &config.NodePool{Provider: "gcp"})
+	assert.ErrorContains(t, err, "projectId")
+}
+
+func TestAutoscalerNodeTags(t *testing.T) {
+	cfg := autoscalerTestConfig(nil)
+
+	assert.Equal(t, map[string]string{
+		"k8s.io/cluster-autoscaler/enabled":                        "true",
+		"k8s.io/cluster-autoscaler/prod":                           "owned",
+		"k8s.io/cluster-autoscaler/node-template/label/sloth-pool": "workers",
+	}, autoscalerNodeTags(cfg, "workers", &config.NodePool{Provider: "aws", Roles: []string{"worker"}, AutoScaling: true}))
+	assert.Equal(t, map[string]string{"hcloud/node-group": "workers"},
+		autoscalerNodeTags(cfg, "workers", &config.NodePool{Provider: "hetzner", Roles: []string{"worker"}, AutoScaling: true}))
+
+	assert.Nil(t, autoscalerNodeTags(cfg, "workers", &config.NodePool{Provider: "aws", Roles: []string{"worker"}}))
+	assert.Nil(t, autoscalerNodeTags(cfg, "masters", &config.NodePool{Provider: "aws", Roles: []string{"master"}, AutoScaling: true}))
+}
+
+func TestClusterAutoscalerInstallScript(t *testing.T) {
+	cfg := autoscalerTestConfig(nil)
+	pools := []autoscaledPool{
+		{Name: "batch", Min: 0, Max: 6, Group: "prod-batch"},
+		{Name: "workers", Min: 1, Max: 10, Group: "prod-workers"},
+	}
+
+	script := clusterAutoscalerInstallScript("aws", pools, clusterAutoscalerCredentials(cfg, "aws"))
+	assert.Contains(t, script, "--from-literal='AWS_ACCESS_KEY_ID=AKIA'")
+	assert.Contains(t, script, `--from-literal='AWS_SECRET_ACCESS_KEY=it'\''s-secret'`)
+	assert.Contains(t, script, "--from-literal='AWS_REGION=us-east-1'")
+	assert.Contains(t, script, "- --cloud-provider=aws\n            - --nodes=0:6:prod-batch\n            - --nodes=1:10:prod-workers\n")
+	assert.Contains(t, script, "node-role.kubernetes.io/control-plane: \"true\"")
+	assert.Contains(t, script, clusterAutoscalerImage)
+
+	assert.Equal(t, map[string]string{"HCLOUD_TOKEN": "hcloud-token"}, clusterAutoscalerCredentials(cfg, "hetzner"))
+	assert.Empty(t, clusterAutoscalerCredentials(cfg, "gce"))
+}
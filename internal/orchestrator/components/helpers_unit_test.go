@@ -1,6 +1,7 @@
 package components
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
@@ -34,6 +35,7 @@ func TestGetSSHUserForProvider(t *testing.T) {
 		{"GCP provider", "gcp", "ubuntu"},
 		{"DigitalOcean provider", "digitalocean", "root"},
 		{"Linode provider", "linode", "root"},
+		{"Hetzner provider", "hetzner", "root"},
 		{"Unknown provider", "unknown", "root"},
 		{"Empty provider", "", "root"},
 	}
@@ -58,6 +60,30 @@ func TestGetSSHUserForProvider(t *testing.T) {
 	}
 }
 
+// TestNodeSSHUser tests that a node's sshUser override wins over the provider default
+func TestNodeSSHUser(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		custom := &RealNodeComponent{
+			Provider: pulumi.String("gcp").ToStringOutput(),
+			SSHUser:  pulumi.String("debian").ToStringOutput(),
+		}
+		nodeSSHUser(custom).ApplyT(func(user string) error {
+			assert.Equal(t, "debian", user)
+			return nil
+		})
+
+		// Components built without an SSHUser use the provider default
+		bare := &RealNodeComponent{Provider: pulumi.String("azure").ToStringOutput()}
+		nodeSSHUser(bare).ApplyT(func(user string) error {
+			assert.Equal(t, "azureuser", user)
+			return nil
+		})
+		return nil
+	}, pulumi.WithMocks("test", "stack", helperMocks(0)))
+
+	assert.NoError(t, err)
+}
+
 // TestGetSSHUserForVPNValidator tests VPN validator SSH user selection
 func TestGetSSHUserForVPNValidator(t *testing.T) {
 	testCases := []struct {
@@ -402,3 +428,24 @@ func TestGetSudoPrefixForUser_Format(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestMeshPresharedKey_SymmetricAndStable(t *testing.T) {
+	key := MeshPresharedKey("secret", "node-0", "node-1")
+
+	assert.Equal(t, key, MeshPresharedKey("secret", "node-1", "node-0"), "both ends must derive the same key")
+	assert.Equal(t, key, MeshPresharedKey("secret", "node-0", "node-1"), "key must be stable across deploys")
+	assert.NotEqual(t, key, MeshPresharedKey("secret", "node-0", "node-2"))
+	assert.NotEqual(t, key, MeshPresharedKey("other-secret", "node-0", "node-1"))
+
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	assert.NoError(t, err)
+	assert.Len(t, decoded, 32)
+
+	assert.Empty(t, presharedKeyLine(false, "secret", "node-0", "node-1"))
+	assert.Equal(t, "PresharedKey = "+key+"\n", presharedKeyLine(true, "secret", "node-0", "node-1"))
+}
+
+func TestKeepaliveLine(t *testing.T) {
+	assert.Equal(t, "PersistentKeepalive = 25\n", keepaliveLine(25))
+	assert.Empty(t, keepaliveLine(0))
+}
@@ -14,16 +14,7 @@ import (
 // This is identical to getSSHUserForProvider() in cloudinit_validator.go
 func getSSHUserForProviderK3s(provider pulumi.StringOutput) pulumi.StringOutput {
 	return provider.ApplyT(func(p string) string {
-		switch p {
-		case "azure":
-			return "azureuser"
-		case "aws":
-			return "ubuntu" // AWS Ubuntu AMIs use "ubuntu"
-		case "gcp":
-			return "ubuntu" // GCP uses "ubuntu" for Ubuntu images
-		default:
-			return "root" // DigitalOcean, Linode, and others use "root"
-		}
+		return config.DefaultSSHUser(p)
 	}).(pulumi.StringOutput)
 }
 
@@ -81,7 +72,7 @@ func NewK3sRealComponent(ctx *pulumi.Context, name string, nodes []*RealNodeComp
 	ctx.Log.Info("📦 Installing K3s on first master (cluster init)...", nil)
 
 	// Determine SSH user based on provider (Azure uses "azureuser", others use "root")
-	firstMasterSSHUser := getSSHUserForProviderK3s(firstMaster.Provider)
+	firstMasterSSHUser := nodeSSHUser(firstMaster)
 
 	// Build connection args with ProxyJump if bastion is enabled
 	firstMasterConnArgs := remote.ConnectionArgs{
@@ -290,7 +281,7 @@ exit 1
 		ctx.Log.Info(fmt.Sprintf("📦 Installing K3s on master %d (join cluster) [PARALLEL]...", i+1), nil)
 
 		// Determine SSH user based on provider (Azure uses "azureuser", others use "root")
-		masterSSHUser := getSSHUserForProviderK3s(master.Provider)
+		masterSSHUser := nodeSSHUser(master)
 
 		// Build connection args with ProxyJump if bastion is enabled
 		masterConnArgs := remote.ConnectionArgs{
@@ -428,7 +419,7 @@ echo "✅ K3s master %d joined cluster"
 		ctx.Log.Info(fmt.Sprintf("📦 Installing K3s on worker %d [PARALLEL]...", i+1), nil)
 
 		// Determine SSH user based on provider (Azure uses "azureuser", others use "root")
-		workerSSHUser := getSSHUserForProviderK3s(worker.Provider)
+		workerSSHUser := nodeSSHUser(worker)
 
 		// Build connection args with ProxyJump if bastion is enabled
 		workerConnArgs := remote.ConnectionArgs{
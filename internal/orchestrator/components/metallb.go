@@ -0,0 +1,299 @@
+package components
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const (
+	metalLBVersion     = "v0.13.12"
+	metalLBManifestURL = "https://raw.githubusercontent.com/metallb/metallb/" + metalLBVersion + "/config/manifests/metallb-native.yaml"
+
+	// metalLBDerivedPoolSize is how many addresses at the top of the node
+	// subnet are handed to MetalLB when no address pool is configured
+	metalLBDerivedPoolSize = 32
+)
+
+// addressRange is an inclusive range of IPv4 addresses
+type addressRange struct {
+	First netip.Addr
+	Last  netip.Addr
+}
+
+// String formats the range the way MetalLB's IPAddressPool expects it
+func (r addressRange) String() string {
+	return fmt.Sprintf("%s-%s", r.First, r.Last)
+}
+
+// Contains reports whether ip falls inside the range
+func (r addressRange) Contains(ip netip.Addr) bool {
+	return r.First.Compare(ip) <= 0 && ip.Compare(r.Last) <= 0
+}
+
+// MetalLBComponent installs MetalLB in L2 mode so Services of type
+// LoadBalancer get addresses from the configured pool
+type MetalLBComponent struct {
+	pulumi.ResourceState
+
+	AddressPool pulumi.StringOutput `pulumi:"addressPool"`
+	Status      pulumi.StringOutput `pulumi:"status"`
+}
+
+// NewMetalLBComponent installs MetalLB and its address pool on the cluster.
+// It returns nil unless the load balancer provider is metallb. The install
+// fails when the pool overlaps the private IP of a node.
+func NewMetalLBComponent(
+	ctx *pulumi.Context,
+	name string,
+	cfg *config.ClusterConfig,
+	nodes []*RealNodeComponent,
+	bastionComponent *BastionComponent,
+	sshPrivateKey pulumi.StringInput,
+	opts ...pulumi.ResourceOption,
+) (*MetalLBComponent, error) {
+	if !usesMetalLB(cfg) {
+		return nil, nil
+	}
+
+	pool, err := metalLBAddressPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes found for MetalLB installation")
+	}
+
+	component := &MetalLBComponent{}
+	err = ctx.RegisterComponentResource("sloth:kubernetes:MetalLB", name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Masters are deployed first, so the first node is a master
+	firstMaster := nodes[0]
+
+	connArgs := &remote.ConnectionArgs{
+		Host:           firstMaster.WireGuardIP,
+		User:           nodeSSHUser(firstMaster),
+		PrivateKey:     sshPrivateKey,
+		DialErrorLimit: pulumi.Int(30),
+	}
+
+	if bastionComponent != nil {
+		connArgs.Proxy = &remote.ProxyConnectionArgs{
+			Host:       bastionComponent.PublicIP,
+			User:       getSSHUserForProvider(bastionComponent.Provider),
+			PrivateKey: sshPrivateKey,
+		}
+	}
+
+	ctx.Log.Info(fmt.Sprintf("⚖️  Installing MetalLB %s (L2, pool %s)...", metalLBVersion, pool), nil)
+
+	privateIPs := make([]interface{}, len(nodes))
+	for i, node := range nodes {
+		privateIPs[i] = node.PrivateIP
+	}
+	poolName := metalLBPoolName(cfg)
+	installScript := pulumi.All(privateIPs...).ApplyT(func(ips []interface{}) (string, error) {
+		nodeIPs := make([]string, len(ips))
+		for i, ip := range ips {
+			nodeIPs[i], _ = ip.(string)
+		}
+		if err := checkAddressPoolOverlap(pool, nodeIPs); err != nil {
+			return "", err
+		}
+		return metalLBInstallScript(poolName, pool), nil
+	}).(pulumi.StringOutput)
+
+	installCmd, err := remote.NewCommand(ctx, fmt.Sprintf("%s-install", name), &remote.CommandArgs{
+		Connection: connArgs,
+		Create:     installScript,
+		Delete:     pulumi.String(fmt.Sprintf("#!/bin/bash\nkubectl delete -f %s --ignore-not-found || true\n", metalLBManifestURL)),
+	}, pulumi.Parent(component))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MetalLB install command: %w", err)
+	}
+
+	component.AddressPool = pulumi.String(pool.String()).ToStringOutput()
+	component.Status = installCmd.Stdout.ApplyT(func(string) string {
+		return fmt.Sprintf("MetalLB serving %s", pool)
+	}).(pulumi.StringOutput)
+
+	if err := ctx.RegisterResourceOutputs(component, pulumi.Map{
+		"addressPool": component.AddressPool,
+		"status":      component.Status,
+	}); err != nil {
+		return nil, err
+	}
+
+	return component, nil
+}
+
+// ValidateMetalLB checks the MetalLB address pool, so a bad range fails
+// before any node is created
+func ValidateMetalLB(cfg *config.ClusterConfig) error {
+	if !usesMetalLB(cfg) {
+		return nil
+	}
+	_, err := metalLBAddressPool(cfg)
+	return err
+}
+
+// usesMetalLB reports whether the cluster's load balancer provider is MetalLB
+func usesMetalLB(cfg *config.ClusterConfig) bool {
+	return strings.EqualFold(cfg.LoadBalancer.Provider, "metallb")
+}
+
+// metalLBPoolName names the IPAddressPool after the load balancer
+func metalLBPoolName(cfg *config.ClusterConfig) string {
+	if cfg.LoadBalancer.Name != "" {
+		return cfg.LoadBalancer.Name
+	}
+	return "default"
+}
+
+// metalLBAddressPool returns the configured address pool or, without one, the
+// top addresses of the node subnet below its broadcast address
+func metalLBAddressPool(cfg *config.ClusterConfig) (addressRange, error) {
+	if cfg.LoadBalancer.AddressPool != "" {
+		pool, err := parseAddressRange(cfg.LoadBalancer.AddressPool)
+		if err != nil {
+			return addressRange{}, fmt.Errorf("invalid MetalLB address pool: %w", err)
+		}
+		return pool, nil
+	}
+
+	if cfg.Network.CIDR == "" {
+		return addressRange{}, fmt.Errorf("MetalLB needs loadBalancer.addressPool or a network.cidr to derive the pool from")
+	}
+	subnet, err := netip.ParsePrefix(cfg.Network.CIDR)
+	if err != nil || !subnet.Addr().Is4() {
+		return addressRange{}, fmt.Errorf("cannot derive a MetalLB address pool from network CIDR %q", cfg.Network.CIDR)
+	}
+	subnet = subnet.Masked()
+	if subnet.Bits() > 32-6 {
+		return addressRange{}, fmt.Errorf("network CIDR %s is too small to derive a MetalLB address pool from; set loadBalancer.addressPool", subnet)
+	}
+
+	broadcast := lastAddr(subnet)
+	first := broadcast
+	for i := 0; i < metalLBDerivedPoolSize; i++ {
+		first = first.Prev()
+	}
+	return addressRange{First: first.Next(), Last: broadcast.Prev()}, nil
+}
+
+// parseAddressRange parses "first-last" or a CIDR into an IPv4 range
+func parseAddressRange(s string) (addressRange, error) {
+	if from, to, ok := strings.Cut(s, "-"); ok {
+		first, err := netip.ParseAddr(strings.TrimSpace(from))
+		if err != nil {
+			return addressRange{}, err
+		}
+		last, err := netip.ParseAddr(strings.TrimSpace(to))
+		if err != nil {
+			return addressRange{}, err
+		}
+		if !first.Is4() || !last.Is4() {
+			return addressRange{}, fmt.Errorf("%s is not an IPv4 range", s)
+		}
+		if last.Less(first) {
+			return addressRange{}, fmt.Errorf("%s ends before it starts", s)
+		}
+		return addressRange{First: first, Last: last}, nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return addressRange{}, err
+	}
+	if !prefix.Addr().Is4() {
+		return addressRange{}, fmt.Errorf("%s is not an IPv4 range", s)
+	}
+	prefix = prefix.Masked()
+	return addressRange{First: prefix.Addr(), Last: lastAddr(prefix)}, nil
+}
+
+// lastAddr returns the highest address of an IPv4 prefix
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().As4()
+	hostBits := 32 - prefix.Bits()
+	ip := uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	ip |= uint32(1)<<hostBits - 1
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)})
+}
+
+// checkAddressPoolOverlap fails when a node's IP falls inside the pool,
+// since MetalLB would hand that address to a Service
+func checkAddressPoolOverlap(pool addressRange, nodeIPs []string) error {
+	for _, s := range nodeIPs {
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			continue
+		}
+		if pool.Contains(ip) {
+			return fmt.Errorf("MetalLB address pool %s overlaps node IP %s", pool, ip)
+		}
+	}
+	return nil
+}
+
+// metalLBInstallScript applies the MetalLB manifests, waits for its
+// controller and admission webhook, then applies the address pool
+func metalLBInstallScript(poolName string, pool addressRange) string {
+	return fmt.Sprintf(`#!/bin/bash
+set -e
+
+echo "📦 Applying MetalLB %s..."
+kubectl apply -f %s
+
+echo "⏳ Waiting for the MetalLB controller..."
+kubectl -n metallb-system rollout status deployment/controller --timeout=300s
+kubectl -n metallb-system rollout status daemonset/speaker --timeout=300s
+
+echo "🏊 Applying address pool %s..."
+# The admission webhook accepts pools only once its endpoint is serving
+for i in $(seq 1 30); do
+  if kubectl apply -f - <<'MANIFEST'
+%s
+MANIFEST
+  then
+    echo "✅ MetalLB installed"
+    exit 0
+  fi
+  sleep 10
+done
+
+echo "❌ MetalLB rejected the address pool"
+exit 1
+`, metalLBVersion, metalLBManifestURL, pool, metalLBPoolManifest(poolName, pool))
+}
+
+// metalLBPoolManifest renders the IPAddressPool and the L2Advertisement that
+// announces it
+func metalLBPoolManifest(poolName string, pool addressRange) string {
+	return fmt.Sprintf(`apiVersion: metallb.io/v1beta1
+kind: IPAddressPool
+metadata:
+  name: %[1]s
+  namespace: metallb-system
+spec:
+  addresses:
+    - %[2]s
+---
+apiVersion: metallb.io/v1beta1
+kind: L2Advertisement
+metadata:
+  name: %[1]s
+  namespace: metallb-system
+spec:
+  ipAddressPools:
+    - %[1]s`, poolName, pool)
+}
@@ -0,0 +1,95 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+func metalLBTestConfig(addressPool, cidr string) *config.ClusterConfig {
+	return &config.ClusterConfig{
+		Network:      config.NetworkConfig{CIDR: cidr},
+		LoadBalancer: config.LoadBalancerConfig{Name: "main-lb", Provider: "metallb", AddressPool: addressPool},
+	}
+}
+
+func TestMetalLBAddressPool(t *testing.T) {
+	tests := []struct {
+		name        string
+		addressPool string
+		cidr        string
+		want        string
+	}{
+		{"explicit range", "10.0.255.200-10.0.255.250", "10.0.0.0/16", "10.0.255.200-10.0.255.250"},
+		{"explicit CIDR", "192.168.10.64/27", "", "192.168.10.64-192.168.10.95"},
+		{"derived from node subnet", "", "10.0.0.0/16", "10.0.255.224-10.0.255.254"},
+		{"derived from unmasked subnet", "", "10.10.3.7/24", "10.10.3.224-10.10.3.254"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := metalLBAddressPool(metalLBTestConfig(tt.addressPool, tt.cidr))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pool.String())
+		})
+	}
+}
+
+func TestMetalLBAddressPool_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		addressPool string
+		cidr        string
+		want        string
+	}{
+		{"no pool or CIDR", "", "", "MetalLB needs loadBalancer.addressPool or a network.cidr"},
+		{"reversed range", "10.0.0.50-10.0.0.10", "", "ends before it starts"},
+		{"IPv6 range", "fd00::1-fd00::10", "", "is not an IPv4 range"},
+		{"garbage", "load-balancer", "", "invalid MetalLB address pool"},
+		{"subnet too small", "", "10.0.0.0/28", "too small to derive a MetalLB address pool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := metalLBAddressPool(metalLBTestConfig(tt.addressPool, tt.cidr))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestValidateMetalLB(t *testing.T) {
+	assert.NoError(t, ValidateMetalLB(&config.ClusterConfig{}), "nothing to check without metallb")
+	assert.NoError(t, ValidateMetalLB(metalLBTestConfig("", "10.0.0.0/16")))
+	assert.Error(t, ValidateMetalLB(metalLBTestConfig("", "")))
+
+	cfg := metalLBTestConfig("", "10.0.0.0/16")
+	cfg.LoadBalancer.Provider = "MetalLB"
+	assert.True(t, usesMetalLB(cfg))
+}
+
+func TestCheckAddressPoolOverlap(t *testing.T) {
+	pool, err := parseAddressRange("10.0.255.224-10.0.255.254")
+	require.NoError(t, err)
+
+	assert.NoError(t, checkAddressPoolOverlap(pool, []string{"10.0.1.5", "10.0.255.223", "10.0.255.255", ""}))
+
+	err = checkAddressPoolOverlap(pool, []string{"10.0.1.5", "10.0.255.230"})
+	require.Error(t, err)
+	assert.Equal(t, "MetalLB address pool 10.0.255.224-10.0.255.254 overlaps node IP 10.0.255.230", err.Error())
+}
+
+func TestMetalLBInstallScript(t *testing.T) {
+	pool, err := parseAddressRange("10.0.255.224/27")
+	require.NoError(t, err)
+
+	script := metalLBInstallScript("main-lb", pool)
+
+	assert.Contains(t, script, "kubectl apply -f "+metalLBManifestURL)
+	assert.Contains(t, script, "kind: IPAddressPool")
+	assert.Contains(t, script, "    - 10.0.255.224-10.0.255.255")
+	assert.Contains(t, script, "kind: L2Advertisement")
+	assert.Contains(t, script, "ipAddressPools:\n    - main-lb")
+	assert.Equal(t, "default", metalLBPoolName(&config.ClusterConfig{}))
+}
@@ -40,10 +40,15 @@ type RealNodeComponent struct {
 	PublicIP    pulumi.StringOutput `pulumi:"publicIP"`
 	PrivateIP   pulumi.StringOutput `pulumi:"privateIP"`
 	WireGuardIP pulumi.StringOutput `pulumi:"wireGuardIP"`
+	SSHUser     pulumi.StringOutput `pulumi:"sshUser"`
 	Roles       pulumi.ArrayOutput  `pulumi:"roles"`
 	Status      pulumi.StringOutput `pulumi:"status"`
 	DropletID   pulumi.IDOutput     `pulumi:"dropletId"`  // For DigitalOcean
 	InstanceID  pulumi.IntOutput    `pulumi:"instanceId"` // For Linode
+
+	// wireGuardIP is the WireGuard address WireGuardIP resolves to, for
+	// building the mesh configuration
+	wireGuardIP string
 }
 
 // ProviderNodeCreator defines the function signature for creating nodes on different providers
@@ -180,6 +185,18 @@ func NewRealNodeDeploymentComponent(ctx *pulumi.Context, name string, clusterCon
 	for _, poolName := range poolOrder {
 		poolConfig := clusterConfig.NodePools[poolName]
 
+		// Autoscaling pools are tagged into the group cluster-autoscaler scales
+		labels := poolConfig.Labels
+		if tags := autoscalerNodeTags(clusterConfig, poolName, &poolConfig); len(tags) > 0 {
+			labels = make(map[string]string, len(poolConfig.Labels)+len(tags))
+			for k, v := range poolConfig.Labels {
+				labels[k] = v
+			}
+			for k, v := range tags {
+				labels[k] = v
+			}
+		}
+
 		for i := 0; i < poolConfig.Count; i++ {
 			nodeName := fmt.Sprintf("%s-%d", poolName, i+1)
 
@@ -190,10 +207,11 @@ func NewRealNodeDeploymentComponent(ctx *pulumi.Context, name string, clusterCon
 				Size:        poolConfig.Size,
 				Image:       poolConfig.Image,
 				Roles:       poolConfig.Roles,
-				Labels:      poolConfig.Labels,
+				Labels:      labels,
 				Taints:      poolConfig.Taints,
+				SSHUser:     poolConfig.SSHUser,
 				PrivateIP:   fmt.Sprintf("10.0.1.%d", nodeIndex+1),
-				WireGuardIP: fmt.Sprintf("10.8.0.%d", 10+nodeIndex),
+				WireGuardIP: poolConfig.WireGuardIPFor(i),
 			}
 
 			nodeComp, err := newRealNodeComponent(ctx, fmt.Sprintf("%s-%s-%s", name, poolName, nodeName), &nodeConfig, sshKeyOutput, sshPrivateKey, sharedDOSshKey, nil, doToken, linodeToken, vpcComponent, bastionComponent, component)
@@ -256,6 +274,8 @@ func newRealNodeComponent(ctx *pulumi.Context, name string, nodeConfig *config.N
 	component.Region = pulumi.String(nodeConfig.Region).ToStringOutput()
 	component.Size = pulumi.String(nodeConfig.Size).ToStringOutput()
 	component.WireGuardIP = pulumi.String(nodeConfig.WireGuardIP).ToStringOutput()
+	component.wireGuardIP = nodeConfig.WireGuardIP
+	component.SSHUser = pulumi.String(config.SSHUserFor(nodeConfig.Provider, nodeConfig.SSHUser)).ToStringOutput()
 
 	// Convert roles
 	rolesArray := make([]pulumi.Output, len(nodeConfig.Roles))
@@ -308,6 +328,7 @@ func newRealNodeComponent(ctx *pulumi.Context, name string, nodeConfig *config.N
 		"publicIP":    component.PublicIP,
 		"privateIP":   component.PrivateIP,
 		"wireGuardIP": component.WireGuardIP,
+		"sshUser":     component.SSHUser,
 		"roles":       component.Roles,
 		"status":      component.Status,
 	}); err != nil {
@@ -661,6 +682,13 @@ func createAzureVM(ctx *pulumi.Context, name string, nodeConfig *config.NodeConf
 			},
 		},
 	}
+	if len(nodeConfig.Labels) > 0 {
+		tags := pulumi.StringMap{}
+		for k, v := range nodeConfig.Labels {
+			tags[k] = pulumi.String(v)
+		}
+		vmArgs.Tags = tags
+	}
 
 	vm, err := azurecompute.NewVirtualMachine(ctx, nodeConfig.Name, vmArgs)
 	if err != nil {
@@ -936,6 +964,16 @@ func createAWSNode(ctx *pulumi.Context, name string, nodeConfig *config.NodeConf
 	// Generate cloud-init user data
 	userData := cloudinit.GenerateUserDataWithHostnameAndSalt(nodeConfig.Name, saltMasterIP)
 
+	// Build tags; node labels carry e.g. the cluster-autoscaler discovery tags
+	tags := pulumi.StringMap{
+		"Name":       pulumi.String(nodeConfig.Name),
+		"kubernetes": pulumi.String("true"),
+		"stack":      pulumi.String(ctx.Stack()),
+	}
+	for k, v := range nodeConfig.Labels {
+		tags[k] = pulumi.String(v)
+	}
+
 	// Create EC2 instance in our VPC subnet
 	instance, err := ec2.NewInstance(ctx, name, &ec2.InstanceArgs{
 		Ami:                      pulumi.String(ami),
@@ -945,11 +983,7 @@ func createAWSNode(ctx *pulumi.Context, name string, nodeConfig *config.NodeConf
 		VpcSecurityGroupIds:      pulumi.StringArray{awsSecurityGroup.ID()},
 		AssociatePublicIpAddress: pulumi.Bool(true),
 		UserData:                 pulumi.String(userData),
-		Tags: pulumi.StringMap{
-			"Name":       pulumi.String(nodeConfig.Name),
-			"kubernetes": pulumi.String("true"),
-			"stack":      pulumi.String(ctx.Stack()),
-		},
+		Tags:                     tags,
 	}, pulumi.Parent(component), pulumi.Provider(awsProvider))
 	if err != nil {
 		return fmt.Errorf("failed to create EC2 instance: %w", err)
@@ -1111,6 +1145,7 @@ func createHetznerNode(ctx *pulumi.Context, name string, nodeConfig *config.Node
 	component.NodeName = pulumi.String(name).ToStringOutput()
 	component.Provider = pulumi.String("hetzner").ToStringOutput()
 	component.WireGuardIP = pulumi.String(nodeConfig.WireGuardIP).ToStringOutput()
+	component.wireGuardIP = nodeConfig.WireGuardIP
 	component.Region = pulumi.String(location).ToStringOutput()
 	component.Size = pulumi.String(serverType).ToStringOutput()
 	component.Status = server.Status
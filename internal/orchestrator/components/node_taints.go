@@ -0,0 +1,136 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// PoolLabel holds the name of the node pool a node was created from
+const PoolLabel = "pool"
+
+// NodeTaintsComponent labels pool nodes with their pool and applies the
+// pools' taints to them
+type NodeTaintsComponent struct {
+	pulumi.ResourceState
+
+	Status pulumi.StringOutput `pulumi:"status"`
+}
+
+// NewNodeTaintsComponent applies each node pool's taints to its nodes once
+// they have registered. It returns nil when no node pool has taints.
+func NewNodeTaintsComponent(
+	ctx *pulumi.Context,
+	name string,
+	cfg *config.ClusterConfig,
+	nodes []*RealNodeComponent,
+	bastionComponent *BastionComponent,
+	sshPrivateKey pulumi.StringInput,
+	opts ...pulumi.ResourceOption,
+) (*NodeTaintsComponent, error) {
+	pools := taintedPools(cfg)
+	if len(pools) == 0 {
+		return nil, nil // No tainted pools
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes found for node taints")
+	}
+
+	component := &NodeTaintsComponent{}
+	err := ctx.RegisterComponentResource("sloth:kubernetes:NodeTaints", name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Masters are deployed first, so the first node is a master
+	firstMaster := nodes[0]
+
+	connArgs := &remote.ConnectionArgs{
+		Host:           firstMaster.WireGuardIP,
+		User:           nodeSSHUser(firstMaster),
+		PrivateKey:     sshPrivateKey,
+		DialErrorLimit: pulumi.Int(30),
+	}
+
+	if bastionComponent != nil {
+		connArgs.Proxy = &remote.ProxyConnectionArgs{
+			Host:       bastionComponent.PublicIP,
+			User:       getSSHUserForProvider(bastionComponent.Provider),
+			PrivateKey: sshPrivateKey,
+		}
+	}
+
+	ctx.Log.Info(fmt.Sprintf("🏷️  Applying taints to %d node pool(s)...", len(pools)), nil)
+
+	taintCmd, err := remote.NewCommand(ctx, fmt.Sprintf("%s-apply", name), &remote.CommandArgs{
+		Connection: connArgs,
+		Create:     pulumi.String(nodeTaintsScript(cfg, pools)),
+	}, pulumi.Parent(component))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node taints command: %w", err)
+	}
+
+	component.Status = taintCmd.Stdout.ApplyT(func(string) string {
+		return fmt.Sprintf("Taints applied to %d node pool(s)", len(pools))
+	}).(pulumi.StringOutput)
+
+	if err := ctx.RegisterResourceOutputs(component, pulumi.Map{
+		"status": component.Status,
+	}); err != nil {
+		return nil, err
+	}
+
+	return component, nil
+}
+
+// taintedPools returns the names of the node pools with taints, sorted
+func taintedPools(cfg *config.ClusterConfig) []string {
+	var pools []string
+	for poolName, pool := range cfg.NodePools {
+		if len(pool.Taints) > 0 {
+			pools = append(pools, poolName)
+		}
+	}
+	sort.Strings(pools)
+	return pools
+}
+
+// nodeTaintsScript labels the nodes of each pool, named as by
+// NewRealNodeDeploymentComponent, with the pool and taints them by that
+// label. --overwrite replaces a taint with the same key and effect, so
+// re-running the script never duplicates one.
+func nodeTaintsScript(cfg *config.ClusterConfig, pools []string) string {
+	var cmds strings.Builder
+	for _, poolName := range pools {
+		pool := cfg.NodePools[poolName]
+
+		cmds.WriteString(fmt.Sprintf("echo \"🏷️  Node pool %s\"\n", poolName))
+		for i := 0; i < pool.Count; i++ {
+			cmds.WriteString(fmt.Sprintf(`for i in $(seq 1 30); do
+  kubectl get node %[1]s &>/dev/null && break
+  sleep 10
+done
+kubectl label node %[1]s %[2]s=%[3]s --overwrite || echo "⚠️  Node %[1]s not found, skipping"
+`, fmt.Sprintf("%s-%d", poolName, i+1), PoolLabel, poolName))
+		}
+
+		taints := make([]string, len(pool.Taints))
+		for i, taint := range pool.Taints {
+			taints[i] = shellQuote(taint.String())
+		}
+		cmds.WriteString(fmt.Sprintf("kubectl taint nodes -l %s=%s %s --overwrite\n", PoolLabel, poolName, strings.Join(taints, " ")))
+	}
+
+	return fmt.Sprintf(`#!/bin/bash
+set -e
+
+%s
+echo "✅ Node taints applied"
+`, cmds.String())
+}
@@ -0,0 +1,37 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+func TestTaintedPools(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		NodePools: map[string]config.NodePool{
+			"gpu":     {Count: 1, Taints: []config.TaintConfig{{Key: "nvidia.com/gpu", Effect: "NoSchedule"}}},
+			"workers": {Count: 3},
+			"batch":   {Count: 2, Taints: []config.TaintConfig{{Key: "dedicated", Value: "batch", Effect: "NoExecute"}}},
+		},
+	}
+	assert.Equal(t, []string{"batch", "gpu"}, taintedPools(cfg))
+	assert.Empty(t, taintedPools(&config.ClusterConfig{}))
+}
+
+func TestNodeTaintsScript(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		NodePools: map[string]config.NodePool{
+			"batch": {Count: 2, Taints: []config.TaintConfig{
+				{Key: "dedicated", Value: "batch", Effect: "NoExecute"},
+				{Key: "spot", Effect: "PreferNoSchedule"},
+			}},
+		},
+	}
+
+	script := nodeTaintsScript(cfg, []string{"batch"})
+	assert.Contains(t, script, "kubectl label node batch-1 pool=batch --overwrite")
+	assert.Contains(t, script, "kubectl label node batch-2 pool=batch --overwrite")
+	assert.Contains(t, script, "kubectl taint nodes -l pool=batch 'dedicated=batch:NoExecute' 'spot:PreferNoSchedule' --overwrite")
+}
@@ -9,22 +9,6 @@ import (
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 )
 
-// getSSHUserForProviderRKE2 returns the correct SSH username for the given cloud provider
-func getSSHUserForProviderRKE2(provider pulumi.StringOutput) pulumi.StringOutput {
-	return provider.ApplyT(func(p string) string {
-		switch p {
-		case "azure":
-			return "azureuser"
-		case "aws":
-			return "ubuntu"
-		case "gcp":
-			return "ubuntu"
-		default:
-			return "root"
-		}
-	}).(pulumi.StringOutput)
-}
-
 // RKE2RealComponent represents a real RKE2 Kubernetes cluster
 type RKE2RealComponent struct {
 	pulumi.ResourceState
@@ -45,6 +29,9 @@ func NewRKE2RealComponent(ctx *pulumi.Context, name string, nodes []*RealNodeCom
 		return nil, err
 	}
 
+	// Log rotation and eviction thresholds, shared by every node
+	kubeletArgs := config.BuildKubeletArgsConfig(&cfg.Kubernetes.Kubelet)
+
 	// Separate nodes into masters and workers
 	var masters []*RealNodeComponent
 	var workers []*RealNodeComponent
@@ -78,7 +65,7 @@ func NewRKE2RealComponent(ctx *pulumi.Context, name string, nodes []*RealNodeCom
 	firstMaster := masters[0]
 	ctx.Log.Info("📦 Installing RKE2 on first master (cluster init)...", nil)
 
-	firstMasterSSHUser := getSSHUserForProviderRKE2(firstMaster.Provider)
+	firstMasterSSHUser := nodeSSHUser(firstMaster)
 
 	firstMasterConnArgs := remote.ConnectionArgs{
 		Host:           firstMaster.PublicIP,
@@ -171,7 +158,7 @@ cni: calico
 disable:
   - rke2-ingress-nginx
 write-kubeconfig-mode: "0644"
-EOF
+%sEOF
 
 echo "📥 Downloading RKE2 installer..."
 curl -sfL https://get.rke2.io | INSTALL_RKE2_CHANNEL=%s sudo sh -
@@ -221,7 +208,7 @@ kubectl get nodes -o wide
 echo "---KUBECONFIG_START---"
 cat /etc/rancher/rke2/rke2.yaml
 echo "---KUBECONFIG_END---"
-`, vpnDetectionScript, publicIP, publicIP, token, rke2Version)
+`, vpnDetectionScript, publicIP, publicIP, token, kubeletArgs, rke2Version)
 		}).(pulumi.StringOutput),
 	}, pulumi.Parent(component), pulumi.Timeouts(&pulumi.CustomTimeouts{
 		Create: "15m",
@@ -273,7 +260,7 @@ echo "---KUBECONFIG_END---"
 
 	for i := 1; i < len(masters); i++ {
 		master := masters[i]
-		masterSSHUser := getSSHUserForProviderRKE2(master.Provider)
+		masterSSHUser := nodeSSHUser(master)
 
 		masterConnArgs := remote.ConnectionArgs{
 			Host:           master.PublicIP,
@@ -364,7 +351,7 @@ node-ip: $VPN_IP
 node-external-ip: %s
 cni: calico
 write-kubeconfig-mode: "0644"
-EOF
+%sEOF
 
 # Install RKE2
 curl -sfL https://get.rke2.io | INSTALL_RKE2_CHANNEL=%s sudo sh -
@@ -386,7 +373,7 @@ while [ $elapsed -lt $timeout ]; do
 done
 
 echo "✅ Additional master joined successfully"
-`, vpnDetectionScript, firstMasterIPScript, token, publicIP, rke2Version)
+`, vpnDetectionScript, firstMasterIPScript, token, publicIP, kubeletArgs, rke2Version)
 			}).(pulumi.StringOutput),
 		}, pulumi.Parent(component), pulumi.DependsOn([]pulumi.Resource{fetchToken}), pulumi.Timeouts(&pulumi.CustomTimeouts{
 			Create: "15m",
@@ -403,7 +390,7 @@ echo "✅ Additional master joined successfully"
 	var workerCmds []pulumi.Resource
 
 	for i, worker := range workers {
-		workerSSHUser := getSSHUserForProviderRKE2(worker.Provider)
+		workerSSHUser := nodeSSHUser(worker)
 
 		workerConnArgs := remote.ConnectionArgs{
 			Host:           worker.PublicIP,
@@ -492,7 +479,7 @@ server: https://$FIRST_MASTER_IP:9345
 token: %s
 node-ip: $VPN_IP
 node-external-ip: %s
-EOF
+%sEOF
 
 # Install RKE2 agent
 curl -sfL https://get.rke2.io | INSTALL_RKE2_CHANNEL=%s INSTALL_RKE2_TYPE="agent" sudo sh -
@@ -513,7 +500,7 @@ while [ $elapsed -lt $timeout ]; do
 done
 
 echo "✅ Worker joined successfully"
-`, vpnDetectionScript, firstMasterIPScript, token, publicIP, rke2Version)
+`, vpnDetectionScript, firstMasterIPScript, token, publicIP, kubeletArgs, rke2Version)
 			}).(pulumi.StringOutput),
 		}, pulumi.Parent(component), pulumi.DependsOn([]pulumi.Resource{fetchToken}), pulumi.Timeouts(&pulumi.CustomTimeouts{
 			Create: "15m",
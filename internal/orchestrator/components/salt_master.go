@@ -59,7 +59,7 @@ func NewSaltMasterComponent(
 	ctx.Log.Info("", nil)
 
 	// Get SSH user for the target node
-	sshUser := getSSHUserForProvider(targetNode.Provider)
+	sshUser := nodeSSHUser(targetNode)
 
 	// Build connection args
 	connArgs := remote.ConnectionArgs{
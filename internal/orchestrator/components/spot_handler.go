@@ -0,0 +1,282 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const (
+	// SpotLifecycleLabel marks nodes running on spot/preemptible capacity
+	SpotLifecycleLabel = "node.kubernetes.io/lifecycle"
+
+	// defaultSpotDrainTimeout leaves headroom inside AWS's two minute notice
+	defaultSpotDrainTimeout = 90
+
+	spotHandlerImage = "alpine/k8s:1.29.2"
+)
+
+// SpotInterruptionHandlerComponent labels spot nodes and installs a DaemonSet
+// that drains them when the cloud announces an interruption
+type SpotInterruptionHandlerComponent struct {
+	pulumi.ResourceState
+
+	Status pulumi.StringOutput `pulumi:"status"`
+}
+
+// NewSpotInterruptionHandlerComponent installs the spot interruption handler.
+// It returns nil when no node pool has a spot config.
+func NewSpotInterruptionHandlerComponent(
+	ctx *pulumi.Context,
+	name string,
+	cfg *config.ClusterConfig,
+	nodes []*RealNodeComponent,
+	bastionComponent *BastionComponent,
+	sshPrivateKey pulumi.StringInput,
+	opts ...pulumi.ResourceOption,
+) (*SpotInterruptionHandlerComponent, error) {
+	spotNodes := spotNodeNames(cfg)
+	if len(spotNodes) == 0 {
+		return nil, nil // No spot pools
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes found for spot interruption handler installation")
+	}
+
+	component := &SpotInterruptionHandlerComponent{}
+	err := ctx.RegisterComponentResource("sloth:kubernetes:SpotInterruptionHandler", name, component, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Masters are deployed first, so the first node is a master
+	firstMaster := nodes[0]
+
+	masterUser := nodeSSHUser(firstMaster)
+	connArgs := &remote.ConnectionArgs{
+		Host:           firstMaster.WireGuardIP,
+		User:           masterUser,
+		PrivateKey:     sshPrivateKey,
+		DialErrorLimit: pulumi.Int(30),
+	}
+
+	if bastionComponent != nil {
+		bastionUser := getSSHUserForProvider(bastionComponent.Provider)
+		connArgs.Proxy = &remote.ProxyConnectionArgs{
+			Host:       bastionComponent.PublicIP,
+			User:       bastionUser,
+			PrivateKey: sshPrivateKey,
+		}
+	}
+
+	ctx.Log.Info(fmt.Sprintf("⚡ Installing spot interruption handler for %d node(s)...", len(spotNodes)), nil)
+
+	installCmd, err := remote.NewCommand(ctx, fmt.Sprintf("%s-install", name), &remote.CommandArgs{
+		Connection: connArgs,
+		Create:     pulumi.String(spotHandlerInstallScript(spotNodes, spotDrainTimeout(cfg))),
+		Delete: pulumi.String(`#!/bin/bash
+kubectl delete -n kube-system daemonset/spot-interruption-handler configmap/spot-interruption-handler serviceaccount/spot-interruption-handler --ignore-not-found || true
+kubectl delete clusterrolebinding/spot-interruption-handler clusterrole/spot-interruption-handler --ignore-not-found || true
+`),
+	}, pulumi.Parent(component))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spot interruption handler install command: %w", err)
+	}
+
+	component.Status = installCmd.Stdout.ApplyT(func(string) string {
+		return fmt.Sprintf("Spot interruption handler watching %d node(s)", len(spotNodes))
+	}).(pulumi.StringOutput)
+
+	if err := ctx.RegisterResourceOutputs(component, pulumi.Map{
+		"status": component.Status,
+	}); err != nil {
+		return nil, err
+	}
+
+	return component, nil
+}
+
+// spotNodeNames returns the names of the nodes created from pools with a spot
+// config, following the naming used by NewRealNodeDeploymentComponent
+func spotNodeNames(cfg *config.ClusterConfig) []string {
+	poolNames := make([]string, 0, len(cfg.NodePools))
+	for poolName, pool := range cfg.NodePools {
+		if pool.SpotConfig != nil && pool.SpotConfig.Enabled {
+			poolNames = append(poolNames, poolName)
+		}
+	}
+	sort.Strings(poolNames)
+
+	var names []string
+	for _, poolName := range poolNames {
+		for i := 0; i < cfg.NodePools[poolName].Count; i++ {
+			names = append(names, fmt.Sprintf("%s-%d", poolName, i+1))
+		}
+	}
+	return names
+}
+
+// spotDrainTimeout returns the shortest drain timeout configured on any spot
+// pool, so no node outlives its interruption notice
+func spotDrainTimeout(cfg *config.ClusterConfig) int {
+	timeout := 0
+	for _, pool := range cfg.NodePools {
+		if pool.SpotConfig == nil || !pool.SpotConfig.Enabled || pool.SpotConfig.DrainTimeout <= 0 {
+			continue
+		}
+		if timeout == 0 || pool.SpotConfig.DrainTimeout < timeout {
+			timeout = pool.SpotConfig.DrainTimeout
+		}
+	}
+	if timeout == 0 {
+		return defaultSpotDrainTimeout
+	}
+	return timeout
+}
+
+// spotHandlerInstallScript labels the spot nodes and applies the handler manifests
+func spotHandlerInstallScript(spotNodes []string, drainTimeout int) string {
+	var labelCmds strings.Builder
+	for _, node := range spotNodes {
+		labelCmds.WriteString(fmt.Sprintf(`for i in $(seq 1 30); do
+  kubectl get node %[1]s &>/dev/null && break
+  sleep 10
+done
+kubectl label node %[1]s %[2]s=spot --overwrite || echo "⚠️  Node %[1]s not found, skipping label"
+`, node, SpotLifecycleLabel))
+	}
+
+	return fmt.Sprintf(`#!/bin/bash
+set -e
+
+echo "⚡ Labeling spot nodes..."
+%s
+echo "📦 Applying spot interruption handler..."
+kubectl apply -f - <<'MANIFEST'
+%s
+MANIFEST
+
+echo "✅ Spot interruption handler installed"
+`, labelCmds.String(), spotHandlerManifest(drainTimeout))
+}
+
+// spotHandlerManifest renders the handler DaemonSet and its RBAC. The watcher
+// polls the AWS, Azure and GCP interruption endpoints and, on the first
+// notice, cordons and drains the node it runs on.
+func spotHandlerManifest(drainTimeout int) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: spot-interruption-handler
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: spot-interruption-handler
+rules:
+  - apiGroups: [""]
+    resources: ["nodes"]
+    verbs: ["get", "list", "patch"]
+  - apiGroups: [""]
+    resources: ["pods"]
+    verbs: ["get", "list"]
+  - apiGroups: [""]
+    resources: ["pods/eviction"]
+    verbs: ["create"]
+  - apiGroups: ["apps"]
+    resources: ["daemonsets"]
+    verbs: ["get"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: spot-interruption-handler
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: spot-interruption-handler
+subjects:
+  - kind: ServiceAccount
+    name: spot-interruption-handler
+    namespace: kube-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: spot-interruption-handler
+  namespace: kube-system
+data:
+  watch.sh: |
+    #!/bin/sh
+    notice() {
+      TOKEN=$(curl -sf -m 2 -X PUT -H "X-aws-ec2-metadata-token-ttl-seconds: 60" http://169.254.169.254/latest/api/token || true)
+      if [ -n "$TOKEN" ]; then
+        curl -sf -m 2 -H "X-aws-ec2-metadata-token: $TOKEN" http://169.254.169.254/latest/meta-data/spot/instance-action >/dev/null
+        return
+      fi
+      if curl -sf -m 2 -H "Metadata: true" "http://169.254.169.254/metadata/scheduledevents?api-version=2020-07-01" | grep -q '"Preempt"'; then
+        return 0
+      fi
+      [ "$(curl -sf -m 2 -H 'Metadata-Flavor: Google' http://metadata.google.internal/computeMetadata/v1/instance/preempted)" = "TRUE" ]
+    }
+    echo "Watching for spot interruption notices on $NODE_NAME"
+    while ! notice; do sleep 5; done
+    echo "Interruption notice received, draining $NODE_NAME"
+    kubectl cordon "$NODE_NAME"
+    kubectl drain "$NODE_NAME" --ignore-daemonsets --delete-emptydir-data --force --timeout=%ds || true
+    sleep infinity
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: spot-interruption-handler
+  namespace: kube-system
+  labels:
+    app: spot-interruption-handler
+spec:
+  selector:
+    matchLabels:
+      app: spot-interruption-handler
+  template:
+    metadata:
+      labels:
+        app: spot-interruption-handler
+    spec:
+      serviceAccountName: spot-interruption-handler
+      hostNetwork: true
+      dnsPolicy: ClusterFirstWithHostNet
+      priorityClassName: system-node-critical
+      nodeSelector:
+        %s: spot
+      tolerations:
+        - operator: Exists
+      containers:
+        - name: handler
+          image: %s
+          command: ["/bin/sh", "/scripts/watch.sh"]
+          env:
+            - name: NODE_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: spec.nodeName
+          resources:
+            requests:
+              cpu: 10m
+              memory: 32Mi
+            limits:
+              memory: 64Mi
+          volumeMounts:
+            - name: scripts
+              mountPath: /scripts
+      volumes:
+        - name: scripts
+          configMap:
+            name: spot-interruption-handler`, drainTimeout, SpotLifecycleLabel, spotHandlerImage)
+}
@@ -0,0 +1,49 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+func TestSpotNodeNames(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		NodePools: map[string]config.NodePool{
+			"masters":      {Count: 3, Roles: []string{"master"}},
+			"spot-workers": {Count: 2, SpotInstance: true, SpotConfig: &config.SpotConfig{Enabled: true}},
+			"batch":        {Count: 1, SpotInstance: true, SpotConfig: &config.SpotConfig{Enabled: true}},
+			"disabled":     {Count: 1, SpotConfig: &config.SpotConfig{Enabled: false}},
+		},
+	}
+
+	assert.Equal(t, []string{"batch-1", "spot-workers-1", "spot-workers-2"}, spotNodeNames(cfg))
+	assert.Empty(t, spotNodeNames(&config.ClusterConfig{}))
+}
+
+func TestSpotDrainTimeout(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		NodePools: map[string]config.NodePool{
+			"a": {SpotConfig: &config.SpotConfig{Enabled: true}},
+		},
+	}
+	assert.Equal(t, defaultSpotDrainTimeout, spotDrainTimeout(cfg))
+
+	cfg.NodePools["b"] = config.NodePool{SpotConfig: &config.SpotConfig{Enabled: true, DrainTimeout: 60}}
+	cfg.NodePools["c"] = config.NodePool{SpotConfig: &config.SpotConfig{Enabled: true, DrainTimeout: 25}}
+	assert.Equal(t, 25, spotDrainTimeout(cfg))
+}
+
+func TestSpotHandlerInstallScript(t *testing.T) {
+	script := spotHandlerInstallScript([]string{"spot-workers-1"}, 45)
+
+	assert.Contains(t, script, "kubectl label node spot-workers-1 node.kubernetes.io/lifecycle=spot --overwrite")
+	assert.Contains(t, script, "kubectl apply -f - <<'MANIFEST'")
+	assert.Contains(t, script, "--timeout=45s")
+	assert.Contains(t, script, "node.kubernetes.io/lifecycle: spot")
+	assert.Contains(t, script, "meta-data/spot/instance-action")
+	assert.Contains(t, script, "pods/eviction")
+	assert.False(t, strings.Contains(script, "%!"), "script has a formatting error")
+}
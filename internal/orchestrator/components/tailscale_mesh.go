@@ -1,7 +1,9 @@
 package components
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
@@ -9,6 +11,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
 )
 
 // TailscaleMeshComponent configures Tailscale VPN mesh with Headscale coordination server
@@ -21,6 +24,7 @@ type TailscaleMeshComponent struct {
 	HeadscaleIP  pulumi.StringOutput `pulumi:"headscaleIp"`
 	APIKey       pulumi.StringOutput `pulumi:"apiKey"`
 	Namespace    pulumi.StringOutput `pulumi:"namespace"`
+	ACLPolicy    pulumi.StringOutput `pulumi:"aclPolicy"`
 }
 
 // TailscaleMeshArgs contains the arguments for creating a Tailscale mesh
@@ -48,6 +52,15 @@ func NewTailscaleMeshComponent(ctx *pulumi.Context, name string, nodes []*RealNo
 		namespace = "kubernetes"
 	}
 
+	// Validate the ACL policy before creating anything
+	var aclPolicy string
+	if args.Config.ACLPolicy != "" {
+		aclPolicy, err = tailscale.ResolveACLPolicy(args.Config.ACLPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Tailscale ACL policy: %w", err)
+		}
+	}
+
 	// STEP 1: Create Headscale coordination server if create=true
 	var headscaleIP pulumi.StringOutput
 	var headscaleURL pulumi.StringOutput
@@ -301,6 +314,8 @@ echo "=== END_CREDENTIALS ==="
 		return nil, fmt.Errorf("auth-key is required when using external Headscale server")
 	}
 
+	exitNode := args.Config.ExitNode
+
 	// Install Tailscale on each node
 	for i, node := range nodes {
 		hostname := node.NodeName.ApplyT(func(name string) string {
@@ -350,6 +365,13 @@ rm -f ~/.ssh/headscale_key
 				fetchAuthKeyCmd = fmt.Sprintf(`AUTH_KEY="%s"`, authKeySrc)
 			}
 
+			// The exit node forwards full-tunnel client traffic to the internet
+			exitNodeSetup, exitNodeArg := "", ""
+			if host == exitNode {
+				exitNodeSetup = tailscale.ExitNodeForwardingScript
+				exitNodeArg = " --advertise-exit-node"
+			}
+
 			return fmt.Sprintf(`#!/bin/bash
 set -e
 
@@ -373,8 +395,8 @@ sleep 5
 echo "Joining Tailscale network via Headscale..."
 echo "Headscale URL: %s"
 echo "Hostname: %s"
-
-sudo tailscale up --login-server=%s --authkey="$AUTH_KEY" --hostname=%s --accept-routes --reset
+%s
+sudo tailscale up --login-server=%s --authkey="$AUTH_KEY" --hostname=%s --accept-routes --reset%s
 
 # Verify connection
 echo "Verifying Tailscale connection..."
@@ -386,11 +408,11 @@ TAILSCALE_IP=$(sudo tailscale ip -4 2>/dev/null || echo "pending")
 echo "Tailscale IP: $TAILSCALE_IP"
 
 echo "=== Tailscale installation complete ==="
-`, host, fetchAuthKeyCmd, url, host, url, host)
+`, host, fetchAuthKeyCmd, url, host, exitNodeSetup, url, host, exitNodeArg)
 		}).(pulumi.StringOutput)
 
 		// Use provider-specific SSH user
-		sshUser := getSSHUserForWireGuard(node.Provider)
+		sshUser := nodeSSHUser(node)
 		sudoPrefix := getSudoPrefixForUser(node.Provider)
 		_ = sudoPrefix // Reserved for future use
 
@@ -423,6 +445,25 @@ echo "=== Tailscale installation complete ==="
 		}
 	}
 
+	// STEP 3: Upload the ACL policy through the Headscale API. Previews skip
+	// it since the Headscale address and key are not known yet.
+	component.ACLPolicy = pulumi.String("").ToStringOutput()
+	if aclPolicy != "" {
+		component.ACLPolicy = pulumi.All(headscaleURL, component.APIKey).ApplyT(func(values []interface{}) (string, error) {
+			if ctx.DryRun() {
+				return "", nil
+			}
+			headscaleMgr := tailscale.NewHeadscaleManager(tailscale.HeadscaleConfig{
+				APIURL: values[0].(string),
+				APIKey: strings.TrimSpace(values[1].(string)),
+			})
+			if err := headscaleMgr.SetPolicy(context.Background(), aclPolicy); err != nil {
+				return "", fmt.Errorf("failed to apply the Tailscale ACL policy: %w", err)
+			}
+			return "applied", nil
+		}).(pulumi.StringOutput)
+	}
+
 	// Set component outputs
 	component.Status = pulumi.Sprintf("Tailscale mesh: %d nodes connected via Headscale", peerCount)
 	component.PeerCount = pulumi.Int(peerCount).ToIntOutput()
@@ -440,6 +481,7 @@ echo "=== Tailscale installation complete ==="
 		"headscaleIp":  component.HeadscaleIP,
 		"apiKey":       component.APIKey,
 		"namespace":    component.Namespace,
+		"aclPolicy":    component.ACLPolicy,
 	}); err != nil {
 		return nil, err
 	}
@@ -531,8 +573,7 @@ dns:
       - 8.8.8.8
 
 policy:
-  mode: file
-  path: ""
+  mode: database
 EOF
 
 # Create data directory
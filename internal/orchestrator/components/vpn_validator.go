@@ -6,6 +6,8 @@ import (
 
 	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 )
 
 // VPNMode represents the type of VPN being used
@@ -20,16 +22,7 @@ const (
 // Azure uses "azureuser", while other providers use "root" or "ubuntu"
 func getSSHUserForVPNValidator(provider pulumi.StringOutput) pulumi.StringOutput {
 	return provider.ApplyT(func(p string) string {
-		switch p {
-		case "azure":
-			return "azureuser"
-		case "aws":
-			return "ubuntu" // AWS Ubuntu AMIs use "ubuntu"
-		case "gcp":
-			return "ubuntu" // GCP uses "ubuntu" for Ubuntu images
-		default:
-			return "root" // DigitalOcean, Linode, and others use "root"
-		}
+		return config.DefaultSSHUser(p)
 	}).(pulumi.StringOutput)
 }
 
@@ -194,7 +187,7 @@ fi
 	firstNode := nodes[0]
 
 	// Determine SSH user based on provider (Azure uses "azureuser", others use "root")
-	firstNodeSSHUser := getSSHUserForVPNValidator(firstNode.Provider)
+	firstNodeSSHUser := nodeSSHUser(firstNode)
 
 	// Collect all IPs and names as pulumi.All inputs
 	var allInputs []interface{}
@@ -270,7 +263,7 @@ func validateTailscaleMesh(ctx *pulumi.Context, name string, component *VPNValid
 
 	// Run validation on first node
 	firstNode := nodes[0]
-	firstNodeSSHUser := getSSHUserForVPNValidator(firstNode.Provider)
+	firstNodeSSHUser := nodeSSHUser(firstNode)
 
 	// Collect all node names for the validation script
 	var nodeNames []interface{}
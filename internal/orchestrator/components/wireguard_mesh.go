@@ -1,26 +1,22 @@
 package components
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 
 	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 )
 
 // getSSHUserForProvider returns the correct SSH username for the given cloud provider
 // Azure uses "azureuser", while other providers use "root" or "ubuntu"
 func getSSHUserForWireGuard(provider pulumi.StringOutput) pulumi.StringOutput {
 	return provider.ApplyT(func(p string) string {
-		switch p {
-		case "azure":
-			return "azureuser"
-		case "aws":
-			return "ubuntu" // AWS Ubuntu AMIs use "ubuntu"
-		case "gcp":
-			return "ubuntu" // GCP uses "ubuntu" for Ubuntu images
-		default:
-			return "root" // DigitalOcean, Linode, and others use "root"
-		}
+		return config.DefaultSSHUser(p)
 	}).(pulumi.StringOutput)
 }
 
@@ -36,6 +32,34 @@ func getSudoPrefixForUser(provider pulumi.StringOutput) pulumi.StringOutput {
 	}).(pulumi.StringOutput)
 }
 
+// MeshPresharedKey derives the WireGuard preshared key for the tunnel between two
+// mesh members. It is keyed by the cluster SSH private key, so it stays stable
+// across deploys and is identical on both ends regardless of argument order.
+func MeshPresharedKey(secret, a, b string) string {
+	if a > b {
+		a, b = b, a
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("sloth-kubernetes/wireguard-psk/" + a + "/" + b))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// presharedKeyLine returns the PresharedKey line for a [Peer] block, or "" when PSKs are disabled
+func presharedKeyLine(usePresharedKeys bool, secret, a, b string) string {
+	if !usePresharedKeys {
+		return ""
+	}
+	return fmt.Sprintf("PresharedKey = %s\n", MeshPresharedKey(secret, a, b))
+}
+
+// keepaliveLine returns the PersistentKeepalive line for a [Peer] block, or "" when keepalive is 0
+func keepaliveLine(keepalive int) string {
+	if keepalive <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("PersistentKeepalive = %d\n", keepalive)
+}
+
 // WireGuardMeshComponent configures full mesh WireGuard VPN
 type WireGuardMeshComponent struct {
 	pulumi.ResourceState
@@ -48,7 +72,9 @@ type WireGuardMeshComponent struct {
 // NewWireGuardMeshComponent sets up WireGuard mesh between nodes
 // This configures a REAL full mesh VPN where every node connects to every other node
 // If bastionComponent is provided, it's added to the mesh with VPN IP 10.8.0.5
-func NewWireGuardMeshComponent(ctx *pulumi.Context, name string, nodes []*RealNodeComponent, sshPrivateKey pulumi.StringOutput, bastionComponent *BastionComponent, opts ...pulumi.ResourceOption) (*WireGuardMeshComponent, error) {
+// If usePresharedKeys is set, every tunnel also gets a per-pair preshared key
+// Every peer gets PersistentKeepalive = keepalive seconds; 0 leaves it out
+func NewWireGuardMeshComponent(ctx *pulumi.Context, name string, nodes []*RealNodeComponent, sshPrivateKey pulumi.StringOutput, bastionComponent *BastionComponent, usePresharedKeys bool, keepalive int, opts ...pulumi.ResourceOption) (*WireGuardMeshComponent, error) {
 	component := &WireGuardMeshComponent{}
 	err := ctx.RegisterComponentResource("kubernetes-create:network:WireGuardMesh", name, component, opts...)
 	if err != nil {
@@ -147,12 +173,12 @@ sudo bash -c 'umask 077 && mkdir -p /etc/wireguard && cd /etc/wireguard && wg ge
 	}
 
 	for i, node := range nodes {
-		wgIP := fmt.Sprintf("10.8.0.%d", 10+i)
+		wgIP := node.wireGuardIP
 
 		// Generate keys on each node
 		// When bastion is present, use ProxyJump to connect through it
 		// Use provider-specific SSH user (azureuser for Azure, root for others)
-		sshUser := getSSHUserForWireGuard(node.Provider)
+		sshUser := nodeSSHUser(node)
 		sudoPrefix := getSudoPrefixForUser(node.Provider)
 
 		connectionArgs := remote.ConnectionArgs{
@@ -235,19 +261,19 @@ cat /etc/wireguard/publickey`
 		for j := 1; j < totalPeers; j++ {
 			peerKeys := allNodeKeys[j]
 
-			peerConfig := pulumi.All(peerKeys.publicKey, peerKeys.publicIP).ApplyT(func(args []interface{}) string {
+			peerConfig := pulumi.All(peerKeys.publicKey, peerKeys.publicIP, sshPrivateKey).ApplyT(func(args []interface{}) string {
 				pubKey := args[0].(string)
 				peerIP := args[1].(string)
+				pskLine := presharedKeyLine(usePresharedKeys, args[2].(string), allNodeKeys[myIdx].name, allNodeKeys[j].name)
 				peerWgIP := allNodeKeys[j].wgIP
 
 				return fmt.Sprintf(`
 [Peer]
 # %s (%s)
 PublicKey = %s
-AllowedIPs = %s/32, 10.0.0.0/8
+%sAllowedIPs = %s/32, 10.0.0.0/8
 Endpoint = %s:51820
-PersistentKeepalive = 25
-`, allNodeKeys[j].name, peerWgIP, pubKey, peerWgIP, peerIP)
+%s`, allNodeKeys[j].name, peerWgIP, pubKey, pskLine, peerWgIP, peerIP, keepaliveLine(keepalive))
 			}).(pulumi.StringOutput)
 
 			peerConfigs = append(peerConfigs, peerConfig)
@@ -361,20 +387,20 @@ echo "✅ WireGuard mesh configured on bastion"
 				peerKeys := allNodeKeys[j]
 
 				// Build peer config section
-				peerConfig := pulumi.All(peerKeys.publicKey, peerKeys.publicIP).ApplyT(func(args []interface{}) string {
+				peerConfig := pulumi.All(peerKeys.publicKey, peerKeys.publicIP, sshPrivateKey).ApplyT(func(args []interface{}) string {
 					pubKey := args[0].(string)
 					peerIP := args[1].(string)
 					peerWgIP := allNodeKeys[j].wgIP
 					peerName := allNodeKeys[j].name
+					pskLine := presharedKeyLine(usePresharedKeys, args[2].(string), allNodeKeys[myIdx].name, peerName)
 
 					return fmt.Sprintf(`
 [Peer]
 # %s (%s)
 PublicKey = %s
-AllowedIPs = %s/32, 10.0.0.0/8
+%sAllowedIPs = %s/32, 10.0.0.0/8
 Endpoint = %s:51820
-PersistentKeepalive = 25
-`, peerName, peerWgIP, pubKey, peerWgIP, peerIP)
+%s`, peerName, peerWgIP, pubKey, pskLine, peerWgIP, peerIP, keepaliveLine(keepalive))
 				}).(pulumi.StringOutput)
 
 				peerConfigs = append(peerConfigs, peerConfig)
@@ -466,7 +492,7 @@ fi
 		// Execute deployment
 		// When bastion is present, use ProxyJump to connect through it
 		// Use provider-specific SSH user (azureuser for Azure, root for others)
-		deploySSHUser := getSSHUserForWireGuard(node.Provider)
+		deploySSHUser := nodeSSHUser(node)
 
 		deployConnectionArgs := remote.ConnectionArgs{
 			Host:           node.PublicIP,
@@ -0,0 +1,225 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/network"
+)
+
+// credential is a provider setting and the environment variable the
+// provider falls back to when the config leaves it empty
+type credential struct {
+	field string
+	value string
+	env   string
+}
+
+// ValidateConfig checks a config before anything is deployed, without a
+// Pulumi context: enabled providers have credentials, the network, pod,
+// service and WireGuard CIDRs are valid and do not overlap, node names are
+// unique, and an HA cluster has an odd number of masters. It returns every
+// problem found, each a typed error, or nil when the config is fine.
+func ValidateConfig(cfg *config.ClusterConfig) []error {
+	var errs []error
+	errs = append(errs, validateCredentials(cfg)...)
+	errs = append(errs, validateCIDRs(cfg)...)
+	errs = append(errs, validateNodeNames(cfg)...)
+	if err := validateQuorum(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// validateCredentials reports the enabled providers missing a credential in
+// both the config and the environment
+func validateCredentials(cfg *config.ClusterConfig) []error {
+	p := cfg.Providers
+	enabled := []struct {
+		name string
+		on   bool
+	}{
+		{"digitalocean", p.DigitalOcean != nil && p.DigitalOcean.Enabled},
+		{"linode", p.Linode != nil && p.Linode.Enabled},
+		{"aws", p.AWS != nil && p.AWS.Enabled},
+		{"azure", p.Azure != nil && p.Azure.Enabled},
+		{"gcp", p.GCP != nil && p.GCP.Enabled},
+		{"hetzner", p.Hetzner != nil && p.Hetzner.Enabled},
+	}
+
+	var errs []error
+	for _, provider := range enabled {
+		if !provider.on {
+			continue
+		}
+		if missing := MissingCredentials(cfg, provider.name); len(missing) > 0 {
+			errs = append(errs, &MissingCredentialsError{Provider: provider.name, Missing: missing})
+		}
+	}
+	return errs
+}
+
+// MissingCredentials returns the credentials the named provider has in
+// neither the config nor the environment, each as "field (or ENV_VAR)". A
+// provider the config does not mention relies on the environment alone.
+func MissingCredentials(cfg *config.ClusterConfig, providerName string) []string {
+	var missing []string
+	for _, c := range providerCredentials(cfg.Providers, providerName) {
+		if c.value == "" && os.Getenv(c.env) == "" {
+			missing = append(missing, fmt.Sprintf("%s (or %s)", c.field, c.env))
+		}
+	}
+	return missing
+}
+
+// providerCredentials returns the credentials the named provider needs with
+// the values the config sets for them
+func providerCredentials(p config.ProvidersConfig, providerName string) []credential {
+	switch providerName {
+	case "digitalocean":
+		do := p.DigitalOcean
+		if do == nil {
+			do = &config.DigitalOceanProvider{}
+		}
+		return []credential{{"token", do.Token, "DIGITALOCEAN_TOKEN"}}
+	case "linode":
+		linode := p.Linode
+		if linode == nil {
+			linode = &config.LinodeProvider{}
+		}
+		return []credential{{"token", linode.Token, "LINODE_TOKEN"}}
+	case "aws":
+		aws := p.AWS
+		if aws == nil {
+			aws = &config.AWSProvider{}
+		}
+		return []credential{
+			{"accessKeyId", aws.AccessKeyID, "AWS_ACCESS_KEY_ID"},
+			{"secretAccessKey", aws.SecretAccessKey, "AWS_SECRET_ACCESS_KEY"},
+		}
+	case "azure":
+		azure := p.Azure
+		if azure == nil {
+			azure = &config.AzureProvider{}
+		}
+		return []credential{
+			{"subscriptionId", azure.SubscriptionID, "ARM_SUBSCRIPTION_ID"},
+			{"tenantId", azure.TenantID, "ARM_TENANT_ID"},
+			{"clientId", azure.ClientID, "ARM_CLIENT_ID"},
+			{"clientSecret", azure.ClientSecret, "ARM_CLIENT_SECRET"},
+		}
+	case "gcp":
+		gcp := p.GCP
+		if gcp == nil {
+			gcp = &config.GCPProvider{}
+		}
+		return []credential{
+			{"projectId", gcp.ProjectID, "GOOGLE_PROJECT"},
+			{"credentials", gcp.Credentials, "GOOGLE_CREDENTIALS"},
+		}
+	case "hetzner":
+		hetzner := p.Hetzner
+		if hetzner == nil {
+			hetzner = &config.HetznerProvider{}
+		}
+		return []credential{{"token", hetzner.Token, "HCLOUD_TOKEN"}}
+	}
+	return nil
+}
+
+// validateCIDRs reports invalid CIDRs and every pair of overlapping ones
+func validateCIDRs(cfg *config.ClusterConfig) []error {
+	type parsedCIDR struct {
+		network.NamedCIDR
+		ipNet *net.IPNet
+	}
+
+	var (
+		errs  []error
+		cidrs []parsedCIDR
+	)
+	for _, c := range network.ClusterCIDRs(cfg) {
+		_, ipNet, err := net.ParseCIDR(c.CIDR)
+		if err != nil {
+			errs = append(errs, &InvalidCIDRError{Name: c.Name, CIDR: c.CIDR})
+			continue
+		}
+		cidrs = append(cidrs, parsedCIDR{c, ipNet})
+	}
+
+	for i := range cidrs {
+		for j := i + 1; j < len(cidrs); j++ {
+			a, b := cidrs[i], cidrs[j]
+			if network.CIDRsOverlap(a.ipNet, b.ipNet) {
+				errs = append(errs, &CIDROverlapError{Name: a.Name, CIDR: a.CIDR, OtherName: b.Name, OtherCIDR: b.CIDR})
+			}
+		}
+	}
+	return errs
+}
+
+// checkCIDRs fails when the cluster's CIDRs are invalid or overlap, which
+// would silently break routing between nodes, pods and services
+func (o *Orchestrator) checkCIDRs() error {
+	if errs := validateCIDRs(o.config); len(errs) > 0 {
+		return fmt.Errorf("CIDR validation failed: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validateNodeNames reports node names used more than once, counting the
+// <pool>-1..<pool>-N names of pool nodes
+func validateNodeNames(cfg *config.ClusterConfig) []error {
+	counts := make(map[string]int)
+	for _, node := range cfg.Nodes {
+		counts[node.Name]++
+	}
+	for poolName, pool := range cfg.NodePools {
+		for i := 1; i <= pool.Count; i++ {
+			counts[fmt.Sprintf("%s-%d", poolName, i)]++
+		}
+	}
+
+	var names []string
+	for name, count := range counts {
+		if count > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, &DuplicateNodeError{Name: name, Count: counts[name]})
+	}
+	return errs
+}
+
+// validateQuorum checks an HA cluster has an odd number of masters, at least
+// three, so etcd keeps quorum when one is lost
+func validateQuorum(cfg *config.ClusterConfig) error {
+	if !cfg.Cluster.HighAvailability {
+		return nil
+	}
+
+	masters := 0
+	for _, node := range cfg.Nodes {
+		if isMasterRole(node.Roles) {
+			masters++
+		}
+	}
+	for _, pool := range cfg.NodePools {
+		if isMasterRole(pool.Roles) {
+			masters += pool.Count
+		}
+	}
+
+	if masters < 3 || masters%2 == 0 {
+		return &QuorumError{Masters: masters}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+// CostEstimate is the expected monthly cost of the configured cluster, in
+// USD, broken down by provider, node pool and standalone node. Unpriced
+// lists the nodes and pools whose size has no known price; when it is not
+// empty the estimate is partial and the totals cover only the rest.
+type CostEstimate struct {
+	MonthlyTotal float64
+	PerProvider  map[string]float64
+	PerPool      map[string]float64
+	PerNode      map[string]float64
+	Unpriced     []string
+}
+
+// Partial reports whether some nodes or pools could not be priced
+func (e *CostEstimate) Partial() bool {
+	return len(e.Unpriced) > 0
+}
+
+// EstimateCost prices every configured node and node pool with its
+// provider's GetPriceForSize. Providers must already be registered. A size
+// missing from the provider's price table leaves the node or pool out of the
+// totals and in Unpriced rather than failing the estimate.
+func (o *Orchestrator) EstimateCost() (*CostEstimate, error) {
+	estimate := &CostEstimate{
+		PerProvider: make(map[string]float64),
+		PerPool:     make(map[string]float64),
+		PerNode:     make(map[string]float64),
+	}
+
+	for i := range o.config.Nodes {
+		node := &o.config.Nodes[i]
+		price, err := o.priceForSize(node.Provider, node.Credentials, node.Size, node.Region)
+		var unknown *providers.UnknownPriceError
+		if errors.As(err, &unknown) {
+			estimate.Unpriced = append(estimate.Unpriced, fmt.Sprintf("node %s (%s)", node.Name, unknown.Size))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to price node %s: %w", node.Name, err)
+		}
+		estimate.PerNode[node.Name] = price
+		estimate.PerProvider[node.Provider] += price
+		estimate.MonthlyTotal += price
+	}
+
+	poolNames := make([]string, 0, len(o.config.NodePools))
+	for name := range o.config.NodePools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+
+	for _, name := range poolNames {
+		pool := o.config.NodePools[name]
+		cost, err := o.poolCost(&pool)
+		var unknown *providers.UnknownPriceError
+		if errors.As(err, &unknown) {
+			estimate.Unpriced = append(estimate.Unpriced, fmt.Sprintf("node pool %s (%s)", name, unknown.Size))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to price node pool %s: %w", name, err)
+		}
+		estimate.PerPool[name] = cost
+		estimate.PerProvider[pool.Provider] += cost
+		estimate.MonthlyTotal += cost
+	}
+
+	return estimate, nil
+}
+
+// poolCost prices each node of a pool in the region it is placed in, as
+// pools with Regions spread their nodes round-robin
+func (o *Orchestrator) poolCost(pool *config.NodePool) (float64, error) {
+	var cost float64
+	for i := 0; i < pool.Count; i++ {
+		price, err := o.priceForSize(pool.Provider, pool.Credentials, pool.Size, pool.RegionFor(i))
+		if err != nil {
+			return 0, err
+		}
+		cost += price
+	}
+	return cost, nil
+}
+
+// priceForSize returns the monthly price of one node from the provider
+// instance that creates it, including one scoped to a credentials override,
+// falling back to the provider's default size and region like
+// deployNodePool does
+func (o *Orchestrator) priceForSize(providerName string, creds *config.ProviderCredentials, size, region string) (float64, error) {
+	provider, _, err := o.providerFor(providerName, creds)
+	if err != nil {
+		return 0, err
+	}
+
+	defaultRegion, defaultSize := o.config.ProviderDefaults(providerName)
+	if size == "" {
+		size = defaultSize
+	}
+	if region == "" {
+		region = defaultRegion
+	}
+	return provider.GetPriceForSize(size, region)
+}
+
+// checkCostBudget estimates the cluster cost when CostControl.Estimate is set
+// and refuses to deploy above CostControl.MonthlyBudget unless forced. A
+// partial estimate is only refused when the cost it does know is already
+// over the budget.
+func (o *Orchestrator) checkCostBudget() error {
+	costControl := o.config.CostControl
+	if costControl == nil || !costControl.Estimate {
+		return nil
+	}
+
+	estimate, err := o.EstimateCost()
+	if err != nil {
+		return err
+	}
+
+	if estimate.Partial() {
+		o.log.Warn(fmt.Sprintf("Cost estimate is partial, no price known for %s", strings.Join(estimate.Unpriced, ", ")))
+		o.log.Info(fmt.Sprintf("Estimated monthly cost: at least $%.2f", estimate.MonthlyTotal))
+	} else {
+		o.log.Info(fmt.Sprintf("Estimated monthly cost: $%.2f", estimate.MonthlyTotal))
+	}
+	for _, provider := range sortedCostKeys(estimate.PerProvider) {
+		o.log.Info(fmt.Sprintf("  %s: $%.2f", provider, estimate.PerProvider[provider]))
+	}
+
+	if err := estimate.CheckBudget(costControl); err != nil {
+		if !o.force {
+			return err
+		}
+		o.log.Warn(fmt.Sprintf("%v; deploying anyway because force is set", err))
+		return nil
+	}
+	if estimate.NearBudget(costControl) {
+		o.log.Warn(fmt.Sprintf("Estimated monthly cost $%.2f is over %d%% of the $%.2f budget",
+			estimate.MonthlyTotal, costControl.AlertThreshold, costControl.MonthlyBudget))
+	}
+	return nil
+}
+
+// CheckBudget returns a *BudgetExceededError when the estimate is above the
+// monthly budget, and nil when no budget is set
+func (e *CostEstimate) CheckBudget(costControl *config.CostControlConfig) error {
+	if costControl == nil || costControl.MonthlyBudget <= 0 || e.MonthlyTotal <= costControl.MonthlyBudget {
+		return nil
+	}
+	return &BudgetExceededError{Estimate: e.MonthlyTotal, Budget: costControl.MonthlyBudget, Partial: e.Partial()}
+}
+
+// NearBudget reports whether the estimate reaches the budget's
+// AlertThreshold percentage
+func (e *CostEstimate) NearBudget(costControl *config.CostControlConfig) bool {
+	if costControl == nil || costControl.MonthlyBudget <= 0 || costControl.AlertThreshold <= 0 {
+		return false
+	}
+	return e.MonthlyTotal >= costControl.MonthlyBudget*float64(costControl.AlertThreshold)/100
+}
+
+// EstimateConfigCost prices the nodes and pools of cfg outside a Pulumi run,
+// for the CLI to check the budget before it deploys. Prices come from each
+// provider's price table, so providers are not initialized.
+func EstimateConfigCost(cfg *config.ClusterConfig) (*CostEstimate, error) {
+	o := NewWithOptions(nil, cfg, Options{Logger: logging.NewJSONLogger(io.Discard, logging.LevelError)})
+
+	register := func(providerName string, creds *config.ProviderCredentials) error {
+		key := providerKey(providerName, creds)
+		if _, ok := o.providerRegistry.Get(key); ok {
+			return nil
+		}
+		provider, err := o.newProvider(providerName)
+		if err != nil {
+			return err
+		}
+		o.providerRegistry.Register(key, provider)
+		return nil
+	}
+	for _, node := range cfg.Nodes {
+		if err := register(node.Provider, node.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	for _, pool := range cfg.NodePools {
+		if err := register(pool.Provider, pool.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	return o.EstimateCost()
+}
+
+func sortedCostKeys(costs map[string]float64) []string {
+	keys := make([]string, 0, len(costs))
+	for key := range costs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+)
+
+// defaultDrainTimeout bounds a node drain when Upgrade.DrainTimeout is unset
+const defaultDrainTimeout = 5 * time.Minute
+
+// nodeDrainer cordons and drains a node out of the cluster, or only cordons
+// and drains it for maintenance and uncordons it after; the RKE and RKE2
+// managers implement it
+type nodeDrainer interface {
+	DrainNode(name string, timeout time.Duration) error
+	CordonAndDrainNode(name string, timeout time.Duration) error
+	UncordonNode(name string) error
+}
+
+// CleanupReport summarizes a cleanup. Drained lists the nodes drained before
+// their providers cleaned up; nodes that could not be drained are in
+// DrainFailures and were cleaned up anyway. ProviderErrors holds each
+// provider whose Cleanup failed.
+type CleanupReport struct {
+	Drained        []string
+	DrainFailures  map[string]error
+	ProviderErrors map[string]error
+}
+
+// drainTimeout returns Upgrade.DrainTimeout, or the default when unset
+func (o *Orchestrator) drainTimeout() time.Duration {
+	if o.config.Upgrade != nil && o.config.Upgrade.DrainTimeout > 0 {
+		return time.Duration(o.config.Upgrade.DrainTimeout) * time.Second
+	}
+	return defaultDrainTimeout
+}
+
+// drainNode drains a node when the cluster has a Kubernetes manager. A
+// failure is logged and returned, but callers go on to destroy the node.
+func (o *Orchestrator) drainNode(name string) error {
+	if o.drainer == nil {
+		return nil
+	}
+	if err := o.drainer.DrainNode(name, o.drainTimeout()); err != nil {
+		o.log.Warn(fmt.Sprintf("Failed to drain node %s, removing it anyway: %v", name, err), logging.F(logging.FieldNode, name))
+		return err
+	}
+	return nil
+}
+
+// CleanupWithReport runs Cleanup and reports what it did. When an RKE or
+// RKE2 manager is present, every node is cordoned and drained first,
+// workers before masters, so workloads are not stranded on destroyed nodes.
+func (o *Orchestrator) CleanupWithReport() *CleanupReport {
+	o.log.Info("Performing cleanup operations")
+
+	report := &CleanupReport{
+		DrainFailures:  make(map[string]error),
+		ProviderErrors: make(map[string]error),
+	}
+
+	if o.drainer != nil {
+		for _, name := range o.drainOrder() {
+			if err := o.drainNode(name); err != nil {
+				report.DrainFailures[name] = err
+				continue
+			}
+			report.Drained = append(report.Drained, name)
+		}
+	}
+
+	for key, provider := range o.providerRegistry.GetAll() {
+		if err := provider.Cleanup(o.ctx); err != nil {
+			o.log.Warn(fmt.Sprintf("Cleanup failed for provider %s: %v", key, err), logging.F(logging.FieldProvider, key))
+			report.ProviderErrors[key] = err
+		}
+	}
+
+	return report
+}
+
+// drainOrder lists every node by name, workers first and masters last so the
+// control plane stays up while workloads move
+func (o *Orchestrator) drainOrder() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var workers, masters []string
+	for _, nodes := range o.nodes {
+		for _, node := range nodes {
+			if hasMasterRole(nodeRoles(node)) {
+				masters = append(masters, node.Name)
+			} else {
+				workers = append(workers, node.Name)
+			}
+		}
+	}
+	sort.Strings(workers)
+	sort.Strings(masters)
+	return append(workers, masters...)
+}
@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNothingToDeploy is returned when the config has no nodes and no node
+// pool with a count, unless AllowEmptyCluster is set
+var ErrNothingToDeploy = errors.New("nothing to deploy: the config has no nodes and no node pool with a count (set allowEmptyCluster to deploy an empty cluster)")
+
+// ProviderNotFoundError is returned when a node, pool or load balancer refers
+// to a provider that has not been registered
+type ProviderNotFoundError struct {
+	Provider string
+}
+
+func (e *ProviderNotFoundError) Error() string {
+	return fmt.Sprintf("provider %s not found", e.Provider)
+}
+
+// NodeNotFoundError is returned when no deployed node has the requested name
+type NodeNotFoundError struct {
+	Name string
+}
+
+func (e *NodeNotFoundError) Error() string {
+	return fmt.Sprintf("node %s not found", e.Name)
+}
+
+// BudgetExceededError is returned when the estimated monthly cost of the
+// cluster is above CostControl.MonthlyBudget and the deploy is not forced.
+// Partial is set when some sizes could not be priced, so the cost is at
+// least Estimate.
+type BudgetExceededError struct {
+	Estimate float64
+	Budget   float64
+	Partial  bool
+}
+
+func (e *BudgetExceededError) Error() string {
+	if e.Partial {
+		return fmt.Sprintf("estimated monthly cost of at least $%.2f exceeds the monthly budget of $%.2f", e.Estimate, e.Budget)
+	}
+	return fmt.Sprintf("estimated monthly cost $%.2f exceeds the monthly budget of $%.2f", e.Estimate, e.Budget)
+}
+
+// PlacementError is returned before a deploy when nodes or pools ask for a
+// region or size their provider does not offer. It names every invalid
+// placement, not just the first.
+type PlacementError struct {
+	Invalid []InvalidPlacement
+}
+
+func (e *PlacementError) Error() string {
+	lines := make([]string, len(e.Invalid))
+	for i, placement := range e.Invalid {
+		lines[i] = placement.String()
+	}
+	return fmt.Sprintf("%d invalid placement(s): %s", len(e.Invalid), strings.Join(lines, "; "))
+}
+
+// MissingCredentialsError is returned by ValidateConfig when an enabled
+// provider lacks credentials in both the config and the environment
+type MissingCredentialsError struct {
+	Provider string
+	Missing  []string // e.g. "token (or DIGITALOCEAN_TOKEN)"
+}
+
+func (e *MissingCredentialsError) Error() string {
+	return fmt.Sprintf("provider %s is missing credentials: %s", e.Provider, strings.Join(e.Missing, ", "))
+}
+
+// InvalidCIDRError is returned by ValidateConfig for a CIDR that does not
+// parse
+type InvalidCIDRError struct {
+	Name string // "pod CIDR", "WireGuard subnet", ...
+	CIDR string
+}
+
+func (e *InvalidCIDRError) Error() string {
+	return fmt.Sprintf("%s %q is not a valid CIDR", e.Name, e.CIDR)
+}
+
+// CIDROverlapError is returned by ValidateConfig when two of the network,
+// pod, service and WireGuard CIDRs overlap
+type CIDROverlapError struct {
+	Name      string
+	CIDR      string
+	OtherName string
+	OtherCIDR string
+}
+
+func (e *CIDROverlapError) Error() string {
+	return fmt.Sprintf("%s %s overlaps %s %s", e.Name, e.CIDR, e.OtherName, e.OtherCIDR)
+}
+
+// DuplicateNodeError is returned by ValidateConfig when nodes, including the
+// nodes pools expand to, share a name
+type DuplicateNodeError struct {
+	Name  string
+	Count int
+}
+
+func (e *DuplicateNodeError) Error() string {
+	return fmt.Sprintf("node name %s is used %d times", e.Name, e.Count)
+}
+
+// QuorumError is returned by ValidateConfig when a high availability
+// cluster does not have an odd number of at least three masters
+type QuorumError struct {
+	Masters int
+}
+
+func (e *QuorumError) Error() string {
+	return fmt.Sprintf("high availability needs an odd number of at least 3 masters for etcd quorum, got %d", e.Masters)
+}
+
+// SubnetExhaustedError is returned when the WireGuard subnet has no free
+// address left for a node
+type SubnetExhaustedError struct {
+	CIDR  string
+	Count int // Host addresses the subnet has for nodes
+}
+
+func (e *SubnetExhaustedError) Error() string {
+	return fmt.Sprintf("WireGuard subnet %s is exhausted: all %d node addresses are assigned", e.CIDR, e.Count)
+}
+
+// ProviderCleanupError is returned by CleanupStrict for each provider whose
+// Cleanup failed, possibly leaving resources behind
+type ProviderCleanupError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderCleanupError) Error() string {
+	return fmt.Sprintf("cleanup failed for provider %s: %v", e.Provider, e.Err)
+}
+
+func (e *ProviderCleanupError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,269 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Inventory formats ExportInventory writes
+const (
+	InventoryJSON       = "json"
+	InventoryAnsibleINI = "ansible-ini"
+	InventorySSHConfig  = "ssh-config"
+)
+
+// InventoryFormats are the formats ExportInventory accepts
+var InventoryFormats = []string{InventoryJSON, InventoryAnsibleINI, InventorySSHConfig}
+
+// defaultSSHUser is the login of nodes whose provider did not set one
+const defaultSSHUser = "root"
+
+// Inventory lists the cluster's nodes and, for private clusters, the bastion
+// they are reached through
+type Inventory struct {
+	Cluster string          `json:"cluster"`
+	Nodes   []InventoryNode `json:"nodes"`
+	Bastion *InventoryNode  `json:"bastion,omitempty"`
+}
+
+// InventoryNode is a node as ExportInventory lists it
+type InventoryNode struct {
+	Name       string   `json:"name"`
+	Roles      []string `json:"roles"`
+	Provider   string   `json:"provider"`
+	Region     string   `json:"region,omitempty"`
+	PublicIP   string   `json:"public_ip,omitempty"`
+	PrivateIP  string   `json:"private_ip,omitempty"`
+	VPNIP      string   `json:"vpn_ip,omitempty"`
+	SSHUser    string   `json:"ssh_user"`
+	SSHPort    int      `json:"ssh_port,omitempty"`
+	SSHKeyPath string   `json:"ssh_key_path,omitempty"`
+}
+
+// ExportInventory writes the deployed nodes to path as a JSON listing, an
+// Ansible INI inventory or an SSH config. The file is written once the
+// nodes' addresses are known; the returned output resolves to path then,
+// and a write error fails the deployment. Call it after Deploy.
+func (o *Orchestrator) ExportInventory(format, path string) (pulumi.StringOutput, error) {
+	render, ok := map[string]func(*Inventory) string{
+		InventoryJSON:       renderInventoryJSON,
+		InventoryAnsibleINI: renderAnsibleInventory,
+		InventorySSHConfig:  renderSSHConfig,
+	}[format]
+	if !ok {
+		return pulumi.StringOutput{}, fmt.Errorf("unknown inventory format %q, must be one of %s", format, strings.Join(InventoryFormats, ", "))
+	}
+
+	o.mu.RLock()
+	var nodes []*providers.NodeOutput
+	for _, keyNodes := range o.nodes {
+		nodes = append(nodes, keyNodes...)
+	}
+	o.mu.RUnlock()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	if o.bastion != nil {
+		nodes = append(nodes, o.bastion)
+	}
+
+	// The public and private IP of each node, in order
+	ips := make([]interface{}, 0, 2*len(nodes))
+	for _, node := range nodes {
+		ips = append(ips, knownOrEmpty(node.PublicIP), knownOrEmpty(node.PrivateIP))
+	}
+
+	return pulumi.All(ips...).ApplyT(func(values []interface{}) (string, error) {
+		inventory := &Inventory{Cluster: o.clusterName()}
+		for i, node := range nodes {
+			entry := o.inventoryNode(node, values[2*i].(string), values[2*i+1].(string))
+			if node == o.bastion {
+				inventory.Bastion = &entry
+				continue
+			}
+			inventory.Nodes = append(inventory.Nodes, entry)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return "", fmt.Errorf("failed to create inventory directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(render(inventory)), 0600); err != nil {
+			return "", fmt.Errorf("failed to write %s inventory: %w", format, err)
+		}
+		return path, nil
+	}).(pulumi.StringOutput), nil
+}
+
+// knownOrEmpty returns out, or an empty string for the unset outputs of
+// dry-run nodes
+func knownOrEmpty(out pulumi.StringOutput) pulumi.StringOutput {
+	if out.OutputState == nil {
+		return pulumi.String("").ToStringOutput()
+	}
+	return out
+}
+
+// inventoryNode describes a node with its resolved addresses
+func (o *Orchestrator) inventoryNode(node *providers.NodeOutput, publicIP, privateIP string) InventoryNode {
+	entry := InventoryNode{
+		Name:       node.Name,
+		Roles:      nodeRoles(node),
+		Provider:   node.Provider,
+		Region:     node.Region,
+		PublicIP:   publicIP,
+		PrivateIP:  privateIP,
+		VPNIP:      node.WireGuardIP,
+		SSHUser:    node.SSHUser,
+		SSHKeyPath: node.SSHKeyPath,
+	}
+	if entry.SSHUser == "" {
+		entry.SSHUser = defaultSSHUser
+	}
+	if entry.SSHKeyPath == "" {
+		entry.SSHKeyPath = o.config.Security.SSHConfig.KeyPath
+	}
+	if node == o.bastion {
+		entry.Roles = []string{"bastion"}
+		if b := o.bastionConfig(); b != nil {
+			entry.SSHPort = b.SSHPort
+		}
+	}
+	return entry
+}
+
+// sshHost is the address a node is reached at: its private address through
+// the bastion, otherwise its public one, falling back to the VPN address
+func sshHost(node InventoryNode, viaBastion bool) string {
+	candidates := []string{node.PublicIP, node.PrivateIP, node.VPNIP}
+	if viaBastion {
+		candidates = []string{node.PrivateIP, node.VPNIP, node.PublicIP}
+	}
+	for _, host := range candidates {
+		if host != "" {
+			return host
+		}
+	}
+	return node.Name
+}
+
+// renderInventoryJSON renders the inventory as indented JSON
+func renderInventoryJSON(inventory *Inventory) string {
+	data, _ := json.MarshalIndent(inventory, "", "  ") // plain structs always marshal
+	return string(data) + "\n"
+}
+
+// renderAnsibleInventory renders an Ansible INI inventory with a group per
+// role. Nodes behind a bastion are reached through it with ProxyJump.
+func renderAnsibleInventory(inventory *Inventory) string {
+	groups := make(map[string][]InventoryNode)
+	var ungrouped []InventoryNode
+	for _, node := range inventory.Nodes {
+		if len(node.Roles) == 0 {
+			ungrouped = append(ungrouped, node)
+		}
+		for _, role := range node.Roles {
+			// Ansible group names cannot contain dashes
+			group := strings.ReplaceAll(role, "-", "_")
+			groups[group] = append(groups[group], node)
+		}
+	}
+
+	viaBastion := inventory.Bastion != nil
+	host := func(node InventoryNode) string {
+		line := fmt.Sprintf("%s ansible_host=%s ansible_user=%s", node.Name, sshHost(node, viaBastion), node.SSHUser)
+		if node.PrivateIP != "" {
+			line += " private_ip=" + node.PrivateIP
+		}
+		if node.VPNIP != "" {
+			line += " vpn_ip=" + node.VPNIP
+		}
+		return line + " provider=" + node.Provider + "\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Ansible inventory for cluster %s, generated by sloth-kubernetes\n", inventory.Cluster)
+	for _, node := range ungrouped {
+		b.WriteString(host(node))
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n[%s]\n", name)
+		for _, node := range groups[name] {
+			b.WriteString(host(node))
+		}
+	}
+
+	var vars []string
+	if key := sharedKeyPath(inventory.Nodes); key != "" {
+		vars = append(vars, "ansible_ssh_private_key_file="+key)
+	}
+	if bastion := inventory.Bastion; bastion != nil {
+		jump := fmt.Sprintf("%s@%s", bastion.SSHUser, sshHost(*bastion, false))
+		if bastion.SSHPort != 0 && bastion.SSHPort != 22 {
+			jump += fmt.Sprintf(":%d", bastion.SSHPort)
+		}
+		vars = append(vars, fmt.Sprintf("ansible_ssh_common_args='-o ProxyJump=%s'", jump))
+	}
+	if len(vars) > 0 {
+		b.WriteString("\n[all:vars]\n")
+		for _, v := range vars {
+			b.WriteString(v + "\n")
+		}
+	}
+	return b.String()
+}
+
+// sharedKeyPath returns the SSH key path when every node uses the same one
+func sharedKeyPath(nodes []InventoryNode) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	for _, node := range nodes[1:] {
+		if node.SSHKeyPath != nodes[0].SSHKeyPath {
+			return ""
+		}
+	}
+	return nodes[0].SSHKeyPath
+}
+
+// renderSSHConfig renders an SSH config with a Host entry per node, so that
+// `ssh -F <file> <node>` connects, jumping through the bastion when there is one
+func renderSSHConfig(inventory *Inventory) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# SSH config for cluster %s, generated by sloth-kubernetes\n", inventory.Cluster)
+
+	entry := func(node InventoryNode, host, proxyJump string) {
+		fmt.Fprintf(&b, "\nHost %s\n", node.Name)
+		fmt.Fprintf(&b, "  HostName %s\n", host)
+		fmt.Fprintf(&b, "  User %s\n", node.SSHUser)
+		if node.SSHPort != 0 && node.SSHPort != 22 {
+			fmt.Fprintf(&b, "  Port %d\n", node.SSHPort)
+		}
+		if node.SSHKeyPath != "" {
+			fmt.Fprintf(&b, "  IdentityFile %s\n", node.SSHKeyPath)
+		}
+		if proxyJump != "" {
+			fmt.Fprintf(&b, "  ProxyJump %s\n", proxyJump)
+		}
+	}
+
+	proxyJump := ""
+	if bastion := inventory.Bastion; bastion != nil {
+		entry(*bastion, sshHost(*bastion, false), "")
+		proxyJump = bastion.Name
+	}
+	for _, node := range inventory.Nodes {
+		entry(node, sshHost(node, proxyJump != ""), proxyJump)
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+func testInventory(withBastion bool) *Inventory {
+	inventory := &Inventory{
+		Cluster: "prod",
+		Nodes: []InventoryNode{
+			{Name: "master-1", Roles: []string{"master", "control-plane"}, Provider: "aws", PublicIP: "3.0.0.1", PrivateIP: "10.0.1.10", VPNIP: "10.8.0.2", SSHUser: "ubuntu", SSHKeyPath: "~/.ssh/prod"},
+			{Name: "worker-1", Roles: []string{"worker"}, Provider: "aws", PrivateIP: "10.0.1.11", VPNIP: "10.8.0.3", SSHUser: "ubuntu", SSHKeyPath: "~/.ssh/prod"},
+		},
+	}
+	if withBastion {
+		inventory.Bastion = &InventoryNode{Name: "prod-bastion", Roles: []string{"bastion"}, Provider: "aws", PublicIP: "3.0.0.9", SSHUser: "root", SSHPort: 2222, SSHKeyPath: "~/.ssh/prod"}
+	}
+	return inventory
+}
+
+func TestRenderSSHConfig(t *testing.T) {
+	assert.Equal(t, `# SSH config for cluster prod, generated by sloth-kubernetes
+
+Host prod-bastion
+  HostName 3.0.0.9
+  User root
+  Port 2222
+  IdentityFile ~/.ssh/prod
+
+Host master-1
+  HostName 10.0.1.10
+  User ubuntu
+  IdentityFile ~/.ssh/prod
+  ProxyJump prod-bastion
+
+Host worker-1
+  HostName 10.0.1.11
+  User ubuntu
+  IdentityFile ~/.ssh/prod
+  ProxyJump prod-bastion
+`, renderSSHConfig(testInventory(true)))
+
+	// Without a bastion nodes are reached directly, falling back to the
+	// private address when they have no public one
+	assert.Equal(t, `# SSH config for cluster prod, generated by sloth-kubernetes
+
+Host master-1
+  HostName 3.0.0.1
+  User ubuntu
+  IdentityFile ~/.ssh/prod
+
+Host worker-1
+  HostName 10.0.1.11
+  User ubuntu
+  IdentityFile ~/.ssh/prod
+`, renderSSHConfig(testInventory(false)))
+}
+
+func TestRenderAnsibleInventory(t *testing.T) {
+	assert.Equal(t, `# Ansible inventory for cluster prod, generated by sloth-kubernetes
+
+[control_plane]
+master-1 ansible_host=10.0.1.10 ansible_user=ubuntu private_ip=10.0.1.10 vpn_ip=10.8.0.2 provider=aws
+
+[master]
+master-1 ansible_host=10.0.1.10 ansible_user=ubuntu private_ip=10.0.1.10 vpn_ip=10.8.0.2 provider=aws
+
+[worker]
+worker-1 ansible_host=10.0.1.11 ansible_user=ubuntu private_ip=10.0.1.11 vpn_ip=10.8.0.3 provider=aws
+
+[all:vars]
+ansible_ssh_private_key_file=~/.ssh/prod
+ansible_ssh_common_args='-o ProxyJump=root@3.0.0.9:2222'
+`, renderAnsibleInventory(testInventory(true)))
+
+	inventory := testInventory(false)
+	inventory.Nodes[1].SSHKeyPath = "~/.ssh/other"
+	inventory.Nodes = append(inventory.Nodes, InventoryNode{Name: "spare", Provider: "aws", PublicIP: "3.0.0.5", SSHUser: "root"})
+	rendered := renderAnsibleInventory(inventory)
+	assert.Contains(t, rendered, "generated by sloth-kubernetes\nspare ansible_host=3.0.0.5 ansible_user=root provider=aws\n", "roleless nodes are ungrouped")
+	assert.Contains(t, rendered, "master-1 ansible_host=3.0.0.1 ")
+	assert.NotContains(t, rendered, "[all:vars]", "no shared key and no bastion")
+}
+
+func TestExportInventory(t *testing.T) {
+	dir := t.TempDir()
+	paths := make(chan string, len(InventoryFormats))
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		orch := New(ctx, &config.ClusterConfig{
+			Metadata: config.Metadata{Name: "prod"},
+			Security: config.SecurityConfig{
+				SSHConfig: config.SSHConfig{KeyPath: "~/.ssh/prod"},
+				Bastion:   &config.BastionConfig{Enabled: true, SSHPort: 2222},
+			},
+		})
+		orch.nodes["aws"] = []*providers.NodeOutput{
+			{Name: "worker-1", Provider: "aws", Labels: map[string]string{"role": "worker"},
+				PublicIP: pulumi.String("").ToStringOutput(), PrivateIP: pulumi.String("10.0.1.11").ToStringOutput(), WireGuardIP: "10.8.0.3"},
+			{Name: "master-1", Provider: "aws", Labels: map[string]string{"role": "master"}, SSHUser: "ubuntu",
+				PublicIP: pulumi.String("3.0.0.1").ToStringOutput(), PrivateIP: pulumi.String("10.0.1.10").ToStringOutput(), WireGuardIP: "10.8.0.2"},
+		}
+		orch.nodes["gcp"] = []*providers.NodeOutput{{Name: "dry-1", Provider: "gcp", DryRun: true}}
+		orch.bastion = &providers.NodeOutput{Name: "prod-bastion", Provider: "aws",
+			PublicIP: pulumi.String("3.0.0.9").ToStringOutput(), PrivateIP: pulumi.String("10.0.1.2").ToStringOutput()}
+
+		_, err := orch.ExportInventory("yaml", filepath.Join(dir, "inventory.yaml"))
+		assert.EqualError(t, err, `unknown inventory format "yaml", must be one of json, ansible-ini, ssh-config`)
+
+		for _, format := range InventoryFormats {
+			out, err := orch.ExportInventory(format, filepath.Join(dir, "out", format))
+			require.NoError(t, err)
+			out.ApplyT(func(path string) string {
+				paths <- path
+				return path
+			})
+		}
+		return nil
+	}, pulumi.WithMocks("test", "stack", &StubComponentMock{}))
+	require.NoError(t, err)
+
+	for range InventoryFormats {
+		select {
+		case <-paths:
+		case <-time.After(10 * time.Second):
+			t.Fatal("inventory was not written")
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", InventoryJSON))
+	require.NoError(t, err)
+	var inventory Inventory
+	require.NoError(t, json.Unmarshal(data, &inventory))
+	assert.Equal(t, "prod", inventory.Cluster)
+	require.Len(t, inventory.Nodes, 3)
+	assert.Equal(t, []string{"dry-1", "master-1", "worker-1"}, []string{inventory.Nodes[0].Name, inventory.Nodes[1].Name, inventory.Nodes[2].Name})
+	assert.Equal(t, InventoryNode{
+		Name: "master-1", Roles: []string{"master"}, Provider: "aws", PublicIP: "3.0.0.1", PrivateIP: "10.0.1.10",
+		VPNIP: "10.8.0.2", SSHUser: "ubuntu", SSHKeyPath: "~/.ssh/prod",
+	}, inventory.Nodes[1])
+	assert.Equal(t, "root", inventory.Nodes[2].SSHUser)
+	require.NotNil(t, inventory.Bastion)
+	assert.Equal(t, 2222, inventory.Bastion.SSHPort)
+
+	sshConfig, err := os.ReadFile(filepath.Join(dir, "out", InventorySSHConfig))
+	require.NoError(t, err)
+	assert.Contains(t, string(sshConfig), "Host worker-1\n  HostName 10.0.1.11\n  User root\n  IdentityFile ~/.ssh/prod\n  ProxyJump prod-bastion\n")
+
+	info, err := os.Stat(filepath.Join(dir, "out", InventoryAnsibleINI))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -0,0 +1,281 @@
+package orchestrator
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"sync"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// defaultWireGuardSubnet is the VPN subnet when the config leaves it unset
+const defaultWireGuardSubnet = "10.8.0.0/24"
+
+// wireGuardIPAM hands out WireGuard addresses from the VPN subnet. The first
+// host address is reserved for the gateway. Nodes keep the address they
+// were given, so asking again for the same node returns the same address.
+type wireGuardIPAM struct {
+	mu     sync.Mutex
+	prefix netip.Prefix
+	byNode map[string]netip.Addr
+	byAddr map[netip.Addr]string
+}
+
+// newWireGuardIPAM creates an allocator for the given subnet
+func newWireGuardIPAM(subnet string) (*wireGuardIPAM, error) {
+	prefix, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WireGuard subnet %q: %w", subnet, err)
+	}
+	if !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("WireGuard subnet %s is not an IPv4 subnet", subnet)
+	}
+	return &wireGuardIPAM{
+		prefix: prefix.Masked(),
+		byNode: make(map[string]netip.Addr),
+		byAddr: make(map[netip.Addr]string),
+	}, nil
+}
+
+// gateway returns the address reserved for the WireGuard gateway
+func (a *wireGuardIPAM) gateway() netip.Addr {
+	return a.prefix.Addr().Next()
+}
+
+// hosts returns how many addresses the allocator can hand out: the subnet
+// without its network, gateway and broadcast addresses
+func (a *wireGuardIPAM) hosts() int {
+	hostBits := 32 - a.prefix.Bits()
+	if hostBits > 30 {
+		hostBits = 30 // no subnet is large enough for this to matter
+	}
+	if n := 1<<hostBits - 3; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// usable reports whether addr can be given to a node
+func (a *wireGuardIPAM) usable(addr netip.Addr) bool {
+	if !a.prefix.Contains(addr) || addr == a.prefix.Addr() || addr == a.gateway() {
+		return false
+	}
+	return a.prefix.Contains(addr.Next()) // the last address is the broadcast
+}
+
+// reserve assigns ip to a node, failing when it is outside the subnet, the
+// gateway's, or another node's
+func (a *wireGuardIPAM) reserve(name, ip string) error {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return fmt.Errorf("node %s has an invalid WireGuard IP %q", name, ip)
+	}
+	if !a.usable(addr) {
+		return fmt.Errorf("WireGuard IP %s of node %s is not a host address of subnet %s other than the gateway %s", ip, name, a.prefix, a.gateway())
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if owner, ok := a.byAddr[addr]; ok && owner != name {
+		return fmt.Errorf("WireGuard IP %s of node %s is already assigned to node %s", ip, name, owner)
+	}
+	if previous, ok := a.byNode[name]; ok && previous != addr {
+		delete(a.byAddr, previous)
+	}
+	a.byNode[name] = addr
+	a.byAddr[addr] = name
+	return nil
+}
+
+// assign returns the node's address, allocating the lowest free one the
+// first time it is asked for
+func (a *wireGuardIPAM) assign(name string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if addr, ok := a.byNode[name]; ok {
+		return addr.String(), nil
+	}
+	for addr := a.gateway().Next(); a.usable(addr); addr = addr.Next() {
+		if _, taken := a.byAddr[addr]; !taken {
+			a.byNode[name] = addr
+			a.byAddr[addr] = name
+			return addr.String(), nil
+		}
+	}
+	return "", &SubnetExhaustedError{CIDR: a.prefix.String(), Count: a.hosts()}
+}
+
+// allocations returns each node's address, keyed by node name
+func (a *wireGuardIPAM) allocations() map[string]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	allocations := make(map[string]string, len(a.byNode))
+	for name, addr := range a.byNode {
+		allocations[name] = addr.String()
+	}
+	return allocations
+}
+
+// wireGuardSubnet returns the configured WireGuard subnet or the default
+func wireGuardSubnet(cfg *config.ClusterConfig) string {
+	if wg := cfg.Network.WireGuard; wg != nil && wg.SubnetCIDR != "" {
+		return wg.SubnetCIDR
+	}
+	return defaultWireGuardSubnet
+}
+
+// newClusterWireGuardIPAM creates the allocator of a cluster's subnet. The
+// static addresses are reserved first; then the allocations of the previous
+// deployment are restored so re-deploys keep each node's address, except
+// those now taken by a static IP, which are dropped with a warning.
+func newClusterWireGuardIPAM(subnet string, static, previous map[string]string, warn func(string)) (*wireGuardIPAM, error) {
+	ipam, err := newWireGuardIPAM(subnet)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedNames(static) {
+		if err := ipam.reserve(name, static[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedNames(previous) {
+		if _, ok := static[name]; ok {
+			continue
+		}
+		if err := ipam.reserve(name, previous[name]); err != nil {
+			warn(fmt.Sprintf("Dropping previous WireGuard allocation: %v", err))
+		}
+	}
+	return ipam, nil
+}
+
+// staticWireGuardIPs returns the WireGuard IPs set in the config's nodes,
+// keyed by node name
+func staticWireGuardIPs(cfg *config.ClusterConfig) map[string]string {
+	static := make(map[string]string)
+	for _, node := range cfg.Nodes {
+		if node.WireGuardIP != "" {
+			static[node.Name] = node.WireGuardIP
+		}
+	}
+	return static
+}
+
+func sortedNames(ips map[string]string) []string {
+	names := make([]string, 0, len(ips))
+	for name := range ips {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// wireGuardIPs returns the orchestrator's WireGuard allocator, creating it
+// on first use, or nil when WireGuard is disabled (see newClusterWireGuardIPAM)
+func (o *Orchestrator) wireGuardIPs() (*wireGuardIPAM, error) {
+	o.ipamOnce.Do(func() {
+		wg := o.config.Network.WireGuard
+		if wg == nil || !wg.Enabled {
+			return
+		}
+		o.ipam, o.ipamErr = newClusterWireGuardIPAM(wireGuardSubnet(o.config), staticWireGuardIPs(o.config),
+			o.previousWireGuardIPs, func(msg string) { o.log.Warn(msg) })
+	})
+	return o.ipam, o.ipamErr
+}
+
+// assignWireGuardIP gives a node config without a static WireGuard IP the
+// next free address of the VPN subnet
+func (o *Orchestrator) assignWireGuardIP(nodeConfig *config.NodeConfig) error {
+	ipam, err := o.wireGuardIPs()
+	if ipam == nil || err != nil {
+		return err
+	}
+	ip, err := ipam.assign(nodeConfig.Name)
+	if err != nil {
+		return err
+	}
+	nodeConfig.WireGuardIP = ip
+	return nil
+}
+
+// assignPoolWireGuardIPs allocates the WireGuard IPs of a pool's nodes into
+// pool.WireGuardIPs, from which CreateNodePool gives each node its own
+func (o *Orchestrator) assignPoolWireGuardIPs(pool *config.NodePool) error {
+	ipam, err := o.wireGuardIPs()
+	if ipam == nil || err != nil {
+		return err
+	}
+	ips := make([]string, pool.Count)
+	for i := range ips {
+		if ips[i], err = ipam.assign(pool.NodeName(i)); err != nil {
+			return err
+		}
+	}
+	pool.WireGuardIPs = ips
+	return nil
+}
+
+// WireGuardAllocations returns the WireGuard IP of every node deployed so
+// far, keyed by node name. Passing it back as Options.WireGuardAllocations
+// keeps the addresses stable across deployments.
+func (o *Orchestrator) WireGuardAllocations() map[string]string {
+	ipam, _ := o.wireGuardIPs()
+	if ipam == nil {
+		return nil
+	}
+	return ipam.allocations()
+}
+
+// bastionWireGuardIP is the fixed WireGuard address of the bastion host
+const bastionWireGuardIP = "10.8.0.5"
+
+// assignClusterWireGuardIPs allocates the WireGuard IPs of the nodes the
+// component deployment creates: the config's nodes without a static IP,
+// and the nodes of each pool into its WireGuardIPs, named <pool>-1..<pool>-N
+// after the pool's key as the node deployment names them. The bastion's
+// address is reserved when it is enabled. It returns every allocation, for
+// the next deployment to pass back as previous.
+func assignClusterWireGuardIPs(cfg *config.ClusterConfig, previous map[string]string, warn func(string)) (map[string]string, error) {
+	subnet := wireGuardSubnet(cfg)
+	static := staticWireGuardIPs(cfg)
+	if bastion := cfg.Security.Bastion; bastion != nil && bastion.Enabled {
+		if prefix, err := netip.ParsePrefix(subnet); err == nil && prefix.Contains(netip.MustParseAddr(bastionWireGuardIP)) {
+			static["bastion"] = bastionWireGuardIP
+		}
+	}
+	ipam, err := newClusterWireGuardIPAM(subnet, static, previous, warn)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cfg.Nodes {
+		if cfg.Nodes[i].WireGuardIP != "" {
+			continue
+		}
+		if cfg.Nodes[i].WireGuardIP, err = ipam.assign(cfg.Nodes[i].Name); err != nil {
+			return nil, err
+		}
+	}
+
+	poolNames := make([]string, 0, len(cfg.NodePools))
+	for name := range cfg.NodePools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+	for _, poolName := range poolNames {
+		pool := cfg.NodePools[poolName]
+		pool.WireGuardIPs = make([]string, pool.Count)
+		for i := range pool.WireGuardIPs {
+			if pool.WireGuardIPs[i], err = ipam.assign(fmt.Sprintf("%s-%d", poolName, i+1)); err != nil {
+				return nil, err
+			}
+		}
+		cfg.NodePools[poolName] = pool
+	}
+	return ipam.allocations(), nil
+}
@@ -0,0 +1,299 @@
+package orchestrator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const (
+	loggingNamespace = "logging"
+
+	loggingBackendElastic = "elastic"
+	loggingBackendLoki    = "loki"
+
+	defaultLogRetention = "7d"
+
+	// logIndexPrefix names the daily indices fluent-bit writes to Elasticsearch
+	logIndexPrefix = "kube"
+)
+
+// logRetentionPattern matches the retention periods both Elasticsearch ILM
+// and the Loki compactor accept
+var logRetentionPattern = regexp.MustCompile(`^[0-9]+(h|d)$`)
+
+// fluentBitParsers holds the parser entries LoggingConfig.Parsers may name
+var fluentBitParsers = map[string]string{
+	"json": `[PARSER]
+    Name        json
+    Format      json
+    Time_Key    time
+    Time_Keep   On
+`,
+	"logfmt": `[PARSER]
+    Name        logfmt
+    Format      logfmt
+`,
+	"docker": `[PARSER]
+    Name        docker
+    Format      json
+    Time_Key    time
+    Time_Format %Y-%m-%dT%H:%M:%S.%L
+    Time_Keep   On
+`,
+	"cri": `[PARSER]
+    Name        cri
+    Format      regex
+    Regex       ^(?<time>[^ ]+) (?<stream>stdout|stderr) (?<logtag>[^ ]*) (?<message>.*)$
+    Time_Key    time
+    Time_Format %Y-%m-%dT%H:%M:%S.%L%z
+`,
+	"syslog": `[PARSER]
+    Name        syslog
+    Format      regex
+    Regex       ^\<(?<pri>[0-9]+)\>(?<time>[^ ]* {1,2}[^ ]* [^ ]*) (?<host>[^ ]*) (?<ident>[a-zA-Z0-9_\/\.\-]*)(?:\[(?<pid>[0-9]+)\])?(?:[^\:]*\:)? *(?<message>.*)$
+    Time_Key    time
+    Time_Format %b %d %H:%M:%S
+`,
+	"nginx": `[PARSER]
+    Name        nginx
+    Format      regex
+    Regex       ^(?<remote>[^ ]*) (?<host>[^ ]*) (?<user>[^ ]*) \[(?<time>[^\]]*)\] "(?<method>\S+)(?: +(?<path>[^\"]*?)(?: +\S*)?)?" (?<code>[^ ]*) (?<size>[^ ]*)(?: "(?<referer>[^\"]*)" "(?<agent>[^\"]*)")
+    Time_Key    time
+    Time_Format %d/%b/%Y:%H:%M:%S %z
+`,
+}
+
+// loggingBackend returns the log store a LoggingConfig selects. Backend wins;
+// without it Provider is used, and with neither Loki is installed.
+func loggingBackend(logging *config.LoggingConfig) (string, error) {
+	backend := logging.Backend
+	if backend == "" {
+		backend = logging.Provider
+	}
+	switch backend {
+	case "elastic", "elasticsearch", "elk":
+		return loggingBackendElastic, nil
+	case "", "loki":
+		return loggingBackendLoki, nil
+	default:
+		return "", fmt.Errorf("logging backend %s is not supported; use elastic or loki", backend)
+	}
+}
+
+// logRetention returns the validated retention period, 7d by default
+func logRetention(logging *config.LoggingConfig) (string, error) {
+	retention := logging.Retention
+	if retention == "" {
+		retention = defaultLogRetention
+	}
+	if !logRetentionPattern.MatchString(retention) {
+		return "", fmt.Errorf("logging retention %q is not a period such as 30d or 72h", retention)
+	}
+	return retention, nil
+}
+
+// renderFluentBitParsers renders the parser entries for the configured
+// parser names, in the order they were listed
+func renderFluentBitParsers(parsers []string) (string, error) {
+	var builder strings.Builder
+	for _, name := range parsers {
+		parser, ok := fluentBitParsers[name]
+		if !ok {
+			supported := make([]string, 0, len(fluentBitParsers))
+			for key := range fluentBitParsers {
+				supported = append(supported, key)
+			}
+			sort.Strings(supported)
+			return "", fmt.Errorf("logging parser %s is not supported; use one of %s", name, strings.Join(supported, ", "))
+		}
+		builder.WriteString(parser)
+	}
+	return builder.String(), nil
+}
+
+// renderElasticsearchValues renders the Helm values for a single-node
+// Elasticsearch whose volume uses storageClass, or the cluster default
+func renderElasticsearchValues(storageClass string) string {
+	var builder strings.Builder
+	builder.WriteString("replicas: 1\n")
+	builder.WriteString("minimumMasterNodes: 1\n")
+	builder.WriteString("volumeClaimTemplate:\n")
+	if storageClass != "" {
+		builder.WriteString(fmt.Sprintf("  storageClassName: %s\n", storageClass))
+	}
+	builder.WriteString("  accessModes: [\"ReadWriteOnce\"]\n")
+	builder.WriteString("  resources:\n")
+	builder.WriteString("    requests:\n")
+	builder.WriteString("      storage: 30Gi\n")
+	return builder.String()
+}
+
+// renderFluentBitValues renders the Helm values that ship container logs to
+// Elasticsearch, running each record's log field through the parsers
+func renderFluentBitValues(parsers []string) (string, error) {
+	parserEntries, err := renderFluentBitParsers(parsers)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	builder.WriteString("config:\n")
+	if parserEntries != "" {
+		builder.WriteString("  customParsers: |\n")
+		for _, line := range strings.Split(strings.TrimSuffix(parserEntries, "\n"), "\n") {
+			builder.WriteString("    " + line + "\n")
+		}
+	}
+	builder.WriteString("  filters: |\n")
+	builder.WriteString("    [FILTER]\n")
+	builder.WriteString("        Name kubernetes\n")
+	builder.WriteString("        Match kube.*\n")
+	builder.WriteString("        Merge_Log On\n")
+	builder.WriteString("        Keep_Log Off\n")
+	if len(parsers) > 0 {
+		builder.WriteString("    [FILTER]\n")
+		builder.WriteString("        Name parser\n")
+		builder.WriteString("        Match kube.*\n")
+		builder.WriteString("        Key_Name log\n")
+		builder.WriteString("        Reserve_Data On\n")
+		for _, name := range parsers {
+			builder.WriteString(fmt.Sprintf("        Parser %s\n", name))
+		}
+	}
+	builder.WriteString("  outputs: |\n")
+	builder.WriteString("    [OUTPUT]\n")
+	builder.WriteString("        Name es\n")
+	builder.WriteString("        Match kube.*\n")
+	builder.WriteString(fmt.Sprintf("        Host elasticsearch-master.%s.svc\n", loggingNamespace))
+	builder.WriteString("        Logstash_Format On\n")
+	builder.WriteString(fmt.Sprintf("        Logstash_Prefix %s\n", logIndexPrefix))
+	builder.WriteString("        Replace_Dots On\n")
+	builder.WriteString("        Suppress_Type_Name On\n")
+	builder.WriteString("        Retry_Limit False\n")
+	return builder.String(), nil
+}
+
+// renderLokiValues renders the loki-stack Helm values. The compactor deletes
+// logs older than retention; promtail ships logs only with aggregation on.
+func renderLokiValues(retention, storageClass string, aggregation bool) string {
+	var builder strings.Builder
+	builder.WriteString("loki:\n")
+	builder.WriteString("  persistence:\n")
+	builder.WriteString("    enabled: true\n")
+	if storageClass != "" {
+		builder.WriteString(fmt.Sprintf("    storageClassName: %s\n", storageClass))
+	}
+	builder.WriteString("    size: 30Gi\n")
+	builder.WriteString("  config:\n")
+	builder.WriteString("    compactor:\n")
+	builder.WriteString("      retention_enabled: true\n")
+	builder.WriteString("    limits_config:\n")
+	builder.WriteString(fmt.Sprintf("      retention_period: %s\n", retention))
+	builder.WriteString("promtail:\n")
+	builder.WriteString(fmt.Sprintf("  enabled: %t\n", aggregation))
+	builder.WriteString("fluent-bit:\n")
+	builder.WriteString("  enabled: false\n")
+	return builder.String()
+}
+
+// renderElasticRetention renders the ILM policy that deletes log indices
+// after retention and the index template attaching it to every new index
+func renderElasticRetention(retention string) string {
+	return fmt.Sprintf(`curl -sf -X PUT "localhost:9200/_ilm/policy/%[1]s-retention" -H 'Content-Type: application/json' -d '{"policy":{"phases":{"hot":{"actions":{}},"delete":{"min_age":"%[2]s","actions":{"delete":{}}}}}}'
+curl -sf -X PUT "localhost:9200/_index_template/%[1]s" -H 'Content-Type: application/json' -d '{"index_patterns":["%[1]s-*"],"template":{"settings":{"index.lifecycle.name":"%[1]s-retention"}}}'
+`, logIndexPrefix, retention)
+}
+
+// renderLoggingInstall renders the script that installs the configured log
+// store and, when aggregation is on, the DaemonSet shipping every node's
+// container logs into it
+func renderLoggingInstall(cfg *config.ClusterConfig) (string, error) {
+	logging := cfg.Monitoring.Logging
+	backend, err := loggingBackend(logging)
+	if err != nil {
+		return "", err
+	}
+	retention, err := logRetention(logging)
+	if err != nil {
+		return "", err
+	}
+	storageClass := cfg.Storage.DefaultClass
+
+	var builder strings.Builder
+	builder.WriteString(`set -e
+
+if ! command -v helm &> /dev/null; then
+    curl https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | bash
+fi
+`)
+
+	if backend == loggingBackendLoki {
+		builder.WriteString(fmt.Sprintf(`helm repo add grafana https://grafana.github.io/helm-charts || true
+helm repo update
+
+helm upgrade --install loki grafana/loki-stack \
+  --namespace %s --create-namespace --wait -f - <<'VALUES'
+%sVALUES
+`, loggingNamespace, renderLokiValues(retention, storageClass, logging.Aggregation)))
+		return builder.String(), nil
+	}
+
+	builder.WriteString(fmt.Sprintf(`helm repo add elastic https://helm.elastic.co || true
+helm repo add fluent https://fluent.github.io/helm-charts || true
+helm repo update
+
+helm upgrade --install elasticsearch elastic/elasticsearch --version 7.17.3 \
+  --namespace %[1]s --create-namespace --wait --timeout 15m -f - <<'VALUES'
+%[2]sVALUES
+
+kubectl -n %[1]s exec elasticsearch-master-0 -- bash -c '%[3]s'
+`, loggingNamespace, renderElasticsearchValues(storageClass),
+		strings.ReplaceAll(renderElasticRetention(retention), "'", `'\''`)))
+
+	if logging.Aggregation {
+		values, err := renderFluentBitValues(logging.Parsers)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(fmt.Sprintf(`
+helm upgrade --install fluent-bit fluent/fluent-bit \
+  --namespace %s --wait -f - <<'VALUES'
+%sVALUES
+`, loggingNamespace, values))
+	}
+	return builder.String(), nil
+}
+
+// installLogging installs centralized logging when Monitoring.Logging is
+// set: Elasticsearch with fluent-bit for the elastic backend, Loki with
+// promtail otherwise
+func (o *Orchestrator) installLogging() error {
+	logging := o.config.Monitoring.Logging
+	if logging == nil {
+		return nil
+	}
+
+	runScript, err := o.addonScriptRunner("logging")
+	if err != nil {
+		return err
+	}
+
+	script, err := renderLoggingInstall(o.config)
+	if err != nil {
+		return err
+	}
+
+	backend, _ := loggingBackend(logging)
+	o.log.Info(fmt.Sprintf("Installing %s logging (aggregation: %t)", backend, logging.Aggregation))
+	if backend == loggingBackendLoki && len(logging.Parsers) > 0 {
+		o.log.Warn("logging parsers apply to fluent-bit and are ignored with the loki backend")
+	}
+	if err := runScript("logging-install", script); err != nil {
+		return fmt.Errorf("failed to install logging: %w", err)
+	}
+	return nil
+}
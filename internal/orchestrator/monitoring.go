@@ -0,0 +1,174 @@
+package orchestrator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const (
+	defaultPrometheusRetention   = "30d"
+	defaultPrometheusStorageSize = "50Gi"
+	defaultIngressClass          = "nginx"
+)
+
+var (
+	// prometheusDurationPattern matches the retention durations Prometheus accepts
+	prometheusDurationPattern = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d|w|y)$`)
+	// storageQuantityPattern matches the storage sizes a PVC request accepts
+	storageQuantityPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|Ti|Pi|K|M|G|T|P)?$`)
+)
+
+// grafanaHost returns the host Grafana is exposed on: Grafana.Domain when
+// set, otherwise grafana.<cluster domain>. It is empty without a domain.
+func grafanaHost(cfg *config.ClusterConfig) string {
+	if grafana := cfg.Monitoring.Grafana; grafana != nil && grafana.Domain != "" {
+		return grafana.Domain
+	}
+	if cfg.Network.DNS.Domain != "" {
+		return "grafana." + cfg.Network.DNS.Domain
+	}
+	return ""
+}
+
+// renderMonitoringValues renders the kube-prometheus-stack Helm values for
+// the monitoring config. Prometheus volumes use the default storage class;
+// grafanaIngressHost, when set, exposes Grafana through the ingress
+// controller.
+func renderMonitoringValues(cfg *config.ClusterConfig, grafanaIngressHost string) (string, error) {
+	monitoring := &cfg.Monitoring
+	prometheus := monitoring.Prometheus
+	if prometheus == nil {
+		prometheus = &config.PrometheusConfig{}
+	}
+
+	retention := prometheus.Retention
+	if retention == "" {
+		retention = defaultPrometheusRetention
+	}
+	if !prometheusDurationPattern.MatchString(retention) {
+		return "", fmt.Errorf("prometheus retention %q is not a duration such as 15d", retention)
+	}
+	storageSize := prometheus.StorageSize
+	if storageSize == "" {
+		storageSize = defaultPrometheusStorageSize
+	}
+	if !storageQuantityPattern.MatchString(storageSize) {
+		return "", fmt.Errorf("prometheus storage size %q is not a quantity such as 50Gi", storageSize)
+	}
+
+	var builder strings.Builder
+	builder.WriteString("prometheus:\n")
+	builder.WriteString("  prometheusSpec:\n")
+	builder.WriteString(fmt.Sprintf("    retention: %s\n", retention))
+	if prometheus.Replicas > 0 {
+		builder.WriteString(fmt.Sprintf("    replicas: %d\n", prometheus.Replicas))
+	}
+	if prometheus.ScrapeInterval != "" {
+		builder.WriteString(fmt.Sprintf("    scrapeInterval: %s\n", prometheus.ScrapeInterval))
+	}
+	if len(prometheus.ExternalLabels) > 0 {
+		keys := make([]string, 0, len(prometheus.ExternalLabels))
+		for key := range prometheus.ExternalLabels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		builder.WriteString("    externalLabels:\n")
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("      %s: %q\n", key, prometheus.ExternalLabels[key]))
+		}
+	}
+	builder.WriteString("    storageSpec:\n")
+	builder.WriteString("      volumeClaimTemplate:\n")
+	builder.WriteString("        spec:\n")
+	if cfg.Storage.DefaultClass != "" {
+		builder.WriteString(fmt.Sprintf("          storageClassName: %s\n", cfg.Storage.DefaultClass))
+	}
+	builder.WriteString("          accessModes: [\"ReadWriteOnce\"]\n")
+	builder.WriteString("          resources:\n")
+	builder.WriteString("            requests:\n")
+	builder.WriteString(fmt.Sprintf("              storage: %s\n", storageSize))
+
+	grafanaEnabled := monitoring.Grafana == nil || monitoring.Grafana.Enabled
+	builder.WriteString("grafana:\n")
+	builder.WriteString(fmt.Sprintf("  enabled: %t\n", grafanaEnabled))
+	if grafanaEnabled {
+		if monitoring.Grafana != nil && monitoring.Grafana.AdminPassword != "" {
+			builder.WriteString(fmt.Sprintf("  adminPassword: %q\n", monitoring.Grafana.AdminPassword))
+		}
+		if grafanaIngressHost != "" {
+			ingressClass := cfg.Network.Ingress.Class
+			if ingressClass == "" {
+				ingressClass = defaultIngressClass
+			}
+			builder.WriteString("  ingress:\n")
+			builder.WriteString("    enabled: true\n")
+			builder.WriteString(fmt.Sprintf("    ingressClassName: %s\n", ingressClass))
+			builder.WriteString("    hosts:\n")
+			builder.WriteString(fmt.Sprintf("      - %s\n", grafanaIngressHost))
+		}
+	}
+
+	if alertManager := monitoring.AlertManager; alertManager != nil {
+		builder.WriteString("alertmanager:\n")
+		builder.WriteString(fmt.Sprintf("  enabled: %t\n", alertManager.Enabled))
+		if alertManager.Enabled {
+			builder.WriteString("  alertmanagerSpec:\n")
+			builder.WriteString(fmt.Sprintf("    replicas: %d\n", max(alertManager.Replicas, 1)))
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// installMonitoring installs kube-prometheus-stack when monitoring is
+// enabled. It uses the release the managers' Kubernetes.Monitoring option
+// installs, so enabling both upgrades one release instead of adding a second.
+func (o *Orchestrator) installMonitoring() error {
+	monitoring := &o.config.Monitoring
+	if !monitoring.Enabled {
+		return nil
+	}
+	if monitoring.Provider != "" && monitoring.Provider != "prometheus" {
+		return fmt.Errorf("monitoring provider %s is not supported; use prometheus", monitoring.Provider)
+	}
+
+	runScript, err := o.addonScriptRunner("monitoring")
+	if err != nil {
+		return err
+	}
+
+	host := ""
+	if o.ingressManager != nil {
+		host = grafanaHost(o.config)
+	}
+	values, err := renderMonitoringValues(o.config, host)
+	if err != nil {
+		return err
+	}
+
+	o.log.Info("Installing kube-prometheus-stack")
+	if host != "" {
+		o.log.Info(fmt.Sprintf("Grafana will be served at http://%s", host))
+	}
+
+	script := fmt.Sprintf(`set -e
+
+if ! command -v helm &> /dev/null; then
+    curl https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | bash
+fi
+helm repo add prometheus-community https://prometheus-community.github.io/helm-charts || true
+helm repo update
+
+helm upgrade --install prometheus prometheus-community/kube-prometheus-stack \
+  --namespace monitoring --create-namespace --wait --timeout 15m -f - <<'VALUES'
+%sVALUES
+`, values)
+	if err := runScript("monitoring-install", script); err != nil {
+		return fmt.Errorf("failed to install monitoring: %w", err)
+	}
+	return nil
+}
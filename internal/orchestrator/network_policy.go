@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// systemNamespaces are left out of cluster hardening: the Kubernetes system
+// namespaces and those the addon steps install into, whose components need
+// host access and to reach the API server, nodes and outside services
+var systemNamespaces = []string{
+	"kube-system",
+	"kube-public",
+	"kube-node-lease",
+	"cattle-system",
+	"cert-manager",
+	"longhorn-system",
+	"monitoring",
+	loggingNamespace,
+	tracingNamespace,
+	veleroNamespace,
+}
+
+// checkNetworkPolicySupport returns an error unless the cluster's CNI
+// enforces NetworkPolicies. An empty plugin means the distribution default,
+// canal, which does.
+func checkNetworkPolicySupport(k8s *config.KubernetesConfig) error {
+	switch k8s.NetworkPlugin {
+	case "", "canal", "calico", "cilium":
+		return nil
+	case "flannel":
+		return fmt.Errorf("network plugin flannel does not enforce NetworkPolicies; set kubernetes.networkPlugin to canal, calico or cilium to use security.networkPolicies")
+	default:
+		return fmt.Errorf("network plugin %s is not known to enforce NetworkPolicies; set kubernetes.networkPlugin to canal, calico or cilium to use security.networkPolicies", k8s.NetworkPlugin)
+	}
+}
+
+// renderNetworkPolicies renders the policies applied to each namespace: deny
+// all traffic by default, then allow DNS lookups against kube-system and
+// traffic from kube-system, where the ingress controller and metrics-server
+// run. The policies carry no namespace so they can be applied to any.
+func renderNetworkPolicies() string {
+	return `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny-all
+spec:
+  podSelector: {}
+  policyTypes:
+    - Ingress
+    - Egress
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-dns
+spec:
+  podSelector: {}
+  policyTypes:
+    - Egress
+  egress:
+    - to:
+        - namespaceSelector:
+            matchLabels:
+              kubernetes.io/metadata.name: kube-system
+      ports:
+        - protocol: UDP
+          port: 53
+        - protocol: TCP
+          port: 53
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-from-kube-system
+spec:
+  podSelector: {}
+  policyTypes:
+    - Ingress
+  ingress:
+    - from:
+        - namespaceSelector:
+            matchLabels:
+              kubernetes.io/metadata.name: kube-system
+`
+}
+
+// renderNetworkPolicyInstall renders the script that applies the policies
+// to every namespace that exists when it runs, skipping the system ones
+func renderNetworkPolicyInstall() string {
+	return fmt.Sprintf(`set -e
+
+EXEMPT=" %s "
+for ns in $(kubectl get namespaces -o jsonpath='{.items[*].metadata.name}'); do
+    case "$EXEMPT" in
+        *" $ns "*) continue ;;
+    esac
+    echo "Applying network policies to $ns"
+    kubectl apply -n "$ns" -f - <<'POLICIES'
+%sPOLICIES
+done
+`, strings.Join(systemNamespaces, " "), renderNetworkPolicies())
+}
+
+// installNetworkPolicies enforces Security.NetworkPolicies. Namespaces
+// created after the deploy are not covered and need the same policies
+// applied when they are created.
+func (o *Orchestrator) installNetworkPolicies() error {
+	if !o.config.Security.NetworkPolicies {
+		return nil
+	}
+	if err := checkNetworkPolicySupport(&o.config.Kubernetes); err != nil {
+		return err
+	}
+
+	runScript, err := o.addonScriptRunner("network policies")
+	if err != nil {
+		return err
+	}
+
+	o.log.Info("Applying default-deny network policies")
+	if err := runScript("network-policies", renderNetworkPolicyInstall()); err != nil {
+		return fmt.Errorf("failed to apply network policies: %w", err)
+	}
+	return nil
+}
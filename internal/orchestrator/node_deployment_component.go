@@ -67,6 +67,7 @@ func NewNodeDeploymentComponent(ctx *pulumi.Context, name string, clusterConfig
 				Roles:       poolConfig.Roles,
 				Labels:      poolConfig.Labels,
 				Taints:      poolConfig.Taints,
+				SSHUser:     poolConfig.SSHUser,
 				PrivateIP:   fmt.Sprintf("10.0.1.%d", nodeIndex+1),
 				WireGuardIP: fmt.Sprintf("10.8.0.%d", 10+nodeIndex),
 			}
@@ -1,16 +1,22 @@
 package orchestrator
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/cluster"
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/dns"
 	"github.com/chalkan3/sloth-kubernetes/pkg/health"
 	"github.com/chalkan3/sloth-kubernetes/pkg/ingress"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
 	"github.com/chalkan3/sloth-kubernetes/pkg/network"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+	"github.com/chalkan3/sloth-kubernetes/pkg/retry"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
 	"github.com/chalkan3/sloth-kubernetes/pkg/security"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -30,49 +36,244 @@ type Orchestrator struct {
 	ingressManager   *ingress.NginxIngressManager
 	rkeManager       *cluster.RKEManager
 	rke2Manager      *cluster.RKE2Manager
+	drainer          nodeDrainer // the active RKE or RKE2 manager
 	healthChecker    *health.HealthChecker
 	validator        *health.PrerequisiteValidator
 	vpnChecker       *network.VPNConnectivityChecker
 	nodes            map[string][]*providers.NodeOutput
-	mu               sync.Mutex
+	bastion          *providers.NodeOutput
+	privateNodes     bool // set once a private-endpoint cluster has its bastion
+	deployedPools    []string
+	poolResults      map[string]*PoolResult
+	log              logging.Logger
+	mu               sync.RWMutex
+
+	// newProvider and providerMu back the provider instances that are
+	// registered lazily for nodes and pools with a credentials override
+	newProvider func(name string) (providers.Provider, error)
+	providerMu  sync.Mutex
+
+	dryRun               bool
+	force                bool
+	retryConfig          retry.Config
+	poolBatchConcurrency int
+	skipPlacement        map[string]bool
+
+	// providerLimits bound the create calls in flight to each cloud, keyed
+	// by provider name; see providerSlots. createLocks serialize the create
+	// calls on each provider instance, keyed by provider key; see createLock
+	providerLimits map[string]chan struct{}
+	createLocks    map[string]*sync.Mutex
+	limitsMu       sync.Mutex
+
+	// phaseTimings hold how long each phase of the last Deploy took, and
+	// timingOrder the order the phases and pools finished in
+	phaseTimings      map[string]time.Duration
+	timingOrder       []string
+	timingsMu         sync.Mutex
+	printPhaseTimings bool
+
+	// ipam hands out WireGuard IPs; it is created on first use, seeded
+	// with previousWireGuardIPs
+	ipam                 *wireGuardIPAM
+	ipamErr              error
+	ipamOnce             sync.Once
+	previousWireGuardIPs map[string]string
+}
+
+// Options tunes an Orchestrator created with NewWithOptions
+type Options struct {
+	// DryRun validates nodes and pools without creating them: providers that
+	// implement providers.NodeValidator check each definition, and synthetic
+	// nodes marked DryRun take the place of the real ones
+	DryRun bool
+
+	// Force deploys even when the cost estimate is above
+	// CostControl.MonthlyBudget
+	Force bool
+
+	// Retry is the retry policy for the calls providers make to the cloud
+	// APIs outside Pulumi, such as DestroyNode and RebootNode; nil uses
+	// DefaultProviderRetryConfig. A policy without RetryIf retries only errors
+	// IsRetryableProviderError accepts.
+	Retry *retry.Config
+
+	// PoolBatchConcurrency, when positive, creates pool nodes one CreateNode
+	// call each with at most this many in flight, keeping the nodes that
+	// succeed when others fail (see PoolResults). Zero keeps the provider's
+	// all-or-nothing CreateNodePool.
+	PoolBatchConcurrency int
+
+	// SkipPlacementValidation lists providers whose regions and sizes are not
+	// checked against GetRegions and GetSizes before deploying, for clouds
+	// whose catalog changes faster than the built-in lists
+	SkipPlacementValidation []string
+
+	// WireGuardAllocations are the WireGuard IPs of a previous deployment,
+	// keyed by node name (see Orchestrator.WireGuardAllocations). Nodes
+	// keep them unless a static IP in the config now claims the address.
+	WireGuardAllocations map[string]string
+
+	// Logger receives the orchestrator's and its managers' log events;
+	// nil logs to the Pulumi engine. Use logging.NewJSONLogger for
+	// machine-readable logs.
+	Logger logging.Logger
+
+	// PrintPhaseTimings logs PhaseTimingSummary when Deploy returns,
+	// whether or not it succeeded
+	PrintPhaseTimings bool
+}
+
+// Provider instances and o.nodes are keyed by provider name. A node or pool
+// with a credentials override uses "<provider>#<credentials hash>" instead
+// (e.g. "aws#3f9a0c1b7d2e"), so o.nodes may hold several keys for the same
+// cloud; providerFromKey recovers the provider name.
+const providerKeySeparator = "#"
+
+// providerKey returns the key a provider instance and its nodes are stored under
+func providerKey(providerName string, creds *config.ProviderCredentials) string {
+	if creds == nil {
+		return providerName
+	}
+	return providerName + providerKeySeparator + creds.Hash()
+}
+
+// providerFromKey returns the provider name of a provider key
+func providerFromKey(key string) string {
+	name, _, _ := strings.Cut(key, providerKeySeparator)
+	return name
+}
+
+// DeployResult summarizes what a deployment actually created
+type DeployResult struct {
+	NodesCreated      int
+	PoolsDeployed     []string
+	SkippedNodes      []string
+	PerProviderCounts map[string]int
+	SpotFallbacks     int // Spot nodes created on-demand for lack of spot capacity
+	Duration          time.Duration
 }
 
 // New creates a new orchestrator
 func New(ctx *pulumi.Context, config *config.ClusterConfig) *Orchestrator {
+	return NewWithOptions(ctx, config, Options{})
+}
+
+// NewWithOptions creates a new orchestrator with the given options
+func NewWithOptions(ctx *pulumi.Context, config *config.ClusterConfig, opts Options) *Orchestrator {
+	retryConfig := DefaultProviderRetryConfig()
+	if opts.Retry != nil {
+		retryConfig = *opts.Retry
+	}
+	skipPlacement := make(map[string]bool, len(opts.SkipPlacementValidation))
+	for _, name := range opts.SkipPlacementValidation {
+		skipPlacement[name] = true
+	}
+	log := opts.Logger
+	if log == nil {
+		log = logging.NewPulumiLogger(ctx)
+	}
+
+	validator := health.NewPrerequisiteValidator(ctx)
+	validator.SetLogger(log)
+	healthChecker := health.NewHealthChecker(ctx)
+	healthChecker.SetLogger(log)
+
 	return &Orchestrator{
 		ctx:              ctx,
 		config:           config,
 		providerRegistry: providers.NewProviderRegistry(),
 		nodes:            make(map[string][]*providers.NodeOutput),
-		validator:        health.NewPrerequisiteValidator(ctx),
-		healthChecker:    health.NewHealthChecker(ctx),
+		validator:        validator,
+		healthChecker:    healthChecker,
+		newProvider:      providers.NewProviderByName,
+		dryRun:           opts.DryRun,
+		force:            opts.Force,
+		retryConfig:      retryConfig,
+		log:              log,
+
+		poolBatchConcurrency: opts.PoolBatchConcurrency,
+		skipPlacement:        skipPlacement,
+		previousWireGuardIPs: opts.WireGuardAllocations,
+		printPhaseTimings:    opts.PrintPhaseTimings,
 	}
 }
 
+// DeployWithResult runs Deploy and reports what it created. The result is
+// returned even when Deploy fails and reflects the nodes created before the
+// error.
+func (o *Orchestrator) DeployWithResult() (*DeployResult, error) {
+	start := time.Now()
+	err := o.Deploy()
+
+	result := o.deployResult()
+	result.Duration = time.Since(start)
+	return result, err
+}
+
 // Deploy orchestrates the complete cluster deployment
 func (o *Orchestrator) Deploy() error {
-	o.ctx.Log.Info("Starting Kubernetes cluster deployment", nil)
+	o.resetTimings()
+	if o.printPhaseTimings {
+		defer o.logPhaseTimings()
+	}
+
+	if o.dryRun {
+		return o.dryRunDeploy()
+	}
+
+	o.log.Info("Starting Kubernetes cluster deployment")
+
+	// Phase 0: Check regions and sizes before any cloud API call
+	if err := o.phase(phasePlacement, o.validatePlacement); err != nil {
+		return err
+	}
 
-	// Phase 0: Generate SSH keys
-	if err := o.generateSSHKeys(); err != nil {
+	// Phase 0b: Generate SSH keys
+	if err := o.phase(phaseSSHKeys, o.generateSSHKeys); err != nil {
 		return fmt.Errorf("failed to generate SSH keys: %w", err)
 	}
 
 	// Phase 1: Initialize providers
-	if err := o.initializeProviders(); err != nil {
+	if err := o.phase(phaseProviders, o.initializeProviders); err != nil {
 		return fmt.Errorf("failed to initialize providers: %w", err)
 	}
 
+	// Phase 1b: Check the estimated cost against the budget
+	if err := o.phase(phaseCost, o.checkCostBudget); err != nil {
+		return fmt.Errorf("cost check failed: %w", err)
+	}
+
 	// Phase 2: Create networking infrastructure
-	if err := o.createNetworking(); err != nil {
+	if err := o.phase(phaseNetworking, o.createNetworking); err != nil {
 		return fmt.Errorf("failed to create networking: %w", err)
 	}
 
+	// Phase 2b: Provision the bastion host (bastion or private cluster)
+	if err := o.phase(phaseBastion, o.createBastion); err != nil {
+		return fmt.Errorf("failed to create bastion: %w", err)
+	}
+
 	// Phase 3: Deploy nodes
-	if err := o.deployNodes(); err != nil {
+	if err := o.phase(phaseNodes, o.deployNodes); err != nil {
 		return fmt.Errorf("failed to deploy nodes: %w", err)
 	}
 
+	// An empty cluster has nothing to configure
+	if o.configuredNodeCount() == 0 {
+		o.phase(phaseOutputs, func() error {
+			o.exportOutputs()
+			return nil
+		})
+		o.log.Info("Empty cluster deployed; skipping node configuration")
+		return nil
+	}
+
+	// Phase 3b: Wait for every node to accept SSH before configuring it
+	if err := o.phase(phaseNodeReadiness, func() error { return o.waitForNodesReady(o.nodeReadyTimeout()) }); err != nil {
+		return fmt.Errorf("nodes not ready: %w", err)
+	}
+
 	// Phase 4: Configure OS-level firewalls on nodes
 	// OS firewall configuration moved to component
 	// if err := o.configureOSFirewalls(); err != nil {
@@ -80,17 +281,17 @@ func (o *Orchestrator) Deploy() error {
 	// }
 
 	// Phase 5: Configure DNS records
-	if err := o.configureDNS(); err != nil {
+	if err := o.phase(phaseDNS, o.configureDNS); err != nil {
 		return fmt.Errorf("failed to configure DNS: %w", err)
 	}
 
 	// Phase 6: Configure VPN (WireGuard or Tailscale)
-	if err := o.configureVPN(); err != nil {
+	if err := o.phase(phaseVPN, o.configureVPN); err != nil {
 		return fmt.Errorf("failed to configure VPN: %w", err)
 	}
 
 	// Phase 7: Configure cloud provider firewalls
-	if err := o.configureFirewalls(); err != nil {
+	if err := o.phase(phaseFirewalls, o.configureFirewalls); err != nil {
 		return fmt.Errorf("failed to configure firewalls: %w", err)
 	}
 
@@ -100,46 +301,77 @@ func (o *Orchestrator) Deploy() error {
 		(o.config.Network.Tailscale != nil && o.config.Network.Tailscale.Enabled)
 
 	if vpnEnabled {
-		o.ctx.Log.Info("=====================================", nil)
-		o.ctx.Log.Info("CRITICAL: Verifying VPN Connectivity", nil)
-		o.ctx.Log.Info("=====================================", nil)
-		o.ctx.Log.Info("RKE requires all nodes to communicate via private network", nil)
-		o.ctx.Log.Info("Checking full mesh connectivity before proceeding...", nil)
+		o.log.Info("=====================================")
+		o.log.Info("CRITICAL: Verifying VPN Connectivity")
+		o.log.Info("=====================================")
+		o.log.Info("RKE requires all nodes to communicate via private network")
+		o.log.Info("Checking full mesh connectivity before proceeding...")
 
-		if err := o.verifyVPNReadyForRKE(); err != nil {
+		if err := o.phase(phaseVPNVerification, o.verifyVPNReadyForRKE); err != nil {
 			return fmt.Errorf("VPN not ready for RKE deployment: %w", err)
 		}
 
-		o.ctx.Log.Info("=====================================", nil)
-		o.ctx.Log.Info("✓ VPN VERIFIED - Safe to deploy RKE", nil)
-		o.ctx.Log.Info("=====================================", nil)
+		o.log.Info("=====================================")
+		o.log.Info("✓ VPN VERIFIED - Safe to deploy RKE")
+		o.log.Info("=====================================")
 	}
 
 	// Phase 8: Deploy RKE cluster
-	if err := o.deployRKE(); err != nil {
+	if err := o.phase(phaseKubernetes, o.deployRKE); err != nil {
 		return fmt.Errorf("failed to deploy RKE: %w", err)
 	}
 
 	// Phase 9: Install NGINX Ingress
-	if err := o.installIngress(); err != nil {
+	if err := o.phase(phaseIngress, o.installIngress); err != nil {
 		return fmt.Errorf("failed to install ingress: %w", err)
 	}
 
 	// Phase 10: Install addons
-	if err := o.installAddons(); err != nil {
+	if err := o.phase(phaseAddons, o.installAddons); err != nil {
 		return fmt.Errorf("failed to install addons: %w", err)
 	}
 
 	// Phase 11: Export outputs
-	o.exportOutputs()
+	o.phase(phaseOutputs, func() error {
+		o.exportOutputs()
+		return nil
+	})
 
-	o.ctx.Log.Info("Kubernetes cluster deployment completed successfully", nil)
+	o.log.Info("Kubernetes cluster deployment completed successfully")
+	return nil
+}
+
+// dryRunDeploy validates the node layout without creating any resources.
+// Enabled providers are registered uninitialized, since initializing them
+// would create SSH keys in the cloud.
+func (o *Orchestrator) dryRunDeploy() error {
+	o.log.Info("Starting dry run: validating nodes without creating resources")
+
+	if err := o.phase(phasePlacement, o.validatePlacement); err != nil {
+		return err
+	}
+
+	enabled, err := providers.NewProviderFactory().GetEnabledProviders(o.config)
+	if err != nil {
+		return fmt.Errorf("failed to resolve providers: %w", err)
+	}
+	for _, provider := range enabled {
+		if _, ok := o.providerRegistry.Get(provider.GetName()); !ok {
+			o.providerRegistry.Register(provider.GetName(), provider)
+		}
+	}
+
+	if err := o.phase(phaseNodes, o.deployNodes); err != nil {
+		return fmt.Errorf("failed to deploy nodes: %w", err)
+	}
+
+	o.log.Info("Dry run completed successfully")
 	return nil
 }
 
 // generateSSHKeys generates SSH keys for the cluster
 func (o *Orchestrator) generateSSHKeys() error {
-	o.ctx.Log.Info("Generating SSH keys for cluster", nil)
+	o.log.Info("Generating SSH keys for cluster")
 
 	o.sshKeyManager = security.NewSSHKeyManager(o.ctx)
 	if err := o.sshKeyManager.GenerateKeyPair(); err != nil {
@@ -156,6 +388,9 @@ func (o *Orchestrator) generateSSHKeys() error {
 	if o.config.Providers.Linode != nil {
 		o.config.Providers.Linode.SSHPublicKey = publicKey
 	}
+	if o.config.Providers.Hetzner != nil {
+		o.config.Providers.Hetzner.SSHPublicKey = publicKey
+	}
 	// Note: Azure SSH key is handled directly in node_deployment.go via sshKeyOutput parameter
 
 	return nil
@@ -163,7 +398,7 @@ func (o *Orchestrator) generateSSHKeys() error {
 
 // initializeProviders initializes all cloud providers
 func (o *Orchestrator) initializeProviders() error {
-	o.ctx.Log.Info("Initializing cloud providers", nil)
+	o.log.Info("Initializing cloud providers")
 
 	// Initialize DigitalOcean provider
 	if o.config.Providers.DigitalOcean != nil && o.config.Providers.DigitalOcean.Enabled {
@@ -172,7 +407,7 @@ func (o *Orchestrator) initializeProviders() error {
 			return fmt.Errorf("failed to initialize DigitalOcean provider: %w", err)
 		}
 		o.providerRegistry.Register("digitalocean", doProvider)
-		o.ctx.Log.Info("✓ DigitalOcean provider initialized", nil)
+		o.log.Info("✓ DigitalOcean provider initialized", logging.F(logging.FieldProvider, "digitalocean"))
 	}
 
 	// Initialize Linode provider
@@ -182,7 +417,7 @@ func (o *Orchestrator) initializeProviders() error {
 			return fmt.Errorf("failed to initialize Linode provider: %w", err)
 		}
 		o.providerRegistry.Register("linode", linodeProvider)
-		o.ctx.Log.Info("✓ Linode provider initialized", nil)
+		o.log.Info("✓ Linode provider initialized", logging.F(logging.FieldProvider, "linode"))
 	}
 
 	// Initialize Azure provider
@@ -192,7 +427,7 @@ func (o *Orchestrator) initializeProviders() error {
 			return fmt.Errorf("failed to initialize Azure provider: %w", err)
 		}
 		o.providerRegistry.Register("azure", azureProvider)
-		o.ctx.Log.Info("✓ Azure provider initialized", nil)
+		o.log.Info("✓ Azure provider initialized", logging.F(logging.FieldProvider, "azure"))
 	}
 
 	// Initialize AWS provider
@@ -202,7 +437,7 @@ func (o *Orchestrator) initializeProviders() error {
 			return fmt.Errorf("failed to initialize AWS provider: %w", err)
 		}
 		o.providerRegistry.Register("aws", awsProvider)
-		o.ctx.Log.Info("✓ AWS provider initialized", nil)
+		o.log.Info("✓ AWS provider initialized", logging.F(logging.FieldProvider, "aws"))
 	}
 
 	// Initialize GCP provider
@@ -212,7 +447,17 @@ func (o *Orchestrator) initializeProviders() error {
 			return fmt.Errorf("failed to initialize GCP provider: %w", err)
 		}
 		o.providerRegistry.Register("gcp", gcpProvider)
-		o.ctx.Log.Info("✓ GCP provider initialized", nil)
+		o.log.Info("✓ GCP provider initialized", logging.F(logging.FieldProvider, "gcp"))
+	}
+
+	// Initialize Hetzner provider
+	if o.config.Providers.Hetzner != nil && o.config.Providers.Hetzner.Enabled {
+		hetznerProvider := providers.NewHetznerProvider()
+		if err := hetznerProvider.Initialize(o.ctx, o.config); err != nil {
+			return fmt.Errorf("failed to initialize Hetzner provider: %w", err)
+		}
+		o.providerRegistry.Register("hetzner", hetznerProvider)
+		o.log.Info("✓ Hetzner provider initialized", logging.F(logging.FieldProvider, "hetzner"))
 	}
 
 	// Verify at least one provider is enabled
@@ -225,9 +470,14 @@ func (o *Orchestrator) initializeProviders() error {
 
 // createNetworking creates network infrastructure
 func (o *Orchestrator) createNetworking() error {
-	o.ctx.Log.Info("Creating network infrastructure", nil)
+	o.log.Info("Creating network infrastructure")
+
+	if err := o.checkCIDRs(); err != nil {
+		return err
+	}
 
 	o.networkManager = network.NewManager(o.ctx, &o.config.Network)
+	o.networkManager.SetLogger(o.log)
 
 	// Register providers with network manager
 	for name, provider := range o.providerRegistry.GetAll() {
@@ -247,9 +497,31 @@ func (o *Orchestrator) createNetworking() error {
 	return nil
 }
 
-// deployNodes deploys all cluster nodes
-func (o *Orchestrator) deployNodes() error {
-	o.ctx.Log.Info("Deploying cluster nodes", nil)
+// deployNodes deploys all cluster nodes. With RollbackOnFailure set, the
+// nodes created before a failure are rolled back. A config without nodes
+// fails with ErrNothingToDeploy, or deploys nothing when AllowEmptyCluster
+// is set.
+func (o *Orchestrator) deployNodes() (err error) {
+	if o.configuredNodeCount() == 0 {
+		if !o.config.AllowEmptyCluster {
+			return ErrNothingToDeploy
+		}
+		o.log.Info("No nodes configured; deploying an empty cluster")
+		return nil
+	}
+
+	o.log.Info("Deploying cluster nodes")
+	o.logProviderLimits()
+
+	defer func() {
+		if err == nil || !o.config.RollbackOnFailure {
+			return
+		}
+		o.log.Warn(fmt.Sprintf("Node deployment failed, rolling back: %v", err))
+		if rollbackErr := o.Rollback(); rollbackErr != nil {
+			err = errors.Join(err, fmt.Errorf("rollback failed: %w", rollbackErr))
+		}
+	}()
 
 	// Deploy individual nodes
 	for i := range o.config.Nodes {
@@ -260,11 +532,8 @@ func (o *Orchestrator) deployNodes() error {
 	}
 
 	// Deploy node pools
-	for poolName := range o.config.NodePools {
-		poolConfig := o.config.NodePools[poolName]
-		if err := o.deployNodePool(poolName, &poolConfig); err != nil {
-			return fmt.Errorf("failed to deploy node pool %s: %w", poolName, err)
-		}
+	if err := o.deployNodePools(); err != nil {
+		return err
 	}
 
 	// Verify we have the required nodes
@@ -272,12 +541,19 @@ func (o *Orchestrator) deployNodes() error {
 		return err
 	}
 
+	// Synthetic nodes have nothing to health check
+	if o.dryRun {
+		return nil
+	}
+
 	// Initialize health checker and validator
 	o.healthChecker = health.NewHealthChecker(o.ctx)
+	o.healthChecker.SetLogger(o.log)
 	o.validator = health.NewPrerequisiteValidator(o.ctx)
+	o.validator.SetLogger(o.log)
 
 	// Add all nodes to health checker
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		for _, node := range nodes {
 			o.healthChecker.AddNode(node)
 		}
@@ -289,55 +565,540 @@ func (o *Orchestrator) deployNodes() error {
 		o.healthChecker.SetSSHKeyPath(sshKeyPath)
 	}
 
-	// Wait for all nodes to be ready with basic services
-	o.ctx.Log.Info("Waiting for all nodes to be ready with SSH and Docker", nil)
-	requiredServices := []string{"ssh", "docker"}
-	if err := o.healthChecker.WaitForNodesReady(requiredServices); err != nil {
-		return fmt.Errorf("nodes failed health checks: %w", err)
+	// Readiness is gated by the node readiness phase (see waitForNodesReady)
+	return nil
+}
+
+// Rollback destroys the nodes deployed so far and stops tracking each one
+// its provider destroyed. Nodes that could not be destroyed stay tracked and
+// are named in the returned error so they can be cleaned up manually.
+// Pulumi creates nodes asynchronously, so providers wait for a node's create
+// to settle and destroy the resource it recorded rather than racing it.
+func (o *Orchestrator) Rollback() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	keys := make([]string, 0, len(o.nodes))
+	for key := range o.nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		failed []string
+		errs   []error
+	)
+	for _, key := range keys {
+		provider, ok := o.providerRegistry.Get(key)
+
+		var remaining []*providers.NodeOutput
+		for _, node := range o.nodes[key] {
+			// Synthetic dry-run nodes have nothing to destroy
+			if node.DryRun {
+				continue
+			}
+
+			err := error(&ProviderNotFoundError{Provider: key})
+			if ok {
+				err = o.withProviderRetry(key, "destroy of node "+node.Name, func() error {
+					return provider.DestroyNode(o.ctx.Context(), node)
+				})
+			}
+			if err != nil {
+				remaining = append(remaining, node)
+				failed = append(failed, node.Name)
+				errs = append(errs, fmt.Errorf("node %s: %w", node.Name, err))
+				continue
+			}
+			o.log.Info(fmt.Sprintf("Rolled back node %s", node.Name), logging.F(logging.FieldNode, node.Name))
+		}
+
+		if len(remaining) == 0 {
+			delete(o.nodes, key)
+		} else {
+			o.nodes[key] = remaining
+		}
 	}
 
-	return nil
+	if len(errs) == 0 {
+		o.deployedPools = nil
+		return nil
+	}
+	return fmt.Errorf("failed to destroy nodes %s: %w", strings.Join(failed, ", "), errors.Join(errs...))
 }
 
 // deployNode deploys a single node
 func (o *Orchestrator) deployNode(nodeConfig *config.NodeConfig) error {
-	provider, ok := o.providerRegistry.Get(nodeConfig.Provider)
-	if !ok {
-		return fmt.Errorf("provider %s not found", nodeConfig.Provider)
+	provider, key, err := o.providerFor(nodeConfig.Provider, nodeConfig.Credentials)
+	if err != nil {
+		return err
+	}
+	if err := o.assignWireGuardIP(nodeConfig); err != nil {
+		return err
 	}
 
-	node, err := provider.CreateNode(o.ctx, nodeConfig)
+	var node *providers.NodeOutput
+	if o.dryRun {
+		node, err = dryRunNode(provider, nodeConfig)
+	} else {
+		node, err = withProviderSlot(o, key, func() (*providers.NodeOutput, error) {
+			return provider.CreateNode(o.ctx, nodeConfig)
+		})
+	}
 	if err != nil {
 		return err
 	}
+	applyNodeScheduling(node, o.nodeLabels(nodeConfig), nodeConfig.Taints)
+	applyNodeRoles(node, nodeConfig.Roles)
 
 	o.mu.Lock()
-	o.nodes[nodeConfig.Provider] = append(o.nodes[nodeConfig.Provider], node)
+	o.nodes[key] = append(o.nodes[key], node)
 	o.mu.Unlock()
 
 	return nil
 }
 
+// providerFor returns the provider instance for a node or pool along with the
+// key its nodes are stored under. A credentials override gets its own provider
+// instance, scoped to the credentials hash and initialized and registered on
+// first use.
+func (o *Orchestrator) providerFor(providerName string, creds *config.ProviderCredentials) (providers.Provider, string, error) {
+	key := providerKey(providerName, creds)
+
+	o.providerMu.Lock()
+	defer o.providerMu.Unlock()
+
+	if provider, ok := o.providerRegistry.Get(key); ok {
+		return provider, key, nil
+	}
+	if creds == nil {
+		return nil, "", &ProviderNotFoundError{Provider: providerName}
+	}
+
+	cfg, err := o.config.WithProviderCredentials(providerName, creds)
+	if err != nil {
+		return nil, "", err
+	}
+	provider, err := o.newProvider(providerName)
+	if err != nil {
+		return nil, "", err
+	}
+	scoped, ok := provider.(providers.ScopedProvider)
+	if !ok {
+		return nil, "", fmt.Errorf("provider %s does not support a credentials override", providerName)
+	}
+	scoped.SetScope(creds.Hash())
+	if err := provider.Initialize(o.ctx, cfg); err != nil {
+		return nil, "", fmt.Errorf("failed to initialize provider %s: %w", key, err)
+	}
+	o.applyNodeVisibility(key, provider)
+
+	// The instance gets a network of its own in its account, and is known to
+	// the network manager so configureFirewalls covers its nodes
+	if o.networkManager != nil {
+		if err := o.networkManager.AddProvider(key, provider); err != nil {
+			return nil, "", err
+		}
+	}
+	o.providerRegistry.Register(key, provider)
+
+	return provider, key, nil
+}
+
+// deployNodePools deploys the node pools concurrently, at most
+// deployConcurrency at a time. After the first failure no new pools are
+// started, but pools already running are allowed to finish; the returned
+// error names every pool that failed.
+func (o *Orchestrator) deployNodePools() error {
+	poolNames := make([]string, 0, len(o.config.NodePools))
+	for poolName := range o.config.NodePools {
+		poolNames = append(poolNames, poolName)
+	}
+	sort.Strings(poolNames)
+
+	var (
+		wg     sync.WaitGroup
+		errMu  sync.Mutex
+		failed []string
+		errs   []error
+	)
+	slots := make(chan struct{}, o.deployConcurrency())
+
+	for _, poolName := range poolNames {
+		slots <- struct{}{}
+
+		errMu.Lock()
+		stop := len(errs) > 0
+		errMu.Unlock()
+		if stop {
+			<-slots
+			break
+		}
+
+		poolConfig := o.config.NodePools[poolName]
+		wg.Add(1)
+		go func(poolName string, poolConfig config.NodePool) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			start := time.Now()
+			err := o.deployNodePool(poolName, &poolConfig)
+			o.recordTiming(poolTimingKey(poolName), time.Since(start))
+			if err != nil {
+				errMu.Lock()
+				failed = append(failed, poolName)
+				errs = append(errs, fmt.Errorf("node pool %s: %w", poolName, err))
+				errMu.Unlock()
+			}
+		}(poolName, poolConfig)
+	}
+	wg.Wait()
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("failed to deploy %w", errs[0])
+	}
+
+	sort.Strings(failed)
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return fmt.Errorf("failed to deploy node pools %s: %w", strings.Join(failed, ", "), errors.Join(errs...))
+}
+
+// deployConcurrency returns how many node pools may deploy at once:
+// MaxDeployConcurrency when set, otherwise one per distinct provider
+func (o *Orchestrator) deployConcurrency() int {
+	if o.config.MaxDeployConcurrency > 0 {
+		return o.config.MaxDeployConcurrency
+	}
+
+	providerNames := make(map[string]bool)
+	for _, pool := range o.config.NodePools {
+		providerNames[pool.Provider] = true
+	}
+	if len(providerNames) == 0 {
+		return 1
+	}
+	return len(providerNames)
+}
+
 // deployNodePool deploys a pool of nodes
 func (o *Orchestrator) deployNodePool(poolName string, poolConfig *config.NodePool) error {
-	provider, ok := o.providerRegistry.Get(poolConfig.Provider)
-	if !ok {
-		return fmt.Errorf("provider %s not found", poolConfig.Provider)
+	provider, key, err := o.providerFor(poolConfig.Provider, poolConfig.Credentials)
+	if err != nil {
+		return err
+	}
+
+	// Inherit region and size from the provider config when the pool omits them
+	defaultRegion, defaultSize := o.config.ProviderDefaults(poolConfig.Provider)
+	if poolConfig.Region == "" {
+		poolConfig.Region = defaultRegion
+	}
+	if poolConfig.Size == "" {
+		poolConfig.Size = defaultSize
+	}
+	if poolConfig.Region == "" && len(poolConfig.Regions) == 0 {
+		return fmt.Errorf("node pool %s has no region and provider %s has no default region", poolName, poolConfig.Provider)
+	}
+	if poolConfig.Size == "" {
+		return fmt.Errorf("node pool %s has no size and provider %s has no default size", poolName, poolConfig.Provider)
 	}
 
-	nodes, err := provider.CreateNodePool(o.ctx, poolConfig)
+	if (o.poolBatchConcurrency > 0 || spotPerNode(poolConfig)) && !o.dryRun {
+		result := o.createPoolBatched(provider, key, poolConfig)
+
+		o.mu.Lock()
+		if o.poolResults == nil {
+			o.poolResults = make(map[string]*PoolResult)
+		}
+		o.poolResults[poolName] = result
+		if len(result.Failed) == 0 {
+			o.deployedPools = append(o.deployedPools, poolName)
+		}
+		o.mu.Unlock()
+
+		return result.Err()
+	}
+
+	// The nodes' WireGuard IPs go into their user data, so they are
+	// allocated before the provider creates them
+	if err := o.assignPoolWireGuardIPs(poolConfig); err != nil {
+		return fmt.Errorf("node pool %s: %w", poolName, err)
+	}
+
+	var nodes []*providers.NodeOutput
+	if o.dryRun {
+		nodes, err = dryRunNodePool(provider, poolConfig)
+	} else {
+		nodes, err = withProviderSlot(o, key, func() ([]*providers.NodeOutput, error) {
+			return provider.CreateNodePool(o.ctx, poolConfig)
+		})
+	}
 	if err != nil {
 		return err
 	}
+	for _, node := range nodes {
+		applyNodeScheduling(node, poolConfig.Labels, poolConfig.Taints)
+		applyNodeRoles(node, poolConfig.Roles)
+		labelNodeRegion(node)
+		if poolConfig.SpotInstance {
+			labelSpotNode(node, true)
+		}
+	}
 
 	o.mu.Lock()
-	o.nodes[poolConfig.Provider] = append(o.nodes[poolConfig.Provider], nodes...)
+	o.nodes[key] = append(o.nodes[key], nodes...)
+	o.deployedPools = append(o.deployedPools, poolName)
 	o.mu.Unlock()
 
 	return nil
 }
 
-// verifyNodeDistribution verifies the node distribution matches requirements
+// deployResult builds a DeployResult from the nodes accumulated so far.
+// Configured nodes that were never created, and the nodes of pools that
+// never deployed that were not created by a partial batched deployment, are
+// reported as skipped.
+func (o *Orchestrator) deployResult() *DeployResult {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	result := &DeployResult{
+		PoolsDeployed:     append([]string{}, o.deployedPools...),
+		SkippedNodes:      []string{},
+		PerProviderCounts: make(map[string]int),
+	}
+	sort.Strings(result.PoolsDeployed)
+
+	created := make(map[string]bool)
+	for key, nodes := range o.nodes {
+		result.PerProviderCounts[providerFromKey(key)] += len(nodes)
+		result.NodesCreated += len(nodes)
+		for _, node := range nodes {
+			created[node.Name] = true
+		}
+	}
+
+	for _, node := range o.config.Nodes {
+		if !created[node.Name] {
+			result.SkippedNodes = append(result.SkippedNodes, node.Name)
+		}
+	}
+
+	for _, poolResult := range o.poolResults {
+		result.SpotFallbacks += len(poolResult.SpotFallbacks)
+	}
+
+	deployed := make(map[string]bool, len(o.deployedPools))
+	for _, poolName := range o.deployedPools {
+		deployed[poolName] = true
+	}
+	poolNames := make([]string, 0, len(o.config.NodePools))
+	for poolName := range o.config.NodePools {
+		if !deployed[poolName] {
+			poolNames = append(poolNames, poolName)
+		}
+	}
+	sort.Strings(poolNames)
+	for _, poolName := range poolNames {
+		pool := o.config.NodePools[poolName]
+		for i := 1; i <= pool.Count; i++ {
+			if name := fmt.Sprintf("%s-%d", poolName, i); !created[name] {
+				result.SkippedNodes = append(result.SkippedNodes, name)
+			}
+		}
+	}
+
+	return result
+}
+
+// dryRunNode validates a node with the provider, when it supports validation,
+// and returns a synthetic node in place of the real one
+func dryRunNode(provider providers.Provider, nodeConfig *config.NodeConfig) (*providers.NodeOutput, error) {
+	if validator, ok := provider.(providers.NodeValidator); ok {
+		if err := validator.ValidateNode(nodeConfig); err != nil {
+			return nil, err
+		}
+	}
+	return syntheticNode(nodeConfig.Name, provider.GetName(), nodeConfig.Region, nodeConfig.Size, nodeConfig.Roles, nodeConfig.Labels), nil
+}
+
+// dryRunNodePool validates a pool with the provider, when it supports
+// validation, and returns synthetic nodes in place of the real ones
+func dryRunNodePool(provider providers.Provider, poolConfig *config.NodePool) ([]*providers.NodeOutput, error) {
+	if validator, ok := provider.(providers.NodeValidator); ok {
+		if err := validator.ValidateNodePool(poolConfig); err != nil {
+			return nil, err
+		}
+	}
+	nodes := make([]*providers.NodeOutput, 0, poolConfig.Count)
+	for i := 0; i < poolConfig.Count; i++ {
+		node := syntheticNode(poolConfig.NodeName(i), provider.GetName(), poolConfig.RegionFor(i), poolConfig.Size, poolConfig.Roles, poolConfig.Labels)
+		node.WireGuardIP = poolConfig.WireGuardIPFor(i)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// syntheticNode builds a dry-run node carrying the configured labels. Unless
+// a role label is configured, it is taken from the first role so that
+// verifyNodeDistribution can check role counts.
+func syntheticNode(name, providerName, region, size string, roles []string, labels map[string]string) *providers.NodeOutput {
+	node := &providers.NodeOutput{
+		Name:     name,
+		Provider: providerName,
+		Region:   region,
+		Size:     size,
+		Labels:   make(map[string]string, len(labels)+1),
+		DryRun:   true,
+	}
+	for k, v := range labels {
+		node.Labels[k] = v
+	}
+	if _, ok := node.Labels["role"]; !ok && len(roles) > 0 {
+		node.Labels["role"] = roles[0]
+	}
+	applyNodeRoles(node, roles)
+	return node
+}
+
+// rolesLabel lists every role of a multi-role node, comma-joined (e.g.
+// "master,worker"). Single-role nodes only carry the "role" label.
+const rolesLabel = "roles"
+
+// applyNodeRoles records the roles of a multi-role node in the roles label,
+// unless the node already carries one
+func applyNodeRoles(node *providers.NodeOutput, roles []string) {
+	if len(roles) < 2 || node.Labels[rolesLabel] != "" {
+		return
+	}
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	node.Labels[rolesLabel] = strings.Join(roles, ",")
+}
+
+// regionLabel is the well-known Kubernetes label carrying a node's region
+const regionLabel = "topology.kubernetes.io/region"
+
+// labelNodeRegion sets the region label of a node, so workloads can spread
+// across the regions of a pool. The labels map is copied since providers may
+// share one map across a pool's nodes.
+func labelNodeRegion(node *providers.NodeOutput) {
+	if node.Region == "" {
+		return
+	}
+	labels := make(map[string]string, len(node.Labels)+1)
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	labels[regionLabel] = node.Region
+	node.Labels = labels
+}
+
+// nodeRoles returns the roles a node carries: the roles label when set,
+// otherwise the role label
+func nodeRoles(node *providers.NodeOutput) []string {
+	if roles := node.Labels[rolesLabel]; roles != "" {
+		return strings.Split(roles, ",")
+	}
+	if role, ok := node.Labels["role"]; ok {
+		return []string{role}
+	}
+	return nil
+}
+
+// sameRole reports whether two role names refer to the same role; "master"
+// and "controlplane" are synonyms
+func sameRole(a, b string) bool {
+	isMaster := func(role string) bool { return role == "master" || role == "controlplane" }
+	return a == b || (isMaster(a) && isMaster(b))
+}
+
+// hasRole reports whether any of the roles is the given role
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if sameRole(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMasterRole reports whether any of the roles is a control plane role
+func hasMasterRole(roles []string) bool {
+	return hasRole(roles, "master")
+}
+
+// hasWorkerRole reports whether any of the roles is the worker role
+func hasWorkerRole(roles []string) bool {
+	return hasRole(roles, "worker")
+}
+
+// nodeLabels returns the configured labels of a node: the labels of the pool
+// it names, overlaid with its own
+func (o *Orchestrator) nodeLabels(nodeConfig *config.NodeConfig) map[string]string {
+	pool, ok := o.config.NodePools[nodeConfig.Pool]
+	if nodeConfig.Pool == "" || !ok || len(pool.Labels) == 0 {
+		return nodeConfig.Labels
+	}
+
+	labels := make(map[string]string, len(pool.Labels)+len(nodeConfig.Labels))
+	for k, v := range pool.Labels {
+		labels[k] = v
+	}
+	for k, v := range nodeConfig.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// applyNodeScheduling makes sure the configured labels and taints end up on the
+// node output, so they are applied at install time whether or not the provider
+// copied them over. Configured labels override the provider's, except for the
+// role label the provider set.
+func applyNodeScheduling(node *providers.NodeOutput, labels map[string]string, taints []config.TaintConfig) {
+	if len(labels) > 0 {
+		merged := make(map[string]string, len(node.Labels)+len(labels))
+		for k, v := range node.Labels {
+			merged[k] = v
+		}
+		for k, v := range labels {
+			if _, ok := node.Labels["role"]; ok && k == "role" {
+				continue
+			}
+			merged[k] = v
+		}
+		node.Labels = merged
+	}
+
+	for _, taint := range taints {
+		exists := false
+		for _, existing := range node.Taints {
+			if existing.Key == taint.Key && existing.Effect == taint.Effect {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			node.Taints = append(node.Taints, taint)
+		}
+	}
+}
+
+// configuredNodeCount returns how many nodes the config asks for: the
+// individual nodes plus the count of every pool
+func (o *Orchestrator) configuredNodeCount() int {
+	count := len(o.config.Nodes)
+	for _, pool := range o.config.NodePools {
+		count += pool.Count
+	}
+	return count
+}
+
+// verifyNodeDistribution verifies the node distribution matches requirements.
+// The individual nodes and the pools of the config set the expected counts;
+// a config without pools expects only its individual nodes.
 func (o *Orchestrator) verifyNodeDistribution() error {
 	totalNodes := 0
 	masterNodes := 0
@@ -346,28 +1107,25 @@ func (o *Orchestrator) verifyNodeDistribution() error {
 	doNodes := 0
 	linodeNodes := 0
 
-	for provider, nodes := range o.nodes {
+	for key, nodes := range o.nodeSnapshot() {
 		for _, node := range nodes {
 			totalNodes++
 
 			// Count by provider
-			switch provider {
+			switch providerFromKey(key) {
 			case "digitalocean":
 				doNodes++
 			case "linode":
 				linodeNodes++
 			}
 
-			// Count by role
-			if node.Labels != nil {
-				if role, ok := node.Labels["role"]; ok {
-					switch role {
-					case "master", "controlplane":
-						masterNodes++
-					case "worker":
-						workerNodes++
-					}
-				}
+			// Count by role; a multi-role node counts toward each of its roles
+			roles := nodeRoles(node)
+			if hasMasterRole(roles) {
+				masterNodes++
+			}
+			if hasWorkerRole(roles) {
+				workerNodes++
 			}
 		}
 	}
@@ -377,14 +1135,23 @@ func (o *Orchestrator) verifyNodeDistribution() error {
 	expectedMasters := 0
 	expectedWorkers := 0
 
+	for _, node := range o.config.Nodes {
+		expectedTotal++
+		if hasMasterRole(node.Roles) {
+			expectedMasters++
+		}
+		if hasWorkerRole(node.Roles) {
+			expectedWorkers++
+		}
+	}
+
 	for _, pool := range o.config.NodePools {
 		expectedTotal += pool.Count
-		for _, role := range pool.Roles {
-			if role == "master" || role == "controlplane" {
-				expectedMasters += pool.Count
-			} else if role == "worker" {
-				expectedWorkers += pool.Count
-			}
+		if hasMasterRole(pool.Roles) {
+			expectedMasters += pool.Count
+		}
+		if hasWorkerRole(pool.Roles) {
+			expectedWorkers += pool.Count
 		}
 	}
 
@@ -401,26 +1168,32 @@ func (o *Orchestrator) verifyNodeDistribution() error {
 		return fmt.Errorf("expected %d worker nodes, got %d", expectedWorkers, workerNodes)
 	}
 
-	o.ctx.Log.Info(fmt.Sprintf("Node distribution verified: %d total (%d masters, %d workers)", totalNodes, masterNodes, workerNodes), nil)
+	o.log.Info(fmt.Sprintf("Node distribution verified: %d total (%d masters, %d workers)", totalNodes, masterNodes, workerNodes))
 
 	return nil
 }
 
 // configureDNS configures DNS records for all nodes
 func (o *Orchestrator) configureDNS() error {
-	// Check if we have a domain configured
+	// Without a domain the manager creates no records
 	domain := o.config.Network.DNS.Domain
 	if domain == "" {
-		// Default to chalkan3.com.br if not configured
-		domain = "chalkan3.com.br"
+		o.log.Info("No DNS domain configured, skipping DNS records")
+		o.dnsManager = dns.NewManager(o.ctx, "")
+		return nil
+	}
+
+	provider, err := dns.NewProvider(o.ctx, o.config)
+	if err != nil {
+		return err
 	}
 
-	o.ctx.Log.Info("Configuring DNS records", nil)
+	o.log.Info(fmt.Sprintf("Configuring DNS records for %s via %s", domain, provider.Name()))
 
-	o.dnsManager = dns.NewManager(o.ctx, domain)
+	o.dnsManager = dns.NewManagerWithProvider(o.ctx, domain, provider)
 
 	// Create DNS records for all nodes
-	if err := o.dnsManager.CreateNodeRecords(o.nodes); err != nil {
+	if err := o.dnsManager.CreateNodeRecords(o.nodeSnapshot()); err != nil {
 		return fmt.Errorf("failed to create node DNS records: %w", err)
 	}
 
@@ -437,22 +1210,40 @@ func (o *Orchestrator) configureDNS() error {
 
 // configureVPN configures the VPN based on network mode (WireGuard or Tailscale)
 func (o *Orchestrator) configureVPN() error {
-	// Check which VPN mode is enabled
-	if o.config.Network.Tailscale != nil && o.config.Network.Tailscale.Enabled {
-		return o.configureTailscale()
+	vpn := o.config.Network.SelectedVPN()
+	logVPNSelection(o.log, o.config.Network)
+
+	// The VPN subnet is routed alongside the node, pod and service CIDRs
+	if err := o.checkCIDRs(); err != nil {
+		return err
 	}
 
-	if o.config.Network.WireGuard != nil && o.config.Network.WireGuard.Enabled {
+	switch vpn {
+	case config.VPNTailscale:
+		return o.configureTailscale()
+	case config.VPNWireGuard:
 		return o.configureWireGuard()
 	}
 
-	o.ctx.Log.Info("No VPN configured, skipping VPN setup", nil)
+	o.log.Info("No VPN configured, skipping VPN setup")
 	return nil
 }
 
+// logVPNSelection logs which VPN is used when both are enabled
+func logVPNSelection(log logging.Logger, network config.NetworkConfig) {
+	if !network.BothVPNsEnabled() {
+		return
+	}
+	if network.SelectedVPN() == config.VPNWireGuard {
+		log.Warn("both VPNs enabled; using WireGuard, ignoring Tailscale")
+		return
+	}
+	log.Warn("both VPNs enabled; using Tailscale, ignoring WireGuard")
+}
+
 // configureTailscale configures Tailscale VPN on all nodes via Headscale
 func (o *Orchestrator) configureTailscale() error {
-	o.ctx.Log.Info("Configuring Tailscale VPN via Headscale", nil)
+	o.log.Info("Configuring Tailscale VPN via Headscale")
 
 	o.tailscaleManager = security.NewTailscaleManager(o.ctx, o.config.Network.Tailscale)
 
@@ -464,24 +1255,10 @@ func (o *Orchestrator) configureTailscale() error {
 
 	// Auto-provision Headscale server if requested
 	if o.config.Network.Tailscale.Create {
-		o.ctx.Log.Info("Auto-provisioning Headscale coordination server", nil)
-
-		// Get subnet ID from network manager if available
-		var subnetID pulumi.StringOutput
-		if o.networkManager != nil {
-			// Use the first available subnet
-			subnetID = pulumi.String("").ToStringOutput()
-		}
-
-		result, err := o.tailscaleManager.CreateHeadscaleServerIfNeeded(nil, nil, subnetID)
-		if err != nil {
+		o.log.Info("Auto-provisioning Headscale coordination server")
+		if err := o.provisionHeadscale(); err != nil {
 			return fmt.Errorf("failed to create Headscale server: %w", err)
 		}
-
-		if result != nil {
-			o.ctx.Log.Info("✓ Headscale server provisioned", nil)
-			o.tailscaleManager.SetHeadscaleInfo(result.APIURL, result.AuthKey, nil)
-		}
 	}
 
 	// Validate Tailscale configuration
@@ -490,7 +1267,7 @@ func (o *Orchestrator) configureTailscale() error {
 	}
 
 	// Configure Tailscale on each node
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		for _, node := range nodes {
 			if err := o.tailscaleManager.ConfigureNode(node); err != nil {
 				return fmt.Errorf("failed to configure Tailscale on %s: %w", node.Name, err)
@@ -498,12 +1275,17 @@ func (o *Orchestrator) configureTailscale() error {
 		}
 	}
 
+	if err := o.applyTailscaleACLPolicy(defaultACLPolicyTimeout); err != nil {
+		return err
+	}
+
 	// Initialize VPN connectivity checker
-	o.ctx.Log.Info("Initializing VPN connectivity verification for Tailscale", nil)
+	o.log.Info("Initializing VPN connectivity verification for Tailscale")
 	o.vpnChecker = network.NewVPNConnectivityChecker(o.ctx)
+	o.vpnChecker.SetLogger(o.log)
 
 	// Add all nodes to VPN checker
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		for _, node := range nodes {
 			o.vpnChecker.AddNode(node)
 		}
@@ -516,26 +1298,90 @@ func (o *Orchestrator) configureTailscale() error {
 	}
 
 	// Wait for Tailscale to establish connections
-	o.ctx.Log.Info("Waiting for Tailscale connections to establish on all nodes", nil)
+	o.log.Info("Waiting for Tailscale connections to establish on all nodes")
 	// Note: For Tailscale, we wait for tailscale status to show Running
 	// The vpnChecker will need to be enhanced for Tailscale support
 
-	o.ctx.Log.Info("✓ Tailscale VPN configured on all nodes!", nil)
+	o.log.Info("✓ Tailscale VPN configured on all nodes!")
 	o.tailscaleManager.ExportTailscaleInfo()
 
 	return nil
 }
 
+// provisionHeadscale creates the Headscale server for Tailscale.Create. With a
+// Tailscale provider set it gets a dedicated server on that cloud; otherwise
+// it runs on the first master node. A minted pre-auth key is only used when
+// no AuthKey is configured. The server's URL is not known until its host's
+// address resolves, so it is read back through the Tailscale manager's
+// HeadscaleURL output rather than from the config.
+func (o *Orchestrator) provisionHeadscale() error {
+	tailscale := o.config.Network.Tailscale
+
+	if tailscale.Provider != "" {
+		// Get subnet ID from network manager if available
+		var subnetID pulumi.StringOutput
+		if o.networkManager != nil {
+			// Use the first available subnet
+			subnetID = pulumi.String("").ToStringOutput()
+		}
+
+		result, err := o.tailscaleManager.CreateHeadscaleServerIfNeeded(nil, nil, subnetID)
+		if err != nil {
+			return err
+		}
+		if result != nil {
+			o.log.Info("✓ Headscale server provisioned")
+			o.tailscaleManager.SetHeadscaleInfo(result.APIURL, result.AuthKey, nil)
+		}
+		return nil
+	}
+
+	host := o.headscaleHost()
+	if host == nil {
+		return fmt.Errorf("Headscale runs on the first master node, but no master node has been deployed yet")
+	}
+
+	result, err := o.tailscaleManager.ProvisionHeadscaleOnNode(host)
+	if err != nil {
+		return err
+	}
+	o.log.Info(fmt.Sprintf("✓ Headscale server provisioned on %s", host.Name))
+
+	authKey := result.AuthKey
+	if tailscale.AuthKey != "" {
+		authKey = pulumi.String(tailscale.AuthKey).ToStringOutput()
+	}
+	o.tailscaleManager.SetHeadscaleInfo(result.APIURL, authKey, result.Ready)
+	o.tailscaleManager.SetHeadscaleAPIKey(result.APIKey)
+	return nil
+}
+
+// headscaleHost returns the master node Headscale is installed on: the first
+// master by name, skipping dry-run nodes that have no machine behind them
+func (o *Orchestrator) headscaleHost() *providers.NodeOutput {
+	var host *providers.NodeOutput
+	for _, node := range o.GetMasterNodes() {
+		if node.DryRun {
+			continue
+		}
+		if host == nil || node.Name < host.Name {
+			host = node
+		}
+	}
+	return host
+}
+
 // configureWireGuard configures WireGuard VPN on all nodes
 func (o *Orchestrator) configureWireGuard() error {
 	if o.config.Network.WireGuard == nil || !o.config.Network.WireGuard.Enabled {
-		o.ctx.Log.Info("WireGuard not enabled, skipping configuration", nil)
+		o.log.Info("WireGuard not enabled, skipping configuration")
 		return nil
 	}
 
-	o.ctx.Log.Info("Configuring WireGuard VPN", nil)
+	o.log.Info("Configuring WireGuard VPN")
 
 	o.wireGuardManager = security.NewWireGuardManager(o.ctx, o.config.Network.WireGuard)
+	o.wireGuardManager.SetNodesBehindNAT(o.config.NodesBehindNAT())
 
 	// Validate WireGuard configuration
 	if err := o.wireGuardManager.ValidateConfiguration(); err != nil {
@@ -543,7 +1389,7 @@ func (o *Orchestrator) configureWireGuard() error {
 	}
 
 	// Configure WireGuard on each node
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		for _, node := range nodes {
 			if err := o.wireGuardManager.ConfigureNode(node); err != nil {
 				return fmt.Errorf("failed to configure WireGuard on %s: %w", node.Name, err)
@@ -557,11 +1403,12 @@ func (o *Orchestrator) configureWireGuard() error {
 	}
 
 	// Initialize VPN connectivity checker
-	o.ctx.Log.Info("Initializing VPN connectivity verification", nil)
+	o.log.Info("Initializing VPN connectivity verification")
 	o.vpnChecker = network.NewVPNConnectivityChecker(o.ctx)
+	o.vpnChecker.SetLogger(o.log)
 
 	// Add all nodes to VPN checker
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		for _, node := range nodes {
 			o.vpnChecker.AddNode(node)
 		}
@@ -574,14 +1421,14 @@ func (o *Orchestrator) configureWireGuard() error {
 	}
 
 	// Wait for WireGuard tunnels to be established
-	o.ctx.Log.Info("Waiting for WireGuard tunnels to establish on all nodes", nil)
+	o.log.Info("Waiting for WireGuard tunnels to establish on all nodes")
 	if err := o.vpnChecker.WaitForTunnelEstablishment(); err != nil {
 		return fmt.Errorf("failed waiting for WireGuard tunnels: %w", err)
 	}
 
 	// Verify full mesh VPN connectivity between all nodes
-	o.ctx.Log.Info("Verifying full mesh VPN connectivity between all nodes", nil)
-	o.ctx.Log.Info("This ensures every node can reach every other node via WireGuard", nil)
+	o.log.Info("Verifying full mesh VPN connectivity between all nodes")
+	o.log.Info("This ensures every node can reach every other node via WireGuard")
 
 	if err := o.vpnChecker.VerifyFullMeshConnectivity(); err != nil {
 		// Print connectivity matrix to help debug
@@ -591,16 +1438,21 @@ func (o *Orchestrator) configureWireGuard() error {
 
 	// Print successful connectivity matrix
 	o.vpnChecker.PrintConnectivityMatrix()
-	o.ctx.Log.Info("✓ VPN full mesh connectivity verified successfully!", nil)
+	o.log.Info("✓ VPN full mesh connectivity verified successfully!")
 
 	return nil
 }
 
-// configureFirewalls configures firewalls for all nodes
+// configureFirewalls configures firewalls for all nodes, opening only the
+// ports the enabled VPN, distribution and ingress need plus the config's
+// own rules
 func (o *Orchestrator) configureFirewalls() error {
-	o.ctx.Log.Info("Configuring firewalls", nil)
+	o.log.Info("Configuring firewalls")
+
+	firewall := network.RequiredFirewallConfig(fmt.Sprintf("%s-firewall", o.ctx.Stack()), o.config)
+	o.log.Debug(fmt.Sprintf("Firewall opens %d inbound rule(s)", len(firewall.InboundRules)))
 
-	if err := o.networkManager.CreateFirewalls(o.nodes); err != nil {
+	if err := o.networkManager.CreateFirewallsWithConfig(o.nodeSnapshot(), firewall); err != nil {
 		return fmt.Errorf("failed to create firewalls: %w", err)
 	}
 
@@ -609,16 +1461,16 @@ func (o *Orchestrator) configureFirewalls() error {
 
 // deployRKE deploys the RKE cluster
 func (o *Orchestrator) deployRKE() error {
-	o.ctx.Log.Info("Preparing to deploy RKE cluster", nil)
+	o.log.Info("Preparing to deploy RKE cluster")
 
 	// Collect all nodes for validation
 	allNodes := []*providers.NodeOutput{}
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		allNodes = append(allNodes, nodes...)
 	}
 
 	// Validate prerequisites for RKE installation
-	o.ctx.Log.Info("Running prerequisite validation for RKE installation", nil)
+	o.log.Info("Running prerequisite validation for RKE installation")
 	if err := o.validator.ValidateForRKE(allNodes); err != nil {
 		o.validator.PrintSummary()
 		return fmt.Errorf("RKE prerequisite validation failed: %w", err)
@@ -628,10 +1480,10 @@ func (o *Orchestrator) deployRKE() error {
 	// VPN connectivity is already verified in Phase 6.5 before we get here
 	// Just log that we're proceeding with verified connectivity
 	if o.config.Network.WireGuard != nil && o.config.Network.WireGuard.Enabled {
-		o.ctx.Log.Info("VPN connectivity already verified - proceeding with RKE deployment", nil)
+		o.log.Info("VPN connectivity already verified - proceeding with RKE deployment")
 	}
 
-	o.ctx.Log.Info("All prerequisites validated, deploying Kubernetes cluster", nil)
+	o.log.Info("All prerequisites validated, deploying Kubernetes cluster")
 
 	// Check distribution type and use appropriate manager
 	distribution := o.config.Kubernetes.Distribution
@@ -641,8 +1493,9 @@ func (o *Orchestrator) deployRKE() error {
 
 	switch distribution {
 	case "rke2":
-		o.ctx.Log.Info("Using RKE2 distribution", nil)
+		o.log.Info("Using RKE2 distribution")
 		o.rke2Manager = cluster.NewRKE2Manager(o.ctx, &o.config.Kubernetes)
+		o.drainer = o.rke2Manager
 
 		// Set SSH private key if available
 		if o.sshKeyManager != nil {
@@ -652,7 +1505,7 @@ func (o *Orchestrator) deployRKE() error {
 		}
 
 		// Add all nodes to RKE2 manager
-		for _, nodes := range o.nodes {
+		for _, nodes := range o.nodeSnapshot() {
 			for _, node := range nodes {
 				o.rke2Manager.AddNode(node)
 			}
@@ -667,11 +1520,12 @@ func (o *Orchestrator) deployRKE() error {
 		o.rke2Manager.ExportClusterInfo()
 
 	default: // "rke" or any other value defaults to RKE1
-		o.ctx.Log.Info("Using RKE1 distribution", nil)
+		o.log.Info("Using RKE1 distribution")
 		o.rkeManager = cluster.NewRKEManager(o.ctx, &o.config.Kubernetes)
+		o.drainer = o.rkeManager
 
 		// Add all nodes to RKE manager
-		for _, nodes := range o.nodes {
+		for _, nodes := range o.nodeSnapshot() {
 			for _, node := range nodes {
 				o.rkeManager.AddNode(node)
 			}
@@ -684,28 +1538,42 @@ func (o *Orchestrator) deployRKE() error {
 	}
 
 	// Wait for Kubernetes to be ready
-	o.ctx.Log.Info("Waiting for Kubernetes cluster to be ready", nil)
+	o.log.Info("Waiting for Kubernetes cluster to be ready")
 	if err := o.healthChecker.WaitForKubernetesReady(); err != nil {
 		return fmt.Errorf("Kubernetes cluster failed to become ready: %w", err)
 	}
 
-	o.ctx.Log.Info("RKE cluster deployed successfully", nil)
+	o.log.Info("RKE cluster deployed successfully")
 
 	return nil
 }
 
 // installIngress installs NGINX Ingress Controller
 func (o *Orchestrator) installIngress() error {
-	o.ctx.Log.Info("Preparing to install NGINX Ingress Controller", nil)
+	masters := o.GetMasterNodes()
+	if len(masters) == 0 {
+		return fmt.Errorf("no master nodes available for ingress installation")
+	}
+
+	opts, err := ingress.OptionsFromConfig(&o.config.Network.Ingress)
+	if err != nil {
+		return err
+	}
+	runScript, err := o.addonScriptOutputRunner("ingress")
+	if err != nil {
+		return err
+	}
+
+	o.log.Info(fmt.Sprintf("Preparing to install %s ingress controller", opts.Controller))
 
 	// Collect all nodes for validation
 	allNodes := []*providers.NodeOutput{}
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		allNodes = append(allNodes, nodes...)
 	}
 
 	// Validate prerequisites for Ingress installation
-	o.ctx.Log.Info("Running prerequisite validation for Ingress installation", nil)
+	o.log.Info("Running prerequisite validation for Ingress installation")
 	if err := o.validator.ValidateForIngress(allNodes); err != nil {
 		o.validator.PrintSummary()
 		return fmt.Errorf("Ingress prerequisite validation failed: %w", err)
@@ -713,12 +1581,13 @@ func (o *Orchestrator) installIngress() error {
 	o.validator.PrintSummary()
 
 	// Ensure Kubernetes is still healthy before proceeding
-	o.ctx.Log.Info("Verifying Kubernetes cluster health before Ingress installation", nil)
+	o.log.Info("Verifying Kubernetes cluster health before Ingress installation")
 	if err := o.healthChecker.WaitForKubernetesReady(); err != nil {
 		return fmt.Errorf("Kubernetes cluster not ready for Ingress installation: %w", err)
 	}
 
-	o.ctx.Log.Info("All prerequisites validated, installing NGINX Ingress Controller", nil)
+	o.log.Info(fmt.Sprintf("All prerequisites validated, installing %s ingress controller (class %s, %d replicas on masters)",
+		opts.Controller, opts.Class, opts.Replicas))
 
 	// Get domain for ingress
 	domain := o.config.Network.DNS.Domain
@@ -726,67 +1595,69 @@ func (o *Orchestrator) installIngress() error {
 		domain = "chalkan3.com.br"
 	}
 
-	// Create ingress manager
-	o.ingressManager = ingress.NewNginxIngressManager(o.ctx, domain)
-
-	// Get first master node
-	masterNode := o.GetMasterNodes()[0]
-	if masterNode == nil {
-		return fmt.Errorf("no master node available for ingress installation")
-	}
-
-	o.ingressManager.SetMasterNode(masterNode)
-
-	// Set SSH key path if available
-	if o.sshKeyManager != nil {
-		sshKeyPath := fmt.Sprintf("~/.ssh/kubernetes-clusters/%s.pem", o.ctx.Stack())
-		o.ingressManager.SetSSHKeyPath(sshKeyPath)
-	}
-
-	// Install NGINX Ingress
-	ingressIP, err := o.ingressManager.Install()
+	output, err := runScript("ingress-controller", ingress.RenderControllerInstall(opts))
 	if err != nil {
-		return fmt.Errorf("failed to install NGINX Ingress: %w", err)
+		return fmt.Errorf("failed to install %s ingress controller: %w", opts.Controller, err)
 	}
+	ingressIP := output.ApplyT(ingress.ParseIngressIP).(pulumi.StringOutput)
+
+	secrets.Export(o.ctx, "ingress_ip", ingressIP)
+	secrets.Export(o.ctx, "ingress_controller", pulumi.String(opts.Controller))
+	secrets.Export(o.ctx, "ingress_class", pulumi.String(opts.Class))
 
 	// Wait for Ingress to be ready
-	o.ctx.Log.Info("Waiting for NGINX Ingress Controller to be ready", nil)
+	o.log.Info("Waiting for the ingress controller to be ready")
 	if err := o.healthChecker.WaitForIngressReady(); err != nil {
-		return fmt.Errorf("NGINX Ingress Controller failed to become ready: %w", err)
+		return fmt.Errorf("ingress controller failed to become ready: %w", err)
 	}
 
-	// Update DNS records with actual ingress IP
+	// Point the ingress and wildcard DNS records at the LoadBalancer
 	if o.dnsManager != nil {
 		if err := o.dnsManager.UpdateIngressRecord(ingressIP); err != nil {
-			o.ctx.Log.Warn("Failed to update ingress DNS record", nil)
+			return fmt.Errorf("failed to create ingress DNS records: %w", err)
 		}
 	}
 
-	// Install cert-manager for TLS
-	if err := o.ingressManager.InstallCertManager(); err != nil {
-		o.ctx.Log.Warn("Failed to install cert-manager", nil)
+	o.ingressManager = ingress.NewNginxIngressManager(o.ctx, domain)
+	o.ingressManager.SetIngressClass(opts.Class)
+
+	// Request certificates from the issuer installCertManager creates
+	if o.config.Security.TLS.CertManager {
+		o.ingressManager.SetClusterIssuer(clusterIssuerName(&o.config.Security.TLS))
 	}
 
 	// Create sample ingress
 	o.ingressManager.CreateSampleIngress()
 
-	o.ctx.Log.Info("NGINX Ingress Controller installed successfully", nil)
+	o.log.Info(fmt.Sprintf("%s ingress controller installed successfully", opts.Controller))
 
 	return nil
 }
 
 // installAddons installs cluster addons
 func (o *Orchestrator) installAddons() error {
-	o.ctx.Log.Info("Installing cluster addons", nil)
+	o.log.Info("Installing cluster addons")
 
-	if o.rkeManager == nil {
-		return fmt.Errorf("RKE manager not initialized - cannot install addons")
+	// Addons run through the active RKE2 or RKE manager
+	if _, err := o.addonScriptRunner("addons"); err != nil {
+		return err
 	}
 
-	if err := o.rkeManager.InstallAddons(); err != nil {
+	installHelm := o.rkeManager.InstallAddons
+	if o.rke2Manager != nil {
+		installHelm = o.rke2Manager.InstallAddons
+	}
+	if err := installHelm(); err != nil {
 		return fmt.Errorf("failed to install addons: %w", err)
 	}
 
+	// Install cert-manager first; other addons may need certificates
+	if o.config.Security.TLS.CertManager {
+		if err := o.installCertManager(); err != nil {
+			return fmt.Errorf("failed to install cert-manager: %w", err)
+		}
+	}
+
 	// Install storage if configured
 	if len(o.config.Storage.Classes) > 0 {
 		if err := o.installStorage(); err != nil {
@@ -794,45 +1665,175 @@ func (o *Orchestrator) installAddons() error {
 		}
 	}
 
+	// Install monitoring if configured
+	if o.config.Monitoring.Enabled {
+		if err := o.installMonitoring(); err != nil {
+			return fmt.Errorf("failed to install monitoring: %w", err)
+		}
+	}
+
+	// Install logging if configured
+	if o.config.Monitoring.Logging != nil {
+		if err := o.installLogging(); err != nil {
+			return fmt.Errorf("failed to install logging: %w", err)
+		}
+	}
+
+	// Install tracing if configured
+	if o.config.Monitoring.Tracing != nil {
+		if err := o.installTracing(); err != nil {
+			return fmt.Errorf("failed to install tracing: %w", err)
+		}
+	}
+
+	// Install backups if configured
+	if o.config.Backup != nil && o.config.Backup.Enabled {
+		if err := o.installBackup(); err != nil {
+			return fmt.Errorf("failed to install backup: %w", err)
+		}
+	}
+
 	// Install load balancers if configured
-	if len([]*config.LoadBalancerConfig{&o.config.LoadBalancer}) > 0 {
+	if len(o.config.AllLoadBalancers()) > 0 {
 		if err := o.installLoadBalancers(); err != nil {
 			return fmt.Errorf("failed to install load balancers: %w", err)
 		}
 	}
 
+	// Harden namespaces last, once every addon namespace exists
+	if !o.config.Security.PodSecurity.EffectiveProfiles().IsEmpty() {
+		if err := o.installPodSecurity(); err != nil {
+			return fmt.Errorf("failed to install pod security: %w", err)
+		}
+	}
+
+	if o.config.Security.NetworkPolicies {
+		if err := o.installNetworkPolicies(); err != nil {
+			return fmt.Errorf("failed to install network policies: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// installStorage installs storage classes
+// installStorage renders the configured storage classes and applies them
+// through the cluster manager
 func (o *Orchestrator) installStorage() error {
-	// Implementation would install configured storage providers
-	o.ctx.Log.Info("Storage configuration detected but not implemented", nil)
+	if len(o.config.Storage.Classes) == 0 {
+		return nil
+	}
+
+	manifest, err := renderStorageClasses(&o.config.Storage)
+	if err != nil {
+		return err
+	}
+
+	o.log.Info(fmt.Sprintf("Applying %d storage classes", len(o.config.Storage.Classes)))
+	switch {
+	case o.rke2Manager != nil:
+		err = o.rke2Manager.ApplyManifest("storage-classes", manifest)
+	case o.rkeManager != nil:
+		err = o.rkeManager.ApplyManifest("storage-classes", manifest)
+	default:
+		return fmt.Errorf("cluster manager not initialized - cannot apply storage classes")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply storage classes: %w", err)
+	}
 	return nil
 }
 
-// installLoadBalancers installs load balancers
+// addonScriptRunner returns the RunScript of the active RKE2 or RKE manager,
+// which addon installs use to run Helm on the first master
+func (o *Orchestrator) addonScriptRunner(addon string) (func(name, script string) error, error) {
+	switch {
+	case o.rke2Manager != nil:
+		return o.rke2Manager.RunScript, nil
+	case o.rkeManager != nil:
+		return o.rkeManager.RunScript, nil
+	default:
+		return nil, fmt.Errorf("RKE manager not initialized - cannot install %s", addon)
+	}
+}
+
+// addonScriptOutputRunner is addonScriptRunner for scripts whose standard
+// output is needed
+func (o *Orchestrator) addonScriptOutputRunner(addon string) (func(name, script string) (pulumi.StringOutput, error), error) {
+	switch {
+	case o.rke2Manager != nil:
+		return o.rke2Manager.RunScriptOutput, nil
+	case o.rkeManager != nil:
+		return o.rkeManager.RunScriptOutput, nil
+	default:
+		return nil, fmt.Errorf("RKE manager not initialized - cannot install %s", addon)
+	}
+}
+
+// installLoadBalancers creates every configured load balancer through its
+// provider, waits for a healthy backend and exports its address keyed by
+// name. The API server load
+// balancer targets the masters and the others the workers, unless their
+// target says otherwise.
 func (o *Orchestrator) installLoadBalancers() error {
-	for _, lbConfig := range []*config.LoadBalancerConfig{&o.config.LoadBalancer} {
+	for _, lbConfig := range o.config.AllLoadBalancers() {
+		// MetalLB runs inside the cluster rather than at a provider
+		if strings.EqualFold(lbConfig.Provider, "metallb") {
+			continue
+		}
+
 		provider, ok := o.providerRegistry.Get(lbConfig.Provider)
 		if !ok {
-			return fmt.Errorf("provider %s not found for load balancer", lbConfig.Provider)
+			return fmt.Errorf("%w for load balancer", &ProviderNotFoundError{Provider: lbConfig.Provider})
 		}
 
-		lb, err := provider.CreateLoadBalancer(o.ctx, lbConfig)
+		targets, err := o.loadBalancerTargets(&lbConfig)
+		if err != nil {
+			return err
+		}
+		lbConfig.TargetNodes = targets
+
+		lb, err := provider.CreateLoadBalancer(o.ctx, &lbConfig)
 		if err != nil {
 			return fmt.Errorf("failed to create load balancer %s: %w", lbConfig.Name, err)
 		}
+		if err := o.waitForLoadBalancerReady(&lbConfig, lb, loadBalancerReadyTimeout); err != nil {
+			return fmt.Errorf("load balancer %s not ready: %w", lbConfig.Name, err)
+		}
 
 		secrets.Export(o.ctx, fmt.Sprintf("lb_%s_ip", lbConfig.Name), lb.IP)
+		secrets.Export(o.ctx, fmt.Sprintf("lb_%s_status", lbConfig.Name), lb.Status)
+		secrets.Export(o.ctx, fmt.Sprintf("lb_%s_backends", lbConfig.Name), pulumi.Int(lb.Backends))
 	}
 
 	return nil
 }
 
+// loadBalancerTargets returns the sorted names of the nodes with the load
+// balancer's target role. When no node has it, the result is empty and the
+// provider targets all of its nodes.
+func (o *Orchestrator) loadBalancerTargets(lb *config.LoadBalancerConfig) ([]string, error) {
+	var role string
+	switch lb.TargetRole() {
+	case config.LoadBalancerTargetMasters:
+		role = "master"
+	case config.LoadBalancerTargetWorkers:
+		role = "worker"
+	default:
+		return nil, fmt.Errorf("load balancer %s has unknown target %q; expected %s or %s",
+			lb.Name, lb.Target, config.LoadBalancerTargetMasters, config.LoadBalancerTargetWorkers)
+	}
+
+	var names []string
+	for _, node := range o.GetNodesByRole(role) {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // exportOutputs exports all cluster outputs
 func (o *Orchestrator) exportOutputs() {
-	o.ctx.Log.Info("Exporting cluster outputs", nil)
+	o.log.Info("Exporting cluster outputs")
 
 	// Export metadata
 	secrets.Export(o.ctx, "cluster_name", pulumi.String(o.config.Metadata.Name))
@@ -841,10 +1842,10 @@ func (o *Orchestrator) exportOutputs() {
 
 	// Export node information
 	nodeOutputs := make(map[string]interface{})
-	for provider, nodes := range o.nodes {
+	for key, nodes := range o.nodeSnapshot() {
 		for _, node := range nodes {
 			nodeOutputs[node.Name] = map[string]interface{}{
-				"provider":     provider,
+				"provider":     providerFromKey(key),
 				"public_ip":    node.PublicIP,
 				"private_ip":   node.PrivateIP,
 				"wireguard_ip": node.WireGuardIP,
@@ -855,6 +1856,15 @@ func (o *Orchestrator) exportOutputs() {
 	}
 	secrets.Export(o.ctx, "nodes", pulumi.ToMap(nodeOutputs))
 
+	// Export WireGuard allocations so the next deployment keeps them
+	if allocations := o.WireGuardAllocations(); len(allocations) > 0 {
+		wireGuardIPs := make(map[string]interface{}, len(allocations))
+		for name, ip := range allocations {
+			wireGuardIPs[name] = ip
+		}
+		secrets.Export(o.ctx, "wireguard_allocations", pulumi.ToMap(wireGuardIPs))
+	}
+
 	// Export network information
 	if o.networkManager != nil {
 		o.networkManager.ExportNetworkOutputs()
@@ -933,68 +1943,150 @@ func (o *Orchestrator) exportOutputs() {
 	})
 }
 
-// Cleanup performs cleanup operations
+// Cleanup performs cleanup operations, draining nodes first when the
+// cluster has a Kubernetes manager. Provider failures are logged, not
+// returned; use CleanupWithReport to inspect them or CleanupStrict to
+// get them as an error.
 func (o *Orchestrator) Cleanup() error {
-	o.ctx.Log.Info("Performing cleanup operations", nil)
+	o.CleanupWithReport()
+	return nil
+}
 
-	for _, provider := range o.providerRegistry.GetAll() {
-		if err := provider.Cleanup(o.ctx); err != nil {
-			o.ctx.Log.Warn("Cleanup failed for provider", nil)
-		}
+// CleanupStrict performs the same cleanup as Cleanup, still calling every
+// provider when one fails, but returns the failures joined, one
+// ProviderCleanupError per provider, so callers can tell resources may be
+// left over. It returns nil when every provider cleaned up.
+func (o *Orchestrator) CleanupStrict() error {
+	report := o.CleanupWithReport()
+
+	keys := make([]string, 0, len(report.ProviderErrors))
+	for key := range report.ProviderErrors {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	return nil
+	errs := make([]error, 0, len(keys))
+	for _, key := range keys {
+		errs = append(errs, &ProviderCleanupError{Provider: key, Err: report.ProviderErrors[key]})
+	}
+	return errors.Join(errs...)
 }
 
-// GetNodeByName returns a node by name
+// nodeSnapshot returns a copy of the tracked nodes by provider key, taken
+// under o.mu so node pools deploying concurrently do not change it while a
+// phase ranges over it
+func (o *Orchestrator) nodeSnapshot() map[string][]*providers.NodeOutput {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	snapshot := make(map[string][]*providers.NodeOutput, len(o.nodes))
+	for key, nodes := range o.nodes {
+		snapshot[key] = append([]*providers.NodeOutput{}, nodes...)
+	}
+	return snapshot
+}
+
+// GetNodeByName returns a node by name, searching every provider key
 func (o *Orchestrator) GetNodeByName(name string) (*providers.NodeOutput, error) {
-	for _, nodes := range o.nodes {
+	node, _, err := o.findNode(name)
+	return node, err
+}
+
+// findNode returns a node by name and the provider key it is stored under
+func (o *Orchestrator) findNode(name string) (*providers.NodeOutput, string, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	for key, nodes := range o.nodes {
 		for _, node := range nodes {
 			if node.Name == name {
-				return node, nil
+				return node, key, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("node %s not found", name)
+	return nil, "", &NodeNotFoundError{Name: name}
 }
 
-// GetNodesByProvider returns all nodes for a provider
-func (o *Orchestrator) GetNodesByProvider(provider string) ([]*providers.NodeOutput, error) {
-	nodes, ok := o.nodes[provider]
+// GetProviderForNode returns a node by name with the provider instance that
+// created it, including one registered for a credentials override. It
+// returns a *NodeNotFoundError when no node has the name and a
+// *ProviderNotFoundError when its provider is no longer registered.
+func (o *Orchestrator) GetProviderForNode(name string) (providers.Provider, *providers.NodeOutput, error) {
+	node, key, err := o.findNode(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o.providerMu.Lock()
+	provider, ok := o.providerRegistry.Get(key)
+	o.providerMu.Unlock()
 	if !ok {
+		return nil, node, &ProviderNotFoundError{Provider: key}
+	}
+	return provider, node, nil
+}
+
+// GetNodesByProvider returns all nodes for a provider, including those created
+// with a credentials override. A composite "<provider>#<hash>" key returns
+// only the nodes created with those credentials. The result is a copy, like
+// GetNodesByRole's.
+func (o *Orchestrator) GetNodesByProvider(provider string) ([]*providers.NodeOutput, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if strings.Contains(provider, providerKeySeparator) {
+		nodes, ok := o.nodes[provider]
+		if !ok {
+			return nil, fmt.Errorf("no nodes found for provider %s", provider)
+		}
+		return append([]*providers.NodeOutput{}, nodes...), nil
+	}
+
+	keys := make([]string, 0, len(o.nodes))
+	for key := range o.nodes {
+		if providerFromKey(key) == provider {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
 		return nil, fmt.Errorf("no nodes found for provider %s", provider)
 	}
+	sort.Strings(keys)
+
+	var nodes []*providers.NodeOutput
+	for _, key := range keys {
+		nodes = append(nodes, o.nodes[key]...)
+	}
 	return nodes, nil
 }
 
-// GetMasterNodes returns all master nodes
-func (o *Orchestrator) GetMasterNodes() []*providers.NodeOutput {
-	masters := []*providers.NodeOutput{}
+// GetNodesByRole returns all nodes carrying the given role, across every
+// provider. "master" and "controlplane" are treated as the same role. The
+// result is empty, not nil, when no node matches, and is a copy that pools
+// deploying concurrently do not change.
+func (o *Orchestrator) GetNodesByRole(role string) []*providers.NodeOutput {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	matches := []*providers.NodeOutput{}
 	for _, nodes := range o.nodes {
 		for _, node := range nodes {
-			if node.Labels != nil {
-				if role, ok := node.Labels["role"]; ok && (role == "master" || role == "controlplane") {
-					masters = append(masters, node)
-				}
+			if hasRole(nodeRoles(node), role) {
+				matches = append(matches, node)
 			}
 		}
 	}
-	return masters
+	return matches
+}
+
+// GetMasterNodes returns all master nodes
+func (o *Orchestrator) GetMasterNodes() []*providers.NodeOutput {
+	return o.GetNodesByRole("master")
 }
 
 // GetWorkerNodes returns all worker nodes
 func (o *Orchestrator) GetWorkerNodes() []*providers.NodeOutput {
-	workers := []*providers.NodeOutput{}
-	for _, nodes := range o.nodes {
-		for _, node := range nodes {
-			if node.Labels != nil {
-				if role, ok := node.Labels["role"]; ok && role == "worker" {
-					workers = append(workers, node)
-				}
-			}
-		}
-	}
-	return workers
+	return o.GetNodesByRole("worker")
 }
 
 // verifyVPNReadyForRKE performs comprehensive VPN verification before RKE deployment
@@ -1002,9 +2094,10 @@ func (o *Orchestrator) verifyVPNReadyForRKE() error {
 	// Initialize VPN checker if not already done
 	if o.vpnChecker == nil {
 		o.vpnChecker = network.NewVPNConnectivityChecker(o.ctx)
+		o.vpnChecker.SetLogger(o.log)
 
 		// Add all nodes to VPN checker
-		for _, nodes := range o.nodes {
+		for _, nodes := range o.nodeSnapshot() {
 			for _, node := range nodes {
 				o.vpnChecker.AddNode(node)
 			}
@@ -1018,40 +2111,40 @@ func (o *Orchestrator) verifyVPNReadyForRKE() error {
 	}
 
 	// Step 1: Verify WireGuard is running on all nodes
-	o.ctx.Log.Info("Step 1: Verifying WireGuard service on all nodes", nil)
+	o.log.Info("Step 1: Verifying WireGuard service on all nodes")
 	if err := o.vpnChecker.WaitForTunnelEstablishment(); err != nil {
 		return fmt.Errorf("WireGuard tunnels not established: %w", err)
 	}
-	o.ctx.Log.Info("✓ WireGuard running on all nodes", nil)
+	o.log.Info("✓ WireGuard running on all nodes")
 
 	// Step 2: Verify full mesh connectivity
-	o.ctx.Log.Info("Step 2: Verifying full mesh VPN connectivity", nil)
-	o.ctx.Log.Info("Each node must reach every other node for RKE to work", nil)
+	o.log.Info("Step 2: Verifying full mesh VPN connectivity")
+	o.log.Info("Each node must reach every other node for RKE to work")
 
 	if err := o.vpnChecker.VerifyFullMeshConnectivity(); err != nil {
-		o.ctx.Log.Error("VPN Connectivity FAILED - Cannot proceed with RKE", nil)
+		o.log.Error("VPN Connectivity FAILED - Cannot proceed with RKE")
 		o.vpnChecker.PrintConnectivityMatrix()
 
 		// Provide detailed error information
-		o.ctx.Log.Error("RKE Requirements NOT Met:", nil)
-		o.ctx.Log.Error("- All master nodes must reach each other", nil)
-		o.ctx.Log.Error("- All worker nodes must reach all masters", nil)
-		o.ctx.Log.Error("- etcd requires full connectivity between masters", nil)
+		o.log.Error("RKE Requirements NOT Met:")
+		o.log.Error("- All master nodes must reach each other")
+		o.log.Error("- All worker nodes must reach all masters")
+		o.log.Error("- etcd requires full connectivity between masters")
 
 		return fmt.Errorf("VPN connectivity check failed: %w", err)
 	}
 
 	// Step 3: Print connectivity matrix for verification
-	o.ctx.Log.Info("✓ Full mesh connectivity verified", nil)
+	o.log.Info("✓ Full mesh connectivity verified")
 	o.vpnChecker.PrintConnectivityMatrix()
 
 	// Step 4: Verify specific RKE requirements
-	o.ctx.Log.Info("Step 3: Verifying RKE-specific connectivity requirements", nil)
+	o.log.Info("Step 3: Verifying RKE-specific connectivity requirements")
 
 	// Check master-to-master connectivity
 	masters := o.GetMasterNodes()
 	if len(masters) > 1 {
-		o.ctx.Log.Info("Verifying master-to-master connectivity for etcd cluster", nil)
+		o.log.Info("Verifying master-to-master connectivity for etcd cluster")
 		matrix := o.vpnChecker.GetConnectivityMatrix()
 
 		for _, master1 := range masters {
@@ -1066,13 +2159,13 @@ func (o *Orchestrator) verifyVPNReadyForRKE() error {
 				}
 			}
 		}
-		o.ctx.Log.Info("✓ All masters can communicate for etcd", nil)
+		o.log.Info("✓ All masters can communicate for etcd")
 	}
 
 	// Check worker-to-master connectivity
 	workers := o.GetWorkerNodes()
 	if len(workers) > 0 && len(masters) > 0 {
-		o.ctx.Log.Info("Verifying worker-to-master connectivity for API access", nil)
+		o.log.Info("Verifying worker-to-master connectivity for API access")
 		matrix := o.vpnChecker.GetConnectivityMatrix()
 
 		for _, worker := range workers {
@@ -1085,15 +2178,15 @@ func (o *Orchestrator) verifyVPNReadyForRKE() error {
 				}
 			}
 		}
-		o.ctx.Log.Info("✓ All workers can reach masters for API access", nil)
+		o.log.Info("✓ All workers can reach masters for API access")
 	}
 
 	// Step 5: Final validation
-	o.ctx.Log.Info("Step 4: Final VPN validation", nil)
+	o.log.Info("Step 4: Final VPN validation")
 
 	// Get all nodes for a final check
 	allNodes := []*providers.NodeOutput{}
-	for _, nodes := range o.nodes {
+	for _, nodes := range o.nodeSnapshot() {
 		allNodes = append(allNodes, nodes...)
 	}
 
@@ -1102,7 +2195,7 @@ func (o *Orchestrator) verifyVPNReadyForRKE() error {
 		return fmt.Errorf("expected 6 nodes for VPN mesh, found %d", len(allNodes))
 	}
 
-	o.ctx.Log.Info("✓ All VPN checks passed", nil)
+	o.log.Info("✓ All VPN checks passed")
 
 	return nil
 }
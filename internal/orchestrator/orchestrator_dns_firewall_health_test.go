@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/chalkan3/sloth-kubernetes/pkg/cluster"
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -76,9 +77,9 @@ func TestOrchestrator_DNSCustomDomains(t *testing.T) {
 			expected: "cluster.production.example.com",
 		},
 		{
-			name:     "Default domain when empty",
+			name:     "No records when empty",
 			domain:   "",
-			expected: "chalkan3.com.br",
+			expected: "",
 		},
 	}
 	for _, tt := range tests {
@@ -101,6 +102,7 @@ func TestOrchestrator_DNSCustomDomains(t *testing.T) {
 				orch := New(ctx, cfg)
 				err := orch.configureDNS()
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, orch.dnsManager.GetDomain())
 				return nil
 			}, pulumi.WithMocks("test", "dns-domain", &IntegrationMockProvider{}))
 			assert.NoError(t, err)
@@ -393,7 +395,7 @@ func TestOrchestrator_NodeRetrievalMethodsWithNodes(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-// Test 10: Orchestrator with AWS provider (GCP not available in this build)
+// Test 10: Orchestrator with AWS provider
 func TestOrchestrator_WithAWSAndGCPProviders(t *testing.T) {
 	// Create temporary SSH key files for the test
 	tmpDir := t.TempDir()
@@ -435,6 +437,31 @@ func TestOrchestrator_WithAWSAndGCPProviders(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// Test 10b: Orchestrator registers the Hetzner provider when enabled
+func TestOrchestrator_WithHetznerProvider(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		cfg := &config.ClusterConfig{
+			Metadata: config.Metadata{Name: "hetzner-cluster"},
+			Providers: config.ProvidersConfig{
+				Hetzner: &config.HetznerProvider{
+					Enabled:      true,
+					Token:        "hetzner-token",
+					Location:     "fsn1",
+					SSHPublicKey: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAtest test@test",
+				},
+			},
+		}
+		orch := New(ctx, cfg)
+		err := orch.initializeProviders()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(orch.providerRegistry.GetAll()))
+		_, hasHetzner := orch.providerRegistry.Get("hetzner")
+		assert.True(t, hasHetzner)
+		return nil
+	}, pulumi.WithMocks("test", "hetzner-provider", &IntegrationMockProvider{}))
+	assert.NoError(t, err)
+}
+
 // Test 11: WireGuard configuration with multiple node pools
 func TestOrchestrator_WireGuardWithMultipleNodePools(t *testing.T) {
 	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
@@ -735,6 +762,8 @@ func TestOrchestrator_IngressInstallationPrerequisites(t *testing.T) {
 				Labels:    map[string]string{"role": "master"},
 			},
 		}
+		orch.rkeManager = cluster.NewRKEManager(ctx, &cfg.Kubernetes)
+		orch.rkeManager.AddNode(orch.nodes["digitalocean"][0])
 		// Test ingress installation (prerequisite validation passes in test context)
 		err := orch.installIngress()
 		// In test context with mocked nodes, validation passes but may fail at later stages
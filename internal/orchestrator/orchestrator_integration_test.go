@@ -378,11 +378,11 @@ func TestOrchestrator_ConfigureDNS(t *testing.T) {
 			expectDomain: "example.com",
 		},
 		{
-			name: "Default domain",
+			name: "No domain skips records",
 			dnsConfig: config.DNSConfig{
 				Domain: "",
 			},
-			expectDomain: "chalkan3.com.br",
+			expectDomain: "",
 		},
 	}
 	for _, tt := range tests {
@@ -402,7 +402,8 @@ func TestOrchestrator_ConfigureDNS(t *testing.T) {
 				err := orch.configureDNS()
 				// DNS configuration should work or skip if not enabled
 				assert.NoError(t, err)
-				assert.NotNil(t, orch.dnsManager)
+				require.NotNil(t, orch.dnsManager)
+				assert.Equal(t, tt.expectDomain, orch.dnsManager.GetDomain())
 				return nil
 			}, pulumi.WithMocks("test", "integration", &IntegrationMockProvider{}))
 			assert.NoError(t, err)
@@ -616,9 +617,10 @@ func TestOrchestrator_InstallStorage(t *testing.T) {
 			},
 		}
 		orch := New(ctx, cfg)
-		// Test storage installation
+		// Storage classes are applied through the cluster manager, which is
+		// only set once the cluster is deployed
 		err := orch.installStorage()
-		assert.NoError(t, err) // Should not error, just log
+		assert.ErrorContains(t, err, "cluster manager not initialized")
 		return nil
 	}, pulumi.WithMocks("test", "integration", &IntegrationMockProvider{}))
 	assert.NoError(t, err)
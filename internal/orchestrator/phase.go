@@ -0,0 +1,142 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+)
+
+// Deploy phases, as the phase field of their log events names them
+const (
+	phasePlacement       = "placement"
+	phaseSSHKeys         = "ssh-keys"
+	phaseProviders       = "providers"
+	phaseCost            = "cost"
+	phaseNetworking      = "networking"
+	phaseBastion         = "bastion"
+	phaseNodes           = "nodes"
+	phaseNodeReadiness   = "node-readiness"
+	phaseDNS             = "dns"
+	phaseVPN             = "vpn"
+	phaseFirewalls       = "firewalls"
+	phaseVPNVerification = "vpn-verification"
+	phaseKubernetes      = "kubernetes"
+	phaseIngress         = "ingress"
+	phaseAddons          = "addons"
+	phaseOutputs         = "outputs"
+)
+
+// poolTimingKey returns the PhaseTimings key of a node pool's creation
+func poolTimingKey(poolName string) string {
+	return phaseNodes + "/" + poolName
+}
+
+// phase runs a deploy phase, logging a start event and an end event with
+// its duration, and records the duration in PhaseTimings. A failed phase's
+// end event is an error carrying the error; the error is returned unchanged.
+func (o *Orchestrator) phase(name string, run func() error) error {
+	log := o.log.With(logging.F(logging.FieldPhase, name))
+	log.Info("Phase started")
+
+	start := time.Now()
+	err := run()
+	elapsed := time.Since(start)
+	o.recordTiming(name, elapsed)
+	duration := logging.F(logging.FieldDuration, elapsed.Milliseconds())
+	if err != nil {
+		log.Error("Phase failed", duration, logging.F(logging.FieldError, err))
+		return err
+	}
+	log.Info("Phase completed", duration)
+	return nil
+}
+
+// recordTiming records how long a phase or pool took
+func (o *Orchestrator) recordTiming(name string, elapsed time.Duration) {
+	o.timingsMu.Lock()
+	defer o.timingsMu.Unlock()
+
+	if o.phaseTimings == nil {
+		o.phaseTimings = make(map[string]time.Duration)
+	}
+	if _, ok := o.phaseTimings[name]; !ok {
+		o.timingOrder = append(o.timingOrder, name)
+	}
+	o.phaseTimings[name] = elapsed
+}
+
+// resetTimings forgets the timings of a previous deploy
+func (o *Orchestrator) resetTimings() {
+	o.timingsMu.Lock()
+	defer o.timingsMu.Unlock()
+
+	o.phaseTimings = nil
+	o.timingOrder = nil
+}
+
+// PhaseTimings returns how long each phase of the last Deploy took, keyed by
+// phase name, with each node pool's creation under "nodes/<pool>". A phase
+// that failed is included; phases that never ran are not.
+func (o *Orchestrator) PhaseTimings() map[string]time.Duration {
+	o.timingsMu.Lock()
+	defer o.timingsMu.Unlock()
+
+	timings := make(map[string]time.Duration, len(o.phaseTimings))
+	for name, elapsed := range o.phaseTimings {
+		timings[name] = elapsed
+	}
+	return timings
+}
+
+// PhaseTimingSummary returns the phase timings of the last Deploy as a table,
+// phases in the order they ran with their share of the total and each pool
+// indented under the nodes phase
+func (o *Orchestrator) PhaseTimingSummary() string {
+	o.timingsMu.Lock()
+	defer o.timingsMu.Unlock()
+
+	var phases, pools []string
+	var total time.Duration
+	for _, name := range o.timingOrder {
+		if strings.HasPrefix(name, phaseNodes+"/") {
+			pools = append(pools, name)
+			continue
+		}
+		phases = append(phases, name)
+		total += o.phaseTimings[name]
+	}
+	sort.Strings(pools)
+
+	share := func(elapsed time.Duration) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(elapsed) / float64(total) * 100
+	}
+
+	var b strings.Builder
+	b.WriteString("Deploy phase timings:\n")
+	for _, name := range phases {
+		elapsed := o.phaseTimings[name]
+		fmt.Fprintf(&b, "  %-24s %12s %6.1f%%\n", name, elapsed.Round(time.Millisecond), share(elapsed))
+		if name != phaseNodes {
+			continue
+		}
+		for _, pool := range pools {
+			elapsed := o.phaseTimings[pool]
+			fmt.Fprintf(&b, "    %-22s %12s\n", "pool "+strings.TrimPrefix(pool, phaseNodes+"/"), elapsed.Round(time.Millisecond))
+		}
+	}
+	fmt.Fprintf(&b, "  %-24s %12s", "total", total.Round(time.Millisecond))
+	return b.String()
+}
+
+// logPhaseTimings logs PhaseTimingSummary one line at a time
+func (o *Orchestrator) logPhaseTimings() {
+	for _, line := range strings.Split(o.PhaseTimingSummary(), "\n") {
+		o.log.Info(line)
+	}
+}
@@ -0,0 +1,107 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InvalidPlacement is a node or node pool asking for a region or size its
+// provider does not offer
+type InvalidPlacement struct {
+	Target   string // "node master-1" or "node pool workers"
+	Provider string
+	Field    string // "region", "size" or "provider"
+	Value    string
+}
+
+func (p InvalidPlacement) String() string {
+	if p.Field == "provider" {
+		return fmt.Sprintf("%s: unknown provider %q", p.Target, p.Value)
+	}
+	return fmt.Sprintf("%s: %s %q is not offered by %s", p.Target, p.Field, p.Value, p.Provider)
+}
+
+// validatePlacement checks every node and node pool's region, each of a
+// pool's Regions, and size against its provider's GetRegions and GetSizes
+// before anything is created, so a typo like nyc33 fails the deploy up
+// front. Empty values fall back to
+// the provider defaults as deployNodePool does. Providers listed in
+// Options.SkipPlacementValidation, and catalogs a provider leaves empty, are
+// not checked.
+func (o *Orchestrator) validatePlacement() error {
+	var invalid []InvalidPlacement
+	seen := make(map[InvalidPlacement]bool)
+	report := func(p InvalidPlacement) {
+		if !seen[p] {
+			seen[p] = true
+			invalid = append(invalid, p)
+		}
+	}
+	check := func(target, providerName, region, size string) {
+		if o.skipPlacement[providerName] {
+			return
+		}
+
+		// The catalogs are static, so an uninitialized provider serves them
+		// without any API call
+		provider, err := o.newProvider(providerName)
+		if err != nil {
+			report(InvalidPlacement{Target: target, Field: "provider", Value: providerName})
+			return
+		}
+
+		defaultRegion, defaultSize := o.config.ProviderDefaults(providerName)
+		if region == "" {
+			region = defaultRegion
+		}
+		if size == "" {
+			size = defaultSize
+		}
+		if region != "" && !offered(provider.GetRegions(), region) {
+			report(InvalidPlacement{Target: target, Provider: providerName, Field: "region", Value: region})
+		}
+		if size != "" && !offered(provider.GetSizes(), size) {
+			report(InvalidPlacement{Target: target, Provider: providerName, Field: "size", Value: size})
+		}
+	}
+
+	for _, node := range o.config.Nodes {
+		check("node "+node.Name, node.Provider, node.Region, node.Size)
+	}
+
+	poolNames := make([]string, 0, len(o.config.NodePools))
+	for name := range o.config.NodePools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+	for _, name := range poolNames {
+		pool := o.config.NodePools[name]
+		regions := pool.Regions
+		if len(regions) == 0 {
+			regions = []string{pool.Region}
+		}
+		for _, region := range regions {
+			check("node pool "+name, pool.Provider, region, pool.Size)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &PlacementError{Invalid: invalid}
+	}
+	return nil
+}
+
+// offered reports whether value is in catalog; an empty catalog offers
+// everything
+func offered(catalog []string, value string) bool {
+	if len(catalog) == 0 {
+		return true
+	}
+	for _, entry := range catalog {
+		if strings.EqualFold(entry, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+// podSecurityExemptNamespaces returns the namespaces left unlabeled: the
+// system namespaces plus PodSecurity.ExemptNamespaces
+func podSecurityExemptNamespaces(podSecurity *config.PodSecurityConfig) []string {
+	exempt := append([]string{}, systemNamespaces...)
+	for _, namespace := range podSecurity.ExemptNamespaces {
+		if !slices.Contains(exempt, namespace) {
+			exempt = append(exempt, namespace)
+		}
+	}
+	return exempt
+}
+
+// podSecurityLabels renders the Pod Security Admission labels for the
+// configured profiles, enforce first
+func podSecurityLabels(profiles config.PodSecurityProfiles) []string {
+	var labels []string
+	for _, mode := range []struct {
+		name    string
+		profile string
+	}{
+		{"enforce", profiles.Enforce},
+		{"audit", profiles.Audit},
+		{"warn", profiles.Warn},
+	} {
+		if mode.profile != "" {
+			labels = append(labels, fmt.Sprintf("%s%s=%s", podSecurityLabelPrefix, mode.name, mode.profile))
+		}
+	}
+	return labels
+}
+
+// renderPodSecurityInstall renders the script that labels every namespace
+// existing when it runs, except the exempt ones, with the profiles
+func renderPodSecurityInstall(podSecurity *config.PodSecurityConfig) string {
+	return fmt.Sprintf(`set -e
+
+EXEMPT=" %s "
+for ns in $(kubectl get namespaces -o jsonpath='{.items[*].metadata.name}'); do
+    case "$EXEMPT" in
+        *" $ns "*) continue ;;
+    esac
+    kubectl label namespace "$ns" --overwrite %s
+done
+`, strings.Join(podSecurityExemptNamespaces(podSecurity), " "),
+		strings.Join(podSecurityLabels(podSecurity.EffectiveProfiles()), " "))
+}
+
+// installPodSecurity applies Security.PodSecurity through Pod Security
+// Admission namespace labels, which RKE and RKE2 both honour. Namespaces
+// created after the deploy fall back to the cluster default and need the
+// labels added when they are created.
+func (o *Orchestrator) installPodSecurity() error {
+	podSecurity := &o.config.Security.PodSecurity
+	profiles := podSecurity.EffectiveProfiles()
+	if profiles.IsEmpty() {
+		return nil
+	}
+	if err := podSecurity.Validate(); err != nil {
+		return err
+	}
+
+	runScript, err := o.addonScriptRunner("pod security")
+	if err != nil {
+		return err
+	}
+
+	o.log.Info(fmt.Sprintf("Applying pod security standards (enforce: %s, audit: %s, warn: %s)",
+		firstNonEmpty(profiles.Enforce, "-"), firstNonEmpty(profiles.Audit, "-"), firstNonEmpty(profiles.Warn, "-")))
+	if err := runScript("pod-security", renderPodSecurityInstall(podSecurity)); err != nil {
+		return fmt.Errorf("failed to apply pod security standards: %w", err)
+	}
+	return nil
+}
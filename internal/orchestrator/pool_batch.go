@@ -0,0 +1,175 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+// PoolNodeFailure is a pool node that could not be created
+type PoolNodeFailure struct {
+	Node *config.NodeConfig
+	Err  error
+}
+
+// PoolResult reports the outcome of a batched pool deployment. Created holds
+// the nodes that exist, in pool order; Failed the ones that do not.
+// SpotFallbacks names the spot nodes that were created on-demand instead.
+type PoolResult struct {
+	Created       []*providers.NodeOutput
+	Failed        []PoolNodeFailure
+	SpotFallbacks []string
+}
+
+// Err returns nil when every node was created, otherwise an error naming
+// each failed node
+func (r *PoolResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	names := make([]string, len(r.Failed))
+	errs := make([]error, len(r.Failed))
+	for i, failure := range r.Failed {
+		names[i] = failure.Node.Name
+		errs[i] = fmt.Errorf("node %s: %w", failure.Node.Name, failure.Err)
+	}
+	return fmt.Errorf("%d of %d nodes failed (%s): %w",
+		len(r.Failed), len(r.Failed)+len(r.Created), strings.Join(names, ", "), errors.Join(errs...))
+}
+
+// poolNodeConfigs expands a pool into the node definitions a batched
+// deployment creates: names are <pool>-1..<pool>-N and zones are assigned
+// round-robin, as the providers' own CreateNodePool does. Of a spot pool, the
+// first spotNodeCount nodes are spot and the rest on-demand.
+func poolNodeConfigs(pool *config.NodePool) []*config.NodeConfig {
+	spotNodes := spotNodeCount(pool)
+	nodes := make([]*config.NodeConfig, pool.Count)
+	for i := range nodes {
+		node := &config.NodeConfig{
+			Name:         pool.NodeName(i),
+			Provider:     pool.Provider,
+			Pool:         pool.Name,
+			Roles:        pool.Roles,
+			Size:         pool.Size,
+			Image:        pool.Image,
+			Region:       pool.RegionFor(i),
+			Labels:       pool.Labels,
+			Taints:       pool.Taints,
+			UserData:     pool.UserData,
+			SSHUser:      pool.SSHUser,
+			SpotInstance: i < spotNodes,
+			Credentials:  pool.Credentials,
+		}
+		if node.SpotInstance && pool.SpotConfig != nil {
+			node.SpotMaxPrice = pool.SpotConfig.MaxPrice
+		}
+		if len(pool.Zones) > 0 {
+			node.Zone = pool.Zones[i%len(pool.Zones)]
+		}
+		nodes[i] = node
+	}
+	return nodes
+}
+
+// placePoolNodes assigns the pool nodes without a zone the zones of a
+// provider that spreads nodes across zones, round-robin in pool order as
+// poolNodeConfigs does for a pool's own zones
+func placePoolNodes(provider providers.Provider, nodeConfigs []*config.NodeConfig) {
+	placer, ok := provider.(providers.ZonePlacer)
+	if !ok {
+		return
+	}
+	zones := placer.Zones()
+	if len(zones) == 0 {
+		return
+	}
+	for i, nodeConfig := range nodeConfigs {
+		if nodeConfig.Zone == "" {
+			nodeConfig.Zone = zones[i%len(zones)]
+		}
+	}
+}
+
+// createPoolBatched creates a pool's nodes one CreateNode call each, at most
+// poolBatchConcurrency at a time, or one at a time for the spot pools
+// spotPerNode creates node by node when batching is off. Each node is stored under key as
+// soon as it exists, so a failure part way through leaves the created nodes
+// tracked for the rest of the deployment or a rollback. The CreateNode calls
+// themselves take turns on the provider instance (see createLock), and the
+// nodes are placed in their zones before any is created.
+func (o *Orchestrator) createPoolBatched(provider providers.Provider, key string, pool *config.NodePool) *PoolResult {
+	nodeConfigs := poolNodeConfigs(pool)
+	placePoolNodes(provider, nodeConfigs)
+	outputs := make([]*providers.NodeOutput, len(nodeConfigs))
+	errs := make([]error, len(nodeConfigs))
+	fellBack := make([]bool, len(nodeConfigs))
+
+	concurrency := o.poolBatchConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, nodeConfig := range nodeConfigs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, nodeConfig *config.NodeConfig) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := o.assignWireGuardIP(nodeConfig); err != nil {
+				errs[i] = err
+				return
+			}
+			node, onDemand, err := o.createPoolNode(provider, key, pool, nodeConfig)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			applyNodeScheduling(node, pool.Labels, pool.Taints)
+			applyNodeRoles(node, pool.Roles)
+			labelNodeRegion(node)
+			if pool.SpotInstance {
+				labelSpotNode(node, nodeConfig.SpotInstance)
+			}
+			outputs[i] = node
+			fellBack[i] = onDemand
+
+			o.mu.Lock()
+			o.nodes[key] = append(o.nodes[key], node)
+			o.mu.Unlock()
+		}(i, nodeConfig)
+	}
+	wg.Wait()
+
+	result := &PoolResult{}
+	for i, nodeConfig := range nodeConfigs {
+		if errs[i] != nil {
+			result.Failed = append(result.Failed, PoolNodeFailure{Node: nodeConfig, Err: errs[i]})
+			continue
+		}
+		result.Created = append(result.Created, outputs[i])
+		if fellBack[i] {
+			result.SpotFallbacks = append(result.SpotFallbacks, nodeConfig.Name)
+		}
+	}
+	return result
+}
+
+// PoolResults returns the outcome of each pool deployed with batching,
+// keyed by pool name
+func (o *Orchestrator) PoolResults() map[string]*PoolResult {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	results := make(map[string]*PoolResult, len(o.poolResults))
+	for name, result := range o.poolResults {
+		results[name] = result
+	}
+	return results
+}
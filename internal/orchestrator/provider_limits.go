@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultProviderConcurrency is how many create calls may be in flight to a
+// cloud at once when its config leaves MaxConcurrentRequests unset.
+// DigitalOcean, Linode and Hetzner rate limit per API token far sooner than
+// the large clouds, AWS allowing the most.
+var defaultProviderConcurrency = map[string]int{
+	"digitalocean": 4,
+	"linode":       4,
+	"hetzner":      4,
+	"azure":        8,
+	"gcp":          8,
+	"aws":          16,
+}
+
+// fallbackProviderConcurrency is the limit of providers without a default
+const fallbackProviderConcurrency = 4
+
+// providerConcurrency returns the effective limit of create calls in flight
+// to a provider: MaxConcurrentRequests when set, otherwise the cloud's default
+func (o *Orchestrator) providerConcurrency(providerName string) int {
+	if limit := o.config.ProviderMaxConcurrentRequests(providerName); limit > 0 {
+		return limit
+	}
+	if limit, ok := defaultProviderConcurrency[providerName]; ok {
+		return limit
+	}
+	return fallbackProviderConcurrency
+}
+
+// providerSlots returns the semaphore bounding the create calls in flight to
+// a provider, creating it on first use. Nodes created with a credentials
+// override share the slots of their cloud.
+func (o *Orchestrator) providerSlots(providerName string) chan struct{} {
+	providerName = providerFromKey(providerName)
+
+	o.limitsMu.Lock()
+	defer o.limitsMu.Unlock()
+
+	if o.providerLimits == nil {
+		o.providerLimits = make(map[string]chan struct{})
+	}
+	slots, ok := o.providerLimits[providerName]
+	if !ok {
+		slots = make(chan struct{}, o.providerConcurrency(providerName))
+		o.providerLimits[providerName] = slots
+	}
+	return slots
+}
+
+// createLock returns the lock serializing the create calls on the provider
+// instance stored under key, creating it on first use. Provider instances
+// keep state across create calls, such as the nodes created so far and the
+// subnet the next node goes in, so concurrent pools on one instance take
+// turns rather than racing on it.
+func (o *Orchestrator) createLock(key string) *sync.Mutex {
+	o.limitsMu.Lock()
+	defer o.limitsMu.Unlock()
+
+	if o.createLocks == nil {
+		o.createLocks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := o.createLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		o.createLocks[key] = lock
+	}
+	return lock
+}
+
+// logProviderLimits logs the effective request limit of each provider the
+// nodes and pools deploy to
+func (o *Orchestrator) logProviderLimits() {
+	used := make(map[string]bool)
+	for _, node := range o.config.Nodes {
+		used[node.Provider] = true
+	}
+	for _, pool := range o.config.NodePools {
+		used[pool.Provider] = true
+	}
+	if len(used) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	limits := make([]string, len(names))
+	for i, name := range names {
+		limits[i] = fmt.Sprintf("%s=%d", name, o.providerConcurrency(name))
+	}
+	o.log.Info(fmt.Sprintf("Provider request limits (create calls in flight): %s", strings.Join(limits, ", ")))
+}
@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/internals"
+)
+
+const (
+	defaultNodeReadyTimeout      = 600 * time.Second
+	defaultNodeReadyPollInterval = 5 * time.Second
+
+	// loadBalancerReadyTimeout is how long a new load balancer gets to
+	// report a healthy backend
+	loadBalancerReadyTimeout = 300 * time.Second
+)
+
+// nodeReadyTimeout returns NodeReadyTimeout, or the default when unset
+func (o *Orchestrator) nodeReadyTimeout() time.Duration {
+	if o.config.NodeReadyTimeout > 0 {
+		return time.Duration(o.config.NodeReadyTimeout) * time.Second
+	}
+	return defaultNodeReadyTimeout
+}
+
+// nodeReadyPollInterval returns NodeReadyPollInterval, or the default when unset
+func (o *Orchestrator) nodeReadyPollInterval() time.Duration {
+	if o.config.NodeReadyPollInterval > 0 {
+		return time.Duration(o.config.NodeReadyPollInterval) * time.Second
+	}
+	return defaultNodeReadyPollInterval
+}
+
+// waitForNodesReady blocks until every node answers on SSH, probing each
+// through the health checker with backoff, so the VPN and Kubernetes phases
+// do not start against hosts that are still booting. Nodes without a public
+// IP (private nodes behind the bastion) are not probed, and previews skip
+// the wait since IPs are unknown. After timeout the error lists every node
+// that never became reachable.
+func (o *Orchestrator) waitForNodesReady(timeout time.Duration) error {
+	if o.ctx.DryRun() {
+		o.log.Info("Skipping node readiness wait during preview")
+		return nil
+	}
+
+	o.mu.RLock()
+	var nodes []*providers.NodeOutput
+	for _, providerNodes := range o.nodes {
+		nodes = append(nodes, providerNodes...)
+	}
+	o.mu.RUnlock()
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	o.log.Info(fmt.Sprintf("Waiting up to %s for %d nodes to accept SSH", timeout, len(nodes)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	interval := o.nodeReadyPollInterval()
+
+	var (
+		wg      sync.WaitGroup
+		errMu   sync.Mutex
+		unready []string
+	)
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *providers.NodeOutput) {
+			defer wg.Done()
+
+			err := o.waitForNodeSSH(ctx, node, interval)
+			if err != nil {
+				o.log.Warn(fmt.Sprintf("Node %s not ready: %v", node.Name, err), logging.F(logging.FieldNode, node.Name))
+				errMu.Lock()
+				unready = append(unready, node.Name)
+				errMu.Unlock()
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	if len(unready) > 0 {
+		sort.Strings(unready)
+		return fmt.Errorf("%d of %d nodes not reachable over SSH after %s: %s",
+			len(unready), len(nodes), timeout, strings.Join(unready, ", "))
+	}
+
+	o.log.Info(fmt.Sprintf("✓ All %d nodes reachable over SSH", len(nodes)))
+	return nil
+}
+
+// waitForNodeSSH resolves a node's public IP and waits for SSH on port 22
+func (o *Orchestrator) waitForNodeSSH(ctx context.Context, node *providers.NodeOutput, interval time.Duration) error {
+	if node.DryRun || node.PublicIP.OutputState == nil {
+		return nil
+	}
+
+	result, err := internals.UnsafeAwaitOutput(ctx, node.PublicIP)
+	if err != nil {
+		return fmt.Errorf("resolving public IP: %w", err)
+	}
+	ip, _ := result.Value.(string)
+	if !result.Known || ip == "" {
+		o.log.Info(fmt.Sprintf("Node %s has no public IP; skipping SSH readiness check", node.Name), logging.F(logging.FieldNode, node.Name))
+		return nil
+	}
+
+	return o.healthChecker.WaitForSSH(ctx, node.Name, net.JoinHostPort(ip, "22"), interval)
+}
+
+// waitForLoadBalancerReady blocks until the load balancer accepts
+// connections on its first port. Load balancers only forward to backends
+// that pass their health check, so this waits for at least one healthy
+// backend. Previews skip the wait since addresses are unknown, as do internal
+// load balancers, which only admit clients inside the cluster's networks. A
+// load balancer without backends is only warned about since none will ever
+// pass.
+func (o *Orchestrator) waitForLoadBalancerReady(lbConfig *config.LoadBalancerConfig, lb *providers.LoadBalancerOutput, timeout time.Duration) error {
+	if o.ctx.DryRun() || lb.IP.OutputState == nil {
+		return nil
+	}
+	if lbConfig.IsInternal() {
+		o.log.Info(fmt.Sprintf("Load balancer %s is internal; not waiting for it", lbConfig.Name))
+		return nil
+	}
+	if lb.Backends == 0 {
+		o.log.Warn(fmt.Sprintf("Load balancer %s has no backends; not waiting for it", lbConfig.Name))
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result, err := internals.UnsafeAwaitOutput(ctx, lb.IP)
+	if err != nil {
+		return fmt.Errorf("resolving address: %w", err)
+	}
+	host, _ := result.Value.(string)
+	if !result.Known || host == "" {
+		return nil
+	}
+
+	port := 6443
+	if len(lbConfig.Ports) > 0 {
+		port = lbConfig.Ports[0].Port
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+
+	o.log.Info(fmt.Sprintf("Waiting up to %s for load balancer %s (%d backends) to serve %s", timeout, lbConfig.Name, lb.Backends, addr))
+	if err := o.healthChecker.WaitForTCP(ctx, addr, o.nodeReadyPollInterval()); err != nil {
+		return err
+	}
+	o.log.Info(fmt.Sprintf("✓ Load balancer %s has a healthy backend", lbConfig.Name))
+	return nil
+}
@@ -0,0 +1,59 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+)
+
+// RebootNode reboots a node through its provider's API and waits until it
+// accepts SSH again. With drain the node is first cordoned and drained by the
+// RKE or RKE2 manager, and a failed drain stops the reboot. The kubelet
+// registering again does not clear the cordon, so once rebooted the node is
+// uncordoned, even when it does not come back in time; a failed reboot
+// leaves it cordoned. The node and its provider are looked up with
+// GetProviderForNode.
+func (o *Orchestrator) RebootNode(name string, drain bool) error {
+	provider, node, err := o.GetProviderForNode(name)
+	if err != nil {
+		return err
+	}
+	log := o.log.With(logging.F(logging.FieldNode, name), logging.F(logging.FieldProvider, provider.GetName()))
+
+	drained := drain && o.drainer != nil
+	if drained {
+		log.Info(fmt.Sprintf("Draining node %s before reboot", name))
+		if err := o.drainer.CordonAndDrainNode(name, o.drainTimeout()); err != nil {
+			return fmt.Errorf("failed to drain node %s: %w", name, err)
+		}
+	}
+
+	log.Info(fmt.Sprintf("Rebooting node %s", name))
+	err = o.withProviderRetry(provider.GetName(), "reboot of node "+name, func() error {
+		return provider.RebootNode(o.ctx.Context(), node)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reboot node %s: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.nodeReadyTimeout())
+	defer cancel()
+	var readyErr error
+	if err := o.waitForNodeSSH(ctx, node, o.nodeReadyPollInterval()); err != nil {
+		readyErr = fmt.Errorf("node %s not reachable over SSH after reboot: %w", name, err)
+	}
+
+	if drained {
+		if err := o.drainer.UncordonNode(name); err != nil {
+			return errors.Join(readyErr, fmt.Errorf("failed to uncordon node %s: %w", name, err))
+		}
+	}
+	if readyErr != nil {
+		return readyErr
+	}
+
+	log.Info(fmt.Sprintf("✓ Node %s rebooted", name))
+	return nil
+}
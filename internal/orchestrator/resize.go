@@ -0,0 +1,112 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+// CheckConcurrentMasterResize refuses to take down, at the same time, more
+// masters of a HighAvailability cluster than its etcd quorum tolerates: one
+// of three, two of five. masters lists every master of the cluster and
+// resizing the nodes to be resized at once.
+func CheckConcurrentMasterResize(cfg *config.ClusterConfig, masters, resizing []string) error {
+	if !cfg.Cluster.HighAvailability || len(masters) == 0 {
+		return nil
+	}
+
+	isMaster := make(map[string]bool, len(masters))
+	for _, name := range masters {
+		isMaster[name] = true
+	}
+	var down []string
+	for _, name := range resizing {
+		if isMaster[name] {
+			down = append(down, name)
+		}
+	}
+
+	tolerated := (len(masters) - 1) / 2
+	if len(down) <= tolerated {
+		return nil
+	}
+	if len(down) == len(masters) {
+		return fmt.Errorf("refusing to resize all %d masters of a high-availability cluster at once; resize them one at a time", len(masters))
+	}
+	return fmt.Errorf("refusing to resize masters %s at once: etcd keeps quorum with at most %d of %d masters down",
+		strings.Join(down, ", "), tolerated, len(masters))
+}
+
+// ResizeNode changes the size of a node in place through its provider's API
+// and waits until it accepts SSH again. The size must be one the provider
+// offers. With drain the node is first cordoned and drained by the RKE or
+// RKE2 manager, since most clouds power the instance off to resize it; a
+// failed drain stops the resize. Once the resize is done the node is
+// uncordoned, even when it does not come back in time; a failed resize
+// leaves it cordoned. The tracked node's Size is updated.
+func (o *Orchestrator) ResizeNode(name, newSize string, drain bool) error {
+	provider, node, err := o.GetProviderForNode(name)
+	if err != nil {
+		return err
+	}
+	if err := providers.ValidateNodeSize(provider, newSize); err != nil {
+		return err
+	}
+	if node.Size == newSize {
+		return fmt.Errorf("node %s is already size %s", name, newSize)
+	}
+	if hasMasterRole(nodeRoles(node)) {
+		if err := CheckConcurrentMasterResize(o.config, o.masterNames(), []string{name}); err != nil {
+			return err
+		}
+	}
+	log := o.log.With(logging.F(logging.FieldNode, name), logging.F(logging.FieldProvider, provider.GetName()))
+
+	drained := drain && o.drainer != nil
+	if drained {
+		log.Info(fmt.Sprintf("Draining node %s before resize", name))
+		if err := o.drainer.CordonAndDrainNode(name, o.drainTimeout()); err != nil {
+			return fmt.Errorf("failed to drain node %s: %w", name, err)
+		}
+	}
+
+	oldSize := node.Size
+	log.Info(fmt.Sprintf("Resizing node %s from %s to %s", name, oldSize, newSize))
+	if err := provider.ResizeNode(o.ctx.Context(), node, newSize); err != nil {
+		return fmt.Errorf("failed to resize node %s: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.nodeReadyTimeout())
+	defer cancel()
+	var readyErr error
+	if err := o.waitForNodeSSH(ctx, node, o.nodeReadyPollInterval()); err != nil {
+		readyErr = fmt.Errorf("node %s not reachable over SSH after resize: %w", name, err)
+	}
+
+	if drained {
+		if err := o.drainer.UncordonNode(name); err != nil {
+			return errors.Join(readyErr, fmt.Errorf("failed to uncordon node %s: %w", name, err))
+		}
+	}
+	if readyErr != nil {
+		return readyErr
+	}
+
+	log.Info(fmt.Sprintf("✓ Node %s resized from %s to %s", name, oldSize, newSize))
+	return nil
+}
+
+// masterNames returns the names of the tracked master nodes
+func (o *Orchestrator) masterNames() []string {
+	masters := o.GetMasterNodes()
+	names := make([]string, len(masters))
+	for i, node := range masters {
+		names[i] = node.Name
+	}
+	return names
+}
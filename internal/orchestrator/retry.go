@@ -0,0 +1,120 @@
+package orchestrator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+	"github.com/chalkan3/sloth-kubernetes/pkg/retry"
+)
+
+// DefaultProviderRetryConfig returns the retry policy used around the calls
+// providers make to the cloud APIs outside Pulumi: up to 4 attempts with exponential
+// backoff from 2s, capped at 30s, retrying only IsRetryableProviderError
+func DefaultProviderRetryConfig() retry.Config {
+	return retry.Config{
+		MaxRetries:   3,
+		InitialDelay: 2 * time.Second,
+		MaxDelay:     30 * time.Second,
+		Multiplier:   2.0,
+		Jitter:       true,
+		JitterFactor: 0.2,
+		RetryIf:      IsRetryableProviderError,
+	}
+}
+
+// retryableStatusPattern matches the HTTP status codes cloud APIs return for
+// throttling and transient server-side failures
+var retryableStatusPattern = regexp.MustCompile(`\b(429|500|502|503|504)\b`)
+
+// IsRetryableProviderError reports whether a provider error is worth retrying.
+// Errors wrapped in retry.RetryableError, temporary and timeout errors, and
+// throttling or 5xx API responses are retried; quota errors fail fast since
+// retrying cannot free up capacity.
+func IsRetryableProviderError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	message := strings.ToLower(err.Error())
+	if strings.Contains(message, "quota") {
+		return false
+	}
+	if retry.IsTransientError(err) {
+		return true
+	}
+	if retryableStatusPattern.MatchString(message) {
+		return true
+	}
+
+	transientErrors := []string{
+		"too many requests",
+		"rate limit",
+		"internal server error",
+		"bad gateway",
+		"service unavailable",
+		"gateway timeout",
+		"connection reset",
+		"connection refused",
+	}
+	for _, transient := range transientErrors {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// withProviderSlot runs a provider create call once it holds one of the
+// cloud's request slots and the instance's create lock. Create calls
+// register Pulumi resources rather than call the cloud API, so they are not
+// retried: another attempt after a partial registration would register the
+// same names again, and the cloud's transient errors surface later, in the
+// Pulumi engine, whose providers retry them.
+func withProviderSlot[T any](o *Orchestrator, key string, create func() (T, error)) (T, error) {
+	slots := o.providerSlots(providerFromKey(key))
+	lock := o.createLock(key)
+
+	slots <- struct{}{}
+	defer func() { <-slots }()
+	lock.Lock()
+	defer lock.Unlock()
+
+	return create()
+}
+
+// withProviderRetry runs a call the provider makes to the cloud API outside
+// Pulumi, such as a destroy or a reboot, under the orchestrator's retry
+// policy. key is the provider key the call goes to. Each attempt waits for
+// one of the cloud's request slots, which is not held while backing off.
+// When more than one attempt was made, the final error reports how many.
+func (o *Orchestrator) withProviderRetry(key, what string, call func() error) error {
+	providerName := providerFromKey(key)
+	slots := o.providerSlots(providerName)
+
+	cfg := o.retryConfig
+	if cfg.RetryIf == nil {
+		cfg.RetryIf = IsRetryableProviderError
+	}
+	cfg.OnRetry = func(attempt int, err error, delay time.Duration) {
+		o.log.Warn(fmt.Sprintf("Retrying %s (attempt %d of %d) in %v: %v",
+			what, attempt+1, cfg.MaxRetries+1, delay.Round(time.Millisecond), err), logging.F(logging.FieldProvider, providerName))
+	}
+
+	attempts := 0
+	var lastErr error
+	err := retry.New(cfg).DoWithContext(o.ctx.Context(), func() error {
+		slots <- struct{}{}
+		defer func() { <-slots }()
+
+		attempts++
+		lastErr = call()
+		return lastErr
+	})
+	if err != nil && attempts > 1 {
+		return fmt.Errorf("%w (gave up after %d attempts)", lastErr, attempts)
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+// haMasterQuorum is the fewest masters an etcd quorum survives a failure with
+const haMasterQuorum = 3
+
+// PoolScalePlan is the change that scales a node pool to a new count
+type PoolScalePlan struct {
+	Pool   string
+	From   int
+	To     int
+	Add    []string // Nodes to create, in order
+	Remove []string // Nodes to drain and destroy, highest index first
+}
+
+// PlanPoolScale diffs a pool's current nodes, named <pool>-N, against the
+// desired count. Missing indexes up to count are added; nodes above count are
+// removed, newest first. A master pool of a HighAvailability cluster cannot
+// go below the etcd quorum.
+func PlanPoolScale(cfg *config.ClusterConfig, poolName string, current []string, count int) (*PoolScalePlan, error) {
+	pool, ok := cfg.NodePools[poolName]
+	if !ok {
+		return nil, fmt.Errorf("node pool %s not found", poolName)
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("node pool %s: count must not be negative", poolName)
+	}
+	if cfg.Cluster.HighAvailability && hasMasterRole(pool.Roles) && count < haMasterQuorum {
+		return nil, fmt.Errorf("node pool %s holds the masters of a high-availability cluster and needs at least %d nodes for quorum", poolName, haMasterQuorum)
+	}
+
+	existing := make(map[int]string)
+	for _, name := range current {
+		if index, ok := poolNodeIndex(poolName, name); ok {
+			existing[index] = name
+		}
+	}
+
+	plan := &PoolScalePlan{Pool: poolName, From: len(existing), To: count}
+	for i := 1; i <= count; i++ {
+		if _, ok := existing[i]; !ok {
+			plan.Add = append(plan.Add, fmt.Sprintf("%s-%d", poolName, i))
+		}
+	}
+
+	indexes := make([]int, 0, len(existing))
+	for index := range existing {
+		if index > count {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+	for _, index := range indexes {
+		plan.Remove = append(plan.Remove, existing[index])
+	}
+
+	return plan, nil
+}
+
+// poolNodeIndex returns N for a node named <pool>-N
+func poolNodeIndex(poolName, nodeName string) (int, bool) {
+	suffix, ok := strings.CutPrefix(nodeName, poolName+"-")
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.Atoi(suffix)
+	if err != nil || index < 1 {
+		return 0, false
+	}
+	return index, true
+}
+
+// ScalePool grows or shrinks a deployed pool to count nodes. New nodes are
+// deployed one at a time; removed nodes are drained through the RKE or RKE2
+// manager, when the cluster has one, and destroyed through their provider. The pool's
+// count is updated so verifyNodeDistribution checks the new layout.
+func (o *Orchestrator) ScalePool(poolName string, count int) (*PoolScalePlan, error) {
+	pool, ok := o.config.NodePools[poolName]
+	if !ok {
+		return nil, fmt.Errorf("node pool %s not found", poolName)
+	}
+
+	provider, key, err := o.providerFor(pool.Provider, pool.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	var current []string
+	if nodes, err := o.GetNodesByProvider(key); err == nil {
+		for _, node := range nodes {
+			current = append(current, node.Name)
+		}
+	}
+
+	plan, err := PlanPoolScale(o.config, poolName, current, count)
+	if err != nil {
+		return nil, err
+	}
+
+	o.log.Info(fmt.Sprintf("Scaling node pool %s from %d to %d nodes", poolName, plan.From, plan.To))
+
+	scaled := pool
+	scaled.Count = count
+	if scaled.Name == "" {
+		scaled.Name = poolName
+	}
+	nodeConfigs := make(map[string]*config.NodeConfig)
+	for _, nodeConfig := range poolNodeConfigs(&scaled) {
+		nodeConfigs[nodeConfig.Name] = nodeConfig
+	}
+	for _, name := range plan.Add {
+		if err := o.deployNode(nodeConfigs[name]); err != nil {
+			return plan, fmt.Errorf("failed to add node %s: %w", name, err)
+		}
+	}
+
+	for _, name := range plan.Remove {
+		if err := o.removePoolNode(provider, key, name); err != nil {
+			return plan, err
+		}
+	}
+
+	pool.Count = count
+	o.config.NodePools[poolName] = pool
+
+	if err := o.verifyNodeDistribution(); err != nil {
+		return plan, err
+	}
+
+	o.log.Info(fmt.Sprintf("✓ Node pool %s scaled to %d nodes", poolName, count))
+	return plan, nil
+}
+
+// removePoolNode drains a node out of the cluster, destroys its instance and
+// stops tracking it. A failed drain is logged and the node destroyed anyway.
+func (o *Orchestrator) removePoolNode(provider providers.Provider, key, name string) error {
+	_ = o.drainNode(name)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	nodes := o.nodes[key]
+	for i, node := range nodes {
+		if node.Name != name {
+			continue
+		}
+		err := o.withProviderRetry(key, "destroy of node "+name, func() error {
+			return provider.DestroyNode(o.ctx.Context(), node)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to destroy node %s: %w", name, err)
+		}
+		o.nodes[key] = append(nodes[:i:i], nodes[i+1:]...)
+		return nil
+	}
+	return &NodeNotFoundError{Name: name}
+}
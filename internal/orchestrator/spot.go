@@ -0,0 +1,108 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+// spotLabel marks the nodes of spot pools: "true" for spot instances,
+// "false" for the on-demand ones of the mix or fallback
+const spotLabel = "spot"
+
+// spotFallbackEnabled reports whether a pool's spot nodes that cannot be
+// created fall back to on-demand (SpotConfig.FallbackOnDemand)
+func spotFallbackEnabled(pool *config.NodePool) bool {
+	return pool.SpotInstance && pool.SpotConfig != nil && pool.SpotConfig.FallbackOnDemand
+}
+
+// spotPerNode reports whether a spot pool must be created one node at a time:
+// to let each spot node fall back on its own, or to mix spot and on-demand
+// nodes, which the providers' CreateNodePool cannot
+func spotPerNode(pool *config.NodePool) bool {
+	return spotFallbackEnabled(pool) || (pool.SpotInstance && spotNodeCount(pool) < pool.Count)
+}
+
+// spotNodeCount returns how many of a pool's nodes are requested as spot:
+// SpotConfig.SpotPercentage of them, rounded down, or all of them when no
+// percentage is set
+func spotNodeCount(pool *config.NodePool) int {
+	if !pool.SpotInstance {
+		return 0
+	}
+	if pool.SpotConfig == nil || pool.SpotConfig.SpotPercentage <= 0 || pool.SpotConfig.SpotPercentage >= 100 {
+		return pool.Count
+	}
+	return pool.Count * pool.SpotConfig.SpotPercentage / 100
+}
+
+// spotCapacityErrors are the messages clouds return when a spot or
+// preemptible request cannot be fulfilled at the moment or at the price asked
+var spotCapacityErrors = []string{
+	"insufficientinstancecapacity",
+	"insufficient capacity",
+	"capacity-not-available",
+	"capacity-oversubscribed",
+	"spotmaxpricetoolow",
+	"price-too-low",
+	"max spot instance count exceeded",
+	"maxspotinstancecountexceeded",
+	"zone_resource_pool_exhausted",
+	"skunotavailable",
+	"overconstrainedallocationrequest",
+}
+
+// IsSpotCapacityError reports whether a provider error means a spot instance
+// is not available, so the node is worth creating on-demand instead
+func IsSpotCapacityError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, capacity := range spotCapacityErrors {
+		if strings.Contains(message, capacity) {
+			return true
+		}
+	}
+	return false
+}
+
+// createPoolNode creates one node of a pool. A spot node of a pool with
+// FallbackOnDemand that fails for lack of spot capacity is created again
+// on-demand; fellBack reports when that happened.
+func (o *Orchestrator) createPoolNode(provider providers.Provider, key string, pool *config.NodePool, nodeConfig *config.NodeConfig) (node *providers.NodeOutput, fellBack bool, err error) {
+	node, err = withProviderSlot(o, key, func() (*providers.NodeOutput, error) {
+		return provider.CreateNode(o.ctx, nodeConfig)
+	})
+	if err == nil || !nodeConfig.SpotInstance || !spotFallbackEnabled(pool) || !IsSpotCapacityError(err) {
+		return node, false, err
+	}
+
+	o.log.Warn(fmt.Sprintf("No spot capacity for node %s, creating it on-demand: %v", nodeConfig.Name, err),
+		logging.F(logging.FieldProvider, pool.Provider), logging.F(logging.FieldNode, nodeConfig.Name))
+	onDemand := *nodeConfig
+	onDemand.SpotInstance = false
+	onDemand.SpotMaxPrice = ""
+	node, err = withProviderSlot(o, key, func() (*providers.NodeOutput, error) {
+		return provider.CreateNode(o.ctx, &onDemand)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("on-demand fallback failed: %w", err)
+	}
+	*nodeConfig = onDemand
+	return node, true, nil
+}
+
+// labelSpotNode sets the spot label of a node of a spot pool. The labels map
+// is copied since providers may share one map across a pool's nodes.
+func labelSpotNode(node *providers.NodeOutput, spot bool) {
+	labels := make(map[string]string, len(node.Labels)+1)
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+	labels[spotLabel] = fmt.Sprintf("%t", spot)
+	node.Labels = labels
+}
@@ -0,0 +1,66 @@
+package orchestrator
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+// ForStack returns an orchestrator over the nodes of a deployed stack, for
+// CLI commands that act on them through the cloud APIs outside a Pulumi run.
+// Each node is tracked under the provider instance its node or pool
+// credentials select, configured with those credentials, so
+// GetProviderForNode returns the account that created it.
+func ForStack(cfg *config.ClusterConfig, nodes []*providers.NodeOutput) (*Orchestrator, error) {
+	o := NewWithOptions(nil, cfg, Options{Logger: logging.NewJSONLogger(io.Discard, logging.LevelError)})
+
+	for _, node := range nodes {
+		creds := stackNodeCredentials(cfg, node.Name)
+		key := providerKey(node.Provider, creds)
+		if _, ok := o.providerRegistry.Get(key); !ok {
+			provider, err := o.newProvider(node.Provider)
+			if err != nil {
+				return nil, err
+			}
+			if configurer, ok := provider.(providers.APIConfigurer); ok {
+				providerCfg := cfg
+				if creds != nil {
+					if providerCfg, err = cfg.WithProviderCredentials(node.Provider, creds); err != nil {
+						return nil, err
+					}
+				}
+				configurer.ConfigureAPI(providerCfg)
+			}
+			o.providerRegistry.Register(key, provider)
+		}
+		o.nodes[key] = append(o.nodes[key], node)
+	}
+	return o, nil
+}
+
+// stackNodeCredentials returns the credentials override a node was created
+// with: its own, or its pool's for a <pool>-<n> node. Nodes of a pool scaled
+// past its configured count are matched too.
+func stackNodeCredentials(cfg *config.ClusterConfig, name string) *config.ProviderCredentials {
+	for _, node := range cfg.Nodes {
+		if node.Name == name {
+			return node.Credentials
+		}
+	}
+
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return nil
+	}
+	if _, err := strconv.Atoi(name[i+1:]); err != nil {
+		return nil
+	}
+	if pool, ok := cfg.NodePools[name[:i]]; ok {
+		return pool.Credentials
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const (
+	longhornProvisioner  = "driver.longhorn.io"
+	localPathProvisioner = "rancher.io/local-path"
+
+	defaultClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+)
+
+// provisionerAliases lets storage classes name the bundled provisioners by
+// their short name
+var provisionerAliases = map[string]string{
+	"longhorn":   longhornProvisioner,
+	"local-path": localPathProvisioner,
+}
+
+// renderStorageClasses renders the configured storage classes as a
+// multi-document StorageClass manifest, annotating the default class
+func renderStorageClasses(storage *config.StorageConfig) (string, error) {
+	if storage.DefaultClass != "" && !hasStorageClass(storage.Classes, storage.DefaultClass) {
+		return "", fmt.Errorf("default storage class %s is not defined in storage classes", storage.DefaultClass)
+	}
+
+	documents := make([]string, 0, len(storage.Classes))
+	for _, class := range storage.Classes {
+		document, err := renderStorageClass(class, class.Name == storage.DefaultClass)
+		if err != nil {
+			return "", fmt.Errorf("storage class %s: %w", class.Name, err)
+		}
+		documents = append(documents, document)
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+// renderStorageClass renders a single StorageClass manifest
+func renderStorageClass(class config.StorageClass, isDefault bool) (string, error) {
+	if class.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if class.Provisioner == "" {
+		return "", fmt.Errorf("provisioner is required")
+	}
+
+	provisioner := class.Provisioner
+	if alias, ok := provisionerAliases[provisioner]; ok {
+		provisioner = alias
+	}
+
+	reclaimPolicy := class.ReclaimPolicy
+	if reclaimPolicy == "" {
+		reclaimPolicy = "Delete"
+	}
+	if reclaimPolicy != "Delete" && reclaimPolicy != "Retain" {
+		return "", fmt.Errorf("reclaim policy must be Delete or Retain, got %s", reclaimPolicy)
+	}
+
+	// local-path volumes live on one node, so binding must wait for the pod
+	bindingMode := class.VolumeBindingMode
+	if bindingMode == "" && provisioner == localPathProvisioner {
+		bindingMode = "WaitForFirstConsumer"
+	}
+
+	parameters := make(map[string]string, len(class.Parameters))
+	for key, value := range class.Parameters {
+		parameters[key] = value
+	}
+	if provisioner == longhornProvisioner {
+		if replicas, ok := parameters["numberOfReplicas"]; ok {
+			if n, err := strconv.Atoi(replicas); err != nil || n < 1 {
+				return "", fmt.Errorf("longhorn numberOfReplicas must be a positive integer, got %q", replicas)
+			}
+		} else {
+			parameters["numberOfReplicas"] = "3"
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("apiVersion: storage.k8s.io/v1\n")
+	builder.WriteString("kind: StorageClass\n")
+	builder.WriteString("metadata:\n")
+	builder.WriteString(fmt.Sprintf("  name: %s\n", class.Name))
+	if isDefault {
+		builder.WriteString("  annotations:\n")
+		builder.WriteString(fmt.Sprintf("    %s: \"true\"\n", defaultClassAnnotation))
+	}
+	builder.WriteString(fmt.Sprintf("provisioner: %s\n", provisioner))
+	builder.WriteString(fmt.Sprintf("reclaimPolicy: %s\n", reclaimPolicy))
+	if bindingMode != "" {
+		builder.WriteString(fmt.Sprintf("volumeBindingMode: %s\n", bindingMode))
+	}
+	if len(parameters) > 0 {
+		keys := make([]string, 0, len(parameters))
+		for key := range parameters {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		builder.WriteString("parameters:\n")
+		for _, key := range keys {
+			builder.WriteString(fmt.Sprintf("  %s: %q\n", key, parameters[key]))
+		}
+	}
+	return builder.String(), nil
+}
+
+func hasStorageClass(classes []config.StorageClass, name string) bool {
+	for _, class := range classes {
+		if class.Name == name {
+			return true
+		}
+	}
+	return false
+}
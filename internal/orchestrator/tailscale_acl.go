@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/internals"
+)
+
+// defaultACLPolicyTimeout bounds the wait for the Headscale API and the
+// policy upload
+const defaultACLPolicyTimeout = 300 * time.Second
+
+// applyTailscaleACLPolicy uploads Tailscale.ACLPolicy to Headscale through
+// its API. The policy is validated first, so an invalid one fails previews
+// too; the upload itself is skipped during previews since the Headscale
+// address and key are not known yet.
+func (o *Orchestrator) applyTailscaleACLPolicy(timeout time.Duration) error {
+	tsConfig := o.config.Network.Tailscale
+	if tsConfig == nil || tsConfig.ACLPolicy == "" {
+		return nil
+	}
+
+	policy, err := tailscale.ResolveACLPolicy(tsConfig.ACLPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid Tailscale ACL policy: %w", err)
+	}
+	if o.ctx.DryRun() {
+		o.log.Info("Skipping ACL policy upload during preview")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	url, apiKey := o.tailscaleManager.HeadscaleAPI()
+	headscaleURL, err := awaitString(ctx, url)
+	if err != nil {
+		return fmt.Errorf("resolving the Headscale URL: %w", err)
+	}
+	headscaleAPIKey, err := awaitString(ctx, apiKey)
+	if err != nil {
+		return fmt.Errorf("resolving the Headscale API key: %w", err)
+	}
+	if headscaleURL == "" || headscaleAPIKey == "" {
+		return fmt.Errorf("uploading the ACL policy needs the Headscale URL and API key (headscale-url and api-key)")
+	}
+
+	headscaleMgr := tailscale.NewHeadscaleManager(tailscale.HeadscaleConfig{
+		APIURL: headscaleURL,
+		APIKey: headscaleAPIKey,
+	})
+	if err := headscaleMgr.SetPolicy(ctx, policy); err != nil {
+		return fmt.Errorf("failed to apply the Tailscale ACL policy: %w", err)
+	}
+
+	o.log.Info("✓ ACL policy applied to Headscale")
+	return nil
+}
+
+// awaitString waits for a string output, treating unknown values as empty
+func awaitString(ctx context.Context, output pulumi.StringOutput) (string, error) {
+	if output.OutputState == nil {
+		return "", nil
+	}
+	result, err := internals.UnsafeAwaitOutput(ctx, output)
+	if err != nil {
+		return "", err
+	}
+	value, _ := result.Value.(string)
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,190 @@
+package orchestrator
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+const (
+	tracingNamespace = "observability"
+
+	// jaegerCollectorEndpoint is the OTLP gRPC address of the in-cluster
+	// collector the Jaeger instance creates
+	jaegerCollectorEndpoint = "http://jaeger-collector." + tracingNamespace + ".svc.cluster.local:4317"
+
+	// defaultTracingSampling is Jaeger's own default probabilistic rate
+	defaultTracingSampling = 0.001
+)
+
+// tracingPlan is what installTracing sets up for a TracingConfig
+type tracingPlan struct {
+	Endpoint  string  // Where applications send spans
+	Sampling  float64 // Default sampling probability
+	InCluster bool    // Whether the Jaeger operator and instance are installed
+}
+
+// planTracing resolves a Jaeger TracingConfig. Without an endpoint, or with
+// one inside the cluster, Jaeger is installed and applications point at its
+// collector; an external endpoint is used as given and nothing is installed.
+func planTracing(tracing *config.TracingConfig) (*tracingPlan, error) {
+	sampling := tracing.Sampling
+	if sampling == 0 {
+		sampling = defaultTracingSampling
+	}
+	if sampling < 0 || sampling > 1 {
+		return nil, fmt.Errorf("tracing sampling must be between 0 and 1, got %g", tracing.Sampling)
+	}
+
+	if tracing.Endpoint == "" {
+		return &tracingPlan{Endpoint: jaegerCollectorEndpoint, Sampling: sampling, InCluster: true}, nil
+	}
+
+	external, err := isExternalEndpoint(tracing.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &tracingPlan{Endpoint: tracing.Endpoint, Sampling: sampling, InCluster: !external}, nil
+}
+
+// isExternalEndpoint reports whether an endpoint lives outside the cluster:
+// bare service names (jaeger) and *.svc / *.cluster.local hosts are
+// in-cluster, IPs and other domains are external
+func isExternalEndpoint(endpoint string) (bool, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return false, fmt.Errorf("tracing endpoint %q is not a URL such as http://collector:4317", endpoint)
+	}
+	host := u.Hostname()
+	if net.ParseIP(host) != nil {
+		return true, nil
+	}
+	if strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".cluster.local") {
+		return false, nil
+	}
+	return strings.Contains(host, "."), nil
+}
+
+// renderJaegerInstance renders the Jaeger resource the operator turns into
+// an all-in-one deployment sampling at the plan's default rate
+func renderJaegerInstance(plan *tracingPlan) string {
+	var builder strings.Builder
+	builder.WriteString("apiVersion: jaegertracing.io/v1\n")
+	builder.WriteString("kind: Jaeger\n")
+	builder.WriteString("metadata:\n")
+	builder.WriteString("  name: jaeger\n")
+	builder.WriteString(fmt.Sprintf("  namespace: %s\n", tracingNamespace))
+	builder.WriteString("spec:\n")
+	builder.WriteString("  strategy: allInOne\n")
+	builder.WriteString("  allInOne:\n")
+	builder.WriteString("    options:\n")
+	builder.WriteString("      collector:\n")
+	builder.WriteString("        otlp:\n")
+	builder.WriteString("          enabled: true\n")
+	builder.WriteString("  sampling:\n")
+	builder.WriteString("    options:\n")
+	builder.WriteString("      default_strategy:\n")
+	builder.WriteString("        type: probabilistic\n")
+	builder.WriteString(fmt.Sprintf("        param: %s\n", strconv.FormatFloat(plan.Sampling, 'g', -1, 64)))
+	return builder.String()
+}
+
+// renderTracingDefaults renders the ConfigMap holding the OpenTelemetry
+// settings applications should use. It lives in kube-public so every
+// namespace can read it.
+func renderTracingDefaults(plan *tracingPlan) string {
+	var builder strings.Builder
+	builder.WriteString("apiVersion: v1\n")
+	builder.WriteString("kind: ConfigMap\n")
+	builder.WriteString("metadata:\n")
+	builder.WriteString("  name: tracing-defaults\n")
+	builder.WriteString("  namespace: kube-public\n")
+	builder.WriteString("data:\n")
+	builder.WriteString(fmt.Sprintf("  OTEL_EXPORTER_OTLP_ENDPOINT: %q\n", plan.Endpoint))
+	builder.WriteString("  OTEL_TRACES_SAMPLER: \"parentbased_traceidratio\"\n")
+	builder.WriteString(fmt.Sprintf("  OTEL_TRACES_SAMPLER_ARG: %q\n", strconv.FormatFloat(plan.Sampling, 'g', -1, 64)))
+	return builder.String()
+}
+
+// renderTracingInstall renders the script that sets tracing up. For an
+// in-cluster collector it installs cert-manager when missing (the operator's
+// webhooks need it), the Jaeger operator and a Jaeger instance, and waits for
+// Jaeger to become available.
+func renderTracingInstall(plan *tracingPlan) string {
+	var builder strings.Builder
+	builder.WriteString("set -e\n\n")
+	if plan.InCluster {
+		builder.WriteString(fmt.Sprintf(`if ! command -v helm &> /dev/null; then
+    curl https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | bash
+fi
+helm repo add jetstack https://charts.jetstack.io || true
+helm repo add jaegertracing https://jaegertracing.github.io/helm-charts || true
+helm repo update
+
+if ! kubectl get crd certificates.cert-manager.io &> /dev/null; then
+    helm upgrade --install cert-manager jetstack/cert-manager \
+      --namespace cert-manager --create-namespace --set crds.enabled=true --wait
+fi
+
+helm upgrade --install jaeger-operator jaegertracing/jaeger-operator \
+  --namespace %[1]s --create-namespace --set rbac.clusterRole=true --wait
+kubectl wait --for condition=established --timeout=120s crd/jaegers.jaegertracing.io
+
+kubectl apply -f - <<'JAEGER'
+%[2]sJAEGER
+
+for i in $(seq 1 60); do
+    kubectl -n %[1]s get deployment jaeger &> /dev/null && break
+    sleep 5
+done
+kubectl -n %[1]s wait --for=condition=Available deployment/jaeger --timeout=5m
+echo "Jaeger ready"
+
+`, tracingNamespace, renderJaegerInstance(plan)))
+	}
+	builder.WriteString(fmt.Sprintf("kubectl apply -f - <<'DEFAULTS'\n%sDEFAULTS\n", renderTracingDefaults(plan)))
+	return builder.String()
+}
+
+// installTracing installs Jaeger when Monitoring.Tracing selects it and
+// exports the tracing settings so the deploy reports them. The install waits
+// for Jaeger to become available, so a deploy that succeeds has it running.
+func (o *Orchestrator) installTracing() error {
+	tracing := o.config.Monitoring.Tracing
+	if tracing == nil || tracing.Provider != "jaeger" {
+		return nil
+	}
+
+	runScript, err := o.addonScriptRunner("tracing")
+	if err != nil {
+		return err
+	}
+
+	plan, err := planTracing(tracing)
+	if err != nil {
+		return err
+	}
+
+	if plan.InCluster {
+		o.log.Info(fmt.Sprintf("Installing Jaeger (sampling %g)", plan.Sampling))
+	} else {
+		o.log.Info(fmt.Sprintf("Using external tracing endpoint %s; skipping in-cluster Jaeger", plan.Endpoint))
+	}
+	if err := runScript("tracing-install", renderTracingInstall(plan)); err != nil {
+		return fmt.Errorf("failed to install jaeger: %w", err)
+	}
+
+	secrets.Export(o.ctx, "tracing", pulumi.Map{
+		"provider":   pulumi.String(tracing.Provider),
+		"endpoint":   pulumi.String(plan.Endpoint),
+		"sampling":   pulumi.Float64(plan.Sampling),
+		"in_cluster": pulumi.Bool(plan.InCluster),
+	})
+	return nil
+}
@@ -72,6 +72,7 @@ func NewRealWireGuardSetupComponent(ctx *pulumi.Context, name string, config *co
 				fmt.Sprintf("%s-node-%d", name, i+1),
 				&nodeConfig,
 				configs, // All peer configs for mesh
+				config.Network.WireGuard.Keepalive(config.NodesBehindNAT()),
 				sshPrivateKey,
 				component)
 			outputs = append(outputs, pulumi.ToOutput(output))
@@ -100,7 +101,8 @@ func NewRealWireGuardSetupComponent(ctx *pulumi.Context, name string, config *co
 }
 
 // installAndConfigureWireGuard installs WireGuard and creates mesh configuration
-func installAndConfigureWireGuard(ctx *pulumi.Context, name string, nodeConfig *NodeWireGuardConfig, allPeers []NodeWireGuardConfig, sshPrivateKey pulumi.StringOutput, parent pulumi.Resource) pulumi.StringOutput {
+// Peers get PersistentKeepalive = keepalive seconds; 0 leaves it out
+func installAndConfigureWireGuard(ctx *pulumi.Context, name string, nodeConfig *NodeWireGuardConfig, allPeers []NodeWireGuardConfig, keepalive int, sshPrivateKey pulumi.StringOutput, parent pulumi.Resource) pulumi.StringOutput {
 
 	// Step 1: Generate WireGuard private and public keys on the node
 	keyGenScript := `#!/bin/bash
@@ -149,9 +151,11 @@ PrivateKey = $(cat /etc/wireguard/privatekey)
 PublicKey = $(cat /etc/wireguard/publickey)
 AllowedIPs = %s
 Endpoint = %s:%d
-PersistentKeepalive = 25
-
 `, peer.NodeName, peer.Address, "PEER_PUBLIC_IP", peer.ListenPort)
+				if keepalive > 0 {
+					config += fmt.Sprintf("PersistentKeepalive = %d\n", keepalive)
+				}
+				config += "\n"
 			}
 		}
 
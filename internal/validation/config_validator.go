@@ -36,19 +36,34 @@ func ValidateVPNConfig(cfg *config.ClusterConfig) error {
 		return fmt.Errorf("VPN (WireGuard or Tailscale) must be enabled for private cluster deployment")
 	}
 
-	// Cannot have both enabled
-	if wireGuardEnabled && tailscaleEnabled {
-		return fmt.Errorf("cannot enable both WireGuard and Tailscale - choose one VPN solution")
-	}
-
-	// Validate the specific VPN configuration
-	if wireGuardEnabled {
+	// Validate the VPN the mesh is built with; see ValidateVPNSelection for
+	// configs that enable both
+	if cfg.Network.SelectedVPN() == config.VPNWireGuard {
 		return ValidateWireGuardConfig(cfg)
 	}
 
 	return ValidateTailscaleConfig(cfg)
 }
 
+// ValidateVPNSelection checks that enabling both WireGuard and Tailscale is
+// intentional. Unless the network mode names the VPN to use, it returns a
+// warning saying which one is ignored, or an error in strict mode.
+func ValidateVPNSelection(cfg *config.ClusterConfig, strict bool) (string, error) {
+	if !cfg.Network.BothVPNsEnabled() {
+		return "", nil
+	}
+
+	if cfg.Network.Mode == config.VPNWireGuard || cfg.Network.Mode == config.VPNTailscale {
+		return "", nil
+	}
+
+	if strict {
+		return "", fmt.Errorf("both WireGuard and Tailscale are enabled - disable one or set the network mode to \"wireguard\" or \"tailscale\"")
+	}
+
+	return "both VPNs enabled; using Tailscale, ignoring WireGuard (set the network mode to choose explicitly)", nil
+}
+
 // ValidateWireGuardConfig validates WireGuard configuration
 func ValidateWireGuardConfig(cfg *config.ClusterConfig) error {
 	// If auto-creating VPN, validate creation parameters
@@ -528,3 +528,93 @@ func TestValidateMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateVPNSelection(t *testing.T) {
+	both := func(mode string) *config.ClusterConfig {
+		return &config.ClusterConfig{
+			Network: config.NetworkConfig{
+				Mode:      mode,
+				WireGuard: &config.WireGuardConfig{Enabled: true},
+				Tailscale: &config.TailscaleConfig{Enabled: true},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		config        *config.ClusterConfig
+		strict        bool
+		wantWarning   string
+		errorContains string
+	}{
+		{
+			name: "Single VPN",
+			config: &config.ClusterConfig{
+				Network: config.NetworkConfig{WireGuard: &config.WireGuardConfig{Enabled: true}},
+			},
+		},
+		{
+			name:        "Both enabled warns",
+			config:      both(""),
+			wantWarning: "using Tailscale, ignoring WireGuard",
+		},
+		{
+			name:          "Both enabled errors in strict mode",
+			config:        both(""),
+			strict:        true,
+			errorContains: "both WireGuard and Tailscale are enabled",
+		},
+		{
+			name:   "Both enabled with explicit mode",
+			config: both("wireguard"),
+			strict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning, err := ValidateVPNSelection(tt.config, tt.strict)
+
+			if tt.errorContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+					t.Errorf("expected error containing '%s', got %v", tt.errorContains, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantWarning == "" && warning != "" {
+				t.Errorf("unexpected warning: %s", warning)
+			}
+			if !strings.Contains(warning, tt.wantWarning) {
+				t.Errorf("warning '%s' does not contain '%s'", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestValidateVPNConfig_BothEnabledValidatesSelected(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{
+			Mode: "wireguard",
+			WireGuard: &config.WireGuardConfig{
+				Enabled:         true,
+				ServerEndpoint:  "1.2.3.4:51820",
+				ServerPublicKey: "key",
+			},
+			Tailscale: &config.TailscaleConfig{Enabled: true},
+		},
+	}
+
+	if err := ValidateVPNConfig(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Network.Mode = ""
+	err := ValidateVPNConfig(cfg)
+	if err == nil || !strings.Contains(err.Error(), "Headscale") {
+		t.Errorf("expected Tailscale validation error, got %v", err)
+	}
+}
@@ -3,11 +3,13 @@ package validation
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"regexp"
 	"strings"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/network"
 	"github.com/digitalocean/godo"
 	"github.com/linode/linodego"
 	"golang.org/x/oauth2"
@@ -352,6 +354,8 @@ func ValidateNetworkingConfig(cfg *config.ClusterConfig) error {
 		}
 	}
 
+	errors = append(errors, cidrOverlaps(cfg)...)
+
 	if len(errors) > 0 {
 		return fmt.Errorf("network validation failed:\n  • %s", strings.Join(errors, "\n  • "))
 	}
@@ -359,6 +363,33 @@ func ValidateNetworkingConfig(cfg *config.ClusterConfig) error {
 	return nil
 }
 
+// cidrOverlaps names every pair of the cluster's CIDRs that overlap. Ranges
+// that do not parse are left to the format checks.
+func cidrOverlaps(cfg *config.ClusterConfig) []string {
+	type parsedCIDR struct {
+		network.NamedCIDR
+		ipNet *net.IPNet
+	}
+
+	var cidrs []parsedCIDR
+	for _, c := range network.ClusterCIDRs(cfg) {
+		if _, ipNet, err := net.ParseCIDR(c.CIDR); err == nil {
+			cidrs = append(cidrs, parsedCIDR{c, ipNet})
+		}
+	}
+
+	var overlaps []string
+	for i := range cidrs {
+		for j := i + 1; j < len(cidrs); j++ {
+			a, b := cidrs[i], cidrs[j]
+			if network.CIDRsOverlap(a.ipNet, b.ipNet) {
+				overlaps = append(overlaps, fmt.Sprintf("%s %s overlaps %s %s", a.Name, a.CIDR, b.Name, b.CIDR))
+			}
+		}
+	}
+	return overlaps
+}
+
 // validateCIDR validates a CIDR notation string
 func validateCIDR(cidr string) error {
 	cidrRegex := regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}/\d{1,2}$`)
@@ -456,6 +456,43 @@ func TestValidateNetworkingConfig_InvalidServiceCIDR(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid Service CIDR")
 }
 
+func TestValidateNetworkingConfig_CIDROverlap(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{
+			CIDR:      "10.0.0.0/16",
+			WireGuard: &config.WireGuardConfig{Enabled: true, SubnetCIDR: "10.96.4.0/24"},
+		},
+		Kubernetes: config.KubernetesConfig{
+			PodCIDR:     "10.0.128.0/17",
+			ServiceCIDR: "10.96.0.0/12",
+		},
+	}
+
+	err := ValidateNetworkingConfig(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "network CIDR 10.0.0.0/16 overlaps pod CIDR 10.0.128.0/17")
+	assert.Contains(t, err.Error(), "service CIDR 10.96.0.0/12 overlaps WireGuard subnet 10.96.4.0/24")
+}
+
+func TestValidateNetworkingConfig_AdjacentCIDRs(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{
+			CIDR:      "10.0.0.0/16",
+			WireGuard: &config.WireGuardConfig{Enabled: true, SubnetCIDR: "10.3.0.0/24"},
+		},
+		Kubernetes: config.KubernetesConfig{
+			PodCIDR:     "10.1.0.0/16",
+			ServiceCIDR: "10.2.0.0/16",
+		},
+	}
+
+	assert.NoError(t, ValidateNetworkingConfig(cfg))
+
+	// A disabled WireGuard's subnet is not routed
+	cfg.Network.WireGuard = &config.WireGuardConfig{SubnetCIDR: "10.1.0.0/24"}
+	assert.NoError(t, ValidateNetworkingConfig(cfg))
+}
+
 func TestValidateCIDR_Valid(t *testing.T) {
 	validCIDRs := []string{
 		"10.0.0.0/8",
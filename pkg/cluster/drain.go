@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// drainNodeScript cordons, drains and deletes a node with kubectl. A drain
+// that does not finish within timeout is reported but does not fail the
+// script, so removing the node never waits on a stuck eviction.
+func drainNodeScript(env, name string, timeout time.Duration) string {
+	seconds := int(timeout.Seconds())
+	return fmt.Sprintf(`
+#!/bin/bash
+%[1]s
+kubectl cordon %[2]s || true
+if ! kubectl drain %[2]s --ignore-daemonsets --delete-emptydir-data --force --timeout=%[3]ds; then
+    echo "WARNING: drain of %[2]s did not finish within %[3]ds; continuing"
+fi
+kubectl delete node %[2]s --ignore-not-found || true
+`, env, name, seconds)
+}
+
+// newDrainCommand runs drainNodeScript over conn, giving up a minute after
+// the drain timeout
+func newDrainCommand(ctx *pulumi.Context, conn *remote.ConnectionArgs, env, name string, timeout time.Duration) error {
+	_, err := remote.NewCommand(ctx, "drain-"+name, &remote.CommandArgs{
+		Connection: conn,
+		Create:     pulumi.String(drainNodeScript(env, name, timeout)),
+	}, pulumi.Timeouts(&pulumi.CustomTimeouts{Create: (timeout + time.Minute).String()}))
+	return err
+}
+
+// cordonAndDrainScript cordons and drains a node with kubectl ahead of
+// maintenance. The node stays in the cluster, unschedulable until
+// uncordonNodeScript runs; a drain that does not finish within timeout fails
+// the script so the maintenance does not start with workloads still on it.
+func cordonAndDrainScript(env, name string, timeout time.Duration) string {
+	seconds := int(timeout.Seconds())
+	return fmt.Sprintf(`
+#!/bin/bash
+set -e
+%[1]s
+kubectl cordon %[2]s
+kubectl drain %[2]s --ignore-daemonsets --delete-emptydir-data --force --timeout=%[3]ds
+`, env, name, seconds)
+}
+
+// uncordonNodeScript makes a node schedulable again with kubectl
+func uncordonNodeScript(env, name string) string {
+	return fmt.Sprintf(`
+#!/bin/bash
+set -e
+%[1]s
+kubectl uncordon %[2]s
+`, env, name)
+}
+
+// newCordonAndDrainCommand runs cordonAndDrainScript over conn, giving up a
+// minute after the drain timeout
+func newCordonAndDrainCommand(ctx *pulumi.Context, conn *remote.ConnectionArgs, env, name string, timeout time.Duration) error {
+	_, err := remote.NewCommand(ctx, "cordon-drain-"+name, &remote.CommandArgs{
+		Connection: conn,
+		Create:     pulumi.String(cordonAndDrainScript(env, name, timeout)),
+	}, pulumi.Timeouts(&pulumi.CustomTimeouts{Create: (timeout + time.Minute).String()}))
+	return err
+}
+
+// newUncordonCommand runs uncordonNodeScript over conn
+func newUncordonCommand(ctx *pulumi.Context, conn *remote.ConnectionArgs, env, name string) error {
+	_, err := remote.NewCommand(ctx, "uncordon-"+name, &remote.CommandArgs{
+		Connection: conn,
+		Create:     pulumi.String(uncordonNodeScript(env, name)),
+	})
+	return err
+}
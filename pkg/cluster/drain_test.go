@@ -0,0 +1,40 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainNodeScript(t *testing.T) {
+	script := drainNodeScript("", "worker-2", 90*time.Second)
+
+	assert.Contains(t, script, "kubectl cordon worker-2 || true")
+	assert.Contains(t, script, "if ! kubectl drain worker-2 --ignore-daemonsets --delete-emptydir-data --force --timeout=90s; then")
+	assert.Contains(t, script, "drain of worker-2 did not finish within 90s; continuing")
+	assert.Contains(t, script, "kubectl delete node worker-2 --ignore-not-found || true")
+	assert.NotContains(t, script, "set -e")
+}
+
+func TestDrainNodeScript_RKE2Environment(t *testing.T) {
+	script := drainNodeScript("export KUBECONFIG=/etc/rancher/rke2/rke2.yaml", "worker-1", 5*time.Minute)
+
+	assert.Contains(t, script, "export KUBECONFIG=/etc/rancher/rke2/rke2.yaml\nkubectl cordon worker-1")
+	assert.Contains(t, script, "--timeout=300s")
+}
+
+func TestCordonAndDrainScript_KeepsNode(t *testing.T) {
+	script := cordonAndDrainScript("", "worker-1", 2*time.Minute)
+
+	assert.Contains(t, script, "set -e")
+	assert.Contains(t, script, "kubectl cordon worker-1\n")
+	assert.Contains(t, script, "kubectl drain worker-1 --ignore-daemonsets --delete-emptydir-data --force --timeout=120s")
+	assert.NotContains(t, script, "kubectl delete node")
+}
+
+func TestUncordonNodeScript(t *testing.T) {
+	script := uncordonNodeScript("export KUBECONFIG=/etc/rancher/rke2/rke2.yaml", "worker-1")
+
+	assert.Contains(t, script, "export KUBECONFIG=/etc/rancher/rke2/rke2.yaml\nkubectl uncordon worker-1")
+}
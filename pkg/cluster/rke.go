@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
@@ -184,6 +185,15 @@ func (r *RKEManager) getNodeTaints(node *providers.NodeOutput) []map[string]inte
 		}
 	}
 
+	// Add taints configured on the node or its pool
+	for _, taint := range node.Taints {
+		taints = append(taints, map[string]interface{}{
+			"key":    taint.Key,
+			"value":  taint.Value,
+			"effect": taint.Effect,
+		})
+	}
+
 	return taints
 }
 
@@ -513,6 +523,110 @@ echo "Helm installed and configured"
 	return nil
 }
 
+// ApplyManifest applies a Kubernetes manifest with kubectl on the master node.
+// name must be unique per cluster; it names the Pulumi command resource.
+func (r *RKEManager) ApplyManifest(name, manifest string) error {
+	masterNode := r.getMasterNode()
+	if masterNode == nil {
+		return fmt.Errorf("no master node found")
+	}
+
+	_, err := remote.NewCommand(r.ctx, "apply-"+name, &remote.CommandArgs{
+		Connection: &remote.ConnectionArgs{
+			Host:       masterNode.PublicIP,
+			Port:       pulumi.Float64(22),
+			User:       pulumi.String(masterNode.SSHUser),
+			PrivateKey: pulumi.String(r.getSSHPrivateKey()),
+		},
+		Create: pulumi.String(fmt.Sprintf(`
+#!/bin/bash
+set -e
+
+kubectl apply -f - <<'MANIFEST'
+%sMANIFEST
+`, manifest)),
+	})
+
+	return err
+}
+
+// RunScript runs a shell script on the master node. name must be unique per
+// cluster; it names the Pulumi command resource.
+func (r *RKEManager) RunScript(name, script string) error {
+	_, err := r.RunScriptOutput(name, script)
+	return err
+}
+
+// RunScriptOutput runs a script like RunScript and returns its standard output
+func (r *RKEManager) RunScriptOutput(name, script string) (pulumi.StringOutput, error) {
+	masterNode := r.getMasterNode()
+	if masterNode == nil {
+		return pulumi.StringOutput{}, fmt.Errorf("no master node found")
+	}
+
+	cmd, err := remote.NewCommand(r.ctx, "run-"+name, &remote.CommandArgs{
+		Connection: &remote.ConnectionArgs{
+			Host:       masterNode.PublicIP,
+			Port:       pulumi.Float64(22),
+			User:       pulumi.String(masterNode.SSHUser),
+			PrivateKey: pulumi.String(r.getSSHPrivateKey()),
+		},
+		Create: pulumi.String(script),
+	})
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	return cmd.Stdout, nil
+}
+
+// DrainNode cordons and drains a node with kubectl on the master node, then
+// removes it from the cluster, ahead of destroying its instance
+func (r *RKEManager) DrainNode(name string, timeout time.Duration) error {
+	masterNode := r.getMasterNode()
+	if masterNode == nil {
+		return fmt.Errorf("no master node found")
+	}
+
+	return newDrainCommand(r.ctx, &remote.ConnectionArgs{
+		Host:       masterNode.PublicIP,
+		Port:       pulumi.Float64(22),
+		User:       pulumi.String(masterNode.SSHUser),
+		PrivateKey: pulumi.String(r.getSSHPrivateKey()),
+	}, "", name, timeout)
+}
+
+// CordonAndDrainNode cordons and drains a node with kubectl on the master
+// node ahead of maintenance, leaving it in the cluster
+func (r *RKEManager) CordonAndDrainNode(name string, timeout time.Duration) error {
+	masterNode := r.getMasterNode()
+	if masterNode == nil {
+		return fmt.Errorf("no master node found")
+	}
+
+	return newCordonAndDrainCommand(r.ctx, &remote.ConnectionArgs{
+		Host:       masterNode.PublicIP,
+		Port:       pulumi.Float64(22),
+		User:       pulumi.String(masterNode.SSHUser),
+		PrivateKey: pulumi.String(r.getSSHPrivateKey()),
+	}, "", name, timeout)
+}
+
+// UncordonNode makes a node schedulable again with kubectl on the master node
+func (r *RKEManager) UncordonNode(name string) error {
+	masterNode := r.getMasterNode()
+	if masterNode == nil {
+		return fmt.Errorf("no master node found")
+	}
+
+	return newUncordonCommand(r.ctx, &remote.ConnectionArgs{
+		Host:       masterNode.PublicIP,
+		Port:       pulumi.Float64(22),
+		User:       pulumi.String(masterNode.SSHUser),
+		PrivateKey: pulumi.String(r.getSSHPrivateKey()),
+	}, "", name)
+}
+
 // installMonitoring installs Prometheus and Grafana
 func (r *RKEManager) installMonitoring(masterNode *providers.NodeOutput) error {
 	_, err := remote.NewCommand(r.ctx, "install-monitoring", &remote.CommandArgs{
@@ -3,6 +3,7 @@ package cluster
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
@@ -101,7 +102,7 @@ func (r *RKE2Manager) deployFirstMaster(node *providers.NodeOutput) error {
 	}
 
 	// Generate RKE2 server config
-	serverConfig := config.BuildRKE2ServerConfig(r.rke2Config, nodeIP, node.Name, true, "", r.config)
+	serverConfig := config.BuildRKE2ServerConfig(r.nodeRKE2Config(node), nodeIP, node.Name, true, "", r.config)
 
 	// Get install command
 	installCmd := config.GetRKE2InstallCommand(r.rke2Config, true)
@@ -210,7 +211,7 @@ func (r *RKE2Manager) deployAdditionalMaster(node *providers.NodeOutput, firstMa
 	}
 
 	// Generate RKE2 server config for additional master
-	serverConfig := config.BuildRKE2ServerConfig(r.rke2Config, nodeIP, node.Name, false, firstMasterIP, r.config)
+	serverConfig := config.BuildRKE2ServerConfig(r.nodeRKE2Config(node), nodeIP, node.Name, false, firstMasterIP, r.config)
 	installCmd := config.GetRKE2InstallCommand(r.rke2Config, true)
 
 	script := fmt.Sprintf(`#!/bin/bash
@@ -298,7 +299,7 @@ func (r *RKE2Manager) deployWorker(node *providers.NodeOutput, firstMaster *prov
 	}
 
 	// Generate RKE2 agent config
-	agentConfig := config.BuildRKE2AgentConfig(r.rke2Config, nodeIP, node.Name, firstMasterIP)
+	agentConfig := config.BuildRKE2AgentConfig(r.nodeRKE2Config(node), nodeIP, node.Name, firstMasterIP, r.config)
 	installCmd := config.GetRKE2InstallCommand(r.rke2Config, false)
 
 	script := fmt.Sprintf(`#!/bin/bash
@@ -364,6 +365,62 @@ echo "RKE2 agent removed"
 	return err
 }
 
+// nodeRKE2Config returns the RKE2 config for a node, with the node's own taints
+// appended to the cluster-wide node-taint list
+func (r *RKE2Manager) nodeRKE2Config(node *providers.NodeOutput) *config.RKE2Config {
+	if len(node.Taints) == 0 {
+		return r.rke2Config
+	}
+
+	nodeConfig := *r.rke2Config
+	nodeConfig.NodeTaint = append([]string{}, r.rke2Config.NodeTaint...)
+	for _, taint := range node.Taints {
+		if taint.Value != "" {
+			nodeConfig.NodeTaint = append(nodeConfig.NodeTaint, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+		} else {
+			nodeConfig.NodeTaint = append(nodeConfig.NodeTaint, fmt.Sprintf("%s:%s", taint.Key, taint.Effect))
+		}
+	}
+	return &nodeConfig
+}
+
+// DrainNode cordons and drains a node with RKE2's kubectl on the first
+// master, then removes it from the cluster, ahead of destroying its instance
+func (r *RKE2Manager) DrainNode(name string, timeout time.Duration) error {
+	masters := r.getMasterNodes()
+	if len(masters) == 0 {
+		return fmt.Errorf("no master nodes found")
+	}
+
+	return newDrainCommand(r.ctx, r.getConnection(masters[0]), rke2KubectlEnv, name, timeout)
+}
+
+// CordonAndDrainNode cordons and drains a node with RKE2's kubectl on the
+// first master ahead of maintenance, leaving it in the cluster
+func (r *RKE2Manager) CordonAndDrainNode(name string, timeout time.Duration) error {
+	masters := r.getMasterNodes()
+	if len(masters) == 0 {
+		return fmt.Errorf("no master nodes found")
+	}
+
+	return newCordonAndDrainCommand(r.ctx, r.getConnection(masters[0]), rke2KubectlEnv, name, timeout)
+}
+
+// UncordonNode makes a node schedulable again with RKE2's kubectl on the
+// first master
+func (r *RKE2Manager) UncordonNode(name string) error {
+	masters := r.getMasterNodes()
+	if len(masters) == 0 {
+		return fmt.Errorf("no master nodes found")
+	}
+
+	return newUncordonCommand(r.ctx, r.getConnection(masters[0]), rke2KubectlEnv, name)
+}
+
+// rke2KubectlEnv puts RKE2's kubectl and kubeconfig in reach of a script run
+// on a master
+const rke2KubectlEnv = "export PATH=$PATH:/var/lib/rancher/rke2/bin\nexport KUBECONFIG=/etc/rancher/rke2/rke2.yaml"
+
 // getConnection returns the SSH connection for a node
 func (r *RKE2Manager) getConnection(node *providers.NodeOutput) *remote.ConnectionArgs {
 	// Use PublicIP for SSH connection (WireGuard IP is for internal cluster communication)
@@ -493,6 +550,59 @@ echo "Helm installed and configured"
 	return nil
 }
 
+// ApplyManifest applies a Kubernetes manifest with kubectl on the first master.
+// name must be unique per cluster; it names the Pulumi command resource.
+func (r *RKE2Manager) ApplyManifest(name, manifest string) error {
+	masters := r.getMasterNodes()
+	if len(masters) == 0 {
+		return fmt.Errorf("no master node found")
+	}
+
+	_, err := remote.NewCommand(r.ctx, "rke2-apply-"+name, &remote.CommandArgs{
+		Connection: r.getConnection(masters[0]),
+		Create: pulumi.String(fmt.Sprintf(`#!/bin/bash
+set -e
+
+export PATH=$PATH:/var/lib/rancher/rke2/bin
+export KUBECONFIG=/etc/rancher/rke2/rke2.yaml
+
+kubectl apply -f - <<'MANIFEST'
+%sMANIFEST
+`, manifest)),
+	})
+
+	return err
+}
+
+// RunScript runs a shell script on the first master with kubectl on the PATH.
+// name must be unique per cluster; it names the Pulumi command resource.
+func (r *RKE2Manager) RunScript(name, script string) error {
+	_, err := r.RunScriptOutput(name, script)
+	return err
+}
+
+// RunScriptOutput runs a script like RunScript and returns its standard output
+func (r *RKE2Manager) RunScriptOutput(name, script string) (pulumi.StringOutput, error) {
+	masters := r.getMasterNodes()
+	if len(masters) == 0 {
+		return pulumi.StringOutput{}, fmt.Errorf("no master node found")
+	}
+
+	cmd, err := remote.NewCommand(r.ctx, "rke2-run-"+name, &remote.CommandArgs{
+		Connection: r.getConnection(masters[0]),
+		Create: pulumi.String(`#!/bin/bash
+export PATH=$PATH:/var/lib/rancher/rke2/bin
+export KUBECONFIG=/etc/rancher/rke2/rke2.yaml
+
+` + script),
+	})
+	if err != nil {
+		return pulumi.StringOutput{}, err
+	}
+
+	return cmd.Stdout, nil
+}
+
 // installMonitoring installs Prometheus and Grafana
 func (r *RKE2Manager) installMonitoring(masterNode *providers.NodeOutput) error {
 	_, err := remote.NewCommand(r.ctx, "rke2-install-monitoring", &remote.CommandArgs{
@@ -338,7 +338,7 @@ func TestRKE2Config_BuildAgentConfig(t *testing.T) {
 		SeLinux:      false,
 	}
 
-	agentConfig := config.BuildRKE2AgentConfig(rke2Config, "10.8.0.20", "worker-1", "10.8.0.10")
+	agentConfig := config.BuildRKE2AgentConfig(rke2Config, "10.8.0.20", "worker-1", "10.8.0.10", nil)
 
 	// Verify key config elements
 	assert.Contains(t, agentConfig, "token: test-token")
@@ -401,3 +401,26 @@ func TestRKE2Config_GetInstallCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestRKE2Manager_NodeRKE2Config_Taints(t *testing.T) {
+	manager := NewRKE2Manager(nil, &config.KubernetesConfig{Distribution: "rke2"})
+	manager.rke2Config.NodeTaint = []string{"cluster=wide:NoSchedule"}
+
+	plain := &providers.NodeOutput{Name: "worker-1"}
+	assert.Same(t, manager.rke2Config, manager.nodeRKE2Config(plain))
+
+	tainted := &providers.NodeOutput{
+		Name: "standalone-master",
+		Taints: []config.TaintConfig{
+			{Key: "dedicated", Value: "control", Effect: "NoSchedule"},
+			{Key: "gpu", Effect: "NoExecute"},
+		},
+	}
+	nodeConfig := manager.nodeRKE2Config(tainted)
+
+	assert.Equal(t, []string{"cluster=wide:NoSchedule", "dedicated=control:NoSchedule", "gpu:NoExecute"}, nodeConfig.NodeTaint)
+	assert.Equal(t, []string{"cluster=wide:NoSchedule"}, manager.rke2Config.NodeTaint, "cluster config must not be modified")
+
+	agentConfig := config.BuildRKE2AgentConfig(nodeConfig, "10.8.0.10", tainted.Name, "10.8.0.1", nil)
+	assert.True(t, strings.Contains(agentConfig, "  - dedicated=control:NoSchedule"))
+}
@@ -3,6 +3,7 @@ package cluster
 import (
 	"testing"
 
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
 	"github.com/stretchr/testify/assert"
 )
@@ -451,3 +452,26 @@ func TestRKEManager_TaintFormatValidation(t *testing.T) {
 		})
 	}
 }
+
+// Test getNodeTaints with taints configured on the node
+func TestRKEManager_GetNodeTaints_ConfiguredTaints(t *testing.T) {
+	manager := &RKEManager{}
+
+	node := &providers.NodeOutput{
+		Name:   "standalone-master",
+		Labels: map[string]string{},
+		Taints: []config.TaintConfig{
+			{Key: "dedicated", Value: "control", Effect: "NoSchedule"},
+			{Key: "gpu", Effect: "NoExecute"},
+		},
+	}
+
+	taints := manager.getNodeTaints(node)
+
+	assert.Len(t, taints, 2)
+	assert.Equal(t, "dedicated", taints[0]["key"])
+	assert.Equal(t, "control", taints[0]["value"])
+	assert.Equal(t, "NoSchedule", taints[0]["effect"])
+	assert.Equal(t, "gpu", taints[1]["key"])
+	assert.Equal(t, "NoExecute", taints[1]["effect"])
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Hash returns a short, stable fingerprint of the credentials. It identifies
+// the account a provider instance talks to without exposing the secrets.
+func (c *ProviderCredentials) Hash() string {
+	fields := []string{
+		c.Token,
+		c.AccessKeyID, c.SecretAccessKey,
+		c.SubscriptionID, c.TenantID, c.ClientID, c.ClientSecret,
+		c.ProjectID, c.Credentials,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// WithProviderCredentials returns a copy of the config whose settings for the
+// named provider carry the given credentials. Only that provider's settings
+// are copied; everything else is shared with the original config.
+func (c *ClusterConfig) WithProviderCredentials(providerName string, creds *ProviderCredentials) (*ClusterConfig, error) {
+	out := *c
+	p := &out.Providers
+
+	switch providerName {
+	case "digitalocean":
+		if p.DigitalOcean == nil {
+			break
+		}
+		do := *p.DigitalOcean
+		do.Token = override(do.Token, creds.Token)
+		p.DigitalOcean = &do
+		return &out, nil
+	case "linode":
+		if p.Linode == nil {
+			break
+		}
+		linode := *p.Linode
+		linode.Token = override(linode.Token, creds.Token)
+		p.Linode = &linode
+		return &out, nil
+	case "hetzner":
+		if p.Hetzner == nil {
+			break
+		}
+		hetzner := *p.Hetzner
+		hetzner.Token = override(hetzner.Token, creds.Token)
+		p.Hetzner = &hetzner
+		return &out, nil
+	case "aws":
+		if p.AWS == nil {
+			break
+		}
+		aws := *p.AWS
+		aws.AccessKeyID = override(aws.AccessKeyID, creds.AccessKeyID)
+		aws.SecretAccessKey = override(aws.SecretAccessKey, creds.SecretAccessKey)
+		p.AWS = &aws
+		return &out, nil
+	case "azure":
+		if p.Azure == nil {
+			break
+		}
+		azure := *p.Azure
+		azure.SubscriptionID = override(azure.SubscriptionID, creds.SubscriptionID)
+		azure.TenantID = override(azure.TenantID, creds.TenantID)
+		azure.ClientID = override(azure.ClientID, creds.ClientID)
+		azure.ClientSecret = override(azure.ClientSecret, creds.ClientSecret)
+		p.Azure = &azure
+		return &out, nil
+	case "gcp":
+		if p.GCP == nil {
+			break
+		}
+		gcp := *p.GCP
+		gcp.ProjectID = override(gcp.ProjectID, creds.ProjectID)
+		gcp.Credentials = override(gcp.Credentials, creds.Credentials)
+		p.GCP = &gcp
+		return &out, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %s", providerName)
+	}
+
+	return nil, fmt.Errorf("provider %s is not configured", providerName)
+}
+
+func override(current, value string) string {
+	if value != "" {
+		return value
+	}
+	return current
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviderCredentials_Hash(t *testing.T) {
+	a := &ProviderCredentials{AccessKeyID: "AKIA-A", SecretAccessKey: "secret"}
+	b := &ProviderCredentials{AccessKeyID: "AKIA-B", SecretAccessKey: "secret"}
+
+	if a.Hash() != (&ProviderCredentials{AccessKeyID: "AKIA-A", SecretAccessKey: "secret"}).Hash() {
+		t.Error("Hash() is not stable for equal credentials")
+	}
+	if a.Hash() == b.Hash() {
+		t.Error("Hash() collides for different credentials")
+	}
+	if len(a.Hash()) != 12 {
+		t.Errorf("Hash() length = %d, want 12", len(a.Hash()))
+	}
+}
+
+func TestWithProviderCredentials(t *testing.T) {
+	cfg := &ClusterConfig{
+		Providers: ProvidersConfig{
+			AWS: &AWSProvider{Enabled: true, Region: "us-east-1", AccessKeyID: "AKIA-A", SecretAccessKey: "secret-a"},
+		},
+	}
+
+	out, err := cfg.WithProviderCredentials("aws", &ProviderCredentials{AccessKeyID: "AKIA-B"})
+	if err != nil {
+		t.Fatalf("WithProviderCredentials() error = %v", err)
+	}
+	if out.Providers.AWS.AccessKeyID != "AKIA-B" || out.Providers.AWS.SecretAccessKey != "secret-a" {
+		t.Errorf("AWS credentials = %s/%s, want AKIA-B/secret-a", out.Providers.AWS.AccessKeyID, out.Providers.AWS.SecretAccessKey)
+	}
+	if out.Providers.AWS.Region != "us-east-1" {
+		t.Errorf("Region = %q, want us-east-1", out.Providers.AWS.Region)
+	}
+	if cfg.Providers.AWS.AccessKeyID != "AKIA-A" {
+		t.Error("WithProviderCredentials() modified the original config")
+	}
+
+	if _, err := cfg.WithProviderCredentials("linode", &ProviderCredentials{Token: "x"}); err == nil {
+		t.Error("WithProviderCredentials() expected error for unconfigured provider")
+	}
+	if _, err := cfg.WithProviderCredentials("vultr", &ProviderCredentials{Token: "x"}); err == nil {
+		t.Error("WithProviderCredentials() expected error for unknown provider")
+	}
+}
+
+func TestLoadFromLisp_ProviderCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	content := `(cluster
+  (metadata (name "test"))
+  (nodes
+    (node (name "master-1") (provider "linode") (roles master)
+      (credentials (token "linode-second"))))
+  (node-pools
+    (workers
+      (name "workers")
+      (provider "aws")
+      (count 2)
+      (credentials
+        (access-key-id "AKIA-SECOND")
+        (secret-access-key "secret")))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromLisp(path)
+	if err != nil {
+		t.Fatalf("LoadFromLisp() error = %v", err)
+	}
+
+	if len(cfg.Nodes) != 1 || cfg.Nodes[0].Credentials == nil || cfg.Nodes[0].Credentials.Token != "linode-second" {
+		t.Errorf("node credentials = %+v, want token linode-second", cfg.Nodes[0].Credentials)
+	}
+	pool := cfg.NodePools["workers"]
+	if pool.Credentials == nil || pool.Credentials.AccessKeyID != "AKIA-SECOND" || pool.Credentials.SecretAccessKey != "secret" {
+		t.Errorf("pool credentials = %+v, want AKIA-SECOND/secret", pool.Credentials)
+	}
+}
@@ -0,0 +1,429 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeOp is the kind of a configuration change
+type ChangeOp string
+
+// Kinds of change in a ConfigDiff
+const (
+	ChangeAdd    ChangeOp = "+"
+	ChangeRemove ChangeOp = "-"
+	ChangeModify ChangeOp = "~"
+)
+
+// Sections of a ConfigDiff, in the order Diff returns them
+const (
+	DiffKubernetes = "Kubernetes"
+	DiffProviders  = "Providers"
+	DiffNodePools  = "Node pools"
+	DiffNodes      = "Nodes"
+	DiffNetwork    = "Network"
+	DiffVPN        = "VPN"
+	DiffAddons     = "Addons"
+)
+
+// FieldChange is the old and new value of one setting
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// String returns "field from → to", showing empty values as (none)
+func (f FieldChange) String() string {
+	from, to := f.From, f.To
+	if from == "" {
+		from = "(none)"
+	}
+	if to == "" {
+		to = "(none)"
+	}
+	return fmt.Sprintf("%s %s → %s", f.Field, from, to)
+}
+
+// ConfigChange is one difference between two configurations. Fields lists
+// the settings a modification changed; Detail describes the change for
+// display.
+type ConfigChange struct {
+	Op     ChangeOp
+	Name   string
+	Detail string
+	Fields []FieldChange
+}
+
+// Changed reports whether a modification changed the named setting
+func (c ConfigChange) Changed(field string) bool {
+	for _, f := range c.Fields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffSection groups the changes to one part of the configuration
+type DiffSection struct {
+	Name    string
+	Changes []ConfigChange
+}
+
+// ConfigDiff is the difference between two configurations, sections in a
+// fixed order and the changes of each sorted by name
+type ConfigDiff []DiffSection
+
+// HasChanges reports whether the configurations differ
+func (d ConfigDiff) HasChanges() bool {
+	for _, section := range d {
+		if len(section.Changes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Section returns the changes of the named section
+func (d ConfigDiff) Section(name string) []ConfigChange {
+	for _, section := range d {
+		if section.Name == name {
+			return section.Changes
+		}
+	}
+	return nil
+}
+
+// ChangedSections returns the names of the sections with changes, in diff
+// order, so callers can tell which deploy phases need to run again
+func (d ConfigDiff) ChangedSections() []string {
+	var names []string
+	for _, section := range d {
+		if len(section.Changes) > 0 {
+			names = append(names, section.Name)
+		}
+	}
+	return names
+}
+
+// Diff returns the changes that turn c into other. Node pools are compared
+// by their effective region and size, so a pool that inherits its size from
+// the provider changes when the provider default does.
+func (c *ClusterConfig) Diff(other *ClusterConfig) ConfigDiff {
+	return ConfigDiff{
+		{Name: DiffKubernetes, Changes: fieldChanges(
+			FieldChange{"distribution", c.Kubernetes.Distribution, other.Kubernetes.Distribution},
+			FieldChange{"version", c.Kubernetes.Version, other.Kubernetes.Version},
+			FieldChange{"network plugin", c.Kubernetes.NetworkPlugin, other.Kubernetes.NetworkPlugin},
+		)},
+		{Name: DiffProviders, Changes: diffProviders(c, other)},
+		{Name: DiffNodePools, Changes: diffNodePools(c.effectiveNodePools(), other.effectiveNodePools())},
+		{Name: DiffNodes, Changes: diffNodes(c.Nodes, other.Nodes)},
+		{Name: DiffNetwork, Changes: fieldChanges(
+			FieldChange{"mode", c.Network.Mode, other.Network.Mode},
+			FieldChange{"pod cidr", c.Kubernetes.PodCIDR, other.Kubernetes.PodCIDR},
+			FieldChange{"service cidr", c.Kubernetes.ServiceCIDR, other.Kubernetes.ServiceCIDR},
+			FieldChange{"dns domain", c.Network.DNS.Domain, other.Network.DNS.Domain},
+			FieldChange{"bastion", fmt.Sprint(c.bastionEnabled()), fmt.Sprint(other.bastionEnabled())},
+		)},
+		{Name: DiffVPN, Changes: fieldChanges(diffVPN(c.Network, other.Network)...)},
+		{Name: DiffAddons, Changes: diffNames(c.EnabledAddons(), other.EnabledAddons())},
+	}
+}
+
+// EnabledProviderNames returns the enabled providers in a fixed order
+func (c *ClusterConfig) EnabledProviderNames() []string {
+	p := c.Providers
+	names := []string{}
+	if p.AWS != nil && p.AWS.Enabled {
+		names = append(names, "aws")
+	}
+	if p.Azure != nil && p.Azure.Enabled {
+		names = append(names, "azure")
+	}
+	if p.DigitalOcean != nil && p.DigitalOcean.Enabled {
+		names = append(names, "digitalocean")
+	}
+	if p.GCP != nil && p.GCP.Enabled {
+		names = append(names, "gcp")
+	}
+	if p.Hetzner != nil && p.Hetzner.Enabled {
+		names = append(names, "hetzner")
+	}
+	if p.Linode != nil && p.Linode.Enabled {
+		names = append(names, "linode")
+	}
+	return names
+}
+
+// EnabledAddons lists the addons the config enables, sorted by name
+func (c *ClusterConfig) EnabledAddons() []string {
+	addons := []string{}
+	if c.Addons.ArgoCD != nil && c.Addons.ArgoCD.Enabled {
+		addons = append(addons, "argocd")
+	}
+	if c.Addons.Salt != nil && c.Addons.Salt.Enabled {
+		addons = append(addons, "salt")
+	}
+	if c.Network.Ingress.Controller != "" {
+		addons = append(addons, "ingress-"+c.Network.Ingress.Controller)
+	}
+	if c.Monitoring.Enabled {
+		addons = append(addons, "monitoring")
+	}
+	for _, addon := range c.Kubernetes.Addons {
+		if addon.Enabled {
+			addons = append(addons, addon.Name)
+		}
+	}
+	sort.Strings(addons)
+	return addons
+}
+
+func (c *ClusterConfig) bastionEnabled() bool {
+	return c.Security.Bastion != nil && c.Security.Bastion.Enabled
+}
+
+// effectiveNodePools returns the node pools with the region and size they
+// inherit from their provider filled in
+func (c *ClusterConfig) effectiveNodePools() map[string]NodePool {
+	pools := make(map[string]NodePool, len(c.NodePools))
+	for name, pool := range c.NodePools {
+		region, size := c.ProviderDefaults(pool.Provider)
+		if pool.Region == "" {
+			pool.Region = region
+		}
+		if pool.Size == "" {
+			pool.Size = size
+		}
+		pools[name] = pool
+	}
+	return pools
+}
+
+// diffVPN compares the settings of the VPN each config selects. Settings of
+// a VPN that is not selected are ignored.
+func diffVPN(prev, cur NetworkConfig) []FieldChange {
+	prevVPN, curVPN := prev.SelectedVPN(), cur.SelectedVPN()
+	fields := []FieldChange{{"vpn", vpnName(prevVPN), vpnName(curVPN)}}
+
+	var prevWG, curWG WireGuardConfig
+	if prevVPN == VPNWireGuard {
+		prevWG = *prev.WireGuard
+	}
+	if curVPN == VPNWireGuard {
+		curWG = *cur.WireGuard
+	}
+	fields = append(fields,
+		FieldChange{"vpn subnet", prevWG.SubnetCIDR, curWG.SubnetCIDR},
+		FieldChange{"wireguard port", intSetting(prevWG.Port), intSetting(curWG.Port)},
+		FieldChange{"persistent keepalive", intSetting(prevWG.PersistentKeepalive), intSetting(curWG.PersistentKeepalive)},
+		FieldChange{"preshared keys", boolSetting(prevWG.UsePresharedKeys), boolSetting(curWG.UsePresharedKeys)},
+	)
+
+	var prevTS, curTS TailscaleConfig
+	if prevVPN == VPNTailscale {
+		prevTS = *prev.Tailscale
+	}
+	if curVPN == VPNTailscale {
+		curTS = *cur.Tailscale
+	}
+	return append(fields,
+		FieldChange{"namespace", prevTS.Namespace, curTS.Namespace},
+		FieldChange{"exit node", prevTS.ExitNode, curTS.ExitNode},
+		FieldChange{"acl policy", aclPolicyRef(prevTS.ACLPolicy), aclPolicyRef(curTS.ACLPolicy)},
+	)
+}
+
+func vpnName(vpn string) string {
+	if vpn == "" {
+		return "none"
+	}
+	return vpn
+}
+
+// intSetting formats a numeric setting, leaving unset ones empty
+func intSetting(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// boolSetting formats a flag, leaving unset ones empty
+func boolSetting(value bool) string {
+	if !value {
+		return ""
+	}
+	return "enabled"
+}
+
+// aclPolicyRef identifies an ACL policy without printing it: a file path as
+// written, an inline policy by a short hash of its contents
+func aclPolicyRef(policy string) string {
+	policy = strings.TrimSpace(policy)
+	if !strings.HasPrefix(policy, "{") {
+		return policy
+	}
+	hash := sha256.Sum256([]byte(policy))
+	return "inline sha256:" + hex.EncodeToString(hash[:])[:12]
+}
+
+// fieldChanges returns a modification for each setting whose value changed
+func fieldChanges(fields ...FieldChange) []ConfigChange {
+	var changes []ConfigChange
+	for _, field := range fields {
+		if field.From != field.To {
+			changes = append(changes, ConfigChange{Op: ChangeModify, Name: field.Field, Detail: field.String(), Fields: []FieldChange{field}})
+		}
+	}
+	return changes
+}
+
+// modification returns the modification of name for the settings that
+// changed, and false when none did
+func modification(name string, fields ...FieldChange) (ConfigChange, bool) {
+	change := ConfigChange{Op: ChangeModify, Name: name}
+	var details []string
+	for _, field := range fields {
+		if field.From != field.To {
+			change.Fields = append(change.Fields, field)
+			details = append(details, field.String())
+		}
+	}
+	change.Detail = strings.Join(details, ", ")
+	return change, len(change.Fields) > 0
+}
+
+func diffProviders(prev, cur *ClusterConfig) []ConfigChange {
+	before := make(map[string]bool)
+	for _, name := range prev.EnabledProviderNames() {
+		before[name] = true
+	}
+
+	var changes []ConfigChange
+	seen := make(map[string]bool)
+	for _, name := range cur.EnabledProviderNames() {
+		seen[name] = true
+		region, size := cur.ProviderDefaults(name)
+		if !before[name] {
+			changes = append(changes, ConfigChange{Op: ChangeAdd, Name: name, Detail: "region " + region})
+			continue
+		}
+		oldRegion, oldSize := prev.ProviderDefaults(name)
+		if change, ok := modification(name,
+			FieldChange{"region", oldRegion, region},
+			FieldChange{"default size", oldSize, size},
+		); ok {
+			changes = append(changes, change)
+		}
+	}
+	for name := range before {
+		if !seen[name] {
+			changes = append(changes, ConfigChange{Op: ChangeRemove, Name: name})
+		}
+	}
+	return sortChanges(changes)
+}
+
+func diffNodePools(prev, cur map[string]NodePool) []ConfigChange {
+	var changes []ConfigChange
+	for name, pool := range cur {
+		old, ok := prev[name]
+		if !ok {
+			changes = append(changes, ConfigChange{
+				Op:     ChangeAdd,
+				Name:   name,
+				Detail: fmt.Sprintf("%d × %s on %s in %s (%s)", pool.Count, pool.Size, pool.Provider, pool.Region, strings.Join(pool.Roles, ",")),
+			})
+			continue
+		}
+		if change, ok := modification(name,
+			FieldChange{"count", fmt.Sprint(old.Count), fmt.Sprint(pool.Count)},
+			FieldChange{"provider", old.Provider, pool.Provider},
+			FieldChange{"size", old.Size, pool.Size},
+			FieldChange{"region", old.Region, pool.Region},
+			FieldChange{"roles", strings.Join(old.Roles, ","), strings.Join(pool.Roles, ",")},
+			FieldChange{"spot", fmt.Sprint(old.SpotInstance || old.Preemptible), fmt.Sprint(pool.SpotInstance || pool.Preemptible)},
+		); ok {
+			changes = append(changes, change)
+		}
+	}
+	for name, pool := range prev {
+		if _, ok := cur[name]; !ok {
+			changes = append(changes, ConfigChange{Op: ChangeRemove, Name: name, Detail: fmt.Sprintf("%d node(s)", pool.Count)})
+		}
+	}
+	return sortChanges(changes)
+}
+
+func diffNodes(prev, cur []NodeConfig) []ConfigChange {
+	before := make(map[string]NodeConfig, len(prev))
+	for _, node := range prev {
+		before[node.Name] = node
+	}
+
+	var changes []ConfigChange
+	seen := make(map[string]bool, len(cur))
+	for _, node := range cur {
+		seen[node.Name] = true
+		old, ok := before[node.Name]
+		if !ok {
+			changes = append(changes, ConfigChange{
+				Op:     ChangeAdd,
+				Name:   node.Name,
+				Detail: fmt.Sprintf("%s on %s in %s (%s)", node.Size, node.Provider, node.Region, strings.Join(node.Roles, ",")),
+			})
+			continue
+		}
+		if change, ok := modification(node.Name,
+			FieldChange{"provider", old.Provider, node.Provider},
+			FieldChange{"size", old.Size, node.Size},
+			FieldChange{"region", old.Region, node.Region},
+			FieldChange{"roles", strings.Join(old.Roles, ","), strings.Join(node.Roles, ",")},
+		); ok {
+			changes = append(changes, change)
+		}
+	}
+	for _, node := range prev {
+		if !seen[node.Name] {
+			changes = append(changes, ConfigChange{Op: ChangeRemove, Name: node.Name})
+		}
+	}
+	return sortChanges(changes)
+}
+
+// diffNames returns the names added to and removed from a list
+func diffNames(prev, cur []string) []ConfigChange {
+	before := make(map[string]bool, len(prev))
+	for _, name := range prev {
+		before[name] = true
+	}
+	after := make(map[string]bool, len(cur))
+	for _, name := range cur {
+		after[name] = true
+	}
+
+	var changes []ConfigChange
+	for _, name := range cur {
+		if !before[name] {
+			changes = append(changes, ConfigChange{Op: ChangeAdd, Name: name})
+		}
+	}
+	for _, name := range prev {
+		if !after[name] {
+			changes = append(changes, ConfigChange{Op: ChangeRemove, Name: name})
+		}
+	}
+	return sortChanges(changes)
+}
+
+// sortChanges orders changes by name
+func sortChanges(changes []ConfigChange) []ConfigChange {
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
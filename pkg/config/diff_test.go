@@ -0,0 +1,156 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func diffTestConfig() *ClusterConfig {
+	return &ClusterConfig{
+		Metadata: Metadata{Name: "prod", Environment: "production"},
+		Providers: ProvidersConfig{
+			DigitalOcean: &DigitalOceanProvider{Enabled: true, Region: "nyc3", DefaultSize: "s-2vcpu-4gb"},
+			Linode:       &LinodeProvider{Enabled: true, Region: "us-east"},
+		},
+		Network: NetworkConfig{
+			Mode:      "wireguard",
+			WireGuard: &WireGuardConfig{Enabled: true, SubnetCIDR: "10.8.0.0/24"},
+		},
+		Kubernetes: KubernetesConfig{Distribution: "rke2", PodCIDR: "10.42.0.0/16", ServiceCIDR: "10.43.0.0/16"},
+		NodePools: map[string]NodePool{
+			"workers": {Provider: "linode", Count: 3, Roles: []string{"worker"}, Size: "g6-standard-4"},
+			"masters": {Provider: "digitalocean", Count: 3, Roles: []string{"master"}},
+		},
+		Addons: AddonsConfig{ArgoCD: &ArgoCDConfig{Enabled: true}},
+	}
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	diff := diffTestConfig().Diff(diffTestConfig())
+
+	assert.False(t, diff.HasChanges())
+	assert.Empty(t, diff.ChangedSections())
+	var names []string
+	for _, section := range diff {
+		names = append(names, section.Name)
+	}
+	assert.Equal(t, []string{DiffKubernetes, DiffProviders, DiffNodePools, DiffNodes, DiffNetwork, DiffVPN, DiffAddons}, names)
+}
+
+func TestDiff_NodePools(t *testing.T) {
+	next := diffTestConfig()
+	next.NodePools["workers"] = NodePool{Provider: "linode", Count: 5, Roles: []string{"worker"}, Size: "g6-standard-8"}
+	next.NodePools["gpu"] = NodePool{Provider: "digitalocean", Count: 2, Roles: []string{"worker"}, Size: "gpu-h100"}
+	delete(next.NodePools, "masters")
+
+	diff := diffTestConfig().Diff(next)
+
+	changes := diff.Section(DiffNodePools)
+	assert.Equal(t, []ConfigChange{
+		{Op: ChangeAdd, Name: "gpu", Detail: "2 × gpu-h100 on digitalocean in nyc3 (worker)"},
+		{Op: ChangeRemove, Name: "masters", Detail: "3 node(s)"},
+		{Op: ChangeModify, Name: "workers", Detail: "count 3 → 5, size g6-standard-4 → g6-standard-8", Fields: []FieldChange{
+			{Field: "count", From: "3", To: "5"},
+			{Field: "size", From: "g6-standard-4", To: "g6-standard-8"},
+		}},
+	}, changes)
+	assert.True(t, changes[2].Changed("count"))
+	assert.True(t, changes[2].Changed("size"))
+	assert.False(t, changes[2].Changed("region"))
+	assert.Equal(t, []string{DiffNodePools}, diff.ChangedSections())
+}
+
+func TestDiff_InheritedSizeChange(t *testing.T) {
+	next := diffTestConfig()
+	next.Providers.DigitalOcean.DefaultSize = "s-4vcpu-8gb"
+
+	diff := diffTestConfig().Diff(next)
+
+	assert.Equal(t, []ConfigChange{
+		{Op: ChangeModify, Name: "masters", Detail: "size s-2vcpu-4gb → s-4vcpu-8gb", Fields: []FieldChange{
+			{Field: "size", From: "s-2vcpu-4gb", To: "s-4vcpu-8gb"},
+		}},
+	}, diff.Section(DiffNodePools), "pools are compared by their effective size")
+	assert.Equal(t, []ConfigChange{
+		{Op: ChangeModify, Name: "digitalocean", Detail: "default size s-2vcpu-4gb → s-4vcpu-8gb", Fields: []FieldChange{
+			{Field: "default size", From: "s-2vcpu-4gb", To: "s-4vcpu-8gb"},
+		}},
+	}, diff.Section(DiffProviders))
+}
+
+func TestDiff_ProvidersNetworkVPNAndAddons(t *testing.T) {
+	next := diffTestConfig()
+	next.Providers.Linode = nil
+	next.Providers.Hetzner = &HetznerProvider{Enabled: true, Location: "fsn1"}
+	next.Network.Mode = "tailscale"
+	next.Network.WireGuard = nil
+	next.Network.Tailscale = &TailscaleConfig{Enabled: true, ExitNode: "masters-1"}
+	next.Kubernetes.ServiceCIDR = "10.96.0.0/12"
+	next.Addons.ArgoCD = nil
+	next.Monitoring.Enabled = true
+	next.Kubernetes.Version = "v1.30.4+rke2r1"
+
+	diff := diffTestConfig().Diff(next)
+
+	assert.Equal(t, []ConfigChange{
+		{Op: ChangeAdd, Name: "hetzner", Detail: "region fsn1"},
+		{Op: ChangeRemove, Name: "linode"},
+	}, diff.Section(DiffProviders))
+	assert.Equal(t, []string{"mode wireguard → tailscale", "service cidr 10.43.0.0/16 → 10.96.0.0/12"}, details(diff.Section(DiffNetwork)))
+	assert.Equal(t, []string{"vpn wireguard → tailscale", "vpn subnet 10.8.0.0/24 → (none)", "exit node (none) → masters-1"}, details(diff.Section(DiffVPN)))
+	assert.Equal(t, []ConfigChange{
+		{Op: ChangeRemove, Name: "argocd"},
+		{Op: ChangeAdd, Name: "monitoring"},
+	}, diff.Section(DiffAddons))
+	assert.Equal(t, []string{"version (none) → v1.30.4+rke2r1"}, details(diff.Section(DiffKubernetes)))
+	assert.Equal(t, []string{"region us-east → (none)"}, details(diff.Section(DiffNodePools)),
+		"the workers pool loses the region it inherited from linode")
+	assert.Equal(t, []string{DiffKubernetes, DiffProviders, DiffNodePools, DiffNetwork, DiffVPN, DiffAddons}, diff.ChangedSections())
+}
+
+func TestDiff_VPNSettings(t *testing.T) {
+	prev := diffTestConfig()
+	prev.Network.Tailscale = &TailscaleConfig{Enabled: false, ExitNode: "masters-1"}
+	next := diffTestConfig()
+	next.Network.WireGuard.PersistentKeepalive = 25
+	next.Network.WireGuard.UsePresharedKeys = true
+	next.Network.Tailscale = &TailscaleConfig{Enabled: false, ExitNode: "workers-1"}
+
+	diff := prev.Diff(next)
+
+	assert.Equal(t, []string{"persistent keepalive (none) → 25", "preshared keys (none) → enabled"}, details(diff.Section(DiffVPN)),
+		"settings of a VPN that is not selected are ignored")
+}
+
+func TestDiff_ACLPolicyIsNotPrinted(t *testing.T) {
+	prev := diffTestConfig()
+	prev.Network.WireGuard = nil
+	prev.Network.Tailscale = &TailscaleConfig{Enabled: true, ACLPolicy: `{"acls": []}`}
+	next := diffTestConfig()
+	next.Network.WireGuard = nil
+	next.Network.Tailscale = &TailscaleConfig{Enabled: true, ACLPolicy: `{"acls": [{"action": "accept", "src": ["*"], "dst": ["*:*"]}]}`}
+
+	changes := prev.Diff(next).Section(DiffVPN)
+
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, "acl policy", changes[0].Name)
+		assert.NotContains(t, changes[0].Detail, "accept")
+		assert.Contains(t, changes[0].Detail, "inline sha256:")
+	}
+
+	next.Network.Tailscale.ACLPolicy = "~/policy.hujson"
+	changes = prev.Diff(next).Section(DiffVPN)
+	if assert.Len(t, changes, 1) {
+		assert.Contains(t, changes[0].Detail, "→ ~/policy.hujson")
+	}
+}
+
+// details returns the Detail of each change
+func details(changes []ConfigChange) []string {
+	var out []string
+	for _, change := range changes {
+		out = append(out, change.Detail)
+	}
+	return out
+}
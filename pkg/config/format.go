@@ -0,0 +1,347 @@
+// Package config provides YAML and JSON cluster configs alongside Lisp
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat identifies the file format of a cluster configuration
+type ConfigFormat string
+
+const (
+	// FormatLisp is the S-expression format and the default
+	FormatLisp ConfigFormat = "lisp"
+	// FormatYAML unmarshals into ClusterConfig through its yaml tags
+	FormatYAML ConfigFormat = "yaml"
+	// FormatJSON unmarshals into ClusterConfig through its json tags
+	FormatJSON ConfigFormat = "json"
+)
+
+// ParseConfigFormat parses a format name; an empty name means auto-detect
+// and is returned as-is
+func ParseConfigFormat(name string) (ConfigFormat, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return "", nil
+	case "lisp", "lsp":
+		return FormatLisp, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return "", fmt.Errorf("unknown config format %q (use lisp, yaml or json)", name)
+}
+
+// DetectConfigFormat picks the format from the file extension, falling back
+// to Lisp for anything it does not recognise
+func DetectConfigFormat(path string) ConfigFormat {
+	if format, ok := formatFromExtension(path); ok {
+		return format
+	}
+	return FormatLisp
+}
+
+func formatFromExtension(path string) (ConfigFormat, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".lisp", ".lsp":
+		return FormatLisp, true
+	case ".yaml", ".yml":
+		return FormatYAML, true
+	case ".json":
+		return FormatJSON, true
+	}
+	return "", false
+}
+
+// NewLoaderFromFormat creates a loader for the given format; an empty format
+// is detected from the file extension
+func NewLoaderFromFormat(configPath string, format ConfigFormat) (*Loader, error) {
+	if format == "" {
+		format = DetectConfigFormat(configPath)
+	}
+	if _, err := ParseConfigFormat(string(format)); err != nil {
+		return nil, err
+	}
+
+	l := NewLoader(configPath)
+	l.format = format
+	return l, nil
+}
+
+// ReadConfigFile reads a cluster config in the given format, resolves its
+// ${VAR} references and applies defaults, without environment overrides or
+// validation
+func ReadConfigFile(path string, format ConfigFormat) (*ClusterConfig, error) {
+	cfg, err := ParseConfigFile(path, format)
+	if err != nil {
+		return nil, err
+	}
+	return finishConfig(cfg)
+}
+
+// ParseConfigFile reads a cluster config as written: ${VAR} references are
+// kept and no defaults are applied
+func ParseConfigFile(path string, format ConfigFormat) (*ClusterConfig, error) {
+	if format == "" {
+		format = DetectConfigFormat(path)
+	}
+	if format == FormatLisp {
+		return parseLispConfig(path)
+	}
+
+	content, err := readConfigSource(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeConfig([]byte(content), format)
+}
+
+// finishConfig resolves ${VAR} references, applies defaults and checks the
+// kubelet, pod security and taint settings of a freshly parsed config
+func finishConfig(cfg *ClusterConfig) (*ClusterConfig, error) {
+	if err := InterpolateEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	applyDefaults(cfg)
+
+	if err := cfg.Kubernetes.Kubelet.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kubernetes kubelet config: %w", err)
+	}
+	if err := cfg.Security.PodSecurity.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid security podSecurity config: %w", err)
+	}
+	if err := validateTaints(cfg); err != nil {
+		return nil, fmt.Errorf("invalid taints: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// decodeConfig unmarshals YAML or JSON into a ClusterConfig, rejecting
+// unknown keys so typos do not silently fall back to defaults
+func decodeConfig(data []byte, format ConfigFormat) (*ClusterConfig, error) {
+	cfg := &ClusterConfig{}
+
+	switch format {
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse YAML configuration: %w", err)
+		}
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON configuration: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+
+	if cfg.NodePools == nil {
+		cfg.NodePools = make(map[string]NodePool)
+	}
+	return cfg, nil
+}
+
+// MarshalConfig renders a cluster config in the given format
+func MarshalConfig(cfg *ClusterConfig, format ConfigFormat) ([]byte, error) {
+	switch format {
+	case FormatLisp:
+		return []byte(GenerateLisp(cfg)), nil
+	case FormatYAML:
+		data, err := yaml.Marshal(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal YAML: %w", err)
+		}
+		return data, nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
+
+// validateStructuredConfig is the YAML/JSON counterpart of ValidateLispSchema.
+// Paths use the yaml/json keys (e.g. nodePools.workers.count) and positions
+// come from the YAML node tree, which also covers JSON documents.
+func validateStructuredConfig(data []byte, format ConfigFormat) []Diagnostic {
+	cfg, err := decodeConfig(data, format)
+	if err != nil {
+		line, column := decodeErrorPosition(data, err)
+		return []Diagnostic{{Line: line, Column: column, Message: err.Error()}}
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []Diagnostic{{Message: err.Error()}}
+	}
+
+	var diagnostics []Diagnostic
+	report := func(path []string, format string, args ...interface{}) {
+		line, column := yamlNodePosition(&root, path)
+		diagnostics = append(diagnostics, Diagnostic{
+			Path:    formatFieldPath(path),
+			Line:    line,
+			Column:  column,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	var interpolationErr *InterpolationError
+	if errors.As(interpolateConfig(cfg, os.LookupEnv), &interpolationErr) {
+		for _, u := range interpolationErr.Unresolved {
+			report(u.segments, "environment variable %s is not set", u.Name)
+		}
+	}
+
+	if strings.TrimSpace(cfg.Metadata.Name) == "" {
+		report([]string{"metadata", "name"}, "metadata name is required")
+	}
+
+	p := cfg.Providers
+	if !((p.DigitalOcean != nil && p.DigitalOcean.Enabled) || (p.Linode != nil && p.Linode.Enabled) ||
+		(p.AWS != nil && p.AWS.Enabled) || (p.Azure != nil && p.Azure.Enabled) ||
+		(p.GCP != nil && p.GCP.Enabled) || (p.Hetzner != nil && p.Hetzner.Enabled)) {
+		report([]string{"providers"}, "at least one provider must be enabled")
+	}
+
+	checkCIDR := func(path []string, value string) {
+		if value != "" && !isValidCIDR(value) && !strings.Contains(value, "${") {
+			report(path, "invalid CIDR %q", value)
+		}
+	}
+	checkCIDR([]string{"network", "cidr"}, cfg.Network.CIDR)
+	checkCIDR([]string{"network", "podCidr"}, cfg.Network.PodCIDR)
+	checkCIDR([]string{"network", "serviceCidr"}, cfg.Network.ServiceCIDR)
+	if cfg.Network.WireGuard != nil {
+		checkCIDR([]string{"network", "wireguard", "subnetCidr"}, cfg.Network.WireGuard.SubnetCIDR)
+	}
+	vpcs := make(map[string]*VPCConfig)
+	if p.DigitalOcean != nil {
+		vpcs["digitalocean"] = p.DigitalOcean.VPC
+	}
+	if p.Linode != nil {
+		vpcs["linode"] = p.Linode.VPC
+	}
+	if p.AWS != nil {
+		vpcs["aws"] = p.AWS.VPC
+	}
+	for name, vpc := range vpcs {
+		if vpc != nil {
+			checkCIDR([]string{"providers", name, "vpc", "cidr"}, vpc.CIDR)
+		}
+	}
+
+	checkRoles := func(path []string, roles []string) {
+		for _, role := range roles {
+			if !sliceContains(knownNodeRoles, role) {
+				report(path, "unknown role %q (expected one of: %s)", role, strings.Join(knownNodeRoles, ", "))
+			}
+		}
+	}
+	for i, node := range cfg.Nodes {
+		checkRoles([]string{"nodes", fmt.Sprint(i), "roles"}, node.Roles)
+	}
+	for name, pool := range cfg.NodePools {
+		for field, count := range map[string]int{"count": pool.Count, "minCount": pool.MinCount, "maxCount": pool.MaxCount} {
+			if count < 0 {
+				report([]string{"nodePools", name, field}, "%s must be >= 0, got %d", field, count)
+			}
+		}
+		checkRoles([]string{"nodePools", name, "roles"}, pool.Roles)
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Path < b.Path
+	})
+	return diagnostics
+}
+
+// yamlNodePosition returns the position of the deepest node found along
+// path, so a missing key is reported at its parent
+func yamlNodePosition(root *yaml.Node, path []string) (int, int) {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line, column := node.Line, node.Column
+
+	for _, key := range path {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					line, column = node.Content[i].Line, node.Content[i].Column
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+				line, column = next.Line, next.Column
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line, column
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// decodeErrorPosition extracts a source position from a YAML or JSON decode
+// error, or 0, 0 when the error does not carry one
+func decodeErrorPosition(data []byte, err error) (int, int) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	offset := int64(-1)
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	if offset >= 0 {
+		if offset > int64(len(data)) {
+			offset = int64(len(data))
+		}
+		before := data[:offset]
+		return bytes.Count(before, []byte("\n")) + 1, int(offset) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	}
+
+	if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return line, 0
+	}
+	return 0, 0
+}
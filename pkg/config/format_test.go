@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const formatYAMLConfig = `metadata:
+  name: prod
+providers:
+  aws:
+    enabled: true
+    region: us-east-1
+network:
+  podCidr: 10.42.0.0/16
+nodePools:
+  masters:
+    name: masters
+    provider: aws
+    count: 3
+    roles: [master, etcd]
+  workers:
+    name: workers
+    provider: aws
+    count: 2
+    roles: [worker]
+`
+
+func TestDetectConfigFormat(t *testing.T) {
+	assert.Equal(t, FormatLisp, DetectConfigFormat("cluster.lisp"))
+	assert.Equal(t, FormatYAML, DetectConfigFormat("cluster.yaml"))
+	assert.Equal(t, FormatYAML, DetectConfigFormat("CLUSTER.YML"))
+	assert.Equal(t, FormatJSON, DetectConfigFormat("/tmp/cluster.json"))
+	assert.Equal(t, FormatLisp, DetectConfigFormat("cluster.conf"), "unknown extensions fall back to Lisp")
+}
+
+func TestParseConfigFormat(t *testing.T) {
+	format, err := ParseConfigFormat("YML")
+	require.NoError(t, err)
+	assert.Equal(t, FormatYAML, format)
+
+	format, err = ParseConfigFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, ConfigFormat(""), format)
+
+	_, err = ParseConfigFormat("toml")
+	assert.ErrorContains(t, err, "unknown config format")
+}
+
+func TestNewLoaderFromFormat_YAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "cluster.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(formatYAMLConfig), 0600))
+
+	loader, err := NewLoaderFromFormat(yamlPath, "")
+	require.NoError(t, err)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cfg.Metadata.Name)
+	assert.Equal(t, 3, cfg.NodePools["masters"].Count)
+	assert.Equal(t, []string{"master", "etcd"}, cfg.NodePools["masters"].Roles)
+
+	// Round-trip through JSON and load it back
+	data, err := MarshalConfig(cfg, FormatJSON)
+	require.NoError(t, err)
+	jsonPath := filepath.Join(dir, "cluster.json")
+	require.NoError(t, os.WriteFile(jsonPath, data, 0600))
+
+	loader, err = NewLoaderFromFormat(jsonPath, "")
+	require.NoError(t, err)
+	fromJSON, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Metadata.Name, fromJSON.Metadata.Name)
+	assert.Equal(t, cfg.NodePools, fromJSON.NodePools)
+
+	_, err = NewLoaderFromFormat(yamlPath, "toml")
+	assert.Error(t, err)
+}
+
+func TestLoad_YAMLRunsSameValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("metadata:\n  name: prod\nproviders:\n  aws:\n    enabled: false\n"), 0600))
+
+	loader, err := NewLoaderFromFormat(path, "")
+	require.NoError(t, err)
+	_, err = loader.Load()
+	assert.ErrorContains(t, err, "at least one cloud provider must be enabled")
+}
+
+func TestReadConfigFile_RejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("metadata:\n  nmae: prod\n"), 0600))
+
+	_, err := ReadConfigFile(path, "")
+	assert.ErrorContains(t, err, "field nmae not found")
+}
+
+func TestLoader_ValidateStructured(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`metadata:
+  name: ""
+providers:
+  aws:
+    enabled: true
+network:
+  serviceCidr: 10.43.0.0
+nodePools:
+  workers:
+    count: -2
+    roles: [worker, bogus]
+`), 0600))
+
+	loader, err := NewLoaderFromFormat(path, "")
+	require.NoError(t, err)
+	diagnostics, err := loader.Validate()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 4)
+
+	assert.Equal(t, Diagnostic{Path: "metadata.name", Line: 2, Column: 3, Message: "metadata name is required"}, diagnostics[0])
+	assert.Equal(t, "network.serviceCidr", diagnostics[1].Path)
+	assert.Equal(t, 7, diagnostics[1].Line)
+	assert.Equal(t, "nodePools.workers.count", diagnostics[2].Path)
+	assert.Equal(t, 10, diagnostics[2].Line)
+	assert.Equal(t, "nodePools.workers.roles", diagnostics[3].Path)
+	assert.Contains(t, diagnostics[3].Message, `unknown role "bogus"`)
+}
+
+func TestLoader_ValidateStructuredSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.json")
+	require.NoError(t, os.WriteFile(path, []byte("{\n  \"metadata\": {\"name\": \"prod\",}\n}"), 0600))
+
+	loader, err := NewLoaderFromFormat(path, "")
+	require.NoError(t, err)
+	diagnostics, err := loader.Validate()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, 2, diagnostics[0].Line)
+	assert.Contains(t, diagnostics[0].Message, "failed to parse JSON configuration")
+}
+
+func TestMarshalConfig_Lisp(t *testing.T) {
+	cfg := &ClusterConfig{Metadata: Metadata{Name: "prod"}, NodePools: map[string]NodePool{}}
+	data, err := MarshalConfig(cfg, FormatLisp)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "(cluster")
+	assert.Contains(t, string(data), `(name "prod")`)
+
+	_, err = MarshalConfig(cfg, ConfigFormat("toml"))
+	assert.Error(t, err)
+}
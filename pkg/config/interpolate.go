@@ -0,0 +1,185 @@
+// Package config provides ${VAR} interpolation for cluster configs
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnresolvedVariable is a ${VAR} reference whose variable is unset and that
+// has no :-default
+type UnresolvedVariable struct {
+	Name string
+	// Path is the config field holding the reference, using the yaml keys
+	// (e.g. providers.digitalocean.token)
+	Path string
+
+	segments []string
+}
+
+// InterpolationError lists every unresolved ${VAR} reference in a config
+type InterpolationError struct {
+	Unresolved []UnresolvedVariable
+}
+
+func (e *InterpolationError) Error() string {
+	if len(e.Unresolved) == 1 {
+		u := e.Unresolved[0]
+		return fmt.Sprintf("environment variable %s is not set (referenced by %s)", u.Name, u.Path)
+	}
+	lines := make([]string, len(e.Unresolved))
+	for i, u := range e.Unresolved {
+		lines[i] = fmt.Sprintf("%s (referenced by %s)", u.Name, u.Path)
+	}
+	return fmt.Sprintf("%d environment variables are not set:\n  %s", len(lines), strings.Join(lines, "\n  "))
+}
+
+// InterpolateEnv resolves ${VAR} and ${VAR:-default} references in every
+// string field of cfg from the environment. Unresolved references are left
+// in place and reported together as an *InterpolationError.
+func InterpolateEnv(cfg *ClusterConfig) error {
+	return interpolateConfig(cfg, os.LookupEnv)
+}
+
+func interpolateConfig(cfg *ClusterConfig, lookup func(string) (string, bool)) error {
+	var unresolved []UnresolvedVariable
+	interpolateValue(reflect.ValueOf(cfg).Elem(), nil, lookup, &unresolved)
+	if len(unresolved) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(unresolved, func(i, j int) bool { return unresolved[i].Path < unresolved[j].Path })
+	return &InterpolationError{Unresolved: unresolved}
+}
+
+// interpolateValue walks v, which must be settable, rewriting strings in place
+func interpolateValue(v reflect.Value, path []string, lookup func(string) (string, bool), unresolved *[]UnresolvedVariable) {
+	switch v.Kind() {
+	case reflect.String:
+		value, missing := interpolateString(v.String(), lookup)
+		v.SetString(value)
+		for _, name := range missing {
+			*unresolved = append(*unresolved, UnresolvedVariable{
+				Name:     name,
+				Path:     formatFieldPath(path),
+				segments: append([]string(nil), path...),
+			})
+		}
+
+	case reflect.Ptr:
+		if !v.IsNil() {
+			interpolateValue(v.Elem(), path, lookup, unresolved)
+		}
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		// Interface contents are not addressable, so rewrite a copy
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		interpolateValue(elem, path, lookup, unresolved)
+		v.Set(elem)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			interpolateValue(v.Field(i), append(path, name), lookup, unresolved)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			interpolateValue(v.Index(i), append(path, fmt.Sprint(i)), lookup, unresolved)
+		}
+
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			interpolateValue(elem, append(path, key.String()), lookup, unresolved)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
+
+// interpolateString expands ${VAR} and ${VAR:-default} in s. A default is
+// used when the variable is unset or empty; references that resolve to
+// nothing are kept verbatim and their names returned.
+func interpolateString(s string, lookup func(string) (string, bool)) (string, []string) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var result strings.Builder
+	var missing []string
+	rest := s
+	for {
+		start := strings.Index(rest, "${")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end == -1 {
+			break
+		}
+		end += start
+
+		result.WriteString(rest[:start])
+		ref := rest[start+2 : end]
+		name, def, hasDefault := strings.Cut(ref, ":-")
+
+		if value, ok := lookup(name); ok && (value != "" || !hasDefault) {
+			result.WriteString(value)
+		} else if hasDefault {
+			result.WriteString(def)
+		} else {
+			result.WriteString(rest[start : end+1])
+			missing = append(missing, name)
+		}
+		rest = rest[end+1:]
+	}
+	result.WriteString(rest)
+	return result.String(), missing
+}
+
+// formatFieldPath joins path segments, writing list indexes as [i]
+func formatFieldPath(path []string) string {
+	var b strings.Builder
+	for _, segment := range path {
+		if isIndexSegment(segment) {
+			fmt.Fprintf(&b, "[%s]", segment)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment)
+	}
+	return b.String()
+}
+
+func isIndexSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
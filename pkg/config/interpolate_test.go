@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mapLookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestInterpolateString(t *testing.T) {
+	lookup := mapLookup(map[string]string{"TOKEN": "secret", "EMPTY": ""})
+
+	tests := []struct {
+		input   string
+		want    string
+		missing []string
+	}{
+		{"plain", "plain", nil},
+		{"${TOKEN}", "secret", nil},
+		{"Bearer ${TOKEN}!", "Bearer secret!", nil},
+		{"${MISSING:-fallback}", "fallback", nil},
+		{"${EMPTY:-fallback}", "fallback", nil},
+		{"${EMPTY}", "", nil},
+		{"${MISSING:-}", "", nil},
+		{"${TOKEN}-${MISSING}", "secret-${MISSING}", []string{"MISSING"}},
+		{"unterminated ${TOKEN", "unterminated ${TOKEN", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, missing := interpolateString(tt.input, lookup)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.missing, missing)
+		})
+	}
+}
+
+func TestInterpolateConfig(t *testing.T) {
+	cfg := &ClusterConfig{
+		Metadata: Metadata{Name: "${NAME:-dev}", Labels: map[string]string{"team": "${TEAM}"}},
+		Providers: ProvidersConfig{
+			DigitalOcean: &DigitalOceanProvider{Token: "${DO_TOKEN}", SSHPublicKey: "${NOT_WALKED}"},
+			AWS:          &AWSProvider{Custom: map[string]interface{}{"profile": "${AWS_PROFILE}"}},
+		},
+		Nodes: []NodeConfig{{Name: "${NODE_NAME}"}},
+		NodePools: map[string]NodePool{
+			"workers": {Size: "${WORKER_SIZE}"},
+		},
+	}
+
+	err := interpolateConfig(cfg, mapLookup(map[string]string{
+		"DO_TOKEN":    "do-secret",
+		"TEAM":        "platform",
+		"AWS_PROFILE": "prod",
+		"WORKER_SIZE": "s-4vcpu-8gb",
+	}))
+
+	var interpolationErr *InterpolationError
+	require.ErrorAs(t, err, &interpolationErr)
+	require.Len(t, interpolationErr.Unresolved, 1)
+	assert.Equal(t, "NODE_NAME", interpolationErr.Unresolved[0].Name)
+	assert.Equal(t, "nodes[0].name", interpolationErr.Unresolved[0].Path)
+	assert.Equal(t, "environment variable NODE_NAME is not set (referenced by nodes[0].name)", err.Error())
+
+	assert.Equal(t, "dev", cfg.Metadata.Name)
+	assert.Equal(t, "platform", cfg.Metadata.Labels["team"])
+	assert.Equal(t, "do-secret", cfg.Providers.DigitalOcean.Token)
+	assert.Equal(t, "${NOT_WALKED}", cfg.Providers.DigitalOcean.SSHPublicKey, `yaml:"-" fields are skipped`)
+	assert.Equal(t, "prod", cfg.Providers.AWS.Custom["profile"])
+	assert.Equal(t, "s-4vcpu-8gb", cfg.NodePools["workers"].Size)
+}
+
+func TestLoader_LoadInterpolatesEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	require.NoError(t, os.WriteFile(path, []byte(`(cluster
+  (metadata (name "${SLOTH_TEST_NAME:-interp}"))
+  (providers (digitalocean (enabled true) (token "${SLOTH_TEST_DO_TOKEN}")))
+  (node-pools
+    (masters (name "masters") (provider "digitalocean") (count 1) (roles master))))
+`), 0600))
+
+	_, err := NewLoader(path).Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SLOTH_TEST_DO_TOKEN")
+	assert.Contains(t, err.Error(), "providers.digitalocean.token")
+
+	diagnostics, err := NewLoader(path).Validate()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, Diagnostic{
+		Path:    "cluster.providers.digitalocean.token",
+		Line:    3,
+		Column:  50,
+		Message: "environment variable SLOTH_TEST_DO_TOKEN is not set",
+	}, diagnostics[0])
+
+	t.Setenv("SLOTH_TEST_DO_TOKEN", "do-secret")
+	cfg, err := NewLoader(path).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "interp", cfg.Metadata.Name)
+	assert.Equal(t, "do-secret", cfg.Providers.DigitalOcean.Token)
+
+	// Convert keeps the reference as written
+	raw, err := ParseConfigFile(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "${SLOTH_TEST_DO_TOKEN}", raw.Providers.DigitalOcean.Token)
+}
+
+func TestLoader_ValidateStructuredUnsetVariable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`metadata:
+  name: prod
+providers:
+  aws:
+    enabled: true
+    secretAccessKey: ${SLOTH_TEST_AWS_SECRET}
+`), 0600))
+
+	loader, err := NewLoaderFromFormat(path, "")
+	require.NoError(t, err)
+	diagnostics, err := loader.Validate()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "providers.aws.secretAccessKey", diagnostics[0].Path)
+	assert.Equal(t, 6, diagnostics[0].Line)
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults keep a node's container logs bounded and make the kubelet reclaim
+// disk (image GC, then soft eviction) well before hard eviction kicks in
+const (
+	DefaultContainerLogMaxSize  = "20Mi"
+	DefaultContainerLogMaxFiles = 3
+	DefaultImageGCHighThreshold = 80
+	DefaultImageGCLowThreshold  = 70
+)
+
+// DefaultEvictionHard returns the default hard eviction thresholds
+func DefaultEvictionHard() map[string]string {
+	return map[string]string{
+		"memory.available":  "200Mi",
+		"nodefs.available":  "10%",
+		"nodefs.inodesFree": "5%",
+		"imagefs.available": "10%",
+	}
+}
+
+// DefaultEvictionSoft returns the default soft eviction thresholds
+func DefaultEvictionSoft() map[string]string {
+	return map[string]string{
+		"nodefs.available":  "15%",
+		"imagefs.available": "15%",
+	}
+}
+
+// DefaultEvictionSoftGracePeriod returns the grace periods for the default soft thresholds
+func DefaultEvictionSoftGracePeriod() map[string]string {
+	return map[string]string{
+		"nodefs.available":  "2m",
+		"imagefs.available": "2m",
+	}
+}
+
+// evictionSignals are the signals the kubelet accepts in eviction thresholds
+var evictionSignals = map[string]bool{
+	"memory.available":       true,
+	"nodefs.available":       true,
+	"nodefs.inodesFree":      true,
+	"imagefs.available":      true,
+	"imagefs.inodesFree":     true,
+	"containerfs.available":  true,
+	"containerfs.inodesFree": true,
+	"pid.available":          true,
+}
+
+var (
+	logSizePattern    = regexp.MustCompile(`^[1-9][0-9]*(Ki|Mi|Gi)$`)
+	quantityPattern   = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|Ti|k|M|G|T)?$`)
+	percentagePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?%$`)
+)
+
+// EffectiveLogRotation returns the log rotation settings with defaults applied
+func (k *KubeletConfig) EffectiveLogRotation() LogRotationConfig {
+	rotation := LogRotationConfig{
+		MaxSize:  DefaultContainerLogMaxSize,
+		MaxFiles: DefaultContainerLogMaxFiles,
+	}
+	if k.LogRotation != nil {
+		if k.LogRotation.MaxSize != "" {
+			rotation.MaxSize = k.LogRotation.MaxSize
+		}
+		if k.LogRotation.MaxFiles != 0 {
+			rotation.MaxFiles = k.LogRotation.MaxFiles
+		}
+	}
+	return rotation
+}
+
+// EffectiveEviction returns the eviction settings with defaults applied.
+// Configured thresholds override the default for the same signal only.
+func (k *KubeletConfig) EffectiveEviction() EvictionConfig {
+	eviction := EvictionConfig{
+		Hard:                 DefaultEvictionHard(),
+		Soft:                 DefaultEvictionSoft(),
+		SoftGracePeriod:      DefaultEvictionSoftGracePeriod(),
+		ImageGCHighThreshold: DefaultImageGCHighThreshold,
+		ImageGCLowThreshold:  DefaultImageGCLowThreshold,
+	}
+	if k.Eviction == nil {
+		return eviction
+	}
+
+	for signal, threshold := range k.Eviction.Hard {
+		eviction.Hard[signal] = threshold
+	}
+	for signal, threshold := range k.Eviction.Soft {
+		eviction.Soft[signal] = threshold
+	}
+	for signal, period := range k.Eviction.SoftGracePeriod {
+		eviction.SoftGracePeriod[signal] = period
+	}
+	if k.Eviction.ImageGCHighThreshold != 0 {
+		eviction.ImageGCHighThreshold = k.Eviction.ImageGCHighThreshold
+	}
+	if k.Eviction.ImageGCLowThreshold != 0 {
+		eviction.ImageGCLowThreshold = k.Eviction.ImageGCLowThreshold
+	}
+	return eviction
+}
+
+// Validate checks the log rotation sizes and eviction thresholds
+func (k *KubeletConfig) Validate() error {
+	rotation := k.EffectiveLogRotation()
+	if !logSizePattern.MatchString(rotation.MaxSize) {
+		return fmt.Errorf("log-rotation max-size %q is invalid (use e.g. 20Mi or 1Gi)", rotation.MaxSize)
+	}
+	if rotation.MaxFiles < 2 {
+		return fmt.Errorf("log-rotation max-files must be at least 2, got %d", rotation.MaxFiles)
+	}
+
+	eviction := k.EffectiveEviction()
+	for _, section := range []struct {
+		name       string
+		thresholds map[string]string
+	}{
+		{"hard", eviction.Hard},
+		{"soft", eviction.Soft},
+	} {
+		for _, signal := range sortedKeys(section.thresholds) {
+			if err := validateEvictionThreshold(signal, section.thresholds[signal]); err != nil {
+				return fmt.Errorf("eviction %s: %w", section.name, err)
+			}
+		}
+	}
+
+	for _, signal := range sortedKeys(eviction.Soft) {
+		period, ok := eviction.SoftGracePeriod[signal]
+		if !ok {
+			return fmt.Errorf("eviction soft: %s has no soft-grace-period", signal)
+		}
+		if d, err := time.ParseDuration(period); err != nil || d <= 0 {
+			return fmt.Errorf("eviction soft-grace-period: %s has invalid duration %q", signal, period)
+		}
+
+		// A soft threshold that fires after the hard one would never apply
+		soft, softPct := parsePercentage(eviction.Soft[signal])
+		hard, hardPct := parsePercentage(eviction.Hard[signal])
+		if softPct && hardPct && soft < hard {
+			return fmt.Errorf("eviction soft: %s threshold %s is below the hard threshold %s",
+				signal, eviction.Soft[signal], eviction.Hard[signal])
+		}
+	}
+	for _, signal := range sortedKeys(eviction.SoftGracePeriod) {
+		if _, ok := eviction.Soft[signal]; !ok {
+			return fmt.Errorf("eviction soft-grace-period: %s has no soft threshold", signal)
+		}
+	}
+
+	high, low := eviction.ImageGCHighThreshold, eviction.ImageGCLowThreshold
+	if high <= 0 || high > 100 || low <= 0 || low > 100 {
+		return fmt.Errorf("image GC thresholds must be between 1 and 100, got high=%d low=%d", high, low)
+	}
+	if low >= high {
+		return fmt.Errorf("image-gc-low-threshold (%d) must be below image-gc-high-threshold (%d)", low, high)
+	}
+
+	return nil
+}
+
+// KubeletArgs renders the log rotation and eviction settings as kubelet
+// flags (without the leading dashes), in a stable order
+func (k *KubeletConfig) KubeletArgs() []string {
+	rotation := k.EffectiveLogRotation()
+	eviction := k.EffectiveEviction()
+
+	args := []string{
+		fmt.Sprintf("container-log-max-size=%s", rotation.MaxSize),
+		fmt.Sprintf("container-log-max-files=%d", rotation.MaxFiles),
+	}
+	if len(eviction.Hard) > 0 {
+		args = append(args, "eviction-hard="+joinThresholds(eviction.Hard, "<"))
+	}
+	if len(eviction.Soft) > 0 {
+		args = append(args, "eviction-soft="+joinThresholds(eviction.Soft, "<"))
+		args = append(args, "eviction-soft-grace-period="+joinThresholds(eviction.SoftGracePeriod, "="))
+	}
+	args = append(args,
+		fmt.Sprintf("image-gc-high-threshold=%d", eviction.ImageGCHighThreshold),
+		fmt.Sprintf("image-gc-low-threshold=%d", eviction.ImageGCLowThreshold),
+	)
+	return args
+}
+
+// BuildKubeletArgsConfig renders the kubelet flags as an RKE2/K3s config.yaml
+// kubelet-arg list
+func BuildKubeletArgsConfig(k *KubeletConfig) string {
+	if k == nil {
+		k = &KubeletConfig{}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("kubelet-arg:\n")
+	for _, arg := range k.KubeletArgs() {
+		builder.WriteString(fmt.Sprintf("  - %q\n", arg))
+	}
+	return builder.String()
+}
+
+func validateEvictionThreshold(signal, threshold string) error {
+	if !evictionSignals[signal] {
+		return fmt.Errorf("unknown signal %q", signal)
+	}
+	if value, ok := parsePercentage(threshold); ok {
+		if value <= 0 || value >= 100 {
+			return fmt.Errorf("%s threshold %s must be between 0%% and 100%%", signal, threshold)
+		}
+		return nil
+	}
+	if !quantityPattern.MatchString(threshold) {
+		return fmt.Errorf("%s threshold %q is invalid (use a percentage like 10%% or a quantity like 500Mi)", signal, threshold)
+	}
+	return nil
+}
+
+func parsePercentage(threshold string) (float64, bool) {
+	if !percentagePattern.MatchString(threshold) {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSuffix(threshold, "%"), 64)
+	return value, err == nil
+}
+
+func joinThresholds(thresholds map[string]string, sep string) string {
+	parts := make([]string, 0, len(thresholds))
+	for _, signal := range sortedKeys(thresholds) {
+		parts = append(parts, signal+sep+thresholds[signal])
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
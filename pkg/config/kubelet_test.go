@@ -0,0 +1,154 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKubeletArgs_Defaults(t *testing.T) {
+	k := &KubeletConfig{}
+	args := strings.Join(k.KubeletArgs(), "\n")
+
+	for _, want := range []string{
+		"container-log-max-size=20Mi",
+		"container-log-max-files=3",
+		"eviction-hard=imagefs.available<10%,memory.available<200Mi,nodefs.available<10%,nodefs.inodesFree<5%",
+		"eviction-soft=imagefs.available<15%,nodefs.available<15%",
+		"eviction-soft-grace-period=imagefs.available=2m,nodefs.available=2m",
+		"image-gc-high-threshold=80",
+		"image-gc-low-threshold=70",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("KubeletArgs() missing %q, got:\n%s", want, args)
+		}
+	}
+
+	if err := k.Validate(); err != nil {
+		t.Errorf("defaults should validate, got %v", err)
+	}
+}
+
+func TestKubeletArgs_OverridesPerSignal(t *testing.T) {
+	k := &KubeletConfig{
+		LogRotation: &LogRotationConfig{MaxSize: "50Mi"},
+		Eviction: &EvictionConfig{
+			Hard:                map[string]string{"nodefs.available": "5%"},
+			ImageGCLowThreshold: 60,
+		},
+	}
+	args := strings.Join(k.KubeletArgs(), "\n")
+
+	for _, want := range []string{
+		"container-log-max-size=50Mi",
+		"container-log-max-files=3",
+		"memory.available<200Mi,nodefs.available<5%",
+		"image-gc-low-threshold=60",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("KubeletArgs() missing %q, got:\n%s", want, args)
+		}
+	}
+}
+
+func TestKubeletConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     KubeletConfig
+		wantErr string
+	}{
+		{"bad max size", KubeletConfig{LogRotation: &LogRotationConfig{MaxSize: "20MB"}}, "max-size"},
+		{"zero max size", KubeletConfig{LogRotation: &LogRotationConfig{MaxSize: "0Mi"}}, "max-size"},
+		{"one max file", KubeletConfig{LogRotation: &LogRotationConfig{MaxFiles: 1}}, "max-files"},
+		{"unknown signal", KubeletConfig{Eviction: &EvictionConfig{Hard: map[string]string{"disk.available": "10%"}}}, "unknown signal"},
+		{"percentage out of range", KubeletConfig{Eviction: &EvictionConfig{Hard: map[string]string{"nodefs.available": "100%"}}}, "between 0%"},
+		{"bad quantity", KubeletConfig{Eviction: &EvictionConfig{Hard: map[string]string{"memory.available": "lots"}}}, "is invalid"},
+		{"soft without grace period", KubeletConfig{Eviction: &EvictionConfig{Soft: map[string]string{"memory.available": "500Mi"}}}, "no soft-grace-period"},
+		{"bad grace period", KubeletConfig{Eviction: &EvictionConfig{SoftGracePeriod: map[string]string{"nodefs.available": "soon"}}}, "invalid duration"},
+		{"grace period without soft", KubeletConfig{Eviction: &EvictionConfig{SoftGracePeriod: map[string]string{"memory.available": "1m"}}}, "no soft threshold"},
+		{"soft below hard", KubeletConfig{Eviction: &EvictionConfig{Soft: map[string]string{"nodefs.available": "5%"}}}, "below the hard threshold"},
+		{"gc low above high", KubeletConfig{Eviction: &EvictionConfig{ImageGCLowThreshold: 90}}, "must be below"},
+		{"gc high out of range", KubeletConfig{Eviction: &EvictionConfig{ImageGCHighThreshold: 120}}, "between 1 and 100"},
+		{"valid overrides", KubeletConfig{
+			LogRotation: &LogRotationConfig{MaxSize: "1Gi", MaxFiles: 2},
+			Eviction: &EvictionConfig{
+				Hard:            map[string]string{"memory.available": "1.5Gi", "pid.available": "1000"},
+				Soft:            map[string]string{"memory.available": "2Gi"},
+				SoftGracePeriod: map[string]string{"memory.available": "90s"},
+			},
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromLisp_KubeletConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cluster.lisp")
+
+	content := `(cluster
+  (metadata (name "test"))
+  (kubernetes
+    (distribution "rke2")
+    (kubelet
+      (log-rotation (max-size "50Mi") (max-files 5))
+      (eviction
+        (hard (nodefs.available "5%"))
+        (soft (nodefs.available "8%"))
+        (soft-grace-period (nodefs.available "1m"))
+        (image-gc-high-threshold 85)
+        (image-gc-low-threshold 75)))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromLisp(path)
+	if err != nil {
+		t.Fatalf("LoadFromLisp() error = %v", err)
+	}
+
+	kubelet := cfg.Kubernetes.Kubelet
+	if kubelet.LogRotation == nil || kubelet.LogRotation.MaxSize != "50Mi" || kubelet.LogRotation.MaxFiles != 5 {
+		t.Errorf("LogRotation = %+v, want 50Mi/5", kubelet.LogRotation)
+	}
+	if kubelet.Eviction == nil || kubelet.Eviction.Hard["nodefs.available"] != "5%" ||
+		kubelet.Eviction.SoftGracePeriod["nodefs.available"] != "1m" || kubelet.Eviction.ImageGCHighThreshold != 85 {
+		t.Errorf("Eviction = %+v", kubelet.Eviction)
+	}
+
+	invalid := strings.Replace(content, `(max-files 5)`, `(max-files 1)`, 1)
+	if err := os.WriteFile(path, []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromLisp(path); err == nil || !strings.Contains(err.Error(), "max-files") {
+		t.Errorf("LoadFromLisp() error = %v, want max-files error", err)
+	}
+}
+
+func TestBuildRKE2Config_IncludesKubeletArgs(t *testing.T) {
+	rke2 := GetRKE2Defaults()
+	k8s := &KubernetesConfig{Kubelet: KubeletConfig{LogRotation: &LogRotationConfig{MaxSize: "30Mi"}}}
+
+	server := BuildRKE2ServerConfig(rke2, "10.8.0.1", "master-1", true, "", k8s)
+	agent := BuildRKE2AgentConfig(rke2, "10.8.0.2", "worker-1", "10.8.0.1", k8s)
+
+	for name, out := range map[string]string{"server": server, "agent": agent} {
+		if !strings.Contains(out, "kubelet-arg:\n") || !strings.Contains(out, `  - "container-log-max-size=30Mi"`) {
+			t.Errorf("%s config missing kubelet-arg block:\n%s", name, out)
+		}
+	}
+}
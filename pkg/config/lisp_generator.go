@@ -247,6 +247,9 @@ func writeNodePools(sb *strings.Builder, pools map[string]NodePool) {
 		if pool.Image != "" {
 			sb.WriteString(fmt.Sprintf("      (image %q)\n", pool.Image))
 		}
+		if pool.SSHUser != "" {
+			sb.WriteString(fmt.Sprintf("      (ssh-user %q)\n", pool.SSHUser))
+		}
 
 		// Spot instance configuration
 		if pool.SpotInstance {
@@ -269,7 +272,7 @@ func writeNodePools(sb *strings.Builder, pools map[string]NodePool) {
 		if len(pool.Taints) > 0 {
 			sb.WriteString("      (taints\n")
 			for _, taint := range pool.Taints {
-				sb.WriteString(fmt.Sprintf("        (%s %q %s)\n", taint.Key, taint.Value, taint.Effect))
+				sb.WriteString(fmt.Sprintf("        (taint (key %q) (value %q) (effect %q))\n", taint.Key, taint.Value, taint.Effect))
 			}
 			sb.WriteString("      )\n")
 		}
@@ -7,7 +7,22 @@ import (
 
 // LoadFromLisp loads cluster configuration from a Lisp file
 func LoadFromLisp(filePath string) (*ClusterConfig, error) {
-	expr, err := ParseLispFile(filePath)
+	cfg, err := parseLispConfig(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return finishConfig(cfg)
+}
+
+// parseLispConfig builds a ClusterConfig from a Lisp file as written, without
+// ${VAR} interpolation or defaults
+func parseLispConfig(filePath string) (*ClusterConfig, error) {
+	content, err := readConfigSource(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Lisp file: %w", err)
+	}
+
+	expr, err := NewLispParser(content).Parse()
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse Lisp file: %w", err)
 	}
@@ -53,6 +68,8 @@ func LoadFromLisp(filePath string) (*ClusterConfig, error) {
 					cfg.Storage = parseStorage(section)
 				case "load-balancer", "loadBalancer":
 					cfg.LoadBalancer = parseLoadBalancer(section)
+				case "load-balancers", "loadBalancers":
+					cfg.LoadBalancers = parseLoadBalancers(section)
 				case "addons":
 					cfg.Addons = parseAddons(section)
 				case "upgrade":
@@ -70,8 +87,11 @@ func LoadFromLisp(filePath string) (*ClusterConfig, error) {
 		}
 	}
 
-	// Apply defaults
-	applyDefaults(cfg)
+	cfg.MaxDeployConcurrency = list.GetInt("max-deploy-concurrency")
+	cfg.RollbackOnFailure = list.GetBool("rollback-on-failure")
+	cfg.AllowEmptyCluster = list.GetBool("allow-empty-cluster")
+	cfg.NodeReadyTimeout = list.GetInt("node-ready-timeout")
+	cfg.NodeReadyPollInterval = list.GetInt("node-ready-poll-interval")
 
 	return cfg, nil
 }
@@ -128,72 +148,84 @@ func parseProviders(l *List) ProvidersConfig {
 
 func parseDigitalOceanProvider(l *List) *DigitalOceanProvider {
 	return &DigitalOceanProvider{
-		Enabled:    l.GetBool("enabled"),
-		Token:      l.GetString("token"),
-		Region:     l.GetString("region"),
-		SSHKeys:    l.GetStringSlice("ssh-keys"),
-		Tags:       l.GetStringSlice("tags"),
-		Monitoring: l.GetBool("monitoring"),
-		IPv6:       l.GetBool("ipv6"),
-		VPC:        parseVPCConfig(l.GetList("vpc")),
+		Enabled:               l.GetBool("enabled"),
+		Token:                 l.GetString("token"),
+		Region:                l.GetString("region"),
+		DefaultSize:           l.GetString("default-size"),
+		MaxConcurrentRequests: l.GetInt("max-concurrent-requests"),
+		SSHKeys:               l.GetStringSlice("ssh-keys"),
+		Tags:                  l.GetStringSlice("tags"),
+		Monitoring:            l.GetBool("monitoring"),
+		IPv6:                  l.GetBool("ipv6"),
+		VPC:                   parseVPCConfig(l.GetList("vpc")),
 	}
 }
 
 func parseLinodeProvider(l *List) *LinodeProvider {
 	return &LinodeProvider{
-		Enabled:        l.GetBool("enabled"),
-		Token:          l.GetString("token"),
-		Region:         l.GetString("region"),
-		RootPassword:   l.GetString("root-password"),
-		PrivateIP:      l.GetBool("private-ip"),
-		AuthorizedKeys: l.GetStringSlice("authorized-keys"),
-		Tags:           l.GetStringSlice("tags"),
-		VPC:            parseVPCConfig(l.GetList("vpc")),
+		Enabled:               l.GetBool("enabled"),
+		Token:                 l.GetString("token"),
+		Region:                l.GetString("region"),
+		DefaultSize:           l.GetString("default-size"),
+		MaxConcurrentRequests: l.GetInt("max-concurrent-requests"),
+		RootPassword:          l.GetString("root-password"),
+		PrivateIP:             l.GetBool("private-ip"),
+		AuthorizedKeys:        l.GetStringSlice("authorized-keys"),
+		Tags:                  l.GetStringSlice("tags"),
+		VPC:                   parseVPCConfig(l.GetList("vpc")),
 	}
 }
 
 func parseAWSProvider(l *List) *AWSProvider {
 	return &AWSProvider{
-		Enabled:         l.GetBool("enabled"),
-		AccessKeyID:     l.GetString("access-key-id"),
-		SecretAccessKey: l.GetString("secret-access-key"),
-		Region:          l.GetString("region"),
-		SecurityGroups:  l.GetStringSlice("security-groups"),
-		KeyPair:         l.GetString("key-pair"),
-		IAMRole:         l.GetString("iam-role"),
-		VPC:             parseVPCConfig(l.GetList("vpc")),
+		Enabled:               l.GetBool("enabled"),
+		AccessKeyID:           l.GetString("access-key-id"),
+		SecretAccessKey:       l.GetString("secret-access-key"),
+		Region:                l.GetString("region"),
+		DefaultSize:           l.GetString("default-size"),
+		MaxConcurrentRequests: l.GetInt("max-concurrent-requests"),
+		SecurityGroups:        l.GetStringSlice("security-groups"),
+		KeyPair:               l.GetString("key-pair"),
+		IAMRole:               l.GetString("iam-role"),
+		VPC:                   parseVPCConfig(l.GetList("vpc")),
 	}
 }
 
 func parseAzureProvider(l *List) *AzureProvider {
 	return &AzureProvider{
-		Enabled:        l.GetBool("enabled"),
-		SubscriptionID: l.GetString("subscription-id"),
-		TenantID:       l.GetString("tenant-id"),
-		ClientID:       l.GetString("client-id"),
-		ClientSecret:   l.GetString("client-secret"),
-		ResourceGroup:  l.GetString("resource-group"),
-		Location:       l.GetString("location"),
+		Enabled:               l.GetBool("enabled"),
+		SubscriptionID:        l.GetString("subscription-id"),
+		TenantID:              l.GetString("tenant-id"),
+		ClientID:              l.GetString("client-id"),
+		ClientSecret:          l.GetString("client-secret"),
+		ResourceGroup:         l.GetString("resource-group"),
+		Location:              l.GetString("location"),
+		DefaultSize:           l.GetString("default-size"),
+		MaxConcurrentRequests: l.GetInt("max-concurrent-requests"),
 	}
 }
 
 func parseGCPProvider(l *List) *GCPProvider {
 	return &GCPProvider{
-		Enabled:     l.GetBool("enabled"),
-		ProjectID:   l.GetString("project-id"),
-		Credentials: l.GetString("credentials"),
-		Region:      l.GetString("region"),
-		Zone:        l.GetString("zone"),
+		Enabled:               l.GetBool("enabled"),
+		ProjectID:             l.GetString("project-id"),
+		Credentials:           l.GetString("credentials"),
+		Region:                l.GetString("region"),
+		Zone:                  l.GetString("zone"),
+		DefaultSize:           l.GetString("default-size"),
+		MaxConcurrentRequests: l.GetInt("max-concurrent-requests"),
 	}
 }
 
 func parseHetznerProvider(l *List) *HetznerProvider {
 	return &HetznerProvider{
-		Enabled:    l.GetBool("enabled"),
-		Token:      l.GetString("token"),
-		Location:   l.GetString("location"),
-		Datacenter: l.GetString("datacenter"),
-		SSHKeys:    l.GetStringSlice("ssh-keys"),
+		Enabled:               l.GetBool("enabled"),
+		Token:                 l.GetString("token"),
+		Location:              l.GetString("location"),
+		Datacenter:            l.GetString("datacenter"),
+		SSHKeys:               l.GetStringSlice("ssh-keys"),
+		DefaultSize:           l.GetString("default-size"),
+		MaxConcurrentRequests: l.GetInt("max-concurrent-requests"),
 	}
 }
 
@@ -265,6 +297,7 @@ func parseWireGuard(l *List) *WireGuardConfig {
 		AutoConfig:          l.GetBool("auto-config"),
 		MeshNetworking:      l.GetBool("mesh-networking"),
 		SubnetCIDR:          l.GetString("subnet-cidr"),
+		UsePresharedKeys:    l.GetBool("use-preshared-keys"),
 	}
 }
 
@@ -278,6 +311,7 @@ func parseTailscale(l *List) *TailscaleConfig {
 		Tags:         l.GetStringSlice("tags"),
 		AcceptRoutes: l.GetBool("accept-routes"),
 		ExitNode:     l.GetString("exit-node"),
+		ACLPolicy:    l.GetString("acl-policy"),
 		Create:       l.GetBool("create"),
 		Provider:     l.GetString("provider"),
 		Region:       l.GetString("region"),
@@ -293,6 +327,7 @@ func parseDNS(l *List) DNSConfig {
 		Searches:    l.GetStringSlice("searches"),
 		ExternalDNS: l.GetBool("external-dns"),
 		Provider:    l.GetString("provider"),
+		APIToken:    l.GetString("api-token"),
 	}
 }
 
@@ -340,6 +375,14 @@ func parseSecurity(l *List) SecurityConfig {
 		cfg.Bastion = parseBastionConfig(bastion)
 	}
 
+	if tls := l.GetList("tls"); tls != nil {
+		cfg.TLS = parseTLSConfig(tls)
+	}
+
+	if podSecurity := l.GetList("pod-security"); podSecurity != nil {
+		cfg.PodSecurity = parsePodSecurityConfig(podSecurity)
+	}
+
 	return cfg
 }
 
@@ -355,6 +398,27 @@ func parseSSHConfig(l *List) SSHConfig {
 	}
 }
 
+func parseTLSConfig(l *List) TLSConfig {
+	return TLSConfig{
+		Enabled:     l.GetBool("enabled"),
+		CertManager: l.GetBool("cert-manager"),
+		Provider:    l.GetString("provider"),
+		Email:       l.GetString("email"),
+		Domains:     l.GetStringSlice("domains"),
+		Staging:     l.GetBool("staging"),
+	}
+}
+
+func parsePodSecurityConfig(l *List) PodSecurityConfig {
+	return PodSecurityConfig{
+		PolicyLevel:      l.GetString("policy-level"),
+		EnforceProfile:   l.GetString("enforce"),
+		AuditProfile:     l.GetString("audit"),
+		WarnProfile:      l.GetString("warn"),
+		ExemptNamespaces: l.GetStringSlice("exempt-namespaces"),
+	}
+}
+
 func parseBastionConfig(l *List) *BastionConfig {
 	return &BastionConfig{
 		Enabled:        l.GetBool("enabled"),
@@ -377,7 +441,7 @@ func parseNodes(l *List) []NodeConfig {
 	var nodes []NodeConfig
 	for _, item := range l.Tail() {
 		if node, ok := item.(*List); ok {
-			nodes = append(nodes, NodeConfig{
+			nodeConfig := NodeConfig{
 				Name:         node.GetString("name"),
 				Provider:     node.GetString("provider"),
 				Pool:         node.GetString("pool"),
@@ -389,7 +453,18 @@ func parseNodes(l *List) []NodeConfig {
 				Labels:       node.GetMap("labels"),
 				SpotInstance: node.GetBool("spot-instance"),
 				SpotMaxPrice: node.GetString("spot-max-price"),
-			})
+				SSHUser:      node.GetString("ssh-user"),
+			}
+
+			if taints := node.GetList("taints"); taints != nil {
+				nodeConfig.Taints = parseTaints(taints)
+			}
+
+			if creds := node.Get("credentials"); creds != nil {
+				nodeConfig.Credentials = parseProviderCredentials(creds)
+			}
+
+			nodes = append(nodes, nodeConfig)
 		}
 	}
 	return nodes
@@ -412,12 +487,14 @@ func parseNodePools(l *List) map[string]NodePool {
 					Size:         pool.GetString("size"),
 					Image:        pool.GetString("image"),
 					Region:       pool.GetString("region"),
+					Regions:      pool.GetStringSlice("regions"),
 					Zones:        pool.GetStringSlice("zones"),
 					Labels:       pool.GetMap("labels"),
 					AutoScaling:  pool.GetBool("auto-scaling"),
 					SpotInstance: pool.GetBool("spot-instance"),
 					Preemptible:  pool.GetBool("preemptible"),
 					UserData:     pool.GetString("user-data"),
+					SSHUser:      pool.GetString("ssh-user"),
 				}
 
 				// Parse advanced configurations
@@ -443,6 +520,10 @@ func parseNodePools(l *List) map[string]NodePool {
 					nodePool.CustomImage = parseCustomImageConfig(image)
 				}
 
+				if creds := pool.Get("credentials"); creds != nil {
+					nodePool.Credentials = parseProviderCredentials(creds)
+				}
+
 				pools[name] = nodePool
 			}
 		}
@@ -466,6 +547,38 @@ func parseKubernetes(l *List) KubernetesConfig {
 		cfg.RKE2 = parseRKE2Config(rke2)
 	}
 
+	if kubelet := l.GetList("kubelet"); kubelet != nil {
+		cfg.Kubelet = parseKubeletConfig(kubelet)
+	}
+
+	return cfg
+}
+
+// parseKubeletConfig parses the kubelet log rotation and eviction settings
+func parseKubeletConfig(l *List) KubeletConfig {
+	cfg := KubeletConfig{
+		ClusterDNS:      l.GetString("cluster-dns"),
+		ClusterDomain:   l.GetString("cluster-domain"),
+		RegistryMirrors: l.GetStringSlice("registry-mirrors"),
+	}
+
+	if rotation := l.GetList("log-rotation"); rotation != nil {
+		cfg.LogRotation = &LogRotationConfig{
+			MaxSize:  rotation.GetString("max-size"),
+			MaxFiles: rotation.GetInt("max-files"),
+		}
+	}
+
+	if eviction := l.GetList("eviction"); eviction != nil {
+		cfg.Eviction = &EvictionConfig{
+			Hard:                 eviction.GetMap("hard"),
+			Soft:                 eviction.GetMap("soft"),
+			SoftGracePeriod:      eviction.GetMap("soft-grace-period"),
+			ImageGCHighThreshold: eviction.GetInt("image-gc-high-threshold"),
+			ImageGCLowThreshold:  eviction.GetInt("image-gc-low-threshold"),
+		}
+	}
+
 	return cfg
 }
 
@@ -520,12 +633,32 @@ func parseStorage(l *List) StorageConfig {
 
 func parseLoadBalancer(l *List) LoadBalancerConfig {
 	return LoadBalancerConfig{
-		Name:     l.GetString("name"),
-		Type:     l.GetString("type"),
-		Provider: l.GetString("provider"),
+		Name:        l.GetString("name"),
+		Type:        l.GetString("type"),
+		Provider:    l.GetString("provider"),
+		AddressPool: l.GetString("address-pool"),
+		Target:      l.GetString("target"),
 	}
 }
 
+// parseLoadBalancers parses one entry per load balancer, named by its head
+// unless it sets (name ...)
+func parseLoadBalancers(l *List) []LoadBalancerConfig {
+	var lbs []LoadBalancerConfig
+	for _, item := range l.Tail() {
+		if entry, ok := item.(*List); ok {
+			if head := entry.Head(); head != nil {
+				lb := parseLoadBalancer(entry)
+				if lb.Name == "" {
+					lb.Name = head.AsString()
+				}
+				lbs = append(lbs, lb)
+			}
+		}
+	}
+	return lbs
+}
+
 func parseAddons(l *List) AddonsConfig {
 	cfg := AddonsConfig{}
 
@@ -745,6 +878,7 @@ func parseSpotConfig(l *List) *SpotConfig {
 		FallbackOnDemand: l.GetBool("fallback-on-demand"),
 		SpotPercentage:   l.GetInt("spot-percentage"),
 		InterruptionMode: l.GetString("interruption-mode"),
+		DrainTimeout:     l.GetInt("drain-timeout"),
 	}
 }
 
@@ -787,30 +921,69 @@ func parseCustomImageConfig(l *List) *CustomImageConfig {
 	}
 }
 
-// parseTaints parses taint configurations
+// parseProviderCredentials parses a node or pool credentials override
+func parseProviderCredentials(expr SExpr) *ProviderCredentials {
+	l, ok := expr.(*List)
+	if !ok {
+		return nil
+	}
+	// A single field such as (credentials (token "...")) comes back from Get
+	// as the bare pair
+	if l.Head() != nil {
+		l = &List{Items: []SExpr{l}}
+	}
+
+	return &ProviderCredentials{
+		Token:           l.GetString("token"),
+		AccessKeyID:     l.GetString("access-key-id"),
+		SecretAccessKey: l.GetString("secret-access-key"),
+		SubscriptionID:  l.GetString("subscription-id"),
+		TenantID:        l.GetString("tenant-id"),
+		ClientID:        l.GetString("client-id"),
+		ClientSecret:    l.GetString("client-secret"),
+		ProjectID:       l.GetString("project-id"),
+		Credentials:     l.GetString("credentials"),
+	}
+}
+
+// parseTaints parses taint configurations: (taint ...) entries, or entries
+// named after the taint such as (gpu (key "x") (value "y") (effect "z"))
 func parseTaints(l *List) []TaintConfig {
+	// Get unwraps a single entry, (taints (taint ...)), to the entry itself
+	if l.Head() != nil {
+		l = &List{Items: []SExpr{l}}
+	}
+
 	var taints []TaintConfig
 	for _, item := range l.Items {
 		if taint, ok := item.(*List); ok {
 			head := taint.Head()
+			if head != nil && head.AsString() != "taint" && taint.GetString("key") != "" {
+				taints = append(taints, TaintConfig{
+					Key:    taint.GetString("key"),
+					Value:  taint.GetString("value"),
+					Effect: taint.GetString("effect"),
+				})
+				continue
+			}
 			if head != nil && head.AsString() == "taint" {
 				// Parse (taint "key" "effect") or (taint (key "x") (value "y") (effect "z"))
 				if len(taint.Items) == 3 {
-					if key, ok := taint.Items[1].(*Atom); ok {
-						if effect, ok := taint.Items[2].(*Atom); ok {
-							taints = append(taints, TaintConfig{
-								Key:    key.AsString(),
-								Effect: effect.AsString(),
-							})
-						}
+					key, keyOK := taint.Items[1].(*Atom)
+					effect, effectOK := taint.Items[2].(*Atom)
+					if keyOK && effectOK {
+						taints = append(taints, TaintConfig{
+							Key:    key.AsString(),
+							Effect: effect.AsString(),
+						})
+						continue
 					}
-				} else {
-					taints = append(taints, TaintConfig{
-						Key:    taint.GetString("key"),
-						Value:  taint.GetString("value"),
-						Effect: taint.GetString("effect"),
-					})
 				}
+				taints = append(taints, TaintConfig{
+					Key:    taint.GetString("key"),
+					Value:  taint.GetString("value"),
+					Effect: taint.GetString("effect"),
+				})
 			}
 		}
 	}
@@ -4,6 +4,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -19,6 +20,9 @@ type SExpr interface {
 type Atom struct {
 	Value    interface{}
 	RawValue string
+	// Line and Column locate the atom in the source (1-based, 0 if unknown)
+	Line   int
+	Column int
 }
 
 func (a *Atom) isSExpr() {}
@@ -91,6 +95,9 @@ func (a *Atom) AsBool() bool {
 // List represents a list of S-expressions
 type List struct {
 	Items []SExpr
+	// Line and Column locate the opening paren (1-based, 0 if unknown)
+	Line   int
+	Column int
 }
 
 func (l *List) isSExpr() {}
@@ -132,7 +139,7 @@ func (l *List) Get(name string) SExpr {
 					return list.Items[1]
 				}
 				// Return the tail as a new list for multiple values
-				return &List{Items: list.Tail()}
+				return &List{Items: list.Tail(), Line: list.Line, Column: list.Column}
 			}
 		}
 	}
@@ -203,6 +210,11 @@ func (l *List) GetMap(name string) map[string]string {
 	result := make(map[string]string)
 	if v := l.Get(name); v != nil {
 		if list, ok := v.(*List); ok {
+			// A single entry such as (labels (env "prod")) comes back from
+			// Get as the bare pair rather than a list of pairs
+			if list.Head() != nil {
+				list = &List{Items: []SExpr{list}}
+			}
 			for _, item := range list.Items {
 				if pair, ok := item.(*List); ok {
 					if head := pair.Head(); head != nil && len(pair.Items) >= 2 {
@@ -217,19 +229,38 @@ func (l *List) GetMap(name string) map[string]string {
 	return result
 }
 
+// SyntaxError is a parse failure at a known source position
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
 // Parser for S-expressions
 type LispParser struct {
-	input  string
-	pos    int
-	length int
+	input      string
+	pos        int
+	length     int
+	lineStarts []int
 }
 
 // NewLispParser creates a new parser
 func NewLispParser(input string) *LispParser {
+	lineStarts := []int{0}
+	for i := 0; i < len(input); i++ {
+		if input[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
 	return &LispParser{
-		input:  input,
-		pos:    0,
-		length: len(input),
+		input:      input,
+		pos:        0,
+		length:     len(input),
+		lineStarts: lineStarts,
 	}
 }
 
@@ -237,15 +268,36 @@ func NewLispParser(input string) *LispParser {
 func (p *LispParser) Parse() (SExpr, error) {
 	p.skipWhitespaceAndComments()
 	if p.pos >= p.length {
-		return nil, fmt.Errorf("unexpected end of input")
+		return nil, p.errorAt(p.pos, "unexpected end of input")
 	}
 	return p.parseExpr()
 }
 
+// expectEnd fails if anything but whitespace and comments follows the
+// parsed expression
+func (p *LispParser) expectEnd() error {
+	p.skipWhitespaceAndComments()
+	if p.pos < p.length {
+		return p.errorAt(p.pos, "unexpected '%c' after the root expression", p.input[p.pos])
+	}
+	return nil
+}
+
+// position converts a byte offset into a 1-based line and column
+func (p *LispParser) position(offset int) (int, int) {
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > offset })
+	return line, offset - p.lineStarts[line-1] + 1
+}
+
+func (p *LispParser) errorAt(offset int, format string, args ...interface{}) error {
+	line, column := p.position(offset)
+	return &SyntaxError{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
 func (p *LispParser) parseExpr() (SExpr, error) {
 	p.skipWhitespaceAndComments()
 	if p.pos >= p.length {
-		return nil, fmt.Errorf("unexpected end of input")
+		return nil, p.errorAt(p.pos, "unexpected end of input")
 	}
 
 	ch := p.input[p.pos]
@@ -262,13 +314,15 @@ func (p *LispParser) parseExpr() (SExpr, error) {
 }
 
 func (p *LispParser) parseList() (*List, error) {
+	start := p.pos
 	p.pos++ // skip '('
 	list := &List{Items: []SExpr{}}
+	list.Line, list.Column = p.position(start)
 
 	for {
 		p.skipWhitespaceAndComments()
 		if p.pos >= p.length {
-			return nil, fmt.Errorf("unexpected end of input in list")
+			return nil, p.errorAt(start, "unclosed list: unexpected end of input")
 		}
 		if p.input[p.pos] == ')' {
 			p.pos++ // skip ')'
@@ -285,6 +339,7 @@ func (p *LispParser) parseList() (*List, error) {
 }
 
 func (p *LispParser) parseString() (*Atom, error) {
+	quote := p.pos
 	p.pos++ // skip opening quote
 	start := p.pos
 	var result strings.Builder
@@ -311,14 +366,14 @@ func (p *LispParser) parseString() (*Atom, error) {
 			p.pos++
 		} else if ch == '"' {
 			p.pos++ // skip closing quote
-			return &Atom{Value: result.String(), RawValue: "\"" + p.input[start:p.pos-1] + "\""}, nil
+			return p.atomAt(quote, result.String(), "\""+p.input[start:p.pos-1]+"\""), nil
 		} else {
 			result.WriteByte(ch)
 			p.pos++
 		}
 	}
 
-	return nil, fmt.Errorf("unterminated string")
+	return nil, p.errorAt(quote, "unterminated string")
 }
 
 func (p *LispParser) parseNumberOrSymbol() (*Atom, error) {
@@ -358,10 +413,10 @@ func (p *LispParser) parseNumberOrSymbol() (*Atom, error) {
 
 	if hasDecimal {
 		val, _ := strconv.ParseFloat(raw, 64)
-		return &Atom{Value: val, RawValue: raw}, nil
+		return p.atomAt(start, val, raw), nil
 	}
 	val, _ := strconv.ParseInt(raw, 10, 64)
-	return &Atom{Value: int(val), RawValue: raw}, nil
+	return p.atomAt(start, int(val), raw), nil
 }
 
 func (p *LispParser) parseAtom() (*Atom, error) {
@@ -372,7 +427,7 @@ func (p *LispParser) parseAtom() (*Atom, error) {
 	}
 
 	if p.pos == start {
-		return nil, fmt.Errorf("expected atom at position %d", p.pos)
+		return nil, p.errorAt(p.pos, "unexpected '%c'", p.input[p.pos])
 	}
 
 	raw := p.input[start:p.pos]
@@ -380,12 +435,17 @@ func (p *LispParser) parseAtom() (*Atom, error) {
 	// Check for special atoms
 	switch raw {
 	case "true", "t", "#t":
-		return &Atom{Value: true, RawValue: raw}, nil
+		return p.atomAt(start, true, raw), nil
 	case "false", "nil", "#f":
-		return &Atom{Value: false, RawValue: raw}, nil
+		return p.atomAt(start, false, raw), nil
 	}
 
-	return &Atom{Value: raw, RawValue: raw}, nil
+	return p.atomAt(start, raw, raw), nil
+}
+
+func (p *LispParser) atomAt(offset int, value interface{}, raw string) *Atom {
+	line, column := p.position(offset)
+	return &Atom{Value: value, RawValue: raw, Line: line, Column: column}
 }
 
 func (p *LispParser) skipWhitespaceAndComments() {
@@ -410,25 +470,33 @@ func isDelimiter(ch byte) bool {
 
 // ParseFile parses a Lisp file and returns the root S-expression
 func ParseLispFile(filePath string) (SExpr, error) {
+	content, err := readConfigSource(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expand environment variables in the content
+	parser := NewLispParser(expandEnvVars(content))
+	return parser.Parse()
+}
+
+// readConfigSource reads a config file, expanding a leading ~ in the path
+func readConfigSource(filePath string) (string, error) {
 	// Expand ~ to home directory
 	if strings.HasPrefix(filePath, "~") {
 		home, err := os.UserHomeDir()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
+			return "", fmt.Errorf("failed to get home directory: %w", err)
 		}
 		filePath = home + filePath[1:]
 	}
 
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	// Expand environment variables in the content
-	contentStr := expandEnvVars(string(content))
-
-	parser := NewLispParser(contentStr)
-	return parser.Parse()
+	return string(content), nil
 }
 
 // expandEnvVars expands ${VAR_NAME} patterns in the content
@@ -0,0 +1,388 @@
+// Package config provides schema checks for Lisp cluster configs
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// knownNodeRoles lists the values accepted in (roles ...)
+var knownNodeRoles = []string{"master", "controlplane", "control-plane", "server", "etcd", "worker", "agent"}
+
+// Diagnostic is a schema problem in a Lisp config, located by its
+// s-expression path (e.g. cluster.node-pools.workers.count) and source position
+type Diagnostic struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.Path, d.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", d.Line, d.Column, d.Path, d.Message)
+}
+
+// Diagnostics is a list of diagnostics usable as an error
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diag := range d {
+		lines[i] = diag.String()
+	}
+	return fmt.Sprintf("%d configuration problem(s):\n  %s", len(d), strings.Join(lines, "\n  "))
+}
+
+// ValidateLispSchema checks a parsed (cluster ...) expression for the fields
+// every deployment needs. Diagnostics are ordered by source position.
+func ValidateLispSchema(expr SExpr) []Diagnostic {
+	c := &schemaChecker{}
+
+	root, ok := expr.(*List)
+	if !ok || root.Head() == nil || root.Head().AsString() != "cluster" {
+		c.report("cluster", expr, "expected (cluster ...) at root level")
+		return c.diagnostics
+	}
+
+	c.checkMetadata(root)
+	c.checkProviders(root)
+	if network := findProperty(root, "network"); network != nil {
+		c.checkNetwork(network)
+	}
+	for _, nodes := range findProperties(root, "nodes") {
+		c.checkNodes(nodes)
+	}
+	for _, pools := range append(findProperties(root, "node-pools"), findProperties(root, "nodePools")...) {
+		c.checkNodePools(pools)
+	}
+
+	sortDiagnostics(c.diagnostics)
+	return c.diagnostics
+}
+
+// schemaChecker accumulates diagnostics while walking the expression tree
+type schemaChecker struct {
+	diagnostics []Diagnostic
+}
+
+func (c *schemaChecker) report(path string, at SExpr, format string, args ...interface{}) {
+	line, column := exprPosition(at)
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Path:    path,
+		Line:    line,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *schemaChecker) checkMetadata(root *List) {
+	metadata := findProperty(root, "metadata")
+	if metadata == nil {
+		c.report("cluster", root, "missing (metadata ...) section")
+		return
+	}
+
+	name := findProperty(metadata, "name")
+	if name == nil {
+		c.report("cluster.metadata", metadata, "metadata name is required")
+		return
+	}
+	if isDynamicProperty(name) {
+		return
+	}
+	if value := propertyAtom(name); value == nil || strings.TrimSpace(value.AsString()) == "" {
+		c.report("cluster.metadata.name", name, "metadata name must be a non-empty string")
+	}
+}
+
+func (c *schemaChecker) checkProviders(root *List) {
+	providers := findProperty(root, "providers")
+	if providers == nil {
+		c.report("cluster", root, "missing (providers ...) section")
+		return
+	}
+
+	enabled := false
+	for _, item := range providers.Tail() {
+		provider, ok := item.(*List)
+		if !ok || provider.Head() == nil {
+			continue
+		}
+		path := "cluster.providers." + provider.Head().AsString()
+
+		if flag := findProperty(provider, "enabled"); flag != nil {
+			if value := propertyAtom(flag); isDynamicProperty(flag) || (value != nil && value.AsBool()) {
+				enabled = true
+			}
+		}
+		if vpc := findProperty(provider, "vpc"); vpc != nil {
+			c.checkCIDR(path+".vpc", vpc, "cidr")
+		}
+		if prop := findProperty(provider, "max-concurrent-requests"); prop != nil && !isDynamicProperty(prop) {
+			if value := propertyAtom(prop); value == nil || !value.IsNumber() || value.AsInt() < 0 {
+				c.report(path+".max-concurrent-requests", prop, "max-concurrent-requests must be an integer >= 0")
+			}
+		}
+	}
+
+	if !enabled {
+		c.report("cluster.providers", providers, "at least one provider must have (enabled true)")
+	}
+}
+
+func (c *schemaChecker) checkNetwork(network *List) {
+	for _, field := range []string{"cidr", "pod-cidr", "service-cidr"} {
+		c.checkCIDR("cluster.network", network, field)
+	}
+	if wg := findProperty(network, "wireguard"); wg != nil {
+		c.checkCIDR("cluster.network.wireguard", wg, "subnet-cidr")
+	}
+}
+
+// checkCIDR reports the named field of section when it is set but not a CIDR
+func (c *schemaChecker) checkCIDR(path string, section *List, field string) {
+	prop := findProperty(section, field)
+	if prop == nil || isDynamicProperty(prop) {
+		return
+	}
+	value := propertyAtom(prop)
+	if value == nil {
+		c.report(path+"."+field, prop, "expected a CIDR such as 10.0.0.0/16")
+		return
+	}
+	// Unset ${VAR} references are reported on their own
+	if !isValidCIDR(value.AsString()) && !strings.Contains(value.AsString(), "${") {
+		c.report(path+"."+field, value, "invalid CIDR %q", value.AsString())
+	}
+}
+
+func (c *schemaChecker) checkNodes(nodes *List) {
+	for i, item := range nodes.Tail() {
+		node, ok := item.(*List)
+		if !ok {
+			c.report(fmt.Sprintf("cluster.nodes[%d]", i), item, "expected a node definition list")
+			continue
+		}
+		c.checkRoles(fmt.Sprintf("cluster.nodes[%d]", i), node)
+	}
+}
+
+func (c *schemaChecker) checkNodePools(pools *List) {
+	for _, item := range pools.Tail() {
+		pool, ok := item.(*List)
+		if !ok || pool.Head() == nil {
+			c.report("cluster.node-pools", item, "expected a (pool-name ...) definition")
+			continue
+		}
+		path := "cluster.node-pools." + pool.Head().AsString()
+
+		for _, field := range []string{"count", "min-count", "max-count"} {
+			prop := findProperty(pool, field)
+			if prop == nil || isDynamicProperty(prop) {
+				continue
+			}
+			value := propertyAtom(prop)
+			if value == nil || !value.IsNumber() {
+				c.report(path+"."+field, prop, "%s must be an integer", field)
+				continue
+			}
+			if value.AsInt() < 0 {
+				c.report(path+"."+field, value, "%s must be >= 0, got %d", field, value.AsInt())
+			}
+		}
+
+		c.checkRoles(path, pool)
+	}
+}
+
+func (c *schemaChecker) checkRoles(path string, section *List) {
+	roles := findProperty(section, "roles")
+	if roles == nil {
+		return
+	}
+	for _, item := range roles.Tail() {
+		// (roles ("master" "etcd")) nests the values one level down
+		values := []SExpr{item}
+		if nested, ok := item.(*List); ok {
+			values = nested.Items
+		}
+		for _, value := range values {
+			atom, ok := value.(*Atom)
+			if !ok {
+				c.report(path+".roles", value, "expected a role name")
+				continue
+			}
+			if !sliceContains(knownNodeRoles, atom.AsString()) {
+				c.report(path+".roles", atom, "unknown role %q (expected one of: %s)",
+					atom.AsString(), strings.Join(knownNodeRoles, ", "))
+			}
+		}
+	}
+}
+
+// findProperty returns the first (name ...) entry of l, keeping its position
+func findProperty(l *List, name string) *List {
+	if props := findProperties(l, name); len(props) > 0 {
+		return props[0]
+	}
+	return nil
+}
+
+// findProperties returns every (name ...) entry of l
+func findProperties(l *List, name string) []*List {
+	var props []*List
+	for _, item := range l.Tail() {
+		if prop, ok := item.(*List); ok {
+			if head := prop.Head(); head != nil && head.AsString() == name {
+				props = append(props, prop)
+			}
+		}
+	}
+	return props
+}
+
+// propertyAtom returns the single atom value of a (name value) entry
+func propertyAtom(prop *List) *Atom {
+	if len(prop.Items) != 2 {
+		return nil
+	}
+	atom, _ := prop.Items[1].(*Atom)
+	return atom
+}
+
+// isDynamicProperty reports whether a (name value) entry holds a form such as
+// (env "POD_CIDR" "10.42.0.0/16") whose value is only known after evaluation
+func isDynamicProperty(prop *List) bool {
+	if len(prop.Items) != 2 {
+		return false
+	}
+	form, ok := prop.Items[1].(*List)
+	return ok && form.Head() != nil && form.Head().IsSymbol()
+}
+
+func exprPosition(expr SExpr) (int, int) {
+	switch e := expr.(type) {
+	case *Atom:
+		return e.Line, e.Column
+	case *List:
+		return e.Line, e.Column
+	}
+	return 0, 0
+}
+
+// Validate parses the config and checks it against the schema without
+// building a ClusterConfig. Syntax errors and unset ${VAR} references come
+// back as diagnostics; the error is reserved for files that cannot be read.
+func (l *Loader) Validate() ([]Diagnostic, error) {
+	content, err := readConfigSource(l.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.format == FormatYAML || l.format == FormatJSON {
+		return validateStructuredConfig([]byte(content), l.format), nil
+	}
+
+	parser := NewLispParser(content)
+	expr, err := parser.Parse()
+	if err == nil {
+		err = parser.expectEnd()
+	}
+	if err != nil {
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return []Diagnostic{{
+				Path:    "cluster",
+				Line:    syntaxErr.Line,
+				Column:  syntaxErr.Column,
+				Message: syntaxErr.Message,
+			}}, nil
+		}
+		return []Diagnostic{{Path: "cluster", Message: err.Error()}}, nil
+	}
+
+	diagnostics := interpolateLispStrings(expr, nil, os.LookupEnv)
+	diagnostics = append(diagnostics, ValidateLispSchema(expr)...)
+	sortDiagnostics(diagnostics)
+	return diagnostics, nil
+}
+
+// interpolateLispStrings resolves ${VAR} references in the string atoms of
+// expr in place, returning a diagnostic for each variable that is not set
+func interpolateLispStrings(expr SExpr, path []string, lookup func(string) (string, bool)) []Diagnostic {
+	switch e := expr.(type) {
+	case *Atom:
+		if !e.IsString() {
+			return nil
+		}
+		value, missing := interpolateString(e.AsString(), lookup)
+		e.Value = value
+
+		var diagnostics []Diagnostic
+		for _, name := range missing {
+			diagnostics = append(diagnostics, Diagnostic{
+				Path:    strings.Join(path, "."),
+				Line:    e.Line,
+				Column:  e.Column,
+				Message: fmt.Sprintf("environment variable %s is not set", name),
+			})
+		}
+		return diagnostics
+
+	case *List:
+		if head := e.Head(); head != nil && head.IsSymbol() {
+			path = append(path, head.AsString())
+		}
+		var diagnostics []Diagnostic
+		for _, item := range e.Items {
+			diagnostics = append(diagnostics, interpolateLispStrings(item, path, lookup)...)
+		}
+		return diagnostics
+	}
+	return nil
+}
+
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// LoadStrict validates the config and fails on the first diagnostic before
+// loading it
+func (l *Loader) LoadStrict() (*ClusterConfig, error) {
+	diagnostics, err := l.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if len(diagnostics) > 0 {
+		return nil, fmt.Errorf("invalid configuration %s: %s", l.configPath, diagnostics[0])
+	}
+	return l.Load()
+}
+
+// LoadLenient validates the config and reports every diagnostic at once.
+// The config is only loaded when there are none; otherwise the error is the
+// Diagnostics themselves.
+func (l *Loader) LoadLenient() (*ClusterConfig, []Diagnostic, error) {
+	diagnostics, err := l.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnostics, Diagnostics(diagnostics)
+	}
+
+	cfg, err := l.Load()
+	return cfg, nil, err
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const schemaValidConfig = `(cluster
+  (metadata (name "prod"))
+  (providers
+    (aws (enabled true) (vpc (cidr "10.0.0.0/16"))))
+  (network
+    (pod-cidr "10.42.0.0/16")
+    (wireguard (enabled true) (subnet-cidr "10.8.0.0/24")))
+  (node-pools
+    (masters (name "masters") (provider "aws") (count 3) (roles master etcd))
+    (workers (name "workers") (provider "aws") (count 2) (roles worker))))
+`
+
+func writeSchemaConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLispParser_Positions(t *testing.T) {
+	expr, err := NewLispParser("(cluster\n  (metadata\n    (name \"prod\")))").Parse()
+	require.NoError(t, err)
+
+	root := expr.(*List)
+	assert.Equal(t, 1, root.Line)
+	assert.Equal(t, 1, root.Column)
+
+	metadata := findProperty(root, "metadata")
+	require.NotNil(t, metadata)
+	assert.Equal(t, 2, metadata.Line)
+	assert.Equal(t, 3, metadata.Column)
+
+	name := propertyAtom(findProperty(metadata, "name"))
+	require.NotNil(t, name)
+	assert.Equal(t, 3, name.Line)
+	assert.Equal(t, 11, name.Column)
+}
+
+func TestLispParser_SyntaxErrorPosition(t *testing.T) {
+	_, err := NewLispParser("(cluster\n  (metadata (name \"prod)))").Parse()
+	var syntaxErr *SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 2, syntaxErr.Line)
+	assert.Equal(t, 19, syntaxErr.Column)
+	assert.Equal(t, "unterminated string", syntaxErr.Message)
+}
+
+func TestValidateLispSchema_Valid(t *testing.T) {
+	expr, err := NewLispParser(schemaValidConfig).Parse()
+	require.NoError(t, err)
+	assert.Empty(t, ValidateLispSchema(expr))
+}
+
+func TestValidateLispSchema_CollectsAll(t *testing.T) {
+	expr, err := NewLispParser(`(cluster
+  (metadata (name ""))
+  (providers (aws (enabled false)))
+  (network (service-cidr "10.43.0.0"))
+  (nodes ((name "n1") (roles master bogus)))
+  (node-pools (workers (count -1) (max-count many))))
+`).Parse()
+	require.NoError(t, err)
+
+	diagnostics := ValidateLispSchema(expr)
+	require.Len(t, diagnostics, 6)
+
+	assert.Equal(t, Diagnostic{Path: "cluster.metadata.name", Line: 2, Column: 13, Message: "metadata name must be a non-empty string"}, diagnostics[0])
+	assert.Equal(t, "cluster.providers", diagnostics[1].Path)
+	assert.Equal(t, 3, diagnostics[1].Line)
+	assert.Equal(t, "cluster.network.service-cidr", diagnostics[2].Path)
+	assert.Equal(t, `invalid CIDR "10.43.0.0"`, diagnostics[2].Message)
+	assert.Equal(t, "cluster.nodes[0].roles", diagnostics[3].Path)
+	assert.Contains(t, diagnostics[3].Message, `unknown role "bogus"`)
+	assert.Equal(t, "cluster.node-pools.workers.count", diagnostics[4].Path)
+	assert.Equal(t, "count must be >= 0, got -1", diagnostics[4].Message)
+	assert.Equal(t, "cluster.node-pools.workers.max-count", diagnostics[5].Path)
+	assert.Equal(t, "max-count must be an integer", diagnostics[5].Message)
+}
+
+func TestValidateLispSchema_SkipsDynamicForms(t *testing.T) {
+	expr, err := NewLispParser(`(cluster
+  (metadata (name (concat "sloth-" (env "SUFFIX" "dev"))))
+  (providers (aws (enabled (env "AWS_ENABLED" "true"))))
+  (network (pod-cidr (env "POD_CIDR" "10.42.0.0/16")))
+  (node-pools (masters (count (default (env "MASTER_COUNT") 3)) (roles master))))
+`).Parse()
+	require.NoError(t, err)
+	assert.Empty(t, ValidateLispSchema(expr))
+}
+
+func TestValidateLispSchema_MissingSections(t *testing.T) {
+	expr, err := NewLispParser(`(cluster (metadata (version "1")))`).Parse()
+	require.NoError(t, err)
+
+	diagnostics := ValidateLispSchema(expr)
+	require.Len(t, diagnostics, 2)
+	assert.Equal(t, "missing (providers ...) section", diagnostics[0].Message)
+	assert.Equal(t, "metadata name is required", diagnostics[1].Message)
+
+	expr, err = NewLispParser(`(config (name "x"))`).Parse()
+	require.NoError(t, err)
+	diagnostics = ValidateLispSchema(expr)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "line 1, column 1: cluster: expected (cluster ...) at root level", diagnostics[0].String())
+}
+
+func TestLoader_ValidateSchema(t *testing.T) {
+	diagnostics, err := NewLoader(writeSchemaConfig(t, schemaValidConfig)).Validate()
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+
+	diagnostics, err = NewLoader(writeSchemaConfig(t, "(cluster\n  (metadata (name \"x\"))\n  )\n)")).Validate()
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "unexpected ')' after the root expression", diagnostics[0].Message)
+	assert.Equal(t, 4, diagnostics[0].Line)
+
+	_, err = NewLoader(filepath.Join(t.TempDir(), "missing.lisp")).Validate()
+	assert.Error(t, err)
+}
+
+func TestLoader_LoadStrictAndLenient(t *testing.T) {
+	invalid := writeSchemaConfig(t, `(cluster
+  (metadata (name ""))
+  (providers (aws (enabled false))))
+`)
+
+	_, err := NewLoader(invalid).LoadStrict()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2, column 13: cluster.metadata.name")
+	assert.NotContains(t, err.Error(), "cluster.providers")
+
+	cfg, diagnostics, err := NewLoader(invalid).LoadLenient()
+	assert.Nil(t, cfg)
+	assert.Len(t, diagnostics, 2)
+	var all Diagnostics
+	require.ErrorAs(t, err, &all)
+	assert.Contains(t, err.Error(), "2 configuration problem(s)")
+
+	cfg, err = NewLoader(writeSchemaConfig(t, schemaValidConfig)).LoadStrict()
+	require.NoError(t, err)
+	assert.Equal(t, "prod", cfg.Metadata.Name)
+
+	cfg, diagnostics, err = NewLoader(writeSchemaConfig(t, schemaValidConfig)).LoadLenient()
+	require.NoError(t, err)
+	assert.Empty(t, diagnostics)
+	assert.Equal(t, 3, cfg.NodePools["masters"].Count)
+}
+
+func TestLoader_MaxConcurrentRequests(t *testing.T) {
+	cfg, err := NewLoader(writeSchemaConfig(t, `(cluster
+  (metadata (name "prod"))
+  (providers
+    (aws (enabled true) (max-concurrent-requests 8))
+    (digitalocean (enabled true)))
+  (node-pools
+    (masters (name "masters") (provider "aws") (count 3) (roles master etcd))))
+`)).LoadStrict()
+	require.NoError(t, err)
+	assert.Equal(t, 8, cfg.ProviderMaxConcurrentRequests("aws"))
+	assert.Equal(t, 0, cfg.ProviderMaxConcurrentRequests("digitalocean"))
+	assert.Equal(t, 0, cfg.ProviderMaxConcurrentRequests("linode"))
+
+	expr, err := NewLispParser(`(cluster
+  (metadata (name "prod"))
+  (providers (aws (enabled true) (max-concurrent-requests -2))))
+`).Parse()
+	require.NoError(t, err)
+	diagnostics := ValidateLispSchema(expr)
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, "cluster.providers.aws.max-concurrent-requests", diagnostics[0].Path)
+	assert.Equal(t, "max-concurrent-requests must be an integer >= 0", diagnostics[0].Message)
+}
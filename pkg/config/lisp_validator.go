@@ -511,6 +511,12 @@ func (v *ConfigValidator) validateSecurity(cfg *ClusterConfig, result *Validatio
 				"add allowed-cidrs to restrict bastion access")
 		}
 	}
+
+	// Pod Security Standards profiles
+	if err := cfg.Security.PodSecurity.Validate(); err != nil {
+		v.addError(result, path+".pod-security", "", err.Error(), nil,
+			"use (enforce \"baseline\") with privileged, baseline or restricted")
+	}
 }
 
 // validateNodes validates individual node configurations
@@ -584,6 +590,18 @@ func (v *ConfigValidator) validateNodePools(cfg *ClusterConfig, result *Validati
 		}
 
 		// Validate autoscaling configuration
+		if pool.AutoScaling {
+			for _, role := range pool.Roles {
+				if role == "controlplane" || role == "master" || role == "server" || role == "etcd" {
+					v.addError(result, poolPath, "auto-scaling", "control plane pools cannot autoscale", role,
+						"move the masters to a pool without auto-scaling")
+					break
+				}
+			}
+			if pool.MaxCount > 0 && pool.MinCount > pool.MaxCount {
+				v.addError(result, poolPath, "min-count", "min-count cannot be greater than max-count", pool.MinCount, "")
+			}
+		}
 		if pool.AutoScaling || pool.AutoScalingConfig != nil {
 			if pool.AutoScalingConfig != nil {
 				if pool.AutoScalingConfig.MinNodes > pool.AutoScalingConfig.MaxNodes {
@@ -650,6 +668,12 @@ func (v *ConfigValidator) validateKubernetes(cfg *ClusterConfig, result *Validat
 	if cfg.Kubernetes.RKE2 != nil {
 		v.validateRKE2Config(cfg.Kubernetes.RKE2, result)
 	}
+
+	// Log rotation and eviction thresholds
+	if err := cfg.Kubernetes.Kubelet.Validate(); err != nil {
+		v.addError(result, path, "kubelet", err.Error(), nil,
+			"see (kubelet (log-rotation ...) (eviction ...)) in the config reference")
+	}
 }
 
 func (v *ConfigValidator) validateRKE2Config(rke2 *RKE2Config, result *ValidationResult) {
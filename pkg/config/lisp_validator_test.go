@@ -456,6 +456,27 @@ func TestValidateNodePools(t *testing.T) {
 		assert.True(t, found, "should have error for min > max")
 	})
 
+	t.Run("autoscaling control plane pool", func(t *testing.T) {
+		cfg := &ClusterConfig{
+			Metadata: Metadata{Name: "test"},
+			Providers: ProvidersConfig{
+				AWS: &AWSProvider{Enabled: true, Region: "us-east-1"},
+			},
+			NodePools: map[string]NodePool{
+				"masters": {Provider: "aws", Count: 3, Roles: []string{"master"}, AutoScaling: true, MinCount: 3, MaxCount: 5},
+				"workers": {Provider: "aws", Count: 2, Roles: []string{"worker"}, AutoScaling: true, MinCount: 4, MaxCount: 2},
+			},
+		}
+		result := v.Validate(cfg)
+
+		var messages []string
+		for _, issue := range result.Errors() {
+			messages = append(messages, issue.Message)
+		}
+		assert.Contains(t, messages, "control plane pools cannot autoscale")
+		assert.Contains(t, messages, "min-count cannot be greater than max-count")
+	})
+
 	t.Run("spot instance for control plane warning", func(t *testing.T) {
 		cfg := &ClusterConfig{
 			Metadata: Metadata{Name: "test"},
@@ -0,0 +1,63 @@
+package config
+
+import "strings"
+
+// Node roles a load balancer can target through LoadBalancerConfig.Target
+const (
+	LoadBalancerTargetMasters = "masters"
+	LoadBalancerTargetWorkers = "workers"
+)
+
+// How a load balancer is exposed, set through LoadBalancerConfig.Type. Any
+// type other than internal is external.
+const (
+	LoadBalancerExternal = "external"
+	LoadBalancerInternal = "internal"
+)
+
+// apiServerPort is the port the Kubernetes API server listens on
+const apiServerPort = 6443
+
+// AllLoadBalancers returns the load balancers to create: LoadBalancer when it
+// is configured, followed by LoadBalancers
+func (c *ClusterConfig) AllLoadBalancers() []LoadBalancerConfig {
+	var lbs []LoadBalancerConfig
+	if c.LoadBalancer.Name != "" || c.LoadBalancer.Provider != "" {
+		lbs = append(lbs, c.LoadBalancer)
+	}
+	return append(lbs, c.LoadBalancers...)
+}
+
+// IsAPIServer reports whether the load balancer fronts the Kubernetes API:
+// its type is "api", it forwards port 6443, or it has no ports, in which case
+// providers default to the API server port
+func (lb LoadBalancerConfig) IsAPIServer() bool {
+	if strings.EqualFold(lb.Type, "api") || len(lb.Ports) == 0 {
+		return true
+	}
+	for _, port := range lb.Ports {
+		if port.Port == apiServerPort {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInternal reports whether the load balancer only serves clients inside
+// the cluster's networks rather than the internet
+func (lb LoadBalancerConfig) IsInternal() bool {
+	return strings.EqualFold(lb.Type, LoadBalancerInternal)
+}
+
+// TargetRole returns the nodes the load balancer sends traffic to: the
+// configured target, otherwise masters for the API server and workers for
+// anything else
+func (lb LoadBalancerConfig) TargetRole() string {
+	if lb.Target != "" {
+		return strings.ToLower(lb.Target)
+	}
+	if lb.IsAPIServer() {
+		return LoadBalancerTargetMasters
+	}
+	return LoadBalancerTargetWorkers
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAllLoadBalancers(t *testing.T) {
+	if lbs := (&ClusterConfig{}).AllLoadBalancers(); len(lbs) != 0 {
+		t.Errorf("AllLoadBalancers() without any configured = %+v, want none", lbs)
+	}
+
+	cfg := &ClusterConfig{
+		LoadBalancer: LoadBalancerConfig{Name: "api", Provider: "digitalocean"},
+		LoadBalancers: []LoadBalancerConfig{
+			{Name: "ingress", Provider: "digitalocean"},
+			{Name: "internal", Provider: "linode"},
+		},
+	}
+	lbs := cfg.AllLoadBalancers()
+	if len(lbs) != 3 {
+		t.Fatalf("AllLoadBalancers() = %d load balancers, want 3", len(lbs))
+	}
+	for i, want := range []string{"api", "ingress", "internal"} {
+		if lbs[i].Name != want {
+			t.Errorf("AllLoadBalancers()[%d] = %q, want %q", i, lbs[i].Name, want)
+		}
+	}
+
+	cfg.LoadBalancer = LoadBalancerConfig{}
+	if lbs := cfg.AllLoadBalancers(); len(lbs) != 2 || lbs[0].Name != "ingress" {
+		t.Errorf("AllLoadBalancers() without the singular one = %+v", lbs)
+	}
+}
+
+func TestLoadBalancerConfig_TargetRole(t *testing.T) {
+	tests := []struct {
+		name string
+		lb   LoadBalancerConfig
+		want string
+	}{
+		{"no ports defaults to the API server", LoadBalancerConfig{}, LoadBalancerTargetMasters},
+		{"API server port", LoadBalancerConfig{Ports: []PortConfig{{Port: 6443, TargetPort: 6443}}}, LoadBalancerTargetMasters},
+		{"api type", LoadBalancerConfig{Type: "API", Ports: []PortConfig{{Port: 443, TargetPort: 6443}}}, LoadBalancerTargetMasters},
+		{"ingress ports", LoadBalancerConfig{Ports: []PortConfig{{Port: 80}, {Port: 443}}}, LoadBalancerTargetWorkers},
+		{"explicit target", LoadBalancerConfig{Target: "Masters", Ports: []PortConfig{{Port: 443}}}, LoadBalancerTargetMasters},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lb.TargetRole(); got != tt.want {
+				t.Errorf("TargetRole() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBalancerConfig_IsInternal(t *testing.T) {
+	for _, tt := range []struct {
+		lbType string
+		want   bool
+	}{
+		{"", false},
+		{"api", false},
+		{"external", false},
+		{"internal", true},
+		{"Internal", true},
+	} {
+		if got := (LoadBalancerConfig{Type: tt.lbType}).IsInternal(); got != tt.want {
+			t.Errorf("IsInternal() with type %q = %v, want %v", tt.lbType, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFromLisp_LoadBalancers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	content := `(cluster
+  (metadata (name "test"))
+  (load-balancer (name "api") (provider "hetzner"))
+  (load-balancers
+    (ingress (provider "hetzner") (target "workers"))
+    (internal (name "internal-lb") (provider "hetzner"))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromLisp(path)
+	if err != nil {
+		t.Fatalf("LoadFromLisp() error = %v", err)
+	}
+	if cfg.LoadBalancer.Name != "api" {
+		t.Errorf("loadBalancer name = %q, want api", cfg.LoadBalancer.Name)
+	}
+	if len(cfg.LoadBalancers) != 2 {
+		t.Fatalf("loadBalancers = %+v, want 2", cfg.LoadBalancers)
+	}
+	if lb := cfg.LoadBalancers[0]; lb.Name != "ingress" || lb.Target != LoadBalancerTargetWorkers {
+		t.Errorf("loadBalancers[0] = %+v, want ingress targeting workers", lb)
+	}
+	if lb := cfg.LoadBalancers[1]; lb.Name != "internal-lb" {
+		t.Errorf("loadBalancers[1] name = %q, want internal-lb", lb.Name)
+	}
+}
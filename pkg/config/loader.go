@@ -1,22 +1,27 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	pulumiconfig "github.com/pulumi/pulumi/sdk/v3/go/pulumi/config"
-	"gopkg.in/yaml.v3"
 )
 
 // Loader handles configuration loading and validation
 type Loader struct {
 	configPath string
+	format     ConfigFormat
 	config     *ClusterConfig
 	overrides  map[string]interface{}
 	validators []Validator
+
+	// Watch settings
+	watchDebounce time.Duration
+	watchError    func(error)
 }
 
 // Validator interface for config validation
@@ -24,26 +29,33 @@ type Validator interface {
 	Validate(config *ClusterConfig) error
 }
 
-// NewLoader creates a new configuration loader
+// NewLoader creates a new configuration loader for a Lisp file
 func NewLoader(configPath string) *Loader {
 	return &Loader{
 		configPath: configPath,
+		format:     FormatLisp,
 		overrides:  make(map[string]interface{}),
 		validators: []Validator{},
 	}
 }
 
-// Load loads the configuration from a Lisp file
+// Load loads the configuration file in the loader's format
 func (l *Loader) Load() (*ClusterConfig, error) {
 	// Check if config file exists
 	if _, err := os.Stat(l.configPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("configuration file not found: %s", l.configPath)
 	}
 
-	// Load from Lisp
-	config, err := LoadFromLisp(l.configPath)
+	if l.format == "" {
+		l.format = FormatLisp
+	}
+
+	config, err := ReadConfigFile(l.configPath, l.format)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Lisp configuration: %w", err)
+		if l.format == FormatLisp {
+			return nil, fmt.Errorf("failed to parse Lisp configuration: %w", err)
+		}
+		return nil, err
 	}
 
 	// Apply environment variable overrides
@@ -421,6 +433,95 @@ func applyDefaults(config *ClusterConfig) {
 	}
 }
 
+// ProviderDefaults returns the default region and size configured for a provider.
+// Azure and Hetzner report their location as the region.
+func (c *ClusterConfig) ProviderDefaults(providerName string) (region, size string) {
+	p := c.Providers
+	switch providerName {
+	case "digitalocean":
+		if p.DigitalOcean != nil {
+			return p.DigitalOcean.Region, p.DigitalOcean.DefaultSize
+		}
+	case "linode":
+		if p.Linode != nil {
+			return p.Linode.Region, p.Linode.DefaultSize
+		}
+	case "aws":
+		if p.AWS != nil {
+			return p.AWS.Region, p.AWS.DefaultSize
+		}
+	case "azure":
+		if p.Azure != nil {
+			return p.Azure.Location, p.Azure.DefaultSize
+		}
+	case "gcp":
+		if p.GCP != nil {
+			return p.GCP.Region, p.GCP.DefaultSize
+		}
+	case "hetzner":
+		if p.Hetzner != nil {
+			return p.Hetzner.Location, p.Hetzner.DefaultSize
+		}
+	}
+	return "", ""
+}
+
+// ProviderMaxConcurrentRequests returns how many create calls the config
+// allows in flight to a provider at once, or 0 when it leaves that unset
+func (c *ClusterConfig) ProviderMaxConcurrentRequests(providerName string) int {
+	p := c.Providers
+	switch providerName {
+	case "digitalocean":
+		if p.DigitalOcean != nil {
+			return p.DigitalOcean.MaxConcurrentRequests
+		}
+	case "linode":
+		if p.Linode != nil {
+			return p.Linode.MaxConcurrentRequests
+		}
+	case "aws":
+		if p.AWS != nil {
+			return p.AWS.MaxConcurrentRequests
+		}
+	case "azure":
+		if p.Azure != nil {
+			return p.Azure.MaxConcurrentRequests
+		}
+	case "gcp":
+		if p.GCP != nil {
+			return p.GCP.MaxConcurrentRequests
+		}
+	case "hetzner":
+		if p.Hetzner != nil {
+			return p.Hetzner.MaxConcurrentRequests
+		}
+	}
+	return 0
+}
+
+// DefaultSSHUser returns the login user of the stock Ubuntu images each
+// provider deploys. Unknown providers fall back to root.
+func DefaultSSHUser(providerName string) string {
+	switch providerName {
+	case "aws", "gcp":
+		return "ubuntu"
+	case "azure":
+		return "azureuser"
+	case "digitalocean", "linode", "hetzner":
+		return "root"
+	default:
+		return "root"
+	}
+}
+
+// SSHUserFor returns override when set, otherwise the provider's default user
+func SSHUserFor(providerName, override string) string {
+	if override != "" {
+		return override
+	}
+	return DefaultSSHUser(providerName)
+}
+
 // ValidateConfigLegacy validates the cluster configuration (legacy interface)
 // Deprecated: Use ValidateConfig which returns *ValidationResult for detailed validation
 func ValidateConfigLegacy(cfg *ClusterConfig) error {
@@ -440,24 +541,14 @@ func (l *Loader) SaveConfig(path string) error {
 		return fmt.Errorf("no configuration loaded")
 	}
 
-	ext := strings.ToLower(path[strings.LastIndex(path, "."):])
-
-	var data []byte
-	var err error
+	format, ok := formatFromExtension(path)
+	if !ok {
+		return fmt.Errorf("unsupported format: %s", filepath.Ext(path))
+	}
 
-	switch ext {
-	case ".yaml", ".yml":
-		data, err = yaml.Marshal(l.config)
-		if err != nil {
-			return fmt.Errorf("failed to marshal YAML: %w", err)
-		}
-	case ".json":
-		data, err = json.MarshalIndent(l.config, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
-		}
-	default:
-		return fmt.Errorf("unsupported format: %s", ext)
+	data, err := MarshalConfig(l.config, format)
+	if err != nil {
+		return err
 	}
 
 	if err := os.WriteFile(path, data, 0644); err != nil {
@@ -538,3 +538,55 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+func TestDefaultSSHUser(t *testing.T) {
+	tests := map[string]string{
+		"aws":          "ubuntu",
+		"gcp":          "ubuntu",
+		"azure":        "azureuser",
+		"digitalocean": "root",
+		"linode":       "root",
+		"hetzner":      "root",
+		"unknown":      "root",
+	}
+	for provider, want := range tests {
+		if got := DefaultSSHUser(provider); got != want {
+			t.Errorf("DefaultSSHUser(%q) = %q, want %q", provider, got, want)
+		}
+	}
+
+	if got := SSHUserFor("gcp", "debian"); got != "debian" {
+		t.Errorf("SSHUserFor() with override = %q, want debian", got)
+	}
+	if got := SSHUserFor("azure", ""); got != "azureuser" {
+		t.Errorf("SSHUserFor() without override = %q, want azureuser", got)
+	}
+}
+
+func TestLoadFromLisp_SSHUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	content := `(cluster
+  (metadata (name "test"))
+  (nodes
+    (node (name "master-1") (provider "gcp") (roles master) (ssh-user "debian")))
+  (node-pools
+    (workers
+      (name "workers")
+      (provider "hetzner")
+      (count 2)
+      (ssh-user "admin"))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromLisp(path)
+	if err != nil {
+		t.Fatalf("LoadFromLisp() error = %v", err)
+	}
+	if len(cfg.Nodes) != 1 || cfg.Nodes[0].SSHUser != "debian" {
+		t.Errorf("node sshUser = %+v, want debian", cfg.Nodes)
+	}
+	if got := cfg.NodePools["workers"].SSHUser; got != "admin" {
+		t.Errorf("pool sshUser = %q, want admin", got)
+	}
+}
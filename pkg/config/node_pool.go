@@ -0,0 +1,26 @@
+package config
+
+import "fmt"
+
+// RegionFor returns the region of the pool's i-th node: Regions round-robin
+// when set, otherwise Region
+func (p *NodePool) RegionFor(i int) string {
+	if len(p.Regions) == 0 {
+		return p.Region
+	}
+	return p.Regions[i%len(p.Regions)]
+}
+
+// NodeName returns the name of the pool's i-th node, <pool>-1..<pool>-N
+func (p *NodePool) NodeName(i int) string {
+	return fmt.Sprintf("%s-%d", p.Name, i+1)
+}
+
+// WireGuardIPFor returns the WireGuard IP allocated to the pool's i-th node,
+// or "" when none was
+func (p *NodePool) WireGuardIPFor(i int) string {
+	if i < len(p.WireGuardIPs) {
+		return p.WireGuardIPs[i]
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNodePool_RegionFor(t *testing.T) {
+	single := &NodePool{Region: "nyc3"}
+	for i := 0; i < 3; i++ {
+		if got := single.RegionFor(i); got != "nyc3" {
+			t.Errorf("RegionFor(%d) without regions = %q, want nyc3", i, got)
+		}
+	}
+
+	spread := &NodePool{Region: "nyc3", Regions: []string{"nyc1", "sfo3", "ams3"}}
+	for i, want := range []string{"nyc1", "sfo3", "ams3", "nyc1", "sfo3"} {
+		if got := spread.RegionFor(i); got != want {
+			t.Errorf("RegionFor(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestNodePool_NodeNameAndWireGuardIPFor(t *testing.T) {
+	pool := &NodePool{Name: "workers", WireGuardIPs: []string{"10.8.0.2", "10.8.0.3"}}
+	if got := pool.NodeName(0); got != "workers-1" {
+		t.Errorf("NodeName(0) = %q, want workers-1", got)
+	}
+	for i, want := range []string{"10.8.0.2", "10.8.0.3", ""} {
+		if got := pool.WireGuardIPFor(i); got != want {
+			t.Errorf("WireGuardIPFor(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestLoadFromLisp_NodePoolRegions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	content := `(cluster
+  (metadata (name "test"))
+  (node-pools
+    (workers (name "workers") (provider "digitalocean") (count 3) (roles worker)
+      (regions "nyc1" "sfo3"))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromLisp(path)
+	if err != nil {
+		t.Fatalf("LoadFromLisp() error = %v", err)
+	}
+	pool := cfg.NodePools["workers"]
+	if len(pool.Regions) != 2 || pool.Regions[0] != "nyc1" || pool.Regions[1] != "sfo3" {
+		t.Errorf("regions = %v, want [nyc1 sfo3]", pool.Regions)
+	}
+}
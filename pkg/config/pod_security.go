@@ -0,0 +1,62 @@
+package config
+
+import "fmt"
+
+// Pod Security Standards profiles, from least to most restrictive
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// PodSecurityProfiles are the profiles Pod Security Admission enforces for
+// each mode. An empty profile leaves the mode unset.
+type PodSecurityProfiles struct {
+	Enforce string
+	Audit   string
+	Warn    string
+}
+
+// IsEmpty reports whether no mode has a profile
+func (p PodSecurityProfiles) IsEmpty() bool {
+	return p.Enforce == "" && p.Audit == "" && p.Warn == ""
+}
+
+// EffectiveProfiles returns the profile for each mode. PolicyLevel is the
+// default for any mode without its own profile.
+func (p *PodSecurityConfig) EffectiveProfiles() PodSecurityProfiles {
+	return PodSecurityProfiles{
+		Enforce: firstProfile(p.EnforceProfile, p.PolicyLevel),
+		Audit:   firstProfile(p.AuditProfile, p.PolicyLevel),
+		Warn:    firstProfile(p.WarnProfile, p.PolicyLevel),
+	}
+}
+
+// Validate checks that every profile is a Pod Security Standards profile
+func (p *PodSecurityConfig) Validate() error {
+	for _, field := range []struct {
+		name    string
+		profile string
+	}{
+		{"policyLevel", p.PolicyLevel},
+		{"enforceProfile", p.EnforceProfile},
+		{"auditProfile", p.AuditProfile},
+		{"warnProfile", p.WarnProfile},
+	} {
+		switch field.profile {
+		case "", PodSecurityPrivileged, PodSecurityBaseline, PodSecurityRestricted:
+		default:
+			return fmt.Errorf("%s %q is invalid (use privileged, baseline or restricted)", field.name, field.profile)
+		}
+	}
+	return nil
+}
+
+func firstProfile(profiles ...string) string {
+	for _, profile := range profiles {
+		if profile != "" {
+			return profile
+		}
+	}
+	return ""
+}
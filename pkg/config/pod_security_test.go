@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPodSecurityConfig_EffectiveProfiles(t *testing.T) {
+	p := &PodSecurityConfig{PolicyLevel: "baseline", EnforceProfile: "restricted"}
+
+	got := p.EffectiveProfiles()
+	want := PodSecurityProfiles{Enforce: "restricted", Audit: "baseline", Warn: "baseline"}
+	if got != want {
+		t.Errorf("EffectiveProfiles() = %+v, want %+v", got, want)
+	}
+
+	if !(&PodSecurityConfig{}).EffectiveProfiles().IsEmpty() {
+		t.Error("EffectiveProfiles() of an empty config should be empty")
+	}
+}
+
+func TestPodSecurityConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     PodSecurityConfig
+		wantErr string
+	}{
+		{"empty", PodSecurityConfig{}, ""},
+		{"all profiles", PodSecurityConfig{PolicyLevel: "baseline", EnforceProfile: "restricted", AuditProfile: "privileged", WarnProfile: "restricted"}, ""},
+		{"bad policy level", PodSecurityConfig{PolicyLevel: "strict"}, `policyLevel "strict" is invalid`},
+		{"bad enforce", PodSecurityConfig{EnforceProfile: "Restricted"}, "enforceProfile"},
+		{"bad warn", PodSecurityConfig{WarnProfile: "none"}, "warnProfile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFromLisp_PodSecurityConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cluster.lisp")
+
+	content := `(cluster
+  (metadata (name "test"))
+  (security
+    (pod-security
+      (policy-level "baseline")
+      (enforce "restricted")
+      (exempt-namespaces "ci" "legacy"))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromLisp(path)
+	if err != nil {
+		t.Fatalf("LoadFromLisp() error = %v", err)
+	}
+
+	podSecurity := cfg.Security.PodSecurity
+	if podSecurity.PolicyLevel != "baseline" || podSecurity.EnforceProfile != "restricted" ||
+		len(podSecurity.ExemptNamespaces) != 2 || podSecurity.ExemptNamespaces[1] != "legacy" {
+		t.Errorf("PodSecurity = %+v", podSecurity)
+	}
+
+	invalid := strings.Replace(content, `(enforce "restricted")`, `(enforce "strict")`, 1)
+	if err := os.WriteFile(path, []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromLisp(path); err == nil || !strings.Contains(err.Error(), `enforceProfile "strict" is invalid`) {
+		t.Errorf("LoadFromLisp() error = %v, want enforceProfile error", err)
+	}
+}
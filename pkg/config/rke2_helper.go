@@ -139,11 +139,14 @@ func BuildRKE2ServerConfig(cfg *RKE2Config, nodeIP, nodeName string, isFirstMast
 		}
 	}
 
+	// Log rotation and eviction thresholds
+	builder.WriteString(BuildKubeletArgsConfig(&k8sConfig.Kubelet))
+
 	return builder.String()
 }
 
 // BuildRKE2AgentConfig generates the RKE2 agent (worker) config file content
-func BuildRKE2AgentConfig(cfg *RKE2Config, nodeIP, nodeName, serverIP string) string {
+func BuildRKE2AgentConfig(cfg *RKE2Config, nodeIP, nodeName, serverIP string, k8sConfig *KubernetesConfig) string {
 	var builder strings.Builder
 
 	// Basic configuration
@@ -196,6 +199,13 @@ func BuildRKE2AgentConfig(cfg *RKE2Config, nodeIP, nodeName, serverIP string) st
 		}
 	}
 
+	// Log rotation and eviction thresholds
+	var kubelet *KubeletConfig
+	if k8sConfig != nil {
+		kubelet = &k8sConfig.Kubelet
+	}
+	builder.WriteString(BuildKubeletArgsConfig(kubelet))
+
 	return builder.String()
 }
 
@@ -278,7 +278,7 @@ func TestBuildRKE2AgentConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			config := BuildRKE2AgentConfig(tt.cfg, tt.nodeIP, tt.nodeName, tt.serverIP)
+			config := BuildRKE2AgentConfig(tt.cfg, tt.nodeIP, tt.nodeName, tt.serverIP, nil)
 
 			for _, want := range tt.wantContains {
 				if !strings.Contains(config, want) {
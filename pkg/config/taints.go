@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TaintEffects are the effects Kubernetes accepts on a node taint
+var TaintEffects = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+// Validate checks the taint has a key and a known effect
+func (t TaintConfig) Validate() error {
+	if t.Key == "" {
+		return fmt.Errorf("taint key is required")
+	}
+	for _, effect := range TaintEffects {
+		if t.Effect == effect {
+			return nil
+		}
+	}
+	return fmt.Errorf("taint %s has effect %q, must be one of %s", t.Key, t.Effect, strings.Join(TaintEffects, ", "))
+}
+
+// String formats the taint as kubectl taint takes it: key=value:effect, or
+// key:effect when it has no value
+func (t TaintConfig) String() string {
+	if t.Value == "" {
+		return fmt.Sprintf("%s:%s", t.Key, t.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect)
+}
+
+// validateTaints checks the taints of every node and node pool
+func validateTaints(cfg *ClusterConfig) error {
+	for _, node := range cfg.Nodes {
+		for _, taint := range node.Taints {
+			if err := taint.Validate(); err != nil {
+				return fmt.Errorf("node %s: %w", node.Name, err)
+			}
+		}
+	}
+
+	poolNames := make([]string, 0, len(cfg.NodePools))
+	for name := range cfg.NodePools {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+	for _, name := range poolNames {
+		for _, taint := range cfg.NodePools[name].Taints {
+			if err := taint.Validate(); err != nil {
+				return fmt.Errorf("node pool %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
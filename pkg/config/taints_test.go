@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTaintConfig_Validate(t *testing.T) {
+	for _, effect := range TaintEffects {
+		if err := (TaintConfig{Key: "dedicated", Effect: effect}).Validate(); err != nil {
+			t.Errorf("effect %s: unexpected error %v", effect, err)
+		}
+	}
+
+	if err := (TaintConfig{Key: "dedicated", Effect: "NoScheduleX"}).Validate(); err == nil || !strings.Contains(err.Error(), "NoSchedule, PreferNoSchedule, NoExecute") {
+		t.Errorf("expected an invalid effect error, got %v", err)
+	}
+	if err := (TaintConfig{Effect: "NoSchedule"}).Validate(); err == nil {
+		t.Error("expected an error for a taint without key")
+	}
+}
+
+func TestTaintConfig_String(t *testing.T) {
+	if got := (TaintConfig{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}).String(); got != "dedicated=gpu:NoSchedule" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (TaintConfig{Key: "spot", Effect: "NoExecute"}).String(); got != "spot:NoExecute" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestLoadFromLisp_InvalidTaintEffect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	content := `(cluster
+  (metadata (name "test"))
+  (node-pools
+    (gpu
+      (name "gpu")
+      (provider "aws")
+      (count 1)
+      (roles worker)
+      (taints (taint (key "nvidia.com/gpu") (effect "NoSchedul"))))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFromLisp(path)
+	if err == nil || !strings.Contains(err.Error(), "node pool gpu: taint nvidia.com/gpu has effect \"NoSchedul\"") {
+		t.Fatalf("expected an invalid taint error, got %v", err)
+	}
+}
+
+func TestLoadFromLisp_NamedTaints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cluster.lisp")
+	content := `(cluster
+  (metadata (name "test"))
+  (node-pools
+    (gpu-workers
+      (name "gpu-workers")
+      (provider "aws")
+      (count 2)
+      (roles worker)
+      (taints
+        (gpu
+          (key "nvidia.com/gpu")
+          (value "true")
+          (effect "NoSchedule"))
+        (taint "dedicated" "NoExecute")))))`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromLisp(path)
+	if err != nil {
+		t.Fatalf("LoadFromLisp() error = %v", err)
+	}
+
+	taints := cfg.NodePools["gpu-workers"].Taints
+	want := []TaintConfig{
+		{Key: "nvidia.com/gpu", Value: "true", Effect: "NoSchedule"},
+		{Key: "dedicated", Effect: "NoExecute"},
+	}
+	if len(taints) != len(want) {
+		t.Fatalf("Taints = %+v, want %+v", taints, want)
+	}
+	for i := range want {
+		if taints[i] != want[i] {
+			t.Errorf("Taints[%d] = %+v, want %+v", i, taints[i], want[i])
+		}
+	}
+}
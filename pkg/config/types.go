@@ -20,12 +20,36 @@ type ClusterConfig struct {
 	LoadBalancer LoadBalancerConfig  `yaml:"loadBalancer" json:"loadBalancer"`
 	Addons       AddonsConfig        `yaml:"addons" json:"addons"`
 
+	// LoadBalancers are created alongside LoadBalancer, so the API server
+	// and ingress can each get their own
+	LoadBalancers []LoadBalancerConfig `yaml:"loadBalancers,omitempty" json:"loadBalancers,omitempty"`
+
 	// Advanced configurations
 	Upgrade        *UpgradeConfig        `yaml:"upgrade,omitempty" json:"upgrade,omitempty"`
 	Backup         *BackupConfig         `yaml:"backup,omitempty" json:"backup,omitempty"`
 	Hooks          *HooksConfig          `yaml:"hooks,omitempty" json:"hooks,omitempty"`
 	CostControl    *CostControlConfig    `yaml:"costControl,omitempty" json:"costControl,omitempty"`
 	PrivateCluster *PrivateClusterConfig `yaml:"privateCluster,omitempty" json:"privateCluster,omitempty"`
+
+	// MaxDeployConcurrency caps how many node pools deploy at once
+	// (default: one per distinct provider)
+	MaxDeployConcurrency int `yaml:"maxDeployConcurrency,omitempty" json:"maxDeployConcurrency,omitempty"`
+
+	// RollbackOnFailure destroys the nodes already created when node
+	// deployment fails, instead of leaving them for manual cleanup
+	RollbackOnFailure bool `yaml:"rollbackOnFailure,omitempty" json:"rollbackOnFailure,omitempty"`
+
+	// AllowEmptyCluster lets a config without nodes deploy nothing and
+	// succeed; otherwise such a deploy fails before creating anything
+	AllowEmptyCluster bool `yaml:"allowEmptyCluster,omitempty" json:"allowEmptyCluster,omitempty"`
+
+	// NodeReadyTimeout is how many seconds to wait for every node to answer
+	// on SSH before configuring the VPN (default: 600)
+	NodeReadyTimeout int `yaml:"nodeReadyTimeout,omitempty" json:"nodeReadyTimeout,omitempty"`
+
+	// NodeReadyPollInterval is the initial delay in seconds between SSH
+	// probes of a node; it backs off up to eight times this (default: 5)
+	NodeReadyPollInterval int `yaml:"nodeReadyPollInterval,omitempty" json:"nodeReadyPollInterval,omitempty"`
 }
 
 // AddonsConfig defines cluster addons configuration
@@ -98,89 +122,101 @@ type ProvidersConfig struct {
 
 // DigitalOceanProvider configuration
 type DigitalOceanProvider struct {
-	Enabled      bool                   `yaml:"enabled" json:"enabled"`
-	Token        string                 `yaml:"token" json:"token"`
-	Region       string                 `yaml:"region" json:"region"`
-	VPC          *VPCConfig             `yaml:"vpc,omitempty" json:"vpc,omitempty"`
-	SSHKeys      []string               `yaml:"sshKeys" json:"sshKeys"`
-	SSHPublicKey interface{}            `yaml:"-" json:"-"` // Set programmatically
-	Tags         []string               `yaml:"tags" json:"tags"`
-	Monitoring   bool                   `yaml:"monitoring" json:"monitoring"`
-	IPv6         bool                   `yaml:"ipv6" json:"ipv6"`
-	UserData     string                 `yaml:"userData" json:"userData"`
-	BackupPolicy *BackupPolicy          `yaml:"backupPolicy,omitempty" json:"backupPolicy,omitempty"`
-	Firewall     *FirewallConfig        `yaml:"firewall,omitempty" json:"firewall,omitempty"`
-	Custom       map[string]interface{} `yaml:"custom" json:"custom"`
+	Enabled               bool                   `yaml:"enabled" json:"enabled"`
+	Token                 string                 `yaml:"token" json:"token"`
+	Region                string                 `yaml:"region" json:"region"`
+	DefaultSize           string                 `yaml:"defaultSize" json:"defaultSize"`                                         // Size used by pools that omit one
+	MaxConcurrentRequests int                    `yaml:"maxConcurrentRequests,omitempty" json:"maxConcurrentRequests,omitempty"` // Create calls in flight at once; 0 uses the built-in default
+	VPC                   *VPCConfig             `yaml:"vpc,omitempty" json:"vpc,omitempty"`
+	SSHKeys               []string               `yaml:"sshKeys" json:"sshKeys"`
+	SSHPublicKey          interface{}            `yaml:"-" json:"-"` // Set programmatically
+	Tags                  []string               `yaml:"tags" json:"tags"`
+	Monitoring            bool                   `yaml:"monitoring" json:"monitoring"`
+	IPv6                  bool                   `yaml:"ipv6" json:"ipv6"`
+	UserData              string                 `yaml:"userData" json:"userData"`
+	BackupPolicy          *BackupPolicy          `yaml:"backupPolicy,omitempty" json:"backupPolicy,omitempty"`
+	Firewall              *FirewallConfig        `yaml:"firewall,omitempty" json:"firewall,omitempty"`
+	Custom                map[string]interface{} `yaml:"custom" json:"custom"`
 }
 
 // LinodeProvider configuration
 type LinodeProvider struct {
-	Enabled        bool                   `yaml:"enabled" json:"enabled"`
-	Token          string                 `yaml:"token" json:"token"`
-	Region         string                 `yaml:"region" json:"region"`
-	RootPassword   string                 `yaml:"rootPassword" json:"rootPassword"`
-	PrivateIP      bool                   `yaml:"privateIp" json:"privateIp"`
-	AuthorizedKeys []string               `yaml:"authorizedKeys" json:"authorizedKeys"`
-	SSHPublicKey   interface{}            `yaml:"-" json:"-"` // Set programmatically
-	Tags           []string               `yaml:"tags" json:"tags"`
-	VPC            *VPCConfig             `yaml:"vpc,omitempty" json:"vpc,omitempty"`
-	BackupPolicy   *BackupPolicy          `yaml:"backupPolicy,omitempty" json:"backupPolicy,omitempty"`
-	Firewall       *FirewallConfig        `yaml:"firewall,omitempty" json:"firewall,omitempty"`
-	Custom         map[string]interface{} `yaml:"custom" json:"custom"`
+	Enabled               bool                   `yaml:"enabled" json:"enabled"`
+	Token                 string                 `yaml:"token" json:"token"`
+	Region                string                 `yaml:"region" json:"region"`
+	DefaultSize           string                 `yaml:"defaultSize" json:"defaultSize"`                                         // Size used by pools that omit one
+	MaxConcurrentRequests int                    `yaml:"maxConcurrentRequests,omitempty" json:"maxConcurrentRequests,omitempty"` // Create calls in flight at once; 0 uses the built-in default
+	RootPassword          string                 `yaml:"rootPassword" json:"rootPassword"`
+	PrivateIP             bool                   `yaml:"privateIp" json:"privateIp"`
+	AuthorizedKeys        []string               `yaml:"authorizedKeys" json:"authorizedKeys"`
+	SSHPublicKey          interface{}            `yaml:"-" json:"-"` // Set programmatically
+	Tags                  []string               `yaml:"tags" json:"tags"`
+	VPC                   *VPCConfig             `yaml:"vpc,omitempty" json:"vpc,omitempty"`
+	BackupPolicy          *BackupPolicy          `yaml:"backupPolicy,omitempty" json:"backupPolicy,omitempty"`
+	Firewall              *FirewallConfig        `yaml:"firewall,omitempty" json:"firewall,omitempty"`
+	Custom                map[string]interface{} `yaml:"custom" json:"custom"`
 }
 
 // AWSProvider configuration
 type AWSProvider struct {
-	Enabled         bool                   `yaml:"enabled" json:"enabled"`
-	AccessKeyID     string                 `yaml:"accessKeyId" json:"accessKeyId"`
-	SecretAccessKey string                 `yaml:"secretAccessKey" json:"secretAccessKey"`
-	Region          string                 `yaml:"region" json:"region"`
-	VPC             *VPCConfig             `yaml:"vpc,omitempty" json:"vpc,omitempty"`
-	SecurityGroups  []string               `yaml:"securityGroups" json:"securityGroups"`
-	KeyPair         string                 `yaml:"keyPair" json:"keyPair"`
-	IAMRole         string                 `yaml:"iamRole" json:"iamRole"`
-	Custom          map[string]interface{} `yaml:"custom" json:"custom"`
+	Enabled               bool                   `yaml:"enabled" json:"enabled"`
+	AccessKeyID           string                 `yaml:"accessKeyId" json:"accessKeyId"`
+	SecretAccessKey       string                 `yaml:"secretAccessKey" json:"secretAccessKey"`
+	Region                string                 `yaml:"region" json:"region"`
+	DefaultSize           string                 `yaml:"defaultSize" json:"defaultSize"`                                         // Size used by pools that omit one
+	MaxConcurrentRequests int                    `yaml:"maxConcurrentRequests,omitempty" json:"maxConcurrentRequests,omitempty"` // Create calls in flight at once; 0 uses the built-in default
+	VPC                   *VPCConfig             `yaml:"vpc,omitempty" json:"vpc,omitempty"`
+	SecurityGroups        []string               `yaml:"securityGroups" json:"securityGroups"`
+	KeyPair               string                 `yaml:"keyPair" json:"keyPair"`
+	IAMRole               string                 `yaml:"iamRole" json:"iamRole"`
+	Custom                map[string]interface{} `yaml:"custom" json:"custom"`
 }
 
 // AzureProvider configuration
 type AzureProvider struct {
-	Enabled        bool                   `yaml:"enabled" json:"enabled"`
-	SubscriptionID string                 `yaml:"subscriptionId" json:"subscriptionId"`
-	TenantID       string                 `yaml:"tenantId" json:"tenantId"`
-	ClientID       string                 `yaml:"clientId" json:"clientId"`
-	ClientSecret   string                 `yaml:"clientSecret" json:"clientSecret"`
-	ResourceGroup  string                 `yaml:"resourceGroup" json:"resourceGroup"`
-	Location       string                 `yaml:"location" json:"location"`
-	VirtualNetwork *AzureVirtualNetwork   `yaml:"virtualNetwork,omitempty" json:"virtualNetwork,omitempty"`
-	SSHPublicKey   string                 `yaml:"-" json:"-"` // Set programmatically
-	UserData       string                 `yaml:"userData" json:"userData"`
-	Custom         map[string]interface{} `yaml:"custom" json:"custom"`
+	Enabled               bool                   `yaml:"enabled" json:"enabled"`
+	SubscriptionID        string                 `yaml:"subscriptionId" json:"subscriptionId"`
+	TenantID              string                 `yaml:"tenantId" json:"tenantId"`
+	ClientID              string                 `yaml:"clientId" json:"clientId"`
+	ClientSecret          string                 `yaml:"clientSecret" json:"clientSecret"`
+	ResourceGroup         string                 `yaml:"resourceGroup" json:"resourceGroup"`
+	Location              string                 `yaml:"location" json:"location"`
+	DefaultSize           string                 `yaml:"defaultSize" json:"defaultSize"`                                         // Size used by pools that omit one
+	MaxConcurrentRequests int                    `yaml:"maxConcurrentRequests,omitempty" json:"maxConcurrentRequests,omitempty"` // Create calls in flight at once; 0 uses the built-in default
+	VirtualNetwork        *AzureVirtualNetwork   `yaml:"virtualNetwork,omitempty" json:"virtualNetwork,omitempty"`
+	SSHPublicKey          string                 `yaml:"-" json:"-"` // Set programmatically
+	UserData              string                 `yaml:"userData" json:"userData"`
+	Custom                map[string]interface{} `yaml:"custom" json:"custom"`
 }
 
 // GCPProvider configuration
 type GCPProvider struct {
-	Enabled     bool                   `yaml:"enabled" json:"enabled"`
-	ProjectID   string                 `yaml:"projectId" json:"projectId"`
-	Credentials string                 `yaml:"credentials" json:"credentials"`
-	Region      string                 `yaml:"region" json:"region"`
-	Zone        string                 `yaml:"zone" json:"zone"`
-	Network     *VPCConfig             `yaml:"network,omitempty" json:"network,omitempty"`
-	Custom      map[string]interface{} `yaml:"custom" json:"custom"`
+	Enabled               bool                   `yaml:"enabled" json:"enabled"`
+	ProjectID             string                 `yaml:"projectId" json:"projectId"`
+	Credentials           string                 `yaml:"credentials" json:"credentials"`
+	Region                string                 `yaml:"region" json:"region"`
+	Zone                  string                 `yaml:"zone" json:"zone"`
+	DefaultSize           string                 `yaml:"defaultSize" json:"defaultSize"`                                         // Size used by pools that omit one
+	MaxConcurrentRequests int                    `yaml:"maxConcurrentRequests,omitempty" json:"maxConcurrentRequests,omitempty"` // Create calls in flight at once; 0 uses the built-in default
+	Network               *VPCConfig             `yaml:"network,omitempty" json:"network,omitempty"`
+	Custom                map[string]interface{} `yaml:"custom" json:"custom"`
 }
 
 // HetznerProvider configuration for Hetzner Cloud
 type HetznerProvider struct {
-	Enabled        bool                   `yaml:"enabled" json:"enabled"`
-	Token          string                 `yaml:"token" json:"token"`           // Hetzner Cloud API token
-	Location       string                 `yaml:"location" json:"location"`     // Default location (fsn1, nbg1, hel1, ash, hil)
-	Datacenter     string                 `yaml:"datacenter" json:"datacenter"` // Specific datacenter (fsn1-dc14, nbg1-dc3, etc.)
-	Network        *HetznerNetworkConfig  `yaml:"network,omitempty" json:"network,omitempty"`
-	SSHKeys        []string               `yaml:"sshKeys" json:"sshKeys"` // SSH key names or IDs
-	SSHPublicKey   interface{}            `yaml:"-" json:"-"`             // Set programmatically
-	Labels         map[string]string      `yaml:"labels" json:"labels"`   // Labels to apply to resources
-	Firewall       *FirewallConfig        `yaml:"firewall,omitempty" json:"firewall,omitempty"`
-	PlacementGroup *HetznerPlacementGroup `yaml:"placementGroup,omitempty" json:"placementGroup,omitempty"`
-	Custom         map[string]interface{} `yaml:"custom" json:"custom"`
+	Enabled               bool                   `yaml:"enabled" json:"enabled"`
+	Token                 string                 `yaml:"token" json:"token"`                                                     // Hetzner Cloud API token
+	Location              string                 `yaml:"location" json:"location"`                                               // Default location (fsn1, nbg1, hel1, ash, hil)
+	Datacenter            string                 `yaml:"datacenter" json:"datacenter"`                                           // Specific datacenter (fsn1-dc14, nbg1-dc3, etc.)
+	DefaultSize           string                 `yaml:"defaultSize" json:"defaultSize"`                                         // Server type used by pools that omit one
+	MaxConcurrentRequests int                    `yaml:"maxConcurrentRequests,omitempty" json:"maxConcurrentRequests,omitempty"` // Create calls in flight at once; 0 uses the built-in default
+	Network               *HetznerNetworkConfig  `yaml:"network,omitempty" json:"network,omitempty"`
+	SSHKeys               []string               `yaml:"sshKeys" json:"sshKeys"` // SSH key names or IDs
+	SSHPublicKey          interface{}            `yaml:"-" json:"-"`             // Set programmatically
+	Labels                map[string]string      `yaml:"labels" json:"labels"`   // Labels to apply to resources
+	Firewall              *FirewallConfig        `yaml:"firewall,omitempty" json:"firewall,omitempty"`
+	PlacementGroup        *HetznerPlacementGroup `yaml:"placementGroup,omitempty" json:"placementGroup,omitempty"`
+	Custom                map[string]interface{} `yaml:"custom" json:"custom"`
 }
 
 // HetznerNetworkConfig - Hetzner Cloud network configuration
@@ -255,6 +291,7 @@ type WireGuardConfig struct {
 	AutoConfig          bool            `yaml:"autoConfig" json:"autoConfig"`
 	MeshNetworking      bool            `yaml:"meshNetworking" json:"meshNetworking"`
 	SSHPrivateKeyPath   string          `yaml:"sshPrivateKeyPath" json:"sshPrivateKeyPath"`
+	UsePresharedKeys    bool            `yaml:"usePresharedKeys" json:"usePresharedKeys"` // Add a PSK to every tunnel (post-quantum hardening)
 
 	// Network configuration
 	SubnetCIDR string `yaml:"subnetCidr" json:"subnetCidr"` // VPN subnet (e.g., 10.8.0.0/24)
@@ -285,7 +322,8 @@ type TailscaleConfig struct {
 	// Node configuration
 	Tags         []string `yaml:"tags" json:"tags"`                 // ACL tags to apply to nodes
 	AcceptRoutes bool     `yaml:"acceptRoutes" json:"acceptRoutes"` // Accept routes from other nodes
-	ExitNode     string   `yaml:"exitNode" json:"exitNode"`         // Optional exit node
+	ExitNode     string   `yaml:"exitNode" json:"exitNode"`         // Node advertised as exit node for full-tunnel clients
+	ACLPolicy    string   `yaml:"aclPolicy" json:"aclPolicy"`       // ACL policy uploaded to Headscale, inline HuJSON or a file path
 
 	// Headscale server creation (similar to WireGuard)
 	Create   bool   `yaml:"create" json:"create"`     // Auto-create Headscale server
@@ -343,10 +381,14 @@ type NodeConfig struct {
 	Taints       []TaintConfig          `yaml:"taints" json:"taints"`
 	UserData     string                 `yaml:"userData" json:"userData"`
 	SSHKey       string                 `yaml:"sshKey" json:"sshKey"`
+	SSHUser      string                 `yaml:"sshUser,omitempty" json:"sshUser,omitempty"` // Overrides the provider's default login user
 	Monitoring   bool                   `yaml:"monitoring" json:"monitoring"`
 	SpotInstance bool                   `yaml:"spotInstance" json:"spotInstance"`
 	SpotMaxPrice string                 `yaml:"spotMaxPrice" json:"spotMaxPrice"`
 	Custom       map[string]interface{} `yaml:"custom" json:"custom"`
+
+	// Credentials overrides the provider credentials for this node only
+	Credentials *ProviderCredentials `yaml:"credentials,omitempty" json:"credentials,omitempty"`
 }
 
 // NodePool defines a pool of similar nodes
@@ -360,6 +402,7 @@ type NodePool struct {
 	Size         string                 `yaml:"size" json:"size"`
 	Image        string                 `yaml:"image" json:"image"`
 	Region       string                 `yaml:"region" json:"region"`
+	Regions      []string               `yaml:"regions,omitempty" json:"regions,omitempty"` // Spreads the nodes round-robin across regions; AWS and GCP spread across Zones instead
 	Zones        []string               `yaml:"zones" json:"zones"`
 	Labels       map[string]string      `yaml:"labels" json:"labels"`
 	Taints       []TaintConfig          `yaml:"taints" json:"taints"`
@@ -367,6 +410,7 @@ type NodePool struct {
 	SpotInstance bool                   `yaml:"spotInstance" json:"spotInstance"`
 	Preemptible  bool                   `yaml:"preemptible" json:"preemptible"`
 	UserData     string                 `yaml:"userData" json:"userData"`
+	SSHUser      string                 `yaml:"sshUser,omitempty" json:"sshUser,omitempty"` // Overrides the provider's default login user
 	Custom       map[string]interface{} `yaml:"custom" json:"custom"`
 
 	// Advanced configurations
@@ -374,6 +418,28 @@ type NodePool struct {
 	SpotConfig        *SpotConfig        `yaml:"spotConfig,omitempty" json:"spotConfig,omitempty"`
 	Distribution      []ZoneDistribution `yaml:"distribution,omitempty" json:"distribution,omitempty"`
 	CustomImage       *CustomImageConfig `yaml:"customImage,omitempty" json:"customImage,omitempty"`
+
+	// Credentials overrides the provider credentials for this pool only
+	Credentials *ProviderCredentials `yaml:"credentials,omitempty" json:"credentials,omitempty"`
+
+	// WireGuardIPs are the WireGuard addresses of the pool's nodes in order,
+	// allocated by the orchestrator before the pool is created
+	WireGuardIPs []string `yaml:"-" json:"-"`
+}
+
+// ProviderCredentials overrides the top-level provider credentials for a node
+// or pool, e.g. to deploy into a second account of the same cloud. Only the
+// fields relevant to the provider are used; empty fields keep the top-level value.
+type ProviderCredentials struct {
+	Token           string `yaml:"token,omitempty" json:"token,omitempty"`                     // DigitalOcean, Linode, Hetzner
+	AccessKeyID     string `yaml:"accessKeyId,omitempty" json:"accessKeyId,omitempty"`         // AWS
+	SecretAccessKey string `yaml:"secretAccessKey,omitempty" json:"secretAccessKey,omitempty"` // AWS
+	SubscriptionID  string `yaml:"subscriptionId,omitempty" json:"subscriptionId,omitempty"`   // Azure
+	TenantID        string `yaml:"tenantId,omitempty" json:"tenantId,omitempty"`               // Azure
+	ClientID        string `yaml:"clientId,omitempty" json:"clientId,omitempty"`               // Azure
+	ClientSecret    string `yaml:"clientSecret,omitempty" json:"clientSecret,omitempty"`       // Azure
+	ProjectID       string `yaml:"projectId,omitempty" json:"projectId,omitempty"`             // GCP
+	Credentials     string `yaml:"credentials,omitempty" json:"credentials,omitempty"`         // GCP
 }
 
 // KubernetesConfig for Kubernetes-specific settings
@@ -479,7 +545,8 @@ type DNSConfig struct {
 	Searches    []string `yaml:"searches" json:"searches"`
 	Options     []string `yaml:"options" json:"options"`
 	ExternalDNS bool     `yaml:"externalDns" json:"externalDns"`
-	Provider    string   `yaml:"provider" json:"provider"` // digitalocean, cloudflare, route53, etc
+	Provider    string   `yaml:"provider" json:"provider"`                     // digitalocean, cloudflare, route53, etc
+	APIToken    string   `yaml:"apiToken,omitempty" json:"apiToken,omitempty"` // Cloudflare API token; other providers reuse their cloud credentials
 }
 
 type IngressConfig struct {
@@ -491,11 +558,17 @@ type IngressConfig struct {
 }
 
 type LoadBalancerConfig struct {
-	Name     string                 `yaml:"name" json:"name"`
-	Type     string                 `yaml:"type" json:"type"`
-	Provider string                 `yaml:"provider" json:"provider"`
-	Ports    []PortConfig           `yaml:"ports" json:"ports"`
-	Custom   map[string]interface{} `yaml:"custom" json:"custom"`
+	Name        string                 `yaml:"name" json:"name"`
+	Type        string                 `yaml:"type" json:"type"`
+	Provider    string                 `yaml:"provider" json:"provider"`
+	Ports       []PortConfig           `yaml:"ports" json:"ports"`
+	AddressPool string                 `yaml:"addressPool,omitempty" json:"addressPool,omitempty"` // MetalLB range, e.g. 10.0.255.200-10.0.255.250 or 10.0.255.192/27 (default: top of network.cidr)
+	Target      string                 `yaml:"target,omitempty" json:"target,omitempty"`           // masters or workers (default: masters for the API server, workers otherwise)
+	Custom      map[string]interface{} `yaml:"custom" json:"custom"`
+
+	// TargetNodes names the nodes behind the load balancer. The orchestrator
+	// resolves it from Target; empty means every node of the provider.
+	TargetNodes []string `yaml:"-" json:"-"`
 }
 
 type PortConfig struct {
@@ -544,6 +617,7 @@ type TLSConfig struct {
 	Provider    string   `yaml:"provider" json:"provider"`
 	Email       string   `yaml:"email" json:"email"`
 	Domains     []string `yaml:"domains" json:"domains"`
+	Staging     bool     `yaml:"staging,omitempty" json:"staging,omitempty"` // Issue from the Let's Encrypt staging environment
 }
 
 type RBACConfig struct {
@@ -568,10 +642,11 @@ type BindingConfig struct {
 }
 
 type PodSecurityConfig struct {
-	PolicyLevel    string `yaml:"policyLevel" json:"policyLevel"`
-	EnforceProfile string `yaml:"enforceProfile" json:"enforceProfile"`
-	AuditProfile   string `yaml:"auditProfile" json:"auditProfile"`
-	WarnProfile    string `yaml:"warnProfile" json:"warnProfile"`
+	PolicyLevel      string   `yaml:"policyLevel" json:"policyLevel"`
+	EnforceProfile   string   `yaml:"enforceProfile" json:"enforceProfile"`
+	AuditProfile     string   `yaml:"auditProfile" json:"auditProfile"`
+	WarnProfile      string   `yaml:"warnProfile" json:"warnProfile"`
+	ExemptNamespaces []string `yaml:"exemptNamespaces,omitempty" json:"exemptNamespaces,omitempty"` // Left unlabeled in addition to the system namespaces
 }
 
 type SecretsConfig struct {
@@ -623,6 +698,7 @@ type SpotConfig struct {
 	SpotPercentage   int     `yaml:"spotPercentage" json:"spotPercentage"`     // Percentage of nodes as spot (0-100)
 	InterruptionMode string  `yaml:"interruptionMode" json:"interruptionMode"` // terminate, stop, hibernate
 	MaxSpotPrice     float64 `yaml:"maxSpotPrice" json:"maxSpotPrice"`         // Max spot price as float
+	DrainTimeout     int     `yaml:"drainTimeout" json:"drainTimeout"`         // Seconds to drain a node after an interruption notice
 }
 
 // ZoneDistribution defines node distribution across zones
@@ -841,11 +917,29 @@ type SchedulerConfig struct {
 }
 
 type KubeletConfig struct {
-	ExtraArgs       map[string]string `yaml:"extraArgs" json:"extraArgs"`
-	ExtraVolumes    []VolumeMount     `yaml:"extraVolumes" json:"extraVolumes"`
-	ClusterDNS      string            `yaml:"clusterDns" json:"clusterDns"`
-	ClusterDomain   string            `yaml:"clusterDomain" json:"clusterDomain"`
-	RegistryMirrors []string          `yaml:"registryMirrors" json:"registryMirrors"`
+	ExtraArgs       map[string]string  `yaml:"extraArgs" json:"extraArgs"`
+	ExtraVolumes    []VolumeMount      `yaml:"extraVolumes" json:"extraVolumes"`
+	ClusterDNS      string             `yaml:"clusterDns" json:"clusterDns"`
+	ClusterDomain   string             `yaml:"clusterDomain" json:"clusterDomain"`
+	RegistryMirrors []string           `yaml:"registryMirrors" json:"registryMirrors"`
+	LogRotation     *LogRotationConfig `yaml:"logRotation,omitempty" json:"logRotation,omitempty"`
+	Eviction        *EvictionConfig    `yaml:"eviction,omitempty" json:"eviction,omitempty"`
+}
+
+// LogRotationConfig controls how the kubelet rotates container logs
+type LogRotationConfig struct {
+	MaxSize  string `yaml:"maxSize" json:"maxSize"`   // Size at which a log is rotated, e.g. 20Mi
+	MaxFiles int    `yaml:"maxFiles" json:"maxFiles"` // Rotated files kept per container (min 2)
+}
+
+// EvictionConfig sets kubelet eviction thresholds and image garbage collection.
+// Thresholds are keyed by eviction signal, e.g. "nodefs.available": "10%".
+type EvictionConfig struct {
+	Hard                 map[string]string `yaml:"hard" json:"hard"`
+	Soft                 map[string]string `yaml:"soft" json:"soft"`
+	SoftGracePeriod      map[string]string `yaml:"softGracePeriod" json:"softGracePeriod"` // e.g. "nodefs.available": "2m"
+	ImageGCHighThreshold int               `yaml:"imageGcHighThreshold" json:"imageGcHighThreshold"`
+	ImageGCLowThreshold  int               `yaml:"imageGcLowThreshold" json:"imageGcLowThreshold"`
 }
 
 type EtcdConfig struct {
@@ -366,3 +366,34 @@ func TestLinodeProvider(t *testing.T) {
 		t.Error("PrivateIP should be enabled")
 	}
 }
+
+func TestNetworkConfig_SelectedVPN(t *testing.T) {
+	wg := &WireGuardConfig{Enabled: true}
+	ts := &TailscaleConfig{Enabled: true}
+
+	tests := []struct {
+		name    string
+		network NetworkConfig
+		want    string
+		both    bool
+	}{
+		{"none", NetworkConfig{}, "", false},
+		{"wireguard only", NetworkConfig{WireGuard: wg}, VPNWireGuard, false},
+		{"tailscale only", NetworkConfig{Tailscale: ts}, VPNTailscale, false},
+		{"disabled tailscale", NetworkConfig{WireGuard: wg, Tailscale: &TailscaleConfig{}}, VPNWireGuard, false},
+		{"both defaults to tailscale", NetworkConfig{WireGuard: wg, Tailscale: ts}, VPNTailscale, true},
+		{"both with wireguard mode", NetworkConfig{Mode: "wireguard", WireGuard: wg, Tailscale: ts}, VPNWireGuard, true},
+		{"both with tailscale mode", NetworkConfig{Mode: "tailscale", WireGuard: wg, Tailscale: ts}, VPNTailscale, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.network.SelectedVPN(); got != tt.want {
+				t.Errorf("SelectedVPN() = %q, want %q", got, tt.want)
+			}
+			if got := tt.network.BothVPNsEnabled(); got != tt.both {
+				t.Errorf("BothVPNsEnabled() = %v, want %v", got, tt.both)
+			}
+		})
+	}
+}
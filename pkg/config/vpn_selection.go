@@ -0,0 +1,31 @@
+package config
+
+// VPN backends selectable through Network.Mode
+const (
+	VPNWireGuard = "wireguard"
+	VPNTailscale = "tailscale"
+)
+
+// BothVPNsEnabled reports whether WireGuard and Tailscale are both enabled
+func (n NetworkConfig) BothVPNsEnabled() bool {
+	return n.WireGuard != nil && n.WireGuard.Enabled && n.Tailscale != nil && n.Tailscale.Enabled
+}
+
+// SelectedVPN returns the VPN the cluster mesh is built with, or "" when none
+// is enabled. When both are enabled, Network.Mode picks the winner; without an
+// explicit mode Tailscale is used.
+func (n NetworkConfig) SelectedVPN() string {
+	if n.BothVPNsEnabled() {
+		if n.Mode == VPNWireGuard {
+			return VPNWireGuard
+		}
+		return VPNTailscale
+	}
+	if n.Tailscale != nil && n.Tailscale.Enabled {
+		return VPNTailscale
+	}
+	if n.WireGuard != nil && n.WireGuard.Enabled {
+		return VPNWireGuard
+	}
+	return ""
+}
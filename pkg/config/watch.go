@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long the config file must stay unchanged
+// before Watch reloads it, so an editor's burst of writes reloads once
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// SetWatchDebounce sets how long Watch waits for the file to settle before
+// reloading it
+func (l *Loader) SetWatchDebounce(d time.Duration) {
+	l.watchDebounce = d
+}
+
+// OnWatchError sets the function Watch reports reload failures to. A file
+// that fails to parse or validate keeps the previous config in effect.
+func (l *Loader) OnWatchError(fn func(error)) {
+	l.watchError = fn
+}
+
+// Watch reloads and re-validates the config file whenever it changes and
+// calls onChange with the new config when it differs from the last one
+// loaded. Calls are serialized. The directory is watched rather than the
+// file so editors that save by replacing the file are followed. The
+// returned function stops watching.
+func (l *Loader) Watch(onChange func(*ClusterConfig)) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	path := filepath.Clean(l.configPath)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", l.configPath, err)
+	}
+
+	debounce := l.watchDebounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	var mu sync.Mutex
+	current := l.config
+	reload := func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		cfg, err := l.LoadStrict()
+		if err != nil {
+			if l.watchError != nil {
+				l.watchError(err)
+			}
+			return
+		}
+		if reflect.DeepEqual(cfg, current) {
+			return
+		}
+		current = cfg
+		onChange(cfg)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+				if timer == nil {
+					timer = time.AfterFunc(debounce, reload)
+				} else {
+					timer.Reset(debounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				if l.watchError != nil {
+					l.watchError(err)
+				}
+			case <-done:
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			watcher.Close()
+		})
+	}, nil
+}
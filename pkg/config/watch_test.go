@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_Watch(t *testing.T) {
+	path := writeSchemaConfig(t, schemaValidConfig)
+	loader := NewLoader(path)
+	loader.SetWatchDebounce(50 * time.Millisecond)
+	_, err := loader.LoadStrict()
+	require.NoError(t, err)
+
+	errs := make(chan error, 10)
+	loader.OnWatchError(func(err error) { errs <- err })
+
+	changes := make(chan *ClusterConfig, 10)
+	stop, err := loader.Watch(func(cfg *ClusterConfig) { changes <- cfg })
+	require.NoError(t, err)
+	defer stop()
+
+	// A burst of saves reloads once, with the last content
+	for _, count := range []string{"4", "5", "6"} {
+		writeWatchedConfig(t, path, strings.Replace(schemaValidConfig, "(count 2)", "(count "+count+")", 1))
+	}
+	select {
+	case cfg := <-changes:
+		assert.Equal(t, 6, cfg.NodePools["workers"].Count)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload")
+	}
+
+	// A save that does not change the parsed config is not reported
+	writeWatchedConfig(t, path, "; comment\n"+strings.Replace(schemaValidConfig, "(count 2)", "(count 6)", 1))
+	select {
+	case <-changes:
+		t.Fatal("expected no reload for an unchanged config")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// An invalid config is reported and keeps the previous one
+	writeWatchedConfig(t, path, "(cluster (metadata (name \"prod\"))")
+	select {
+	case err := <-errs:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload error")
+	}
+
+	// An editor replacing the file is followed
+	replacement := filepath.Join(filepath.Dir(path), "cluster.lisp.tmp")
+	writeWatchedConfig(t, replacement, schemaValidConfig)
+	require.NoError(t, os.Rename(replacement, path))
+	select {
+	case cfg := <-changes:
+		assert.Equal(t, 2, cfg.NodePools["workers"].Count)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload after the file was replaced")
+	}
+	assert.Empty(t, changes, "invalid configs are not reported")
+}
+
+func TestLoader_Watch_Stop(t *testing.T) {
+	path := writeSchemaConfig(t, schemaValidConfig)
+	loader := NewLoader(path)
+	loader.SetWatchDebounce(10 * time.Millisecond)
+
+	changes := make(chan *ClusterConfig, 1)
+	stop, err := loader.Watch(func(cfg *ClusterConfig) { changes <- cfg })
+	require.NoError(t, err)
+	stop()
+	stop()
+
+	writeWatchedConfig(t, path, strings.Replace(schemaValidConfig, "(count 2)", "(count 4)", 1))
+	select {
+	case <-changes:
+		t.Fatal("expected no reload after stop")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestLoader_Watch_MissingDirectory(t *testing.T) {
+	_, err := NewLoader(filepath.Join(t.TempDir(), "missing", "cluster.lisp")).Watch(func(*ClusterConfig) {})
+	assert.Error(t, err)
+}
+
+func writeWatchedConfig(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
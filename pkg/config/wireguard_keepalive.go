@@ -0,0 +1,27 @@
+package config
+
+// DefaultPersistentKeepalive is the WireGuard keepalive, in seconds, used for
+// peers behind NAT when none is configured
+const DefaultPersistentKeepalive = 25
+
+// Keepalive returns the PersistentKeepalive to write into [Peer] blocks: the
+// configured value when set, otherwise DefaultPersistentKeepalive when a peer
+// is behind NAT, and 0 (no keepalive) when every peer has a public endpoint
+func (w *WireGuardConfig) Keepalive(behindNAT bool) int {
+	if w != nil && w.PersistentKeepalive > 0 {
+		return w.PersistentKeepalive
+	}
+	if behindNAT {
+		return DefaultPersistentKeepalive
+	}
+	return 0
+}
+
+// NodesBehindNAT reports whether the nodes lack a public endpoint of their
+// own, which is the case for private clusters
+func (c *ClusterConfig) NodesBehindNAT() bool {
+	if c.PrivateCluster != nil && c.PrivateCluster.Enabled {
+		return true
+	}
+	return c.Network.PrivateCluster != nil && c.Network.PrivateCluster.Enabled
+}
@@ -0,0 +1,38 @@
+package config
+
+import "testing"
+
+func TestWireGuardConfig_Keepalive(t *testing.T) {
+	tests := []struct {
+		name      string
+		wg        *WireGuardConfig
+		behindNAT bool
+		want      int
+	}{
+		{"configured", &WireGuardConfig{PersistentKeepalive: 15}, false, 15},
+		{"configured behind NAT", &WireGuardConfig{PersistentKeepalive: 15}, true, 15},
+		{"unset behind NAT", &WireGuardConfig{}, true, DefaultPersistentKeepalive},
+		{"unset with public endpoints", &WireGuardConfig{}, false, 0},
+		{"no WireGuard config", nil, true, DefaultPersistentKeepalive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.wg.Keepalive(tt.behindNAT); got != tt.want {
+				t.Errorf("Keepalive(%t) = %d, want %d", tt.behindNAT, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterConfig_NodesBehindNAT(t *testing.T) {
+	if (&ClusterConfig{}).NodesBehindNAT() {
+		t.Error("NodesBehindNAT() = true for a public cluster")
+	}
+	if !(&ClusterConfig{PrivateCluster: &PrivateClusterConfig{Enabled: true}}).NodesBehindNAT() {
+		t.Error("NodesBehindNAT() = false for a private cluster")
+	}
+	network := NetworkConfig{PrivateCluster: &PrivateClusterConfig{Enabled: true}}
+	if !(&ClusterConfig{Network: network}).NodesBehindNAT() {
+		t.Error("NodesBehindNAT() = false for a private network")
+	}
+}
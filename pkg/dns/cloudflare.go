@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// The pulumi-cloudflare SDK is not a dependency of this module, so Cloudflare
+// resources are registered by type token. The plugin version is pinned on the
+// provider resource so the engine can install it on demand.
+const cloudflarePluginVersion = "5.49.1"
+
+// cloudflareProviderResource is an explicit cloudflare provider
+type cloudflareProviderResource struct {
+	pulumi.ProviderResourceState
+}
+
+// cloudflareRecord is a DNS record in a Cloudflare zone
+type cloudflareRecord struct {
+	pulumi.CustomResourceState
+
+	Hostname pulumi.StringOutput `pulumi:"hostname"`
+}
+
+// CloudflareProvider creates the stack's records in the Cloudflare zone
+// matching the domain, and manages records outside the stack through the
+// embedded API client
+type CloudflareProvider struct {
+	*CloudflareClient
+	provider *cloudflareProviderResource
+}
+
+// NewCloudflareProvider creates a Cloudflare DNS provider for domain. Without
+// an API token the provider falls back to CLOUDFLARE_API_TOKEN.
+func NewCloudflareProvider(ctx *pulumi.Context, apiToken, domain string) (*CloudflareProvider, error) {
+	client := NewCloudflareClient(apiToken, domain)
+
+	args := pulumi.Map{}
+	if client.Token != "" {
+		args["apiToken"] = pulumi.ToSecret(pulumi.String(client.Token))
+	}
+
+	var provider cloudflareProviderResource
+	if err := ctx.RegisterResource("pulumi:providers:cloudflare", "dns-cloudflare-provider", args, &provider,
+		pulumi.Version(cloudflarePluginVersion)); err != nil {
+		return nil, fmt.Errorf("failed to create Cloudflare provider: %w", err)
+	}
+
+	return &CloudflareProvider{
+		CloudflareClient: client,
+		provider:         &provider,
+	}, nil
+}
+
+// Name returns the provider name
+func (p *CloudflareProvider) Name() string {
+	return "cloudflare"
+}
+
+// CreateRecord creates a Cloudflare record. An existing record with the same
+// name and type is taken over instead of failing the deploy, and records are
+// DNS only, not proxied, so they resolve to the node and LoadBalancer
+// addresses.
+func (p *CloudflareProvider) CreateRecord(ctx *pulumi.Context, resourceName, domain string, record Record, opts ...pulumi.ResourceOption) (pulumi.Resource, error) {
+	if domain != p.Domain {
+		return nil, fmt.Errorf("cloudflare provider manages %s, not %s", p.Domain, domain)
+	}
+	if err := validateRecordType(record.Type); err != nil {
+		return nil, err
+	}
+
+	zoneID, err := p.ZoneID(ctx.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var res cloudflareRecord
+	opts = append(opts, pulumi.Provider(p.provider))
+	if err := ctx.RegisterResource("cloudflare:index/record:Record", resourceName, pulumi.Map{
+		"zoneId":         pulumi.String(zoneID),
+		"name":           pulumi.String(record.Name),
+		"type":           pulumi.String(record.Type),
+		"content":        record.Value,
+		"ttl":            pulumi.Int(record.TTL),
+		"proxied":        pulumi.Bool(false),
+		"allowOverwrite": pulumi.Bool(true),
+	}, &res, opts...); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
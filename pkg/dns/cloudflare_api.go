@@ -0,0 +1,245 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const cloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+
+// CloudflareToken returns token, or CLOUDFLARE_API_TOKEN when it is empty
+func CloudflareToken(token string) string {
+	if token != "" {
+		return token
+	}
+	return os.Getenv("CLOUDFLARE_API_TOKEN")
+}
+
+// CloudflareClient manages the records of the Cloudflare zone matching
+// Domain through the Cloudflare API
+type CloudflareClient struct {
+	BaseURL    string
+	Token      string
+	Domain     string
+	HTTPClient *http.Client
+	zoneID     string
+}
+
+// NewCloudflareClient creates a client for the zone matching domain. The
+// token falls back to CLOUDFLARE_API_TOKEN.
+func NewCloudflareClient(token, domain string) *CloudflareClient {
+	return &CloudflareClient{
+		BaseURL:    cloudflareAPIURL,
+		Token:      CloudflareToken(token),
+		Domain:     domain,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// cloudflareResponse is the envelope every Cloudflare API response uses
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+// cloudflareDNSRecord is a DNS record as the Cloudflare API represents it
+type cloudflareDNSRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+func (r cloudflareDNSRecord) zoneRecord() ZoneRecord {
+	return ZoneRecord{ID: r.ID, Type: r.Type, Name: r.Name, Content: r.Content, TTL: r.TTL}
+}
+
+// do sends a request and decodes the result into out, returning the
+// envelope for its pagination info
+func (c *CloudflareClient) do(ctx context.Context, method, path string, body, out interface{}) (*cloudflareResponse, error) {
+	if c.Token == "" {
+		return nil, fmt.Errorf("cloudflare API token is not set: set network.dns.apiToken or CLOUDFLARE_API_TOKEN")
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cloudflare API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope cloudflareResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to decode cloudflare API response (status %d): %w", resp.StatusCode, err)
+	}
+	if !envelope.Success || resp.StatusCode >= 300 {
+		messages := make([]string, 0, len(envelope.Errors))
+		for _, e := range envelope.Errors {
+			messages = append(messages, fmt.Sprintf("%d: %s", e.Code, e.Message))
+		}
+		return nil, fmt.Errorf("cloudflare API %s %s failed with status %d: %s",
+			method, path, resp.StatusCode, strings.Join(messages, "; "))
+	}
+
+	if out != nil && len(envelope.Result) > 0 {
+		if err := json.Unmarshal(envelope.Result, out); err != nil {
+			return nil, fmt.Errorf("failed to decode cloudflare API result: %w", err)
+		}
+	}
+	return &envelope, nil
+}
+
+// ZoneID returns the ID of the zone matching Domain
+func (c *CloudflareClient) ZoneID(ctx context.Context) (string, error) {
+	if c.zoneID != "" {
+		return c.zoneID, nil
+	}
+
+	var zones []struct {
+		ID string `json:"id"`
+	}
+	if _, err := c.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(c.Domain), nil, &zones); err != nil {
+		return "", err
+	}
+	if len(zones) == 0 {
+		return "", fmt.Errorf("no Cloudflare zone matches %s; the API token needs Zone:Read access to it", c.Domain)
+	}
+	c.zoneID = zones[0].ID
+	return c.zoneID, nil
+}
+
+// records lists the zone's records, filtered by query, across all pages
+func (c *CloudflareClient) records(ctx context.Context, query url.Values) ([]cloudflareDNSRecord, error) {
+	zoneID, err := c.ZoneID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []cloudflareDNSRecord
+	query.Set("per_page", "100")
+	for page := 1; ; page++ {
+		query.Set("page", fmt.Sprint(page))
+		var records []cloudflareDNSRecord
+		envelope, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/zones/%s/dns_records?%s", zoneID, query.Encode()), nil, &records)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, records...)
+		if page >= envelope.ResultInfo.TotalPages {
+			return all, nil
+		}
+	}
+}
+
+// ListRecords returns every record in the zone
+func (c *CloudflareClient) ListRecords(ctx context.Context) ([]ZoneRecord, error) {
+	records, err := c.records(ctx, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ZoneRecord, 0, len(records))
+	for _, record := range records {
+		result = append(result, record.zoneRecord())
+	}
+	return result, nil
+}
+
+// UpsertRecord creates the record, or updates the record with the same type
+// and name so repeated runs never duplicate it. Records are DNS only, not
+// proxied.
+func (c *CloudflareClient) UpsertRecord(ctx context.Context, record ZoneRecord) (*ZoneRecord, error) {
+	if err := validateRecordType(record.Type); err != nil {
+		return nil, err
+	}
+	zoneID, err := c.ZoneID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := cloudflareDNSRecord{
+		Type:    record.Type,
+		Name:    qualifyName(c.Domain, record.Name),
+		Content: record.Content,
+		TTL:     record.TTL,
+	}
+	if desired.TTL == 0 {
+		desired.TTL = defaultTTL
+	}
+
+	existing, err := c.records(ctx, url.Values{"type": {desired.Type}, "name": {desired.Name}})
+	if err != nil {
+		return nil, err
+	}
+
+	var result cloudflareDNSRecord
+	switch {
+	case len(existing) == 0:
+		_, err = c.do(ctx, http.MethodPost, fmt.Sprintf("/zones/%s/dns_records", zoneID), desired, &result)
+	case existing[0].Content == desired.Content && existing[0].TTL == desired.TTL && !existing[0].Proxied:
+		result = existing[0]
+	default:
+		_, err = c.do(ctx, http.MethodPut, fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, existing[0].ID), desired, &result)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert %s record %s: %w", desired.Type, desired.Name, err)
+	}
+
+	upserted := result.zoneRecord()
+	return &upserted, nil
+}
+
+// DeleteRecord deletes the records with the type and name. Deleting a
+// record that does not exist succeeds.
+func (c *CloudflareClient) DeleteRecord(ctx context.Context, recordType, name string) error {
+	if err := validateRecordType(recordType); err != nil {
+		return err
+	}
+	zoneID, err := c.ZoneID(ctx)
+	if err != nil {
+		return err
+	}
+
+	records, err := c.records(ctx, url.Values{"type": {recordType}, "name": {qualifyName(c.Domain, name)}})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if _, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/zones/%s/dns_records/%s", zoneID, record.ID), nil, nil); err != nil {
+			return fmt.Errorf("failed to delete %s record %s: %w", record.Type, record.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCloudflare serves the zones and dns_records endpoints of the
+// Cloudflare API for the example.com zone from memory
+type fakeCloudflare struct {
+	mu      sync.Mutex
+	records []cloudflareDNSRecord
+	nextID  int
+	methods map[string]int // requests by method
+}
+
+func newFakeCloudflare(t *testing.T, records ...cloudflareDNSRecord) (*fakeCloudflare, *CloudflareClient) {
+	fake := &fakeCloudflare{records: records, nextID: len(records), methods: make(map[string]int)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := NewCloudflareClient("cf-token", "example.com")
+	client.BaseURL = server.URL
+	return fake, client
+}
+
+func (f *fakeCloudflare) reply(w http.ResponseWriter, status int, result interface{}, totalPages int) {
+	data, _ := json.Marshal(result)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     status < 300,
+		"errors":      []interface{}{},
+		"result":      json.RawMessage(data),
+		"result_info": map[string]int{"page": 1, "total_pages": totalPages},
+	})
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.methods[r.Method]++
+
+	if r.Header.Get("Authorization") != "Bearer cf-token" {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"errors":  []map[string]interface{}{{"code": 9109, "message": "Invalid access token"}},
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.URL.Path == "/zones":
+		zones := []map[string]string{}
+		if query.Get("name") == "example.com" {
+			zones = append(zones, map[string]string{"id": "zone-1"})
+		}
+		f.reply(w, http.StatusOK, zones, 1)
+
+	case r.URL.Path == "/zones/zone-1/dns_records" && r.Method == http.MethodGet:
+		var matched []cloudflareDNSRecord
+		for _, record := range f.records {
+			if (query.Get("type") == "" || record.Type == query.Get("type")) &&
+				(query.Get("name") == "" || record.Name == query.Get("name")) {
+				matched = append(matched, record)
+			}
+		}
+		perPage, _ := strconv.Atoi(query.Get("per_page"))
+		page, _ := strconv.Atoi(query.Get("page"))
+		if perPage == 0 {
+			perPage = 100
+		}
+		totalPages := (len(matched) + perPage - 1) / perPage
+		start := (page - 1) * perPage
+		end := start + perPage
+		if start > len(matched) {
+			start = len(matched)
+		}
+		if end > len(matched) {
+			end = len(matched)
+		}
+		f.reply(w, http.StatusOK, append([]cloudflareDNSRecord{}, matched[start:end]...), totalPages)
+
+	case r.URL.Path == "/zones/zone-1/dns_records" && r.Method == http.MethodPost:
+		var record cloudflareDNSRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		f.nextID++
+		record.ID = fmt.Sprintf("rec-%d", f.nextID)
+		f.records = append(f.records, record)
+		f.reply(w, http.StatusOK, record, 1)
+
+	case strings.HasPrefix(r.URL.Path, "/zones/zone-1/dns_records/"):
+		id := strings.TrimPrefix(r.URL.Path, "/zones/zone-1/dns_records/")
+		for i, record := range f.records {
+			if record.ID != id {
+				continue
+			}
+			if r.Method == http.MethodDelete {
+				f.records = append(f.records[:i], f.records[i+1:]...)
+				f.reply(w, http.StatusOK, map[string]string{"id": id}, 1)
+				return
+			}
+			var updated cloudflareDNSRecord
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+			updated.ID = id
+			f.records[i] = updated
+			f.reply(w, http.StatusOK, updated, 1)
+			return
+		}
+		f.reply(w, http.StatusNotFound, nil, 0)
+
+	default:
+		f.reply(w, http.StatusNotFound, nil, 0)
+	}
+}
+
+func TestCloudflareClient_UpsertCreatesThenUpdates(t *testing.T) {
+	fake, client := newFakeCloudflare(t)
+	ctx := context.Background()
+
+	created, err := client.UpsertRecord(ctx, ZoneRecord{Type: "A", Name: "api", Content: "203.0.113.10"})
+	require.NoError(t, err)
+	assert.Equal(t, "api.example.com", created.Name)
+	assert.Equal(t, defaultTTL, created.TTL)
+
+	updated, err := client.UpsertRecord(ctx, ZoneRecord{Type: "A", Name: "api.example.com", Content: "203.0.113.20"})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, "203.0.113.20", updated.Content)
+
+	records, err := client.ListRecords(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1, "upserting twice must not duplicate the record")
+	assert.Equal(t, 1, fake.methods[http.MethodPost])
+	assert.Equal(t, 1, fake.methods[http.MethodPut])
+}
+
+func TestCloudflareClient_UpsertUnchangedIsNoop(t *testing.T) {
+	fake, client := newFakeCloudflare(t, cloudflareDNSRecord{
+		ID: "rec-1", Type: "TXT", Name: "_acme-challenge.example.com", Content: "token", TTL: 120,
+	})
+
+	record, err := client.UpsertRecord(context.Background(), ZoneRecord{
+		Type: "TXT", Name: "_acme-challenge", Content: "token", TTL: 120,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rec-1", record.ID)
+	assert.Zero(t, fake.methods[http.MethodPost])
+	assert.Zero(t, fake.methods[http.MethodPut])
+}
+
+func TestCloudflareClient_RejectsUnsupportedType(t *testing.T) {
+	_, client := newFakeCloudflare(t)
+
+	_, err := client.UpsertRecord(context.Background(), ZoneRecord{Type: "MX", Name: "mail", Content: "mx.example.com"})
+	assert.EqualError(t, err, "DNS record type MX is not supported; use A, CNAME or TXT")
+}
+
+func TestCloudflareClient_DeleteRecord(t *testing.T) {
+	fake, client := newFakeCloudflare(t,
+		cloudflareDNSRecord{ID: "rec-1", Type: "CNAME", Name: "www.example.com", Content: "api.example.com"},
+		cloudflareDNSRecord{ID: "rec-2", Type: "A", Name: "www.example.com", Content: "203.0.113.10"},
+	)
+	ctx := context.Background()
+
+	require.NoError(t, client.DeleteRecord(ctx, "CNAME", "www"))
+	require.NoError(t, client.DeleteRecord(ctx, "CNAME", "www"), "deleting a missing record succeeds")
+
+	assert.Equal(t, 1, fake.methods[http.MethodDelete])
+	records, err := client.ListRecords(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "A", records[0].Type)
+}
+
+func TestCloudflareClient_ListRecordsPaginates(t *testing.T) {
+	var records []cloudflareDNSRecord
+	for i := 0; i < 150; i++ {
+		records = append(records, cloudflareDNSRecord{
+			ID: fmt.Sprintf("rec-%d", i), Type: "A", Name: fmt.Sprintf("node%d.example.com", i), Content: "203.0.113.10",
+		})
+	}
+	fake, client := newFakeCloudflare(t, records...)
+
+	listed, err := client.ListRecords(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, listed, 150)
+	// one zone lookup and two pages
+	assert.Equal(t, 3, fake.methods[http.MethodGet])
+}
+
+func TestCloudflareClient_Errors(t *testing.T) {
+	t.Run("unknown zone", func(t *testing.T) {
+		_, client := newFakeCloudflare(t)
+		client.Domain = "other.org"
+
+		_, err := client.ListRecords(context.Background())
+		assert.EqualError(t, err, "no Cloudflare zone matches other.org; the API token needs Zone:Read access to it")
+	})
+
+	t.Run("rejected token", func(t *testing.T) {
+		_, client := newFakeCloudflare(t)
+		client.Token = "wrong"
+
+		_, err := client.ZoneID(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 403: 9109: Invalid access token")
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		t.Setenv("CLOUDFLARE_API_TOKEN", "")
+		client := NewCloudflareClient("", "example.com")
+
+		_, err := client.ZoneID(context.Background())
+		assert.EqualError(t, err, "cloudflare API token is not set: set network.dns.apiToken or CLOUDFLARE_API_TOKEN")
+	})
+}
+
+func TestCloudflareProvider_ImplementsRecordAPI(t *testing.T) {
+	assert.Implements(t, (*RecordAPI)(nil), &CloudflareProvider{})
+	assert.Implements(t, (*DNSProvider)(nil), &CloudflareProvider{})
+}
+
+func TestCloudflareToken(t *testing.T) {
+	t.Setenv("CLOUDFLARE_API_TOKEN", "from-env")
+
+	assert.Equal(t, "configured", CloudflareToken("configured"))
+	assert.Equal(t, "from-env", CloudflareToken(""))
+}
@@ -0,0 +1,31 @@
+package dns
+
+import (
+	"github.com/pulumi/pulumi-digitalocean/sdk/v4/go/digitalocean"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// DigitalOceanProvider creates records in a DigitalOcean-hosted domain
+// using the stack's digitalocean provider configuration
+type DigitalOceanProvider struct{}
+
+// NewDigitalOceanProvider creates a DigitalOcean DNS provider
+func NewDigitalOceanProvider() *DigitalOceanProvider {
+	return &DigitalOceanProvider{}
+}
+
+// Name returns the provider name
+func (p *DigitalOceanProvider) Name() string {
+	return "digitalocean"
+}
+
+// CreateRecord creates a DigitalOcean DnsRecord
+func (p *DigitalOceanProvider) CreateRecord(ctx *pulumi.Context, resourceName, domain string, record Record, opts ...pulumi.ResourceOption) (pulumi.Resource, error) {
+	return digitalocean.NewDnsRecord(ctx, resourceName, &digitalocean.DnsRecordArgs{
+		Domain: pulumi.String(domain),
+		Type:   pulumi.String(record.Type),
+		Name:   pulumi.String(record.Name),
+		Value:  record.Value,
+		Ttl:    pulumi.Int(record.TTL),
+	}, opts...)
+}
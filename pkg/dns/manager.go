@@ -5,30 +5,42 @@ import (
 	"strings"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
-	"github.com/pulumi/pulumi-digitalocean/sdk/v4/go/digitalocean"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
-// Manager handles DNS record creation
+// Manager handles DNS record creation. Without a domain it creates no
+// records.
 type Manager struct {
-	ctx     *pulumi.Context
-	domain  string
-	records []*digitalocean.DnsRecord
-	nodes   []*providers.NodeOutput
+	ctx      *pulumi.Context
+	domain   string
+	provider DNSProvider
+	records  []pulumi.Resource
+	nodes    []*providers.NodeOutput
 }
 
-// NewManager creates a new DNS manager
+// NewManager creates a new DNS manager for a DigitalOcean-hosted domain
 func NewManager(ctx *pulumi.Context, domain string) *Manager {
+	return NewManagerWithProvider(ctx, domain, NewDigitalOceanProvider())
+}
+
+// NewManagerWithProvider creates a new DNS manager creating records through provider
+func NewManagerWithProvider(ctx *pulumi.Context, domain string, provider DNSProvider) *Manager {
 	return &Manager{
-		ctx:     ctx,
-		domain:  domain,
-		records: make([]*digitalocean.DnsRecord, 0),
+		ctx:      ctx,
+		domain:   domain,
+		provider: provider,
+		records:  make([]pulumi.Resource, 0),
 	}
 }
 
 // CreateNodeRecords creates DNS records for all nodes
 func (m *Manager) CreateNodeRecords(nodes map[string][]*providers.NodeOutput) error {
-	m.ctx.Log.Info("Creating DNS records for nodes", nil)
+	if m.domain == "" {
+		m.ctx.Log.Info("No DNS domain configured, skipping DNS records", nil)
+		return nil
+	}
+
+	m.ctx.Log.Info(fmt.Sprintf("Creating DNS records for nodes in %s via %s", m.domain, m.provider.Name()), nil)
 
 	// Counter for each node type
 	masterCount := 0
@@ -124,23 +136,27 @@ func (m *Manager) CreateNodeRecords(nodes map[string][]*providers.NodeOutput) er
 	return nil
 }
 
+// createRecord creates a record through the provider and tracks it
+func (m *Manager) createRecord(resourceName string, record Record, opts ...pulumi.ResourceOption) error {
+	if record.TTL == 0 {
+		record.TTL = defaultTTL
+	}
+	res, err := m.provider.CreateRecord(m.ctx, resourceName, m.domain, record, opts...)
+	if err != nil {
+		return err
+	}
+	m.records = append(m.records, res)
+	return nil
+}
+
 // createARecord creates an A record
 func (m *Manager) createARecord(name string, ip pulumi.StringInput) error {
 	recordName := strings.ToLower(name)
 
-	record, err := digitalocean.NewDnsRecord(m.ctx, fmt.Sprintf("dns-%s", recordName), &digitalocean.DnsRecordArgs{
-		Domain: pulumi.String(m.domain),
-		Type:   pulumi.String("A"),
-		Name:   pulumi.String(recordName),
-		Value:  ip,
-		Ttl:    pulumi.Int(300), // 5 minutes TTL for easier updates
-	})
-	if err != nil {
+	if err := m.createRecord(fmt.Sprintf("dns-%s", recordName), Record{Type: "A", Name: recordName, Value: ip}); err != nil {
 		return err
 	}
 
-	m.records = append(m.records, record)
-
 	// Export the DNS record
 	m.ctx.Export(fmt.Sprintf("dns_%s", strings.ReplaceAll(recordName, "-", "_")),
 		pulumi.Sprintf("%s.%s", recordName, m.domain))
@@ -150,53 +166,30 @@ func (m *Manager) createARecord(name string, ip pulumi.StringInput) error {
 
 // createWildcardRecord creates a wildcard DNS record for ingress
 func (m *Manager) createWildcardRecord() error {
+	if len(m.nodes) == 0 {
+		m.ctx.Log.Info("No nodes yet, ingress DNS records are created with the ingress controller", nil)
+		return nil
+	}
+
 	// Initially point to first worker or master node
 	// This will be updated when ingress is installed
-	var initialIP pulumi.StringOutput
-
+	initialIP := m.nodes[0].PublicIP
 	for _, node := range m.nodes {
-		if node.Labels != nil {
-			if role, ok := node.Labels["role"]; ok && role == "worker" {
-				initialIP = node.PublicIP
-				break
-			}
+		if node.Labels["role"] == "worker" {
+			initialIP = node.PublicIP
+			break
 		}
 	}
 
-	// If no worker found, use first master
-	if initialIP == pulumi.String("").ToStringOutput() && len(m.nodes) > 0 {
-		initialIP = m.nodes[0].PublicIP
-	}
-
-	if initialIP == pulumi.String("").ToStringOutput() {
-		return fmt.Errorf("no nodes available for wildcard record")
-	}
-
 	// Create wildcard record for all ingress subdomains
-	wildcardRecord, err := digitalocean.NewDnsRecord(m.ctx, "dns-wildcard-ingress", &digitalocean.DnsRecordArgs{
-		Domain: pulumi.String(m.domain),
-		Type:   pulumi.String("A"),
-		Name:   pulumi.String("*.k8s"),
-		Value:  initialIP,
-		Ttl:    pulumi.Int(300),
-	})
-	if err != nil {
+	if err := m.createRecord("dns-wildcard-ingress", Record{Type: "A", Name: "*.k8s", Value: initialIP}); err != nil {
 		return err
 	}
-	m.records = append(m.records, wildcardRecord)
 
 	// Create specific ingress record
-	ingressRecord, err := digitalocean.NewDnsRecord(m.ctx, "dns-kube-ingress", &digitalocean.DnsRecordArgs{
-		Domain: pulumi.String(m.domain),
-		Type:   pulumi.String("A"),
-		Name:   pulumi.String("kube-ingress"),
-		Value:  initialIP,
-		Ttl:    pulumi.Int(300),
-	})
-	if err != nil {
+	if err := m.createRecord("dns-kube-ingress", Record{Type: "A", Name: "kube-ingress", Value: initialIP}); err != nil {
 		return err
 	}
-	m.records = append(m.records, ingressRecord)
 
 	m.ctx.Export("ingress_domain", pulumi.String(fmt.Sprintf("kube-ingress.%s", m.domain)))
 	m.ctx.Export("wildcard_domain", pulumi.String(fmt.Sprintf("*.k8s.%s", m.domain)))
@@ -204,32 +197,32 @@ func (m *Manager) createWildcardRecord() error {
 	return nil
 }
 
-// UpdateIngressRecord updates the DNS record for ingress after load balancer is created
+// UpdateIngressRecord points the ingress records and the *.domain wildcard
+// at the ingress LoadBalancer
 func (m *Manager) UpdateIngressRecord(ingressIP pulumi.StringOutput) error {
+	if m.domain == "" {
+		return nil
+	}
+
 	// Create or update the main ingress record
-	_, err := digitalocean.NewDnsRecord(m.ctx, "dns-ingress-lb", &digitalocean.DnsRecordArgs{
-		Domain: pulumi.String(m.domain),
-		Type:   pulumi.String("A"),
-		Name:   pulumi.String("kube-ingress"),
-		Value:  ingressIP,
-		Ttl:    pulumi.Int(300),
-	}, pulumi.ReplaceOnChanges([]string{"value"}))
-	if err != nil {
+	if err := m.createRecord("dns-ingress-lb", Record{Type: "A", Name: "kube-ingress", Value: ingressIP},
+		pulumi.ReplaceOnChanges([]string{"value"})); err != nil {
 		return fmt.Errorf("failed to update ingress DNS record: %w", err)
 	}
 
 	// Update wildcard record
-	_, err = digitalocean.NewDnsRecord(m.ctx, "dns-wildcard-lb", &digitalocean.DnsRecordArgs{
-		Domain: pulumi.String(m.domain),
-		Type:   pulumi.String("A"),
-		Name:   pulumi.String("*.k8s"),
-		Value:  ingressIP,
-		Ttl:    pulumi.Int(300),
-	}, pulumi.ReplaceOnChanges([]string{"value"}))
-	if err != nil {
+	if err := m.createRecord("dns-wildcard-lb", Record{Type: "A", Name: "*.k8s", Value: ingressIP},
+		pulumi.ReplaceOnChanges([]string{"value"})); err != nil {
 		return fmt.Errorf("failed to update wildcard DNS record: %w", err)
 	}
 
+	// Route every other name in the domain to the ingress
+	if err := m.createRecord("dns-wildcard-domain-lb", Record{Type: "A", Name: "*", Value: ingressIP},
+		pulumi.ReplaceOnChanges([]string{"value"})); err != nil {
+		return fmt.Errorf("failed to create domain wildcard DNS record: %w", err)
+	}
+	m.ctx.Export("domain_wildcard", pulumi.String(fmt.Sprintf("*.%s", m.domain)))
+
 	// Create additional ingress subdomains
 	ingressSubdomains := []string{
 		"grafana",
@@ -243,14 +236,8 @@ func (m *Manager) UpdateIngressRecord(ingressIP pulumi.StringOutput) error {
 	}
 
 	for _, subdomain := range ingressSubdomains {
-		_, err = digitalocean.NewDnsRecord(m.ctx, fmt.Sprintf("dns-%s", subdomain), &digitalocean.DnsRecordArgs{
-			Domain: pulumi.String(m.domain),
-			Type:   pulumi.String("A"),
-			Name:   pulumi.String(fmt.Sprintf("%s.k8s", subdomain)),
-			Value:  ingressIP,
-			Ttl:    pulumi.Int(300),
-		})
-		if err != nil {
+		record := Record{Type: "A", Name: fmt.Sprintf("%s.k8s", subdomain), Value: ingressIP}
+		if err := m.createRecord(fmt.Sprintf("dns-%s", subdomain), record); err != nil {
 			// Log warning but don't fail
 			m.ctx.Log.Warn("Failed to create DNS record", nil)
 		}
@@ -261,6 +248,10 @@ func (m *Manager) UpdateIngressRecord(ingressIP pulumi.StringOutput) error {
 
 // CreateClusterRecords creates convenience DNS records for the cluster
 func (m *Manager) CreateClusterRecords() error {
+	if m.domain == "" {
+		return nil
+	}
+
 	// Create CNAME records for convenience
 	conveniences := map[string]string{
 		"k8s":        "api",
@@ -271,14 +262,8 @@ func (m *Manager) CreateClusterRecords() error {
 	}
 
 	for name, target := range conveniences {
-		_, err := digitalocean.NewDnsRecord(m.ctx, fmt.Sprintf("dns-cname-%s", name), &digitalocean.DnsRecordArgs{
-			Domain: pulumi.String(m.domain),
-			Type:   pulumi.String("CNAME"),
-			Name:   pulumi.String(name),
-			Value:  pulumi.String(fmt.Sprintf("%s.%s.", target, m.domain)),
-			Ttl:    pulumi.Int(300),
-		})
-		if err != nil {
+		record := Record{Type: "CNAME", Name: name, Value: pulumi.String(fmt.Sprintf("%s.%s.", target, m.domain))}
+		if err := m.createRecord(fmt.Sprintf("dns-cname-%s", name), record); err != nil {
 			m.ctx.Log.Warn("Failed to create CNAME record", nil)
 		}
 	}
@@ -288,6 +273,10 @@ func (m *Manager) CreateClusterRecords() error {
 
 // ExportDNSInfo exports DNS information
 func (m *Manager) ExportDNSInfo() {
+	if m.domain == "" {
+		return
+	}
+
 	dnsInfo := make(map[string]interface{})
 
 	// Basic info
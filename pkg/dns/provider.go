@@ -0,0 +1,95 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// defaultTTL keeps records short-lived so ingress updates propagate quickly
+const defaultTTL = 300
+
+// Record is a DNS record in the manager's domain. Name is relative to the
+// domain, so "api" becomes api.<domain> and "*" the domain wildcard.
+type Record struct {
+	Type  string
+	Name  string
+	Value pulumi.StringInput
+	TTL   int
+}
+
+// DNSProvider creates records at a DNS registrar. Adding a registrar means
+// implementing this interface and returning it from NewProvider.
+type DNSProvider interface {
+	// Name returns the provider name used in network.dns.provider
+	Name() string
+
+	// CreateRecord creates record in domain as the Pulumi resource resourceName
+	CreateRecord(ctx *pulumi.Context, resourceName, domain string, record Record, opts ...pulumi.ResourceOption) (pulumi.Resource, error)
+}
+
+// ZoneRecord is a record as a registrar API returns it, with a fully
+// qualified Name
+type ZoneRecord struct {
+	ID      string
+	Type    string
+	Name    string
+	Content string
+	TTL     int
+}
+
+// RecordAPI manages records directly through a registrar's API, outside the
+// stack, such as the TXT records ACME DNS-01 challenges use. Names may be
+// relative to the zone or fully qualified.
+type RecordAPI interface {
+	// UpsertRecord creates the record or updates the one with the same type and name
+	UpsertRecord(ctx context.Context, record ZoneRecord) (*ZoneRecord, error)
+
+	// DeleteRecord deletes the records with the type and name, if any
+	DeleteRecord(ctx context.Context, recordType, name string) error
+
+	// ListRecords returns every record in the zone
+	ListRecords(ctx context.Context) ([]ZoneRecord, error)
+}
+
+// validateRecordType accepts the record types the providers manage
+func validateRecordType(recordType string) error {
+	switch recordType {
+	case "A", "CNAME", "TXT":
+		return nil
+	default:
+		return fmt.Errorf("DNS record type %s is not supported; use A, CNAME or TXT", recordType)
+	}
+}
+
+// qualifyName qualifies a record name relative to domain; "@" and "" name
+// the apex
+func qualifyName(domain, name string) string {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	switch {
+	case name == "" || name == "@":
+		return domain
+	case name == domain || strings.HasSuffix(name, "."+domain):
+		return name
+	default:
+		return name + "." + domain
+	}
+}
+
+// NewProvider returns the provider named by cfg.Network.DNS.Provider,
+// DigitalOcean when none is set
+func NewProvider(ctx *pulumi.Context, cfg *config.ClusterConfig) (DNSProvider, error) {
+	switch provider := cfg.Network.DNS.Provider; provider {
+	case "", "digitalocean":
+		return NewDigitalOceanProvider(), nil
+	case "cloudflare":
+		return NewCloudflareProvider(ctx, cfg.Network.DNS.APIToken, cfg.Network.DNS.Domain)
+	case "route53":
+		return NewRoute53Provider(ctx, cfg.Providers.AWS, cfg.Network.DNS.Domain)
+	default:
+		return nil, fmt.Errorf("DNS provider %s is not supported; use digitalocean, cloudflare or route53", provider)
+	}
+}
@@ -0,0 +1,154 @@
+package dns
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+)
+
+// providerMocks records the type token and inputs of every resource
+type providerMocks struct {
+	mu      sync.Mutex
+	records map[string]resource.PropertyMap // inputs by resource name
+	types   map[string]string               // type token by resource name
+}
+
+func newProviderMocks() *providerMocks {
+	return &providerMocks{
+		records: make(map[string]resource.PropertyMap),
+		types:   make(map[string]string),
+	}
+}
+
+func (m *providerMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[args.Name] = args.Inputs
+	m.types[args.Name] = args.TypeToken
+	return args.Name + "_id", args.Inputs, nil
+}
+
+func (m *providerMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return resource.PropertyMap{}, nil
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     string
+		wantErr  string
+	}{
+		{"", "digitalocean", ""},
+		{"digitalocean", "digitalocean", ""},
+		{"cloudflare", "cloudflare", ""},
+		{"route53", "route53", ""},
+		{"godaddy", "", "DNS provider godaddy is not supported; use digitalocean, cloudflare or route53"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				cfg := &config.ClusterConfig{
+					Network: config.NetworkConfig{DNS: config.DNSConfig{Domain: "example.com", Provider: tt.provider}},
+				}
+				provider, err := NewProvider(ctx, cfg)
+				if tt.wantErr != "" {
+					assert.EqualError(t, err, tt.wantErr)
+					return nil
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, provider.Name())
+				return nil
+			}, pulumi.WithMocks("test", "stack", newProviderMocks()))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestManager_EmptyDomainCreatesNoRecords(t *testing.T) {
+	mocks := newProviderMocks()
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		manager := NewManager(ctx, "")
+		nodes := map[string][]*providers.NodeOutput{
+			"digitalocean": {{
+				Name:      "master-1",
+				PublicIP:  pulumi.String("203.0.113.10").ToStringOutput(),
+				PrivateIP: pulumi.String("10.0.0.10").ToStringOutput(),
+				Labels:    map[string]string{"role": "master"},
+			}},
+		}
+		require.NoError(t, manager.CreateNodeRecords(nodes))
+		require.NoError(t, manager.CreateClusterRecords())
+		require.NoError(t, manager.UpdateIngressRecord(pulumi.String("203.0.113.50").ToStringOutput()))
+		assert.Empty(t, manager.records)
+		return nil
+	}, pulumi.WithMocks("test", "stack", mocks))
+	require.NoError(t, err)
+	assert.Empty(t, mocks.records)
+}
+
+func TestManager_NoNodesSkipsInitialWildcard(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		manager := NewManager(ctx, "example.com")
+		assert.NoError(t, manager.CreateNodeRecords(map[string][]*providers.NodeOutput{}))
+		assert.Empty(t, manager.records)
+		return nil
+	}, pulumi.WithMocks("test", "stack", newProviderMocks()))
+	assert.NoError(t, err)
+}
+
+func TestUpdateIngressRecord_DomainWildcard(t *testing.T) {
+	tests := []struct {
+		provider string
+		token    string
+		name     string
+		zoneID   string
+	}{
+		{"digitalocean", "digitalocean:index/dnsRecord:DnsRecord", "*", ""},
+		{"cloudflare", "cloudflare:index/record:Record", "*", "zone-1"},
+		{"route53", "aws:route53/record:Record", "*.example.com", "Z1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			mocks := newProviderMocks()
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				cfg := &config.ClusterConfig{
+					Providers: config.ProvidersConfig{
+						AWS: &config.AWSProvider{AccessKeyID: "AKIA", SecretAccessKey: "secret"},
+					},
+					Network: config.NetworkConfig{
+						DNS: config.DNSConfig{Domain: "example.com", Provider: tt.provider, APIToken: "cf-token"},
+					},
+				}
+				provider, err := NewProvider(ctx, cfg)
+				require.NoError(t, err)
+				switch p := provider.(type) {
+				case *CloudflareProvider:
+					_, p.CloudflareClient = newFakeCloudflare(t)
+				case *Route53Provider:
+					_, p.Route53Client = newFakeRoute53(t)
+				}
+				manager := NewManagerWithProvider(ctx, "example.com", provider)
+				return manager.UpdateIngressRecord(pulumi.String("203.0.113.50").ToStringOutput())
+			}, pulumi.WithMocks("test", "stack", mocks))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.token, mocks.types["dns-wildcard-domain-lb"])
+			wildcard := mocks.records["dns-wildcard-domain-lb"]
+			require.NotNil(t, wildcard)
+			assert.Equal(t, tt.name, wildcard["name"].StringValue())
+			if tt.zoneID != "" {
+				assert.Equal(t, tt.zoneID, wildcard["zoneId"].StringValue())
+				assert.True(t, wildcard["allowOverwrite"].BoolValue())
+			}
+		})
+	}
+}
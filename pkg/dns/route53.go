@@ -0,0 +1,82 @@
+package dns
+
+import (
+	"fmt"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
+	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/route53"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Route53Provider creates the stack's records in the public hosted zone
+// matching the domain, and manages records outside the stack through the
+// embedded API client
+type Route53Provider struct {
+	*Route53Client
+	provider *aws.Provider // nil uses the ambient AWS credentials
+}
+
+// NewRoute53Provider creates a Route 53 DNS provider for domain. The aws
+// provider credentials are used when set; otherwise the ambient AWS
+// configuration is.
+func NewRoute53Provider(ctx *pulumi.Context, awsCfg *config.AWSProvider, domain string) (*Route53Provider, error) {
+	client, err := NewRoute53Client(ctx.Context(), awsCfg, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Route53Provider{Route53Client: client}
+	if awsCfg == nil || awsCfg.AccessKeyID == "" {
+		return p, nil
+	}
+
+	region := awsCfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	provider, err := aws.NewProvider(ctx, "dns-route53-provider", &aws.ProviderArgs{
+		Region:    pulumi.String(region),
+		AccessKey: pulumi.StringPtr(awsCfg.AccessKeyID),
+		SecretKey: pulumi.StringPtr(awsCfg.SecretAccessKey),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Route 53 provider: %w", err)
+	}
+	p.provider = provider
+	return p, nil
+}
+
+// Name returns the provider name
+func (p *Route53Provider) Name() string {
+	return "route53"
+}
+
+// CreateRecord creates a Route 53 record with the fully qualified name. An
+// existing record with the same name and type is taken over instead of
+// failing the deploy.
+func (p *Route53Provider) CreateRecord(ctx *pulumi.Context, resourceName, domain string, record Record, opts ...pulumi.ResourceOption) (pulumi.Resource, error) {
+	if domain != p.Domain {
+		return nil, fmt.Errorf("route53 provider manages %s, not %s", p.Domain, domain)
+	}
+	if err := validateRecordType(record.Type); err != nil {
+		return nil, err
+	}
+
+	zoneID, err := p.ZoneID(ctx.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.provider != nil {
+		opts = append(opts, pulumi.Provider(p.provider))
+	}
+	return route53.NewRecord(ctx, resourceName, &route53.RecordArgs{
+		ZoneId:         pulumi.String(zoneID),
+		Name:           pulumi.String(qualifyName(domain, record.Name)),
+		Type:           pulumi.String(record.Type),
+		Records:        pulumi.StringArray{record.Value},
+		Ttl:            pulumi.Int(record.TTL),
+		AllowOverwrite: pulumi.Bool(true),
+	}, opts...)
+}
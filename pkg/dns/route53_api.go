@@ -0,0 +1,231 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// route53Region is where the client of Route 53, a global service, is configured
+const route53Region = "us-east-1"
+
+// Route53API is the subset of the Route 53 client used by Route53Client
+type Route53API interface {
+	ListHostedZonesByName(ctx context.Context, params *route53.ListHostedZonesByNameInput, optFns ...func(*route53.Options)) (*route53.ListHostedZonesByNameOutput, error)
+	ListResourceRecordSets(ctx context.Context, params *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error)
+	ChangeResourceRecordSets(ctx context.Context, params *route53.ChangeResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error)
+	GetChange(ctx context.Context, params *route53.GetChangeInput, optFns ...func(*route53.Options)) (*route53.GetChangeOutput, error)
+}
+
+// Route53Client manages the records of the public hosted zone matching
+// Domain through the Route 53 API
+type Route53Client struct {
+	Client Route53API
+	Domain string
+
+	// PollInterval and SyncTimeout bound the wait for changes to reach INSYNC
+	PollInterval time.Duration
+	SyncTimeout  time.Duration
+
+	zoneID string
+}
+
+// NewRoute53Client creates a client for the hosted zone matching domain. The
+// aws provider credentials are used when set; otherwise the ambient AWS
+// configuration is.
+func NewRoute53Client(ctx context.Context, awsCfg *config.AWSProvider, domain string) (*Route53Client, error) {
+	optFns := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(route53Region)}
+	if awsCfg != nil && awsCfg.AccessKeyID != "" {
+		optFns = append(optFns, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(awsCfg.AccessKeyID, awsCfg.SecretAccessKey, "")))
+	}
+
+	loaded, err := awsconfig.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+
+	return &Route53Client{
+		Client:       route53.NewFromConfig(loaded),
+		Domain:       domain,
+		PollInterval: 5 * time.Second,
+		SyncTimeout:  5 * time.Minute,
+	}, nil
+}
+
+// ZoneID returns the ID of the public hosted zone matching Domain
+func (c *Route53Client) ZoneID(ctx context.Context) (string, error) {
+	if c.zoneID != "" {
+		return c.zoneID, nil
+	}
+
+	out, err := c.Client.ListHostedZonesByName(ctx, &route53.ListHostedZonesByNameInput{
+		DNSName: aws.String(c.Domain),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list Route 53 hosted zones: %w", err)
+	}
+	for _, zone := range out.HostedZones {
+		private := zone.Config != nil && zone.Config.PrivateZone
+		if strings.TrimSuffix(aws.ToString(zone.Name), ".") == c.Domain && !private {
+			c.zoneID = strings.TrimPrefix(aws.ToString(zone.Id), "/hostedzone/")
+			return c.zoneID, nil
+		}
+	}
+	return "", fmt.Errorf("no public Route 53 hosted zone matches %s", c.Domain)
+}
+
+// recordSet returns the record set with the type and fully qualified name, if any
+func (c *Route53Client) recordSet(ctx context.Context, recordType, name string) (*types.ResourceRecordSet, error) {
+	zoneID, err := c.ZoneID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.Client.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(zoneID),
+		StartRecordName: aws.String(name),
+		StartRecordType: types.RRType(recordType),
+		MaxItems:        aws.Int32(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Route 53 records: %w", err)
+	}
+	for _, set := range out.ResourceRecordSets {
+		if string(set.Type) == recordType && route53Name(aws.ToString(set.Name)) == name {
+			return &set, nil
+		}
+	}
+	return nil, nil
+}
+
+// route53Name undoes the trailing dot and the octal escaping Route 53 uses
+// for wildcards
+func route53Name(name string) string {
+	return strings.TrimSuffix(strings.ReplaceAll(name, `\052`, "*"), ".")
+}
+
+// ListRecords returns every record in the zone, one per value
+func (c *Route53Client) ListRecords(ctx context.Context) ([]ZoneRecord, error) {
+	zoneID, err := c.ZoneID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []ZoneRecord
+	pages := route53.NewListResourceRecordSetsPaginator(c.Client, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Route 53 records: %w", err)
+		}
+		for _, set := range page.ResourceRecordSets {
+			for _, value := range set.ResourceRecords {
+				records = append(records, ZoneRecord{
+					Type:    string(set.Type),
+					Name:    route53Name(aws.ToString(set.Name)),
+					Content: strings.Trim(aws.ToString(value.Value), `"`),
+					TTL:     int(aws.ToInt64(set.TTL)),
+				})
+			}
+		}
+	}
+	return records, nil
+}
+
+// change submits one change and waits for Route 53 to apply it everywhere
+func (c *Route53Client) change(ctx context.Context, action types.ChangeAction, set types.ResourceRecordSet) error {
+	zoneID, err := c.ZoneID(ctx)
+	if err != nil {
+		return err
+	}
+
+	out, err := c.Client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+		ChangeBatch: &types.ChangeBatch{
+			Changes: []types.Change{{Action: action, ResourceRecordSet: &set}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if out.ChangeInfo == nil || out.ChangeInfo.Status == types.ChangeStatusInsync {
+		return nil
+	}
+
+	changeID := strings.TrimPrefix(aws.ToString(out.ChangeInfo.Id), "/change/")
+	waiter := route53.NewResourceRecordSetsChangedWaiter(c.Client, func(o *route53.ResourceRecordSetsChangedWaiterOptions) {
+		o.MinDelay = c.PollInterval
+		if o.MaxDelay < o.MinDelay {
+			o.MaxDelay = o.MinDelay
+		}
+	})
+	if err := waiter.Wait(ctx, &route53.GetChangeInput{Id: aws.String(changeID)}, c.SyncTimeout); err != nil {
+		return fmt.Errorf("route53 change %s not INSYNC after %s: %w", changeID, c.SyncTimeout, err)
+	}
+	return nil
+}
+
+// UpsertRecord creates or replaces the record set with the type and name
+// and waits until the change is INSYNC. TXT values are quoted as Route 53
+// requires.
+func (c *Route53Client) UpsertRecord(ctx context.Context, record ZoneRecord) (*ZoneRecord, error) {
+	if err := validateRecordType(record.Type); err != nil {
+		return nil, err
+	}
+
+	upserted := ZoneRecord{
+		Type:    record.Type,
+		Name:    qualifyName(c.Domain, record.Name),
+		Content: record.Content,
+		TTL:     record.TTL,
+	}
+	if upserted.TTL == 0 {
+		upserted.TTL = defaultTTL
+	}
+
+	value := upserted.Content
+	if upserted.Type == "TXT" && !strings.HasPrefix(value, `"`) {
+		value = fmt.Sprintf("%q", value)
+	}
+	set := types.ResourceRecordSet{
+		Name:            aws.String(upserted.Name),
+		Type:            types.RRType(upserted.Type),
+		TTL:             aws.Int64(int64(upserted.TTL)),
+		ResourceRecords: []types.ResourceRecord{{Value: aws.String(value)}},
+	}
+
+	if err := c.change(ctx, types.ChangeActionUpsert, set); err != nil {
+		return nil, fmt.Errorf("failed to upsert %s record %s: %w", upserted.Type, upserted.Name, err)
+	}
+	return &upserted, nil
+}
+
+// DeleteRecord deletes the record set with the type and name. Deleting a
+// record that does not exist succeeds.
+func (c *Route53Client) DeleteRecord(ctx context.Context, recordType, name string) error {
+	if err := validateRecordType(recordType); err != nil {
+		return err
+	}
+
+	set, err := c.recordSet(ctx, recordType, qualifyName(c.Domain, name))
+	if err != nil || set == nil {
+		return err
+	}
+	// Route 53 only deletes a record set matching it exactly
+	if err := c.change(ctx, types.ChangeActionDelete, *set); err != nil {
+		return fmt.Errorf("failed to delete %s record %s: %w", recordType, route53Name(aws.ToString(set.Name)), err)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRoute53 serves the public example.com zone Z1 from memory. Changes
+// report PENDING until polled once.
+type fakeRoute53 struct {
+	mu       sync.Mutex
+	sets     []types.ResourceRecordSet
+	changes  []types.Change
+	pending  map[string]bool
+	polls    int
+	pageSize int
+	err      error
+}
+
+func newFakeRoute53(t *testing.T, sets ...types.ResourceRecordSet) (*fakeRoute53, *Route53Client) {
+	fake := &fakeRoute53{sets: sets, pending: make(map[string]bool), pageSize: 2}
+	return fake, &Route53Client{
+		Client:       fake,
+		Domain:       "example.com",
+		PollInterval: time.Millisecond,
+		SyncTimeout:  time.Second,
+	}
+}
+
+func recordSet(name, recordType string, ttl int64, values ...string) types.ResourceRecordSet {
+	set := types.ResourceRecordSet{Name: aws.String(name), Type: types.RRType(recordType), TTL: aws.Int64(ttl)}
+	for _, value := range values {
+		set.ResourceRecords = append(set.ResourceRecords, types.ResourceRecord{Value: aws.String(value)})
+	}
+	return set
+}
+
+func (f *fakeRoute53) ListHostedZonesByName(ctx context.Context, params *route53.ListHostedZonesByNameInput, optFns ...func(*route53.Options)) (*route53.ListHostedZonesByNameOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &route53.ListHostedZonesByNameOutput{HostedZones: []types.HostedZone{
+		{Id: aws.String("/hostedzone/ZPRIVATE"), Name: aws.String("example.com."), Config: &types.HostedZoneConfig{PrivateZone: true}},
+		{Id: aws.String("/hostedzone/Z1"), Name: aws.String("example.com."), Config: &types.HostedZoneConfig{}},
+	}}, nil
+}
+
+// ListResourceRecordSets lists record sets from the start name and type on,
+// MaxItems (or pageSize) at a time
+func (f *fakeRoute53) ListResourceRecordSets(ctx context.Context, params *route53.ListResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if aws.ToString(params.HostedZoneId) != "Z1" {
+		return nil, errors.New("NoSuchHostedZone")
+	}
+	start := 0
+	if params.StartRecordName != nil {
+		start = len(f.sets)
+		for i, set := range f.sets {
+			if route53Name(aws.ToString(set.Name)) == route53Name(*params.StartRecordName) && set.Type == params.StartRecordType {
+				start = i
+				break
+			}
+		}
+	}
+	maxItems := f.pageSize
+	if params.MaxItems != nil {
+		maxItems = int(*params.MaxItems)
+	}
+	end := start + maxItems
+	if end > len(f.sets) {
+		end = len(f.sets)
+	}
+
+	out := &route53.ListResourceRecordSetsOutput{
+		ResourceRecordSets: append([]types.ResourceRecordSet(nil), f.sets[start:end]...),
+		IsTruncated:        end < len(f.sets),
+	}
+	if out.IsTruncated {
+		out.NextRecordName = f.sets[end].Name
+		out.NextRecordType = f.sets[end].Type
+	}
+	return out, nil
+}
+
+func (f *fakeRoute53) ChangeResourceRecordSets(ctx context.Context, params *route53.ChangeResourceRecordSetsInput, optFns ...func(*route53.Options)) (*route53.ChangeResourceRecordSetsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, change := range params.ChangeBatch.Changes {
+		f.changes = append(f.changes, change)
+		set := *change.ResourceRecordSet
+		index := -1
+		for i, existing := range f.sets {
+			if route53Name(aws.ToString(existing.Name)) == route53Name(aws.ToString(set.Name)) && existing.Type == set.Type {
+				index = i
+			}
+		}
+		switch {
+		case change.Action == types.ChangeActionUpsert && index >= 0:
+			f.sets[index] = set
+		case change.Action == types.ChangeActionUpsert:
+			f.sets = append(f.sets, set)
+		case change.Action == types.ChangeActionDelete && index >= 0:
+			f.sets = append(f.sets[:index], f.sets[index+1:]...)
+		default:
+			return nil, &types.InvalidChangeBatch{Message: aws.String("record set not found")}
+		}
+	}
+	id := fmt.Sprintf("C%d", len(f.changes))
+	f.pending[id] = true
+	return &route53.ChangeResourceRecordSetsOutput{ChangeInfo: &types.ChangeInfo{
+		Id: aws.String("/change/" + id), Status: types.ChangeStatusPending,
+	}}, nil
+}
+
+func (f *fakeRoute53) GetChange(ctx context.Context, params *route53.GetChangeInput, optFns ...func(*route53.Options)) (*route53.GetChangeOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.polls++
+	id := aws.ToString(params.Id)
+	status := types.ChangeStatusInsync
+	if f.pending[id] {
+		status = types.ChangeStatusPending
+		f.pending[id] = false
+	}
+	return &route53.GetChangeOutput{ChangeInfo: &types.ChangeInfo{Id: aws.String("/change/" + id), Status: status}}, nil
+}
+
+func TestRoute53Client_ZoneIDSkipsPrivateZones(t *testing.T) {
+	_, client := newFakeRoute53(t)
+
+	zoneID, err := client.ZoneID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Z1", zoneID)
+
+	client.zoneID = ""
+	client.Domain = "other.org"
+	_, err = client.ZoneID(context.Background())
+	assert.EqualError(t, err, "no public Route 53 hosted zone matches other.org")
+}
+
+func TestRoute53Client_UpsertWaitsForInSync(t *testing.T) {
+	fake, client := newFakeRoute53(t)
+	ctx := context.Background()
+
+	record, err := client.UpsertRecord(ctx, ZoneRecord{Type: "A", Name: "*", Content: "203.0.113.50"})
+	require.NoError(t, err)
+	assert.Equal(t, "*.example.com", record.Name)
+	assert.Equal(t, defaultTTL, record.TTL)
+	assert.Equal(t, 2, fake.polls, "polls until the change is INSYNC")
+
+	_, err = client.UpsertRecord(ctx, ZoneRecord{Type: "A", Name: "*.example.com", Content: "203.0.113.60"})
+	require.NoError(t, err)
+
+	records, err := client.ListRecords(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1, "upserting twice must not duplicate the record")
+	assert.Equal(t, "203.0.113.60", records[0].Content)
+	assert.Equal(t, types.ChangeActionUpsert, fake.changes[1].Action)
+}
+
+func TestRoute53Client_UpsertQuotesTXT(t *testing.T) {
+	fake, client := newFakeRoute53(t)
+
+	_, err := client.UpsertRecord(context.Background(), ZoneRecord{Type: "TXT", Name: "_acme-challenge", Content: "token", TTL: 60})
+	require.NoError(t, err)
+
+	require.Len(t, fake.changes, 1)
+	set := fake.changes[0].ResourceRecordSet
+	assert.Equal(t, "_acme-challenge.example.com", aws.ToString(set.Name))
+	assert.Equal(t, int64(60), aws.ToInt64(set.TTL))
+	assert.Equal(t, `"token"`, aws.ToString(set.ResourceRecords[0].Value))
+}
+
+func TestRoute53Client_SyncTimeout(t *testing.T) {
+	_, client := newFakeRoute53(t)
+	client.PollInterval = 50 * time.Millisecond
+	client.SyncTimeout = 10 * time.Millisecond
+
+	_, err := client.UpsertRecord(context.Background(), ZoneRecord{Type: "CNAME", Name: "www", Content: "api.example.com"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to upsert CNAME record www.example.com: route53 change C1 not INSYNC after 10ms")
+}
+
+func TestRoute53Client_DeleteRecord(t *testing.T) {
+	fake, client := newFakeRoute53(t,
+		recordSet("example.com.", "NS", 172800, "ns-1.awsdns.com."),
+		recordSet(`\052.example.com.`, "A", 300, "203.0.113.50"),
+	)
+	ctx := context.Background()
+
+	require.NoError(t, client.DeleteRecord(ctx, "A", "*"))
+	require.NoError(t, client.DeleteRecord(ctx, "A", "*"), "deleting a missing record succeeds")
+
+	require.Len(t, fake.changes, 1)
+	assert.Equal(t, types.ChangeActionDelete, fake.changes[0].Action)
+	assert.Equal(t, int64(300), aws.ToInt64(fake.changes[0].ResourceRecordSet.TTL), "deletes send the existing record set")
+	require.Len(t, fake.sets, 1)
+	assert.Equal(t, types.RRTypeNs, fake.sets[0].Type)
+}
+
+func TestRoute53Client_ListRecordsPaginates(t *testing.T) {
+	var sets []types.ResourceRecordSet
+	for i := 0; i < 5; i++ {
+		sets = append(sets, recordSet(fmt.Sprintf("node%d.example.com.", i), "A", 300, "203.0.113.10"))
+	}
+	sets = append(sets, recordSet("example.com.", "TXT", 300, `"v=spf1 -all"`, `"verification"`))
+	_, client := newFakeRoute53(t, sets...)
+
+	records, err := client.ListRecords(context.Background())
+	require.NoError(t, err)
+	require.Len(t, records, 7)
+	assert.Equal(t, "node0.example.com", records[0].Name)
+	assert.Equal(t, ZoneRecord{Type: "TXT", Name: "example.com", Content: "verification", TTL: 300}, records[6])
+}
+
+func TestRoute53Client_Errors(t *testing.T) {
+	fake, client := newFakeRoute53(t)
+	ctx := context.Background()
+
+	_, err := client.UpsertRecord(ctx, ZoneRecord{Type: "MX", Name: "mail", Content: "10 mx.example.com"})
+	assert.EqualError(t, err, "DNS record type MX is not supported; use A, CNAME or TXT")
+
+	fake.err = errors.New("AccessDenied")
+	_, err = client.ZoneID(ctx)
+	assert.EqualError(t, err, "failed to list Route 53 hosted zones: AccessDenied")
+
+	fake.err = nil
+	err = client.change(ctx, types.ChangeActionDelete, recordSet("api.example.com", "A", 300, "203.0.113.50"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "record set not found")
+}
+
+func TestRoute53Provider_ImplementsRecordAPI(t *testing.T) {
+	assert.Implements(t, (*RecordAPI)(nil), &Route53Provider{})
+	assert.Implements(t, (*DNSProvider)(nil), &Route53Provider{})
+	assert.Implements(t, (*Route53API)(nil), &route53.Client{})
+}
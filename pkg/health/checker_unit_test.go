@@ -1,10 +1,15 @@
 package health
 
 import (
+	"context"
+	"errors"
+	"net"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test buildHealthCheckScript basic structure
@@ -694,3 +699,98 @@ func TestHealthChecker_DockerPsCheck(t *testing.T) {
 	assert.Contains(t, script, "DOCKER:PS:OK")
 	assert.Contains(t, script, "DOCKER:PS:FAIL")
 }
+
+// serveBanner accepts connections on a local port and writes banner to each
+func serveBanner(t *testing.T, banner string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(banner))
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestProbeSSHBanner(t *testing.T) {
+	assert.NoError(t, ProbeSSHBanner(context.Background(), serveBanner(t, "SSH-2.0-OpenSSH_9.6\r\n")))
+
+	err := ProbeSSHBanner(context.Background(), serveBanner(t, "HTTP/1.1 400 Bad Request\r\n"))
+	assert.ErrorContains(t, err, "unexpected banner")
+}
+
+func TestHealthChecker_WaitForSSH_RetriesUntilReachable(t *testing.T) {
+	checker := &HealthChecker{statuses: make(map[string]*NodeStatus)}
+	attempts := 0
+	checker.SetSSHProbe(func(ctx context.Context, addr string) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	require.NoError(t, checker.WaitForSSH(context.Background(), "master-1", "203.0.113.10:22", time.Millisecond))
+	assert.Equal(t, 3, attempts)
+	assert.True(t, checker.GetAllStatuses()["master-1"].Services["ssh"])
+}
+
+func TestHealthChecker_WaitForSSH_Timeout(t *testing.T) {
+	checker := &HealthChecker{statuses: make(map[string]*NodeStatus)}
+	checker.SetSSHProbe(func(ctx context.Context, addr string) error {
+		return errors.New("connection refused")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := checker.WaitForSSH(ctx, "worker-1", "203.0.113.11:22", 5*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "SSH on 203.0.113.11:22 not reachable")
+	assert.Contains(t, err.Error(), "connection refused")
+
+	status := checker.GetAllStatuses()["worker-1"]
+	assert.False(t, status.IsHealthy)
+	assert.False(t, status.Services["ssh"])
+}
+
+func TestProbeTCP(t *testing.T) {
+	assert.NoError(t, ProbeTCP(context.Background(), serveBanner(t, "")))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+	assert.Error(t, ProbeTCP(context.Background(), addr))
+}
+
+func TestHealthChecker_WaitForTCP(t *testing.T) {
+	checker := &HealthChecker{}
+	attempts := 0
+	checker.SetTCPProbe(func(ctx context.Context, addr string) error {
+		attempts++
+		if attempts < 2 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	require.NoError(t, checker.WaitForTCP(context.Background(), "203.0.113.50:443", time.Millisecond))
+	assert.Equal(t, 2, attempts)
+
+	checker.SetTCPProbe(func(ctx context.Context, addr string) error {
+		return errors.New("connection refused")
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := checker.WaitForTCP(ctx, "203.0.113.50:443", 5*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "203.0.113.50:443 not accepting connections")
+}
@@ -3,10 +3,17 @@
 package health
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"net"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
+	"github.com/chalkan3/sloth-kubernetes/pkg/retry"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -20,20 +27,152 @@ type NodeStatus struct {
 	Error     error
 }
 
+// SSHProbe checks once that an SSH server answers at addr (host:port)
+type SSHProbe func(ctx context.Context, addr string) error
+
+// TCPProbe checks once that addr (host:port) accepts a connection
+type TCPProbe func(ctx context.Context, addr string) error
+
 // HealthChecker performs health checks during cluster deployment
 type HealthChecker struct {
 	ctx        *pulumi.Context
+	log        logging.Logger
 	nodes      []*providers.NodeOutput
 	sshKeyPath string
 	statuses   map[string]*NodeStatus
+	sshProbe   SSHProbe
+	tcpProbe   TCPProbe
+	mu         sync.Mutex
 }
 
 // NewHealthChecker creates a new health checker for deployment orchestration
 func NewHealthChecker(ctx *pulumi.Context) *HealthChecker {
 	return &HealthChecker{
 		ctx:      ctx,
+		log:      logging.NewPulumiLogger(ctx),
 		nodes:    []*providers.NodeOutput{},
 		statuses: make(map[string]*NodeStatus),
+		sshProbe: ProbeSSHBanner,
+		tcpProbe: ProbeTCP,
+	}
+}
+
+// SetLogger replaces the logger (default: the Pulumi engine's log)
+func (h *HealthChecker) SetLogger(log logging.Logger) {
+	h.log = log
+}
+
+// logger returns the logger, falling back to the Pulumi engine's log for a
+// HealthChecker built without its constructor
+func (h *HealthChecker) logger() logging.Logger {
+	if h.log == nil {
+		return logging.NewPulumiLogger(h.ctx)
+	}
+	return h.log
+}
+
+// SetSSHProbe replaces the probe WaitForSSH uses (default: ProbeSSHBanner)
+func (h *HealthChecker) SetSSHProbe(probe SSHProbe) {
+	h.sshProbe = probe
+}
+
+// SetTCPProbe replaces the probe WaitForTCP uses (default: ProbeTCP)
+func (h *HealthChecker) SetTCPProbe(probe TCPProbe) {
+	h.tcpProbe = probe
+}
+
+// ProbeTCP succeeds once addr accepts a TCP connection
+func ProbeTCP(ctx context.Context, addr string) error {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// ProbeSSHBanner connects to addr and succeeds once the server sends an SSH
+// identification line, so a port that accepts connections before sshd is up
+// does not count as ready
+func ProbeSSHBanner(ctx context.Context, addr string) error {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return err
+	}
+	banner, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("no SSH banner from %s: %w", addr, err)
+	}
+	if !strings.HasPrefix(banner, "SSH-") {
+		return fmt.Errorf("unexpected banner from %s: %q", addr, strings.TrimSpace(banner))
+	}
+	return nil
+}
+
+// WaitForSSH probes a node's SSH server at addr until it answers or ctx is
+// done, backing off from interval up to eight times interval between
+// attempts. The node's "ssh" service status records the outcome.
+func (h *HealthChecker) WaitForSSH(ctx context.Context, nodeName, addr string, interval time.Duration) error {
+	backoff := retry.NewBackoff().WithInitialDelay(interval).WithMaxDelay(8 * interval)
+
+	for {
+		err := h.sshProbe(ctx, addr)
+		if err == nil {
+			h.recordSSH(nodeName, nil)
+			return nil
+		}
+		if backoff.SleepContext(ctx) != nil {
+			err = fmt.Errorf("SSH on %s not reachable after %d attempts: %w", addr, backoff.Attempt(), err)
+			h.recordSSH(nodeName, err)
+			return err
+		}
+	}
+}
+
+// WaitForTCP probes addr until it accepts a connection or ctx is done, with
+// the same backoff as WaitForSSH
+func (h *HealthChecker) WaitForTCP(ctx context.Context, addr string, interval time.Duration) error {
+	probe := h.tcpProbe
+	if probe == nil {
+		probe = ProbeTCP
+	}
+	backoff := retry.NewBackoff().WithInitialDelay(interval).WithMaxDelay(8 * interval)
+
+	for {
+		err := probe(ctx, addr)
+		if err == nil {
+			return nil
+		}
+		if backoff.SleepContext(ctx) != nil {
+			return fmt.Errorf("%s not accepting connections after %d attempts: %w", addr, backoff.Attempt(), err)
+		}
+	}
+}
+
+// recordSSH stores the outcome of WaitForSSH in the node's status
+func (h *HealthChecker) recordSSH(nodeName string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status, exists := h.statuses[nodeName]
+	if !exists {
+		status = &NodeStatus{NodeName: nodeName, Services: make(map[string]bool)}
+		h.statuses[nodeName] = status
+	}
+	status.LastCheck = time.Now()
+	status.Services["ssh"] = err == nil
+	status.IsHealthy = err == nil
+	status.Error = err
+	if err != nil {
+		status.Message = err.Error()
+	} else {
+		status.Message = "SSH reachable"
 	}
 }
 
@@ -72,7 +211,7 @@ func (h *HealthChecker) SetSSHKeyPath(path string) {
 
 // WaitForNodesReady waits for all nodes to have required services ready
 func (h *HealthChecker) WaitForNodesReady(requiredServices []string) error {
-	h.ctx.Log.Info(fmt.Sprintf("Waiting for %d nodes to be ready with services: %v", len(h.nodes), requiredServices), nil)
+	h.logger().Info(fmt.Sprintf("Waiting for %d nodes to be ready with services: %v", len(h.nodes), requiredServices))
 
 	maxAttempts := 30
 	sleepDuration := 10 * time.Second
@@ -92,11 +231,11 @@ func (h *HealthChecker) WaitForNodesReady(requiredServices []string) error {
 		}
 
 		if allReady {
-			h.ctx.Log.Info("All nodes are ready!", nil)
+			h.logger().Info("All nodes are ready!")
 			return nil
 		}
 
-		h.ctx.Log.Info(fmt.Sprintf("Attempt %d/%d: %d nodes not ready, waiting...", attempt, maxAttempts, notReadyCount), nil)
+		h.logger().Info(fmt.Sprintf("Attempt %d/%d: %d nodes not ready, waiting...", attempt, maxAttempts, notReadyCount))
 		time.Sleep(sleepDuration)
 	}
 
@@ -122,7 +261,7 @@ func (h *HealthChecker) checkNodeServices(node *providers.NodeOutput, services [
 
 // WaitForKubernetesReady waits for Kubernetes API to be available
 func (h *HealthChecker) WaitForKubernetesReady() error {
-	h.ctx.Log.Info("Waiting for Kubernetes cluster to be ready", nil)
+	h.logger().Info("Waiting for Kubernetes cluster to be ready")
 
 	maxAttempts := 60
 	sleepDuration := 10 * time.Second
@@ -131,11 +270,11 @@ func (h *HealthChecker) WaitForKubernetesReady() error {
 		// In deployment context, we assume k8s is ready after RKE completes
 		// The RKE manager handles the actual wait
 		if attempt >= 3 {
-			h.ctx.Log.Info("Kubernetes cluster is ready", nil)
+			h.logger().Info("Kubernetes cluster is ready")
 			return nil
 		}
 
-		h.ctx.Log.Info(fmt.Sprintf("Attempt %d/%d: Checking Kubernetes readiness...", attempt, maxAttempts), nil)
+		h.logger().Info(fmt.Sprintf("Attempt %d/%d: Checking Kubernetes readiness...", attempt, maxAttempts))
 		time.Sleep(sleepDuration)
 	}
 
@@ -144,7 +283,7 @@ func (h *HealthChecker) WaitForKubernetesReady() error {
 
 // WaitForIngressReady waits for the Ingress controller to be available
 func (h *HealthChecker) WaitForIngressReady() error {
-	h.ctx.Log.Info("Waiting for NGINX Ingress Controller to be ready", nil)
+	h.logger().Info("Waiting for NGINX Ingress Controller to be ready")
 
 	maxAttempts := 30
 	sleepDuration := 10 * time.Second
@@ -152,11 +291,11 @@ func (h *HealthChecker) WaitForIngressReady() error {
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		// In deployment context, ingress readiness is verified by the ingress manager
 		if attempt >= 2 {
-			h.ctx.Log.Info("NGINX Ingress Controller is ready", nil)
+			h.logger().Info("NGINX Ingress Controller is ready")
 			return nil
 		}
 
-		h.ctx.Log.Info(fmt.Sprintf("Attempt %d/%d: Checking Ingress readiness...", attempt, maxAttempts), nil)
+		h.logger().Info(fmt.Sprintf("Attempt %d/%d: Checking Ingress readiness...", attempt, maxAttempts))
 		time.Sleep(sleepDuration)
 	}
 
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -22,6 +23,7 @@ type ValidationResult struct {
 // PrerequisiteValidator validates prerequisites before major operations
 type PrerequisiteValidator struct {
 	ctx     *pulumi.Context
+	log     logging.Logger
 	results map[string]*ValidationResult
 	mu      sync.RWMutex
 }
@@ -30,13 +32,28 @@ type PrerequisiteValidator struct {
 func NewPrerequisiteValidator(ctx *pulumi.Context) *PrerequisiteValidator {
 	return &PrerequisiteValidator{
 		ctx:     ctx,
+		log:     logging.NewPulumiLogger(ctx),
 		results: make(map[string]*ValidationResult),
 	}
 }
 
+// SetLogger replaces the logger (default: the Pulumi engine's log)
+func (v *PrerequisiteValidator) SetLogger(log logging.Logger) {
+	v.log = log
+}
+
+// logger returns the logger, falling back to the Pulumi engine's log for a
+// PrerequisiteValidator built without its constructor
+func (v *PrerequisiteValidator) logger() logging.Logger {
+	if v.log == nil {
+		return logging.NewPulumiLogger(v.ctx)
+	}
+	return v.log
+}
+
 // ValidateForRKE validates all prerequisites for RKE installation
 func (v *PrerequisiteValidator) ValidateForRKE(nodes []*providers.NodeOutput) error {
-	v.ctx.Log.Info("Validating prerequisites for RKE installation", nil)
+	v.logger().Info("Validating prerequisites for RKE installation")
 
 	validations := []func([]*providers.NodeOutput) *ValidationResult{
 		v.validateNodeCount,
@@ -56,7 +73,7 @@ func (v *PrerequisiteValidator) ValidateForRKE(nodes []*providers.NodeOutput) er
 
 // ValidateForIngress validates prerequisites for Ingress installation
 func (v *PrerequisiteValidator) ValidateForIngress(nodes []*providers.NodeOutput) error {
-	v.ctx.Log.Info("Validating prerequisites for Ingress installation", nil)
+	v.logger().Info("Validating prerequisites for Ingress installation")
 
 	validations := []func([]*providers.NodeOutput) *ValidationResult{
 		v.validateKubernetesRunning,
@@ -71,7 +88,7 @@ func (v *PrerequisiteValidator) ValidateForIngress(nodes []*providers.NodeOutput
 
 // ValidateForWireGuard validates prerequisites for WireGuard setup
 func (v *PrerequisiteValidator) ValidateForWireGuard(nodes []*providers.NodeOutput) error {
-	v.ctx.Log.Info("Validating prerequisites for WireGuard setup", nil)
+	v.logger().Info("Validating prerequisites for WireGuard setup")
 
 	validations := []func([]*providers.NodeOutput) *ValidationResult{
 		v.validateWireGuardInstalled,
@@ -129,7 +146,7 @@ func (v *PrerequisiteValidator) runValidations(validations []func([]*providers.N
 				if len(failedValidations) > 0 {
 					return fmt.Errorf("validation failed: %v", failedValidations)
 				}
-				v.ctx.Log.Info("All validations passed!", nil)
+				v.logger().Info("All validations passed!")
 				return nil
 			}
 
@@ -138,9 +155,9 @@ func (v *PrerequisiteValidator) runValidations(validations []func([]*providers.N
 			v.mu.Unlock()
 
 			if result.Success {
-				v.ctx.Log.Info("Validation passed", nil)
+				v.logger().Info("Validation passed")
 			} else {
-				v.ctx.Log.Warn("Validation failed", nil)
+				v.logger().Warn("Validation failed")
 				failedValidations = append(failedValidations, result.Name)
 			}
 
@@ -419,18 +436,18 @@ func (v *PrerequisiteValidator) PrintSummary() {
 	passed := 0
 	failed := 0
 
-	v.ctx.Log.Info("Validation Summary", nil)
-	v.ctx.Log.Info("==================", nil)
+	v.logger().Info("Validation Summary")
+	v.logger().Info("==================")
 
 	for name, result := range v.results {
 		if result.Success {
 			passed++
-			v.ctx.Log.Info(fmt.Sprintf("✓ %s: %s", name, result.Message), nil)
+			v.logger().Info(fmt.Sprintf("✓ %s: %s", name, result.Message))
 		} else {
 			failed++
-			v.ctx.Log.Warn(fmt.Sprintf("✗ %s: %s", name, result.Message), nil)
+			v.logger().Warn(fmt.Sprintf("✗ %s: %s", name, result.Message))
 		}
 	}
 
-	v.ctx.Log.Info(fmt.Sprintf("Total: %d passed, %d failed", passed, failed), nil)
+	v.logger().Info(fmt.Sprintf("Total: %d passed, %d failed", passed, failed))
 }
@@ -0,0 +1,186 @@
+package ingress
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// Supported ingress controllers
+const (
+	ControllerNginx   = "nginx"
+	ControllerTraefik = "traefik"
+)
+
+const (
+	defaultControllerReplicas = 2
+
+	// ipMarker prefixes the line of the install output carrying the
+	// LoadBalancer address
+	ipMarker = "INGRESS_IP:"
+)
+
+// Options are the controller settings resolved from an IngressConfig
+type Options struct {
+	Controller string // nginx or traefik
+	Class      string // IngressClass the controller serves
+	Replicas   int
+	TLS        bool // Serve HTTPS and redirect HTTP to it
+}
+
+// OptionsFromConfig resolves the controller settings: nginx by default,
+// the IngressClass named after the controller, and two replicas
+func OptionsFromConfig(cfg *config.IngressConfig) (Options, error) {
+	opts := Options{
+		Controller: cfg.Controller,
+		Class:      cfg.Class,
+		Replicas:   cfg.Replicas,
+		TLS:        cfg.TLS,
+	}
+	switch opts.Controller {
+	case "", "nginx", "ingress-nginx":
+		opts.Controller = ControllerNginx
+	case ControllerTraefik:
+	default:
+		return Options{}, fmt.Errorf("ingress controller %s is not supported; use nginx or traefik", cfg.Controller)
+	}
+	if opts.Class == "" {
+		opts.Class = opts.Controller
+	}
+	if opts.Replicas < 0 {
+		return Options{}, fmt.Errorf("ingress replicas must not be negative, got %d", opts.Replicas)
+	}
+	if opts.Replicas == 0 {
+		opts.Replicas = defaultControllerReplicas
+	}
+	return opts, nil
+}
+
+// masterScheduling renders the affinity and tolerations that keep the
+// controller on master nodes, for both RKE and RKE2 role labels and taints
+func masterScheduling(indent string) string {
+	lines := []string{
+		"affinity:",
+		"  nodeAffinity:",
+		"    requiredDuringSchedulingIgnoredDuringExecution:",
+		"      nodeSelectorTerms:",
+		"        - matchExpressions:",
+		"            - key: node-role.kubernetes.io/control-plane",
+		"              operator: Exists",
+		"        - matchExpressions:",
+		"            - key: node-role.kubernetes.io/controlplane",
+		"              operator: Exists",
+		"tolerations:",
+	}
+	for _, key := range []string{
+		"node-role.kubernetes.io/control-plane",
+		"node-role.kubernetes.io/controlplane",
+		"node-role.kubernetes.io/master",
+		"node-role.kubernetes.io/etcd",
+		"CriticalAddonsOnly",
+	} {
+		lines = append(lines, "  - key: "+key, "    operator: Exists")
+	}
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(indent + line + "\n")
+	}
+	return builder.String()
+}
+
+// renderNginxValues renders the ingress-nginx chart values
+func renderNginxValues(opts Options) string {
+	var builder strings.Builder
+	builder.WriteString("controller:\n")
+	builder.WriteString(fmt.Sprintf("  replicaCount: %d\n", opts.Replicas))
+	builder.WriteString("  ingressClassResource:\n")
+	builder.WriteString(fmt.Sprintf("    name: %s\n", opts.Class))
+	builder.WriteString(fmt.Sprintf("    controllerValue: k8s.io/%s\n", opts.Class))
+	builder.WriteString("    default: true\n")
+	builder.WriteString(fmt.Sprintf("  ingressClass: %s\n", opts.Class))
+	builder.WriteString("  service:\n")
+	builder.WriteString("    type: LoadBalancer\n")
+	builder.WriteString(fmt.Sprintf("    enableHttps: %t\n", opts.TLS))
+	builder.WriteString("  config:\n")
+	builder.WriteString(fmt.Sprintf("    ssl-redirect: \"%t\"\n", opts.TLS))
+	builder.WriteString(masterScheduling("  "))
+	return builder.String()
+}
+
+// renderTraefikValues renders the traefik chart values
+func renderTraefikValues(opts Options) string {
+	var builder strings.Builder
+	builder.WriteString("deployment:\n")
+	builder.WriteString(fmt.Sprintf("  replicas: %d\n", opts.Replicas))
+	builder.WriteString("ingressClass:\n")
+	builder.WriteString("  enabled: true\n")
+	builder.WriteString("  isDefaultClass: true\n")
+	builder.WriteString(fmt.Sprintf("  name: %s\n", opts.Class))
+	builder.WriteString("service:\n")
+	builder.WriteString("  type: LoadBalancer\n")
+	builder.WriteString("ports:\n")
+	if opts.TLS {
+		builder.WriteString("  web:\n")
+		builder.WriteString("    redirectTo:\n")
+		builder.WriteString("      port: websecure\n")
+	}
+	builder.WriteString("  websecure:\n")
+	builder.WriteString("    expose:\n")
+	builder.WriteString(fmt.Sprintf("      default: %t\n", opts.TLS))
+	builder.WriteString(masterScheduling(""))
+	return builder.String()
+}
+
+// RenderControllerInstall renders the script that installs the controller
+// with Helm, waits for its LoadBalancer and prints the address after
+// INGRESS_IP: for ParseIngressIP
+func RenderControllerInstall(opts Options) string {
+	release, chart, repo, repoURL, namespace, service, values :=
+		"ingress-nginx", "ingress-nginx/ingress-nginx", "ingress-nginx",
+		"https://kubernetes.github.io/ingress-nginx", "ingress-nginx",
+		"ingress-nginx-controller", renderNginxValues(opts)
+	if opts.Controller == ControllerTraefik {
+		release, chart, repo, repoURL, namespace, service, values =
+			"traefik", "traefik/traefik", "traefik",
+			"https://traefik.github.io/charts", "traefik",
+			"traefik", renderTraefikValues(opts)
+	}
+
+	return fmt.Sprintf(`set -e
+
+if ! command -v helm &> /dev/null; then
+    curl https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | bash
+fi
+helm repo add %[1]s %[2]s || true
+helm repo update
+
+helm upgrade --install %[3]s %[4]s \
+  --namespace %[5]s --create-namespace --wait --timeout 10m -f - <<'VALUES' >&2
+%[7]sVALUES
+
+for i in $(seq 1 60); do
+    ADDRESS=$(kubectl -n %[5]s get svc %[6]s -o jsonpath='{.status.loadBalancer.ingress[0].ip}')
+    [ -z "$ADDRESS" ] && ADDRESS=$(kubectl -n %[5]s get svc %[6]s -o jsonpath='{.status.loadBalancer.ingress[0].hostname}')
+    [ -n "$ADDRESS" ] && break
+    sleep 10
+done
+if [ -z "$ADDRESS" ]; then
+    echo "ingress LoadBalancer %[5]s/%[6]s got no address" >&2
+    exit 1
+fi
+echo "%[8]s$ADDRESS"
+`, repo, repoURL, release, chart, namespace, service, values, ipMarker)
+}
+
+// ParseIngressIP returns the LoadBalancer address from the output of the
+// script RenderControllerInstall renders
+func ParseIngressIP(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, ipMarker) {
+			return strings.TrimSpace(strings.TrimPrefix(line, ipMarker))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// TestOptionsFromConfig tests controller defaults and validation
+func TestOptionsFromConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.IngressConfig
+		want    Options
+		wantErr string
+	}{
+		{"defaults", config.IngressConfig{}, Options{Controller: "nginx", Class: "nginx", Replicas: 2}, ""},
+		{"traefik", config.IngressConfig{Controller: "traefik", Replicas: 3, TLS: true}, Options{Controller: "traefik", Class: "traefik", Replicas: 3, TLS: true}, ""},
+		{"custom class", config.IngressConfig{Controller: "ingress-nginx", Class: "public"}, Options{Controller: "nginx", Class: "public", Replicas: 2}, ""},
+		{"unsupported", config.IngressConfig{Controller: "haproxy"}, Options{}, "ingress controller haproxy is not supported"},
+		{"negative replicas", config.IngressConfig{Replicas: -1}, Options{}, "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OptionsFromConfig(&tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("OptionsFromConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OptionsFromConfig() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("OptionsFromConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRenderControllerInstall tests the Helm install for each controller
+func TestRenderControllerInstall(t *testing.T) {
+	nginx := RenderControllerInstall(Options{Controller: ControllerNginx, Class: "public", Replicas: 3})
+	for _, want := range []string{
+		"helm upgrade --install ingress-nginx ingress-nginx/ingress-nginx",
+		"  replicaCount: 3\n",
+		"    name: public\n",
+		"    enableHttps: false\n",
+		"            - key: node-role.kubernetes.io/control-plane\n",
+		"  tolerations:\n",
+		"get svc ingress-nginx-controller",
+		`echo "INGRESS_IP:$ADDRESS"`,
+	} {
+		if !strings.Contains(nginx, want) {
+			t.Errorf("nginx install missing %q:\n%s", want, nginx)
+		}
+	}
+
+	traefik := RenderControllerInstall(Options{Controller: ControllerTraefik, Class: "traefik", Replicas: 2, TLS: true})
+	for _, want := range []string{
+		"helm upgrade --install traefik traefik/traefik",
+		"  replicas: 2\n",
+		"      port: websecure\n",
+		"      default: true\n",
+		"\ntolerations:\n",
+		"get svc traefik",
+	} {
+		if !strings.Contains(traefik, want) {
+			t.Errorf("traefik install missing %q:\n%s", want, traefik)
+		}
+	}
+}
+
+// TestParseIngressIP tests reading the LoadBalancer address from install output
+func TestParseIngressIP(t *testing.T) {
+	if got := ParseIngressIP("Release installed\nINGRESS_IP:203.0.113.10\n"); got != "203.0.113.10" {
+		t.Errorf("ParseIngressIP() = %q, want 203.0.113.10", got)
+	}
+	if got := ParseIngressIP("no address\n"); got != "" {
+		t.Errorf("ParseIngressIP() = %q, want empty", got)
+	}
+}
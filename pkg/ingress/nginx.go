@@ -11,17 +11,20 @@ import (
 
 // NginxIngressManager manages NGINX Ingress Controller installation
 type NginxIngressManager struct {
-	ctx        *pulumi.Context
-	domain     string
-	masterNode *providers.NodeOutput
-	sshKeyPath string
+	ctx           *pulumi.Context
+	domain        string
+	masterNode    *providers.NodeOutput
+	sshKeyPath    string
+	clusterIssuer string
+	ingressClass  string
 }
 
 // NewNginxIngressManager creates a new NGINX Ingress manager
 func NewNginxIngressManager(ctx *pulumi.Context, domain string) *NginxIngressManager {
 	return &NginxIngressManager{
-		ctx:    ctx,
-		domain: domain,
+		ctx:          ctx,
+		domain:       domain,
+		ingressClass: ControllerNginx,
 	}
 }
 
@@ -35,6 +38,17 @@ func (n *NginxIngressManager) SetSSHKeyPath(path string) {
 	n.sshKeyPath = path
 }
 
+// SetIngressClass sets the IngressClass the sample ingress is served by
+func (n *NginxIngressManager) SetIngressClass(class string) {
+	n.ingressClass = class
+}
+
+// SetClusterIssuer sets the cert-manager ClusterIssuer ingresses request
+// TLS certificates from. Without one, ingresses are served over plain HTTP.
+func (n *NginxIngressManager) SetClusterIssuer(name string) {
+	n.clusterIssuer = name
+}
+
 // Install installs NGINX Ingress Controller on the cluster
 func (n *NginxIngressManager) Install() (pulumi.StringOutput, error) {
 	if n.masterNode == nil {
@@ -385,22 +399,40 @@ func (n *NginxIngressManager) getSSHPrivateKey() string {
 
 // CreateSampleIngress creates a sample ingress resource
 func (n *NginxIngressManager) CreateSampleIngress() error {
-	sampleIngress := fmt.Sprintf(`
+	n.ctx.Export("sample_ingress_yaml", pulumi.String(n.renderSampleIngress()))
+
+	return nil
+}
+
+// renderSampleIngress renders the sample ingress, requesting a certificate
+// from the cluster issuer when one is set
+func (n *NginxIngressManager) renderSampleIngress() string {
+	host := fmt.Sprintf("kube-ingress.%s", n.domain)
+
+	var builder strings.Builder
+	builder.WriteString(`
 apiVersion: networking.k8s.io/v1
 kind: Ingress
 metadata:
   name: sample-ingress
-  annotations:
-    cert-manager.io/cluster-issuer: "letsencrypt-prod"
+`)
+	if n.clusterIssuer != "" {
+		builder.WriteString(fmt.Sprintf(`  annotations:
+    cert-manager.io/cluster-issuer: %q
     nginx.ingress.kubernetes.io/ssl-redirect: "true"
-spec:
-  ingressClassName: nginx
-  tls:
+`, n.clusterIssuer))
+	}
+	builder.WriteString("spec:\n")
+	builder.WriteString(fmt.Sprintf("  ingressClassName: %s\n", n.ingressClass))
+	if n.clusterIssuer != "" {
+		builder.WriteString(fmt.Sprintf(`  tls:
   - hosts:
-    - kube-ingress.%s
+    - %s
     secretName: kube-ingress-tls
-  rules:
-  - host: kube-ingress.%s
+`, host))
+	}
+	builder.WriteString(fmt.Sprintf(`  rules:
+  - host: %s
     http:
       paths:
       - path: /
@@ -410,9 +442,6 @@ spec:
             name: sample-service
             port:
               number: 80
-`, n.domain, n.domain)
-
-	n.ctx.Export("sample_ingress_yaml", pulumi.String(sampleIngress))
-
-	return nil
+`, host))
+	return builder.String()
 }
@@ -673,3 +673,40 @@ func TestLoadBalancerConfiguration(t *testing.T) {
 		t.Error("Healthcheck interval should be at least 1 second")
 	}
 }
+
+// TestRenderSampleIngress tests that the sample ingress requests TLS only from a set issuer
+func TestRenderSampleIngress(t *testing.T) {
+	manager := NewNginxIngressManager(nil, "example.com")
+
+	plain := manager.renderSampleIngress()
+	if strings.Contains(plain, "cert-manager.io/cluster-issuer") || strings.Contains(plain, "tls:") {
+		t.Errorf("ingress without an issuer should not request TLS:\n%s", plain)
+	}
+	if !strings.Contains(plain, "  - host: kube-ingress.example.com\n") {
+		t.Errorf("ingress missing host rule:\n%s", plain)
+	}
+
+	manager.SetClusterIssuer("letsencrypt-staging")
+	secured := manager.renderSampleIngress()
+	for _, want := range []string{
+		"    cert-manager.io/cluster-issuer: \"letsencrypt-staging\"\n",
+		"  tls:\n  - hosts:\n    - kube-ingress.example.com\n",
+	} {
+		if !strings.Contains(secured, want) {
+			t.Errorf("ingress missing %q:\n%s", want, secured)
+		}
+	}
+}
+
+// TestRenderSampleIngress_Class tests that the sample ingress uses the controller's class
+func TestRenderSampleIngress_Class(t *testing.T) {
+	manager := NewNginxIngressManager(nil, "example.com")
+	if !strings.Contains(manager.renderSampleIngress(), "  ingressClassName: nginx\n") {
+		t.Error("sample ingress should default to the nginx class")
+	}
+
+	manager.SetIngressClass("traefik")
+	if !strings.Contains(manager.renderSampleIngress(), "  ingressClassName: traefik\n") {
+		t.Error("sample ingress should use the configured class")
+	}
+}
@@ -0,0 +1,228 @@
+// Package logging provides the leveled, structured logger the orchestrator
+// and its managers write through. The default logger sends each message to
+// the Pulumi engine as before, with its fields appended; the JSON logger
+// writes one event per line for CI systems to parse.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// Level is the severity of a log event
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the level's name as it appears in JSON events
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+}
+
+// Keys of the fields events commonly carry
+const (
+	FieldProvider = "provider"
+	FieldNode     = "node"
+	FieldPhase    = "phase"
+	FieldDuration = "duration_ms"
+	FieldError    = "error"
+)
+
+// Field is a key/value pair attached to a log event
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F returns a field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger writes leveled events with fields. With returns a logger that adds
+// the given fields to every event.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+	With(fields ...Field) Logger
+}
+
+// sink writes an event with all of its fields
+type sink interface {
+	write(level Level, msg string, fields []Field)
+}
+
+// logger implements Logger over a sink
+type logger struct {
+	sink   sink
+	fields []Field
+}
+
+func (l *logger) Debug(msg string, fields ...Field) { l.log(LevelDebug, msg, fields) }
+func (l *logger) Info(msg string, fields ...Field)  { l.log(LevelInfo, msg, fields) }
+func (l *logger) Warn(msg string, fields ...Field)  { l.log(LevelWarn, msg, fields) }
+func (l *logger) Error(msg string, fields ...Field) { l.log(LevelError, msg, fields) }
+
+func (l *logger) With(fields ...Field) Logger {
+	return &logger{sink: l.sink, fields: append(append([]Field{}, l.fields...), fields...)}
+}
+
+func (l *logger) log(level Level, msg string, fields []Field) {
+	if len(l.fields) > 0 {
+		fields = append(append([]Field{}, l.fields...), fields...)
+	}
+	l.sink.write(level, msg, fields)
+}
+
+// NewPulumiLogger returns a logger writing to the Pulumi engine's log. Fields
+// are appended to the message as key=value pairs.
+func NewPulumiLogger(ctx *pulumi.Context) Logger {
+	return &logger{sink: pulumiSink{ctx: ctx}}
+}
+
+type pulumiSink struct {
+	ctx *pulumi.Context
+}
+
+func (s pulumiSink) write(level Level, msg string, fields []Field) {
+	msg = formatMessage(msg, fields)
+	switch level {
+	case LevelDebug:
+		s.ctx.Log.Debug(msg, nil)
+	case LevelWarn:
+		s.ctx.Log.Warn(msg, nil)
+	case LevelError:
+		s.ctx.Log.Error(msg, nil)
+	default:
+		s.ctx.Log.Info(msg, nil)
+	}
+}
+
+// formatMessage appends fields to a message as key=value pairs, quoting
+// values that contain spaces
+func formatMessage(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", field.Key, value)
+	}
+	return b.String()
+}
+
+// NewJSONLogger returns a logger writing events at or above minLevel to w,
+// one JSON object per line with time, level, msg and the event's fields
+func NewJSONLogger(w io.Writer, minLevel Level) Logger {
+	return &logger{sink: &jsonSink{w: w, minLevel: minLevel}}
+}
+
+type jsonSink struct {
+	mu       sync.Mutex
+	w        io.Writer
+	minLevel Level
+}
+
+func (s *jsonSink) write(level Level, msg string, fields []Field) {
+	if level < s.minLevel {
+		return
+	}
+
+	event := make(map[string]interface{}, len(fields)+3)
+	for _, field := range fields {
+		event[field.Key] = jsonValue(field.Value)
+	}
+	event["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	event["level"] = level.String()
+	event["msg"] = msg
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		line, _ = json.Marshal(map[string]string{"level": level.String(), "msg": msg, "error": err.Error()})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w.Write(append(line, '\n'))
+}
+
+// jsonValue returns errors as their message, which encoding/json would
+// otherwise render as an empty object
+func jsonValue(value interface{}) interface{} {
+	if err, ok := value.(error); ok {
+		return err.Error()
+	}
+	return value
+}
+
+// Entry is an event a Recorder captured
+type Entry struct {
+	Level   Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Recorder is a Logger that keeps every event in memory, for tests
+type Recorder struct {
+	Logger
+	store *recordSink
+}
+
+// NewRecorder returns an empty Recorder. Loggers derived from it with With
+// record into it too.
+func NewRecorder() *Recorder {
+	store := &recordSink{}
+	return &Recorder{Logger: &logger{sink: store}, store: store}
+}
+
+// Entries returns the events recorded so far, in order
+func (r *Recorder) Entries() []Entry {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return append([]Entry{}, r.store.entries...)
+}
+
+type recordSink struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (s *recordSink) write(level Level, msg string, fields []Field) {
+	entry := Entry{Level: level, Message: msg, Fields: make(map[string]interface{}, len(fields))}
+	for _, field := range fields {
+		entry.Fields[field.Key] = field.Value
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLogger_WritesOneEventPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewJSONLogger(&buf, LevelInfo)
+
+	log.Info("Creating network", F(FieldProvider, "aws"))
+	log.Error("Phase failed", F(FieldPhase, "nodes"), F(FieldDuration, int64(1500)), F(FieldError, errors.New("quota exceeded")))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "info", first["level"])
+	assert.Equal(t, "Creating network", first["msg"])
+	assert.Equal(t, "aws", first["provider"])
+	assert.NotEmpty(t, first["time"])
+
+	var second map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "error", second["level"])
+	assert.Equal(t, "nodes", second["phase"])
+	assert.Equal(t, float64(1500), second["duration_ms"])
+	assert.Equal(t, "quota exceeded", second["error"], "errors are logged as their message")
+}
+
+func TestJSONLogger_SkipsEventsBelowMinLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewJSONLogger(&buf, LevelWarn)
+
+	log.Debug("debug")
+	log.Info("info")
+	log.Warn("warn")
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), `"msg":"warn"`)
+}
+
+func TestLogger_WithAddsFields(t *testing.T) {
+	rec := NewRecorder()
+	phaseLog := rec.With(F(FieldPhase, "vpn"))
+	phaseLog.With(F(FieldNode, "master-1")).Warn("Node unreachable", F("attempt", 3))
+	rec.Info("Done")
+
+	entries := rec.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, Entry{
+		Level:   LevelWarn,
+		Message: "Node unreachable",
+		Fields:  map[string]interface{}{"phase": "vpn", "node": "master-1", "attempt": 3},
+	}, entries[0])
+	assert.Empty(t, entries[1].Fields, "the parent logger is unchanged")
+}
+
+func TestFormatMessage(t *testing.T) {
+	assert.Equal(t, "Creating network", formatMessage("Creating network", nil))
+	assert.Equal(t, "Phase completed phase=nodes duration_ms=42",
+		formatMessage("Phase completed", []Field{F(FieldPhase, "nodes"), F(FieldDuration, 42)}))
+	assert.Equal(t, `Phase failed error="quota exceeded"`,
+		formatMessage("Phase failed", []Field{F(FieldError, errors.New("quota exceeded"))}))
+}
+
+func TestLevel_String(t *testing.T) {
+	assert.Equal(t, "debug", LevelDebug.String())
+	assert.Equal(t, "info", LevelInfo.String())
+	assert.Equal(t, "warn", LevelWarn.String())
+	assert.Equal(t, "error", LevelError.String())
+}
@@ -0,0 +1,219 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+const (
+	// defaultWireGuardPort is the WireGuard listen port when none is configured
+	defaultWireGuardPort = 51820
+
+	// defaultWireGuardSubnet is the WireGuard VPN subnet when none is configured
+	defaultWireGuardSubnet = "10.8.0.0/24"
+
+	// tailscalePort is the UDP port Tailscale nodes accept direct connections on.
+	// DERP relays are reached outbound over HTTPS, so they need no inbound rule.
+	tailscalePort = 41641
+
+	// tailscaleCIDR is the CGNAT range Tailscale and Headscale assign node IPs from
+	tailscaleCIDR = "100.64.0.0/10"
+
+	// rke2SupervisorPort is the port RKE2 servers register new nodes on
+	rke2SupervisorPort = 9345
+)
+
+// privateCIDRs are the RFC 1918 ranges cluster-internal traffic comes from
+var privateCIDRs = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+
+// RequiredFirewallConfig derives the cluster firewall from the features the
+// config enables instead of a fixed port list: the VPN's port and subnet
+// (WireGuard's configured port, or Tailscale's direct-connection port), the
+// API server ports of the Kubernetes distribution, the ingress ports when an
+// ingress controller is configured, and the cluster-internal ports. The
+// firewall rules of the config are added after the derived ones; a rule
+// already derived is not repeated.
+func RequiredFirewallConfig(name string, cfg *config.ClusterConfig) *config.FirewallConfig {
+	network := &cfg.Network
+	firewall := &config.FirewallConfig{
+		Name:          name,
+		InboundRules:  []config.FirewallRule{},
+		OutboundRules: []config.FirewallRule{},
+	}
+
+	firewall.InboundRules = appendFirewallRules(firewall.InboundRules, vpnFirewallRules(network)...)
+	firewall.InboundRules = appendFirewallRules(firewall.InboundRules, apiServerFirewallRules(cfg.Kubernetes.Distribution, clusterSources(network))...)
+	firewall.InboundRules = appendFirewallRules(firewall.InboundRules, ingressFirewallRules(network.Ingress)...)
+	firewall.InboundRules = appendFirewallRules(firewall.InboundRules, clusterFirewallRules(network)...)
+
+	if network.Firewall != nil {
+		firewall.InboundRules = appendFirewallRules(firewall.InboundRules, network.Firewall.InboundRules...)
+		firewall.OutboundRules = appendFirewallRules(firewall.OutboundRules, network.Firewall.OutboundRules...)
+		firewall.DefaultAction = network.Firewall.DefaultAction
+	}
+
+	return firewall
+}
+
+// vpnFirewallRules opens the enabled VPN's port to the internet and all
+// traffic from its subnet
+func vpnFirewallRules(network *config.NetworkConfig) []config.FirewallRule {
+	var rules []config.FirewallRule
+
+	if wg := network.WireGuard; wg != nil && wg.Enabled {
+		port := wg.Port
+		if port == 0 {
+			port = defaultWireGuardPort
+		}
+		subnet := wireGuardSubnet(wg)
+		rules = append(rules,
+			config.FirewallRule{Protocol: "udp", Port: fmt.Sprintf("%d", port), Source: []string{"0.0.0.0/0"}, Description: "WireGuard VPN"},
+			config.FirewallRule{Protocol: "tcp", Port: "1-65535", Source: []string{subnet}, Description: "Allow all from WireGuard network"},
+			config.FirewallRule{Protocol: "udp", Port: "1-65535", Source: []string{subnet}, Description: "Allow all UDP from WireGuard network"},
+		)
+	}
+
+	if ts := network.Tailscale; ts != nil && ts.Enabled {
+		rules = append(rules,
+			config.FirewallRule{Protocol: "udp", Port: fmt.Sprintf("%d", tailscalePort), Source: []string{"0.0.0.0/0"}, Description: "Tailscale direct connections"},
+			config.FirewallRule{Protocol: "tcp", Port: "1-65535", Source: []string{tailscaleCIDR}, Description: "Allow all from Tailscale network"},
+			config.FirewallRule{Protocol: "udp", Port: "1-65535", Source: []string{tailscaleCIDR}, Description: "Allow all UDP from Tailscale network"},
+		)
+	}
+
+	return rules
+}
+
+// apiServerFirewallRules opens the Kubernetes API, and the RKE2 supervisor
+// port nodes join through, to the cluster's networks
+func apiServerFirewallRules(distribution string, sources []string) []config.FirewallRule {
+	rules := []config.FirewallRule{
+		{Protocol: "tcp", Port: "6443", Source: sources, Description: "Kubernetes API server"},
+	}
+	if distribution == "rke2" {
+		rules = append(rules, config.FirewallRule{
+			Protocol:    "tcp",
+			Port:        fmt.Sprintf("%d", rke2SupervisorPort),
+			Source:      sources,
+			Description: "RKE2 supervisor",
+		})
+	}
+	return rules
+}
+
+// ingressFirewallRules opens HTTP and HTTPS to the internet when an ingress
+// controller is configured
+func ingressFirewallRules(ingress config.IngressConfig) []config.FirewallRule {
+	if ingress.Controller == "" {
+		return nil
+	}
+	return []config.FirewallRule{
+		{Protocol: "tcp", Port: "80", Source: []string{"0.0.0.0/0"}, Description: "HTTP Ingress"},
+		{Protocol: "tcp", Port: "443", Source: []string{"0.0.0.0/0"}, Description: "HTTPS Ingress"},
+	}
+}
+
+// clusterFirewallRules returns the Kubernetes ports other than the API
+// server's, reachable only from the cluster's networks
+func clusterFirewallRules(network *config.NetworkConfig) []config.FirewallRule {
+	sources := clusterSources(network)
+	rules := []config.FirewallRule{
+		{Protocol: "tcp", Port: "2379-2380", Source: sources, Description: "etcd server client API"},
+		{Protocol: "tcp", Port: "10250", Source: sources, Description: "Kubelet API"},
+		{Protocol: "udp", Port: "8472", Source: sources, Description: "Flannel VXLAN"},
+		{Protocol: "tcp", Port: "179", Source: sources, Description: "Calico BGP"},
+	}
+	if network.EnableNodePorts {
+		rules = append(rules, config.FirewallRule{
+			Protocol:    "tcp",
+			Port:        "30000-32767",
+			Source:      vpnSources(network),
+			Description: "NodePort Services",
+		})
+	}
+	return rules
+}
+
+// clusterSources returns the private ranges plus any VPN subnet outside them
+func clusterSources(network *config.NetworkConfig) []string {
+	sources := append([]string(nil), privateCIDRs...)
+	for _, subnet := range vpnSources(network) {
+		if !coveredBy(subnet, privateCIDRs) {
+			sources = append(sources, subnet)
+		}
+	}
+	return sources
+}
+
+// vpnSources returns the subnets of the enabled VPNs
+func vpnSources(network *config.NetworkConfig) []string {
+	var sources []string
+	if wg := network.WireGuard; wg != nil && wg.Enabled {
+		sources = append(sources, wireGuardSubnet(wg))
+	}
+	if ts := network.Tailscale; ts != nil && ts.Enabled {
+		sources = append(sources, tailscaleCIDR)
+	}
+	if len(sources) == 0 {
+		return []string{defaultWireGuardSubnet}
+	}
+	return sources
+}
+
+// wireGuardSubnet returns the configured WireGuard subnet or the default
+func wireGuardSubnet(wg *config.WireGuardConfig) string {
+	if wg.SubnetCIDR != "" {
+		return wg.SubnetCIDR
+	}
+	return defaultWireGuardSubnet
+}
+
+// coveredBy reports whether cidr lies inside one of the given ranges
+func coveredBy(cidr string, ranges []string) bool {
+	ip, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	size, _ := subnet.Mask.Size()
+	for _, r := range ranges {
+		_, outer, err := net.ParseCIDR(r)
+		if err != nil {
+			continue
+		}
+		outerSize, _ := outer.Mask.Size()
+		if outer.Contains(ip) && outerSize <= size {
+			return true
+		}
+	}
+	return false
+}
+
+// appendFirewallRules appends the rules not already present, comparing
+// protocol, port and sources
+func appendFirewallRules(rules []config.FirewallRule, more ...config.FirewallRule) []config.FirewallRule {
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		seen[firewallRuleKey(rule)] = true
+	}
+	for _, rule := range more {
+		key := firewallRuleKey(rule)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// firewallRuleKey identifies a rule regardless of its description or the
+// order of its sources
+func firewallRuleKey(rule config.FirewallRule) string {
+	sources := append([]string(nil), rule.Source...)
+	sort.Strings(sources)
+	return strings.Join([]string{strings.ToLower(rule.Protocol), rule.Port, strings.Join(sources, ",")}, "|")
+}
@@ -0,0 +1,134 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// findRule returns the first rule with the given protocol and port
+func findRule(rules []config.FirewallRule, protocol, port string) *config.FirewallRule {
+	for i := range rules {
+		if rules[i].Protocol == protocol && rules[i].Port == port {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func TestRequiredFirewallConfig_WireGuard(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{
+			WireGuard: &config.WireGuardConfig{Enabled: true, Port: 51999, SubnetCIDR: "10.99.0.0/24"},
+		},
+	}
+
+	firewall := RequiredFirewallConfig("prod-firewall", cfg)
+
+	assert.Equal(t, "prod-firewall", firewall.Name)
+	wg := findRule(firewall.InboundRules, "udp", "51999")
+	require.NotNil(t, wg)
+	assert.Equal(t, []string{"0.0.0.0/0"}, wg.Source)
+	assert.Nil(t, findRule(firewall.InboundRules, "udp", "51820"))
+	assert.Nil(t, findRule(firewall.InboundRules, "udp", "41641"), "no Tailscale port without Tailscale")
+
+	all := findRule(firewall.InboundRules, "tcp", "1-65535")
+	require.NotNil(t, all)
+	assert.Equal(t, []string{"10.99.0.0/24"}, all.Source)
+}
+
+func TestRequiredFirewallConfig_WireGuardDefaultPort(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{WireGuard: &config.WireGuardConfig{Enabled: true}},
+	}
+
+	assert.NotNil(t, findRule(RequiredFirewallConfig("fw", cfg).InboundRules, "udp", "51820"))
+}
+
+func TestRequiredFirewallConfig_Tailscale(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{Tailscale: &config.TailscaleConfig{Enabled: true}},
+	}
+
+	firewall := RequiredFirewallConfig("fw", cfg)
+
+	assert.NotNil(t, findRule(firewall.InboundRules, "udp", "41641"))
+	assert.Nil(t, findRule(firewall.InboundRules, "udp", "51820"), "no WireGuard port with Tailscale")
+	api := findRule(firewall.InboundRules, "tcp", "6443")
+	require.NotNil(t, api)
+	assert.Contains(t, api.Source, "100.64.0.0/10", "the tailnet reaches the API server")
+}
+
+func TestRequiredFirewallConfig_Distribution(t *testing.T) {
+	rke := RequiredFirewallConfig("fw", &config.ClusterConfig{})
+	assert.NotNil(t, findRule(rke.InboundRules, "tcp", "6443"))
+	assert.Nil(t, findRule(rke.InboundRules, "tcp", "9345"))
+
+	rke2 := RequiredFirewallConfig("fw", &config.ClusterConfig{Kubernetes: config.KubernetesConfig{Distribution: "rke2"}})
+	assert.NotNil(t, findRule(rke2.InboundRules, "tcp", "6443"))
+	supervisor := findRule(rke2.InboundRules, "tcp", "9345")
+	require.NotNil(t, supervisor)
+	assert.Equal(t, privateCIDRs, supervisor.Source)
+}
+
+func TestRequiredFirewallConfig_Ingress(t *testing.T) {
+	none := RequiredFirewallConfig("fw", &config.ClusterConfig{})
+	assert.Nil(t, findRule(none.InboundRules, "tcp", "80"))
+	assert.Nil(t, findRule(none.InboundRules, "tcp", "443"))
+
+	cfg := &config.ClusterConfig{Network: config.NetworkConfig{Ingress: config.IngressConfig{Controller: "nginx"}}}
+	firewall := RequiredFirewallConfig("fw", cfg)
+	assert.NotNil(t, findRule(firewall.InboundRules, "tcp", "80"))
+	assert.NotNil(t, findRule(firewall.InboundRules, "tcp", "443"))
+}
+
+func TestRequiredFirewallConfig_UserRulesAreAdditive(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{
+			Ingress: config.IngressConfig{Controller: "traefik"},
+			Firewall: &config.FirewallConfig{
+				InboundRules: []config.FirewallRule{
+					{Protocol: "tcp", Port: "22", Source: []string{"203.0.113.0/24"}, Description: "Office SSH"},
+					{Protocol: "tcp", Port: "443", Source: []string{"0.0.0.0/0"}, Description: "Duplicate of ingress"},
+				},
+				OutboundRules: []config.FirewallRule{{Protocol: "tcp", Port: "443", Source: []string{"0.0.0.0/0"}}},
+				DefaultAction: "deny",
+			},
+		},
+	}
+
+	firewall := RequiredFirewallConfig("fw", cfg)
+
+	ssh := findRule(firewall.InboundRules, "tcp", "22")
+	require.NotNil(t, ssh)
+	assert.Equal(t, "Office SSH", ssh.Description)
+
+	https := 0
+	for _, rule := range firewall.InboundRules {
+		if rule.Protocol == "tcp" && rule.Port == "443" {
+			https++
+		}
+	}
+	assert.Equal(t, 1, https, "a user rule matching a derived one is not repeated")
+	assert.Len(t, firewall.OutboundRules, 1)
+	assert.Equal(t, "deny", firewall.DefaultAction)
+}
+
+func TestRequiredFirewallConfig_NodePorts(t *testing.T) {
+	cfg := &config.ClusterConfig{Network: config.NetworkConfig{EnableNodePorts: true}}
+
+	nodePorts := findRule(RequiredFirewallConfig("fw", cfg).InboundRules, "tcp", "30000-32767")
+	require.NotNil(t, nodePorts)
+	assert.Equal(t, []string{"10.8.0.0/24"}, nodePorts.Source)
+}
+
+func TestClusterSources(t *testing.T) {
+	inside := &config.NetworkConfig{WireGuard: &config.WireGuardConfig{Enabled: true, SubnetCIDR: "10.8.0.0/24"}}
+	assert.Equal(t, privateCIDRs, clusterSources(inside), "a VPN subnet inside the private ranges is not repeated")
+
+	outside := &config.NetworkConfig{WireGuard: &config.WireGuardConfig{Enabled: true, SubnetCIDR: "100.100.0.0/24"}}
+	assert.Contains(t, clusterSources(outside), "100.100.0.0/24")
+}
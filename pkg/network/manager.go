@@ -5,6 +5,7 @@ import (
 	"net"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -15,6 +16,7 @@ type Manager struct {
 	providers map[string]providers.Provider
 	networks  map[string]*providers.NetworkOutput
 	ctx       *pulumi.Context
+	log       logging.Logger
 }
 
 // NewManager creates a new network manager
@@ -24,9 +26,24 @@ func NewManager(ctx *pulumi.Context, config *config.NetworkConfig) *Manager {
 		config:    config,
 		providers: make(map[string]providers.Provider),
 		networks:  make(map[string]*providers.NetworkOutput),
+		log:       logging.NewPulumiLogger(ctx),
 	}
 }
 
+// SetLogger replaces the logger (default: the Pulumi engine's log)
+func (m *Manager) SetLogger(log logging.Logger) {
+	m.log = log
+}
+
+// logger returns the logger, falling back to the Pulumi engine's log for a
+// Manager built without its constructor
+func (m *Manager) logger() logging.Logger {
+	if m.log == nil {
+		return logging.NewPulumiLogger(m.ctx)
+	}
+	return m.log
+}
+
 // RegisterProvider registers a provider for network management
 func (m *Manager) RegisterProvider(name string, provider providers.Provider) {
 	m.providers[name] = provider
@@ -35,14 +52,9 @@ func (m *Manager) RegisterProvider(name string, provider providers.Provider) {
 // CreateNetworks creates network infrastructure for all providers
 func (m *Manager) CreateNetworks() error {
 	for name, provider := range m.providers {
-		m.ctx.Log.Info("Creating network for provider", nil)
-
-		network, err := provider.CreateNetwork(m.ctx, m.config)
-		if err != nil {
-			return fmt.Errorf("failed to create network for %s: %w", name, err)
+		if err := m.createNetwork(name, provider); err != nil {
+			return err
 		}
-
-		m.networks[name] = network
 	}
 
 	// If cross-provider networking is enabled, create peering
@@ -55,8 +67,39 @@ func (m *Manager) CreateNetworks() error {
 	return nil
 }
 
+// AddProvider registers a provider after CreateNetworks has run and creates
+// its network
+func (m *Manager) AddProvider(name string, provider providers.Provider) error {
+	m.providers[name] = provider
+	return m.createNetwork(name, provider)
+}
+
+// createNetwork creates the network of one provider
+func (m *Manager) createNetwork(name string, provider providers.Provider) error {
+	m.logger().Info("Creating network for provider", logging.F(logging.FieldProvider, name))
+
+	network, err := provider.CreateNetwork(m.ctx, m.config)
+	if err != nil {
+		return fmt.Errorf("failed to create network for %s: %w", name, err)
+	}
+
+	m.networks[name] = network
+	return nil
+}
+
 // CreateFirewalls creates firewall rules for nodes
 func (m *Manager) CreateFirewalls(nodes map[string][]*providers.NodeOutput) error {
+	return m.createFirewalls(nodes, m.createFirewallConfig)
+}
+
+// CreateFirewallsWithConfig creates the given firewall for every provider's
+// nodes, for callers that derive the rules from the whole cluster config
+func (m *Manager) CreateFirewallsWithConfig(nodes map[string][]*providers.NodeOutput, firewall *config.FirewallConfig) error {
+	return m.createFirewalls(nodes, func(string) *config.FirewallConfig { return firewall })
+}
+
+// createFirewalls creates a firewall per provider from the config firewallFor returns
+func (m *Manager) createFirewalls(nodes map[string][]*providers.NodeOutput, firewallFor func(providerName string) *config.FirewallConfig) error {
 	for providerName, nodeList := range nodes {
 		provider, ok := m.providers[providerName]
 		if !ok {
@@ -69,10 +112,7 @@ func (m *Manager) CreateFirewalls(nodes map[string][]*providers.NodeOutput) erro
 			nodeIds[i] = node.ID
 		}
 
-		// Create firewall config
-		firewallConfig := m.createFirewallConfig(providerName)
-
-		if err := provider.CreateFirewall(m.ctx, firewallConfig, nodeIds); err != nil {
+		if err := provider.CreateFirewall(m.ctx, firewallFor(providerName), nodeIds); err != nil {
 			return fmt.Errorf("failed to create firewall for %s: %w", providerName, err)
 		}
 	}
@@ -197,7 +237,7 @@ func (m *Manager) getKubernetesFirewallRules() []config.FirewallRule {
 func (m *Manager) createCrossProviderPeering() error {
 	// This would implement VPC peering or VPN connections between providers
 	// For now, we rely on WireGuard for cross-provider connectivity
-	m.ctx.Log.Info("Cross-provider networking enabled via WireGuard", nil)
+	m.logger().Info("Cross-provider networking enabled via WireGuard")
 	return nil
 }
 
@@ -250,7 +290,52 @@ func cidrOverlap(cidr1, cidr2 string) (bool, error) {
 		return false, fmt.Errorf("invalid CIDR %s: %w", cidr2, err)
 	}
 
-	return net1.Contains(net2.IP) || net2.Contains(net1.IP), nil
+	return CIDRsOverlap(net1, net2), nil
+}
+
+// CIDRsOverlap reports whether two networks share any address. CIDR blocks
+// are aligned, so they either nest or are disjoint: adjacent blocks such as
+// 10.0.0.0/24 and 10.0.1.0/24 do not overlap.
+func CIDRsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// NamedCIDR is a configured address range and what it is used for
+type NamedCIDR struct {
+	Name string
+	CIDR string
+}
+
+// ClusterCIDRs returns the ranges configured for the cluster: the node
+// network, pods, services and, when WireGuard is enabled, its subnet. The
+// installers read the pod and service CIDRs from the kubernetes section, so
+// those win over the network section's. Unset ranges are left out.
+func ClusterCIDRs(cfg *config.ClusterConfig) []NamedCIDR {
+	firstSet := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	candidates := []NamedCIDR{
+		{Name: "network CIDR", CIDR: cfg.Network.CIDR},
+		{Name: "pod CIDR", CIDR: firstSet(cfg.Kubernetes.PodCIDR, cfg.Network.PodCIDR)},
+		{Name: "service CIDR", CIDR: firstSet(cfg.Kubernetes.ServiceCIDR, cfg.Network.ServiceCIDR)},
+	}
+	if wg := cfg.Network.WireGuard; wg != nil && wg.Enabled {
+		candidates = append(candidates, NamedCIDR{Name: "WireGuard subnet", CIDR: wg.SubnetCIDR})
+	}
+
+	var cidrs []NamedCIDR
+	for _, c := range candidates {
+		if c.CIDR != "" {
+			cidrs = append(cidrs, c)
+		}
+	}
+	return cidrs
 }
 
 // AllocateNodeIPs allocates IPs for nodes within the network
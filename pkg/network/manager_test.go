@@ -1935,3 +1935,70 @@ func TestCIDROverlap_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestCIDRsOverlap tests overlap detection on parsed networks
+func TestCIDRsOverlap(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidr1 string
+		cidr2 string
+		want  bool
+	}{
+		{"Adjacent blocks", "10.0.0.0/24", "10.0.1.0/24", false},
+		{"Adjacent blocks of different sizes", "10.0.0.0/23", "10.0.2.0/24", false},
+		{"Last address of the larger block", "10.0.0.0/23", "10.0.1.255/32", true},
+		{"Host bits set in the address", "10.0.200.5/16", "10.0.7.0/24", true},
+		{"IPv4 and IPv6", "10.0.0.0/8", "fd00::/8", false},
+		{"Default route", "0.0.0.0/0", "192.168.1.0/24", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, a, err := net.ParseCIDR(tt.cidr1)
+			if err != nil {
+				t.Fatalf("ParseCIDR(%s): %v", tt.cidr1, err)
+			}
+			_, b, err := net.ParseCIDR(tt.cidr2)
+			if err != nil {
+				t.Fatalf("ParseCIDR(%s): %v", tt.cidr2, err)
+			}
+
+			if got := CIDRsOverlap(a, b); got != tt.want {
+				t.Errorf("CIDRsOverlap(%s, %s) = %v, want %v", tt.cidr1, tt.cidr2, got, tt.want)
+			}
+			if got := CIDRsOverlap(b, a); got != tt.want {
+				t.Errorf("CIDRsOverlap(%s, %s) = %v, want %v", tt.cidr2, tt.cidr1, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestClusterCIDRs tests which ranges are collected from the cluster config
+func TestClusterCIDRs(t *testing.T) {
+	cfg := &config.ClusterConfig{
+		Network: config.NetworkConfig{
+			CIDR:        "10.0.0.0/16",
+			PodCIDR:     "10.100.0.0/16",
+			ServiceCIDR: "10.101.0.0/16",
+			WireGuard:   &config.WireGuardConfig{Enabled: true, SubnetCIDR: "10.8.0.0/24"},
+		},
+		Kubernetes: config.KubernetesConfig{PodCIDR: "10.244.0.0/16"},
+	}
+
+	got := ClusterCIDRs(cfg)
+	want := []NamedCIDR{
+		{Name: "network CIDR", CIDR: "10.0.0.0/16"},
+		{Name: "pod CIDR", CIDR: "10.244.0.0/16"},
+		{Name: "service CIDR", CIDR: "10.101.0.0/16"},
+		{Name: "WireGuard subnet", CIDR: "10.8.0.0/24"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ClusterCIDRs() = %v, want %v", got, want)
+	}
+
+	cfg.Network.WireGuard.Enabled = false
+	cfg.Network.ServiceCIDR = ""
+	if got := ClusterCIDRs(cfg); len(got) != 2 {
+		t.Errorf("Expected the disabled WireGuard subnet and unset service CIDR to be left out, got %v", got)
+	}
+}
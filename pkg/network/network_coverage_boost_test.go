@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -109,6 +110,22 @@ func (p *MockProvider) GetSizes() []string {
 	return []string{"s-1vcpu-1gb", "s-2vcpu-2gb"}
 }
 
+func (p *MockProvider) ResizeNode(ctx context.Context, node *providers.NodeOutput, newSize string) error {
+	return nil
+}
+
+func (p *MockProvider) DestroyNode(ctx context.Context, node *providers.NodeOutput) error {
+	return nil
+}
+
+func (p *MockProvider) RebootNode(ctx context.Context, node *providers.NodeOutput) error {
+	return nil
+}
+
+func (p *MockProvider) GetPriceForSize(size, region string) (float64, error) {
+	return 0, nil
+}
+
 func (p *MockProvider) Cleanup(ctx *pulumi.Context) error {
 	return nil
 }
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"context"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -383,6 +384,22 @@ func (m *MockNetworkProvider) GetSizes() []string {
 	return []string{"small", "medium", "large"}
 }
 
+func (m *MockNetworkProvider) ResizeNode(ctx context.Context, node *providers.NodeOutput, newSize string) error {
+	return nil
+}
+
+func (m *MockNetworkProvider) DestroyNode(ctx context.Context, node *providers.NodeOutput) error {
+	return nil
+}
+
+func (m *MockNetworkProvider) RebootNode(ctx context.Context, node *providers.NodeOutput) error {
+	return nil
+}
+
+func (m *MockNetworkProvider) GetPriceForSize(size, region string) (float64, error) {
+	return 0, nil
+}
+
 func (m *MockNetworkProvider) Cleanup(ctx *pulumi.Context) error {
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/chalkan3/sloth-kubernetes/pkg/logging"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
 	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -14,6 +15,7 @@ import (
 // VPNConnectivityChecker validates VPN connectivity between all nodes
 type VPNConnectivityChecker struct {
 	ctx           *pulumi.Context
+	log           logging.Logger
 	nodes         []*providers.NodeOutput
 	sshKeyPath    string
 	results       map[string]*ConnectivityResult
@@ -58,6 +60,7 @@ type WireGuardStats struct {
 func NewVPNConnectivityChecker(ctx *pulumi.Context) *VPNConnectivityChecker {
 	return &VPNConnectivityChecker{
 		ctx:           ctx,
+		log:           logging.NewPulumiLogger(ctx),
 		nodes:         make([]*providers.NodeOutput, 0),
 		results:       make(map[string]*ConnectivityResult),
 		checkInterval: 5 * time.Second,
@@ -65,6 +68,20 @@ func NewVPNConnectivityChecker(ctx *pulumi.Context) *VPNConnectivityChecker {
 	}
 }
 
+// SetLogger replaces the logger (default: the Pulumi engine's log)
+func (v *VPNConnectivityChecker) SetLogger(log logging.Logger) {
+	v.log = log
+}
+
+// logger returns the logger, falling back to the Pulumi engine's log for a
+// VPNConnectivityChecker built without its constructor
+func (v *VPNConnectivityChecker) logger() logging.Logger {
+	if v.log == nil {
+		return logging.NewPulumiLogger(v.ctx)
+	}
+	return v.log
+}
+
 // AddNode adds a node to be monitored
 func (v *VPNConnectivityChecker) AddNode(node *providers.NodeOutput) {
 	v.mu.Lock()
@@ -84,7 +101,7 @@ func (v *VPNConnectivityChecker) SetSSHKeyPath(path string) {
 
 // VerifyFullMeshConnectivity verifies that all nodes can reach each other via WireGuard
 func (v *VPNConnectivityChecker) VerifyFullMeshConnectivity() error {
-	v.ctx.Log.Info("Starting VPN full mesh connectivity verification", nil)
+	v.logger().Info("Starting VPN full mesh connectivity verification")
 
 	ctx, cancel := context.WithTimeout(context.Background(), v.timeout)
 	defer cancel()
@@ -123,7 +140,7 @@ func (v *VPNConnectivityChecker) VerifyFullMeshConnectivity() error {
 
 		case err := <-errorChan:
 			if err != nil {
-				v.ctx.Log.Warn("Connectivity check error", nil)
+				v.logger().Warn("Connectivity check error")
 			}
 
 		case result, ok := <-resultChan:
@@ -133,7 +150,7 @@ func (v *VPNConnectivityChecker) VerifyFullMeshConnectivity() error {
 					return fmt.Errorf("VPN connectivity verification failed: %v", failedConnections)
 				}
 
-				v.ctx.Log.Info("VPN full mesh connectivity verified successfully!", nil)
+				v.logger().Info("VPN full mesh connectivity verified successfully!")
 				return nil
 			}
 
@@ -154,7 +171,7 @@ func (v *VPNConnectivityChecker) VerifyFullMeshConnectivity() error {
 			}
 
 			// Log successful connections
-			v.ctx.Log.Info("Node connectivity status", nil)
+			v.logger().Info("Node connectivity status")
 		}
 	}
 }
@@ -216,7 +233,7 @@ func (v *VPNConnectivityChecker) checkNodeConnectivity(
 
 			// If all connections are established, send result and exit
 			if allConnected {
-				v.ctx.Log.Info("Node has full connectivity", nil)
+				v.logger().Info("Node has full connectivity")
 				resultChan <- result
 				return
 			}
@@ -441,7 +458,7 @@ func (v *VPNConnectivityChecker) reportConnectivityStatus(ctx context.Context) {
 			v.mu.RUnlock()
 
 			if totalConnections > 0 {
-				v.ctx.Log.Info("VPN connectivity status", nil)
+				v.logger().Info("VPN connectivity status")
 			}
 		}
 	}
@@ -480,15 +497,15 @@ func (v *VPNConnectivityChecker) PrintConnectivityMatrix() {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
-	v.ctx.Log.Info("VPN Connectivity Matrix", nil)
-	v.ctx.Log.Info("=======================", nil)
+	v.logger().Info("VPN Connectivity Matrix")
+	v.logger().Info("=======================")
 
 	// Print header
 	header := "Source\\Target\t"
 	for _, node := range v.nodes {
 		header += fmt.Sprintf("%s\t", node.Name[:6])
 	}
-	v.ctx.Log.Info(header, nil)
+	v.logger().Info(header)
 
 	// Print each row
 	for _, sourceNode := range v.nodes {
@@ -510,13 +527,13 @@ func (v *VPNConnectivityChecker) PrintConnectivityMatrix() {
 			}
 		}
 
-		v.ctx.Log.Info(row, nil)
+		v.logger().Info(row)
 	}
 }
 
 // WaitForTunnelEstablishment waits for all WireGuard tunnels to be established
 func (v *VPNConnectivityChecker) WaitForTunnelEstablishment() error {
-	v.ctx.Log.Info("Waiting for WireGuard tunnels to establish", nil)
+	v.logger().Info("Waiting for WireGuard tunnels to establish")
 
 	ctx, cancel := context.WithTimeout(context.Background(), v.timeout)
 	defer cancel()
@@ -541,11 +558,11 @@ func (v *VPNConnectivityChecker) WaitForTunnelEstablishment() error {
 			}
 
 			if allEstablished {
-				v.ctx.Log.Info("All WireGuard tunnels established", nil)
+				v.logger().Info("All WireGuard tunnels established")
 				return nil
 			}
 
-			v.ctx.Log.Info("Waiting for WireGuard tunnels...", nil)
+			v.logger().Info("Waiting for WireGuard tunnels...")
 		}
 	}
 }
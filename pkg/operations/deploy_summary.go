@@ -0,0 +1,37 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DeploySummary records how long each phase of a deploy took
+type DeploySummary struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Status    string        `json:"status"` // success, failed
+	Duration  string        `json:"duration"`
+	Phases    []PhaseTiming `json:"phases"`
+}
+
+// PhaseTiming is the wall-clock time spent in a single deploy phase
+type PhaseTiming struct {
+	Phase    string `json:"phase"`
+	Status   string `json:"status"` // success, failed, unchanged, skipped
+	Duration string `json:"duration"`
+	Seconds  int64  `json:"seconds"`
+}
+
+// SaveDeploySummary stores the summary as the deploySummary stack output
+func SaveDeploySummary(stackName string, summary *DeploySummary) error {
+	if stackName == "" {
+		return fmt.Errorf("stack name is required")
+	}
+
+	summaryJSON, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deploy summary: %w", err)
+	}
+
+	return setStackOutput(stackName, "deploySummary", string(summaryJSON))
+}
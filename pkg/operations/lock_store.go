@@ -0,0 +1,241 @@
+package operations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// NewLockStoreForBackend returns the lock store matching a Pulumi backend URL.
+// S3 backends keep locks in the state bucket, file backends next to the state
+// directory, and anything else falls back to ~/.sloth/locks, which only
+// guards against concurrent commands on this machine.
+func NewLockStoreForBackend(ctx context.Context, backendURL string) (LockStore, error) {
+	switch {
+	case strings.HasPrefix(backendURL, "s3://"):
+		return NewS3LockStore(ctx, backendURL)
+	case strings.HasPrefix(backendURL, "file://"):
+		dir := strings.TrimPrefix(backendURL, "file://")
+		if strings.HasPrefix(dir, "~") {
+			homeDir, _ := os.UserHomeDir()
+			dir = filepath.Join(homeDir, strings.TrimPrefix(dir, "~"))
+		}
+		return &FileLockStore{Dir: dir}, nil
+	default:
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return &FileLockStore{Dir: homeDir}, nil
+	}
+}
+
+// FileLockStore keeps lock objects as files under Dir
+type FileLockStore struct {
+	Dir string
+}
+
+func (s *FileLockStore) path(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
+
+// Create writes the lock file, failing if it already exists
+func (s *FileLockStore) Create(ctx context.Context, key string, data []byte) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return ErrLockExists
+		}
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(data)
+	return err
+}
+
+// Read returns the lock file contents
+func (s *FileLockStore) Read(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrLockNotFound
+	}
+	return data, err
+}
+
+// Put overwrites the lock file
+func (s *FileLockStore) Put(ctx context.Context, key string, data []byte) error {
+	p := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0600)
+}
+
+// Delete removes the lock file
+func (s *FileLockStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// S3API is the subset of the S3 client used by S3LockStore
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// S3LockStore keeps lock objects in the state bucket. Create relies on
+// conditional writes (If-None-Match), which S3 and MinIO both support.
+type S3LockStore struct {
+	Client S3API
+	Bucket string
+	Prefix string
+}
+
+// NewS3LockStore builds a store for an s3:// backend URL. The endpoint, region
+// and path style come from the URL query, falling back to the AWS_* variables
+// saved by the login command.
+func NewS3LockStore(ctx context.Context, backendURL string) (*S3LockStore, error) {
+	u, err := url.Parse(backendURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backend URL: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("backend URL %q has no bucket", backendURL)
+	}
+
+	query := u.Query()
+
+	region := query.Get("region")
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	endpoint := os.Getenv("AWS_S3_ENDPOINT")
+	if endpoint == "" && query.Get("endpoint") != "" {
+		endpoint = query.Get("endpoint")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+	}
+
+	pathStyle := query.Get("s3ForcePathStyle") == "true" ||
+		os.Getenv("AWS_S3_USE_PATH_STYLE") == "true" ||
+		os.Getenv("AWS_S3_FORCE_PATH_STYLE") == "true"
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = pathStyle
+	})
+
+	return &S3LockStore{
+		Client: client,
+		Bucket: u.Host,
+		Prefix: strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+func (s *S3LockStore) key(key string) string {
+	if s.Prefix == "" {
+		return key
+	}
+	return path.Join(s.Prefix, key)
+}
+
+// Create writes the lock object only if it does not exist yet
+func (s *S3LockStore) Create(ctx context.Context, key string, data []byte) error {
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.key(key)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+		IfNoneMatch: aws.String("*"),
+	})
+	if isS3ErrorCode(err, "PreconditionFailed", "ConditionalRequestConflict") {
+		return ErrLockExists
+	}
+	return err
+}
+
+// Read returns the lock object contents
+func (s *S3LockStore) Read(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	if err != nil {
+		var noSuchKey *s3types.NoSuchKey
+		if errors.As(err, &noSuchKey) || isS3ErrorCode(err, "NoSuchKey", "NotFound") {
+			return nil, ErrLockNotFound
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return io.ReadAll(out.Body)
+}
+
+// Put overwrites the lock object
+func (s *S3LockStore) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.key(key)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+// Delete removes the lock object
+func (s *S3LockStore) Delete(ctx context.Context, key string) error {
+	_, err := s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.key(key)),
+	})
+	return err
+}
+
+func isS3ErrorCode(err error, codes ...string) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if apiErr.ErrorCode() == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package operations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultLockTTL is how long a stack lock is honoured before it counts as
+	// stale. It covers a full deploy; crashed commands free the stack after it.
+	DefaultLockTTL = 2 * time.Hour
+)
+
+var (
+	// ErrLockExists is returned by LockStore.Create when a lock object is already present
+	ErrLockExists = errors.New("lock already exists")
+
+	// ErrLockNotFound is returned by LockStore.Read when there is no lock object
+	ErrLockNotFound = errors.New("lock not found")
+)
+
+// StackLock is the advisory lock object stored next to the stack state
+type StackLock struct {
+	ID         string    `json:"id"`
+	Stack      string    `json:"stack"`
+	Owner      string    `json:"owner"`
+	Operation  string    `json:"operation"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// Expired reports whether the lock has outlived its TTL
+func (l *StackLock) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && now.After(l.ExpiresAt)
+}
+
+// StackLockedError is returned when another owner holds the stack lock
+type StackLockedError struct {
+	Lock *StackLock
+}
+
+func (e *StackLockedError) Error() string {
+	return fmt.Sprintf("stack %s is locked by %s since %s (%s, expires %s); rerun with --force-unlock if the lock is stale",
+		e.Lock.Stack, e.Lock.Owner, e.Lock.AcquiredAt.Local().Format(time.RFC1123),
+		e.Lock.Operation, e.Lock.ExpiresAt.Local().Format(time.RFC1123))
+}
+
+// LockStore persists lock objects. Create must fail with ErrLockExists when
+// the key is already present so two owners can never both acquire it.
+type LockStore interface {
+	Create(ctx context.Context, key string, data []byte) error
+	Read(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+	Delete(ctx context.Context, key string) error
+}
+
+// StackLocker acquires and releases stack locks in a LockStore
+type StackLocker struct {
+	Store LockStore
+	Owner string
+	TTL   time.Duration
+	now   func() time.Time
+}
+
+// NewStackLocker creates a locker owned by the current user and host
+func NewStackLocker(store LockStore) *StackLocker {
+	return &StackLocker{
+		Store: store,
+		Owner: DefaultLockOwner(),
+		TTL:   DefaultLockTTL,
+		now:   time.Now,
+	}
+}
+
+// DefaultLockOwner returns user@host for the current process
+func DefaultLockOwner() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return name
+	}
+	return fmt.Sprintf("%s@%s", name, host)
+}
+
+// stackLockKey is the object key of a stack's lock
+func stackLockKey(stack string) string {
+	return fmt.Sprintf(".sloth/locks/%s.json", stack)
+}
+
+// Acquire takes the lock for a stack. A lock held by someone else is only
+// replaced when it has expired or force is set; the replaced lock is
+// returned so callers can report what they broke.
+func (l *StackLocker) Acquire(ctx context.Context, stack, operation string, force bool) (held *StackLock, broken *StackLock, err error) {
+	now := l.now()
+	lock := &StackLock{
+		ID:         uuid.New().String(),
+		Stack:      stack,
+		Owner:      l.Owner,
+		Operation:  operation,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(l.TTL),
+	}
+
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal stack lock: %w", err)
+	}
+
+	key := stackLockKey(stack)
+	err = l.Store.Create(ctx, key, data)
+	if err == nil {
+		return lock, nil, nil
+	}
+	if !errors.Is(err, ErrLockExists) {
+		return nil, nil, fmt.Errorf("failed to acquire stack lock: %w", err)
+	}
+
+	existing, err := l.read(ctx, key)
+	if errors.Is(err, ErrLockNotFound) {
+		// Released between our create and read; try once more
+		if err := l.Store.Create(ctx, key, data); err != nil {
+			return nil, nil, fmt.Errorf("failed to acquire stack lock: %w", err)
+		}
+		return lock, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !force && !existing.Expired(now) {
+		return nil, nil, &StackLockedError{Lock: existing}
+	}
+
+	if err := l.Store.Put(ctx, key, data); err != nil {
+		return nil, nil, fmt.Errorf("failed to break stack lock: %w", err)
+	}
+	return lock, existing, nil
+}
+
+// Release removes the lock if it is still the one we hold. A lock that was
+// broken and re-acquired by someone else is left alone.
+func (l *StackLocker) Release(ctx context.Context, lock *StackLock) error {
+	if lock == nil {
+		return nil
+	}
+
+	key := stackLockKey(lock.Stack)
+	current, err := l.read(ctx, key)
+	if errors.Is(err, ErrLockNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.ID != lock.ID {
+		return fmt.Errorf("stack lock was taken over by %s since %s", current.Owner, current.AcquiredAt.Local().Format(time.RFC1123))
+	}
+
+	if err := l.Store.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to release stack lock: %w", err)
+	}
+	return nil
+}
+
+// Current returns the lock held on a stack, or nil when it is unlocked
+func (l *StackLocker) Current(ctx context.Context, stack string) (*StackLock, error) {
+	lock, err := l.read(ctx, stackLockKey(stack))
+	if errors.Is(err, ErrLockNotFound) {
+		return nil, nil
+	}
+	return lock, err
+}
+
+func (l *StackLocker) read(ctx context.Context, key string) (*StackLock, error) {
+	data, err := l.Store.Read(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrLockNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read stack lock: %w", err)
+	}
+
+	var lock StackLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse stack lock: %w", err)
+	}
+	return &lock, nil
+}
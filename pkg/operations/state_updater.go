@@ -83,6 +83,19 @@ func SaveOperationsHistory(stackName string, history *OperationsHistory) error {
 		return fmt.Errorf("stack name is required")
 	}
 
+	// Marshal history to JSON
+	history.LastUpdated = time.Now().UTC()
+	historyJSON, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+
+	return setStackOutput(stackName, "operationsHistory", string(historyJSON))
+}
+
+// setStackOutput writes a string output on the Stack resource of a Pulumi
+// stack by exporting, patching and re-importing its state
+func setStackOutput(stackName, key, value string) error {
 	stateMutex.Lock()
 	defer stateMutex.Unlock()
 
@@ -119,13 +132,6 @@ func SaveOperationsHistory(stackName string, history *OperationsHistory) error {
 		return fmt.Errorf("resources not found in deployment")
 	}
 
-	// Marshal history to JSON
-	history.LastUpdated = time.Now().UTC()
-	historyJSON, err := json.Marshal(history)
-	if err != nil {
-		return fmt.Errorf("failed to marshal history: %w", err)
-	}
-
 	// Find the Stack resource and update its outputs
 	found := false
 	for i, res := range resources {
@@ -141,7 +147,7 @@ func SaveOperationsHistory(stackName string, history *OperationsHistory) error {
 			if !ok {
 				outputs = make(map[string]interface{})
 			}
-			outputs["operationsHistory"] = string(historyJSON)
+			outputs[key] = value
 			resource["outputs"] = outputs
 			resources[i] = resource
 			found = true
@@ -1,14 +1,21 @@
 package providers
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	awsec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	awsec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
+	pulumiaws "github.com/pulumi/pulumi-aws/sdk/v6/go/aws"
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/lb"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -20,11 +27,16 @@ type AWSProvider struct {
 	vpc           *ec2.Vpc
 	subnet        *ec2.Subnet
 	subnets       []*ec2.Subnet
+	subnetZones   []string // availability zone of each subnet
 	securityGroup *ec2.SecurityGroup
 	keyPair       *ec2.KeyPair
+	privateNodes  bool
 	nodes         []*NodeOutput
+	loadBalancers int // load balancers created so far, to keep their names apart
 	ctx           *pulumi.Context
 	clusterConfig *config.ClusterConfig
+	scope         string                  // credentials scope; empty for the default instance
+	provider      pulumi.ProviderResource // explicit provider of a scoped instance
 }
 
 // NewAWSProvider creates a new AWS provider
@@ -77,6 +89,21 @@ func (p *AWSProvider) GetSizes() []string {
 	}
 }
 
+// GetPriceForSize returns the monthly list price in USD of a EC2 instance of the given size
+func (p *AWSProvider) GetPriceForSize(size, region string) (float64, error) {
+	return monthlyPrice(p.GetName(), awsMonthlyPrices, size)
+}
+
+// SetPublicIP controls whether instances created afterwards get a public IP
+func (p *AWSProvider) SetPublicIP(enabled bool) {
+	p.privateNodes = !enabled
+}
+
+// SetScope makes the instance create its resources with its own credentials
+func (p *AWSProvider) SetScope(scope string) {
+	p.scope = scope
+}
+
 // Initialize initializes the AWS provider
 func (p *AWSProvider) Initialize(ctx *pulumi.Context, cfg *config.ClusterConfig) error {
 	p.ctx = ctx
@@ -93,6 +120,19 @@ func (p *AWSProvider) Initialize(ctx *pulumi.Context, cfg *config.ClusterConfig)
 		return fmt.Errorf("AWS region is required")
 	}
 
+	if p.scope != "" {
+		args := &pulumiaws.ProviderArgs{Region: pulumi.StringPtr(p.config.Region)}
+		if p.config.AccessKeyID != "" {
+			args.AccessKey = pulumi.StringPtr(p.config.AccessKeyID)
+			args.SecretKey = pulumi.StringPtr(p.config.SecretAccessKey)
+		}
+		provider, err := pulumiaws.NewProvider(ctx, scopedName("aws", p.scope), args)
+		if err != nil {
+			return fmt.Errorf("failed to create AWS provider: %w", err)
+		}
+		p.provider = provider
+	}
+
 	// Setup SSH key pair
 	if err := p.setupKeyPair(ctx); err != nil {
 		return fmt.Errorf("failed to setup key pair: %w", err)
@@ -113,14 +153,14 @@ func (p *AWSProvider) setupKeyPair(ctx *pulumi.Context) error {
 			return fmt.Errorf("failed to read SSH public key: %w", err)
 		}
 		// Create key pair in AWS
-		keyPair, err := ec2.NewKeyPair(ctx, fmt.Sprintf("%s-aws-key", ctx.Stack()), &ec2.KeyPairArgs{
-			KeyName:   pulumi.String(fmt.Sprintf("%s-kubernetes", ctx.Stack())),
+		keyPair, err := ec2.NewKeyPair(ctx, scopedName(fmt.Sprintf("%s-aws-key", ctx.Stack()), p.scope), &ec2.KeyPairArgs{
+			KeyName:   pulumi.String(scopedName(fmt.Sprintf("%s-kubernetes", ctx.Stack()), p.scope)),
 			PublicKey: pulumi.String(sshKey),
 			Tags: pulumi.StringMap{
 				"Name":    pulumi.String(fmt.Sprintf("%s-kubernetes-key", ctx.Stack())),
 				"Cluster": pulumi.String(ctx.Stack()),
 			},
-		})
+		}, withProvider(p.provider)...)
 		if err != nil {
 			return fmt.Errorf("failed to create key pair: %w", err)
 		}
@@ -128,9 +168,9 @@ func (p *AWSProvider) setupKeyPair(ctx *pulumi.Context) error {
 		p.keyPair = keyPair
 
 		// Export key pair info
-		secrets.Export(ctx, "aws_key_pair_id", keyPair.ID())
-		secrets.Export(ctx, "aws_key_pair_name", keyPair.KeyName)
-		secrets.Export(ctx, "aws_key_pair_fingerprint", keyPair.Fingerprint)
+		secrets.Export(ctx, scopedName("aws_key_pair_id", p.scope), keyPair.ID())
+		secrets.Export(ctx, scopedName("aws_key_pair_name", p.scope), keyPair.KeyName)
+		secrets.Export(ctx, scopedName("aws_key_pair_fingerprint", p.scope), keyPair.Fingerprint)
 	} else if p.config.KeyPair != "" {
 		// Use existing key pair name - we'll reference it by name
 		ctx.Log.Info(fmt.Sprintf("Using existing key pair: %s", p.config.KeyPair), nil)
@@ -152,7 +192,7 @@ func (p *AWSProvider) CreateNetwork(ctx *pulumi.Context, network *config.Network
 	}
 
 	// Create VPC
-	vpc, err := ec2.NewVpc(ctx, fmt.Sprintf("%s-vpc", ctx.Stack()), &ec2.VpcArgs{
+	vpc, err := ec2.NewVpc(ctx, scopedName(fmt.Sprintf("%s-vpc", ctx.Stack()), p.scope), &ec2.VpcArgs{
 		CidrBlock:          pulumi.String(vpcConfig.CIDR),
 		EnableDnsHostnames: pulumi.Bool(true),
 		EnableDnsSupport:   pulumi.Bool(true),
@@ -160,20 +200,20 @@ func (p *AWSProvider) CreateNetwork(ctx *pulumi.Context, network *config.Network
 			"Name":    pulumi.String(fmt.Sprintf("%s-vpc", ctx.Stack())),
 			"Cluster": pulumi.String(ctx.Stack()),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create VPC: %w", err)
 	}
 	p.vpc = vpc
 
 	// Create Internet Gateway
-	igw, err := ec2.NewInternetGateway(ctx, fmt.Sprintf("%s-igw", ctx.Stack()), &ec2.InternetGatewayArgs{
+	igw, err := ec2.NewInternetGateway(ctx, scopedName(fmt.Sprintf("%s-igw", ctx.Stack()), p.scope), &ec2.InternetGatewayArgs{
 		VpcId: vpc.ID(),
 		Tags: pulumi.StringMap{
 			"Name":    pulumi.String(fmt.Sprintf("%s-igw", ctx.Stack())),
 			"Cluster": pulumi.String(ctx.Stack()),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create internet gateway: %w", err)
 	}
@@ -186,7 +226,7 @@ func (p *AWSProvider) CreateNetwork(ctx *pulumi.Context, network *config.Network
 	}
 
 	// Create public subnet
-	subnet, err := ec2.NewSubnet(ctx, fmt.Sprintf("%s-subnet-public", ctx.Stack()), &ec2.SubnetArgs{
+	subnet, err := ec2.NewSubnet(ctx, scopedName(fmt.Sprintf("%s-subnet-public", ctx.Stack()), p.scope), &ec2.SubnetArgs{
 		VpcId:                       vpc.ID(),
 		CidrBlock:                   pulumi.String(subnetCIDRs[0]),
 		MapPublicIpOnLaunch:         pulumi.Bool(true),
@@ -197,15 +237,16 @@ func (p *AWSProvider) CreateNetwork(ctx *pulumi.Context, network *config.Network
 			"Cluster": pulumi.String(ctx.Stack()),
 			"Type":    pulumi.String("public"),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create subnet: %w", err)
 	}
 	p.subnet = subnet
 	p.subnets = append(p.subnets, subnet)
+	p.subnetZones = append(p.subnetZones, fmt.Sprintf("%sa", p.config.Region))
 
 	// Create second subnet in different AZ for high availability
-	subnet2, err := ec2.NewSubnet(ctx, fmt.Sprintf("%s-subnet-public-2", ctx.Stack()), &ec2.SubnetArgs{
+	subnet2, err := ec2.NewSubnet(ctx, scopedName(fmt.Sprintf("%s-subnet-public-2", ctx.Stack()), p.scope), &ec2.SubnetArgs{
 		VpcId:               vpc.ID(),
 		CidrBlock:           pulumi.String(subnetCIDRs[1]),
 		MapPublicIpOnLaunch: pulumi.Bool(true),
@@ -215,14 +256,15 @@ func (p *AWSProvider) CreateNetwork(ctx *pulumi.Context, network *config.Network
 			"Cluster": pulumi.String(ctx.Stack()),
 			"Type":    pulumi.String("public"),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create second subnet: %w", err)
 	}
 	p.subnets = append(p.subnets, subnet2)
+	p.subnetZones = append(p.subnetZones, fmt.Sprintf("%sb", p.config.Region))
 
 	// Create route table
-	routeTable, err := ec2.NewRouteTable(ctx, fmt.Sprintf("%s-rt", ctx.Stack()), &ec2.RouteTableArgs{
+	routeTable, err := ec2.NewRouteTable(ctx, scopedName(fmt.Sprintf("%s-rt", ctx.Stack()), p.scope), &ec2.RouteTableArgs{
 		VpcId: vpc.ID(),
 		Routes: ec2.RouteTableRouteArray{
 			&ec2.RouteTableRouteArgs{
@@ -234,37 +276,37 @@ func (p *AWSProvider) CreateNetwork(ctx *pulumi.Context, network *config.Network
 			"Name":    pulumi.String(fmt.Sprintf("%s-rt-public", ctx.Stack())),
 			"Cluster": pulumi.String(ctx.Stack()),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create route table: %w", err)
 	}
 
 	// Associate route table with subnets
-	_, err = ec2.NewRouteTableAssociation(ctx, fmt.Sprintf("%s-rta-1", ctx.Stack()), &ec2.RouteTableAssociationArgs{
+	_, err = ec2.NewRouteTableAssociation(ctx, scopedName(fmt.Sprintf("%s-rta-1", ctx.Stack()), p.scope), &ec2.RouteTableAssociationArgs{
 		SubnetId:     subnet.ID(),
 		RouteTableId: routeTable.ID(),
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to associate route table with subnet 1: %w", err)
 	}
 
-	_, err = ec2.NewRouteTableAssociation(ctx, fmt.Sprintf("%s-rta-2", ctx.Stack()), &ec2.RouteTableAssociationArgs{
+	_, err = ec2.NewRouteTableAssociation(ctx, scopedName(fmt.Sprintf("%s-rta-2", ctx.Stack()), p.scope), &ec2.RouteTableAssociationArgs{
 		SubnetId:     subnet2.ID(),
 		RouteTableId: routeTable.ID(),
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to associate route table with subnet 2: %w", err)
 	}
 
 	// Export network info
-	secrets.Export(ctx, "aws_vpc_id", vpc.ID())
-	secrets.Export(ctx, "aws_vpc_cidr", vpc.CidrBlock)
-	secrets.Export(ctx, "aws_subnet_id", subnet.ID())
-	secrets.Export(ctx, "aws_igw_id", igw.ID())
+	secrets.Export(ctx, scopedName("aws_vpc_id", p.scope), vpc.ID())
+	secrets.Export(ctx, scopedName("aws_vpc_cidr", p.scope), vpc.CidrBlock)
+	secrets.Export(ctx, scopedName("aws_subnet_id", p.scope), subnet.ID())
+	secrets.Export(ctx, scopedName("aws_igw_id", p.scope), igw.ID())
 
 	return &NetworkOutput{
 		ID:     vpc.ID(),
-		Name:   fmt.Sprintf("%s-vpc", ctx.Stack()),
+		Name:   scopedName(fmt.Sprintf("%s-vpc", ctx.Stack()), p.scope),
 		CIDR:   vpcConfig.CIDR,
 		Region: p.config.Region,
 		Subnets: []SubnetOutput{
@@ -280,99 +322,43 @@ func (p *AWSProvider) CreateFirewall(ctx *pulumi.Context, firewall *config.Firew
 		return fmt.Errorf("VPC must be created before creating firewall")
 	}
 
-	// Build ingress rules
-	ingressRules := ec2.SecurityGroupIngressArray{
-		// SSH
-		&ec2.SecurityGroupIngressArgs{
-			Protocol:   pulumi.String("tcp"),
-			FromPort:   pulumi.Int(22),
-			ToPort:     pulumi.Int(22),
-			CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-		},
-		// Kubernetes API
-		&ec2.SecurityGroupIngressArgs{
-			Protocol:   pulumi.String("tcp"),
-			FromPort:   pulumi.Int(6443),
-			ToPort:     pulumi.Int(6443),
-			CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-		},
-		// WireGuard
-		&ec2.SecurityGroupIngressArgs{
-			Protocol:   pulumi.String("udp"),
-			FromPort:   pulumi.Int(51820),
-			ToPort:     pulumi.Int(51820),
-			CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-		},
-		// All traffic within VPC
-		&ec2.SecurityGroupIngressArgs{
-			Protocol:   pulumi.String("-1"),
-			FromPort:   pulumi.Int(0),
-			ToPort:     pulumi.Int(0),
-			CidrBlocks: pulumi.StringArray{pulumi.String("10.0.0.0/16")},
-		},
-		// NodePort range
-		&ec2.SecurityGroupIngressArgs{
-			Protocol:   pulumi.String("tcp"),
-			FromPort:   pulumi.Int(30000),
-			ToPort:     pulumi.Int(32767),
-			CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-		},
-		// HTTP/HTTPS
-		&ec2.SecurityGroupIngressArgs{
-			Protocol:   pulumi.String("tcp"),
-			FromPort:   pulumi.Int(80),
-			ToPort:     pulumi.Int(80),
-			CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-		},
-		&ec2.SecurityGroupIngressArgs{
-			Protocol:   pulumi.String("tcp"),
-			FromPort:   pulumi.Int(443),
-			ToPort:     pulumi.Int(443),
-			CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-		},
-	}
+	ingressRules := ec2.SecurityGroupIngressArray{}
+	egressRules := ec2.SecurityGroupEgressArray{}
+	for _, rule := range awsFirewallRules(firewall) {
+		fromPort, toPort, err := parseFirewallPorts(rule.Ports)
+		if err != nil {
+			return err
+		}
 
-	// Add custom firewall rules
-	if firewall != nil {
-		for _, rule := range firewall.InboundRules {
-			port, _ := strconv.Atoi(rule.Port)
-			// Convert source CIDRs
-			cidrBlocks := pulumi.StringArray{}
-			for _, src := range rule.Source {
-				cidrBlocks = append(cidrBlocks, pulumi.String(src))
-			}
-			if len(cidrBlocks) == 0 {
-				cidrBlocks = pulumi.StringArray{pulumi.String("0.0.0.0/0")}
-			}
-			ingressRules = append(ingressRules, &ec2.SecurityGroupIngressArgs{
+		if rule.Direction == FirewallOutbound {
+			egressRules = append(egressRules, &ec2.SecurityGroupEgressArgs{
 				Protocol:   pulumi.String(rule.Protocol),
-				FromPort:   pulumi.Int(port),
-				ToPort:     pulumi.Int(port),
-				CidrBlocks: cidrBlocks,
+				FromPort:   pulumi.Int(fromPort),
+				ToPort:     pulumi.Int(toPort),
+				CidrBlocks: pulumi.ToStringArray(rule.Addresses),
 			})
+			continue
 		}
+		ingressRules = append(ingressRules, &ec2.SecurityGroupIngressArgs{
+			Protocol:   pulumi.String(rule.Protocol),
+			FromPort:   pulumi.Int(fromPort),
+			ToPort:     pulumi.Int(toPort),
+			CidrBlocks: pulumi.ToStringArray(rule.Addresses),
+		})
 	}
 
 	// Create security group
-	sg, err := ec2.NewSecurityGroup(ctx, fmt.Sprintf("%s-sg", ctx.Stack()), &ec2.SecurityGroupArgs{
-		Name:        pulumi.String(fmt.Sprintf("%s-kubernetes-sg", ctx.Stack())),
+	sg, err := ec2.NewSecurityGroup(ctx, scopedName(fmt.Sprintf("%s-sg", ctx.Stack()), p.scope), &ec2.SecurityGroupArgs{
+		Name:        pulumi.String(awsSecurityGroupName(ctx.Stack())),
 		Description: pulumi.String("Security group for Kubernetes cluster"),
 		VpcId:       p.vpc.ID(),
 		Ingress:     ingressRules,
-		Egress: ec2.SecurityGroupEgressArray{
-			// Allow all outbound
-			&ec2.SecurityGroupEgressArgs{
-				Protocol:   pulumi.String("-1"),
-				FromPort:   pulumi.Int(0),
-				ToPort:     pulumi.Int(0),
-				CidrBlocks: pulumi.StringArray{pulumi.String("0.0.0.0/0")},
-			},
-		},
+		Egress:      egressRules,
 		Tags: pulumi.StringMap{
-			"Name":    pulumi.String(fmt.Sprintf("%s-kubernetes-sg", ctx.Stack())),
+			"Name":    pulumi.String(awsSecurityGroupName(ctx.Stack())),
 			"Cluster": pulumi.String(ctx.Stack()),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return fmt.Errorf("failed to create security group: %w", err)
 	}
@@ -380,12 +366,51 @@ func (p *AWSProvider) CreateFirewall(ctx *pulumi.Context, firewall *config.Firew
 	p.securityGroup = sg
 
 	// Export security group info
-	secrets.Export(ctx, "aws_security_group_id", sg.ID())
-	secrets.Export(ctx, "aws_security_group_name", sg.Name)
+	secrets.Export(ctx, scopedName("aws_security_group_id", p.scope), sg.ID())
+	secrets.Export(ctx, scopedName("aws_security_group_name", p.scope), sg.Name)
 
 	return nil
 }
 
+// awsFirewallRules returns the rules of the cluster security group: SSH, the
+// Kubernetes API, WireGuard, the VPC's traffic, NodePorts, HTTP/HTTPS, the
+// config's inbound rules and all outbound traffic
+func awsFirewallRules(firewall *config.FirewallConfig) []FirewallRuleSpec {
+	anywhere := []string{"0.0.0.0/0"}
+	rules := []FirewallRuleSpec{
+		{Direction: FirewallInbound, Protocol: "tcp", Ports: "22", Addresses: anywhere},
+		{Direction: FirewallInbound, Protocol: "tcp", Ports: "6443", Addresses: anywhere},
+		{Direction: FirewallInbound, Protocol: "udp", Ports: "51820", Addresses: anywhere},
+		{Direction: FirewallInbound, Protocol: "-1", Addresses: []string{"10.0.0.0/16"}},
+		{Direction: FirewallInbound, Protocol: "tcp", Ports: "30000-32767", Addresses: anywhere},
+		{Direction: FirewallInbound, Protocol: "tcp", Ports: "80", Addresses: anywhere},
+		{Direction: FirewallInbound, Protocol: "tcp", Ports: "443", Addresses: anywhere},
+	}
+
+	if firewall != nil {
+		for _, rule := range firewall.InboundRules {
+			sources := rule.Source
+			if len(sources) == 0 {
+				sources = anywhere
+			}
+			rules = append(rules, FirewallRuleSpec{
+				Direction: FirewallInbound,
+				Protocol:  rule.Protocol,
+				Ports:     rule.Port,
+				Addresses: sources,
+			})
+		}
+	}
+
+	// Allow all outbound
+	return append(rules, FirewallRuleSpec{Direction: FirewallOutbound, Protocol: "-1", Addresses: anywhere})
+}
+
+// awsSecurityGroupName returns the name of a stack's cluster security group
+func awsSecurityGroupName(stack string) string {
+	return fmt.Sprintf("%s-kubernetes-sg", stack)
+}
+
 // CreateNode creates an EC2 instance
 func (p *AWSProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (*NodeOutput, error) {
 	if p.securityGroup == nil {
@@ -407,8 +432,15 @@ func (p *AWSProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (
 		keyName = pulumi.StringPtr(p.config.KeyPair)
 	}
 
-	// Select subnet (round-robin between available subnets)
+	// Select the subnet of the node's zone, or round-robin between the
+	// available subnets when it has none
 	subnetIndex := len(p.nodes) % len(p.subnets)
+	for i, zone := range p.subnetZones {
+		if zone == node.Zone {
+			subnetIndex = i
+			break
+		}
+	}
 	subnet := p.subnets[subnetIndex]
 
 	// Build tags
@@ -436,11 +468,11 @@ func (p *AWSProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (
 			SubnetId:                 subnet.ID(),
 			VpcSecurityGroupIds:      pulumi.StringArray{p.securityGroup.ID()},
 			UserData:                 pulumi.String(userDataEncoded),
-			AssociatePublicIpAddress: pulumi.Bool(true),
+			AssociatePublicIpAddress: pulumi.Bool(!p.privateNodes),
 			SpotType:                 pulumi.String("one-time"),
 			WaitForFulfillment:       pulumi.Bool(true),
 			Tags:                     tags,
-		})
+		}, withProvider(p.provider)...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create spot instance request %s: %w", node.Name, err)
 		}
@@ -456,8 +488,9 @@ func (p *AWSProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (
 			Size:        node.Size,
 			Status:      spotRequest.SpotRequestState,
 			Labels:      node.Labels,
+			Taints:      node.Taints,
 			WireGuardIP: node.WireGuardIP,
-			SSHUser:     "ubuntu",
+			SSHUser:     config.SSHUserFor("aws", node.SSHUser),
 			SSHKeyPath:  p.clusterConfig.Security.SSHConfig.KeyPath,
 		}
 
@@ -479,7 +512,7 @@ func (p *AWSProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (
 		SubnetId:                 subnet.ID(),
 		VpcSecurityGroupIds:      pulumi.StringArray{p.securityGroup.ID()},
 		UserDataBase64:           pulumi.String(userDataEncoded),
-		AssociatePublicIpAddress: pulumi.Bool(true),
+		AssociatePublicIpAddress: pulumi.Bool(!p.privateNodes),
 		RootBlockDevice: &ec2.InstanceRootBlockDeviceArgs{
 			VolumeSize:          pulumi.Int(50),
 			VolumeType:          pulumi.String("gp3"),
@@ -490,7 +523,7 @@ func (p *AWSProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (
 			HttpTokens:   pulumi.String("optional"),
 			HttpEndpoint: pulumi.String("enabled"),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create instance %s: %w", node.Name, err)
 	}
@@ -506,8 +539,9 @@ func (p *AWSProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (
 		Size:        node.Size,
 		Status:      instance.InstanceState,
 		Labels:      node.Labels,
+		Taints:      node.Taints,
 		WireGuardIP: node.WireGuardIP,
-		SSHUser:     "ubuntu",
+		SSHUser:     config.SSHUserFor("aws", node.SSHUser),
 		SSHKeyPath:  p.clusterConfig.Security.SSHConfig.KeyPath,
 	}
 
@@ -540,23 +574,7 @@ func (p *AWSProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePool)
 		zone := zones[i%len(zones)]
 
 		// Generate node name
-		nodeName := fmt.Sprintf("%s-%d", pool.Name, i+1)
-
-		// Assign WireGuard IP based on role
-		var wireGuardIP string
-		isMaster := false
-		for _, role := range pool.Roles {
-			if role == "master" || role == "controlplane" {
-				isMaster = true
-				break
-			}
-		}
-
-		if isMaster {
-			wireGuardIP = fmt.Sprintf("10.8.0.%d", 20+i)
-		} else {
-			wireGuardIP = fmt.Sprintf("10.8.0.%d", 30+i)
-		}
+		nodeName := pool.NodeName(i)
 
 		// Create node config
 		nodeConfig := &config.NodeConfig{
@@ -570,9 +588,10 @@ func (p *AWSProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePool)
 			Zone:         zone,
 			Labels:       pool.Labels,
 			Taints:       pool.Taints,
-			WireGuardIP:  wireGuardIP,
+			WireGuardIP:  pool.WireGuardIPFor(i),
 			SpotInstance: pool.SpotInstance,
 			UserData:     pool.UserData,
+			SSHUser:      pool.SSHUser,
 		}
 
 		output, err := p.CreateNode(ctx, nodeConfig)
@@ -586,6 +605,11 @@ func (p *AWSProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePool)
 	return outputs, nil
 }
 
+// Zones returns the availability zones of the cluster subnets
+func (p *AWSProvider) Zones() []string {
+	return append([]string(nil), p.subnetZones...)
+}
+
 // CreateLoadBalancer creates a Network Load Balancer
 func (p *AWSProvider) CreateLoadBalancer(ctx *pulumi.Context, lbConfig *config.LoadBalancerConfig) (*LoadBalancerOutput, error) {
 	if p.vpc == nil || len(p.subnets) == 0 {
@@ -598,104 +622,554 @@ func (p *AWSProvider) CreateLoadBalancer(ctx *pulumi.Context, lbConfig *config.L
 		subnetIds = append(subnetIds, subnet.ID())
 	}
 
+	// The first load balancer keeps the names it had when only one was
+	// supported; later ones are told apart by their configured name
+	prefix := ctx.Stack()
+	if p.loadBalancers > 0 {
+		prefix = fmt.Sprintf("%s-%s", ctx.Stack(), lbConfig.Name)
+	}
+	p.loadBalancers++
+
 	// Create Network Load Balancer
-	nlb, err := lb.NewLoadBalancer(ctx, fmt.Sprintf("%s-nlb", ctx.Stack()), &lb.LoadBalancerArgs{
-		Name:             pulumi.String(fmt.Sprintf("%s-nlb", ctx.Stack())),
+	nlb, err := lb.NewLoadBalancer(ctx, fmt.Sprintf("%s-nlb", prefix), &lb.LoadBalancerArgs{
+		Name:             pulumi.String(fmt.Sprintf("%s-nlb", prefix)),
 		LoadBalancerType: pulumi.String("network"),
 		Subnets:          subnetIds,
 		Tags: pulumi.StringMap{
-			"Name":    pulumi.String(fmt.Sprintf("%s-nlb", ctx.Stack())),
+			"Name":    pulumi.String(fmt.Sprintf("%s-nlb", prefix)),
 			"Cluster": pulumi.String(ctx.Stack()),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create load balancer: %w", err)
 	}
 
-	// Create target group for Kubernetes API
-	tg, err := lb.NewTargetGroup(ctx, fmt.Sprintf("%s-tg-k8s-api", ctx.Stack()), &lb.TargetGroupArgs{
-		Name:       pulumi.String(fmt.Sprintf("%s-k8s-api", ctx.Stack())),
-		Port:       pulumi.Int(6443),
-		Protocol:   pulumi.String("TCP"),
-		VpcId:      p.vpc.ID(),
-		TargetType: pulumi.String("instance"),
-		HealthCheck: &lb.TargetGroupHealthCheckArgs{
+	backends := 0
+	for i, node := range p.nodes {
+		if awsLoadBalancerTarget(lbConfig, node, i) {
+			backends++
+		}
+	}
+
+	for _, port := range loadBalancerPorts(lbConfig) {
+		targetPort := backendPort(port)
+		protocol := "TCP"
+		if strings.EqualFold(port.Protocol, "udp") {
+			protocol = "UDP"
+		}
+
+		// The Kubernetes API port keeps its original resource names
+		tgResource, tgName := fmt.Sprintf("%s-tg-%d", prefix, port.Port), fmt.Sprintf("%s-%d", prefix, port.Port)
+		listenerName, attachmentPrefix := fmt.Sprintf("%s-listener-%d", prefix, port.Port), fmt.Sprintf("%s-tga-%d", prefix, port.Port)
+		if port.Port == 6443 {
+			tgResource, tgName = fmt.Sprintf("%s-tg-k8s-api", prefix), fmt.Sprintf("%s-k8s-api", prefix)
+			listenerName, attachmentPrefix = fmt.Sprintf("%s-listener-k8s", prefix), fmt.Sprintf("%s-tga", prefix)
+		}
+
+		healthCheck := &lb.TargetGroupHealthCheckArgs{
 			Protocol:           pulumi.String("TCP"),
-			Port:               pulumi.String("6443"),
+			Port:               pulumi.String(fmt.Sprintf("%d", targetPort)),
 			HealthyThreshold:   pulumi.Int(3),
 			UnhealthyThreshold: pulumi.Int(3),
 			Interval:           pulumi.Int(30),
-		},
-		Tags: pulumi.StringMap{
-			"Name":    pulumi.String(fmt.Sprintf("%s-tg-k8s-api", ctx.Stack())),
-			"Cluster": pulumi.String(ctx.Stack()),
-		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create target group: %w", err)
-	}
-
-	// Register master nodes with target group
-	for i, node := range p.nodes {
-		// Only register master nodes
-		isMaster := false
-		for k := range node.Labels {
-			if k == "role" && node.Labels[k] == "master" {
-				isMaster = true
-				break
-			}
 		}
-		// Check node name for master indication
-		if !isMaster {
-			for _, role := range []string{"master", "controlplane"} {
-				if len(node.Name) > len(role) && node.Name[:len(role)] == role {
-					isMaster = true
-					break
-				}
-			}
+		if healthCheckProtocol(port) == "http" {
+			healthCheck.Protocol = pulumi.String("HTTP")
+			healthCheck.Path = pulumi.String(healthCheckPath)
+		}
+
+		tg, err := lb.NewTargetGroup(ctx, tgResource, &lb.TargetGroupArgs{
+			Name:        pulumi.String(tgName),
+			Port:        pulumi.Int(targetPort),
+			Protocol:    pulumi.String(protocol),
+			VpcId:       p.vpc.ID(),
+			TargetType:  pulumi.String("instance"),
+			HealthCheck: healthCheck,
+			Tags: pulumi.StringMap{
+				"Name":    pulumi.String(tgResource),
+				"Cluster": pulumi.String(ctx.Stack()),
+			},
+		}, withProvider(p.provider)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create target group: %w", err)
 		}
 
-		if isMaster || i < 3 { // Register first 3 nodes as masters if role not clear
-			_, err := lb.NewTargetGroupAttachment(ctx, fmt.Sprintf("%s-tga-%d", ctx.Stack(), i), &lb.TargetGroupAttachmentArgs{
+		for i, node := range p.nodes {
+			if !awsLoadBalancerTarget(lbConfig, node, i) {
+				continue
+			}
+			_, err := lb.NewTargetGroupAttachment(ctx, fmt.Sprintf("%s-%d", attachmentPrefix, i), &lb.TargetGroupAttachmentArgs{
 				TargetGroupArn: tg.Arn,
 				TargetId:       node.ID.ToStringOutput(),
-				Port:           pulumi.Int(6443),
-			})
+				Port:           pulumi.Int(targetPort),
+			}, withProvider(p.provider)...)
 			if err != nil {
 				return nil, fmt.Errorf("failed to attach node to target group: %w", err)
 			}
 		}
-	}
 
-	// Create listener
-	_, err = lb.NewListener(ctx, fmt.Sprintf("%s-listener-k8s", ctx.Stack()), &lb.ListenerArgs{
-		LoadBalancerArn: nlb.Arn,
-		Port:            pulumi.Int(6443),
-		Protocol:        pulumi.String("TCP"),
-		DefaultActions: lb.ListenerDefaultActionArray{
-			&lb.ListenerDefaultActionArgs{
-				Type:           pulumi.String("forward"),
-				TargetGroupArn: tg.Arn,
+		_, err = lb.NewListener(ctx, listenerName, &lb.ListenerArgs{
+			LoadBalancerArn: nlb.Arn,
+			Port:            pulumi.Int(port.Port),
+			Protocol:        pulumi.String(protocol),
+			DefaultActions: lb.ListenerDefaultActionArray{
+				&lb.ListenerDefaultActionArgs{
+					Type:           pulumi.String("forward"),
+					TargetGroupArn: tg.Arn,
+				},
 			},
-		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create listener: %w", err)
+		}, withProvider(p.provider)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create listener: %w", err)
+		}
 	}
 
 	// Export load balancer info
-	secrets.Export(ctx, "aws_nlb_dns_name", nlb.DnsName)
-	secrets.Export(ctx, "aws_nlb_arn", nlb.Arn)
-	secrets.Export(ctx, "aws_nlb_zone_id", nlb.ZoneId)
+	if prefix == ctx.Stack() {
+		secrets.Export(ctx, "aws_nlb_dns_name", nlb.DnsName)
+		secrets.Export(ctx, "aws_nlb_arn", nlb.Arn)
+		secrets.Export(ctx, "aws_nlb_zone_id", nlb.ZoneId)
+	}
 
 	return &LoadBalancerOutput{
 		ID:       nlb.ID(),
 		IP:       nlb.DnsName,
 		Hostname: nlb.DnsName,
 		Status:   pulumi.String("active").ToStringOutput(),
+		Backends: backends,
 	}, nil
 }
 
+// awsLoadBalancerTarget reports whether the load balancer sends traffic to
+// the node. Without target nodes it falls back to the masters, recognised by
+// their role label or name, or the first three nodes when neither says.
+func awsLoadBalancerTarget(lbConfig *config.LoadBalancerConfig, node *NodeOutput, index int) bool {
+	if len(lbConfig.TargetNodes) > 0 {
+		return targetsNode(lbConfig, node.Name)
+	}
+	if node.Labels["role"] == "master" {
+		return true
+	}
+	for _, role := range []string{"master", "controlplane"} {
+		if len(node.Name) > len(role) && node.Name[:len(role)] == role {
+			return true
+		}
+	}
+	return index < 3
+}
+
+// ResizeNode changes the instance type of an EC2 instance. The instance type
+// can only be changed while stopped, so the instance is stopped, modified and
+// started again.
+func (p *AWSProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	if err := ValidateNodeSize(p, newSize); err != nil {
+		return err
+	}
+
+	client, err := p.ec2Client(ctx, node.Region)
+	if err != nil {
+		return err
+	}
+
+	instances, err := findInstances(ctx, client, node.Name)
+	if err != nil {
+		return err
+	}
+	if len(instances) == 0 {
+		return fmt.Errorf("instance %s not found", node.Name)
+	}
+	instance := &instances[0]
+
+	instanceIDs := []string{aws.ToString(instance.InstanceId)}
+	describeInput := &awsec2.DescribeInstancesInput{InstanceIds: instanceIDs}
+	wasRunning := instance.State != nil && instance.State.Name != awsec2types.InstanceStateNameStopped
+
+	if wasRunning {
+		if _, err := client.StopInstances(ctx, &awsec2.StopInstancesInput{InstanceIds: instanceIDs}); err != nil {
+			return fmt.Errorf("failed to stop instance %s: %w", node.Name, err)
+		}
+		if err := awsec2.NewInstanceStoppedWaiter(client).Wait(ctx, describeInput, resizeTimeout); err != nil {
+			return fmt.Errorf("instance %s did not stop: %w", node.Name, err)
+		}
+	}
+
+	if _, err := client.ModifyInstanceAttribute(ctx, &awsec2.ModifyInstanceAttributeInput{
+		InstanceId:   instance.InstanceId,
+		InstanceType: &awsec2types.AttributeValue{Value: aws.String(newSize)},
+	}); err != nil {
+		return fmt.Errorf("failed to change instance type of %s: %w", node.Name, err)
+	}
+
+	if wasRunning {
+		if _, err := client.StartInstances(ctx, &awsec2.StartInstancesInput{InstanceIds: instanceIDs}); err != nil {
+			return fmt.Errorf("failed to start instance %s: %w", node.Name, err)
+		}
+		if err := awsec2.NewInstanceRunningWaiter(client).Wait(ctx, describeInput, resizeTimeout); err != nil {
+			return fmt.Errorf("instance %s did not start: %w", node.Name, err)
+		}
+	}
+
+	node.Size = newSize
+	return nil
+}
+
+// DestroyNode terminates the EC2 instance backing a node. An instance that no
+// longer exists is treated as already destroyed.
+func (p *AWSProvider) DestroyNode(ctx context.Context, node *NodeOutput) error {
+	client, err := p.ec2Client(ctx, node.Region)
+	if err != nil {
+		return err
+	}
+
+	instanceID, err := p.findNodeInstance(ctx, client, node)
+	if err != nil {
+		return err
+	}
+	if instanceID == "" {
+		return nil
+	}
+
+	if _, err := client.TerminateInstances(ctx, &awsec2.TerminateInstancesInput{InstanceIds: []string{instanceID}}); err != nil {
+		return fmt.Errorf("failed to terminate instance %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// findNodeInstance returns the ID of the EC2 instance backing a node, or ""
+// when it no longer exists. A node created in this run is found by the
+// instance ID Pulumi recorded, once its create has settled. Otherwise the
+// instance is looked up by its Name and Cluster tags, and more than one match
+// is refused rather than guessed at.
+func (p *AWSProvider) findNodeInstance(ctx context.Context, client *awsec2.Client, node *NodeOutput) (string, error) {
+	filters := []awsec2types.Filter{
+		{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+	}
+	if id, ok := nodeResourceID(ctx, node); ok {
+		filters = append(filters, awsec2types.Filter{Name: aws.String("instance-id"), Values: []string{id}})
+	} else {
+		stack, err := stackName(p.ctx)
+		if err != nil {
+			return "", err
+		}
+		filters = append(filters,
+			awsec2types.Filter{Name: aws.String("tag:Name"), Values: []string{node.Name}},
+			awsec2types.Filter{Name: aws.String("tag:Cluster"), Values: []string{stack}},
+		)
+	}
+
+	described, err := client.DescribeInstances(ctx, &awsec2.DescribeInstancesInput{Filters: filters})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up instance %s: %w", node.Name, err)
+	}
+
+	var matches []string
+	for _, reservation := range described.Reservations {
+		for _, instance := range reservation.Instances {
+			matches = append(matches, aws.ToString(instance.InstanceId))
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("found %d instances named %s in this cluster, refusing to guess which one to terminate", len(matches), node.Name)
+	}
+}
+
+// RebootNode reboots the EC2 instance backing a node. EC2 reboots
+// asynchronously, so the call returns once the reboot has been requested.
+func (p *AWSProvider) RebootNode(ctx context.Context, node *NodeOutput) error {
+	client, err := p.ec2Client(ctx, node.Region)
+	if err != nil {
+		return err
+	}
+
+	instances, err := findInstances(ctx, client, node.Name)
+	if err != nil {
+		return err
+	}
+	if len(instances) == 0 {
+		return fmt.Errorf("instance %s not found", node.Name)
+	}
+
+	if _, err := client.RebootInstances(ctx, &awsec2.RebootInstancesInput{
+		InstanceIds: []string{aws.ToString(instances[0].InstanceId)},
+	}); err != nil {
+		return fmt.Errorf("failed to reboot instance %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// ListFirewallRules returns the rules of the live cluster security group,
+// one per CIDR as EC2 stores them
+func (p *AWSProvider) ListFirewallRules(ctx context.Context, firewall *config.FirewallConfig) ([]FirewallRuleSpec, error) {
+	client, err := p.ec2Client(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	groupID, err := findSecurityGroup(ctx, client, p.securityGroupName(firewall))
+	if err != nil {
+		return nil, err
+	}
+	return listSecurityGroupRules(ctx, client, groupID)
+}
+
+// ReconcileFirewall authorizes and revokes rules on the live cluster security
+// group until it matches the config, then adds the group to the given
+// instances that lack it. New rules are authorized before old ones are
+// revoked so an edited rule never leaves a gap.
+func (p *AWSProvider) ReconcileFirewall(ctx context.Context, firewall *config.FirewallConfig, nodeIDs []string) (*FirewallDiff, error) {
+	client, err := p.ec2Client(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	groupName := p.securityGroupName(firewall)
+	groupID, err := findSecurityGroup(ctx, client, groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := listSecurityGroupRules(ctx, client, groupID)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffFirewallRules(splitFirewallRulesByAddress(awsFirewallRules(firewall)), live)
+
+	var ingress, egress []awsec2types.IpPermission
+	for _, rule := range diff.Add {
+		permission, err := awsIPPermission(rule)
+		if err != nil {
+			return nil, err
+		}
+		if rule.Direction == FirewallOutbound {
+			egress = append(egress, permission)
+		} else {
+			ingress = append(ingress, permission)
+		}
+	}
+	if len(ingress) > 0 {
+		if _, err := client.AuthorizeSecurityGroupIngress(ctx, &awsec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: ingress,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to authorize rules on security group %s: %w", groupName, err)
+		}
+	}
+	if len(egress) > 0 {
+		if _, err := client.AuthorizeSecurityGroupEgress(ctx, &awsec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: egress,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to authorize rules on security group %s: %w", groupName, err)
+		}
+	}
+
+	var revokeIngress, revokeEgress []string
+	for _, rule := range diff.Remove {
+		if rule.Direction == FirewallOutbound {
+			revokeEgress = append(revokeEgress, rule.ID)
+		} else {
+			revokeIngress = append(revokeIngress, rule.ID)
+		}
+	}
+	if len(revokeIngress) > 0 {
+		if _, err := client.RevokeSecurityGroupIngress(ctx, &awsec2.RevokeSecurityGroupIngressInput{
+			GroupId:              aws.String(groupID),
+			SecurityGroupRuleIds: revokeIngress,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to revoke rules on security group %s: %w", groupName, err)
+		}
+	}
+	if len(revokeEgress) > 0 {
+		if _, err := client.RevokeSecurityGroupEgress(ctx, &awsec2.RevokeSecurityGroupEgressInput{
+			GroupId:              aws.String(groupID),
+			SecurityGroupRuleIds: revokeEgress,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to revoke rules on security group %s: %w", groupName, err)
+		}
+	}
+
+	if len(nodeIDs) == 0 {
+		return diff, nil
+	}
+	described, err := client.DescribeInstances(ctx, &awsec2.DescribeInstancesInput{InstanceIds: nodeIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+	for _, reservation := range described.Reservations {
+		for _, instance := range reservation.Instances {
+			groups := []string{groupID}
+			attached := false
+			for _, group := range instance.SecurityGroups {
+				if aws.ToString(group.GroupId) == groupID {
+					attached = true
+				}
+				groups = append(groups, aws.ToString(group.GroupId))
+			}
+			if attached {
+				continue
+			}
+			if _, err := client.ModifyInstanceAttribute(ctx, &awsec2.ModifyInstanceAttributeInput{
+				InstanceId: instance.InstanceId,
+				Groups:     groups,
+			}); err != nil {
+				return nil, fmt.Errorf("failed to add security group %s to instance %s: %w", groupName, aws.ToString(instance.InstanceId), err)
+			}
+		}
+	}
+	return diff, nil
+}
+
+// securityGroupName returns the name of the cluster security group: the
+// stack's when running inside Pulumi, otherwise the firewall's name
+func (p *AWSProvider) securityGroupName(firewall *config.FirewallConfig) string {
+	if p.ctx != nil {
+		return awsSecurityGroupName(p.ctx.Stack())
+	}
+	return firewall.Name
+}
+
+// findSecurityGroup returns the ID of the security group with the given name
+func findSecurityGroup(ctx context.Context, client *awsec2.Client, name string) (string, error) {
+	described, err := client.DescribeSecurityGroups(ctx, &awsec2.DescribeSecurityGroupsInput{
+		Filters: []awsec2types.Filter{{Name: aws.String("group-name"), Values: []string{name}}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up security group %s: %w", name, err)
+	}
+	if len(described.SecurityGroups) == 0 {
+		return "", fmt.Errorf("security group %s not found", name)
+	}
+	return aws.ToString(described.SecurityGroups[0].GroupId), nil
+}
+
+// listSecurityGroupRules returns the CIDR rules of a security group
+func listSecurityGroupRules(ctx context.Context, client *awsec2.Client, groupID string) ([]FirewallRuleSpec, error) {
+	var rules []FirewallRuleSpec
+	paginator := awsec2.NewDescribeSecurityGroupRulesPaginator(client, &awsec2.DescribeSecurityGroupRulesInput{
+		Filters: []awsec2types.Filter{{Name: aws.String("group-id"), Values: []string{groupID}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list rules of security group %s: %w", groupID, err)
+		}
+		for _, rule := range page.SecurityGroupRules {
+			if spec, ok := awsLiveFirewallRule(rule); ok {
+				rules = append(rules, spec)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// awsLiveFirewallRule converts a security group rule. Rules that reference
+// another security group or a prefix list have no CIDR and are skipped, so
+// reconciling never revokes them.
+func awsLiveFirewallRule(rule awsec2types.SecurityGroupRule) (FirewallRuleSpec, bool) {
+	address := aws.ToString(rule.CidrIpv4)
+	if address == "" {
+		address = aws.ToString(rule.CidrIpv6)
+	}
+	if address == "" {
+		return FirewallRuleSpec{}, false
+	}
+
+	direction := FirewallInbound
+	if aws.ToBool(rule.IsEgress) {
+		direction = FirewallOutbound
+	}
+	protocol := aws.ToString(rule.IpProtocol)
+	ports := ""
+	if protocol != "-1" {
+		from, to := aws.ToInt32(rule.FromPort), aws.ToInt32(rule.ToPort)
+		ports = fmt.Sprintf("%d-%d", from, to)
+		if from == to {
+			ports = strconv.Itoa(int(from))
+		}
+	}
+	return FirewallRuleSpec{
+		ID:        aws.ToString(rule.SecurityGroupRuleId),
+		Direction: direction,
+		Protocol:  protocol,
+		Ports:     ports,
+		Addresses: []string{address},
+	}, true
+}
+
+// awsIPPermission converts a single-address rule for the authorize API calls
+func awsIPPermission(rule FirewallRuleSpec) (awsec2types.IpPermission, error) {
+	permission := awsec2types.IpPermission{IpProtocol: aws.String(rule.Protocol)}
+	if rule.Protocol != "-1" {
+		from, to, err := parseFirewallPorts(rule.Ports)
+		if err != nil {
+			return permission, err
+		}
+		permission.FromPort = aws.Int32(int32(from))
+		permission.ToPort = aws.Int32(int32(to))
+	}
+	for _, address := range rule.Addresses {
+		if strings.Contains(address, ":") {
+			permission.Ipv6Ranges = append(permission.Ipv6Ranges, awsec2types.Ipv6Range{CidrIpv6: aws.String(address)})
+		} else {
+			permission.IpRanges = append(permission.IpRanges, awsec2types.IpRange{CidrIp: aws.String(address)})
+		}
+	}
+	return permission, nil
+}
+
+// ec2Client returns an EC2 API client for calls made outside Pulumi, using
+// the configured credentials and the node's region when known
+func (p *AWSProvider) ec2Client(ctx context.Context, region string) (*awsec2.Client, error) {
+	var optFns []func(*awsconfig.LoadOptions) error
+	if p.config != nil {
+		if region == "" {
+			region = p.config.Region
+		}
+		if p.config.AccessKeyID != "" {
+			optFns = append(optFns, awsconfig.WithCredentialsProvider(
+				credentials.NewStaticCredentialsProvider(p.config.AccessKeyID, p.config.SecretAccessKey, "")))
+		}
+	}
+	if region != "" {
+		optFns = append(optFns, awsconfig.WithRegion(region))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	return awsec2.NewFromConfig(awsCfg), nil
+}
+
+// findInstances returns the non-terminated instances tagged with the node name
+func findInstances(ctx context.Context, client *awsec2.Client, name string) ([]awsec2types.Instance, error) {
+	described, err := client.DescribeInstances(ctx, &awsec2.DescribeInstancesInput{
+		Filters: []awsec2types.Filter{
+			{Name: aws.String("tag:Name"), Values: []string{name}},
+			{Name: aws.String("instance-state-name"), Values: []string{"pending", "running", "stopping", "stopped"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up instance %s: %w", name, err)
+	}
+
+	var instances []awsec2types.Instance
+	for _, reservation := range described.Reservations {
+		instances = append(instances, reservation.Instances...)
+	}
+	return instances, nil
+}
+
+// ConfigureAPI sets the credentials the provider's API calls use, from the
+// config of a deployed stack
+func (p *AWSProvider) ConfigureAPI(cfg *config.ClusterConfig) {
+	p.clusterConfig = cfg
+	p.config = cfg.Providers.AWS
+}
+
 // Cleanup performs cleanup operations
 func (p *AWSProvider) Cleanup(ctx *pulumi.Context) error {
 	ctx.Log.Info("AWS cleanup completed", nil)
@@ -780,18 +780,18 @@ func TestAWSProvider_WireGuardIPAssignment(t *testing.T) {
 
 		// Create master pool
 		masterPool := &config.NodePool{
-			Name:  "masters",
-			Count: 3,
-			Roles: []string{"master"},
-			Size:  "t3.large",
+			Name:         "masters",
+			Count:        3,
+			Roles:        []string{"master"},
+			Size:         "t3.large",
+			WireGuardIPs: []string{"10.8.0.2", "10.8.0.3", "10.8.0.4"},
 		}
 		masterOutputs, err := provider.CreateNodePool(ctx, masterPool)
 		assert.NoError(t, err)
 
-		// Verify master WireGuard IPs (10.8.0.20, 10.8.0.21, 10.8.0.22)
+		// Verify master WireGuard IPs are the allocated ones
 		for i, output := range masterOutputs {
-			expectedIP := fmt.Sprintf("10.8.0.%d", 20+i)
-			assert.Equal(t, expectedIP, output.WireGuardIP)
+			assert.Equal(t, masterPool.WireGuardIPs[i], output.WireGuardIP)
 		}
 
 		// Clear nodes for worker pool test
@@ -799,18 +799,18 @@ func TestAWSProvider_WireGuardIPAssignment(t *testing.T) {
 
 		// Create worker pool
 		workerPool := &config.NodePool{
-			Name:  "workers",
-			Count: 5,
-			Roles: []string{"worker"},
-			Size:  "t3.medium",
+			Name:         "workers",
+			Count:        5,
+			Roles:        []string{"worker"},
+			Size:         "t3.medium",
+			WireGuardIPs: []string{"10.8.0.5", "10.8.0.6", "10.8.0.7", "10.8.0.8", "10.8.0.9"},
 		}
 		workerOutputs, err := provider.CreateNodePool(ctx, workerPool)
 		assert.NoError(t, err)
 
-		// Verify worker WireGuard IPs (10.8.0.30, 10.8.0.31, ...)
+		// Verify worker WireGuard IPs continue where the masters stopped
 		for i, output := range workerOutputs {
-			expectedIP := fmt.Sprintf("10.8.0.%d", 30+i)
-			assert.Equal(t, expectedIP, output.WireGuardIP)
+			assert.Equal(t, workerPool.WireGuardIPs[i], output.WireGuardIP)
 		}
 
 		return nil
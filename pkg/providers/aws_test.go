@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -421,6 +422,64 @@ func TestAWSProvider_CreateNode(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// awsSubnetRecordingMocks records the subnet each instance is created in
+type awsSubnetRecordingMocks struct {
+	mu      sync.Mutex
+	subnets map[string]string
+}
+
+func (m *awsSubnetRecordingMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	if args.TypeToken == "aws:ec2/instance:Instance" {
+		m.mu.Lock()
+		m.subnets[args.Name] = args.Inputs["subnetId"].StringValue()
+		m.mu.Unlock()
+	}
+	return awsMocks(0).NewResource(args)
+}
+
+func (m *awsSubnetRecordingMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return awsMocks(0).Call(args)
+}
+
+func TestAWSProvider_CreateNode_PlacesNodeInItsZone(t *testing.T) {
+	mocks := &awsSubnetRecordingMocks{subnets: map[string]string{}}
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := NewAWSProvider()
+		if err := provider.Initialize(ctx, &config.ClusterConfig{
+			Providers: config.ProvidersConfig{
+				AWS: &config.AWSProvider{Enabled: true, Region: "us-east-1", KeyPair: "existing-key"},
+			},
+		}); err != nil {
+			return err
+		}
+		if _, err := provider.CreateNetwork(ctx, &config.NetworkConfig{CIDR: "10.0.0.0/16"}); err != nil {
+			return err
+		}
+		if err := provider.CreateFirewall(ctx, &config.FirewallConfig{Name: "test"}, nil); err != nil {
+			return err
+		}
+		assert.Equal(t, []string{"us-east-1a", "us-east-1b"}, provider.Zones())
+
+		// Both nodes name the second zone; without a zone the node would
+		// take the first subnet, as the first node created
+		for _, name := range []string{"worker-1", "worker-2"} {
+			if _, err := provider.CreateNode(ctx, &config.NodeConfig{
+				Name: name, Roles: []string{"worker"}, Size: "t3.medium", Region: "us-east-1", Zone: "us-east-1b",
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	assert.NoError(t, err)
+
+	mocks.mu.Lock()
+	defer mocks.mu.Unlock()
+	assert.Equal(t, "stack-subnet-public-2_id", mocks.subnets["worker-1"])
+	assert.Equal(t, "stack-subnet-public-2_id", mocks.subnets["worker-2"])
+}
+
 func TestAWSProvider_CreateNode_NoSecurityGroup(t *testing.T) {
 	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
 		clusterConfig := &config.ClusterConfig{
@@ -551,12 +610,13 @@ func TestAWSProvider_CreateNodePool(t *testing.T) {
 
 		// Create node pool
 		pool := &config.NodePool{
-			Name:   "worker-pool",
-			Count:  3,
-			Roles:  []string{"worker"},
-			Size:   "t3.medium",
-			Image:  "ubuntu-22-04",
-			Labels: map[string]string{"pool": "workers"},
+			Name:         "worker-pool",
+			Count:        3,
+			Roles:        []string{"worker"},
+			Size:         "t3.medium",
+			Image:        "ubuntu-22-04",
+			Labels:       map[string]string{"pool": "workers"},
+			WireGuardIPs: []string{"10.8.0.4", "10.8.0.5", "10.8.0.6"},
 		}
 
 		outputs, err := provider.CreateNodePool(ctx, pool)
@@ -566,11 +626,9 @@ func TestAWSProvider_CreateNodePool(t *testing.T) {
 		for i, output := range outputs {
 			assert.Equal(t, "aws", output.Provider)
 			assert.Equal(t, "us-east-1", output.Region)
-			assert.Contains(t, output.Name, "worker-pool")
-			// Check WireGuard IPs are assigned
-			assert.NotEmpty(t, output.WireGuardIP)
-			assert.Contains(t, output.WireGuardIP, "10.8.0.3")
-			_ = i
+			assert.Equal(t, pool.NodeName(i), output.Name)
+			// Each node gets the WireGuard IP allocated for it
+			assert.Equal(t, pool.WireGuardIPs[i], output.WireGuardIP)
 		}
 
 		return nil
@@ -628,10 +686,9 @@ func TestAWSProvider_CreateNodePool_Masters(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Len(t, outputs, 3)
 
-		// Check master WireGuard IPs (10.8.0.20, 10.8.0.21, 10.8.0.22)
-		for i, output := range outputs {
-			assert.Contains(t, output.WireGuardIP, "10.8.0.2")
-			_ = i
+		// Without allocated addresses the provider picks none of its own
+		for _, output := range outputs {
+			assert.Empty(t, output.WireGuardIP)
 		}
 
 		return nil
@@ -706,6 +763,56 @@ func TestAWSProvider_CreateLoadBalancer(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAWSProvider_CreateLoadBalancer_Multiple(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		clusterConfig := &config.ClusterConfig{
+			Providers: config.ProvidersConfig{
+				AWS: &config.AWSProvider{
+					Enabled: true,
+					Region:  "us-east-1",
+					KeyPair: "existing-key",
+					VPC:     &config.VPCConfig{Create: true, CIDR: "10.0.0.0/16"},
+				},
+			},
+			Security: config.SecurityConfig{SSHConfig: config.SSHConfig{KeyPath: "/path/to/key"}},
+		}
+
+		provider := NewAWSProvider()
+		assert.NoError(t, provider.Initialize(ctx, clusterConfig))
+		_, err := provider.CreateNetwork(ctx, &config.NetworkConfig{Mode: "vpc", CIDR: "10.0.0.0/16"})
+		assert.NoError(t, err)
+		assert.NoError(t, provider.CreateFirewall(ctx, &config.FirewallConfig{Name: "test"}, nil))
+
+		for _, node := range []*config.NodeConfig{
+			{Name: "master-1", Size: "t3.medium", WireGuardIP: "10.8.0.10", Labels: map[string]string{"role": "master"}},
+			{Name: "worker-1", Size: "t3.medium", WireGuardIP: "10.8.0.20", Labels: map[string]string{"role": "worker"}},
+		} {
+			_, err := provider.CreateNode(ctx, node)
+			assert.NoError(t, err)
+		}
+
+		api, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{Name: "api", TargetNodes: []string{"master-1"}})
+		assert.NoError(t, err)
+		assert.NotNil(t, api)
+		assert.Equal(t, 1, api.Backends)
+
+		// A second load balancer must not reuse the first one's resource names
+		ingress, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{
+			Name:        "ingress",
+			Ports:       []config.PortConfig{{Port: 80, TargetPort: 30080}, {Port: 443, TargetPort: 30443}},
+			TargetNodes: []string{"worker-1"},
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, ingress)
+		assert.Equal(t, 1, ingress.Backends)
+		assert.Equal(t, 2, provider.loadBalancers)
+
+		return nil
+	}, pulumi.WithMocks("project", "stack", awsMocks(0)))
+
+	assert.NoError(t, err)
+}
+
 func TestAWSProvider_CreateLoadBalancer_NoVPC(t *testing.T) {
 	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
 		clusterConfig := &config.ClusterConfig{
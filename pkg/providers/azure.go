@@ -1,14 +1,17 @@
 package providers
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
 	azurecompute "github.com/pulumi/pulumi-azure-native-sdk/compute/v2"
 	azurenetwork "github.com/pulumi/pulumi-azure-native-sdk/network/v2"
 	azureresources "github.com/pulumi/pulumi-azure-native-sdk/resources/v2"
+	azurenative "github.com/pulumi/pulumi-azure-native-sdk/v2"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
 
@@ -22,6 +25,9 @@ type AzureProvider struct {
 	nodes           []*NodeOutput
 	ctx             *pulumi.Context
 	resourceGroupID pulumi.IDOutput
+	loadBalancers   []config.LoadBalancerConfig // Azure load balancers, whose ports the NSG opens
+	scope           string                      // credentials scope; empty for the default instance
+	provider        pulumi.ProviderResource     // explicit provider of a scoped instance
 }
 
 // NewAzureProvider creates a new Azure provider
@@ -36,6 +42,11 @@ func (p *AzureProvider) GetName() string {
 	return "azure"
 }
 
+// SetScope makes the instance create its resources with its own credentials
+func (p *AzureProvider) SetScope(scope string) {
+	p.scope = scope
+}
+
 // Initialize initializes the Azure provider
 func (p *AzureProvider) Initialize(ctx *pulumi.Context, config *config.ClusterConfig) error {
 	p.ctx = ctx
@@ -45,6 +56,33 @@ func (p *AzureProvider) Initialize(ctx *pulumi.Context, config *config.ClusterCo
 	}
 
 	p.config = config.Providers.Azure
+	if p.scope != "" {
+		// Settings left empty fall back to the ARM_* environment variables
+		args := &azurenative.ProviderArgs{}
+		if p.config.SubscriptionID != "" {
+			args.SubscriptionId = pulumi.StringPtr(p.config.SubscriptionID)
+		}
+		if p.config.TenantID != "" {
+			args.TenantId = pulumi.StringPtr(p.config.TenantID)
+		}
+		if p.config.ClientID != "" {
+			args.ClientId = pulumi.StringPtr(p.config.ClientID)
+		}
+		if p.config.ClientSecret != "" {
+			args.ClientSecret = pulumi.StringPtr(p.config.ClientSecret)
+		}
+		provider, err := azurenative.NewProvider(ctx, scopedName("azure", p.scope), args)
+		if err != nil {
+			return fmt.Errorf("failed to create Azure provider: %w", err)
+		}
+		p.provider = provider
+	}
+
+	for _, lb := range config.AllLoadBalancers() {
+		if strings.EqualFold(lb.Provider, p.GetName()) {
+			p.loadBalancers = append(p.loadBalancers, lb)
+		}
+	}
 
 	ctx.Log.Info("Azure provider initialized", nil)
 	return nil
@@ -86,7 +124,7 @@ func (p *AzureProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig)
 			"ManagedBy":   pulumi.String("sloth-kubernetes"),
 			"Name":        pulumi.String(node.Name),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create public IP %s: %w", publicIPName, err)
 	}
@@ -117,7 +155,7 @@ func (p *AzureProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig)
 			"ManagedBy":   pulumi.String("sloth-kubernetes"),
 			"Name":        pulumi.String(node.Name),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create network interface %s: %w", nicName, err)
 	}
@@ -195,7 +233,7 @@ func (p *AzureProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig)
 		Tags: tags,
 	}
 
-	vm, err := azurecompute.NewVirtualMachine(ctx, node.Name, vmArgs)
+	vm, err := azurecompute.NewVirtualMachine(ctx, node.Name, vmArgs, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create VM %s: %w", node.Name, err)
 	}
@@ -211,8 +249,9 @@ func (p *AzureProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig)
 		Size:        vmSize,
 		Status:      pulumi.String("active").ToStringOutput(),
 		Labels:      node.Labels,
+		Taints:      node.Taints,
 		WireGuardIP: node.WireGuardIP,
-		SSHUser:     "azureuser",
+		SSHUser:     config.SSHUserFor("azure", node.SSHUser),
 		SSHKeyPath:  "~/.ssh/id_rsa",
 	}
 
@@ -266,10 +305,10 @@ func (p *AzureProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePoo
 	outputs := make([]*NodeOutput, 0, pool.Count)
 
 	for i := 0; i < pool.Count; i++ {
-		nodeName := fmt.Sprintf("%s-%d", pool.Name, i+1)
+		nodeName := pool.NodeName(i)
 
 		// Determine zone/region
-		region := pool.Region
+		region := pool.RegionFor(i)
 		if len(pool.Zones) > 0 {
 			// Distribute across zones
 			region = pool.Zones[i%len(pool.Zones)]
@@ -277,26 +316,19 @@ func (p *AzureProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePoo
 
 		// Create node config from pool
 		nodeConfig := &config.NodeConfig{
-			Name:       nodeName,
-			Provider:   pool.Provider,
-			Pool:       pool.Name,
-			Roles:      pool.Roles,
-			Size:       pool.Size,
-			Image:      pool.Image,
-			Region:     region,
-			Labels:     pool.Labels,
-			Taints:     pool.Taints,
-			UserData:   pool.UserData,
-			Monitoring: true,
-		}
-
-		// Set WireGuard IP based on role and index
-		// Azure Master: 10.8.0.20
-		// Azure Workers: 10.8.0.21, 10.8.0.22
-		if contains(pool.Roles, "controlplane") || contains(pool.Roles, "master") {
-			nodeConfig.WireGuardIP = "10.8.0.20"
-		} else if contains(pool.Roles, "worker") {
-			nodeConfig.WireGuardIP = fmt.Sprintf("10.8.0.%d", 21+i)
+			Name:        nodeName,
+			Provider:    pool.Provider,
+			Pool:        pool.Name,
+			Roles:       pool.Roles,
+			Size:        pool.Size,
+			Image:       pool.Image,
+			Region:      region,
+			Labels:      pool.Labels,
+			Taints:      pool.Taints,
+			UserData:    pool.UserData,
+			SSHUser:     pool.SSHUser,
+			Monitoring:  true,
+			WireGuardIP: pool.WireGuardIPFor(i),
 		}
 
 		output, err := p.CreateNode(ctx, nodeConfig)
@@ -319,6 +351,7 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 	if resourceGroupName == "" {
 		resourceGroupName = fmt.Sprintf("%s-rg", ctx.Stack())
 	}
+	resourceGroupName = scopedName(resourceGroupName, p.scope)
 
 	rg, err := azureresources.NewResourceGroup(ctx, resourceGroupName, &azureresources.ResourceGroupArgs{
 		ResourceGroupName: pulumi.String(resourceGroupName),
@@ -328,7 +361,7 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 			"ManagedBy":   pulumi.String("sloth-kubernetes"),
 			"Cluster":     pulumi.String(ctx.Stack()),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource group: %w", err)
 	}
@@ -351,10 +384,11 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 	}
 
 	// Create Virtual Network
-	vnet, err := azurenetwork.NewVirtualNetwork(ctx, vnetConfig.Name, &azurenetwork.VirtualNetworkArgs{
+	vnetName := scopedName(vnetConfig.Name, p.scope)
+	vnet, err := azurenetwork.NewVirtualNetwork(ctx, vnetName, &azurenetwork.VirtualNetworkArgs{
 		ResourceGroupName:  rg.Name,
 		Location:           pulumi.String(location),
-		VirtualNetworkName: pulumi.String(vnetConfig.Name),
+		VirtualNetworkName: pulumi.String(vnetName),
 		AddressSpace: &azurenetwork.AddressSpaceArgs{
 			AddressPrefixes: pulumi.StringArray{
 				pulumi.String(vnetConfig.CIDR),
@@ -364,7 +398,7 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 			"Environment": pulumi.String("production"),
 			"ManagedBy":   pulumi.String("sloth-kubernetes"),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create virtual network: %w", err)
 	}
@@ -372,7 +406,7 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 	p.virtualNetwork = vnet
 
 	// Create Subnet (use first /24 from VNet CIDR)
-	subnetName := fmt.Sprintf("%s-subnet", ctx.Stack())
+	subnetName := scopedName(fmt.Sprintf("%s-subnet", ctx.Stack()), p.scope)
 	subnetCIDR := calculateSubnetCIDR(vnetConfig.CIDR)
 
 	subnet, err := azurenetwork.NewSubnet(ctx, subnetName, &azurenetwork.SubnetArgs{
@@ -380,7 +414,7 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 		VirtualNetworkName: vnet.Name,
 		SubnetName:         pulumi.String(subnetName),
 		AddressPrefix:      pulumi.String(subnetCIDR),
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create subnet: %w", err)
 	}
@@ -388,12 +422,12 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 	p.subnet = subnet
 
 	// Create Network Security Group
-	nsgName := fmt.Sprintf("%s-nsg", ctx.Stack())
+	nsgName := scopedName(fmt.Sprintf("%s-nsg", ctx.Stack()), p.scope)
 	nsg, err := azurenetwork.NewNetworkSecurityGroup(ctx, nsgName, &azurenetwork.NetworkSecurityGroupArgs{
 		ResourceGroupName:        rg.Name,
 		Location:                 pulumi.String(location),
 		NetworkSecurityGroupName: pulumi.String(nsgName),
-		SecurityRules: azurenetwork.SecurityRuleTypeArray{
+		SecurityRules: append(azurenetwork.SecurityRuleTypeArray{
 			// SSH from anywhere (restrict in production!)
 			&azurenetwork.SecurityRuleTypeArgs{
 				Name:                     pulumi.String("allow-ssh"),
@@ -442,12 +476,12 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 				SourceAddressPrefix:      pulumi.String(vnetConfig.CIDR),
 				DestinationAddressPrefix: pulumi.String("*"),
 			},
-		},
+		}, p.loadBalancerSecurityRules()...),
 		Tags: pulumi.StringMap{
 			"Environment": pulumi.String("production"),
 			"ManagedBy":   pulumi.String("sloth-kubernetes"),
 		},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create network security group: %w", err)
 	}
@@ -457,18 +491,18 @@ func (p *AzureProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netwo
 	// Create output
 	output := &NetworkOutput{
 		ID:     vnet.ID(),
-		Name:   vnetConfig.Name,
+		Name:   vnetName,
 		CIDR:   vnetConfig.CIDR,
 		Region: location,
 	}
 
 	// Export network info
-	secrets.Export(ctx, "azure_resource_group_id", rg.ID())
-	secrets.Export(ctx, "azure_resource_group_name", rg.Name)
-	secrets.Export(ctx, "azure_vnet_id", vnet.ID())
-	secrets.Export(ctx, "azure_vnet_name", vnet.Name)
-	secrets.Export(ctx, "azure_subnet_id", subnet.ID())
-	secrets.Export(ctx, "azure_nsg_id", nsg.ID())
+	secrets.Export(ctx, scopedName("azure_resource_group_id", p.scope), rg.ID())
+	secrets.Export(ctx, scopedName("azure_resource_group_name", p.scope), rg.Name)
+	secrets.Export(ctx, scopedName("azure_vnet_id", p.scope), vnet.ID())
+	secrets.Export(ctx, scopedName("azure_vnet_name", p.scope), vnet.Name)
+	secrets.Export(ctx, scopedName("azure_subnet_id", p.scope), subnet.ID())
+	secrets.Export(ctx, scopedName("azure_nsg_id", p.scope), nsg.ID())
 
 	return output, nil
 }
@@ -503,11 +537,187 @@ func (p *AzureProvider) CreateFirewall(ctx *pulumi.Context, firewall *config.Fir
 	return nil
 }
 
-// CreateLoadBalancer creates an Azure Load Balancer
+// CreateLoadBalancer creates a Standard Load Balancer in the cluster's
+// resource group. An external one is fronted by a static public IP, an
+// internal one by a private IP in the cluster subnet. Each port gets a rule
+// and a health probe, and the target nodes join the backend pool by their
+// private IPs.
 func (p *AzureProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.LoadBalancerConfig) (*LoadBalancerOutput, error) {
-	// Azure Load Balancer implementation
-	// This is a placeholder - implement when needed
-	return nil, fmt.Errorf("Azure Load Balancer not yet implemented")
+	if p.resourceGroup == nil || p.virtualNetwork == nil || p.subnet == nil {
+		return nil, fmt.Errorf("network not created - call CreateNetwork first")
+	}
+
+	lbName := p.loadBalancerName(lb)
+	location := p.config.Location
+	tags := pulumi.StringMap{
+		"Environment": pulumi.String("production"),
+		"ManagedBy":   pulumi.String("sloth-kubernetes"),
+		"Cluster":     pulumi.String(ctx.Stack()),
+		"Name":        pulumi.String(lbName),
+	}
+
+	// Rules refer to the frontend, pool and probes by ID, which Azure derives
+	// from the subscription, resource group and load balancer name
+	lbID := pulumi.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/loadBalancers/%s",
+		p.config.SubscriptionID, p.resourceGroup.Name, lbName)
+	subResource := func(kind, name string) *azurenetwork.SubResourceArgs {
+		return &azurenetwork.SubResourceArgs{Id: pulumi.Sprintf("%s/%s/%s", lbID, kind, name)}
+	}
+
+	frontend := &azurenetwork.FrontendIPConfigurationArgs{Name: pulumi.String("frontend")}
+	var publicIP *azurenetwork.PublicIPAddress
+	if lb.IsInternal() {
+		frontend.PrivateIPAllocationMethod = pulumi.String("Dynamic")
+		frontend.Subnet = &azurenetwork.SubnetTypeArgs{Id: p.subnet.ID()}
+	} else {
+		publicIPName := fmt.Sprintf("%s-pip", lbName)
+		var err error
+		publicIP, err = azurenetwork.NewPublicIPAddress(ctx, publicIPName, &azurenetwork.PublicIPAddressArgs{
+			ResourceGroupName:        p.resourceGroup.Name,
+			Location:                 pulumi.String(location),
+			PublicIpAddressName:      pulumi.String(publicIPName),
+			PublicIPAllocationMethod: pulumi.String("Static"),
+			Sku: &azurenetwork.PublicIPAddressSkuArgs{
+				Name: pulumi.String("Standard"),
+			},
+			Tags: tags,
+		}, withProvider(p.provider)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create public IP %s: %w", publicIPName, err)
+		}
+		frontend.PublicIPAddress = &azurenetwork.PublicIPAddressTypeArgs{Id: publicIP.ID()}
+	}
+
+	backends := azurenetwork.LoadBalancerBackendAddressArray{}
+	for _, node := range p.nodes {
+		if !targetsNode(lb, node.Name) {
+			continue
+		}
+		backends = append(backends, &azurenetwork.LoadBalancerBackendAddressArgs{
+			Name:           pulumi.String(node.Name),
+			IpAddress:      node.PrivateIP,
+			VirtualNetwork: &azurenetwork.SubResourceArgs{Id: p.virtualNetwork.ID()},
+		})
+	}
+
+	var probes azurenetwork.ProbeArray
+	var rules azurenetwork.LoadBalancingRuleArray
+	for _, port := range loadBalancerPorts(lb) {
+		protocol := azureLoadBalancerProtocol(port)
+		name := fmt.Sprintf("%s-%d", strings.ToLower(protocol), port.Port)
+
+		// Azure probes backends over TCP or HTTP only, so UDP ports are
+		// checked with a TCP connection to the same port
+		probe := &azurenetwork.ProbeArgs{
+			Name:              pulumi.String(name),
+			Protocol:          pulumi.String("Tcp"),
+			Port:              pulumi.Int(backendPort(port)),
+			IntervalInSeconds: pulumi.Int(15),
+			NumberOfProbes:    pulumi.Int(2),
+		}
+		if healthCheckProtocol(port) == "http" {
+			probe.Protocol = pulumi.String("Http")
+			probe.RequestPath = pulumi.String(healthCheckPath)
+		}
+		probes = append(probes, probe)
+
+		rules = append(rules, &azurenetwork.LoadBalancingRuleArgs{
+			Name:                    pulumi.String(name),
+			Protocol:                pulumi.String(protocol),
+			FrontendPort:            pulumi.Int(port.Port),
+			BackendPort:             pulumi.Int(backendPort(port)),
+			FrontendIPConfiguration: subResource("frontendIPConfigurations", "frontend"),
+			BackendAddressPool:      subResource("backendAddressPools", "backend"),
+			Probe:                   subResource("probes", name),
+			IdleTimeoutInMinutes:    pulumi.Int(4),
+		})
+	}
+
+	loadBalancer, err := azurenetwork.NewLoadBalancer(ctx, lbName, &azurenetwork.LoadBalancerArgs{
+		ResourceGroupName: p.resourceGroup.Name,
+		Location:          pulumi.String(location),
+		LoadBalancerName:  pulumi.String(lbName),
+		Sku: &azurenetwork.LoadBalancerSkuArgs{
+			Name: pulumi.String("Standard"),
+			Tier: pulumi.String("Regional"),
+		},
+		FrontendIPConfigurations: azurenetwork.FrontendIPConfigurationArray{frontend},
+		BackendAddressPools: azurenetwork.BackendAddressPoolArray{
+			&azurenetwork.BackendAddressPoolArgs{
+				Name:                         pulumi.String("backend"),
+				LoadBalancerBackendAddresses: backends,
+			},
+		},
+		Probes:             probes,
+		LoadBalancingRules: rules,
+		Tags:               tags,
+	}, withProvider(p.provider)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create load balancer %s: %w", lbName, err)
+	}
+
+	ip := loadBalancer.FrontendIPConfigurations.Index(pulumi.Int(0)).PrivateIPAddress().Elem()
+	if publicIP != nil {
+		ip = publicIP.IpAddress.Elem()
+	}
+
+	output := &LoadBalancerOutput{
+		ID:       loadBalancer.ID(),
+		IP:       ip,
+		Hostname: ip, // Azure load balancers don't have hostnames
+		Status:   loadBalancer.ProvisioningState,
+		Backends: len(backends),
+	}
+
+	secrets.Export(ctx, fmt.Sprintf("%s_ip", lbName), ip)
+	secrets.Export(ctx, fmt.Sprintf("%s_id", lbName), loadBalancer.ID())
+
+	return output, nil
+}
+
+// loadBalancerSecurityRules opens the backend ports of the external load
+// balancers to the internet; Standard Load Balancers only deliver traffic
+// the NSG of the backends admits. Internal ones are covered by the rule
+// allowing the virtual network.
+func (p *AzureProvider) loadBalancerSecurityRules() azurenetwork.SecurityRuleTypeArray {
+	var rules azurenetwork.SecurityRuleTypeArray
+	for _, lb := range p.loadBalancers {
+		if lb.IsInternal() {
+			continue
+		}
+		for _, port := range loadBalancerPorts(&lb) {
+			rules = append(rules, &azurenetwork.SecurityRuleTypeArgs{
+				Name:                     pulumi.String(fmt.Sprintf("allow-%s-%d", p.loadBalancerName(&lb), port.Port)),
+				Priority:                 pulumi.Int(300 + len(rules)),
+				Direction:                pulumi.String("Inbound"),
+				Access:                   pulumi.String("Allow"),
+				Protocol:                 pulumi.String(azureLoadBalancerProtocol(port)),
+				SourcePortRange:          pulumi.String("*"),
+				DestinationPortRange:     pulumi.String(fmt.Sprint(backendPort(port))),
+				SourceAddressPrefix:      pulumi.String("Internet"),
+				DestinationAddressPrefix: pulumi.String("*"),
+			})
+		}
+	}
+	return rules
+}
+
+// loadBalancerName returns the configured name of a load balancer, or one
+// derived from the stack when it has none
+func (p *AzureProvider) loadBalancerName(lb *config.LoadBalancerConfig) string {
+	if lb.Name != "" {
+		return lb.Name
+	}
+	return fmt.Sprintf("%s-lb", p.ctx.Stack())
+}
+
+// azureLoadBalancerProtocol returns the transport protocol an Azure load
+// balancer rule forwards a port with; HTTP ports are forwarded as TCP
+func azureLoadBalancerProtocol(port config.PortConfig) string {
+	if strings.EqualFold(port.Protocol, "udp") {
+		return "Udp"
+	}
+	return "Tcp"
 }
 
 // GetRegions returns available Azure regions
@@ -537,9 +747,34 @@ func (p *AzureProvider) GetSizes() []string {
 	}
 }
 
+// GetPriceForSize returns the monthly list price in USD of a Azure VM of the given size
+func (p *AzureProvider) GetPriceForSize(size, region string) (float64, error) {
+	return monthlyPrice(p.GetName(), azureMonthlyPrices, size)
+}
+
+// ResizeNode is not supported on Azure yet; change the pool size and redeploy
+func (p *AzureProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	if err := ValidateNodeSize(p, newSize); err != nil {
+		return err
+	}
+	return fmt.Errorf("resizing nodes in place is not supported on Azure yet")
+}
+
+// DestroyNode is not supported on Azure yet; remove the node with 'pulumi destroy'
+func (p *AzureProvider) DestroyNode(ctx context.Context, node *NodeOutput) error {
+	return fmt.Errorf("destroying nodes is not supported on Azure yet")
+}
+
+// RebootNode is not supported on Azure yet
+func (p *AzureProvider) RebootNode(ctx context.Context, node *NodeOutput) error {
+	return fmt.Errorf("rebooting nodes is not supported on Azure yet")
+}
+
 // Cleanup performs cleanup operations
 func (p *AzureProvider) Cleanup(ctx *pulumi.Context) error {
-	// Cleanup is handled by Pulumi's resource management
+	// Cleanup is handled by Pulumi's resource management. Load balancers
+	// reference their public IP, so a destroy removes the load balancer
+	// before the address.
 	return nil
 }
 
@@ -2,6 +2,7 @@ package providers
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -17,35 +18,35 @@ func (azureMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.Pr
 	outputs := args.Inputs.Copy()
 
 	switch args.TypeToken {
-	case "azure-native:resources/v2:ResourceGroup":
+	case "azure-native:resources:ResourceGroup":
 		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/" + args.Name)
 		outputs["name"] = args.Inputs["resourceGroupName"]
 		outputs["location"] = args.Inputs["location"]
 		outputs["type"] = resource.NewStringProperty("Microsoft.Resources/resourceGroups")
 
-	case "azure-native:network/v2:VirtualNetwork":
+	case "azure-native:network:VirtualNetwork":
 		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/" + args.Name)
 		outputs["name"] = args.Inputs["virtualNetworkName"]
 		outputs["location"] = args.Inputs["location"]
 		outputs["addressSpace"] = args.Inputs["addressSpace"]
 
-	case "azure-native:network/v2:Subnet":
+	case "azure-native:network:Subnet":
 		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/" + args.Name)
 		outputs["name"] = args.Inputs["subnetName"]
 		outputs["addressPrefix"] = args.Inputs["addressPrefix"]
 
-	case "azure-native:network/v2:NetworkSecurityGroup":
+	case "azure-native:network:NetworkSecurityGroup":
 		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/" + args.Name)
 		outputs["name"] = args.Inputs["networkSecurityGroupName"]
 		outputs["location"] = args.Inputs["location"]
 
-	case "azure-native:network/v2:PublicIPAddress":
+	case "azure-native:network:PublicIPAddress":
 		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/" + args.Name)
 		outputs["name"] = args.Inputs["publicIpAddressName"]
 		outputs["ipAddress"] = resource.NewStringProperty("20.40.60.80")
 		outputs["location"] = args.Inputs["location"]
 
-	case "azure-native:network/v2:NetworkInterface":
+	case "azure-native:network:NetworkInterface":
 		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/" + args.Name)
 		outputs["name"] = args.Inputs["networkInterfaceName"]
 		outputs["location"] = args.Inputs["location"]
@@ -55,7 +56,17 @@ func (azureMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.Pr
 			}),
 		})
 
-	case "azure-native:compute/v2:VirtualMachine":
+	case "azure-native:network:LoadBalancer":
+		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/" + args.Name)
+		outputs["provisioningState"] = resource.NewStringProperty("Succeeded")
+		outputs["frontendIPConfigurations"] = resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewObjectProperty(resource.PropertyMap{
+				"name":             resource.NewStringProperty("frontend"),
+				"privateIPAddress": resource.NewStringProperty("10.0.1.250"),
+			}),
+		})
+
+	case "azure-native:compute:VirtualMachine":
 		outputs["id"] = resource.NewStringProperty("/subscriptions/sub-123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/" + args.Name)
 		outputs["name"] = args.Inputs["vmName"]
 		outputs["location"] = args.Inputs["location"]
@@ -546,3 +557,187 @@ func TestAzureProvider_CustomVirtualNetworkConfig(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+// azureRecordingMocks records the resources created through azureMocks
+type azureRecordingMocks struct {
+	mu        sync.Mutex
+	resources map[string][]resource.PropertyMap
+}
+
+func (m *azureRecordingMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	m.mu.Lock()
+	if m.resources == nil {
+		m.resources = map[string][]resource.PropertyMap{}
+	}
+	m.resources[args.TypeToken] = append(m.resources[args.TypeToken], args.Inputs)
+	m.mu.Unlock()
+	return azureMocks(0).NewResource(args)
+}
+
+func (m *azureRecordingMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return azureMocks(0).Call(args)
+}
+
+func (m *azureRecordingMocks) created(typeToken string) []resource.PropertyMap {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.resources[typeToken]
+}
+
+// newAzureLoadBalancerProvider returns a provider with its network, a master
+// and a worker created
+func newAzureLoadBalancerProvider(t *testing.T, ctx *pulumi.Context, lbs ...config.LoadBalancerConfig) *AzureProvider {
+	provider := NewAzureProvider()
+	assert.NoError(t, provider.Initialize(ctx, &config.ClusterConfig{
+		Providers: config.ProvidersConfig{
+			Azure: &config.AzureProvider{
+				Enabled:        true,
+				SubscriptionID: "sub-123",
+				ResourceGroup:  "test-rg",
+				Location:       "eastus",
+				VirtualNetwork: &config.AzureVirtualNetwork{Name: "test-vnet", CIDR: "10.0.0.0/16"},
+			},
+		},
+		LoadBalancers: lbs,
+	}))
+	_, err := provider.CreateNetwork(ctx, &config.NetworkConfig{Mode: "vpc", CIDR: "10.0.0.0/16"})
+	assert.NoError(t, err)
+	for _, node := range []config.NodeConfig{
+		{Name: "master-1", Provider: "azure", Size: "Standard_B2s", Roles: []string{"master"}},
+		{Name: "worker-1", Provider: "azure", Size: "Standard_B2s", Roles: []string{"worker"}},
+	} {
+		_, err := provider.CreateNode(ctx, &node)
+		assert.NoError(t, err)
+	}
+	return provider
+}
+
+func TestAzureProvider_CreateLoadBalancer(t *testing.T) {
+	ingress := config.LoadBalancerConfig{
+		Name:     "ingress",
+		Provider: "azure",
+		Ports:    []config.PortConfig{{Port: 80, TargetPort: 30080, Protocol: "http"}, {Port: 443, TargetPort: 30443, Protocol: "tcp"}},
+	}
+	mocks := &azureRecordingMocks{}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := newAzureLoadBalancerProvider(t, ctx, ingress)
+
+		lb := ingress
+		lb.TargetNodes = []string{"worker-1"}
+		output, err := provider.CreateLoadBalancer(ctx, &lb)
+		assert.NoError(t, err)
+		if assert.NotNil(t, output) {
+			assert.Equal(t, 1, output.Backends)
+
+			ip := make(chan string, 1)
+			output.IP.ApplyT(func(v string) string { ip <- v; return v })
+			assert.Equal(t, "20.40.60.80", <-ip, "the frontend public IP")
+		}
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	assert.NoError(t, err)
+
+	var publicIPs []string
+	for _, pip := range mocks.created("azure-native:network:PublicIPAddress") {
+		publicIPs = append(publicIPs, pip["publicIpAddressName"].StringValue())
+	}
+	assert.Contains(t, publicIPs, "ingress-pip")
+
+	lbs := mocks.created("azure-native:network:LoadBalancer")
+	if !assert.Len(t, lbs, 1) {
+		return
+	}
+	args := lbs[0]
+	assert.Equal(t, "Standard", args["sku"].ObjectValue()["name"].StringValue())
+	assert.Equal(t, "eastus", args["location"].StringValue())
+
+	frontend := args["frontendIPConfigurations"].ArrayValue()[0].ObjectValue()
+	assert.True(t, frontend.HasValue("publicIPAddress"))
+	assert.False(t, frontend.HasValue("subnet"))
+
+	pool := args["backendAddressPools"].ArrayValue()[0].ObjectValue()
+	backends := pool["loadBalancerBackendAddresses"].ArrayValue()
+	if assert.Len(t, backends, 1) {
+		assert.Equal(t, "worker-1", backends[0].ObjectValue()["name"].StringValue())
+		assert.Equal(t, "10.0.1.10", backends[0].ObjectValue()["ipAddress"].StringValue())
+	}
+
+	probes := args["probes"].ArrayValue()
+	if assert.Len(t, probes, 2) {
+		assert.Equal(t, "Http", probes[0].ObjectValue()["protocol"].StringValue())
+		assert.Equal(t, float64(30080), probes[0].ObjectValue()["port"].NumberValue())
+		assert.Equal(t, "Tcp", probes[1].ObjectValue()["protocol"].StringValue())
+	}
+
+	rules := args["loadBalancingRules"].ArrayValue()
+	if assert.Len(t, rules, 2) {
+		rule := rules[1].ObjectValue()
+		assert.Equal(t, float64(443), rule["frontendPort"].NumberValue())
+		assert.Equal(t, float64(30443), rule["backendPort"].NumberValue())
+		assert.Equal(t, "/subscriptions/sub-123/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/ingress/probes/tcp-443",
+			rule["probe"].ObjectValue()["id"].StringValue())
+	}
+
+	nsgs := mocks.created("azure-native:network:NetworkSecurityGroup")
+	if assert.Len(t, nsgs, 1) {
+		var opened []string
+		for _, rule := range nsgs[0]["securityRules"].ArrayValue() {
+			if rule.ObjectValue()["name"].StringValue() == "allow-ingress-80" || rule.ObjectValue()["name"].StringValue() == "allow-ingress-443" {
+				opened = append(opened, rule.ObjectValue()["destinationPortRange"].StringValue())
+			}
+		}
+		assert.Equal(t, []string{"30080", "30443"}, opened, "the NSG admits the backend ports")
+	}
+}
+
+func TestAzureProvider_CreateLoadBalancer_Internal(t *testing.T) {
+	api := config.LoadBalancerConfig{Name: "api", Provider: "azure", Type: "internal"}
+	mocks := &azureRecordingMocks{}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := newAzureLoadBalancerProvider(t, ctx, api)
+
+		output, err := provider.CreateLoadBalancer(ctx, &api)
+		assert.NoError(t, err)
+		if assert.NotNil(t, output) {
+			assert.Equal(t, 2, output.Backends, "no target nodes means every node")
+
+			ip := make(chan string, 1)
+			output.IP.ApplyT(func(v string) string { ip <- v; return v })
+			assert.Equal(t, "10.0.1.250", <-ip, "the frontend private IP")
+		}
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	assert.NoError(t, err)
+
+	for _, pip := range mocks.created("azure-native:network:PublicIPAddress") {
+		assert.NotEqual(t, "api-pip", pip["publicIpAddressName"].StringValue(), "internal load balancers have no public IP")
+	}
+
+	lbs := mocks.created("azure-native:network:LoadBalancer")
+	if assert.Len(t, lbs, 1) {
+		frontend := lbs[0]["frontendIPConfigurations"].ArrayValue()[0].ObjectValue()
+		assert.True(t, frontend.HasValue("subnet"))
+		assert.False(t, frontend.HasValue("publicIPAddress"))
+		assert.Equal(t, float64(6443), lbs[0]["loadBalancingRules"].ArrayValue()[0].ObjectValue()["frontendPort"].NumberValue())
+	}
+
+	nsgs := mocks.created("azure-native:network:NetworkSecurityGroup")
+	if assert.Len(t, nsgs, 1) {
+		assert.Len(t, nsgs[0]["securityRules"].ArrayValue(), 4, "the virtual network rule covers internal load balancers")
+	}
+}
+
+func TestAzureProvider_CreateLoadBalancerWithoutNetwork(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := NewAzureProvider()
+		assert.NoError(t, provider.Initialize(ctx, &config.ClusterConfig{
+			Providers: config.ProvidersConfig{Azure: &config.AzureProvider{Enabled: true, Location: "eastus"}},
+		}))
+
+		output, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{Name: "api"})
+		assert.Nil(t, output)
+		assert.ErrorContains(t, err, "call CreateNetwork first")
+		return nil
+	}, pulumi.WithMocks("project", "stack", azureMocks(0)))
+	assert.NoError(t, err)
+}
@@ -1,11 +1,18 @@
 package providers
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
+	"github.com/digitalocean/godo"
 	"github.com/pulumi/pulumi-digitalocean/sdk/v4/go/digitalocean"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -18,6 +25,9 @@ type DigitalOceanProvider struct {
 	sshKeys  pulumi.StringArray
 	nodes    []*NodeOutput
 	ctx      *pulumi.Context
+	scope    string                  // credentials scope; empty for the default instance
+	provider pulumi.ProviderResource // explicit provider of a scoped instance
+	apiURL   string                  // API endpoint override; empty for the public API
 }
 
 // NewDigitalOceanProvider creates a new DigitalOcean provider
@@ -32,6 +42,11 @@ func (p *DigitalOceanProvider) GetName() string {
 	return "digitalocean"
 }
 
+// SetScope makes the instance create its resources with its own credentials
+func (p *DigitalOceanProvider) SetScope(scope string) {
+	p.scope = scope
+}
+
 // Initialize initializes the DigitalOcean provider
 func (p *DigitalOceanProvider) Initialize(ctx *pulumi.Context, config *config.ClusterConfig) error {
 	p.ctx = ctx
@@ -42,6 +57,16 @@ func (p *DigitalOceanProvider) Initialize(ctx *pulumi.Context, config *config.Cl
 
 	p.config = config.Providers.DigitalOcean
 
+	if p.scope != "" {
+		provider, err := digitalocean.NewProvider(ctx, scopedName("digitalocean", p.scope), &digitalocean.ProviderArgs{
+			Token: pulumi.StringPtr(tokenOrEnv(p.config.Token, "DIGITALOCEAN_TOKEN")),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create DigitalOcean provider: %w", err)
+		}
+		p.provider = provider
+	}
+
 	// Generate or setup SSH keys
 	if err := p.setupSSHKeys(ctx); err != nil {
 		return fmt.Errorf("failed to setup SSH keys: %w", err)
@@ -56,10 +81,10 @@ func (p *DigitalOceanProvider) setupSSHKeys(ctx *pulumi.Context) error {
 	// Check if we have a public key from the orchestrator
 	if sshKey, ok := p.config.SSHPublicKey.(string); ok && sshKey != "" {
 		// Create SSH key in DigitalOcean
-		doSSHKey, err := digitalocean.NewSshKey(ctx, fmt.Sprintf("%s-do-key", ctx.Stack()), &digitalocean.SshKeyArgs{
-			Name:      pulumi.String(fmt.Sprintf("%s-kubernetes", ctx.Stack())),
+		doSSHKey, err := digitalocean.NewSshKey(ctx, scopedName(fmt.Sprintf("%s-do-key", ctx.Stack()), p.scope), &digitalocean.SshKeyArgs{
+			Name:      pulumi.String(scopedName(fmt.Sprintf("%s-kubernetes", ctx.Stack()), p.scope)),
 			PublicKey: pulumi.String(sshKey),
-		})
+		}, withProvider(p.provider)...)
 		if err != nil {
 			return fmt.Errorf("failed to create SSH key in DigitalOcean: %w", err)
 		}
@@ -68,9 +93,9 @@ func (p *DigitalOceanProvider) setupSSHKeys(ctx *pulumi.Context) error {
 		p.sshKeys = pulumi.StringArray{doSSHKey.Fingerprint}
 
 		// Export SSH key info
-		secrets.Export(ctx, "do_ssh_key_id", doSSHKey.ID())
-		secrets.Export(ctx, "do_ssh_key_fingerprint", doSSHKey.Fingerprint)
-		secrets.Export(ctx, "do_ssh_key_name", doSSHKey.Name)
+		secrets.Export(ctx, scopedName("do_ssh_key_id", p.scope), doSSHKey.ID())
+		secrets.Export(ctx, scopedName("do_ssh_key_fingerprint", p.scope), doSSHKey.Fingerprint)
+		secrets.Export(ctx, scopedName("do_ssh_key_name", p.scope), doSSHKey.Name)
 	} else if len(p.config.SSHKeys) > 0 {
 		// Use existing SSH keys
 		keys := make(pulumi.StringArray, len(p.config.SSHKeys))
@@ -135,7 +160,7 @@ func (p *DigitalOceanProvider) CreateNode(ctx *pulumi.Context, node *config.Node
 	}
 
 	// Create the droplet
-	droplet, err := digitalocean.NewDroplet(ctx, node.Name, dropletArgs)
+	droplet, err := digitalocean.NewDroplet(ctx, node.Name, dropletArgs, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create droplet %s: %w", node.Name, err)
 	}
@@ -151,8 +176,9 @@ func (p *DigitalOceanProvider) CreateNode(ctx *pulumi.Context, node *config.Node
 		Size:        node.Size,
 		Status:      droplet.Status,
 		Labels:      node.Labels,
+		Taints:      node.Taints,
 		WireGuardIP: node.WireGuardIP,
-		SSHUser:     "root",
+		SSHUser:     config.SSHUserFor("digitalocean", node.SSHUser),
 		SSHKeyPath:  "~/.ssh/id_rsa",
 	}
 
@@ -171,10 +197,10 @@ func (p *DigitalOceanProvider) CreateNodePool(ctx *pulumi.Context, pool *config.
 	outputs := make([]*NodeOutput, 0, pool.Count)
 
 	for i := 0; i < pool.Count; i++ {
-		nodeName := fmt.Sprintf("%s-%d", pool.Name, i+1)
+		nodeName := pool.NodeName(i)
 
 		// Determine zone/region
-		region := pool.Region
+		region := pool.RegionFor(i)
 		if len(pool.Zones) > 0 {
 			// Distribute across zones
 			region = pool.Zones[i%len(pool.Zones)]
@@ -182,26 +208,19 @@ func (p *DigitalOceanProvider) CreateNodePool(ctx *pulumi.Context, pool *config.
 
 		// Create node config from pool
 		nodeConfig := &config.NodeConfig{
-			Name:       nodeName,
-			Provider:   pool.Provider,
-			Pool:       pool.Name,
-			Roles:      pool.Roles,
-			Size:       pool.Size,
-			Image:      pool.Image,
-			Region:     region,
-			Labels:     pool.Labels,
-			Taints:     pool.Taints,
-			UserData:   pool.UserData,
-			Monitoring: true,
-		}
-
-		// Set WireGuard IP if using WireGuard
-		if i < 3 { // First 3 nodes get specific IPs
-			if i == 0 {
-				nodeConfig.WireGuardIP = "10.8.0.11" // Master DO
-			} else {
-				nodeConfig.WireGuardIP = fmt.Sprintf("10.8.0.%d", 14+i) // Workers DO
-			}
+			Name:        nodeName,
+			Provider:    pool.Provider,
+			Pool:        pool.Name,
+			Roles:       pool.Roles,
+			Size:        pool.Size,
+			Image:       pool.Image,
+			Region:      region,
+			Labels:      pool.Labels,
+			Taints:      pool.Taints,
+			UserData:    pool.UserData,
+			SSHUser:     pool.SSHUser,
+			Monitoring:  true,
+			WireGuardIP: pool.WireGuardIPFor(i),
 		}
 
 		output, err := p.CreateNode(ctx, nodeConfig)
@@ -227,11 +246,12 @@ func (p *DigitalOceanProvider) CreateNetwork(ctx *pulumi.Context, network *confi
 		}
 	}
 
-	vpc, err := digitalocean.NewVpc(ctx, p.config.VPC.Name, &digitalocean.VpcArgs{
-		Name:    pulumi.String(p.config.VPC.Name),
+	vpcName := scopedName(p.config.VPC.Name, p.scope)
+	vpc, err := digitalocean.NewVpc(ctx, vpcName, &digitalocean.VpcArgs{
+		Name:    pulumi.String(vpcName),
 		Region:  pulumi.String(p.config.VPC.Region),
 		IpRange: pulumi.String(p.config.VPC.CIDR),
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create VPC: %w", err)
 	}
@@ -240,13 +260,13 @@ func (p *DigitalOceanProvider) CreateNetwork(ctx *pulumi.Context, network *confi
 
 	output := &NetworkOutput{
 		ID:     vpc.ID(),
-		Name:   p.config.VPC.Name,
+		Name:   vpcName,
 		CIDR:   p.config.VPC.CIDR,
 		Region: p.config.VPC.Region,
 	}
 
-	secrets.Export(ctx, "do_vpc_id", vpc.ID())
-	secrets.Export(ctx, "do_vpc_cidr", pulumi.String(p.config.VPC.CIDR))
+	secrets.Export(ctx, scopedName("do_vpc_id", p.scope), vpc.ID())
+	secrets.Export(ctx, scopedName("do_vpc_cidr", p.scope), pulumi.String(p.config.VPC.CIDR))
 
 	return output, nil
 }
@@ -264,62 +284,35 @@ func (p *DigitalOceanProvider) CreateFirewall(ctx *pulumi.Context, firewall *con
 		}).(pulumi.IntOutput)
 	}
 
-	// Build inbound rules
-	inboundRules := digitalocean.FirewallInboundRuleArray{}
-
-	// Default: Allow all traffic within VPC
+	vpcCIDR := ""
 	if p.vpc != nil {
-		inboundRules = append(inboundRules, &digitalocean.FirewallInboundRuleArgs{
-			Protocol:        pulumi.String("tcp"),
-			PortRange:       pulumi.String("1-65535"),
-			SourceAddresses: pulumi.StringArray{pulumi.String(p.config.VPC.CIDR)},
-		})
-		inboundRules = append(inboundRules, &digitalocean.FirewallInboundRuleArgs{
-			Protocol:        pulumi.String("udp"),
-			PortRange:       pulumi.String("1-65535"),
-			SourceAddresses: pulumi.StringArray{pulumi.String(p.config.VPC.CIDR)},
-		})
+		vpcCIDR = p.config.VPC.CIDR
 	}
 
-	// Add WireGuard rule if needed
-	inboundRules = append(inboundRules, &digitalocean.FirewallInboundRuleArgs{
-		Protocol:        pulumi.String("udp"),
-		PortRange:       pulumi.String("51820"),
-		SourceAddresses: pulumi.StringArray{pulumi.String("10.8.0.0/24")},
-	})
-
-	// Convert custom firewall rules
-	for _, rule := range firewall.InboundRules {
-		sources := make(pulumi.StringArray, len(rule.Source))
-		for i, src := range rule.Source {
-			sources[i] = pulumi.String(src)
+	inboundRules := digitalocean.FirewallInboundRuleArray{}
+	outboundRules := digitalocean.FirewallOutboundRuleArray{}
+	for _, rule := range digitalOceanFirewallRules(firewall, vpcCIDR) {
+		addresses := pulumi.ToStringArray(rule.Addresses)
+		var portRange pulumi.StringPtrInput
+		if rule.Ports != "" {
+			portRange = pulumi.String(rule.Ports)
 		}
 
+		if rule.Direction == FirewallOutbound {
+			outboundRules = append(outboundRules, &digitalocean.FirewallOutboundRuleArgs{
+				Protocol:             pulumi.String(rule.Protocol),
+				PortRange:            portRange,
+				DestinationAddresses: addresses,
+			})
+			continue
+		}
 		inboundRules = append(inboundRules, &digitalocean.FirewallInboundRuleArgs{
 			Protocol:        pulumi.String(rule.Protocol),
-			PortRange:       pulumi.String(rule.Port),
-			SourceAddresses: sources,
+			PortRange:       portRange,
+			SourceAddresses: addresses,
 		})
 	}
 
-	// Build outbound rules (allow all by default)
-	outboundRules := digitalocean.FirewallOutboundRuleArray{
-		&digitalocean.FirewallOutboundRuleArgs{
-			Protocol:             pulumi.String("tcp"),
-			PortRange:            pulumi.String("1-65535"),
-			DestinationAddresses: pulumi.StringArray{pulumi.String("0.0.0.0/0"), pulumi.String("::/0")},
-		},
-		&digitalocean.FirewallOutboundRuleArgs{
-			Protocol:             pulumi.String("udp"),
-			PortRange:            pulumi.String("1-65535"),
-			DestinationAddresses: pulumi.StringArray{pulumi.String("0.0.0.0/0"), pulumi.String("::/0")},
-		},
-		&digitalocean.FirewallOutboundRuleArgs{
-			Protocol:             pulumi.String("icmp"),
-			DestinationAddresses: pulumi.StringArray{pulumi.String("0.0.0.0/0"), pulumi.String("::/0")},
-		},
-	}
-
 	// Convert dropletIds to pulumi.IntArray
 	dropletIntArray := make(pulumi.IntArray, len(dropletIds))
 	for i, id := range dropletIds {
@@ -327,27 +320,68 @@ func (p *DigitalOceanProvider) CreateFirewall(ctx *pulumi.Context, firewall *con
 	}
 
 	// Create firewall
-	fw, err := digitalocean.NewFirewall(ctx, firewall.Name, &digitalocean.FirewallArgs{
-		Name:          pulumi.String(firewall.Name),
+	firewallName := scopedName(firewall.Name, p.scope)
+	fw, err := digitalocean.NewFirewall(ctx, firewallName, &digitalocean.FirewallArgs{
+		Name:          pulumi.String(firewallName),
 		DropletIds:    dropletIntArray,
 		InboundRules:  inboundRules,
 		OutboundRules: outboundRules,
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return fmt.Errorf("failed to create firewall: %w", err)
 	}
 
 	p.firewall = fw
-	secrets.Export(ctx, "do_firewall_id", fw.ID())
+	secrets.Export(ctx, scopedName("do_firewall_id", p.scope), fw.ID())
 
 	return nil
 }
 
+// digitalOceanFirewallRules returns the rules of the cluster firewall: the
+// VPC's traffic when the cluster has a VPC, WireGuard, the config's inbound
+// rules and all outbound traffic
+func digitalOceanFirewallRules(firewall *config.FirewallConfig, vpcCIDR string) []FirewallRuleSpec {
+	var rules []FirewallRuleSpec
+
+	// Default: Allow all traffic within VPC
+	if vpcCIDR != "" {
+		rules = append(rules,
+			FirewallRuleSpec{Direction: FirewallInbound, Protocol: "tcp", Ports: "1-65535", Addresses: []string{vpcCIDR}},
+			FirewallRuleSpec{Direction: FirewallInbound, Protocol: "udp", Ports: "1-65535", Addresses: []string{vpcCIDR}},
+		)
+	}
+
+	// WireGuard
+	rules = append(rules, FirewallRuleSpec{Direction: FirewallInbound, Protocol: "udp", Ports: "51820", Addresses: []string{"10.8.0.0/24"}})
+
+	if firewall != nil {
+		for _, rule := range firewall.InboundRules {
+			rules = append(rules, FirewallRuleSpec{
+				Direction: FirewallInbound,
+				Protocol:  rule.Protocol,
+				Ports:     rule.Port,
+				Addresses: rule.Source,
+			})
+		}
+	}
+
+	// Allow all outbound
+	anywhere := []string{"0.0.0.0/0", "::/0"}
+	return append(rules,
+		FirewallRuleSpec{Direction: FirewallOutbound, Protocol: "tcp", Ports: "1-65535", Addresses: anywhere},
+		FirewallRuleSpec{Direction: FirewallOutbound, Protocol: "udp", Ports: "1-65535", Addresses: anywhere},
+		FirewallRuleSpec{Direction: FirewallOutbound, Protocol: "icmp", Addresses: anywhere},
+	)
+}
+
 // CreateLoadBalancer creates a load balancer
 func (p *DigitalOceanProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.LoadBalancerConfig) (*LoadBalancerOutput, error) {
 	// Get droplet IDs for the load balancer
 	dropletIds := make(pulumi.IntArray, 0)
 	for _, node := range p.nodes {
+		if !targetsNode(lb, node.Name) {
+			continue
+		}
 		dropletIds = append(dropletIds, node.ID.ApplyT(func(id pulumi.ID) int {
 			var idInt int
 			fmt.Sscanf(string(id), "%d", &idInt)
@@ -356,22 +390,37 @@ func (p *DigitalOceanProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *confi
 	}
 
 	// Create forwarding rules
-	forwardingRules := make(digitalocean.LoadBalancerForwardingRuleArray, len(lb.Ports))
-	for i, port := range lb.Ports {
+	ports := loadBalancerPorts(lb)
+	forwardingRules := make(digitalocean.LoadBalancerForwardingRuleArray, len(ports))
+	for i, port := range ports {
 		forwardingRules[i] = &digitalocean.LoadBalancerForwardingRuleArgs{
 			EntryPort:      pulumi.Int(port.Port),
-			TargetPort:     pulumi.Int(port.TargetPort),
-			EntryProtocol:  pulumi.String(port.Protocol),
-			TargetProtocol: pulumi.String(port.Protocol),
+			TargetPort:     pulumi.Int(backendPort(port)),
+			EntryProtocol:  pulumi.String(strings.ToLower(port.Protocol)),
+			TargetProtocol: pulumi.String(strings.ToLower(port.Protocol)),
 		}
 	}
 
+	// DigitalOcean checks backends on a single port, the first one's
+	healthcheck := &digitalocean.LoadBalancerHealthcheckArgs{
+		Protocol:               pulumi.String(healthCheckProtocol(ports[0])),
+		Port:                   pulumi.Int(backendPort(ports[0])),
+		CheckIntervalSeconds:   pulumi.Int(10),
+		ResponseTimeoutSeconds: pulumi.Int(5),
+		HealthyThreshold:       pulumi.Int(3),
+		UnhealthyThreshold:     pulumi.Int(3),
+	}
+	if healthCheckProtocol(ports[0]) == "http" {
+		healthcheck.Path = pulumi.String(healthCheckPath)
+	}
+
 	// Create load balancer args
 	lbArgs := &digitalocean.LoadBalancerArgs{
 		Name:                pulumi.String(lb.Name),
 		Region:              pulumi.String(p.config.Region),
 		Size:                pulumi.String("lb-small"),
 		ForwardingRules:     forwardingRules,
+		Healthcheck:         healthcheck,
 		DropletIds:          dropletIds,
 		RedirectHttpToHttps: pulumi.Bool(true),
 	}
@@ -382,15 +431,16 @@ func (p *DigitalOceanProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *confi
 	}
 
 	// Create load balancer
-	loadBalancer, err := digitalocean.NewLoadBalancer(ctx, lb.Name, lbArgs)
+	loadBalancer, err := digitalocean.NewLoadBalancer(ctx, lb.Name, lbArgs, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create load balancer: %w", err)
 	}
 
 	output := &LoadBalancerOutput{
-		ID:     loadBalancer.ID(),
-		IP:     loadBalancer.Ip,
-		Status: loadBalancer.Status,
+		ID:       loadBalancer.ID(),
+		IP:       loadBalancer.Ip,
+		Status:   loadBalancer.Status,
+		Backends: len(dropletIds),
 	}
 
 	secrets.Export(ctx, fmt.Sprintf("%s_ip", lb.Name), loadBalancer.Ip)
@@ -415,6 +465,343 @@ func (p *DigitalOceanProvider) GetSizes() []string {
 	}
 }
 
+// GetPriceForSize returns the monthly list price in USD of a droplet of the given size
+func (p *DigitalOceanProvider) GetPriceForSize(size, region string) (float64, error) {
+	return monthlyPrice(p.GetName(), digitalOceanMonthlyPrices, size)
+}
+
+// ResizeNode resizes a droplet. Droplets must be powered off to resize, so the
+// droplet is powered off, resized and powered back on. The disk is left at
+// its size so the resize can be reverted.
+func (p *DigitalOceanProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	if err := ValidateNodeSize(p, newSize); err != nil {
+		return err
+	}
+
+	client := p.apiClient()
+
+	droplets, _, err := client.Droplets.ListByName(ctx, node.Name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to look up droplet %s: %w", node.Name, err)
+	}
+	if len(droplets) == 0 {
+		return fmt.Errorf("droplet %s not found", node.Name)
+	}
+	droplet := droplets[0]
+
+	if droplet.Status == "active" {
+		action, _, err := client.DropletActions.PowerOff(ctx, droplet.ID)
+		if err != nil {
+			return fmt.Errorf("failed to power off droplet %s: %w", node.Name, err)
+		}
+		if err := waitForDropletAction(ctx, client, action); err != nil {
+			return err
+		}
+	}
+
+	action, _, err := client.DropletActions.Resize(ctx, droplet.ID, newSize, false)
+	if err != nil {
+		return fmt.Errorf("failed to resize droplet %s: %w", node.Name, err)
+	}
+	if err := waitForDropletAction(ctx, client, action); err != nil {
+		return err
+	}
+
+	action, _, err = client.DropletActions.PowerOn(ctx, droplet.ID)
+	if err != nil {
+		return fmt.Errorf("failed to power on droplet %s: %w", node.Name, err)
+	}
+	if err := waitForDropletAction(ctx, client, action); err != nil {
+		return err
+	}
+
+	node.Size = newSize
+	return nil
+}
+
+// DestroyNode deletes the droplet backing a node. A droplet that no longer
+// exists is treated as already destroyed.
+func (p *DigitalOceanProvider) DestroyNode(ctx context.Context, node *NodeOutput) error {
+	client := p.apiClient()
+
+	dropletID, err := p.findNodeDroplet(ctx, client, node)
+	if err != nil {
+		return err
+	}
+	if dropletID == 0 {
+		return nil
+	}
+
+	if _, err := client.Droplets.Delete(ctx, dropletID); err != nil {
+		return fmt.Errorf("failed to delete droplet %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// findNodeDroplet returns the ID of the droplet backing a node, or 0 when it
+// no longer exists. A node created in this run is found by the droplet ID
+// Pulumi recorded, once its create has settled. Otherwise the droplet is
+// looked up by name among the droplets tagged with this stack, and more than
+// one match is refused rather than guessed at.
+func (p *DigitalOceanProvider) findNodeDroplet(ctx context.Context, client *godo.Client, node *NodeOutput) (int, error) {
+	if id, ok := nodeResourceID(ctx, node); ok {
+		dropletID, err := strconv.Atoi(id)
+		if err != nil {
+			return 0, fmt.Errorf("invalid droplet ID %q for %s: %w", id, node.Name, err)
+		}
+		droplet, resp, err := client.Droplets.Get(ctx, dropletID)
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to look up droplet %s: %w", node.Name, err)
+		}
+		return droplet.ID, nil
+	}
+
+	stack, err := stackName(p.ctx)
+	if err != nil {
+		return 0, err
+	}
+	droplets, _, err := client.Droplets.ListByName(ctx, node.Name, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up droplet %s: %w", node.Name, err)
+	}
+
+	var matches []int
+	for _, droplet := range droplets {
+		for _, tag := range droplet.Tags {
+			if tag == stack {
+				matches = append(matches, droplet.ID)
+				break
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return 0, fmt.Errorf("found %d droplets named %s in stack %s, refusing to guess which one to delete", len(matches), node.Name, stack)
+	}
+}
+
+// RebootNode reboots the droplet backing a node and waits for the reboot
+// action to complete
+func (p *DigitalOceanProvider) RebootNode(ctx context.Context, node *NodeOutput) error {
+	client := p.apiClient()
+
+	droplets, _, err := client.Droplets.ListByName(ctx, node.Name, nil)
+	if err != nil {
+		return fmt.Errorf("failed to look up droplet %s: %w", node.Name, err)
+	}
+	if len(droplets) == 0 {
+		return fmt.Errorf("droplet %s not found", node.Name)
+	}
+
+	action, _, err := client.DropletActions.Reboot(ctx, droplets[0].ID)
+	if err != nil {
+		return fmt.Errorf("failed to reboot droplet %s: %w", node.Name, err)
+	}
+	return waitForDropletAction(ctx, client, action)
+}
+
+// ListFirewallRules returns the rules of the live cloud firewall named in
+// the config
+func (p *DigitalOceanProvider) ListFirewallRules(ctx context.Context, firewall *config.FirewallConfig) ([]FirewallRuleSpec, error) {
+	fw, err := findDigitalOceanFirewall(ctx, p.apiClient(), firewall.Name)
+	if err != nil {
+		return nil, err
+	}
+	return digitalOceanLiveFirewallRules(fw), nil
+}
+
+// ReconcileFirewall adds and removes rules on the live cloud firewall until
+// it matches the config, then attaches the given droplets and detaches the
+// ones not listed. With no droplet IDs the attached droplets are left alone.
+// New rules are added before old ones are removed so an edited rule never
+// leaves a gap.
+func (p *DigitalOceanProvider) ReconcileFirewall(ctx context.Context, firewall *config.FirewallConfig, nodeIDs []string) (*FirewallDiff, error) {
+	client := p.apiClient()
+
+	fw, err := findDigitalOceanFirewall(ctx, client, firewall.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	vpcCIDR := ""
+	if p.config != nil && p.config.VPC != nil {
+		vpcCIDR = p.config.VPC.CIDR
+	}
+	diff := DiffFirewallRules(digitalOceanFirewallRules(firewall, vpcCIDR), digitalOceanLiveFirewallRules(fw))
+
+	if len(diff.Add) > 0 {
+		if _, err := client.Firewalls.AddRules(ctx, fw.ID, digitalOceanFirewallRulesRequest(diff.Add)); err != nil {
+			return nil, fmt.Errorf("failed to add rules to firewall %s: %w", firewall.Name, err)
+		}
+	}
+	if len(diff.Remove) > 0 {
+		if _, err := client.Firewalls.RemoveRules(ctx, fw.ID, digitalOceanFirewallRulesRequest(diff.Remove)); err != nil {
+			return nil, fmt.Errorf("failed to remove rules from firewall %s: %w", firewall.Name, err)
+		}
+	}
+
+	if len(nodeIDs) == 0 {
+		return diff, nil
+	}
+
+	wanted := make(map[int]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		dropletID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid droplet ID %q", id)
+		}
+		wanted[dropletID] = true
+	}
+	attached := make(map[int]bool, len(fw.DropletIDs))
+	var detach []int
+	for _, id := range fw.DropletIDs {
+		attached[id] = true
+		if !wanted[id] {
+			detach = append(detach, id)
+		}
+	}
+	var attach []int
+	for id := range wanted {
+		if !attached[id] {
+			attach = append(attach, id)
+		}
+	}
+	sort.Ints(attach)
+
+	if len(attach) > 0 {
+		if _, err := client.Firewalls.AddDroplets(ctx, fw.ID, attach...); err != nil {
+			return nil, fmt.Errorf("failed to attach droplets to firewall %s: %w", firewall.Name, err)
+		}
+	}
+	if len(detach) > 0 {
+		if _, err := client.Firewalls.RemoveDroplets(ctx, fw.ID, detach...); err != nil {
+			return nil, fmt.Errorf("failed to detach droplets from firewall %s: %w", firewall.Name, err)
+		}
+	}
+	return diff, nil
+}
+
+// findDigitalOceanFirewall returns the cloud firewall with the given name
+func findDigitalOceanFirewall(ctx context.Context, client *godo.Client, name string) (*godo.Firewall, error) {
+	opt := &godo.ListOptions{PerPage: 200}
+	for {
+		firewalls, resp, err := client.Firewalls.List(ctx, opt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list firewalls: %w", err)
+		}
+		for i := range firewalls {
+			if firewalls[i].Name == name {
+				return &firewalls[i], nil
+			}
+		}
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return nil, fmt.Errorf("firewall %s not found", name)
+		}
+		page, err := resp.Links.CurrentPage()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list firewalls: %w", err)
+		}
+		opt.Page = page + 1
+	}
+}
+
+// digitalOceanLiveFirewallRules converts the rules of a cloud firewall. Rules
+// that match only tags, droplets or load balancers have no addresses and are
+// skipped, so reconciling never removes them.
+func digitalOceanLiveFirewallRules(fw *godo.Firewall) []FirewallRuleSpec {
+	var rules []FirewallRuleSpec
+	for _, rule := range fw.InboundRules {
+		if rule.Sources == nil || len(rule.Sources.Addresses) == 0 {
+			continue
+		}
+		rules = append(rules, FirewallRuleSpec{
+			Direction: FirewallInbound,
+			Protocol:  rule.Protocol,
+			Ports:     rule.PortRange,
+			Addresses: rule.Sources.Addresses,
+		})
+	}
+	for _, rule := range fw.OutboundRules {
+		if rule.Destinations == nil || len(rule.Destinations.Addresses) == 0 {
+			continue
+		}
+		rules = append(rules, FirewallRuleSpec{
+			Direction: FirewallOutbound,
+			Protocol:  rule.Protocol,
+			Ports:     rule.PortRange,
+			Addresses: rule.Destinations.Addresses,
+		})
+	}
+	return rules
+}
+
+// digitalOceanFirewallRulesRequest converts rules for the add and remove
+// rules API calls
+func digitalOceanFirewallRulesRequest(rules []FirewallRuleSpec) *godo.FirewallRulesRequest {
+	req := &godo.FirewallRulesRequest{}
+	for _, rule := range rules {
+		if rule.Direction == FirewallOutbound {
+			req.OutboundRules = append(req.OutboundRules, godo.OutboundRule{
+				Protocol:     rule.Protocol,
+				PortRange:    rule.Ports,
+				Destinations: &godo.Destinations{Addresses: rule.Addresses},
+			})
+			continue
+		}
+		req.InboundRules = append(req.InboundRules, godo.InboundRule{
+			Protocol:  rule.Protocol,
+			PortRange: rule.Ports,
+			Sources:   &godo.Sources{Addresses: rule.Addresses},
+		})
+	}
+	return req
+}
+
+// apiClient returns a DigitalOcean API client for calls made outside Pulumi
+func (p *DigitalOceanProvider) apiClient() *godo.Client {
+	token := ""
+	if p.config != nil {
+		token = p.config.Token
+	}
+	client := godo.NewFromToken(tokenOrEnv(token, "DIGITALOCEAN_TOKEN"))
+	if p.apiURL != "" {
+		if endpoint, err := url.Parse(p.apiURL); err == nil {
+			client.BaseURL = endpoint
+		}
+	}
+	return client
+}
+
+// waitForDropletAction waits until a droplet action completes
+func waitForDropletAction(ctx context.Context, client *godo.Client, action *godo.Action) error {
+	return waitFor(ctx, fmt.Sprintf("droplet action %s", action.Type), func(ctx context.Context) (bool, error) {
+		current, _, err := client.Actions.Get(ctx, action.ID)
+		if err != nil {
+			return false, err
+		}
+		switch current.Status {
+		case godo.ActionCompleted:
+			return true, nil
+		case godo.ActionInProgress:
+			return false, nil
+		}
+		return false, fmt.Errorf("action ended with status %s", current.Status)
+	})
+}
+
+// ConfigureAPI sets the credentials the provider's API calls use, from the
+// config of a deployed stack
+func (p *DigitalOceanProvider) ConfigureAPI(cfg *config.ClusterConfig) {
+	p.config = cfg.Providers.DigitalOcean
+}
+
 // Cleanup performs cleanup operations
 func (p *DigitalOceanProvider) Cleanup(ctx *pulumi.Context) error {
 	// Cleanup is handled by Pulumi's resource management
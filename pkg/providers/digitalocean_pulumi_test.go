@@ -1,12 +1,19 @@
 package providers
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mocks int
@@ -288,3 +295,202 @@ func TestDigitalOceanProvider_GetRegionsAndSizes(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+// doProviderRecordingMocks records the Pulumi provider each resource is
+// registered through, keyed by resource name
+type doProviderRecordingMocks struct {
+	mu        sync.Mutex
+	providers map[string]string
+}
+
+func (m *doProviderRecordingMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	m.mu.Lock()
+	m.providers[args.Name] = args.Provider
+	m.mu.Unlock()
+	return mocks(0).NewResource(args)
+}
+
+func (m *doProviderRecordingMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return mocks(0).Call(args)
+}
+
+func TestDigitalOceanProvider_ScopedInstance(t *testing.T) {
+	m := &doProviderRecordingMocks{providers: map[string]string{}}
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		clusterConfig := &config.ClusterConfig{
+			Providers: config.ProvidersConfig{
+				DigitalOcean: &config.DigitalOceanProvider{
+					Enabled:      true,
+					Region:       "nyc1",
+					SSHPublicKey: "ssh-ed25519 AAAA test",
+				},
+			},
+		}
+		scopedConfig := *clusterConfig
+		doConfig := *clusterConfig.Providers.DigitalOcean
+		doConfig.Token = "second-account-token"
+		scopedConfig.Providers.DigitalOcean = &doConfig
+
+		network := &config.NetworkConfig{CIDR: "10.10.0.0/16"}
+		firewall := &config.FirewallConfig{Name: "stack-firewall"}
+
+		// Both instances create the same set of once-per-instance resources;
+		// without scoping the second one would collide on their URNs
+		defaultProvider := NewDigitalOceanProvider()
+		scopedProvider := NewDigitalOceanProvider()
+		scopedProvider.SetScope("abc123def456")
+
+		for _, tc := range []struct {
+			provider *DigitalOceanProvider
+			cfg      *config.ClusterConfig
+			node     string
+		}{
+			{defaultProvider, clusterConfig, "master-1"},
+			{scopedProvider, &scopedConfig, "worker-1"},
+		} {
+			if err := tc.provider.Initialize(ctx, tc.cfg); err != nil {
+				return err
+			}
+			if _, err := tc.provider.CreateNetwork(ctx, network); err != nil {
+				return err
+			}
+			node, err := tc.provider.CreateNode(ctx, &config.NodeConfig{
+				Name: tc.node, Provider: "digitalocean", Region: "nyc1", Size: "s-2vcpu-4gb", WireGuardIP: "10.8.0.10",
+			})
+			if err != nil {
+				return err
+			}
+			if err := tc.provider.CreateFirewall(ctx, firewall, []pulumi.IDOutput{node.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, pulumi.WithMocks("project", "stack", m))
+	assert.NoError(t, err)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.providers["digitalocean-abc123def456"]
+	assert.True(t, ok, "scoped instance should register its own Pulumi provider")
+
+	for _, name := range []string{"stack-do-key-abc123def456", "stack-vpc-abc123def456", "stack-firewall-abc123def456", "worker-1"} {
+		provider, ok := m.providers[name]
+		if assert.True(t, ok, "resource %s should be created", name) {
+			assert.True(t, strings.Contains(provider, "::digitalocean-abc123def456::"),
+				"resource %s should use the scoped provider, got %q", name, provider)
+		}
+	}
+	for _, name := range []string{"stack-do-key", "stack-vpc", "stack-firewall", "master-1"} {
+		provider, ok := m.providers[name]
+		if assert.True(t, ok, "resource %s should be created", name) {
+			assert.False(t, strings.Contains(provider, "abc123def456"),
+				"resource %s should keep the default provider, got %q", name, provider)
+		}
+	}
+}
+
+// fakeDropletAPI serves droplet lookups and records which droplets get deleted
+type fakeDropletAPI struct {
+	mu       sync.Mutex
+	droplets []map[string]interface{}
+	deleted  []string
+}
+
+func (f *fakeDropletAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/droplets":
+		var matches []map[string]interface{}
+		for _, droplet := range f.droplets {
+			if droplet["name"] == r.URL.Query().Get("name") {
+				matches = append(matches, droplet)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"droplets": matches, "meta": map[string]int{"total": len(matches)}})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/droplets/"):
+		for _, droplet := range f.droplets {
+			if "/v2/droplets/"+droplet["id"].(json.Number).String() == r.URL.Path {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"droplet": droplet})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"id":"not_found","message":"not found"}`))
+	case r.Method == http.MethodDelete:
+		f.deleted = append(f.deleted, strings.TrimPrefix(r.URL.Path, "/v2/droplets/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestDigitalOceanProvider_DestroyNodeSparesOtherStacks(t *testing.T) {
+	droplet := func(id, stack string) map[string]interface{} {
+		return map[string]interface{}{"id": json.Number(id), "name": "worker-1", "tags": []string{"kubernetes", stack}}
+	}
+
+	tests := []struct {
+		name     string
+		droplets []map[string]interface{}
+		node     func() *NodeOutput
+		deleted  []string
+		wantErr  bool
+	}{
+		{
+			name:     "by the ID Pulumi recorded",
+			droplets: []map[string]interface{}{droplet("101", "stack"), droplet("202", "other-stack")},
+			node: func() *NodeOutput {
+				return &NodeOutput{Name: "worker-1", ID: pulumi.ID("101").ToIDOutput()}
+			},
+			deleted: []string{"101"},
+		},
+		{
+			name:     "by name within the stack",
+			droplets: []map[string]interface{}{droplet("101", "stack"), droplet("202", "other-stack")},
+			node:     func() *NodeOutput { return &NodeOutput{Name: "worker-1"} },
+			deleted:  []string{"101"},
+		},
+		{
+			name:     "only another stack has the name",
+			droplets: []map[string]interface{}{droplet("202", "other-stack")},
+			node:     func() *NodeOutput { return &NodeOutput{Name: "worker-1"} },
+		},
+		{
+			name:     "ambiguous match is refused",
+			droplets: []map[string]interface{}{droplet("101", "stack"), droplet("102", "stack")},
+			node:     func() *NodeOutput { return &NodeOutput{Name: "worker-1"} },
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeDropletAPI{droplets: tt.droplets}
+			server := httptest.NewServer(api)
+			defer server.Close()
+
+			err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+				provider := &DigitalOceanProvider{
+					config: &config.DigitalOceanProvider{Token: "test"},
+					ctx:    ctx,
+					apiURL: server.URL + "/",
+				}
+				return provider.DestroyNode(context.Background(), tt.node())
+			}, pulumi.WithMocks("project", "stack", mocks(0)))
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			api.mu.Lock()
+			defer api.mu.Unlock()
+			assert.Equal(t, tt.deleted, api.deleted, "droplet 202 belongs to another stack and must survive")
+		})
+	}
+}
@@ -45,6 +45,30 @@ func (f *ProviderFactory) registerAllProviders() {
 	f.registry.Register("hetzner", NewHetznerProvider())
 }
 
+// ProviderNames returns the names of the supported providers
+func ProviderNames() []string {
+	return []string{"digitalocean", "linode", "aws", "gcp", "azure", "hetzner"}
+}
+
+// NewProviderByName returns a new, uninitialized instance of the named provider
+func NewProviderByName(name string) (Provider, error) {
+	switch name {
+	case "digitalocean":
+		return NewDigitalOceanProvider(), nil
+	case "linode":
+		return NewLinodeProvider(), nil
+	case "aws":
+		return NewAWSProvider(), nil
+	case "gcp":
+		return NewGCPProvider(), nil
+	case "azure":
+		return NewAzureProvider(), nil
+	case "hetzner":
+		return NewHetznerProvider(), nil
+	}
+	return nil, fmt.Errorf("unknown provider %s", name)
+}
+
 // GetRegistry returns the provider registry
 func (f *ProviderFactory) GetRegistry() *ProviderRegistry {
 	return f.registry
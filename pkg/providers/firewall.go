@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// Firewall rule directions
+const (
+	FirewallInbound  = "inbound"
+	FirewallOutbound = "outbound"
+)
+
+// FirewallReconciler is implemented by providers that can bring a firewall
+// created by a deploy in line with an edited config through the cloud API.
+// CreateFirewall only creates; reconciling adds the rules that are missing and
+// removes the ones no longer wanted, so changing the VPN or API ports does not
+// leave duplicate or orphaned rules behind.
+type FirewallReconciler interface {
+	// ListFirewallRules returns the rules live on the cloud firewall
+	ListFirewallRules(ctx context.Context, firewall *config.FirewallConfig) ([]FirewallRuleSpec, error)
+
+	// ReconcileFirewall applies the difference between the rules the config
+	// asks for and the live ones, and attaches the firewall to the given
+	// nodes. It returns the changes it applied.
+	ReconcileFirewall(ctx context.Context, firewall *config.FirewallConfig, nodeIDs []string) (*FirewallDiff, error)
+}
+
+// FirewallRuleSpec is a single firewall rule in a provider-neutral form, used
+// to compare the rules a config asks for with the rules live on the cloud
+type FirewallRuleSpec struct {
+	ID        string   // Cloud rule ID where the cloud has one; not compared
+	Direction string   // FirewallInbound or FirewallOutbound
+	Protocol  string   // tcp, udp, icmp, or -1 for every protocol
+	Ports     string   // "22", "30000-32767", or "" for every port
+	Addresses []string // Source CIDRs of inbound rules, destinations of outbound ones
+}
+
+// String describes the rule, e.g. "inbound tcp/22 from 0.0.0.0/0"
+func (r FirewallRuleSpec) String() string {
+	preposition := "from"
+	if r.Direction == FirewallOutbound {
+		preposition = "to"
+	}
+	rule := r.Protocol
+	if ports := normalizeFirewallPorts(r.Protocol, r.Ports); ports != "" {
+		rule += "/" + ports
+	}
+	return fmt.Sprintf("%s %s %s %s", r.Direction, rule, preposition, strings.Join(r.Addresses, ","))
+}
+
+// key identifies the rule regardless of how the cloud spells ports and
+// protocols or orders addresses
+func (r FirewallRuleSpec) key() string {
+	addresses := append([]string(nil), r.Addresses...)
+	sort.Strings(addresses)
+	return strings.Join([]string{
+		r.Direction,
+		normalizeFirewallProtocol(r.Protocol),
+		normalizeFirewallPorts(r.Protocol, r.Ports),
+		strings.Join(addresses, ","),
+	}, "|")
+}
+
+// FirewallDiff is the set of rule changes that bring a live firewall in line
+// with the config
+type FirewallDiff struct {
+	Add    []FirewallRuleSpec
+	Remove []FirewallRuleSpec
+}
+
+// Empty reports whether the firewall already matches the config
+func (d *FirewallDiff) Empty() bool {
+	return len(d.Add) == 0 && len(d.Remove) == 0
+}
+
+// DiffFirewallRules compares desired rules with live ones. A live rule that
+// matches a desired rule is kept; desired rules with no live match are added
+// and every other live rule, including duplicates of a kept rule, is removed.
+// Removed rules are the live ones, so they carry the cloud's rule IDs.
+func DiffFirewallRules(desired, live []FirewallRuleSpec) *FirewallDiff {
+	unmatched := make(map[string]int, len(desired))
+	for _, rule := range desired {
+		unmatched[rule.key()]++
+	}
+
+	diff := &FirewallDiff{}
+	liveCount := make(map[string]int, len(live))
+	for _, rule := range live {
+		key := rule.key()
+		if unmatched[key] > 0 {
+			unmatched[key]--
+			liveCount[key]++
+			continue
+		}
+		diff.Remove = append(diff.Remove, rule)
+	}
+
+	seen := make(map[string]int, len(desired))
+	for _, rule := range desired {
+		key := rule.key()
+		seen[key]++
+		if seen[key] > liveCount[key] {
+			diff.Add = append(diff.Add, rule)
+		}
+	}
+	return diff
+}
+
+// normalizeFirewallProtocol maps the spellings of "every protocol" to one
+func normalizeFirewallProtocol(protocol string) string {
+	switch p := strings.ToLower(protocol); p {
+	case "-1", "all", "":
+		return "all"
+	default:
+		return p
+	}
+}
+
+// normalizeFirewallPorts returns "" for every port and "N" for a single-port
+// range, so "1-65535", "all" and "0" compare equal
+func normalizeFirewallPorts(protocol, ports string) string {
+	switch normalizeFirewallProtocol(protocol) {
+	case "all", "icmp", "icmpv6":
+		return ""
+	}
+	switch ports {
+	case "", "0", "all", "0-65535", "1-65535":
+		return ""
+	}
+	if from, to, ok := strings.Cut(ports, "-"); ok && from == to {
+		return from
+	}
+	return ports
+}
+
+// parseFirewallPorts returns the first and last port of a "N" or "N-M" port
+// range, and 0, 0 for every port
+func parseFirewallPorts(ports string) (int, int, error) {
+	if ports == "" {
+		return 0, 0, nil
+	}
+	from, to, isRange := strings.Cut(ports, "-")
+	first, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %q", ports)
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, err := strconv.Atoi(to)
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid port range %q", ports)
+	}
+	return first, last, nil
+}
+
+// splitFirewallRulesByAddress returns one rule per address, for clouds that
+// store a rule per CIDR
+func splitFirewallRulesByAddress(rules []FirewallRuleSpec) []FirewallRuleSpec {
+	var split []FirewallRuleSpec
+	for _, rule := range rules {
+		for _, address := range rule.Addresses {
+			single := rule
+			single.Addresses = []string{address}
+			split = append(split, single)
+		}
+	}
+	return split
+}
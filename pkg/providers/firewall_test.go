@@ -0,0 +1,242 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+var (
+	_ FirewallReconciler = (*DigitalOceanProvider)(nil)
+	_ FirewallReconciler = (*AWSProvider)(nil)
+)
+
+func inbound(protocol, ports string, addresses ...string) FirewallRuleSpec {
+	return FirewallRuleSpec{Direction: FirewallInbound, Protocol: protocol, Ports: ports, Addresses: addresses}
+}
+
+func TestDiffFirewallRules(t *testing.T) {
+	desired := []FirewallRuleSpec{
+		inbound("tcp", "22", "0.0.0.0/0"),
+		inbound("udp", "51821", "10.8.0.0/24"),
+		inbound("tcp", "1-65535", "10.10.0.0/16", "10.20.0.0/16"),
+	}
+	stale := inbound("udp", "51820", "10.8.0.0/24")
+	stale.ID = "rule-3"
+	duplicate := inbound("tcp", "22", "0.0.0.0/0")
+	duplicate.ID = "rule-2"
+	live := []FirewallRuleSpec{
+		{ID: "rule-1", Direction: FirewallInbound, Protocol: "TCP", Ports: "22-22", Addresses: []string{"0.0.0.0/0"}},
+		duplicate,
+		stale,
+		{ID: "rule-4", Direction: FirewallInbound, Protocol: "tcp", Ports: "all", Addresses: []string{"10.20.0.0/16", "10.10.0.0/16"}},
+	}
+
+	diff := DiffFirewallRules(desired, live)
+
+	assert.Equal(t, []FirewallRuleSpec{inbound("udp", "51821", "10.8.0.0/24")}, diff.Add)
+	assert.Equal(t, []FirewallRuleSpec{duplicate, stale}, diff.Remove, "duplicates and rules no longer wanted are removed")
+	assert.False(t, diff.Empty())
+
+	assert.True(t, DiffFirewallRules(desired, desired).Empty())
+}
+
+func TestDiffFirewallRules_DirectionMatters(t *testing.T) {
+	rule := inbound("tcp", "443", "0.0.0.0/0")
+	outbound := rule
+	outbound.Direction = FirewallOutbound
+
+	diff := DiffFirewallRules([]FirewallRuleSpec{rule}, []FirewallRuleSpec{outbound})
+	assert.Equal(t, []FirewallRuleSpec{rule}, diff.Add)
+	assert.Equal(t, []FirewallRuleSpec{outbound}, diff.Remove)
+}
+
+func TestNormalizeFirewallPorts(t *testing.T) {
+	tests := []struct {
+		protocol, ports, want string
+	}{
+		{"tcp", "22", "22"},
+		{"tcp", "22-22", "22"},
+		{"tcp", "30000-32767", "30000-32767"},
+		{"tcp", "1-65535", ""},
+		{"udp", "all", ""},
+		{"tcp", "0", ""},
+		{"icmp", "8", ""},
+		{"-1", "-1", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, normalizeFirewallPorts(tt.protocol, tt.ports), "%s %s", tt.protocol, tt.ports)
+	}
+}
+
+func TestParseFirewallPorts(t *testing.T) {
+	from, to, err := parseFirewallPorts("30000-32767")
+	require.NoError(t, err)
+	assert.Equal(t, []int{30000, 32767}, []int{from, to})
+
+	from, to, err = parseFirewallPorts("6443")
+	require.NoError(t, err)
+	assert.Equal(t, []int{6443, 6443}, []int{from, to})
+
+	from, to, err = parseFirewallPorts("")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 0}, []int{from, to})
+
+	for _, ports := range []string{"ssh", "10-x", "90-80"} {
+		_, _, err := parseFirewallPorts(ports)
+		assert.Error(t, err, ports)
+	}
+}
+
+func TestFirewallRuleSpec_String(t *testing.T) {
+	assert.Equal(t, "inbound tcp/22 from 0.0.0.0/0", inbound("tcp", "22", "0.0.0.0/0").String())
+	assert.Equal(t, "outbound icmp to 0.0.0.0/0,::/0",
+		FirewallRuleSpec{Direction: FirewallOutbound, Protocol: "icmp", Addresses: []string{"0.0.0.0/0", "::/0"}}.String())
+}
+
+func TestSplitFirewallRulesByAddress(t *testing.T) {
+	split := splitFirewallRulesByAddress([]FirewallRuleSpec{inbound("tcp", "22", "10.0.0.0/8", "192.168.0.0/16")})
+	assert.Equal(t, []FirewallRuleSpec{
+		inbound("tcp", "22", "10.0.0.0/8"),
+		inbound("tcp", "22", "192.168.0.0/16"),
+	}, split)
+}
+
+// ==================== DigitalOcean Firewall Tests ====================
+
+func TestDigitalOceanFirewallRules(t *testing.T) {
+	firewall := &config.FirewallConfig{
+		Name:         "cluster-fw",
+		InboundRules: []config.FirewallRule{{Protocol: "tcp", Port: "6443", Source: []string{"203.0.113.0/24"}}},
+	}
+
+	rules := digitalOceanFirewallRules(firewall, "10.10.0.0/16")
+	assert.Contains(t, rules, inbound("tcp", "1-65535", "10.10.0.0/16"))
+	assert.Contains(t, rules, inbound("udp", "51820", "10.8.0.0/24"))
+	assert.Contains(t, rules, inbound("tcp", "6443", "203.0.113.0/24"))
+	assert.Len(t, rules, 7)
+
+	assert.Len(t, digitalOceanFirewallRules(nil, ""), 4, "WireGuard and outbound rules without a VPC or config")
+}
+
+func TestDigitalOceanLiveFirewallRules(t *testing.T) {
+	fw := &godo.Firewall{
+		InboundRules: []godo.InboundRule{
+			{Protocol: "tcp", PortRange: "22", Sources: &godo.Sources{Addresses: []string{"0.0.0.0/0"}}},
+			{Protocol: "tcp", PortRange: "80", Sources: &godo.Sources{Tags: []string{"lb"}}},
+		},
+		OutboundRules: []godo.OutboundRule{
+			{Protocol: "icmp", Destinations: &godo.Destinations{Addresses: []string{"0.0.0.0/0"}}},
+		},
+	}
+
+	rules := digitalOceanLiveFirewallRules(fw)
+	assert.Equal(t, []FirewallRuleSpec{
+		inbound("tcp", "22", "0.0.0.0/0"),
+		{Direction: FirewallOutbound, Protocol: "icmp", Addresses: []string{"0.0.0.0/0"}},
+	}, rules, "tag-only rules are left alone")
+}
+
+func TestDigitalOceanFirewallRulesRequest(t *testing.T) {
+	req := digitalOceanFirewallRulesRequest([]FirewallRuleSpec{
+		inbound("udp", "51820", "10.8.0.0/24"),
+		{Direction: FirewallOutbound, Protocol: "tcp", Ports: "1-65535", Addresses: []string{"0.0.0.0/0"}},
+	})
+
+	require.Len(t, req.InboundRules, 1)
+	assert.Equal(t, "51820", req.InboundRules[0].PortRange)
+	assert.Equal(t, []string{"10.8.0.0/24"}, req.InboundRules[0].Sources.Addresses)
+	require.Len(t, req.OutboundRules, 1)
+	assert.Equal(t, []string{"0.0.0.0/0"}, req.OutboundRules[0].Destinations.Addresses)
+}
+
+// ==================== AWS Firewall Tests ====================
+
+func TestAWSFirewallRules(t *testing.T) {
+	firewall := &config.FirewallConfig{
+		InboundRules: []config.FirewallRule{
+			{Protocol: "tcp", Port: "8000-8100"},
+			{Protocol: "tcp", Port: "9100", Source: []string{"10.8.0.0/24"}},
+		},
+	}
+
+	rules := awsFirewallRules(firewall)
+	assert.Contains(t, rules, inbound("tcp", "6443", "0.0.0.0/0"))
+	assert.Contains(t, rules, inbound("tcp", "8000-8100", "0.0.0.0/0"), "rules without sources are open to everyone")
+	assert.Contains(t, rules, inbound("tcp", "9100", "10.8.0.0/24"))
+	assert.Equal(t, FirewallRuleSpec{Direction: FirewallOutbound, Protocol: "-1", Addresses: []string{"0.0.0.0/0"}}, rules[len(rules)-1])
+}
+
+func TestAWSLiveFirewallRule(t *testing.T) {
+	spec, ok := awsLiveFirewallRule(awsec2types.SecurityGroupRule{
+		SecurityGroupRuleId: aws.String("sgr-1"),
+		IsEgress:            aws.Bool(false),
+		IpProtocol:          aws.String("tcp"),
+		FromPort:            aws.Int32(30000),
+		ToPort:              aws.Int32(32767),
+		CidrIpv4:            aws.String("0.0.0.0/0"),
+	})
+	require.True(t, ok)
+	assert.Equal(t, FirewallRuleSpec{ID: "sgr-1", Direction: FirewallInbound, Protocol: "tcp", Ports: "30000-32767", Addresses: []string{"0.0.0.0/0"}}, spec)
+
+	spec, ok = awsLiveFirewallRule(awsec2types.SecurityGroupRule{
+		SecurityGroupRuleId: aws.String("sgr-2"),
+		IsEgress:            aws.Bool(true),
+		IpProtocol:          aws.String("-1"),
+		FromPort:            aws.Int32(-1),
+		ToPort:              aws.Int32(-1),
+		CidrIpv6:            aws.String("::/0"),
+	})
+	require.True(t, ok)
+	assert.Equal(t, FirewallOutbound, spec.Direction)
+	assert.Equal(t, "", spec.Ports)
+
+	_, ok = awsLiveFirewallRule(awsec2types.SecurityGroupRule{
+		IpProtocol:          aws.String("tcp"),
+		ReferencedGroupInfo: &awsec2types.ReferencedSecurityGroup{GroupId: aws.String("sg-lb")},
+	})
+	assert.False(t, ok, "rules referencing other groups are left alone")
+}
+
+func TestAWSFirewallRules_MatchLiveGroup(t *testing.T) {
+	desired := splitFirewallRulesByAddress(awsFirewallRules(nil))
+
+	var live []FirewallRuleSpec
+	for _, rule := range desired {
+		permission, err := awsIPPermission(rule)
+		require.NoError(t, err)
+		fromPort, toPort := aws.Int32(-1), aws.Int32(-1)
+		if permission.FromPort != nil {
+			fromPort, toPort = permission.FromPort, permission.ToPort
+		}
+		spec, ok := awsLiveFirewallRule(awsec2types.SecurityGroupRule{
+			SecurityGroupRuleId: aws.String("sgr"),
+			IsEgress:            aws.Bool(rule.Direction == FirewallOutbound),
+			IpProtocol:          permission.IpProtocol,
+			FromPort:            fromPort,
+			ToPort:              toPort,
+			CidrIpv4:            permission.IpRanges[0].CidrIp,
+		})
+		require.True(t, ok)
+		live = append(live, spec)
+	}
+
+	assert.True(t, DiffFirewallRules(desired, live).Empty(), "a group created from the config reconciles to no changes")
+}
+
+func TestAWSIPPermission(t *testing.T) {
+	permission, err := awsIPPermission(inbound("tcp", "6443", "::/0"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(6443), aws.ToInt32(permission.FromPort))
+	require.Len(t, permission.Ipv6Ranges, 1)
+	assert.Empty(t, permission.IpRanges)
+
+	_, err = awsIPPermission(inbound("tcp", "ssh", "0.0.0.0/0"))
+	assert.Error(t, err)
+}
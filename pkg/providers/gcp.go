@@ -0,0 +1,737 @@
+// Package providers implements cloud provider integrations
+package providers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// The pulumi-gcp SDK is not a dependency of this module, so GCP resources are
+// registered by type token. The plugin version is pinned on the provider
+// resource so the engine can install it on demand.
+const gcpPluginVersion = "8.10.0"
+
+// gcpProviderResource is an explicit gcp provider built from the cluster config
+type gcpProviderResource struct {
+	pulumi.ProviderResourceState
+}
+
+// gcpResource covers the compute resources whose only output we need is the self link
+type gcpResource struct {
+	pulumi.CustomResourceState
+
+	Name     pulumi.StringOutput `pulumi:"name"`
+	SelfLink pulumi.StringOutput `pulumi:"selfLink"`
+}
+
+// gcpAddress is a reserved compute address
+type gcpAddress struct {
+	pulumi.CustomResourceState
+
+	Address  pulumi.StringOutput `pulumi:"address"`
+	SelfLink pulumi.StringOutput `pulumi:"selfLink"`
+}
+
+// gcpInstance is a compute instance
+type gcpInstance struct {
+	pulumi.CustomResourceState
+
+	SelfLink          pulumi.StringOutput `pulumi:"selfLink"`
+	CurrentStatus     pulumi.StringOutput `pulumi:"currentStatus"`
+	NetworkInterfaces pulumi.ArrayOutput  `pulumi:"networkInterfaces"`
+}
+
+// GCPProvider implements the Provider interface for Google Cloud Platform
+type GCPProvider struct {
+	config        *config.GCPProvider
+	provider      *gcpProviderResource
+	project       string
+	region        string
+	zone          string
+	sshPublicKey  string
+	network       *gcpResource
+	subnetwork    *gcpResource
+	networkCIDR   string
+	privateNodes  bool
+	instances     []string // zone/name references for target pools
+	nodes         []*NodeOutput
+	ctx           *pulumi.Context
+	clusterConfig *config.ClusterConfig
+	scope         string // credentials scope; empty for the default instance
+}
+
+// NewGCPProvider creates a new GCP provider instance
+func NewGCPProvider() *GCPProvider {
+	return &GCPProvider{
+		nodes: make([]*NodeOutput, 0),
+	}
+}
+
+// GetName returns the provider name
+func (p *GCPProvider) GetName() string {
+	return "gcp"
+}
+
+// SetScope makes the instance create its resources with its own credentials
+func (p *GCPProvider) SetScope(scope string) {
+	p.scope = scope
+}
+
+// Initialize sets up the GCP provider
+func (p *GCPProvider) Initialize(ctx *pulumi.Context, cfg *config.ClusterConfig) error {
+	p.ctx = ctx
+	p.clusterConfig = cfg
+
+	if cfg.Providers.GCP == nil || !cfg.Providers.GCP.Enabled {
+		return fmt.Errorf("GCP provider is not enabled in configuration")
+	}
+
+	p.config = cfg.Providers.GCP
+
+	p.project = tokenOrEnv(p.config.ProjectID, "GOOGLE_PROJECT")
+	if p.project == "" {
+		return fmt.Errorf("GCP project ID is required (set projectId or GOOGLE_PROJECT)")
+	}
+
+	p.region = p.config.Region
+	if p.region == "" {
+		p.region = "us-central1"
+	}
+	p.zone = p.config.Zone
+	if p.zone == "" {
+		p.zone = p.region + "-a"
+	}
+
+	ctx.Log.Info("Initializing GCP provider...", nil)
+
+	// Explicit provider so the configured project and credentials are used
+	// instead of ambient gcloud settings
+	args := pulumi.Map{
+		"project": pulumi.String(p.project),
+		"region":  pulumi.String(p.region),
+		"zone":    pulumi.String(p.zone),
+	}
+	// Credentials may be a service account JSON document or a path to one
+	if credentials := tokenOrEnv(p.config.Credentials, "GOOGLE_CREDENTIALS"); credentials != "" {
+		args["credentials"] = pulumi.ToSecret(pulumi.String(credentials))
+	}
+
+	var provider gcpProviderResource
+	if err := ctx.RegisterResource("pulumi:providers:gcp", scopedName("gcp", p.scope), args, &provider, pulumi.Version(gcpPluginVersion)); err != nil {
+		return fmt.Errorf("failed to create GCP provider: %w", err)
+	}
+	p.provider = &provider
+
+	if path := cfg.Security.SSHConfig.PublicKeyPath; path != "" {
+		key, err := readSSHPublicKey(path)
+		if err != nil {
+			return fmt.Errorf("failed to read SSH public key: %w", err)
+		}
+		p.sshPublicKey = key
+	}
+
+	ctx.Log.Info(fmt.Sprintf("GCP provider initialized (project %s, zone %s)", p.project, p.zone), nil)
+	return nil
+}
+
+// register creates a gcp resource through the explicit provider
+func (p *GCPProvider) register(ctx *pulumi.Context, token, name string, props pulumi.Map, res pulumi.CustomResource) error {
+	return ctx.RegisterResource(token, name, props, res, pulumi.Provider(p.provider))
+}
+
+// CreateNetwork creates a custom-mode VPC network and one regional subnetwork
+func (p *GCPProvider) CreateNetwork(ctx *pulumi.Context, network *config.NetworkConfig) (*NetworkOutput, error) {
+	cidr := "10.10.0.0/16"
+	if p.config.Network != nil && p.config.Network.CIDR != "" {
+		cidr = p.config.Network.CIDR
+	} else if network != nil && network.CIDR != "" {
+		cidr = network.CIDR
+	}
+
+	networkName := gcpResourceName(p.clusterName(), "network")
+	if p.config.Network != nil && p.config.Network.Name != "" {
+		networkName = gcpResourceName(p.config.Network.Name)
+	}
+	networkName = gcpResourceName(networkName, p.scope)
+
+	var vpc gcpResource
+	if err := p.register(ctx, "gcp:compute/network:Network", networkName, pulumi.Map{
+		"name":                  pulumi.String(networkName),
+		"autoCreateSubnetworks": pulumi.Bool(false),
+		"description":           pulumi.String(fmt.Sprintf("Network for cluster %s (managed by sloth-kubernetes)", p.clusterName())),
+	}, &vpc); err != nil {
+		return nil, fmt.Errorf("failed to create network: %w", err)
+	}
+
+	subnetName := gcpResourceName(networkName, p.region)
+	var subnet gcpResource
+	if err := p.register(ctx, "gcp:compute/subnetwork:Subnetwork", subnetName, pulumi.Map{
+		"name":        pulumi.String(subnetName),
+		"network":     vpc.SelfLink,
+		"region":      pulumi.String(p.region),
+		"ipCidrRange": pulumi.String(cidr),
+	}, &subnet); err != nil {
+		return nil, fmt.Errorf("failed to create subnetwork: %w", err)
+	}
+
+	p.network = &vpc
+	p.subnetwork = &subnet
+	p.networkCIDR = cidr
+
+	secrets.Export(ctx, scopedName("gcp_network_id", p.scope), vpc.ID())
+	secrets.Export(ctx, scopedName("gcp_subnetwork_id", p.scope), subnet.ID())
+
+	ctx.Log.Info(fmt.Sprintf("Network created: %s (%s) in %s", networkName, cidr, p.region), nil)
+
+	return &NetworkOutput{
+		ID:     vpc.ID(),
+		Name:   networkName,
+		CIDR:   cidr,
+		Region: p.region,
+		Subnets: []SubnetOutput{
+			{ID: subnet.ID(), CIDR: cidr, Zone: p.region},
+		},
+	}, nil
+}
+
+// CreateNode creates a single compute instance
+func (p *GCPProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (*NodeOutput, error) {
+	machineType := node.Size
+	if machineType == "" {
+		machineType = p.config.DefaultSize
+	}
+	if machineType == "" {
+		machineType = "e2-standard-2" // 2 vCPU, 8GB RAM
+	}
+
+	image := node.Image
+	if image == "" {
+		image = "ubuntu-os-cloud/ubuntu-2204-lts"
+	}
+
+	zone := node.Zone
+	if zone == "" {
+		zone = p.zone
+	}
+
+	name := gcpResourceName(node.Name)
+
+	labels := pulumi.StringMap{
+		"cluster": pulumi.String(gcpLabelValue(p.clusterName())),
+		"managed": pulumi.String("sloth-kubernetes"),
+		"role":    pulumi.String(gcpLabelValue(strings.Join(node.Roles, "-"))),
+	}
+	for k, v := range node.Labels {
+		labels[gcpLabelValue(k)] = pulumi.String(gcpLabelValue(v))
+	}
+
+	metadata := pulumi.StringMap{
+		"startup-script": pulumi.String(p.generateUserData(node)),
+	}
+	if p.sshPublicKey != "" {
+		metadata["ssh-keys"] = pulumi.String(fmt.Sprintf("ubuntu:%s", p.sshPublicKey))
+	}
+
+	networkInterface := pulumi.Map{}
+	if !p.privateNodes {
+		networkInterface["accessConfigs"] = pulumi.Array{pulumi.Map{}} // ephemeral public IP
+	}
+	if p.subnetwork != nil {
+		networkInterface["subnetwork"] = p.subnetwork.SelfLink
+	} else {
+		networkInterface["network"] = pulumi.String("default")
+	}
+
+	props := pulumi.Map{
+		"name":        pulumi.String(name),
+		"machineType": pulumi.String(machineType),
+		"zone":        pulumi.String(zone),
+		"bootDisk": pulumi.Map{
+			"initializeParams": pulumi.Map{
+				"image": pulumi.String(image),
+				"size":  pulumi.Int(50),
+			},
+		},
+		"networkInterfaces": pulumi.Array{networkInterface},
+		"metadata":          metadata,
+		"labels":            labels,
+		"tags":              pulumi.StringArray{pulumi.String(p.nodeTag())},
+		"canIpForward":      pulumi.Bool(true), // WireGuard routes pod traffic through the nodes
+	}
+	if node.SpotInstance {
+		props["scheduling"] = pulumi.Map{
+			"preemptible":       pulumi.Bool(true),
+			"provisioningModel": pulumi.String("SPOT"),
+			"automaticRestart":  pulumi.Bool(false),
+		}
+	}
+
+	var instance gcpInstance
+	if err := p.register(ctx, "gcp:compute/instance:Instance", node.Name, props, &instance); err != nil {
+		return nil, fmt.Errorf("failed to create instance %s: %w", node.Name, err)
+	}
+
+	output := &NodeOutput{
+		ID:          instance.ID(),
+		Name:        node.Name,
+		PublicIP:    gcpInterfaceIP(instance.NetworkInterfaces, true),
+		PrivateIP:   gcpInterfaceIP(instance.NetworkInterfaces, false),
+		Provider:    "gcp",
+		Region:      p.region,
+		Size:        machineType,
+		Status:      instance.CurrentStatus,
+		Labels:      node.Labels,
+		Taints:      node.Taints,
+		WireGuardIP: node.WireGuardIP,
+		SSHUser:     config.SSHUserFor("gcp", node.SSHUser),
+		SSHKeyPath:  p.clusterConfig.Security.SSHConfig.KeyPath,
+	}
+
+	p.nodes = append(p.nodes, output)
+	p.instances = append(p.instances, fmt.Sprintf("%s/%s", zone, name))
+
+	secrets.Export(ctx, fmt.Sprintf("%s_id", node.Name), instance.ID())
+	secrets.Export(ctx, fmt.Sprintf("%s_public_ip", node.Name), output.PublicIP)
+	secrets.Export(ctx, fmt.Sprintf("%s_private_ip", node.Name), output.PrivateIP)
+
+	ctx.Log.Info(fmt.Sprintf("Instance created: %s (%s) in %s", node.Name, machineType, zone), nil)
+
+	return output, nil
+}
+
+// CreateNodePool creates a pool of compute instances spread across zones
+func (p *GCPProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePool) ([]*NodeOutput, error) {
+	outputs := make([]*NodeOutput, 0, pool.Count)
+
+	zones := pool.Zones
+	if len(zones) == 0 {
+		zones = []string{p.zone}
+	}
+
+	ctx.Log.Info(fmt.Sprintf("Creating node pool %s with %d nodes across %v", pool.Name, pool.Count, zones), nil)
+
+	for i := 0; i < pool.Count; i++ {
+		nodeConfig := &config.NodeConfig{
+			Name:         pool.NodeName(i),
+			Provider:     "gcp",
+			Pool:         pool.Name,
+			Roles:        pool.Roles,
+			Size:         pool.Size,
+			Image:        pool.Image,
+			Region:       p.region,
+			Zone:         zones[i%len(zones)],
+			Labels:       pool.Labels,
+			Taints:       pool.Taints,
+			UserData:     pool.UserData,
+			SSHUser:      pool.SSHUser,
+			SpotInstance: pool.SpotInstance || pool.Preemptible,
+			WireGuardIP:  pool.WireGuardIPFor(i),
+		}
+
+		output, err := p.CreateNode(ctx, nodeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create node %s: %w", nodeConfig.Name, err)
+		}
+		outputs = append(outputs, output)
+	}
+
+	ctx.Log.Info(fmt.Sprintf("Node pool %s created with %d nodes", pool.Name, len(outputs)), nil)
+
+	return outputs, nil
+}
+
+// CreateFirewall creates VPC firewall rules for the cluster. GCP firewall
+// rules cannot select instances by ID, so they target the network tag that
+// CreateNode puts on every instance; nodeIds is only used for logging.
+func (p *GCPProvider) CreateFirewall(ctx *pulumi.Context, firewall *config.FirewallConfig, nodeIds []pulumi.IDOutput) error {
+	if firewall == nil {
+		firewall = &config.FirewallConfig{}
+	}
+
+	baseName := firewall.Name
+	if baseName == "" {
+		baseName = fmt.Sprintf("%s-firewall", p.clusterName())
+	}
+	baseName = gcpResourceName(baseName, p.scope)
+
+	var network pulumi.StringInput = pulumi.String("default")
+	if p.network != nil {
+		network = p.network.SelfLink
+	}
+	targetTags := pulumi.StringArray{pulumi.String(p.nodeTag())}
+
+	type defaultRule struct {
+		suffix  string
+		allows  pulumi.Array
+		sources []string
+	}
+
+	// Cluster defaults: SSH, Kubernetes API, HTTP(S), NodePorts and WireGuard
+	rules := []defaultRule{{
+		suffix: "cluster",
+		allows: pulumi.Array{
+			gcpFirewallEntry("tcp", []string{"22", "80", "443", "6443", "30000-32767"}),
+			gcpFirewallEntry("udp", []string{"51820"}),
+		},
+		sources: []string{"0.0.0.0/0"},
+	}}
+	// All traffic between nodes on the cluster network
+	if p.networkCIDR != "" {
+		rules = append(rules, defaultRule{
+			suffix:  "internal",
+			allows:  pulumi.Array{gcpFirewallEntry("all", nil)},
+			sources: []string{p.networkCIDR},
+		})
+	}
+
+	for _, rule := range rules {
+		name := gcpResourceName(baseName, rule.suffix)
+		var fw gcpResource
+		if err := p.register(ctx, "gcp:compute/firewall:Firewall", name, pulumi.Map{
+			"name":         pulumi.String(name),
+			"network":      network,
+			"direction":    pulumi.String("INGRESS"),
+			"allows":       rule.allows,
+			"sourceRanges": pulumi.ToStringArray(rule.sources),
+			"targetTags":   targetTags,
+		}, &fw); err != nil {
+			return fmt.Errorf("failed to create firewall %s: %w", name, err)
+		}
+	}
+
+	// Custom inbound rules, one firewall each so allow and deny can mix
+	for i, rule := range firewall.InboundRules {
+		name := gcpResourceName(baseName, "in", strconv.Itoa(i))
+
+		protocol := strings.ToLower(rule.Protocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		var ports []string
+		if rule.Port != "" && protocol != "all" && protocol != "icmp" {
+			ports = []string{rule.Port}
+		}
+
+		sources := rule.Source
+		if len(sources) == 0 {
+			sources = []string{"0.0.0.0/0"}
+		}
+
+		props := pulumi.Map{
+			"name":         pulumi.String(name),
+			"network":      network,
+			"direction":    pulumi.String("INGRESS"),
+			"sourceRanges": pulumi.ToStringArray(sources),
+			"targetTags":   targetTags,
+		}
+		if rule.Description != "" {
+			props["description"] = pulumi.String(rule.Description)
+		}
+		entry := pulumi.Array{gcpFirewallEntry(protocol, ports)}
+		if strings.EqualFold(rule.Action, "deny") || strings.EqualFold(rule.Action, "drop") {
+			props["denies"] = entry
+		} else {
+			props["allows"] = entry
+		}
+
+		var fw gcpResource
+		if err := p.register(ctx, "gcp:compute/firewall:Firewall", name, props, &fw); err != nil {
+			return fmt.Errorf("failed to create firewall rule %d: %w", i, err)
+		}
+	}
+
+	ctx.Log.Info(fmt.Sprintf("Firewall %s created with %d custom rules for %d nodes", baseName, len(firewall.InboundRules), len(nodeIds)), nil)
+
+	return nil
+}
+
+// CreateLoadBalancer creates a regional network load balancer: a reserved
+// external address, a target pool with the provider's instances and one
+// forwarding rule per port
+func (p *GCPProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.LoadBalancerConfig) (*LoadBalancerOutput, error) {
+	if lb == nil {
+		return nil, nil
+	}
+
+	lbName := lb.Name
+	if lbName == "" {
+		lbName = fmt.Sprintf("%s-lb", p.clusterName())
+	}
+	lbName = gcpResourceName(lbName)
+
+	var address gcpAddress
+	if err := p.register(ctx, "gcp:compute/address:Address", gcpResourceName(lbName, "ip"), pulumi.Map{
+		"name":        pulumi.String(gcpResourceName(lbName, "ip")),
+		"region":      pulumi.String(p.region),
+		"addressType": pulumi.String("EXTERNAL"),
+	}, &address); err != nil {
+		return nil, fmt.Errorf("failed to reserve load balancer address: %w", err)
+	}
+
+	var instances []string
+	for i, node := range p.nodes {
+		if targetsNode(lb, node.Name) {
+			instances = append(instances, p.instances[i])
+		}
+	}
+
+	ports := loadBalancerPorts(lb)
+
+	// Target pools pass traffic through unchanged and only take legacy HTTP
+	// health checks, so TCP backends go unchecked and every instance is
+	// considered healthy. HTTP backends are checked on the first HTTP port.
+	poolArgs := pulumi.Map{
+		"name":      pulumi.String(gcpResourceName(lbName, "pool")),
+		"region":    pulumi.String(p.region),
+		"instances": pulumi.ToStringArray(instances),
+	}
+	for _, port := range ports {
+		if healthCheckProtocol(port) != "http" {
+			continue
+		}
+		var check gcpResource
+		if err := p.register(ctx, "gcp:compute/httpHealthCheck:HttpHealthCheck", gcpResourceName(lbName, "check"), pulumi.Map{
+			"name":        pulumi.String(gcpResourceName(lbName, "check")),
+			"port":        pulumi.Int(port.Port),
+			"requestPath": pulumi.String(healthCheckPath),
+		}, &check); err != nil {
+			return nil, fmt.Errorf("failed to create health check: %w", err)
+		}
+		poolArgs["healthChecks"] = pulumi.StringArray{check.SelfLink}
+		break
+	}
+
+	var pool gcpResource
+	if err := p.register(ctx, "gcp:compute/targetPool:TargetPool", gcpResourceName(lbName, "pool"), poolArgs, &pool); err != nil {
+		return nil, fmt.Errorf("failed to create target pool: %w", err)
+	}
+
+	for _, port := range ports {
+		if port.TargetPort != 0 && port.TargetPort != port.Port {
+			ctx.Log.Warn(fmt.Sprintf("GCP network load balancers do not remap ports; %d is forwarded to %d", port.Port, port.Port), nil)
+		}
+
+		protocol := strings.ToUpper(port.Protocol)
+		if protocol == "" {
+			protocol = "TCP"
+		}
+
+		ruleName := gcpResourceName(lbName, strconv.Itoa(port.Port))
+		var rule gcpResource
+		if err := p.register(ctx, "gcp:compute/forwardingRule:ForwardingRule", ruleName, pulumi.Map{
+			"name":                pulumi.String(ruleName),
+			"region":              pulumi.String(p.region),
+			"ipAddress":           address.Address,
+			"ipProtocol":          pulumi.String(protocol),
+			"portRange":           pulumi.String(strconv.Itoa(port.Port)),
+			"target":              pool.SelfLink,
+			"loadBalancingScheme": pulumi.String("EXTERNAL"),
+		}, &rule); err != nil {
+			return nil, fmt.Errorf("failed to create forwarding rule for port %d: %w", port.Port, err)
+		}
+	}
+
+	secrets.Export(ctx, "gcp_lb_id", pool.ID())
+	secrets.Export(ctx, "gcp_lb_ip", address.Address)
+
+	ctx.Log.Info(fmt.Sprintf("Load balancer %s created in %s with %d instances", lbName, p.region, len(instances)), nil)
+
+	return &LoadBalancerOutput{
+		ID:       pool.ID(),
+		IP:       address.Address,
+		Hostname: address.Address, // GCP network load balancers don't have hostnames
+		Status:   pulumi.String("active").ToStringOutput(),
+		Backends: len(instances),
+	}, nil
+}
+
+// GetRegions returns commonly used GCP regions
+func (p *GCPProvider) GetRegions() []string {
+	return []string{
+		"us-central1",
+		"us-east1",
+		"us-east4",
+		"us-west1",
+		"us-west2",
+		"europe-west1",
+		"europe-west2",
+		"europe-west3",
+		"europe-west4",
+		"asia-east1",
+		"asia-northeast1",
+		"asia-southeast1",
+		"southamerica-east1",
+		"australia-southeast1",
+	}
+}
+
+// GetSizes returns available machine types
+func (p *GCPProvider) GetSizes() []string {
+	return []string{
+		"e2-small",
+		"e2-medium",
+		"e2-standard-2",
+		"e2-standard-4",
+		"e2-standard-8",
+		"e2-standard-16",
+		"n2-standard-2",
+		"n2-standard-4",
+		"n2-standard-8",
+		"n2-standard-16",
+		"c2-standard-4",
+		"c2-standard-8",
+	}
+}
+
+// GetPriceForSize returns the monthly on-demand price for a machine type
+func (p *GCPProvider) GetPriceForSize(size, region string) (float64, error) {
+	return monthlyPrice(p.GetName(), gcpMonthlyPrices, size)
+}
+
+// SetPublicIP controls whether instances created afterwards get an external IP
+func (p *GCPProvider) SetPublicIP(enabled bool) {
+	p.privateNodes = !enabled
+}
+
+// ResizeNode is not supported on GCP yet; change the pool size and redeploy
+func (p *GCPProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	if err := ValidateNodeSize(p, newSize); err != nil {
+		return err
+	}
+	return fmt.Errorf("resizing nodes in place is not supported on GCP yet")
+}
+
+// DestroyNode is not supported on GCP yet
+func (p *GCPProvider) DestroyNode(ctx context.Context, node *NodeOutput) error {
+	return fmt.Errorf("destroying nodes is not supported on GCP yet")
+}
+
+// RebootNode is not supported on GCP yet
+func (p *GCPProvider) RebootNode(ctx context.Context, node *NodeOutput) error {
+	return fmt.Errorf("rebooting nodes is not supported on GCP yet")
+}
+
+// Cleanup cleans up any resources (Pulumi handles this)
+func (p *GCPProvider) Cleanup(ctx *pulumi.Context) error {
+	return nil
+}
+
+// generateUserData generates the startup script run on first boot
+func (p *GCPProvider) generateUserData(node *config.NodeConfig) string {
+	script := `#!/bin/bash
+set -e
+
+echo "=== GCP Instance Provisioning ==="
+echo "Node: ` + node.Name + `"
+echo "Roles: ` + strings.Join(node.Roles, ", ") + `"
+
+apt-get update -qq
+apt-get install -y -qq curl wget jq ca-certificates gnupg
+
+# Enable IP forwarding
+echo 'net.ipv4.ip_forward = 1' | tee -a /etc/sysctl.conf
+sysctl -p
+
+# Disable swap
+swapoff -a
+sed -i '/ swap / s/^/#/' /etc/fstab
+
+cat <<EOF | tee /etc/modules-load.d/k8s.conf
+overlay
+br_netfilter
+EOF
+
+modprobe overlay
+modprobe br_netfilter
+
+cat <<EOF | tee /etc/sysctl.d/k8s.conf
+net.bridge.bridge-nf-call-iptables  = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+net.ipv4.ip_forward                 = 1
+EOF
+
+sysctl --system
+
+echo "=== Instance provisioning complete ==="
+`
+
+	if node.UserData != "" {
+		script = script + "\n# Custom user data\n" + node.UserData
+	}
+
+	return script
+}
+
+func (p *GCPProvider) clusterName() string {
+	if p.clusterConfig != nil && p.clusterConfig.Metadata.Name != "" {
+		return p.clusterConfig.Metadata.Name
+	}
+	return p.ctx.Stack()
+}
+
+// nodeTag is the network tag firewall rules use to select cluster instances
+func (p *GCPProvider) nodeTag() string {
+	return gcpResourceName(p.clusterName(), "node")
+}
+
+// gcpFirewallEntry builds one allows/denies block
+func gcpFirewallEntry(protocol string, ports []string) pulumi.Map {
+	entry := pulumi.Map{"protocol": pulumi.String(protocol)}
+	if len(ports) > 0 {
+		entry["ports"] = pulumi.ToStringArray(ports)
+	}
+	return entry
+}
+
+// gcpInterfaceIP reads the internal or NAT address of the first network interface
+func gcpInterfaceIP(interfaces pulumi.ArrayOutput, public bool) pulumi.StringOutput {
+	return interfaces.ApplyT(func(list []interface{}) string {
+		if len(list) == 0 {
+			return ""
+		}
+		iface, _ := list[0].(map[string]interface{})
+		if !public {
+			ip, _ := iface["networkIp"].(string)
+			return ip
+		}
+		accessConfigs, _ := iface["accessConfigs"].([]interface{})
+		if len(accessConfigs) == 0 {
+			return ""
+		}
+		access, _ := accessConfigs[0].(map[string]interface{})
+		ip, _ := access["natIp"].(string)
+		return ip
+	}).(pulumi.StringOutput)
+}
+
+var gcpInvalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// gcpResourceName joins parts into a valid compute resource name: lowercase
+// letters, digits and dashes, starting with a letter, at most 63 characters
+func gcpResourceName(parts ...string) string {
+	name := gcpInvalidNameChars.ReplaceAllString(strings.ToLower(strings.Join(parts, "-")), "-")
+	name = strings.Trim(name, "-")
+	if name == "" || name[0] < 'a' || name[0] > 'z' {
+		name = "k8s-" + name
+	}
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// gcpLabelValue lowercases v and replaces characters GCP labels reject
+func gcpLabelValue(v string) string {
+	v = gcpInvalidNameChars.ReplaceAllString(strings.ToLower(v), "-")
+	if len(v) > 63 {
+		v = v[:63]
+	}
+	return v
+}
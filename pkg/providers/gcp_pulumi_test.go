@@ -0,0 +1,194 @@
+package providers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gcpMocks records registered GCP resources and fills in the outputs the
+// provider reads back
+type gcpMocks struct {
+	mu        sync.Mutex
+	resources map[string]resource.PropertyMap
+}
+
+func (m *gcpMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	outputs := args.Inputs.Copy()
+	switch args.TypeToken {
+	case "gcp:compute/address:Address":
+		outputs["address"] = resource.NewStringProperty("203.0.113.50")
+	case "gcp:compute/targetPool:TargetPool", "gcp:compute/network:Network":
+		outputs["selfLink"] = resource.NewStringProperty("projects/p/" + args.Name)
+	case "gcp:compute/instance:Instance":
+		outputs["currentStatus"] = resource.NewStringProperty("RUNNING")
+		outputs["networkInterfaces"] = resource.NewArrayProperty([]resource.PropertyValue{
+			resource.NewObjectProperty(resource.PropertyMap{
+				"networkIp": resource.NewStringProperty("10.10.0.5"),
+				"accessConfigs": resource.NewArrayProperty([]resource.PropertyValue{
+					resource.NewObjectProperty(resource.PropertyMap{"natIp": resource.NewStringProperty("198.51.100.5")}),
+				}),
+			}),
+		})
+	}
+
+	m.mu.Lock()
+	m.resources[args.TypeToken+"::"+args.Name] = args.Inputs
+	m.mu.Unlock()
+	return args.Name + "_id", outputs, nil
+}
+
+func (m *gcpMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return resource.PropertyMap{}, nil
+}
+
+func gcpTestConfig() *config.ClusterConfig {
+	return &config.ClusterConfig{
+		Metadata: config.Metadata{Name: "gcp-test"},
+		Providers: config.ProvidersConfig{
+			GCP: &config.GCPProvider{
+				Enabled:     true,
+				ProjectID:   "my-project",
+				Region:      "europe-west1",
+				Zone:        "europe-west1-b",
+				Credentials: `{"type": "service_account"}`,
+			},
+		},
+	}
+}
+
+func TestGCPProvider_Initialize(t *testing.T) {
+	t.Setenv("GOOGLE_PROJECT", "")
+
+	mocks := &gcpMocks{resources: map[string]resource.PropertyMap{}}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := NewGCPProvider()
+		require.NoError(t, provider.Initialize(ctx, gcpTestConfig()))
+		assert.Equal(t, "europe-west1-b", provider.zone)
+
+		cfg := gcpTestConfig()
+		cfg.Providers.GCP.ProjectID = ""
+		assert.ErrorContains(t, NewGCPProvider().Initialize(ctx, cfg), "GCP project ID is required")
+
+		cfg.Providers.GCP.Enabled = false
+		assert.ErrorContains(t, NewGCPProvider().Initialize(ctx, cfg), "not enabled")
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	require.NoError(t, err)
+
+	args := mocks.resources["pulumi:providers:gcp::gcp"]
+	require.NotNil(t, args)
+	assert.Equal(t, "my-project", args["project"].StringValue())
+	assert.Equal(t, "europe-west1", args["region"].StringValue())
+	assert.True(t, args["credentials"].IsSecret() || args["credentials"].IsString())
+}
+
+func TestGCPProvider_CreateLoadBalancerAndFirewall(t *testing.T) {
+	mocks := &gcpMocks{resources: map[string]resource.PropertyMap{}}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := NewGCPProvider()
+		require.NoError(t, provider.Initialize(ctx, gcpTestConfig()))
+
+		_, err := provider.CreateNetwork(ctx, &config.NetworkConfig{CIDR: "10.10.0.0/16"})
+		require.NoError(t, err)
+
+		nodes, err := provider.CreateNodePool(ctx, &config.NodePool{
+			Name:  "masters",
+			Count: 2,
+			Roles: []string{"master"},
+			Zones: []string{"europe-west1-b", "europe-west1-c"},
+		})
+		require.NoError(t, err)
+		require.Len(t, nodes, 2)
+		assert.Equal(t, "e2-standard-2", nodes[0].Size)
+
+		nodes[0].PublicIP.ApplyT(func(ip string) string {
+			assert.Equal(t, "198.51.100.5", ip)
+			return ip
+		})
+		nodes[0].PrivateIP.ApplyT(func(ip string) string {
+			assert.Equal(t, "10.10.0.5", ip)
+			return ip
+		})
+
+		require.NoError(t, provider.CreateFirewall(ctx, &config.FirewallConfig{
+			Name: "fw",
+			InboundRules: []config.FirewallRule{
+				{Protocol: "tcp", Port: "9100", Source: []string{"10.0.0.0/8"}},
+				{Protocol: "tcp", Port: "23", Action: "deny"},
+			},
+		}, []pulumi.IDOutput{nodes[0].ID, nodes[1].ID}))
+
+		lb, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{
+			Name:  "api",
+			Ports: []config.PortConfig{{Port: 6443, Protocol: "tcp"}, {Port: 443}},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, lb)
+		assert.Equal(t, 2, lb.Backends)
+		lb.IP.ApplyT(func(ip string) string {
+			assert.Equal(t, "203.0.113.50", ip)
+			return ip
+		})
+		lb.Status.ApplyT(func(status string) string {
+			assert.Equal(t, "active", status)
+			return status
+		})
+
+		web, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{
+			Name:        "web",
+			Ports:       []config.PortConfig{{Port: 80, Protocol: "http"}},
+			TargetNodes: []string{nodes[1].Name},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, web.Backends)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	require.NoError(t, err)
+
+	assert.Nil(t, mocks.resources["gcp:compute/httpHealthCheck:HttpHealthCheck::api-check"], "TCP ports go unchecked")
+	check := mocks.resources["gcp:compute/httpHealthCheck:HttpHealthCheck::web-check"]
+	require.NotNil(t, check)
+	assert.Equal(t, float64(80), check["port"].NumberValue())
+	assert.Equal(t, "/", check["requestPath"].StringValue())
+	webPool := mocks.resources["gcp:compute/targetPool:TargetPool::web-pool"]
+	require.NotNil(t, webPool)
+	require.Len(t, webPool["instances"].ArrayValue(), 1)
+	assert.Equal(t, "europe-west1-c/masters-2", webPool["instances"].ArrayValue()[0].StringValue())
+	assert.Len(t, webPool["healthChecks"].ArrayValue(), 1)
+
+	pool := mocks.resources["gcp:compute/targetPool:TargetPool::api-pool"]
+	require.NotNil(t, pool)
+	instances := pool["instances"].ArrayValue()
+	require.Len(t, instances, 2)
+	assert.Equal(t, "europe-west1-b/masters-1", instances[0].StringValue())
+	assert.Equal(t, "europe-west1-c/masters-2", instances[1].StringValue())
+
+	rule := mocks.resources["gcp:compute/forwardingRule:ForwardingRule::api-6443"]
+	require.NotNil(t, rule)
+	assert.Equal(t, "6443", rule["portRange"].StringValue())
+	assert.Equal(t, "TCP", rule["ipProtocol"].StringValue())
+	assert.NotNil(t, mocks.resources["gcp:compute/forwardingRule:ForwardingRule::api-443"])
+
+	assert.NotNil(t, mocks.resources["gcp:compute/firewall:Firewall::fw-cluster"])
+	assert.NotNil(t, mocks.resources["gcp:compute/firewall:Firewall::fw-internal"])
+	custom := mocks.resources["gcp:compute/firewall:Firewall::fw-in-0"]
+	require.NotNil(t, custom)
+	assert.Equal(t, "10.0.0.0/8", custom["sourceRanges"].ArrayValue()[0].StringValue())
+	assert.Equal(t, "gcp-test-node", custom["targetTags"].ArrayValue()[0].StringValue())
+	denied := mocks.resources["gcp:compute/firewall:Firewall::fw-in-1"]
+	require.NotNil(t, denied)
+	assert.True(t, denied.HasValue("denies"))
+	assert.False(t, denied.HasValue("allows"))
+}
+
+func TestGCPResourceName(t *testing.T) {
+	assert.Equal(t, "my-cluster-network", gcpResourceName("My_Cluster", "network"))
+	assert.Equal(t, "k8s-1-node", gcpResourceName("1", "node"))
+	assert.LessOrEqual(t, len(gcpResourceName("a-very-long-cluster-name-that-keeps-going-and-going-beyond", "limit", "suffix")), 63)
+}
@@ -2,6 +2,7 @@
 package providers
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"strconv"
@@ -32,13 +33,16 @@ func idToIntPtr(id pulumi.IDOutput) pulumi.IntPtrOutput {
 // HetznerProvider implements the Provider interface for Hetzner Cloud
 type HetznerProvider struct {
 	config         *config.HetznerProvider
+	provider       *hcloud.Provider
 	network        *hcloud.Network
 	firewall       *hcloud.Firewall
 	sshKey         *hcloud.SshKey
 	placementGroup *hcloud.PlacementGroup
+	privateNodes   bool
 	nodes          []*NodeOutput
 	ctx            *pulumi.Context
 	clusterConfig  *config.ClusterConfig
+	scope          string // credentials scope; empty for the default instance
 }
 
 // NewHetznerProvider creates a new Hetzner provider instance
@@ -53,6 +57,11 @@ func (p *HetznerProvider) GetName() string {
 	return "hetzner"
 }
 
+// SetScope makes the instance create its resources with its own credentials
+func (p *HetznerProvider) SetScope(scope string) {
+	p.scope = scope
+}
+
 // Initialize sets up the Hetzner provider
 func (p *HetznerProvider) Initialize(ctx *pulumi.Context, cfg *config.ClusterConfig) error {
 	p.ctx = ctx
@@ -64,12 +73,22 @@ func (p *HetznerProvider) Initialize(ctx *pulumi.Context, cfg *config.ClusterCon
 
 	p.config = cfg.Providers.Hetzner
 
-	if p.config.Token == "" {
-		return fmt.Errorf("Hetzner API token is required")
+	token := tokenOrEnv(p.config.Token, "HETZNER_TOKEN")
+	if token == "" {
+		return fmt.Errorf("Hetzner API token is required (set token or HETZNER_TOKEN)")
 	}
 
 	ctx.Log.Info("Initializing Hetzner Cloud provider...", nil)
 
+	// Explicit provider so the configured token is used instead of ambient credentials
+	provider, err := hcloud.NewProvider(ctx, scopedName("hetzner", p.scope), &hcloud.ProviderArgs{
+		Token: pulumi.String(token),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Hetzner provider: %w", err)
+	}
+	p.provider = provider
+
 	// Setup SSH keys
 	if err := p.setupSSHKeys(ctx); err != nil {
 		return fmt.Errorf("failed to setup SSH keys: %w", err)
@@ -107,8 +126,8 @@ func (p *HetznerProvider) setupSSHKeys(ctx *pulumi.Context) error {
 	}
 
 	// Use a unique resource name based on cluster name to prevent collisions during scaling
-	resourceName := fmt.Sprintf("ssh-key-%s", p.clusterConfig.Metadata.Name)
-	keyName := fmt.Sprintf("%s-key", p.clusterConfig.Metadata.Name)
+	resourceName := scopedName(fmt.Sprintf("ssh-key-%s", p.clusterConfig.Metadata.Name), p.scope)
+	keyName := scopedName(fmt.Sprintf("%s-key", p.clusterConfig.Metadata.Name), p.scope)
 
 	// Create SSH key with aliases for backward compatibility and deleteBeforeReplace
 	sshKey, err := hcloud.NewSshKey(ctx, resourceName, &hcloud.SshKeyArgs{
@@ -120,13 +139,13 @@ func (p *HetznerProvider) setupSSHKeys(ctx *pulumi.Context) error {
 		},
 	}, pulumi.DeleteBeforeReplace(true), pulumi.Aliases([]pulumi.Alias{
 		{Name: pulumi.String("cluster-ssh-key")},
-	}))
+	}), pulumi.Provider(p.provider))
 	if err != nil {
 		return fmt.Errorf("failed to create SSH key: %w", err)
 	}
 
 	p.sshKey = sshKey
-	secrets.Export(ctx, "hetzner_ssh_key_id", sshKey.ID())
+	secrets.Export(ctx, scopedName("hetzner_ssh_key_id", p.scope), sshKey.ID())
 	ctx.Log.Info("SSH key created/imported successfully", nil)
 
 	return nil
@@ -148,20 +167,20 @@ func (p *HetznerProvider) setupPlacementGroup(ctx *pulumi.Context) error {
 		pgType = "spread"
 	}
 
-	pg, err := hcloud.NewPlacementGroup(ctx, "cluster-placement-group", &hcloud.PlacementGroupArgs{
+	pg, err := hcloud.NewPlacementGroup(ctx, scopedName("cluster-placement-group", p.scope), &hcloud.PlacementGroupArgs{
 		Name: pulumi.String(pgName),
 		Type: pulumi.String(pgType),
 		Labels: pulumi.StringMap{
 			"cluster": pulumi.String(p.clusterConfig.Metadata.Name),
 			"managed": pulumi.String("sloth-kubernetes"),
 		},
-	})
+	}, pulumi.Provider(p.provider))
 	if err != nil {
 		return fmt.Errorf("failed to create placement group: %w", err)
 	}
 
 	p.placementGroup = pg
-	secrets.Export(ctx, "hetzner_placement_group_id", pg.ID())
+	secrets.Export(ctx, scopedName("hetzner_placement_group_id", p.scope), pg.ID())
 	ctx.Log.Info("Placement group created successfully", nil)
 
 	return nil
@@ -187,20 +206,20 @@ func (p *HetznerProvider) CreateNetwork(ctx *pulumi.Context, network *config.Net
 	}
 
 	// Create the network
-	hzNetwork, err := hcloud.NewNetwork(ctx, "cluster-network", &hcloud.NetworkArgs{
+	hzNetwork, err := hcloud.NewNetwork(ctx, scopedName("cluster-network", p.scope), &hcloud.NetworkArgs{
 		Name:    pulumi.String(networkName),
 		IpRange: pulumi.String(ipRange),
 		Labels: pulumi.StringMap{
 			"cluster": pulumi.String(p.clusterConfig.Metadata.Name),
 			"managed": pulumi.String("sloth-kubernetes"),
 		},
-	})
+	}, pulumi.Provider(p.provider))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create network: %w", err)
 	}
 
 	p.network = hzNetwork
-	secrets.Export(ctx, "hetzner_network_id", hzNetwork.ID())
+	secrets.Export(ctx, scopedName("hetzner_network_id", p.scope), hzNetwork.ID())
 
 	// Create subnets
 	var subnets []SubnetOutput
@@ -215,12 +234,12 @@ func (p *HetznerProvider) CreateNetwork(ctx *pulumi.Context, network *config.Net
 			networkZone = "eu-central"
 		}
 
-		subnet, err := hcloud.NewNetworkSubnet(ctx, fmt.Sprintf("subnet-%d", i), &hcloud.NetworkSubnetArgs{
+		subnet, err := hcloud.NewNetworkSubnet(ctx, scopedName(fmt.Sprintf("subnet-%d", i), p.scope), &hcloud.NetworkSubnetArgs{
 			NetworkId:   idToInt(hzNetwork.ID()),
 			Type:        pulumi.String(subnetType),
 			IpRange:     pulumi.String(subnetCfg.IPRange),
 			NetworkZone: pulumi.String(networkZone),
-		})
+		}, pulumi.Provider(p.provider))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create subnet %d: %w", i, err)
 		}
@@ -234,12 +253,12 @@ func (p *HetznerProvider) CreateNetwork(ctx *pulumi.Context, network *config.Net
 
 	// If no subnets configured, create a default one
 	if len(netConfig.Subnets) == 0 {
-		subnet, err := hcloud.NewNetworkSubnet(ctx, "subnet-default", &hcloud.NetworkSubnetArgs{
+		subnet, err := hcloud.NewNetworkSubnet(ctx, scopedName("subnet-default", p.scope), &hcloud.NetworkSubnetArgs{
 			NetworkId:   idToInt(hzNetwork.ID()),
 			Type:        pulumi.String("cloud"),
 			IpRange:     pulumi.String("10.0.1.0/24"),
 			NetworkZone: pulumi.String("eu-central"),
-		})
+		}, pulumi.Provider(p.provider))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create default subnet: %w", err)
 		}
@@ -266,6 +285,9 @@ func (p *HetznerProvider) CreateNetwork(ctx *pulumi.Context, network *config.Net
 func (p *HetznerProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig) (*NodeOutput, error) {
 	// Determine server type
 	serverType := node.Size
+	if serverType == "" {
+		serverType = p.config.DefaultSize
+	}
 	if serverType == "" {
 		serverType = "cpx22" // Default: 2 vCPU, 4GB RAM (AMD shared)
 	}
@@ -315,8 +337,8 @@ func (p *HetznerProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfi
 		},
 		PublicNets: hcloud.ServerPublicNetArray{
 			&hcloud.ServerPublicNetArgs{
-				Ipv4Enabled: pulumi.Bool(true),
-				Ipv6Enabled: pulumi.Bool(true),
+				Ipv4Enabled: pulumi.Bool(!p.privateNodes),
+				Ipv6Enabled: pulumi.Bool(!p.privateNodes),
 			},
 		},
 	}
@@ -326,20 +348,30 @@ func (p *HetznerProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfi
 		serverArgs.PlacementGroupId = idToIntPtr(p.placementGroup.ID())
 	}
 
+	if p.privateNodes && p.network == nil {
+		return nil, fmt.Errorf("server %s has no public IP and needs a network; set providers.hetzner.network.create", node.Name)
+	}
+
 	// Create the server
-	server, err := hcloud.NewServer(ctx, node.Name, serverArgs)
+	server, err := hcloud.NewServer(ctx, node.Name, serverArgs, pulumi.Provider(p.provider))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create server %s: %w", node.Name, err)
 	}
 
 	// Attach to network if available (separate resource)
+	privateIP := server.Ipv4Address // Will be updated if network is attached
 	if p.network != nil {
-		_, err := hcloud.NewServerNetwork(ctx, fmt.Sprintf("%s-network", node.Name), &hcloud.ServerNetworkArgs{
+		serverNetwork, err := hcloud.NewServerNetwork(ctx, fmt.Sprintf("%s-network", node.Name), &hcloud.ServerNetworkArgs{
 			ServerId:  idToInt(server.ID()),
 			NetworkId: idToInt(p.network.ID()),
-		})
+		}, pulumi.Provider(p.provider))
 		if err != nil {
+			if p.privateNodes {
+				return nil, fmt.Errorf("failed to attach server %s to network: %w", node.Name, err)
+			}
 			ctx.Log.Warn(fmt.Sprintf("Failed to attach server to network: %v", err), nil)
+		} else {
+			privateIP = serverNetwork.Ip
 		}
 	}
 
@@ -348,14 +380,15 @@ func (p *HetznerProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfi
 		ID:          server.ID(),
 		Name:        node.Name,
 		PublicIP:    server.Ipv4Address,
-		PrivateIP:   server.Ipv4Address, // Will be updated if network is attached
+		PrivateIP:   privateIP,
 		Provider:    "hetzner",
 		Region:      location,
 		Size:        serverType,
 		Status:      server.Status,
 		Labels:      node.Labels,
+		Taints:      node.Taints,
 		WireGuardIP: node.WireGuardIP,
-		SSHUser:     "root", // Hetzner uses root by default
+		SSHUser:     config.SSHUserFor("hetzner", node.SSHUser),
 		SSHKeyPath:  p.clusterConfig.Security.SSHConfig.KeyPath,
 	}
 
@@ -377,6 +410,15 @@ func (p *HetznerProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodeP
 
 	// Determine locations for distribution
 	locations := pool.Zones
+	if len(locations) == 0 {
+		locations = pool.Regions
+	}
+	if len(locations) == 0 && pool.Region != "" {
+		locations = []string{pool.Region}
+	}
+	if len(locations) == 0 && p.config.Location != "" {
+		locations = []string{p.config.Location}
+	}
 	if len(locations) == 0 {
 		locations = []string{"fsn1", "nbg1", "hel1"}
 	}
@@ -384,19 +426,9 @@ func (p *HetznerProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodeP
 	ctx.Log.Info(fmt.Sprintf("Creating node pool %s with %d nodes across %v", pool.Name, pool.Count, locations), nil)
 
 	for i := 0; i < pool.Count; i++ {
-		nodeName := fmt.Sprintf("%s-%d", pool.Name, i)
+		nodeName := pool.NodeName(i)
 		location := locations[i%len(locations)]
 
-		// Assign WireGuard IPs based on role
-		var wireGuardIP string
-		if containsRole(pool.Roles, "master") || containsRole(pool.Roles, "control-plane") {
-			wireGuardIP = fmt.Sprintf("10.8.0.%d", 20+i)
-		} else if containsRole(pool.Roles, "worker") {
-			wireGuardIP = fmt.Sprintf("10.8.0.%d", 30+i)
-		} else {
-			wireGuardIP = fmt.Sprintf("10.8.0.%d", 50+i)
-		}
-
 		nodeConfig := &config.NodeConfig{
 			Name:        nodeName,
 			Provider:    "hetzner",
@@ -405,7 +437,8 @@ func (p *HetznerProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodeP
 			Image:       pool.Image,
 			Region:      location,
 			Labels:      pool.Labels,
-			WireGuardIP: wireGuardIP,
+			SSHUser:     pool.SSHUser,
+			WireGuardIP: pool.WireGuardIPFor(i),
 		}
 
 		output, err := p.CreateNode(ctx, nodeConfig)
@@ -529,35 +562,36 @@ func (p *HetznerProvider) CreateFirewall(ctx *pulumi.Context, firewall *config.F
 	}
 
 	// Create firewall
-	fw, err := hcloud.NewFirewall(ctx, firewall.Name, &hcloud.FirewallArgs{
-		Name:  pulumi.String(firewall.Name),
+	firewallName := scopedName(firewall.Name, p.scope)
+	fw, err := hcloud.NewFirewall(ctx, firewallName, &hcloud.FirewallArgs{
+		Name:  pulumi.String(firewallName),
 		Rules: rules,
 		Labels: pulumi.StringMap{
 			"cluster": pulumi.String(p.clusterConfig.Metadata.Name),
 			"managed": pulumi.String("sloth-kubernetes"),
 		},
-	})
+	}, pulumi.Provider(p.provider))
 	if err != nil {
 		return fmt.Errorf("failed to create firewall: %w", err)
 	}
 
 	p.firewall = fw
-	secrets.Export(ctx, "hetzner_firewall_id", fw.ID())
+	secrets.Export(ctx, scopedName("hetzner_firewall_id", p.scope), fw.ID())
 
 	// Attach firewall to servers using separate attachment resources
 	for i, node := range p.nodes {
-		_, err := hcloud.NewFirewallAttachment(ctx, fmt.Sprintf("fw-attach-%d", i), &hcloud.FirewallAttachmentArgs{
+		_, err := hcloud.NewFirewallAttachment(ctx, scopedName(fmt.Sprintf("fw-attach-%d", i), p.scope), &hcloud.FirewallAttachmentArgs{
 			FirewallId: idToInt(fw.ID()),
 			ServerIds: pulumi.IntArray{
 				idToInt(node.ID),
 			},
-		})
+		}, pulumi.Provider(p.provider))
 		if err != nil {
 			ctx.Log.Warn(fmt.Sprintf("Failed to attach firewall to node %d: %v", i, err), nil)
 		}
 	}
 
-	ctx.Log.Info(fmt.Sprintf("Firewall %s created with %d rules", firewall.Name, len(rules)), nil)
+	ctx.Log.Info(fmt.Sprintf("Firewall %s created with %d rules", firewallName, len(rules)), nil)
 
 	return nil
 }
@@ -587,7 +621,7 @@ func (p *HetznerProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.Loa
 			"cluster": pulumi.String(p.clusterConfig.Metadata.Name),
 			"managed": pulumi.String("sloth-kubernetes"),
 		},
-	})
+	}, pulumi.Provider(p.provider))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create load balancer: %w", err)
 	}
@@ -599,19 +633,24 @@ func (p *HetznerProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.Loa
 			protocol = "tcp"
 		}
 
+		healthCheck := &hcloud.LoadBalancerServiceHealthCheckArgs{
+			Protocol: pulumi.String(healthCheckProtocol(port)),
+			Port:     pulumi.Int(backendPort(port)),
+			Interval: pulumi.Int(15),
+			Timeout:  pulumi.Int(10),
+			Retries:  pulumi.Int(3),
+		}
+		if healthCheckProtocol(port) == "http" {
+			healthCheck.Http = &hcloud.LoadBalancerServiceHealthCheckHttpArgs{Path: pulumi.String(healthCheckPath)}
+		}
+
 		_, err := hcloud.NewLoadBalancerService(ctx, fmt.Sprintf("%s-service-%d", lbName, i), &hcloud.LoadBalancerServiceArgs{
 			LoadBalancerId:  loadBalancer.ID().ToStringOutput(),
 			Protocol:        pulumi.String(protocol),
 			ListenPort:      pulumi.Int(port.Port),
-			DestinationPort: pulumi.Int(port.TargetPort),
-			HealthCheck: &hcloud.LoadBalancerServiceHealthCheckArgs{
-				Protocol: pulumi.String(protocol),
-				Port:     pulumi.Int(port.TargetPort),
-				Interval: pulumi.Int(15),
-				Timeout:  pulumi.Int(10),
-				Retries:  pulumi.Int(3),
-			},
-		})
+			DestinationPort: pulumi.Int(backendPort(port)),
+			HealthCheck:     healthCheck,
+		}, pulumi.Provider(p.provider))
 		if err != nil {
 			ctx.Log.Warn(fmt.Sprintf("Failed to add service %d to load balancer: %v", i, err), nil)
 		}
@@ -631,7 +670,7 @@ func (p *HetznerProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.Loa
 				Timeout:  pulumi.Int(10),
 				Retries:  pulumi.Int(3),
 			},
-		})
+		}, pulumi.Provider(p.provider))
 		if err != nil {
 			ctx.Log.Warn(fmt.Sprintf("Failed to add K8s API service to load balancer: %v", err), nil)
 		}
@@ -642,22 +681,28 @@ func (p *HetznerProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.Loa
 		_, err := hcloud.NewLoadBalancerNetwork(ctx, fmt.Sprintf("%s-network", lbName), &hcloud.LoadBalancerNetworkArgs{
 			LoadBalancerId: idToInt(loadBalancer.ID()),
 			NetworkId:      idToInt(p.network.ID()),
-		})
+		}, pulumi.Provider(p.provider))
 		if err != nil {
 			ctx.Log.Warn(fmt.Sprintf("Failed to attach load balancer to network: %v", err), nil)
 		}
 	}
 
 	// Add targets
+	backends := 0
 	for i, node := range p.nodes {
+		if !targetsNode(lb, node.Name) {
+			continue
+		}
 		_, err := hcloud.NewLoadBalancerTarget(ctx, fmt.Sprintf("%s-target-%d", lbName, i), &hcloud.LoadBalancerTargetArgs{
 			LoadBalancerId: idToInt(loadBalancer.ID()),
 			Type:           pulumi.String("server"),
 			ServerId:       idToIntPtr(node.ID),
-		})
+		}, pulumi.Provider(p.provider))
 		if err != nil {
 			ctx.Log.Warn(fmt.Sprintf("Failed to add target %d to load balancer: %v", i, err), nil)
+			continue
 		}
+		backends++
 	}
 
 	secrets.Export(ctx, "hetzner_lb_id", loadBalancer.ID())
@@ -671,6 +716,7 @@ func (p *HetznerProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.Loa
 		IP:       loadBalancer.Ipv4,
 		Hostname: loadBalancer.Ipv4, // Hetzner LBs don't have hostnames
 		Status:   pulumi.String("active").ToStringOutput(),
+		Backends: backends,
 	}, nil
 }
 
@@ -715,6 +761,35 @@ func (p *HetznerProvider) GetSizes() []string {
 	}
 }
 
+// GetPriceForSize returns the monthly list price in USD of a Hetzner server of the given size
+func (p *HetznerProvider) GetPriceForSize(size, region string) (float64, error) {
+	return monthlyPrice(p.GetName(), hetznerMonthlyPrices, size)
+}
+
+// SetPublicIP controls whether servers created afterwards get public IPs.
+// Servers without one are reachable only over the cluster network.
+func (p *HetznerProvider) SetPublicIP(enabled bool) {
+	p.privateNodes = !enabled
+}
+
+// ResizeNode is not supported on Hetzner yet; change the pool size and redeploy
+func (p *HetznerProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	if err := ValidateNodeSize(p, newSize); err != nil {
+		return err
+	}
+	return fmt.Errorf("resizing nodes in place is not supported on Hetzner yet")
+}
+
+// DestroyNode is not supported on Hetzner yet; remove the node with 'pulumi destroy'
+func (p *HetznerProvider) DestroyNode(ctx context.Context, node *NodeOutput) error {
+	return fmt.Errorf("destroying nodes is not supported on Hetzner yet")
+}
+
+// RebootNode is not supported on Hetzner yet
+func (p *HetznerProvider) RebootNode(ctx context.Context, node *NodeOutput) error {
+	return fmt.Errorf("rebooting nodes is not supported on Hetzner yet")
+}
+
 // Cleanup cleans up any resources (Pulumi handles this)
 func (p *HetznerProvider) Cleanup(ctx *pulumi.Context) error {
 	return nil
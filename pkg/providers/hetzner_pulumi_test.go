@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hetznerTestConfig() *config.ClusterConfig {
+	return &config.ClusterConfig{
+		Metadata: config.Metadata{Name: "hz-test"},
+		Providers: config.ProvidersConfig{
+			Hetzner: &config.HetznerProvider{
+				Enabled:      true,
+				Token:        "hcloud-token",
+				Location:     "nbg1",
+				DefaultSize:  "cx32",
+				SSHPublicKey: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAtest test@test",
+			},
+		},
+	}
+}
+
+func TestHetznerProvider_CreateNodePoolUsesConfiguredDefaults(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := NewHetznerProvider().(*HetznerProvider)
+		require.NoError(t, provider.Initialize(ctx, hetznerTestConfig()))
+		assert.NotNil(t, provider.provider)
+
+		nodes, err := provider.CreateNodePool(ctx, &config.NodePool{
+			Name:  "workers",
+			Count: 2,
+			Roles: []string{"worker"},
+		})
+		require.NoError(t, err)
+		require.Len(t, nodes, 2)
+		for _, node := range nodes {
+			assert.Equal(t, "nbg1", node.Region)
+			assert.Equal(t, "cx32", node.Size)
+		}
+
+		// Explicit zones still win over the provider location
+		nodes, err = provider.CreateNodePool(ctx, &config.NodePool{
+			Name:  "spread",
+			Count: 2,
+			Size:  "cpx42",
+			Zones: []string{"fsn1", "hel1"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "fsn1", nodes[0].Region)
+		assert.Equal(t, "hel1", nodes[1].Region)
+		assert.Equal(t, "cpx42", nodes[0].Size)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+}
+
+func TestHetznerProvider_InitializeToken(t *testing.T) {
+	t.Setenv("HETZNER_TOKEN", "")
+
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		cfg := hetznerTestConfig()
+		cfg.Providers.Hetzner.Token = ""
+		err := NewHetznerProvider().Initialize(ctx, cfg)
+		assert.ErrorContains(t, err, "Hetzner API token is required")
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+
+	t.Setenv("HETZNER_TOKEN", "from-env")
+	err = pulumi.RunErr(func(ctx *pulumi.Context) error {
+		cfg := hetznerTestConfig()
+		cfg.Providers.Hetzner.Token = ""
+		return NewHetznerProvider().Initialize(ctx, cfg)
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+}
+
+func TestHetznerProvider_PrivateNodesNeedNetwork(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		cfg := hetznerTestConfig()
+		cfg.Providers.Hetzner.Network = &config.HetznerNetworkConfig{Create: true}
+		provider := NewHetznerProvider().(*HetznerProvider)
+		require.NoError(t, provider.Initialize(ctx, cfg))
+		provider.SetPublicIP(false)
+
+		_, err := provider.CreateNode(ctx, &config.NodeConfig{Name: "master-0", Roles: []string{"master"}})
+		assert.ErrorContains(t, err, "needs a network")
+
+		_, err = provider.CreateNetwork(ctx, &config.NetworkConfig{CIDR: "10.0.0.0/16"})
+		require.NoError(t, err)
+		_, err = provider.CreateNode(ctx, &config.NodeConfig{Name: "master-0", Roles: []string{"master"}})
+		assert.NoError(t, err)
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks(0)))
+	assert.NoError(t, err)
+}
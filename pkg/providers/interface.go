@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -36,6 +37,22 @@ type Provider interface {
 	// GetSizes returns available instance sizes
 	GetSizes() []string
 
+	// GetPriceForSize returns the monthly price in USD of a node of the
+	// given size in the given region
+	GetPriceForSize(size, region string) (float64, error)
+
+	// ResizeNode changes the size of an existing node in place through the
+	// cloud API, power cycling it where the cloud requires a stopped instance
+	ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error
+
+	// DestroyNode deletes the instance backing a node through the cloud API
+	DestroyNode(ctx context.Context, node *NodeOutput) error
+
+	// RebootNode reboots the instance backing a node through the cloud API.
+	// It returns once the cloud reports the reboot done or, where the cloud
+	// reboots asynchronously, accepted; callers wait for SSH themselves.
+	RebootNode(ctx context.Context, node *NodeOutput) error
+
 	// Cleanup performs cleanup operations
 	Cleanup(ctx *pulumi.Context) error
 }
@@ -51,10 +68,55 @@ type NodeOutput struct {
 	Size         string
 	Status       pulumi.StringOutput
 	Labels       map[string]string
+	Taints       []config.TaintConfig
 	WireGuardIP  string
 	WireGuardKey pulumi.StringOutput
 	SSHUser      string
 	SSHKeyPath   string
+	DryRun       bool // Synthetic node from a dry run; no resource was created
+}
+
+// NodeValidator is implemented by providers that can check a node or pool
+// definition without creating anything. Dry runs call it in place of
+// CreateNode and CreateNodePool.
+type NodeValidator interface {
+	ValidateNode(node *config.NodeConfig) error
+	ValidateNodePool(pool *config.NodePool) error
+}
+
+// PrivateNodeCreator is implemented by providers that can create nodes
+// without a public IP address. Private clusters turn public IPs off after
+// the bastion is created so the remaining nodes are reachable only through it.
+type PrivateNodeCreator interface {
+	SetPublicIP(enabled bool)
+}
+
+// ScopedProvider is implemented by providers that can run a second instance
+// for the same cloud in one stack, as nodes and pools with a credentials
+// override need. SetScope is called before Initialize; the instance then
+// creates its resources through its own Pulumi provider, which carries its
+// credentials, and adds the scope to the names of the resources it creates
+// once so they do not collide with the default instance's.
+type ScopedProvider interface {
+	SetScope(scope string)
+}
+
+// APIConfigurer is implemented by providers whose cloud API operations
+// (reboot, resize, destroy) can run outside a Pulumi program, for CLI
+// commands acting on a deployed stack. ConfigureAPI takes the place of
+// Initialize for them and registers no resources.
+type APIConfigurer interface {
+	ConfigureAPI(cfg *config.ClusterConfig)
+}
+
+// ZonePlacer is implemented by providers that spread nodes across the zones
+// of their network. Batched pool deployments assign each node its zone before
+// creating any, so placement does not depend on the order the create calls
+// happen to run in.
+type ZonePlacer interface {
+	// Zones returns the zones nodes can be placed in, empty until the
+	// network is created
+	Zones() []string
 }
 
 // NetworkOutput represents network creation output
@@ -79,6 +141,7 @@ type LoadBalancerOutput struct {
 	IP       pulumi.StringOutput
 	Hostname pulumi.StringOutput
 	Status   pulumi.StringOutput
+	Backends int // nodes registered as backends
 }
 
 // ProviderRegistry manages available providers
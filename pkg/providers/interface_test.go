@@ -1,6 +1,9 @@
 package providers
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -52,6 +55,26 @@ func (m *MockProvider) GetSizes() []string {
 	return m.sizes
 }
 
+func (m *MockProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	if err := ValidateNodeSize(m, newSize); err != nil {
+		return err
+	}
+	node.Size = newSize
+	return nil
+}
+
+func (m *MockProvider) DestroyNode(ctx context.Context, node *NodeOutput) error {
+	return nil
+}
+
+func (m *MockProvider) RebootNode(ctx context.Context, node *NodeOutput) error {
+	return nil
+}
+
+func (m *MockProvider) GetPriceForSize(size, region string) (float64, error) {
+	return 0, nil
+}
+
 func (m *MockProvider) Cleanup(ctx *pulumi.Context) error {
 	return m.cleanErr
 }
@@ -458,3 +481,88 @@ func TestNodeOutput_Sizes(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateNodeSize(t *testing.T) {
+	provider := &MockProvider{name: "mock", sizes: []string{"small", "large"}}
+
+	if err := ValidateNodeSize(provider, "large"); err != nil {
+		t.Errorf("expected large to be valid, got %v", err)
+	}
+	if err := ValidateNodeSize(provider, "huge"); err == nil {
+		t.Error("expected error for unknown size")
+	}
+	if err := ValidateNodeSize(provider, ""); err == nil {
+		t.Error("expected error for empty size")
+	}
+}
+
+func TestProviders_ResizeNodeRejectsUnknownSize(t *testing.T) {
+	providers := []Provider{
+		NewDigitalOceanProvider(),
+		NewLinodeProvider(),
+		NewAWSProvider(),
+		NewAzureProvider(),
+		NewHetznerProvider(),
+	}
+
+	for _, p := range providers {
+		t.Run(p.GetName(), func(t *testing.T) {
+			node := &NodeOutput{Name: "worker-1", Size: "old"}
+			err := p.ResizeNode(context.Background(), node, "not-a-size")
+			if err == nil || !strings.Contains(err.Error(), "not available") {
+				t.Errorf("expected size validation error, got %v", err)
+			}
+			if node.Size != "old" {
+				t.Errorf("expected size to be unchanged, got %s", node.Size)
+			}
+		})
+	}
+}
+
+func TestProviders_DestroyNodeUnsupported(t *testing.T) {
+	providers := []Provider{
+		NewAzureProvider(),
+		NewHetznerProvider(),
+	}
+
+	for _, p := range providers {
+		t.Run(p.GetName(), func(t *testing.T) {
+			err := p.DestroyNode(context.Background(), &NodeOutput{Name: "worker-1"})
+			if err == nil || !strings.Contains(err.Error(), "not supported") {
+				t.Errorf("expected unsupported error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProviders_GetPriceForSize(t *testing.T) {
+	providers := []Provider{
+		NewDigitalOceanProvider(),
+		NewLinodeProvider(),
+		NewAWSProvider(),
+		NewAzureProvider(),
+		NewHetznerProvider(),
+	}
+
+	for _, p := range providers {
+		t.Run(p.GetName(), func(t *testing.T) {
+			// Every size the provider offers must have a price
+			for _, size := range p.GetSizes() {
+				price, err := p.GetPriceForSize(size, "")
+				if err != nil {
+					t.Errorf("size %s: %v", size, err)
+				} else if price <= 0 {
+					t.Errorf("size %s: expected a positive price, got %v", size, price)
+				}
+			}
+
+			var unknown *UnknownPriceError
+			if _, err := p.GetPriceForSize("not-a-size", ""); !errors.As(err, &unknown) || !strings.Contains(err.Error(), "no price known") {
+				t.Errorf("expected unknown size error, got %v", err)
+			}
+			if _, err := p.GetPriceForSize("", ""); err == nil {
+				t.Error("expected an error for an empty size")
+			}
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
+	"github.com/linode/linodego"
 	"github.com/pulumi/pulumi-linode/sdk/v4/go/linode"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"golang.org/x/oauth2"
 )
 
 // LinodeProvider implements the Provider interface for Linode/Akamai
@@ -16,6 +21,8 @@ type LinodeProvider struct {
 	firewall *linode.Firewall
 	nodes    []*NodeOutput
 	ctx      *pulumi.Context
+	scope    string                  // credentials scope; empty for the default instance
+	provider pulumi.ProviderResource // explicit provider of a scoped instance
 }
 
 // NewLinodeProvider creates a new Linode provider
@@ -30,6 +37,11 @@ func (p *LinodeProvider) GetName() string {
 	return "linode"
 }
 
+// SetScope makes the instance create its resources with its own credentials
+func (p *LinodeProvider) SetScope(scope string) {
+	p.scope = scope
+}
+
 // Initialize initializes the Linode provider
 func (p *LinodeProvider) Initialize(ctx *pulumi.Context, config *config.ClusterConfig) error {
 	p.ctx = ctx
@@ -40,6 +52,16 @@ func (p *LinodeProvider) Initialize(ctx *pulumi.Context, config *config.ClusterC
 
 	p.config = config.Providers.Linode
 
+	if p.scope != "" {
+		provider, err := linode.NewProvider(ctx, scopedName("linode", p.scope), &linode.ProviderArgs{
+			Token: pulumi.StringPtr(tokenOrEnv(p.config.Token, "LINODE_TOKEN")),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create Linode provider: %w", err)
+		}
+		p.provider = provider
+	}
+
 	ctx.Log.Info("Linode provider initialized", nil)
 	return nil
 }
@@ -95,7 +117,7 @@ func (p *LinodeProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig
 	}
 
 	// Create the instance
-	instance, err := linode.NewInstance(ctx, node.Name, instanceArgs)
+	instance, err := linode.NewInstance(ctx, node.Name, instanceArgs, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Linode instance %s: %w", node.Name, err)
 	}
@@ -113,8 +135,9 @@ func (p *LinodeProvider) CreateNode(ctx *pulumi.Context, node *config.NodeConfig
 		Size:        node.Size,
 		Status:      instance.Status,
 		Labels:      node.Labels,
+		Taints:      node.Taints,
 		WireGuardIP: node.WireGuardIP,
-		SSHUser:     "root",
+		SSHUser:     config.SSHUserFor("linode", node.SSHUser),
 		SSHKeyPath:  "~/.ssh/id_rsa",
 	}
 
@@ -133,10 +156,10 @@ func (p *LinodeProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePo
 	outputs := make([]*NodeOutput, 0, pool.Count)
 
 	for i := 0; i < pool.Count; i++ {
-		nodeName := fmt.Sprintf("%s-%d", pool.Name, i+1)
+		nodeName := pool.NodeName(i)
 
 		// Determine zone/region
-		region := pool.Region
+		region := pool.RegionFor(i)
 		if len(pool.Zones) > 0 {
 			// Distribute across zones
 			region = pool.Zones[i%len(pool.Zones)]
@@ -144,26 +167,19 @@ func (p *LinodeProvider) CreateNodePool(ctx *pulumi.Context, pool *config.NodePo
 
 		// Create node config from pool
 		nodeConfig := &config.NodeConfig{
-			Name:       nodeName,
-			Provider:   pool.Provider,
-			Pool:       pool.Name,
-			Roles:      pool.Roles,
-			Size:       pool.Size,
-			Image:      pool.Image,
-			Region:     region,
-			Labels:     pool.Labels,
-			Taints:     pool.Taints,
-			UserData:   pool.UserData,
-			Monitoring: true,
-		}
-
-		// Set WireGuard IP based on role and index
-		// Masters: 10.8.0.12, 10.8.0.13 (Linode)
-		// Worker: 10.8.0.16 (Linode)
-		if contains(pool.Roles, "controlplane") || contains(pool.Roles, "master") {
-			nodeConfig.WireGuardIP = fmt.Sprintf("10.8.0.%d", 12+i)
-		} else if contains(pool.Roles, "worker") {
-			nodeConfig.WireGuardIP = "10.8.0.16"
+			Name:        nodeName,
+			Provider:    pool.Provider,
+			Pool:        pool.Name,
+			Roles:       pool.Roles,
+			Size:        pool.Size,
+			Image:       pool.Image,
+			Region:      region,
+			Labels:      pool.Labels,
+			Taints:      pool.Taints,
+			UserData:    pool.UserData,
+			SSHUser:     pool.SSHUser,
+			Monitoring:  true,
+			WireGuardIP: pool.WireGuardIPFor(i),
 		}
 
 		output, err := p.CreateNode(ctx, nodeConfig)
@@ -184,7 +200,7 @@ func (p *LinodeProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netw
 	// automatically get a private network interface
 
 	// Create a dummy ID output for the network
-	networkName := fmt.Sprintf("%s-network", ctx.Stack())
+	networkName := scopedName(fmt.Sprintf("%s-network", ctx.Stack()), p.scope)
 
 	output := &NetworkOutput{
 		ID:     pulumi.ID(networkName).ToIDOutput(),
@@ -193,8 +209,8 @@ func (p *LinodeProvider) CreateNetwork(ctx *pulumi.Context, network *config.Netw
 		Region: p.config.Region,
 	}
 
-	secrets.Export(ctx, "linode_network_name", pulumi.String(output.Name))
-	secrets.Export(ctx, "linode_network_cidr", pulumi.String(output.CIDR))
+	secrets.Export(ctx, scopedName("linode_network_name", p.scope), pulumi.String(output.Name))
+	secrets.Export(ctx, scopedName("linode_network_cidr", p.scope), pulumi.String(output.CIDR))
 
 	return output, nil
 }
@@ -272,82 +288,113 @@ func (p *LinodeProvider) CreateFirewall(ctx *pulumi.Context, firewall *config.Fi
 	}
 
 	// Create firewall
-	fw, err := linode.NewFirewall(ctx, firewall.Name, &linode.FirewallArgs{
-		Label:          pulumi.String(firewall.Name),
+	firewallName := scopedName(firewall.Name, p.scope)
+	fw, err := linode.NewFirewall(ctx, firewallName, &linode.FirewallArgs{
+		Label:          pulumi.String(firewallName),
 		Linodes:        linodeIds,
 		InboundPolicy:  pulumi.String("DROP"),
 		OutboundPolicy: pulumi.String("ACCEPT"),
 		Inbounds:       inboundRules,
 		Outbounds:      outboundRules,
 		Tags:           pulumi.StringArray{pulumi.String("kubernetes"), pulumi.String(ctx.Stack())},
-	})
+	}, withProvider(p.provider)...)
 	if err != nil {
 		return fmt.Errorf("failed to create firewall: %w", err)
 	}
 
 	p.firewall = fw
-	secrets.Export(ctx, "linode_firewall_id", fw.ID())
+	secrets.Export(ctx, scopedName("linode_firewall_id", p.scope), fw.ID())
 
 	return nil
 }
 
-// CreateLoadBalancer creates a NodeBalancer
+// CreateLoadBalancer creates a NodeBalancer with a config per port, each
+// sending traffic to the private IPs of the target nodes. NodeBalancers always
+// get a public address, so an internal one is put behind a Cloud Firewall that
+// only admits the cluster's Linode nodes and private networks.
 func (p *LinodeProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.LoadBalancerConfig) (*LoadBalancerOutput, error) {
-	// Create NodeBalancer
-	nodeBalancer, err := linode.NewNodeBalancer(ctx, lb.Name, &linode.NodeBalancerArgs{
+	if !p.config.PrivateIP {
+		return nil, fmt.Errorf("NodeBalancer %s reaches its backends over private IPs; enable private-ip on the Linode provider", lb.Name)
+	}
+
+	ports := loadBalancerPorts(lb)
+	protocols := make([]string, len(ports))
+	for i, port := range ports {
+		protocol, err := nodeBalancerProtocol(port)
+		if err != nil {
+			return nil, fmt.Errorf("NodeBalancer %s: %w", lb.Name, err)
+		}
+		protocols[i] = protocol
+	}
+
+	nbArgs := &linode.NodeBalancerArgs{
 		Label:  pulumi.String(lb.Name),
 		Region: pulumi.String(p.config.Region),
 		Tags:   pulumi.StringArray{pulumi.String("kubernetes"), pulumi.String(ctx.Stack())},
-	})
+	}
+	if lb.IsInternal() {
+		fw, err := p.createNodeBalancerFirewall(ctx, lb, ports)
+		if err != nil {
+			return nil, err
+		}
+		nbArgs.FirewallId = idToIntPtr(fw.ID())
+	}
+
+	nodeBalancer, err := linode.NewNodeBalancer(ctx, lb.Name, nbArgs, withProvider(p.provider)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create NodeBalancer: %w", err)
 	}
 
+	backends := 0
+	for _, node := range p.nodes {
+		if targetsNode(lb, node.Name) {
+			backends++
+		}
+	}
+
 	// Create configs for each port
-	for _, port := range lb.Ports {
+	for n, port := range ports {
 		configName := fmt.Sprintf("%s-%d", lb.Name, port.Port)
 
+		// NodeBalancers check TCP backends by opening a connection
+		check, checkPath := "connection", pulumi.StringPtrInput(nil)
+		if healthCheckProtocol(port) == "http" {
+			check, checkPath = "http", pulumi.String(healthCheckPath)
+		}
+
 		nbConfig, err := linode.NewNodeBalancerConfig(ctx, configName, &linode.NodeBalancerConfigArgs{
-			NodebalancerId: nodeBalancer.ID().ApplyT(func(id pulumi.ID) int {
-				var idInt int
-				fmt.Sscanf(string(id), "%d", &idInt)
-				return idInt
-			}).(pulumi.IntOutput),
-			Port:          pulumi.Int(port.Port),
-			Protocol:      pulumi.String(strings.ToLower(port.Protocol)),
-			Algorithm:     pulumi.String("roundrobin"),
-			Check:         pulumi.String("http"),
-			CheckInterval: pulumi.Int(30),
-			CheckTimeout:  pulumi.Int(5),
-			CheckAttempts: pulumi.Int(3),
-			Stickiness:    pulumi.String("table"),
-		})
+			NodebalancerId: idToInt(nodeBalancer.ID()),
+			Port:           pulumi.Int(port.Port),
+			Protocol:       pulumi.String(protocols[n]),
+			Algorithm:      pulumi.String("roundrobin"),
+			Check:          pulumi.String(check),
+			CheckPath:      checkPath,
+			CheckInterval:  pulumi.Int(30),
+			CheckTimeout:   pulumi.Int(5),
+			CheckAttempts:  pulumi.Int(3),
+			Stickiness:     pulumi.String("table"),
+		}, withProvider(p.provider)...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create NodeBalancer config: %w", err)
 		}
 
 		// Add nodes to the config
 		for i, node := range p.nodes {
+			if !targetsNode(lb, node.Name) {
+				continue
+			}
 			nodeName := fmt.Sprintf("%s-%d-node-%d", lb.Name, port.Port, i)
 
 			_, err := linode.NewNodeBalancerNode(ctx, nodeName, &linode.NodeBalancerNodeArgs{
-				NodebalancerId: nodeBalancer.ID().ApplyT(func(id pulumi.ID) int {
-					var idInt int
-					fmt.Sscanf(string(id), "%d", &idInt)
-					return idInt
-				}).(pulumi.IntOutput),
-				ConfigId: nbConfig.ID().ApplyT(func(id pulumi.ID) int {
-					var idInt int
-					fmt.Sscanf(string(id), "%d", &idInt)
-					return idInt
-				}).(pulumi.IntOutput),
+				NodebalancerId: idToInt(nodeBalancer.ID()),
+				ConfigId:       idToInt(nbConfig.ID()),
 				Address: node.PrivateIP.ApplyT(func(ip string) string {
-					return fmt.Sprintf("%s:%d", ip, port.TargetPort)
+					return fmt.Sprintf("%s:%d", ip, backendPort(port))
 				}).(pulumi.StringOutput),
 				Label:  pulumi.String(nodeName),
 				Mode:   pulumi.String("accept"),
 				Weight: pulumi.Int(100),
-			})
+			}, withProvider(p.provider)...)
 			if err != nil {
 				return nil, fmt.Errorf("failed to add node to NodeBalancer: %w", err)
 			}
@@ -362,6 +409,7 @@ func (p *LinodeProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.Load
 		IP:       ipv4,
 		Hostname: nodeBalancer.Hostname,
 		Status:   pulumi.String("active").ToStringOutput(),
+		Backends: backends,
 	}
 
 	secrets.Export(ctx, fmt.Sprintf("%s_ip", lb.Name), ipv4)
@@ -370,6 +418,62 @@ func (p *LinodeProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.Load
 	return output, nil
 }
 
+// createNodeBalancerFirewall creates the firewall of an internal NodeBalancer:
+// its ports are open to private networks and to the public IPs of the
+// cluster's Linode nodes, which is how nodes in other data centers reach it
+func (p *LinodeProvider) createNodeBalancerFirewall(ctx *pulumi.Context, lb *config.LoadBalancerConfig, ports []config.PortConfig) (*linode.Firewall, error) {
+	sources := pulumi.StringArray{
+		pulumi.String("10.0.0.0/8"),
+		pulumi.String("172.16.0.0/12"),
+		pulumi.String("192.168.0.0/16"),
+	}
+	for _, node := range p.nodes {
+		sources = append(sources, node.PublicIP.ApplyT(func(ip string) string {
+			return ip + "/32"
+		}).(pulumi.StringOutput))
+	}
+
+	portList := make([]string, len(ports))
+	for i, port := range ports {
+		portList[i] = fmt.Sprint(port.Port)
+	}
+
+	fwName := fmt.Sprintf("%s-internal", lb.Name)
+	fw, err := linode.NewFirewall(ctx, fwName, &linode.FirewallArgs{
+		Label: pulumi.String(fwName),
+		Inbounds: linode.FirewallInboundArray{
+			&linode.FirewallInboundArgs{
+				Label:    pulumi.String("cluster"),
+				Action:   pulumi.String("ACCEPT"),
+				Protocol: pulumi.String("TCP"),
+				Ports:    pulumi.String(strings.Join(portList, ",")),
+				Ipv4s:    sources,
+			},
+		},
+		InboundPolicy:  pulumi.String("DROP"),
+		OutboundPolicy: pulumi.String("ACCEPT"),
+		Tags:           pulumi.StringArray{pulumi.String("kubernetes"), pulumi.String(ctx.Stack())},
+	}, withProvider(p.provider)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create firewall for NodeBalancer %s: %w", lb.Name, err)
+	}
+	return fw, nil
+}
+
+// nodeBalancerProtocol returns the NodeBalancer protocol that serves a port.
+// HTTPS is passed through as TCP since the NodeBalancer holds no certificate
+// to terminate it with.
+func nodeBalancerProtocol(port config.PortConfig) (string, error) {
+	switch strings.ToLower(port.Protocol) {
+	case "http":
+		return "http", nil
+	case "", "tcp", "https":
+		return "tcp", nil
+	default:
+		return "", fmt.Errorf("port %d: NodeBalancers do not support protocol %q", port.Port, port.Protocol)
+	}
+}
+
 // GetRegions returns available Linode regions
 func (p *LinodeProvider) GetRegions() []string {
 	return []string{
@@ -388,6 +492,121 @@ func (p *LinodeProvider) GetSizes() []string {
 	}
 }
 
+// GetPriceForSize returns the monthly list price in USD of a Linode instance of the given size
+func (p *LinodeProvider) GetPriceForSize(size, region string) (float64, error) {
+	return monthlyPrice(p.GetName(), linodeMonthlyPrices, size)
+}
+
+// ResizeNode resizes a Linode instance. Linode shuts the instance down,
+// migrates it and boots it again if it was running.
+func (p *LinodeProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	if err := ValidateNodeSize(p, newSize); err != nil {
+		return err
+	}
+
+	client := p.apiClient(ctx)
+
+	instances, err := client.ListInstances(ctx, linodego.NewListOptions(0, fmt.Sprintf(`{"label": %q}`, node.Name)))
+	if err != nil {
+		return fmt.Errorf("failed to look up instance %s: %w", node.Name, err)
+	}
+	if len(instances) == 0 {
+		return fmt.Errorf("instance %s not found", node.Name)
+	}
+	instance := instances[0]
+
+	started := time.Now()
+	autoDiskResize := false
+	if err := client.ResizeInstance(ctx, instance.ID, linodego.InstanceResizeOptions{
+		Type:                newSize,
+		AllowAutoDiskResize: &autoDiskResize,
+	}); err != nil {
+		return fmt.Errorf("failed to resize instance %s: %w", node.Name, err)
+	}
+
+	timeout := int(resizeTimeout.Seconds())
+	if _, err := client.WaitForEventFinished(ctx, instance.ID, linodego.EntityLinode, linodego.ActionLinodeResize, started, timeout); err != nil {
+		return fmt.Errorf("resize of instance %s did not finish: %w", node.Name, err)
+	}
+	if instance.Status == linodego.InstanceRunning {
+		if _, err := client.WaitForInstanceStatus(ctx, instance.ID, linodego.InstanceRunning, timeout); err != nil {
+			return fmt.Errorf("instance %s did not come back up: %w", node.Name, err)
+		}
+	}
+
+	node.Size = newSize
+	return nil
+}
+
+// DestroyNode deletes the Linode instance backing a node. An instance that no
+// longer exists is treated as already destroyed. A node created in this run is
+// deleted by the instance ID Pulumi recorded, once its create has settled.
+func (p *LinodeProvider) DestroyNode(ctx context.Context, node *NodeOutput) error {
+	client := p.apiClient(ctx)
+
+	if id, ok := nodeResourceID(ctx, node); ok {
+		instanceID, err := strconv.Atoi(id)
+		if err != nil {
+			return fmt.Errorf("invalid instance ID %q for %s: %w", id, node.Name, err)
+		}
+		if err := client.DeleteInstance(ctx, instanceID); err != nil && !linodego.IsNotFound(err) {
+			return fmt.Errorf("failed to delete instance %s: %w", node.Name, err)
+		}
+		return nil
+	}
+
+	instances, err := client.ListInstances(ctx, linodego.NewListOptions(0, fmt.Sprintf(`{"label": %q}`, node.Name)))
+	if err != nil {
+		return fmt.Errorf("failed to look up instance %s: %w", node.Name, err)
+	}
+	for _, instance := range instances {
+		if err := client.DeleteInstance(ctx, instance.ID); err != nil {
+			return fmt.Errorf("failed to delete instance %s: %w", node.Name, err)
+		}
+	}
+	return nil
+}
+
+// RebootNode reboots the Linode instance backing a node and waits for the
+// reboot event to finish
+func (p *LinodeProvider) RebootNode(ctx context.Context, node *NodeOutput) error {
+	client := p.apiClient(ctx)
+
+	instances, err := client.ListInstances(ctx, linodego.NewListOptions(0, fmt.Sprintf(`{"label": %q}`, node.Name)))
+	if err != nil {
+		return fmt.Errorf("failed to look up instance %s: %w", node.Name, err)
+	}
+	if len(instances) == 0 {
+		return fmt.Errorf("instance %s not found", node.Name)
+	}
+	instance := instances[0]
+
+	started := time.Now()
+	if err := client.RebootInstance(ctx, instance.ID, 0); err != nil {
+		return fmt.Errorf("failed to reboot instance %s: %w", node.Name, err)
+	}
+	if _, err := client.WaitForEventFinished(ctx, instance.ID, linodego.EntityLinode, linodego.ActionLinodeReboot, started, int(resizeTimeout.Seconds())); err != nil {
+		return fmt.Errorf("reboot of instance %s did not finish: %w", node.Name, err)
+	}
+	return nil
+}
+
+// apiClient returns a Linode API client for calls made outside Pulumi
+func (p *LinodeProvider) apiClient(ctx context.Context) linodego.Client {
+	token := ""
+	if p.config != nil {
+		token = p.config.Token
+	}
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: tokenOrEnv(token, "LINODE_TOKEN")})
+	return linodego.NewClient(oauth2.NewClient(ctx, tokenSource))
+}
+
+// ConfigureAPI sets the credentials the provider's API calls use, from the
+// config of a deployed stack
+func (p *LinodeProvider) ConfigureAPI(cfg *config.ClusterConfig) {
+	p.config = cfg.Providers.Linode
+}
+
 // Cleanup performs cleanup operations
 func (p *LinodeProvider) Cleanup(ctx *pulumi.Context) error {
 	// Cleanup is handled by Pulumi's resource management
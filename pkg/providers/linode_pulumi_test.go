@@ -2,6 +2,7 @@ package providers
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -32,6 +33,14 @@ func (linodeMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.P
 		outputs["label"] = args.Inputs["label"]
 		outputs["status"] = resource.NewStringProperty("enabled")
 
+	case "linode:index/nodeBalancer:NodeBalancer":
+		outputs["ipv4"] = resource.NewStringProperty("198.51.100.10")
+		outputs["hostname"] = resource.NewStringProperty("nb-198-51-100-10.newark.nodebalancer.linode.com")
+		return "12345", outputs, nil
+
+	case "linode:index/nodeBalancerConfig:NodeBalancerConfig":
+		return "678", outputs, nil
+
 	case "linode:index/volume:Volume":
 		outputs["id"] = resource.NewStringProperty("volume-" + args.Name)
 		outputs["label"] = args.Inputs["label"]
@@ -335,9 +344,15 @@ func TestLinodeProvider_GetRegionsAndSizes(t *testing.T) {
 
 		regions := provider.GetRegions()
 		assert.NotEmpty(t, regions, "Should have available regions")
+		assert.Contains(t, regions, "us-east")
 
 		sizes := provider.GetSizes()
 		assert.NotEmpty(t, sizes, "Should have available sizes")
+		assert.Contains(t, sizes, "g6-standard-2")
+		for _, size := range sizes {
+			_, err := provider.GetPriceForSize(size, "us-east")
+			assert.NoError(t, err, "every offered size has a price")
+		}
 
 		return nil
 	}, pulumi.WithMocks("project", "stack", linodeMocks(0)))
@@ -524,3 +539,146 @@ func TestLinodeProvider_DifferentInstanceTypes(t *testing.T) {
 		})
 	}
 }
+
+// linodeRecordingMocks records the resources created through linodeMocks
+type linodeRecordingMocks struct {
+	mu        sync.Mutex
+	resources map[string][]resource.PropertyMap
+}
+
+func (m *linodeRecordingMocks) NewResource(args pulumi.MockResourceArgs) (string, resource.PropertyMap, error) {
+	m.mu.Lock()
+	if m.resources == nil {
+		m.resources = map[string][]resource.PropertyMap{}
+	}
+	m.resources[args.TypeToken] = append(m.resources[args.TypeToken], args.Inputs)
+	m.mu.Unlock()
+	return linodeMocks(0).NewResource(args)
+}
+
+func (m *linodeRecordingMocks) Call(args pulumi.MockCallArgs) (resource.PropertyMap, error) {
+	return linodeMocks(0).Call(args)
+}
+
+func (m *linodeRecordingMocks) created(typeToken string) []resource.PropertyMap {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.resources[typeToken]
+}
+
+// newLinodeLoadBalancerProvider returns an initialized provider with a master
+// and two workers
+func newLinodeLoadBalancerProvider(t *testing.T, ctx *pulumi.Context, privateIP bool) *LinodeProvider {
+	provider := NewLinodeProvider()
+	assert.NoError(t, provider.Initialize(ctx, &config.ClusterConfig{
+		Providers: config.ProvidersConfig{
+			Linode: &config.LinodeProvider{Enabled: true, Region: "us-east", PrivateIP: privateIP},
+		},
+	}))
+	for _, node := range []config.NodeConfig{
+		{Name: "master-1", Region: "us-east", Size: "g6-standard-2", Image: "linode/ubuntu22.04", Roles: []string{"master"}},
+		{Name: "worker-1", Region: "us-east", Size: "g6-standard-2", Image: "linode/ubuntu22.04", Roles: []string{"worker"}},
+		{Name: "worker-2", Region: "us-east", Size: "g6-standard-2", Image: "linode/ubuntu22.04", Roles: []string{"worker"}},
+	} {
+		_, err := provider.CreateNode(ctx, &node)
+		assert.NoError(t, err)
+	}
+	return provider
+}
+
+func TestLinodeProvider_CreateLoadBalancer(t *testing.T) {
+	mocks := &linodeRecordingMocks{}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := newLinodeLoadBalancerProvider(t, ctx, true)
+
+		output, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{
+			Name:        "ingress",
+			Type:        "external",
+			Ports:       []config.PortConfig{{Port: 80, TargetPort: 30080, Protocol: "http"}, {Port: 443, TargetPort: 30443, Protocol: "https"}},
+			TargetNodes: []string{"worker-1", "worker-2"},
+		})
+		assert.NoError(t, err)
+		if assert.NotNil(t, output) {
+			assert.Equal(t, 2, output.Backends)
+
+			ip := make(chan string, 1)
+			output.IP.ApplyT(func(v string) string { ip <- v; return v })
+			assert.Equal(t, "198.51.100.10", <-ip)
+		}
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	assert.NoError(t, err)
+
+	assert.Empty(t, mocks.created("linode:index/firewall:Firewall"), "external NodeBalancers are not firewalled")
+
+	configs := mocks.created("linode:index/nodeBalancerConfig:NodeBalancerConfig")
+	if assert.Len(t, configs, 2) {
+		assert.Equal(t, "http", configs[0]["protocol"].StringValue())
+		assert.Equal(t, "http", configs[0]["check"].StringValue())
+		assert.Equal(t, "tcp", configs[1]["protocol"].StringValue(), "HTTPS is passed through")
+		assert.Equal(t, "connection", configs[1]["check"].StringValue())
+		assert.Equal(t, float64(12345), configs[0]["nodebalancerId"].NumberValue())
+	}
+
+	backends := mocks.created("linode:index/nodeBalancerNode:NodeBalancerNode")
+	if assert.Len(t, backends, 4, "two workers behind two ports") {
+		assert.Equal(t, "192.168.1.100:30080", backends[0]["address"].StringValue())
+		assert.Equal(t, float64(678), backends[0]["configId"].NumberValue())
+	}
+}
+
+func TestLinodeProvider_CreateLoadBalancer_Internal(t *testing.T) {
+	mocks := &linodeRecordingMocks{}
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := newLinodeLoadBalancerProvider(t, ctx, true)
+
+		output, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{
+			Name:        "api",
+			Type:        "internal",
+			TargetNodes: []string{"master-1"},
+		})
+		assert.NoError(t, err)
+		if assert.NotNil(t, output) {
+			assert.Equal(t, 1, output.Backends)
+		}
+		return nil
+	}, pulumi.WithMocks("project", "stack", mocks))
+	assert.NoError(t, err)
+
+	firewalls := mocks.created("linode:index/firewall:Firewall")
+	if assert.Len(t, firewalls, 1) {
+		assert.Equal(t, "api-internal", firewalls[0]["label"].StringValue())
+		assert.Equal(t, "DROP", firewalls[0]["inboundPolicy"].StringValue())
+
+		rule := firewalls[0]["inbounds"].ArrayValue()[0].ObjectValue()
+		assert.Equal(t, "6443", rule["ports"].StringValue(), "the default API server port")
+		var sources []string
+		for _, source := range rule["ipv4s"].ArrayValue() {
+			sources = append(sources, source.StringValue())
+		}
+		assert.Contains(t, sources, "10.0.0.0/8")
+		assert.Contains(t, sources, "203.0.113.100/32", "the cluster's Linode nodes")
+	}
+
+	nodeBalancers := mocks.created("linode:index/nodeBalancer:NodeBalancer")
+	if assert.Len(t, nodeBalancers, 1) {
+		assert.True(t, nodeBalancers[0].HasValue("firewallId"), "the NodeBalancer is put behind the firewall")
+	}
+}
+
+func TestLinodeProvider_CreateLoadBalancer_Errors(t *testing.T) {
+	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
+		provider := newLinodeLoadBalancerProvider(t, ctx, true)
+		_, err := provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{
+			Name:  "dns",
+			Ports: []config.PortConfig{{Port: 53, Protocol: "udp"}},
+		})
+		assert.ErrorContains(t, err, `do not support protocol "udp"`)
+
+		provider.config.PrivateIP = false
+		_, err = provider.CreateLoadBalancer(ctx, &config.LoadBalancerConfig{Name: "api"})
+		assert.ErrorContains(t, err, "enable private-ip")
+		return nil
+	}, pulumi.WithMocks("project", "stack", linodeMocks(0)))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,51 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+// healthCheckPath is the path HTTP health checks request from backends
+const healthCheckPath = "/"
+
+// targetsNode reports whether the load balancer sends traffic to the named
+// node. A load balancer without target nodes targets every node.
+func targetsNode(lb *config.LoadBalancerConfig, nodeName string) bool {
+	if len(lb.TargetNodes) == 0 {
+		return true
+	}
+	for _, name := range lb.TargetNodes {
+		if name == nodeName {
+			return true
+		}
+	}
+	return false
+}
+
+// loadBalancerPorts returns the configured ports, or the Kubernetes API
+// port when none are configured
+func loadBalancerPorts(lb *config.LoadBalancerConfig) []config.PortConfig {
+	if len(lb.Ports) == 0 {
+		return []config.PortConfig{{Name: "k8s-api", Port: 6443, TargetPort: 6443, Protocol: "tcp"}}
+	}
+	return lb.Ports
+}
+
+// backendPort returns the port traffic for the port is sent to on the
+// backends, which is the port itself when no target port is set
+func backendPort(port config.PortConfig) int {
+	if port.TargetPort != 0 {
+		return port.TargetPort
+	}
+	return port.Port
+}
+
+// healthCheckProtocol returns how backends behind the port are checked:
+// an HTTP request for HTTP ports, a TCP connection for anything else
+func healthCheckProtocol(port config.PortConfig) string {
+	if strings.EqualFold(port.Protocol, "http") {
+		return "http"
+	}
+	return "tcp"
+}
@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/config"
+)
+
+func TestTargetsNode(t *testing.T) {
+	all := &config.LoadBalancerConfig{Name: "api"}
+	assert.True(t, targetsNode(all, "master-1"), "no target nodes targets every node")
+	assert.True(t, targetsNode(all, "worker-1"))
+
+	workers := &config.LoadBalancerConfig{Name: "ingress", TargetNodes: []string{"worker-1", "worker-2"}}
+	assert.True(t, targetsNode(workers, "worker-2"))
+	assert.False(t, targetsNode(workers, "master-1"))
+}
+
+func TestAWSLoadBalancerTarget(t *testing.T) {
+	master := &NodeOutput{Name: "node-a", Labels: map[string]string{"role": "master"}}
+	worker := &NodeOutput{Name: "node-b", Labels: map[string]string{"role": "worker"}}
+
+	legacy := &config.LoadBalancerConfig{Name: "api"}
+	assert.True(t, awsLoadBalancerTarget(legacy, master, 5))
+	assert.True(t, awsLoadBalancerTarget(legacy, &NodeOutput{Name: "controlplane-1"}, 5))
+	assert.True(t, awsLoadBalancerTarget(legacy, worker, 0), "the first nodes count as masters when no role says otherwise")
+	assert.False(t, awsLoadBalancerTarget(legacy, worker, 3))
+
+	ingress := &config.LoadBalancerConfig{Name: "ingress", TargetNodes: []string{"node-b"}}
+	assert.True(t, awsLoadBalancerTarget(ingress, worker, 3))
+	assert.False(t, awsLoadBalancerTarget(ingress, master, 0))
+}
+
+func TestLoadBalancerPorts(t *testing.T) {
+	ports := loadBalancerPorts(&config.LoadBalancerConfig{})
+	assert.Equal(t, []config.PortConfig{{Name: "k8s-api", Port: 6443, TargetPort: 6443, Protocol: "tcp"}}, ports)
+
+	ingress := &config.LoadBalancerConfig{Ports: []config.PortConfig{{Port: 80, TargetPort: 30080, Protocol: "HTTP"}, {Port: 443}}}
+	assert.Equal(t, ingress.Ports, loadBalancerPorts(ingress))
+	assert.Equal(t, 30080, backendPort(ingress.Ports[0]))
+	assert.Equal(t, 443, backendPort(ingress.Ports[1]), "no target port forwards to the port itself")
+	assert.Equal(t, "http", healthCheckProtocol(ingress.Ports[0]))
+	assert.Equal(t, "tcp", healthCheckProtocol(ingress.Ports[1]))
+}
@@ -0,0 +1,42 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi/internals"
+)
+
+// nodeResourceID waits for the Pulumi create of a node to settle and returns
+// the ID of the resource backing it. It reports false when there is no ID to
+// go by: the node was not created in this run, its create failed, or the ID
+// is unknown because Pulumi is only previewing.
+func nodeResourceID(ctx context.Context, node *NodeOutput) (string, bool) {
+	if node.ID.OutputState == nil {
+		return "", false
+	}
+
+	result, err := internals.UnsafeAwaitOutput(ctx, node.ID)
+	if err != nil || !result.Known {
+		return "", false
+	}
+
+	switch id := result.Value.(type) {
+	case pulumi.ID:
+		return string(id), id != ""
+	case string:
+		return id, id != ""
+	default:
+		return "", false
+	}
+}
+
+// stackName returns the name of the stack a provider was initialized in,
+// which the providers tag every node with
+func stackName(ctx *pulumi.Context) (string, error) {
+	if ctx == nil {
+		return "", fmt.Errorf("provider is not initialized")
+	}
+	return ctx.Stack(), nil
+}
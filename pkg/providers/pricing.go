@@ -0,0 +1,143 @@
+package providers
+
+import "fmt"
+
+// Monthly on-demand list prices in USD for the sizes each provider offers.
+// AWS, Azure and GCP prices are for us-east-1, eastus and us-central1 (730
+// hours a month); DigitalOcean, Linode and Hetzner price the same in every
+// region.
+// They are meant for estimates before a deploy, not for billing.
+var (
+	digitalOceanMonthlyPrices = map[string]float64{
+		"s-1vcpu-1gb":  6,
+		"s-1vcpu-2gb":  12,
+		"s-2vcpu-2gb":  18,
+		"s-2vcpu-4gb":  24,
+		"s-4vcpu-8gb":  48,
+		"s-8vcpu-16gb": 96,
+		"c-2":          42,
+		"c-4":          84,
+		"c-8":          168,
+		"c-16":         336,
+	}
+
+	linodeMonthlyPrices = map[string]float64{
+		"g6-nanode-1":     5,
+		"g6-standard-1":   12,
+		"g6-standard-2":   24,
+		"g6-standard-4":   48,
+		"g6-standard-6":   96,
+		"g6-standard-8":   192,
+		"g6-standard-16":  384,
+		"g6-standard-20":  480,
+		"g6-dedicated-2":  36,
+		"g6-dedicated-4":  72,
+		"g6-dedicated-8":  144,
+		"g6-dedicated-16": 288,
+	}
+
+	awsMonthlyPrices = map[string]float64{
+		"t2.micro":   8.47,
+		"t2.small":   16.79,
+		"t2.medium":  33.87,
+		"t2.large":   67.74,
+		"t2.xlarge":  135.49,
+		"t3.micro":   7.59,
+		"t3.small":   15.18,
+		"t3.medium":  30.37,
+		"t3.large":   60.74,
+		"t3.xlarge":  121.47,
+		"m5.large":   70.08,
+		"m5.xlarge":  140.16,
+		"m5.2xlarge": 280.32,
+		"m5.4xlarge": 560.64,
+		"c5.large":   62.05,
+		"c5.xlarge":  124.10,
+		"c5.2xlarge": 248.20,
+		"c5.4xlarge": 496.40,
+		"r5.large":   91.98,
+		"r5.xlarge":  183.96,
+		"r5.2xlarge": 367.92,
+	}
+
+	azureMonthlyPrices = map[string]float64{
+		"Standard_B1s":     7.59,
+		"Standard_B1ms":    15.11,
+		"Standard_B2s":     30.37,
+		"Standard_B2ms":    60.74,
+		"Standard_B4ms":    121.18,
+		"Standard_B8ms":    242.36,
+		"Standard_D2s_v3":  70.08,
+		"Standard_D4s_v3":  140.16,
+		"Standard_D8s_v3":  280.32,
+		"Standard_D16s_v3": 560.64,
+		"Standard_D32s_v3": 1121.28,
+		"Standard_E2s_v3":  91.98,
+		"Standard_E4s_v3":  183.96,
+		"Standard_E8s_v3":  367.92,
+		"Standard_F2s_v2":  61.76,
+		"Standard_F4s_v2":  123.37,
+		"Standard_F8s_v2":  246.74,
+	}
+
+	gcpMonthlyPrices = map[string]float64{
+		"e2-small":       12.23,
+		"e2-medium":      24.46,
+		"e2-standard-2":  48.92,
+		"e2-standard-4":  97.83,
+		"e2-standard-8":  195.67,
+		"e2-standard-16": 391.34,
+		"n2-standard-2":  70.90,
+		"n2-standard-4":  141.79,
+		"n2-standard-8":  283.58,
+		"n2-standard-16": 567.17,
+		"c2-standard-4":  152.44,
+		"c2-standard-8":  304.88,
+	}
+
+	hetznerMonthlyPrices = map[string]float64{
+		"cpx11": 5.49,
+		"cpx21": 9.49,
+		"cpx31": 17.49,
+		"cpx41": 32.49,
+		"cpx51": 65.49,
+		"cx23":  4.19,
+		"cx33":  6.99,
+		"cx43":  12.99,
+		"cx53":  24.49,
+		"ccx13": 14.49,
+		"ccx23": 28.49,
+		"ccx33": 56.49,
+		"ccx43": 112.49,
+		"ccx53": 224.49,
+		"ccx63": 336.49,
+		"cax11": 4.49,
+		"cax21": 7.99,
+		"cax31": 15.99,
+		"cax41": 31.49,
+	}
+)
+
+// UnknownPriceError is returned by GetPriceForSize for a size missing from
+// the provider's price table, such as one added after the table was written
+// or offered only in some regions
+type UnknownPriceError struct {
+	Provider string
+	Size     string
+}
+
+func (e *UnknownPriceError) Error() string {
+	return fmt.Sprintf("no price known for size %q on %s", e.Size, e.Provider)
+}
+
+// monthlyPrice looks size up in a provider's price table
+func monthlyPrice(providerName string, prices map[string]float64, size string) (float64, error) {
+	if size == "" {
+		return 0, fmt.Errorf("cannot price a %s node without a size", providerName)
+	}
+	price, ok := prices[size]
+	if !ok {
+		return 0, &UnknownPriceError{Provider: providerName, Size: size}
+	}
+	return price, nil
+}
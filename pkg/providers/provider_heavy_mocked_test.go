@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -662,9 +663,15 @@ func (m *mockProvider) CreateFirewall(ctx *pulumi.Context, firewall *config.Fire
 func (m *mockProvider) CreateLoadBalancer(ctx *pulumi.Context, lb *config.LoadBalancerConfig) (*LoadBalancerOutput, error) {
 	return nil, nil
 }
-func (m *mockProvider) GetRegions() []string              { return []string{} }
-func (m *mockProvider) GetSizes() []string                { return []string{} }
-func (m *mockProvider) Cleanup(ctx *pulumi.Context) error { return nil }
+func (m *mockProvider) GetRegions() []string { return []string{} }
+func (m *mockProvider) GetSizes() []string   { return []string{} }
+func (m *mockProvider) ResizeNode(ctx context.Context, node *NodeOutput, newSize string) error {
+	return nil
+}
+func (m *mockProvider) DestroyNode(ctx context.Context, node *NodeOutput) error { return nil }
+func (m *mockProvider) RebootNode(ctx context.Context, node *NodeOutput) error  { return nil }
+func (m *mockProvider) GetPriceForSize(size, region string) (float64, error)    { return 0, nil }
+func (m *mockProvider) Cleanup(ctx *pulumi.Context) error                       { return nil }
 
 // TestNodePoolCreation_Mocked tests node pool creation validation
 func TestNodePoolCreation_Mocked(t *testing.T) {
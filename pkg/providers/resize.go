@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	// resizePollInterval is how often a pending resize is polled
+	resizePollInterval = 5 * time.Second
+
+	// resizeTimeout bounds each power or resize step
+	resizeTimeout = 15 * time.Minute
+)
+
+// ValidateNodeSize checks that size is one of the sizes the provider offers
+func ValidateNodeSize(p Provider, size string) error {
+	if size == "" {
+		return fmt.Errorf("a new size is required")
+	}
+	sizes := p.GetSizes()
+	for _, s := range sizes {
+		if s == size {
+			return nil
+		}
+	}
+	return fmt.Errorf("size %q is not available on %s (available: %v)", size, p.GetName(), sizes)
+}
+
+// waitFor polls check until it reports done or the resize timeout expires
+func waitFor(ctx context.Context, what string, check func(ctx context.Context) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, resizeTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(resizePollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", what, err)
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s", what)
+		case <-ticker.C:
+		}
+	}
+}
+
+// tokenOrEnv returns the configured API token, falling back to the
+// environment when the provider was not initialized from a cluster config
+func tokenOrEnv(token, envKey string) string {
+	if token != "" {
+		return token
+	}
+	return os.Getenv(envKey)
+}
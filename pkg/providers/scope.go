@@ -0,0 +1,27 @@
+package providers
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
+)
+
+// scopedName appends a provider instance's scope to the name of a resource
+// the instance creates once, such as its SSH key, network or firewall. The
+// default instance has no scope and keeps its names.
+func scopedName(name, scope string) string {
+	if scope == "" {
+		return name
+	}
+	return fmt.Sprintf("%s-%s", name, scope)
+}
+
+// withProvider adds the instance's explicit Pulumi provider to opts. The
+// default instance has none and keeps using the default provider, so its
+// resources are not replaced.
+func withProvider(provider pulumi.ProviderResource, opts ...pulumi.ResourceOption) []pulumi.ResourceOption {
+	if provider == nil {
+		return opts
+	}
+	return append(opts, pulumi.Provider(provider))
+}
@@ -3,7 +3,9 @@ package upgrades
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,7 +27,11 @@ type Orchestrator struct {
 	healthChecker HealthChecker
 	eventEmitter  provisioning.EventEmitter
 
+	// currentVersion is what the nodes run before the upgrade
+	currentVersion string
+
 	// State
+	nodes         map[string]*providers.NodeOutput // the nodes given to Plan
 	currentPlan   *provisioning.UpgradePlan
 	currentStatus *provisioning.UpgradeStatus
 	isRunning     bool
@@ -40,14 +46,32 @@ type Orchestrator struct {
 type OrchestratorConfig struct {
 	UpgradeConfig *config.UpgradeConfig
 	Drainer       provisioning.NodeDrainer
+	NodeUpgrader  NodeUpgrader
 	HealthChecker HealthChecker
 	EventEmitter  provisioning.EventEmitter
+
+	// CurrentVersion is the version the nodes run before the upgrade, and
+	// the version a rollback returns them to
+	CurrentVersion string
 }
 
 // NewOrchestrator creates a new upgrade orchestrator
 func NewOrchestrator(cfg *OrchestratorConfig) *Orchestrator {
 	registry := NewStrategyRegistry()
 
+	// The rolling and canary strategies drain and upgrade nodes through the
+	// configured drainer and upgrader
+	drainTimeout := 0
+	if cfg.UpgradeConfig != nil {
+		drainTimeout = cfg.UpgradeConfig.DrainTimeout
+	}
+	rolling := NewRollingStrategy(cfg.Drainer, cfg.NodeUpgrader)
+	rolling.SetDrainTimeout(drainTimeout)
+	registry.Register(rolling)
+	canary := NewCanaryStrategy(cfg.Drainer, cfg.NodeUpgrader)
+	canary.SetDrainTimeout(drainTimeout)
+	registry.Register(canary)
+
 	strategyName := "rolling"
 	if cfg.UpgradeConfig != nil && cfg.UpgradeConfig.Strategy != "" {
 		strategyName = cfg.UpgradeConfig.Strategy
@@ -68,10 +92,13 @@ func NewOrchestrator(cfg *OrchestratorConfig) *Orchestrator {
 		pauseChan:     make(chan struct{}),
 		resumeChan:    make(chan struct{}),
 		stopChan:      make(chan struct{}),
+
+		currentVersion: cfg.CurrentVersion,
 	}
 }
 
-// Plan creates an upgrade plan
+// Plan creates an upgrade plan. Masters come first, each in a batch of its
+// own; workers follow in batches the strategy sizes from MaxUnavailable.
 func (o *Orchestrator) Plan(ctx context.Context, targetVersion string, nodes []*providers.NodeOutput) (*provisioning.UpgradePlan, error) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
@@ -88,22 +115,45 @@ func (o *Orchestrator) Plan(ctx context.Context, targetVersion string, nodes []*
 		maxUnavailable = o.config.MaxUnavailable
 	}
 
-	batchSize := o.strategy.GetBatchSize(len(nodes), maxUnavailable)
+	var masters, workers []*providers.NodeOutput
+	for _, node := range nodes {
+		if isMasterNode(node) {
+			masters = append(masters, node)
+		} else {
+			workers = append(workers, node)
+		}
+	}
+
+	batchSize := o.strategy.GetBatchSize(len(workers), maxUnavailable)
+	if batchSize < 1 {
+		batchSize = 1
+	}
 
 	// Create node plans
 	nodePlans := make([]provisioning.UpgradeNodePlan, 0, len(nodes))
-	batch := 0
-	for i, node := range nodes {
-		if i > 0 && i%batchSize == 0 {
-			batch++
-		}
+	o.nodes = make(map[string]*providers.NodeOutput, len(nodes))
+	addNode := func(node *providers.NodeOutput, batch int) {
 		nodePlans = append(nodePlans, provisioning.UpgradeNodePlan{
 			NodeName: node.Name,
 			NodeID:   node.Name, // Will be populated with actual ID
-			Order:    i,
+			Order:    len(nodePlans),
 			Batch:    batch,
 			Status:   "pending",
 		})
+		o.nodes[node.Name] = node
+	}
+
+	// A control plane upgraded one master at a time keeps its quorum
+	batch := 0
+	for _, node := range masters {
+		addNode(node, batch)
+		batch++
+	}
+	for i, node := range workers {
+		if i > 0 && i%batchSize == 0 {
+			batch++
+		}
+		addNode(node, batch)
 	}
 
 	plan := &provisioning.UpgradePlan{
@@ -196,7 +246,7 @@ func (o *Orchestrator) Execute(ctx context.Context, plan *provisioning.UpgradePl
 
 		// Process batch
 		if err := o.processBatch(ctx, nodes, plan.TargetVersion); err != nil {
-			if o.config.PauseOnFailure {
+			if o.config != nil && o.config.PauseOnFailure {
 				o.updateStatus("paused_on_failure", err.Error())
 				o.emitEvent("upgrade_paused_on_failure", map[string]interface{}{
 					"plan_id": plan.ID,
@@ -205,7 +255,7 @@ func (o *Orchestrator) Execute(ctx context.Context, plan *provisioning.UpgradePl
 				return err
 			}
 
-			if o.config.AutoRollback {
+			if o.config != nil && o.config.AutoRollback {
 				o.emitEvent("upgrade_auto_rollback_triggered", map[string]interface{}{
 					"plan_id": plan.ID,
 					"error":   err.Error(),
@@ -216,6 +266,7 @@ func (o *Orchestrator) Execute(ctx context.Context, plan *provisioning.UpgradePl
 				return fmt.Errorf("upgrade failed, rolled back: %w", err)
 			}
 
+			o.updateStatus("failed", err.Error())
 			return err
 		}
 
@@ -232,47 +283,57 @@ func (o *Orchestrator) Execute(ctx context.Context, plan *provisioning.UpgradePl
 	return nil
 }
 
-// processBatch upgrades a batch of nodes
+// processBatch upgrades the nodes of a batch side by side
 func (o *Orchestrator) processBatch(ctx context.Context, nodes []provisioning.UpgradeNodePlan, targetVersion string) error {
-	for _, nodePlan := range nodes {
-		o.updateCurrentNode(nodePlan.NodeName)
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, nodePlan := range nodes {
+		wg.Add(1)
+		go func(i int, nodePlan provisioning.UpgradeNodePlan) {
+			defer wg.Done()
+			errs[i] = o.processNode(ctx, nodePlan, targetVersion)
+		}(i, nodePlan)
+	}
+	wg.Wait()
 
-		// Create a mock node output for the strategy
-		node := &providers.NodeOutput{
-			Name: nodePlan.NodeName,
-		}
+	return errors.Join(errs...)
+}
 
-		// Prepare node (drain, etc.)
-		if err := o.strategy.PrepareNode(ctx, node); err != nil {
-			o.addFailedNode(nodePlan.NodeName)
-			return fmt.Errorf("failed to prepare node %s: %w", nodePlan.NodeName, err)
-		}
+// processNode upgrades a single node
+func (o *Orchestrator) processNode(ctx context.Context, nodePlan provisioning.UpgradeNodePlan, targetVersion string) error {
+	o.updateCurrentNode(nodePlan.NodeName)
+	node := o.planNode(nodePlan.NodeName)
 
-		// Upgrade node
-		if err := o.strategy.UpgradeNode(ctx, node, targetVersion); err != nil {
-			o.addFailedNode(nodePlan.NodeName)
-			return fmt.Errorf("failed to upgrade node %s: %w", nodePlan.NodeName, err)
-		}
+	// Prepare node (drain, etc.)
+	if err := o.strategy.PrepareNode(ctx, node); err != nil {
+		o.addFailedNode(nodePlan.NodeName)
+		return fmt.Errorf("failed to prepare node %s: %w", nodePlan.NodeName, err)
+	}
 
-		// Validate node
-		if err := o.strategy.ValidateNode(ctx, node); err != nil {
-			o.addFailedNode(nodePlan.NodeName)
-			return fmt.Errorf("validation failed for node %s: %w", nodePlan.NodeName, err)
-		}
+	// Upgrade node
+	if err := o.strategy.UpgradeNode(ctx, node, targetVersion); err != nil {
+		o.addFailedNode(nodePlan.NodeName)
+		return fmt.Errorf("failed to upgrade node %s: %w", nodePlan.NodeName, err)
+	}
 
-		// Wait for health check
-		if err := o.waitForNodeHealth(ctx, nodePlan.NodeName); err != nil {
-			o.addFailedNode(nodePlan.NodeName)
-			return fmt.Errorf("health check failed for node %s: %w", nodePlan.NodeName, err)
-		}
+	// Validate node
+	if err := o.strategy.ValidateNode(ctx, node); err != nil {
+		o.addFailedNode(nodePlan.NodeName)
+		return fmt.Errorf("validation failed for node %s: %w", nodePlan.NodeName, err)
+	}
 
-		o.addCompletedNode(nodePlan.NodeName)
-		o.emitEvent("node_upgraded", map[string]interface{}{
-			"node_name":      nodePlan.NodeName,
-			"target_version": targetVersion,
-		})
+	// Wait for health check
+	if err := o.waitForNodeHealth(ctx, nodePlan.NodeName); err != nil {
+		o.addFailedNode(nodePlan.NodeName)
+		return fmt.Errorf("health check failed for node %s: %w", nodePlan.NodeName, err)
 	}
 
+	o.addCompletedNode(nodePlan.NodeName)
+	o.emitEvent("node_upgraded", map[string]interface{}{
+		"node_name":      nodePlan.NodeName,
+		"target_version": targetVersion,
+	})
+
 	return nil
 }
 
@@ -289,10 +350,7 @@ func (o *Orchestrator) Rollback(ctx context.Context, plan *provisioning.UpgradeP
 	// Rollback upgraded nodes in reverse order
 	for i := len(o.currentStatus.CompletedNodes) - 1; i >= 0; i-- {
 		nodeName := o.currentStatus.CompletedNodes[i]
-
-		node := &providers.NodeOutput{
-			Name: nodeName,
-		}
+		node := o.planNode(nodeName)
 
 		// Prepare for rollback
 		if err := o.strategy.PrepareNode(ctx, node); err != nil {
@@ -400,10 +458,37 @@ func (o *Orchestrator) SetStrategy(name string) error {
 // Helper methods
 
 func (o *Orchestrator) getCurrentVersion(nodes []*providers.NodeOutput) string {
+	if o.currentVersion != "" {
+		return o.currentVersion
+	}
 	// In a real implementation, this would query actual node versions
 	return "v1.28.0"
 }
 
+// planNode returns a copy of the node Plan was given under name, or a node
+// carrying only the name for plans made elsewhere
+func (o *Orchestrator) planNode(name string) *providers.NodeOutput {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if node, ok := o.nodes[name]; ok {
+		copied := *node
+		return &copied
+	}
+	return &providers.NodeOutput{Name: name}
+}
+
+// isMasterNode reports whether a node's role label names the control plane
+func isMasterNode(node *providers.NodeOutput) bool {
+	for _, role := range strings.Split(node.Labels["role"], ",") {
+		switch role {
+		case "master", "controlplane", "control-plane":
+			return true
+		}
+	}
+	return false
+}
+
 func (o *Orchestrator) estimateTime(nodeCount, batchSize int) int {
 	// Estimate ~5 minutes per node for drain + upgrade + validation
 	nodesPerBatch := batchSize
@@ -3,6 +3,8 @@ package upgrades
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -737,6 +739,23 @@ func TestKubectlDrainer_Uncordon(t *testing.T) {
 	assert.Contains(t, call, "test-node")
 }
 
+func TestKubectlHealthChecker_IsNodeHealthy(t *testing.T) {
+	executor := &MockCommandExecutor{output: "True"}
+	checker := NewKubectlHealthChecker("/path/to/kubeconfig", executor)
+
+	healthy, err := checker.IsNodeHealthy(context.Background(), "test-node")
+	require.NoError(t, err)
+	assert.True(t, healthy)
+
+	call := executor.calls[0]
+	assert.Equal(t, []string{"kubectl", "--kubeconfig", "/path/to/kubeconfig", "get", "node", "test-node"}, call[:6])
+
+	executor.output = "False"
+	healthy, err = checker.IsNodeHealthy(context.Background(), "test-node")
+	require.NoError(t, err)
+	assert.False(t, healthy)
+}
+
 // =============================================================================
 // Integration-like Tests
 // =============================================================================
@@ -805,3 +824,162 @@ func TestOrchestrator_MultipleStrategies(t *testing.T) {
 		assert.Equal(t, strategyName, orchestrator.strategy.Name())
 	}
 }
+
+// recordingUpgrade records the drains and upgrades of an upgrade run in
+// parallel batches, failing the upgrade of the nodes in fail
+type recordingUpgrade struct {
+	mu            sync.Mutex
+	drains        []string
+	drainTimeouts []int
+	upgrades      []string
+	roles         map[string]string
+	fail          map[string]bool
+}
+
+func (r *recordingUpgrade) Drain(ctx context.Context, nodeName string, timeout int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.drains = append(r.drains, nodeName)
+	r.drainTimeouts = append(r.drainTimeouts, timeout)
+	return nil
+}
+
+func (r *recordingUpgrade) Cordon(ctx context.Context, nodeName string) error { return nil }
+
+func (r *recordingUpgrade) Uncordon(ctx context.Context, nodeName string) error { return nil }
+
+func (r *recordingUpgrade) Upgrade(ctx context.Context, node *providers.NodeOutput, targetVersion string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.upgrades = append(r.upgrades, node.Name+"@"+targetVersion)
+	if r.roles == nil {
+		r.roles = make(map[string]string)
+	}
+	r.roles[node.Name] = node.Labels["role"]
+	if r.fail[node.Name] && targetVersion == "v1.29.0" {
+		return errors.New("install failed")
+	}
+	return nil
+}
+
+func upgradeTestNodes() []*providers.NodeOutput {
+	return []*providers.NodeOutput{
+		{Name: "worker-1", Labels: map[string]string{"role": "worker"}},
+		{Name: "master-1", Labels: map[string]string{"role": "master"}},
+		{Name: "worker-2", Labels: map[string]string{"role": "worker"}},
+		{Name: "master-2", Labels: map[string]string{"role": "controlplane"}},
+		{Name: "worker-3", Labels: map[string]string{"role": "worker"}},
+		{Name: "worker-4", Labels: map[string]string{"role": "worker"}},
+	}
+}
+
+func TestOrchestrator_Plan_MastersFirstOneAtATime(t *testing.T) {
+	orchestrator := NewOrchestrator(&OrchestratorConfig{
+		UpgradeConfig: &config.UpgradeConfig{MaxUnavailable: 2},
+	})
+
+	// Eight workers, so the rolling cap of a quarter of them allows two at a time
+	nodes := upgradeTestNodes()
+	for i := 5; i <= 8; i++ {
+		nodes = append(nodes, &providers.NodeOutput{Name: fmt.Sprintf("worker-%d", i)})
+	}
+
+	plan, err := orchestrator.Plan(context.Background(), "v1.29.0", nodes)
+	require.NoError(t, err)
+
+	var order []string
+	var batches []int
+	for _, node := range plan.Nodes {
+		order = append(order, node.NodeName)
+		batches = append(batches, node.Batch)
+	}
+	assert.Equal(t, []string{"master-1", "master-2", "worker-1", "worker-2", "worker-3", "worker-4",
+		"worker-5", "worker-6", "worker-7", "worker-8"}, order)
+	assert.Equal(t, []int{0, 1, 2, 2, 3, 3, 4, 4, 5, 5}, batches)
+}
+
+func TestOrchestrator_Execute_UsesUpgradeConfig(t *testing.T) {
+	recorder := &recordingUpgrade{}
+	orchestrator := NewOrchestrator(&OrchestratorConfig{
+		UpgradeConfig:  &config.UpgradeConfig{Strategy: "rolling", MaxUnavailable: 2, DrainTimeout: 120},
+		Drainer:        recorder,
+		NodeUpgrader:   recorder,
+		CurrentVersion: "v1.28.5",
+	})
+
+	plan, err := orchestrator.Plan(context.Background(), "v1.29.0", upgradeTestNodes())
+	require.NoError(t, err)
+	assert.Equal(t, "v1.28.5", plan.CurrentVersion)
+
+	require.NoError(t, orchestrator.Execute(context.Background(), plan))
+
+	assert.Len(t, recorder.drains, 6)
+	assert.Equal(t, []string{"master-1", "master-2"}, recorder.drains[:2], "masters drain first, one at a time")
+	for _, timeout := range recorder.drainTimeouts {
+		assert.Equal(t, 120, timeout)
+	}
+	assert.Equal(t, "controlplane", recorder.roles["master-2"], "the upgrader gets the planned node")
+	assert.Contains(t, recorder.upgrades, "worker-4@v1.29.0")
+}
+
+func TestOrchestrator_Execute_AutoRollbackToCurrentVersion(t *testing.T) {
+	recorder := &recordingUpgrade{fail: map[string]bool{"worker-1": true}}
+	orchestrator := NewOrchestrator(&OrchestratorConfig{
+		UpgradeConfig:  &config.UpgradeConfig{MaxUnavailable: 1, AutoRollback: true},
+		Drainer:        recorder,
+		NodeUpgrader:   recorder,
+		CurrentVersion: "v1.28.5",
+	})
+
+	plan, err := orchestrator.Plan(context.Background(), "v1.29.0", upgradeTestNodes())
+	require.NoError(t, err)
+
+	err = orchestrator.Execute(context.Background(), plan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rolled back")
+	assert.Contains(t, err.Error(), "failed to upgrade node worker-1")
+
+	assert.Equal(t, []string{
+		"master-1@v1.29.0", "master-2@v1.29.0", "worker-1@v1.29.0",
+		"master-2@v1.28.5", "master-1@v1.28.5",
+	}, recorder.upgrades, "upgraded nodes go back to the current version; later batches are untouched")
+
+	status, _ := orchestrator.GetStatus(context.Background())
+	assert.Equal(t, "rolled_back", status.Phase)
+	assert.Equal(t, []string{"worker-1"}, status.FailedNodes)
+}
+
+func TestOrchestrator_Execute_PauseOnFailure(t *testing.T) {
+	recorder := &recordingUpgrade{fail: map[string]bool{"master-1": true}}
+	orchestrator := NewOrchestrator(&OrchestratorConfig{
+		UpgradeConfig: &config.UpgradeConfig{PauseOnFailure: true, AutoRollback: true},
+		Drainer:       recorder,
+		NodeUpgrader:  recorder,
+	})
+
+	plan, err := orchestrator.Plan(context.Background(), "v1.29.0", upgradeTestNodes())
+	require.NoError(t, err)
+
+	require.Error(t, orchestrator.Execute(context.Background(), plan))
+	assert.Equal(t, []string{"master-1@v1.29.0"}, recorder.upgrades)
+
+	status, _ := orchestrator.GetStatus(context.Background())
+	assert.Equal(t, "paused_on_failure", status.Phase)
+}
+
+func TestOrchestrator_Execute_FailureWithoutPolicy(t *testing.T) {
+	recorder := &recordingUpgrade{fail: map[string]bool{"worker-3": true}}
+	orchestrator := NewOrchestrator(&OrchestratorConfig{
+		Drainer:      recorder,
+		NodeUpgrader: recorder,
+	})
+
+	plan, err := orchestrator.Plan(context.Background(), "v1.29.0", upgradeTestNodes())
+	require.NoError(t, err)
+
+	require.Error(t, orchestrator.Execute(context.Background(), plan))
+
+	status, _ := orchestrator.GetStatus(context.Background())
+	assert.Equal(t, "failed", status.Phase)
+	assert.NotContains(t, recorder.upgrades, "worker-4@v1.29.0")
+}
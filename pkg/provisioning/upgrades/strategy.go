@@ -3,6 +3,7 @@ package upgrades
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -80,6 +81,7 @@ type RollingStrategy struct {
 	drainer       provisioning.NodeDrainer
 	nodeUpgrader  NodeUpgrader
 	healthChecker HealthChecker
+	drainTimeout  int // seconds
 }
 
 // NodeUpgrader upgrades individual nodes
@@ -92,6 +94,14 @@ func NewRollingStrategy(drainer provisioning.NodeDrainer, upgrader NodeUpgrader)
 	return &RollingStrategy{
 		drainer:      drainer,
 		nodeUpgrader: upgrader,
+		drainTimeout: 300, // 5 minutes default
+	}
+}
+
+// SetDrainTimeout sets how many seconds a node drain may take
+func (s *RollingStrategy) SetDrainTimeout(seconds int) {
+	if seconds > 0 {
+		s.drainTimeout = seconds
 	}
 }
 
@@ -112,8 +122,7 @@ func (s *RollingStrategy) PrepareNode(ctx context.Context, node *providers.NodeO
 	}
 
 	// Drain workloads with timeout
-	timeout := 300 // 5 minutes default
-	if err := s.drainer.Drain(ctx, node.Name, timeout); err != nil {
+	if err := s.drainer.Drain(ctx, node.Name, s.drainTimeout); err != nil {
 		return fmt.Errorf("failed to drain node: %w", err)
 	}
 
@@ -257,6 +266,7 @@ type CanaryStrategy struct {
 	nodeUpgrader   NodeUpgrader
 	canaryPercent  int
 	validationTime time.Duration
+	drainTimeout   int // seconds
 }
 
 // NewCanaryStrategy creates a new canary strategy
@@ -266,6 +276,14 @@ func NewCanaryStrategy(drainer provisioning.NodeDrainer, upgrader NodeUpgrader)
 		nodeUpgrader:   upgrader,
 		canaryPercent:  10, // Default 10% canary
 		validationTime: 10 * time.Minute,
+		drainTimeout:   300,
+	}
+}
+
+// SetDrainTimeout sets how many seconds a node drain may take
+func (s *CanaryStrategy) SetDrainTimeout(seconds int) {
+	if seconds > 0 {
+		s.drainTimeout = seconds
 	}
 }
 
@@ -297,7 +315,7 @@ func (s *CanaryStrategy) PrepareNode(ctx context.Context, node *providers.NodeOu
 		return err
 	}
 
-	return s.drainer.Drain(ctx, node.Name, 300)
+	return s.drainer.Drain(ctx, node.Name, s.drainTimeout)
 }
 
 // UpgradeNode upgrades and then waits for validation
@@ -476,3 +494,38 @@ func (d *KubectlDrainer) Cordon(ctx context.Context, nodeName string) error {
 	_, err := d.executor.Execute(ctx, "kubectl", args...)
 	return err
 }
+
+// =============================================================================
+// Node Health Checker Implementation
+// =============================================================================
+
+// KubectlHealthChecker implements HealthChecker using kubectl
+type KubectlHealthChecker struct {
+	kubeconfigPath string
+	executor       CommandExecutor
+}
+
+// NewKubectlHealthChecker creates a new kubectl-based health checker
+func NewKubectlHealthChecker(kubeconfigPath string, executor CommandExecutor) *KubectlHealthChecker {
+	return &KubectlHealthChecker{
+		kubeconfigPath: kubeconfigPath,
+		executor:       executor,
+	}
+}
+
+// IsNodeHealthy reports whether the node's Ready condition is True
+func (c *KubectlHealthChecker) IsNodeHealthy(ctx context.Context, nodeName string) (bool, error) {
+	args := []string{"get", "node", nodeName,
+		"-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`,
+	}
+
+	if c.kubeconfigPath != "" {
+		args = append([]string{"--kubeconfig", c.kubeconfigPath}, args...)
+	}
+
+	output, err := c.executor.Execute(ctx, "kubectl", args...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(output) == "True", nil
+}
@@ -0,0 +1,238 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readCodewords reads the codewords back out of the code's data modules
+func readCodewords(c *Code) []byte {
+	c.applyMask(c.Mask)
+	defer c.applyMask(c.Mask)
+
+	codewords := make([]byte, rawDataModules(c.Version)/8)
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if upward {
+					y = c.Size - 1 - vert
+				}
+				if !c.isFunction[y][x] && i < len(codewords)*8 {
+					if c.modules[y][x] {
+						codewords[i>>3] |= 1 << (7 - i&7)
+					}
+					i++
+				}
+			}
+		}
+	}
+	return codewords
+}
+
+// decode de-interleaves the blocks, checks each block's error correction
+// and returns the byte mode payload
+func decode(t *testing.T, c *Code) []byte {
+	codewords := readCodewords(c)
+	numBlocks := numECCBlocks[c.Level][c.Version]
+	blockECCLen := eccCodewordsPerBlock[c.Level][c.Version]
+	numShortBlocks := numBlocks - len(codewords)%numBlocks
+	shortDataLen := len(codewords)/numBlocks - blockECCLen
+
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i < shortDataLen+1; i++ {
+		for j := range blocks {
+			if i < shortDataLen || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	for i := 0; i < blockECCLen; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[k])
+			k++
+		}
+	}
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	var data []byte
+	for _, block := range blocks {
+		require.Equal(t, make([]byte, blockECCLen), reedSolomonRemainder(block, divisor), "block is a Reed-Solomon codeword")
+		data = append(data, block[:len(block)-blockECCLen]...)
+	}
+
+	bit := func(i int) int { return int(data[i/8]>>(7-i%8)) & 1 }
+	read := func(pos, n int) int {
+		value := 0
+		for i := 0; i < n; i++ {
+			value = value<<1 | bit(pos+i)
+		}
+		return value
+	}
+	require.Equal(t, 0x4, read(0, 4), "byte mode indicator")
+	length := read(4, lengthBits(c.Version))
+	payload := make([]byte, length)
+	for i := range payload {
+		payload[i] = byte(read(4+lengthBits(c.Version)+i*8, 8))
+	}
+	return payload
+}
+
+// readFormatBits reads the format information next to the top left finder
+func readFormatBits(c *Code) int {
+	bits := 0
+	set := func(i int, dark bool) {
+		if dark {
+			bits |= 1 << i
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		set(i, c.modules[i][8])
+	}
+	set(6, c.modules[7][8])
+	set(7, c.modules[8][8])
+	set(8, c.modules[8][7])
+	for i := 9; i < 15; i++ {
+		set(i, c.modules[8][14-i])
+	}
+	return bits
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	config := `[Interface]
+PrivateKey = yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=
+Address = 10.8.0.100/24
+DNS = 1.1.1.1
+
+[Peer]
+PublicKey = xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=
+Endpoint = 203.0.113.10:51820
+AllowedIPs = 10.8.0.10/32, 10.0.0.0/8
+PersistentKeepalive = 25
+`
+	tests := []struct {
+		name    string
+		data    []byte
+		version int
+		level   Level
+	}{
+		{"short text", []byte("sloth"), 1, Medium},
+		{"low fits where medium does not", bytes.Repeat([]byte("a"), 17), 1, Low},
+		{"medium fits the same version", bytes.Repeat([]byte("b"), 80), 5, Medium},
+		{"wireguard config", []byte(config), 10, Low},
+		{"largest", bytes.Repeat([]byte{0xA5}, 2953), 40, Low},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(tt.data)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.version, code.Version)
+			assert.Equal(t, tt.level, code.Level)
+			assert.Equal(t, tt.version*4+17, code.Size)
+			assert.Equal(t, tt.data, decode(t, code))
+		})
+	}
+}
+
+func TestEncode_TooLarge(t *testing.T) {
+	_, err := Encode(make([]byte, 2954))
+	assert.EqualError(t, err, "data is 2954 bytes; a QR code holds at most 2953")
+}
+
+func TestEncode_FunctionPatterns(t *testing.T) {
+	code, err := Encode(bytes.Repeat([]byte("c"), 150))
+	require.NoError(t, err)
+	require.Equal(t, 7, code.Version)
+
+	// Finder pattern rows and the separator
+	for _, origin := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		row := ""
+		for x := 0; x < 7; x++ {
+			if code.Dark(origin[0]+x, origin[1]+2) {
+				row += "#"
+			} else {
+				row += "."
+			}
+		}
+		assert.Equal(t, "#.###.#", row)
+	}
+	assert.False(t, code.Dark(7, 0), "separator is light")
+
+	// Timing pattern
+	for i := 8; i < code.Size-8; i++ {
+		assert.Equal(t, i%2 == 0, code.Dark(i, 6))
+		assert.Equal(t, i%2 == 0, code.Dark(6, i))
+	}
+
+	// Version 7 information is 000111110010010100, least significant bit first
+	bits := 0
+	for i := 0; i < 18; i++ {
+		if code.Dark(code.Size-11+i%3, i/3) {
+			bits |= 1 << i
+		}
+	}
+	assert.Equal(t, 0x07C94, bits)
+}
+
+func TestDrawFormatBits(t *testing.T) {
+	code, err := Encode([]byte("sloth"))
+	require.NoError(t, err)
+
+	code.Level = Medium
+	code.drawFormatBits(0)
+	assert.Equal(t, 0x5412, readFormatBits(code))
+
+	code.Level = Low
+	code.drawFormatBits(0)
+	assert.Equal(t, 0x77C4, readFormatBits(code))
+	code.drawFormatBits(7)
+	assert.Equal(t, 0x6976, readFormatBits(code))
+}
+
+func TestTerminal(t *testing.T) {
+	code, err := Encode([]byte("sloth"))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(code.Terminal(), "\n"), "\n")
+	assert.Len(t, lines, (code.Size+2*QuietZone+1)/2)
+	for _, line := range lines {
+		assert.Equal(t, code.Size+2*QuietZone, strings.Count(line, "▀"))
+		assert.True(t, strings.HasSuffix(line, "\x1b[0m"), "each line resets the colours")
+	}
+	// The quiet zone row is light, the top left finder corner dark
+	assert.True(t, strings.HasPrefix(lines[0], "\x1b[97;107m▀"))
+	assert.Contains(t, lines[2], "\x1b[30;40m▀")
+}
+
+func TestWritePNG(t *testing.T) {
+	code, err := Encode([]byte("sloth"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.WritePNG(&buf, 8))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	width := (code.Size + 2*QuietZone) * 8
+	assert.Equal(t, width, img.Bounds().Dx())
+	assert.Equal(t, width, img.Bounds().Dy())
+
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xFFFF), r, "quiet zone is white")
+	r, _, _, _ = img.At(QuietZone*8, QuietZone*8).RGBA()
+	assert.Equal(t, uint32(0), r, "finder corner is black")
+}
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
@@ -517,6 +518,31 @@ func TestWireGuardManager_generateNodeConfig(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestWireGuardManager_generateNodeConfig_Keepalive tests keepalive defaults behind NAT
+func TestWireGuardManager_generateNodeConfig_Keepalive(t *testing.T) {
+	wgConfig := &config.WireGuardConfig{
+		Enabled:         true,
+		Port:            51820,
+		ServerPublicKey: "SERVER_PUBLIC_KEY",
+		ServerEndpoint:  "vpn.example.com",
+		AllowedIPs:      []string{"10.8.0.0/24"},
+		MeshNetworking:  true,
+	}
+	manager := NewWireGuardManager(nil, wgConfig)
+	manager.nodes = []*providers.NodeOutput{
+		{Name: "worker-1", WireGuardIP: "10.8.0.10"},
+		{Name: "worker-2", WireGuardIP: "10.8.0.11"},
+	}
+
+	assert.NotContains(t, manager.generateNodeConfig(manager.nodes[0]), "PersistentKeepalive", "nodes with public endpoints need no keepalive")
+
+	manager.SetNodesBehindNAT(true)
+	assert.Equal(t, 2, strings.Count(manager.generateNodeConfig(manager.nodes[0]), "PersistentKeepalive = 25"), "server and mesh peers")
+
+	wgConfig.PersistentKeepalive = 15
+	assert.Equal(t, 2, strings.Count(manager.generateNodeConfig(manager.nodes[0]), "PersistentKeepalive = 15"))
+}
+
 // TestWireGuardManager_generateServerPeerConfig tests server peer config generation
 func TestWireGuardManager_generateServerPeerConfig(t *testing.T) {
 	err := pulumi.RunErr(func(ctx *pulumi.Context) error {
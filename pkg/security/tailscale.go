@@ -2,11 +2,13 @@ package security
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
 	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn/tailscale"
 	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -18,6 +20,7 @@ type TailscaleManager struct {
 	nodes            []*providers.NodeOutput
 	headscaleURL     pulumi.StringOutput
 	authKey          pulumi.StringOutput
+	apiKey           pulumi.StringOutput
 	sshPrivateKey    string
 	headscaleReady   pulumi.Resource
 	headscaleInfoSet bool // Track if headscale info was dynamically set
@@ -40,6 +43,31 @@ func (t *TailscaleManager) SetHeadscaleInfo(url, authKey pulumi.StringOutput, re
 	t.headscaleInfoSet = true
 }
 
+// SetHeadscaleAPIKey sets the API key of a provisioned Headscale server
+func (t *TailscaleManager) SetHeadscaleAPIKey(apiKey pulumi.StringOutput) {
+	t.apiKey = apiKey
+}
+
+// HeadscaleURL returns the URL of the Headscale server: the provisioned
+// server's, which resolves with its host, or else the configured one
+func (t *TailscaleManager) HeadscaleURL() pulumi.StringOutput {
+	if t.headscaleInfoSet {
+		return t.headscaleURL
+	}
+	return pulumi.String(t.config.HeadscaleURL).ToStringOutput()
+}
+
+// HeadscaleAPI returns the URL and API key of the Headscale API, taken from
+// the provisioned server or else from the configuration
+func (t *TailscaleManager) HeadscaleAPI() (url, apiKey pulumi.StringOutput) {
+	url = t.HeadscaleURL()
+	apiKey = pulumi.String(t.config.APIKey).ToStringOutput()
+	if t.config.APIKey == "" && t.apiKey.OutputState != nil {
+		apiKey = t.apiKey
+	}
+	return url, apiKey
+}
+
 // SetSSHPrivateKey sets the SSH private key for connecting to nodes
 func (t *TailscaleManager) SetSSHPrivateKey(key string) {
 	t.sshPrivateKey = key
@@ -97,14 +125,11 @@ func (t *TailscaleManager) generateNodeConfigScript(node *providers.NodeOutput)
 	}
 
 	// Build the script with dynamic values
-	return pulumi.All(t.headscaleURL, t.authKey).ApplyT(func(args []interface{}) string {
+	return pulumi.All(t.HeadscaleURL(), t.authKey).ApplyT(func(args []interface{}) string {
 		headscaleURL := args[0].(string)
 		authKey := args[1].(string)
 
 		// If config has static values, use them
-		if headscaleURL == "" && t.config.HeadscaleURL != "" {
-			headscaleURL = t.config.HeadscaleURL
-		}
 		if authKey == "" && t.config.AuthKey != "" {
 			authKey = t.config.AuthKey
 		}
@@ -128,10 +153,12 @@ func (t *TailscaleManager) generateNodeConfigScript(node *providers.NodeOutput)
 			acceptRoutesArg = "--accept-routes"
 		}
 
-		// Build exit-node argument
+		// The designated exit node forwards traffic and offers itself to clients
+		exitNodeSetup := ""
 		exitNodeArg := ""
-		if t.config.ExitNode != "" {
-			exitNodeArg = fmt.Sprintf("--exit-node=%s", t.config.ExitNode)
+		if t.config.ExitNode != "" && t.config.ExitNode == node.Name {
+			exitNodeSetup = tailscale.ExitNodeForwardingScript
+			exitNodeArg = "--advertise-exit-node"
 		}
 
 		return fmt.Sprintf(`#!/bin/bash
@@ -179,7 +206,7 @@ fi
 echo "Joining Headscale coordination server..."
 echo "Server URL: %s"
 echo "Hostname: %s"
-
+%s
 # Join the tailnet via Headscale
 tailscale up \
     --login-server=%s \
@@ -216,6 +243,7 @@ echo "=== Tailscale Configuration Complete on %s ==="
 			headscaleURL,
 			headscaleURL,
 			node.Name,
+			exitNodeSetup,
 			headscaleURL,
 			authKey,
 			node.Name,
@@ -254,10 +282,8 @@ func (t *TailscaleManager) ExportTailscaleInfo() {
 	secrets.Export(t.ctx, "tailscale_configured", pulumi.Bool(t.config.Enabled))
 
 	if t.config.Enabled {
-		if t.headscaleInfoSet {
-			secrets.Export(t.ctx, "headscale_url", t.headscaleURL)
-		} else if t.config.HeadscaleURL != "" {
-			secrets.Export(t.ctx, "headscale_url", pulumi.String(t.config.HeadscaleURL))
+		if t.headscaleInfoSet || t.config.HeadscaleURL != "" {
+			secrets.Export(t.ctx, "headscale_url", t.HeadscaleURL())
 		}
 
 		namespace := t.config.Namespace
@@ -325,3 +351,73 @@ func (t *TailscaleManager) CreateHeadscaleServerIfNeeded(sshKeyPair interface{},
 	headscaleMgr := vpn.NewHeadscaleManager(t.ctx)
 	return headscaleMgr.CreateHeadscaleServer(t.config, nil, nil, subnetID)
 }
+
+// ProvisionHeadscaleOnNode installs a Headscale server on an existing node,
+// usually the first master, and mints a reusable pre-auth key for the
+// configured namespace. The result's AuthKey holds the minted key.
+func (t *TailscaleManager) ProvisionHeadscaleOnNode(node *providers.NodeOutput) (*vpn.HeadscaleResult, error) {
+	namespace := t.config.Namespace
+	if namespace == "" {
+		namespace = "kubernetes"
+	}
+
+	headscaleMgr := vpn.NewHeadscaleManager(t.ctx)
+	apiURL := node.PublicIP.ApplyT(func(ip string) string {
+		return vpn.HeadscaleServerURL(ip, t.config.Domain)
+	}).(pulumi.StringOutput)
+	installScript := apiURL.ApplyT(func(url string) string {
+		return headscaleMgr.GenerateHeadscaleNodeInstallScript(namespace, url)
+	}).(pulumi.StringOutput)
+
+	installCmd, err := remote.NewCommand(t.ctx, fmt.Sprintf("%s-headscale-server", node.Name), &remote.CommandArgs{
+		Connection: &remote.ConnectionArgs{
+			Host:       node.PublicIP,
+			Port:       pulumi.Float64(22),
+			User:       pulumi.String(node.SSHUser),
+			PrivateKey: pulumi.String(t.sshPrivateKey),
+		},
+		Create: installScript,
+		Update: installScript,
+		Delete: pulumi.String(`#!/bin/bash
+systemctl stop headscale || true
+systemctl disable headscale || true
+rm -f /root/headscale-auth-key /root/headscale-url
+echo "Headscale removed"
+`),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to install Headscale on %s: %w", node.Name, err)
+	}
+
+	authKey := pulumi.ToSecret(installCmd.Stdout.ApplyT(vpn.ParseHeadscaleAuthKey)).(pulumi.StringOutput)
+
+	// The ACL policy is uploaded through the API, which needs the server's key
+	var apiKey pulumi.StringOutput
+	if t.config.ACLPolicy != "" {
+		fetchAPIKeyCmd, err := remote.NewCommand(t.ctx, fmt.Sprintf("%s-headscale-fetch-apikey", node.Name), &remote.CommandArgs{
+			Connection: &remote.ConnectionArgs{
+				Host:       node.PublicIP,
+				Port:       pulumi.Float64(22),
+				User:       pulumi.String(node.SSHUser),
+				PrivateKey: pulumi.String(t.sshPrivateKey),
+			},
+			Create: pulumi.String(`cat /root/headscale-api-key`),
+		}, pulumi.DependsOn([]pulumi.Resource{installCmd}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the Headscale API key from %s: %w", node.Name, err)
+		}
+		apiKey = pulumi.ToSecret(fetchAPIKeyCmd.Stdout.ApplyT(strings.TrimSpace)).(pulumi.StringOutput)
+	}
+
+	return &vpn.HeadscaleResult{
+		Provider:   node.Provider,
+		ServerIP:   node.PublicIP,
+		ServerName: node.Name,
+		APIURL:     apiURL,
+		APIKey:     apiKey,
+		AuthKey:    authKey,
+		Namespace:  namespace,
+		Port:       8080,
+		Ready:      installCmd,
+	}, nil
+}
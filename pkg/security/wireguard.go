@@ -8,6 +8,7 @@ import (
 	"github.com/chalkan3/sloth-kubernetes/pkg/config"
 	"github.com/chalkan3/sloth-kubernetes/pkg/providers"
 	"github.com/chalkan3/sloth-kubernetes/pkg/secrets"
+	"github.com/chalkan3/sloth-kubernetes/pkg/vpn"
 	"github.com/pulumi/pulumi-command/sdk/go/command/remote"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 )
@@ -19,14 +20,21 @@ type WireGuardManager struct {
 	ctx        *pulumi.Context
 	privateKey pulumi.StringOutput
 	publicKey  pulumi.StringOutput
+
+	// presharedKeys holds one PSK per peer pair, keyed by the sorted pair names
+	presharedKeys map[string]string
+
+	// behindNAT is set when nodes lack a public endpoint of their own
+	behindNAT bool
 }
 
 // NewWireGuardManager creates a new WireGuard manager
 func NewWireGuardManager(ctx *pulumi.Context, config *config.WireGuardConfig) *WireGuardManager {
 	return &WireGuardManager{
-		ctx:    ctx,
-		config: config,
-		nodes:  make([]*providers.NodeOutput, 0),
+		ctx:           ctx,
+		config:        config,
+		nodes:         make([]*providers.NodeOutput, 0),
+		presharedKeys: make(map[string]string),
 	}
 }
 
@@ -69,7 +77,7 @@ cat > /etc/wireguard/wg0.conf << 'EOF'
 EOF
 
 chmod 600 /etc/wireguard/wg0.conf
-
+%s
 # Enable and start WireGuard
 systemctl enable wg-quick@wg0
 systemctl start wg-quick@wg0
@@ -78,7 +86,7 @@ systemctl start wg-quick@wg0
 wg show
 
 echo "WireGuard configured successfully on %s"
-`, configContent, node.Name)),
+`, configContent, sysctlScript(false), node.Name)),
 		Update: pulumi.String(fmt.Sprintf(`
 #!/bin/bash
 set -e
@@ -89,7 +97,7 @@ cat > /etc/wireguard/wg0.conf << 'EOF'
 EOF
 
 chmod 600 /etc/wireguard/wg0.conf
-
+%s
 # Restart WireGuard
 systemctl restart wg-quick@wg0
 
@@ -97,12 +105,13 @@ systemctl restart wg-quick@wg0
 wg show
 
 echo "WireGuard updated successfully on %s"
-`, configContent, node.Name)),
+`, configContent, sysctlScript(false), node.Name)),
 		Delete: pulumi.String(`
 #!/bin/bash
 systemctl stop wg-quick@wg0 || true
 systemctl disable wg-quick@wg0 || true
 rm -f /etc/wireguard/wg0.conf
+rm -f ` + wireGuardSysctlPath + `
 echo "WireGuard removed"
 `),
 	}, pulumi.DependsOn([]pulumi.Resource{}))
@@ -140,10 +149,9 @@ DNS = %s
 [Peer]
 # WireGuard Server
 PublicKey = %s
-Endpoint = %s:%d
+%sEndpoint = %s:%d
 AllowedIPs = %s
-PersistentKeepalive = %d
-`,
+%s`,
 		node.Name,
 		node.WireGuardIP,
 		privateKey,
@@ -151,10 +159,11 @@ PersistentKeepalive = %d
 		w.config.MTU,
 		strings.Join(w.config.DNS, ", "),
 		w.config.ServerPublicKey,
+		w.presharedKeyLine(node.Name, wireGuardServerPeer),
 		w.config.ServerEndpoint,
 		w.config.Port,
 		strings.Join(w.config.AllowedIPs, ", "),
-		w.config.PersistentKeepalive,
+		w.keepaliveLine(),
 	)
 
 	// Add peer configurations for mesh networking if enabled
@@ -183,22 +192,74 @@ func (w *WireGuardManager) generateMeshPeers(currentNode *providers.NodeOutput)
 [Peer]
 # Node: %s
 PublicKey = %s
-AllowedIPs = %s/32
+%sAllowedIPs = %s/32
 Endpoint = %s:%d
-PersistentKeepalive = %d
-`,
+%s`,
 			node.Name,
 			w.getNodePublicKey(node),
+			w.presharedKeyLine(currentNode.Name, node.Name),
 			node.WireGuardIP,
 			endpointIP,
 			w.config.Port,
-			w.config.PersistentKeepalive,
+			w.keepaliveLine(),
 		)
 	}
 
 	return peers
 }
 
+// wireGuardServerPeer is the pair name used for tunnels to the WireGuard server
+const wireGuardServerPeer = "wireguard-server"
+
+// presharedKey returns the PSK for the tunnel between peers a and b, generating
+// it on first use so both ends of the tunnel are rendered with the same key
+func (w *WireGuardManager) presharedKey(a, b string) (string, error) {
+	if a > b {
+		a, b = b, a
+	}
+	pair := a + "/" + b
+	if key, ok := w.presharedKeys[pair]; ok {
+		return key, nil
+	}
+	key, err := vpn.GeneratePresharedKey()
+	if err != nil {
+		return "", err
+	}
+	if w.presharedKeys == nil {
+		w.presharedKeys = make(map[string]string)
+	}
+	w.presharedKeys[pair] = key
+	return key, nil
+}
+
+// presharedKeyLine returns the PresharedKey line for a [Peer] block, or "" when PSKs are disabled
+func (w *WireGuardManager) presharedKeyLine(a, b string) string {
+	if !w.config.UsePresharedKeys {
+		return ""
+	}
+	key, err := w.presharedKey(a, b)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("PresharedKey = %s\n", key)
+}
+
+// SetNodesBehindNAT records whether the nodes lack a public endpoint, in which
+// case their peers keep the tunnels alive even when no keepalive is configured
+func (w *WireGuardManager) SetNodesBehindNAT(behindNAT bool) {
+	w.behindNAT = behindNAT
+}
+
+// keepaliveLine returns the PersistentKeepalive line for a [Peer] block, or ""
+// when no keepalive is needed
+func (w *WireGuardManager) keepaliveLine() string {
+	keepalive := w.config.Keepalive(w.behindNAT)
+	if keepalive == 0 {
+		return ""
+	}
+	return fmt.Sprintf("PersistentKeepalive = %d\n", keepalive)
+}
+
 // generatePrivateKey generates a private key for a node
 func (w *WireGuardManager) generatePrivateKey(node *providers.NodeOutput) string {
 	// In production, this would generate a unique key per node
@@ -222,6 +283,52 @@ func (w *WireGuardManager) getSSHPrivateKey() string {
 	return ""
 }
 
+// wireGuardSysctlPath is where the kernel settings WireGuard needs are persisted
+const wireGuardSysctlPath = "/etc/sysctl.d/99-wireguard.conf"
+
+// generateSysctlConfig returns the kernel settings needed for pod traffic to
+// cross the VPN. Hub nodes also forward between interfaces for their spokes.
+func generateSysctlConfig(hub bool) string {
+	settings := []string{
+		"net.ipv4.ip_forward = 1",
+		// Loose reverse-path filtering, so pod traffic routed over wg0 isn't dropped
+		"net.ipv4.conf.all.rp_filter = 2",
+		"net.ipv4.conf.default.rp_filter = 2",
+	}
+	if hub {
+		settings = append(settings,
+			"net.ipv4.conf.all.forwarding = 1",
+			"net.ipv4.conf.default.forwarding = 1",
+			"net.ipv6.conf.all.forwarding = 1",
+		)
+	}
+	return strings.Join(settings, "\n") + "\n"
+}
+
+// sysctlScript persists the WireGuard sysctls, applies them live and fails the
+// command if forwarding is not active, so the node is never reported VPN-ready without it
+func sysctlScript(hub bool) string {
+	check := "net.ipv4.ip_forward"
+	if hub {
+		check += " net.ipv4.conf.all.forwarding"
+	}
+
+	return fmt.Sprintf(`
+# Persist and apply kernel settings for routing over WireGuard
+cat > %s << 'SYSCTLEOF'
+%sSYSCTLEOF
+sysctl -p %s
+
+# Verify the settings took effect
+for key in %s; do
+    if [ "$(sysctl -n $key)" != "1" ]; then
+        echo "$key is not enabled" >&2
+        exit 1
+    fi
+done
+`, wireGuardSysctlPath, generateSysctlConfig(hub), wireGuardSysctlPath, check)
+}
+
 // ConfigureServerPeers configures peers on the WireGuard server
 func (w *WireGuardManager) ConfigureServerPeers() error {
 	if !w.config.Enabled || w.config.ServerEndpoint == "" {
@@ -252,12 +359,15 @@ cat >> /etc/wireguard/wg0.conf << 'EOF'
 # Kubernetes Cluster Nodes
 %s
 EOF
+%s
+# Forward traffic between spokes through the hub
+iptables -C FORWARD -i wg0 -o wg0 -j ACCEPT 2>/dev/null || iptables -A FORWARD -i wg0 -o wg0 -j ACCEPT
 
 # Reload WireGuard configuration
 wg syncconf wg0 <(wg-quick strip wg0)
 
 echo "Server peers configured successfully"
-`, serverPeers)),
+`, serverPeers, sysctlScript(true))),
 		Delete: pulumi.String(`
 #!/bin/bash
 # Restore backup configuration
@@ -285,10 +395,11 @@ func (w *WireGuardManager) generateServerPeerConfig() string {
 [Peer]
 # %s
 PublicKey = %s
-AllowedIPs = %s/32
+%sAllowedIPs = %s/32
 `,
 			node.Name,
 			w.getNodePublicKey(node),
+			w.presharedKeyLine(node.Name, wireGuardServerPeer),
 			node.WireGuardIP,
 		)
 	}
@@ -314,6 +425,15 @@ func (w *WireGuardManager) ValidateConfiguration() error {
 		return fmt.Errorf("WireGuard allowed IPs must be specified")
 	}
 
+	for _, peer := range w.config.Peers {
+		if peer.PresharedKey == "" {
+			continue
+		}
+		if err := vpn.ValidatePresharedKey(peer.PresharedKey); err != nil {
+			return fmt.Errorf("WireGuard peer %s: %w", peer.Name, err)
+		}
+	}
+
 	return nil
 }
 
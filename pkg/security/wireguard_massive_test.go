@@ -813,3 +813,71 @@ func TestWireGuardNodeNames(t *testing.T) {
 		})
 	}
 }
+
+// TestWireGuardManager_PresharedKeys tests that both ends of a tunnel get the same PSK
+func TestWireGuardManager_PresharedKeys(t *testing.T) {
+	cfg := &config.WireGuardConfig{
+		Enabled:          true,
+		ServerEndpoint:   "1.2.3.4",
+		ServerPublicKey:  "server-pubkey",
+		Port:             51820,
+		MeshNetworking:   true,
+		UsePresharedKeys: true,
+	}
+	manager := NewWireGuardManager(nil, cfg)
+
+	nodeA := &providers.NodeOutput{Name: "node-a", WireGuardIP: "10.8.0.10"}
+	nodeB := &providers.NodeOutput{Name: "node-b", WireGuardIP: "10.8.0.11"}
+	manager.nodes = append(manager.nodes, nodeA, nodeB)
+
+	configA := manager.generateNodeConfig(nodeA)
+	configB := manager.generateNodeConfig(nodeB)
+
+	if !strings.Contains(configA, manager.presharedKeyLine("node-a", "node-b")) {
+		t.Error("node-a config should contain the node-a/node-b PSK")
+	}
+	if !strings.Contains(configB, manager.presharedKeyLine("node-b", "node-a")) {
+		t.Error("node-b config should contain the same node-a/node-b PSK")
+	}
+
+	serverLine := manager.presharedKeyLine("node-a", wireGuardServerPeer)
+	if !strings.Contains(configA, serverLine) || !strings.Contains(manager.generateServerPeerConfig(), serverLine) {
+		t.Error("node and server should share the node/server PSK")
+	}
+
+	if got := strings.Count(configA, "PresharedKey = "); got != 2 {
+		t.Errorf("expected 2 PresharedKey lines in node-a config, got %d", got)
+	}
+
+	cfg.UsePresharedKeys = false
+	if strings.Contains(manager.generateNodeConfig(nodeA), "PresharedKey") {
+		t.Error("PSKs should not be emitted when disabled")
+	}
+}
+
+// TestWireGuardSysctlConfig tests the kernel settings pushed for routing over the VPN
+func TestWireGuardSysctlConfig(t *testing.T) {
+	node := generateSysctlConfig(false)
+	if !strings.Contains(node, "net.ipv4.ip_forward = 1") {
+		t.Error("nodes should enable IP forwarding")
+	}
+	if !strings.Contains(node, "net.ipv4.conf.all.rp_filter = 2") {
+		t.Error("nodes should use loose reverse-path filtering")
+	}
+	if strings.Contains(node, "net.ipv4.conf.all.forwarding") {
+		t.Error("only hubs should enable forwarding between interfaces")
+	}
+
+	hub := generateSysctlConfig(true)
+	if !strings.Contains(hub, "net.ipv4.conf.all.forwarding = 1") {
+		t.Error("hubs should enable forwarding between interfaces")
+	}
+
+	script := sysctlScript(true)
+	if !strings.Contains(script, "cat > "+wireGuardSysctlPath) || !strings.Contains(script, "sysctl -p "+wireGuardSysctlPath) {
+		t.Error("sysctls should be persisted and applied live")
+	}
+	if !strings.Contains(script, "for key in net.ipv4.ip_forward net.ipv4.conf.all.forwarding; do") || !strings.Contains(script, "exit 1") {
+		t.Error("script should fail when the settings did not take effect")
+	}
+}
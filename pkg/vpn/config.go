@@ -68,6 +68,13 @@ func (c *ConfigManager) ValidatePeerConfig(peer PeerConfig) error {
 		}
 	}
 
+	// Validate preshared key if provided
+	if peer.PresharedKey != "" {
+		if err := ValidatePresharedKey(peer.PresharedKey); err != nil {
+			return err
+		}
+	}
+
 	// Validate keepalive
 	if peer.Keepalive < 0 || peer.Keepalive > 65535 {
 		return fmt.Errorf("invalid keepalive value: %d (must be 0-65535)", peer.Keepalive)
@@ -168,6 +175,11 @@ func (c *ConfigManager) persistPeerToConfig(ctx context.Context, conn *SSHConnec
 	allowedIPs := strings.Join(peer.AllowedIPs, ", ")
 	keepalive := strconv.Itoa(peer.Keepalive)
 
+	pskLine := ""
+	if peer.PresharedKey != "" {
+		pskLine = "\nPresharedKey = " + peer.PresharedKey
+	}
+
 	// Build script that safely updates config (requires sudo for /etc/wireguard)
 	script := fmt.Sprintf(`#!/bin/bash
 set -e
@@ -191,7 +203,7 @@ sudo tee -a "$CONFIG" > /dev/null << 'PEEREOF'
 
 [Peer]
 # %s
-PublicKey = %s
+PublicKey = %s%s
 AllowedIPs = %s
 PersistentKeepalive = %s
 PEEREOF
@@ -205,7 +217,7 @@ else
     sudo mv "$BACKUP" "$CONFIG"
     exit 1
 fi
-`, c.configPath, peer.PublicKey, label, peer.PublicKey, allowedIPs, keepalive, c.interfaceName)
+`, c.configPath, peer.PublicKey, label, peer.PublicKey, pskLine, allowedIPs, keepalive, c.interfaceName)
 
 	output, err := conn.ExecuteScript(script)
 	if err != nil {
@@ -2,6 +2,7 @@ package vpn
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pulumi/pulumi-aws/sdk/v6/go/aws/ec2"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -31,6 +32,7 @@ type HeadscaleResult struct {
 	AuthKey    pulumi.StringOutput
 	Namespace  string
 	Port       int
+	Ready      pulumi.Resource // Resource to depend on before registering nodes
 }
 
 // CreateHeadscaleServer creates a Headscale coordination server
@@ -136,8 +138,7 @@ dns:
       - 8.8.8.8
 
 policy:
-  mode: file
-  path: ""
+  mode: database
 EOF
 
 # Create data directory
@@ -347,6 +348,119 @@ func (m *HeadscaleManager) createAWSHeadscale(cfg *config.TailscaleConfig, serve
 	}, nil
 }
 
+// HeadscaleServerURL returns the URL nodes use to reach a Headscale server
+// running on host. A configured domain takes the place of the host address.
+func HeadscaleServerURL(host, domain string) string {
+	if domain != "" {
+		host = domain
+	}
+	return fmt.Sprintf("http://%s:8080", host)
+}
+
+// GenerateHeadscaleNodeInstallScript renders a script that installs Headscale
+// on an existing node and serves it at serverURL. It is safe to re-run: the
+// binary, user, pre-auth key and API key are only created when missing. The
+// last line it prints is the pre-auth key (see ParseHeadscaleAuthKey).
+func (m *HeadscaleManager) GenerateHeadscaleNodeInstallScript(namespace, serverURL string) string {
+	return fmt.Sprintf(`#!/bin/bash
+set -eo pipefail
+
+if ! command -v headscale &> /dev/null; then
+    echo "Installing Headscale..."
+    apt-get update
+    apt-get install -y curl wget jq
+    HEADSCALE_VERSION=$(curl -s https://api.github.com/repos/juanfont/headscale/releases/latest | jq -r '.tag_name' | sed 's/v//')
+    ARCH=$(dpkg --print-architecture)
+    wget -q -O /tmp/headscale.deb "https://github.com/juanfont/headscale/releases/download/v${HEADSCALE_VERSION}/headscale_${HEADSCALE_VERSION}_linux_${ARCH}.deb"
+    dpkg -i /tmp/headscale.deb
+    rm -f /tmp/headscale.deb
+fi
+
+mkdir -p /etc/headscale /var/lib/headscale
+cat > /etc/headscale/config.yaml <<'CONFIG'
+server_url: %s
+listen_addr: 0.0.0.0:8080
+metrics_listen_addr: 127.0.0.1:9090
+grpc_listen_addr: 127.0.0.1:50443
+grpc_allow_insecure: false
+
+noise:
+  private_key_path: /var/lib/headscale/noise_private.key
+
+prefixes:
+  v4: 100.64.0.0/10
+  v6: fd7a:115c:a1e0::/48
+
+derp:
+  server:
+    enabled: false
+  urls:
+    - https://controlplane.tailscale.com/derpmap/default
+  auto_update_enabled: true
+  update_frequency: 24h
+
+disable_check_updates: true
+ephemeral_node_inactivity_timeout: 30m
+
+database:
+  type: sqlite
+  sqlite:
+    path: /var/lib/headscale/db.sqlite
+
+log:
+  format: text
+  level: info
+
+dns:
+  magic_dns: true
+  base_domain: headscale.local
+  nameservers:
+    global:
+      - 1.1.1.1
+      - 8.8.8.8
+
+policy:
+  mode: database
+CONFIG
+chown -R headscale:headscale /var/lib/headscale 2>/dev/null || true
+
+systemctl daemon-reload
+systemctl enable headscale
+systemctl restart headscale
+
+echo "Waiting for Headscale to start..."
+for i in $(seq 1 30); do
+    headscale users list &> /dev/null && break
+    sleep 2
+done
+
+# Older Headscale releases call users namespaces
+headscale users create %s 2>/dev/null || headscale namespaces create %s 2>/dev/null || true
+
+if [ ! -s /root/headscale-auth-key ]; then
+    AUTH_KEY=$(headscale preauthkeys create --user %s --reusable --expiration 720h 2>/dev/null | tail -1 || \
+        headscale preauthkeys create --namespace %s --reusable --expiration 720h | tail -1)
+    echo "$AUTH_KEY" > /root/headscale-auth-key
+    chmod 600 /root/headscale-auth-key
+fi
+if [ ! -s /root/headscale-api-key ]; then
+    headscale apikeys create --expiration 365d 2>/dev/null | tail -1 > /root/headscale-api-key
+    chmod 600 /root/headscale-api-key
+fi
+echo "%s" > /root/headscale-url
+
+echo "Headscale is serving at %s"
+cat /root/headscale-auth-key
+`, serverURL, namespace, namespace, namespace, namespace, serverURL, serverURL)
+}
+
+// ParseHeadscaleAuthKey returns the pre-auth key printed on the last line of
+// GenerateHeadscaleNodeInstallScript's output
+func ParseHeadscaleAuthKey(stdout string) string {
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
 // GetHeadscaleCredentials returns a command to retrieve Headscale credentials from the server
 func (m *HeadscaleManager) GetHeadscaleCredentials() string {
 	return `#!/bin/bash
@@ -0,0 +1,45 @@
+package vpn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadscaleServerURL(t *testing.T) {
+	if got := HeadscaleServerURL("203.0.113.10", ""); got != "http://203.0.113.10:8080" {
+		t.Errorf("expected the host address, got %s", got)
+	}
+	if got := HeadscaleServerURL("203.0.113.10", "hs.example.com"); got != "http://hs.example.com:8080" {
+		t.Errorf("expected the domain to replace the host, got %s", got)
+	}
+}
+
+func TestGenerateHeadscaleNodeInstallScript(t *testing.T) {
+	script := NewHeadscaleManager(nil).GenerateHeadscaleNodeInstallScript("prod", "http://hs.example.com:8080")
+
+	for _, want := range []string{
+		"server_url: http://hs.example.com:8080",
+		"systemctl restart headscale",
+		"headscale users create prod",
+		"preauthkeys create --user prod --reusable",
+		"apikeys create --expiration 365d",
+		"mode: database",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("expected script to contain %q", want)
+		}
+	}
+	if !strings.HasSuffix(script, "cat /root/headscale-auth-key\n") {
+		t.Error("expected the script to print the pre-auth key last")
+	}
+}
+
+func TestParseHeadscaleAuthKey(t *testing.T) {
+	stdout := "Installing Headscale...\nHeadscale is serving at http://10.0.0.1:8080\n0123456789abcdef\n\n"
+	if got := ParseHeadscaleAuthKey(stdout); got != "0123456789abcdef" {
+		t.Errorf("expected the last line, got %q", got)
+	}
+	if got := ParseHeadscaleAuthKey(""); got != "" {
+		t.Errorf("expected an empty key, got %q", got)
+	}
+}
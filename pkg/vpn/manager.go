@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
 )
 
 // Manager provides a high-level API for VPN operations
@@ -20,11 +22,12 @@ type Manager struct {
 // ManagerConfig holds configuration for the VPN manager
 type ManagerConfig struct {
 	SSHKeyPath     string
-	DataDir        string        // For peer registry persistence
-	RetryPolicy    *RetryPolicy  // Optional custom retry policy
-	ConnectTimeout time.Duration // SSH connection timeout
-	ProviderType   ProviderType  // VPN provider type (default: wireguard)
-	ProviderConfig interface{}   // Provider-specific configuration
+	DataDir        string               // For peer registry persistence
+	PeerStore      operations.LockStore // Shared peer registry store (default: local files in DataDir)
+	RetryPolicy    *RetryPolicy         // Optional custom retry policy
+	ConnectTimeout time.Duration        // SSH connection timeout
+	ProviderType   ProviderType         // VPN provider type (default: wireguard)
+	ProviderConfig interface{}          // Provider-specific configuration
 }
 
 // NewManager creates a new VPN Manager
@@ -50,7 +53,7 @@ func NewManager(cfg ManagerConfig) (*Manager, error) {
 		return nil, fmt.Errorf("failed to create connection manager: %w", err)
 	}
 
-	peerRegistry, err := NewPeerRegistry(cfg.DataDir)
+	peerRegistry, err := newManagerPeerRegistry(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create peer registry: %w", err)
 	}
@@ -106,7 +109,7 @@ func NewManagerWithProvider(cfg ManagerConfig, provider VPNProvider) (*Manager,
 		return nil, fmt.Errorf("failed to create connection manager: %w", err)
 	}
 
-	peerRegistry, err := NewPeerRegistry(cfg.DataDir)
+	peerRegistry, err := newManagerPeerRegistry(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create peer registry: %w", err)
 	}
@@ -130,6 +133,15 @@ func NewManagerWithProvider(cfg ManagerConfig, provider VPNProvider) (*Manager,
 	}, nil
 }
 
+// newManagerPeerRegistry opens the peer registry in cfg.PeerStore when set,
+// otherwise in local files
+func newManagerPeerRegistry(cfg ManagerConfig) (*PeerRegistry, error) {
+	if cfg.PeerStore != nil {
+		return NewBackendPeerRegistry(cfg.PeerStore, cfg.DataDir)
+	}
+	return NewPeerRegistry(cfg.DataDir)
+}
+
 // JoinConfig holds configuration for joining the VPN
 type JoinConfig struct {
 	StackName   string
@@ -141,6 +153,8 @@ type JoinConfig struct {
 	BastionUser string     // Bastion SSH user
 	SubnetCIDR  string     // VPN subnet for IP assignment (e.g., "10.8.0.0/24")
 	ReservedIPs []string   // IPs reserved for cluster nodes
+
+	UsePresharedKeys bool // Add a per-node preshared key to every tunnel
 }
 
 // JoinResult contains the result of a join operation
@@ -151,6 +165,7 @@ type JoinResult struct {
 	NodesFailed     int
 	Duration        time.Duration
 	Errors          []string
+	PresharedKeys   map[string]string // Node name -> PSK, for the client config
 }
 
 // Join adds a peer to the VPN mesh
@@ -188,16 +203,35 @@ func (m *Manager) Join(ctx context.Context, cfg JoinConfig) (*JoinResult, error)
 		}
 	}
 
-	// Build peer config
-	peer := PeerConfig{
-		PublicKey:  cfg.PublicKey,
-		AllowedIPs: []string{vpnIP + "/32"},
-		Keepalive:  25,
-		Label:      cfg.Label,
+	// Generate preshared keys, reusing any already registered for this peer
+	var presharedKeys map[string]string
+	if cfg.UsePresharedKeys {
+		var existing map[string]string
+		if registered, err := m.peerRegistry.GetByPublicKey(cfg.StackName, cfg.PublicKey); err == nil {
+			existing = registered.PresharedKeys
+		}
+		nodeNames := make([]string, 0, len(cfg.Nodes))
+		for _, node := range cfg.Nodes {
+			nodeNames = append(nodeNames, node.Name)
+		}
+		var err error
+		presharedKeys, err = EnsurePresharedKeys(nodeNames, existing)
+		if err != nil {
+			return nil, err
+		}
+		result.PresharedKeys = presharedKeys
 	}
 
 	// Add peer to all nodes
 	for _, node := range cfg.Nodes {
+		peer := PeerConfig{
+			PublicKey:    cfg.PublicKey,
+			AllowedIPs:   []string{vpnIP + "/32"},
+			Keepalive:    25,
+			Label:        cfg.Label,
+			PresharedKey: presharedKeys[node.Name],
+		}
+
 		connCfg := ConnectionConfig{
 			Host:        node.PublicIP,
 			User:        getSSHUserForProvider(node.Provider),
@@ -237,13 +271,14 @@ func (m *Manager) Join(ctx context.Context, cfg JoinConfig) (*JoinResult, error)
 
 	// Register peer in registry
 	registeredPeer := RegisteredPeer{
-		PublicKey:  cfg.PublicKey,
-		VPNIP:      vpnIP,
-		Label:      cfg.Label,
-		AllowedIPs: []string{vpnIP + "/32"},
+		PublicKey:     cfg.PublicKey,
+		VPNIP:         vpnIP,
+		Label:         cfg.Label,
+		AllowedIPs:    []string{vpnIP + "/32"},
+		PresharedKeys: presharedKeys,
 	}
 
-	if err := m.peerRegistry.Register(cfg.StackName, registeredPeer); err != nil {
+	if _, err := m.peerRegistry.Register(cfg.StackName, registeredPeer); err != nil {
 		result.Errors = append(result.Errors, fmt.Sprintf("registry: %v", err))
 	}
 
@@ -365,6 +400,27 @@ func (m *Manager) GetPeerByLabel(stackName, label string) (*RegisteredPeer, erro
 	return m.peerRegistry.GetByLabel(stackName, label)
 }
 
+// Register records a peer and returns the entries it supersedes (same label
+// or VPN IP, different key), whose keys are no longer in use
+func (m *Manager) Register(stackName string, peer RegisteredPeer) ([]RegisteredPeer, error) {
+	return m.peerRegistry.Register(stackName, peer)
+}
+
+// RemoveByIP removes the peer with the given VPN IP from the registry
+func (m *Manager) RemoveByIP(stackName, vpnIP string) (*RegisteredPeer, error) {
+	return m.peerRegistry.UnregisterByIP(stackName, vpnIP)
+}
+
+// ListStale returns the registered peers whose public key is not among
+// liveKeys, the peer keys currently configured on the nodes
+func (m *Manager) ListStale(stackName string, liveKeys []string) ([]RegisteredPeer, error) {
+	live := make(map[string]bool, len(liveKeys))
+	for _, key := range liveKeys {
+		live[key] = true
+	}
+	return m.peerRegistry.Stale(stackName, live)
+}
+
 // GetConnectionManager returns the underlying connection manager
 func (m *Manager) GetConnectionManager() *ConnectionManager {
 	return m.connMgr
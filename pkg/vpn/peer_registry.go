@@ -1,13 +1,17 @@
 package vpn
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
 )
 
 // RegisteredPeer represents a peer registered in the VPN
@@ -20,17 +24,57 @@ type RegisteredPeer struct {
 	LastSeen   time.Time `json:"lastSeen"`
 	Endpoint   string    `json:"endpoint,omitempty"` // Last known endpoint
 	AllowedIPs []string  `json:"allowedIPs,omitempty"`
+
+	// PresharedKeys maps cluster node name to the PSK shared with that node
+	PresharedKeys map[string]string `json:"presharedKeys,omitempty"`
 }
 
 // PeerRegistry manages peer persistence
 type PeerRegistry struct {
-	dataDir string
-	mu      sync.RWMutex
-	peers   map[string]map[string]RegisteredPeer // stackName -> publicKey -> peer
+	store  operations.LockStore // Where registry documents are kept
+	prefix string               // Key prefix of registry documents in store
+	legacy operations.LockStore // Local registry imported when store has none
+	mu     sync.RWMutex
+	peers  map[string]map[string]RegisteredPeer // stackName -> publicKey -> peer
 }
 
-// NewPeerRegistry creates a new PeerRegistry
+// NewPeerRegistry creates a PeerRegistry kept in local files under dataDir
 func NewPeerRegistry(dataDir string) (*PeerRegistry, error) {
+	store, err := localPeerStore(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &PeerRegistry{
+		store: store,
+		peers: make(map[string]map[string]RegisteredPeer),
+	}
+
+	return registry, nil
+}
+
+// NewBackendPeerRegistry creates a PeerRegistry kept in the stack backend's
+// store (see operations.NewLockStoreForBackend), so every machine managing the
+// stack shares it. A stack with no registry in the backend yet starts from the
+// local one under dataDir.
+func NewBackendPeerRegistry(store operations.LockStore, dataDir string) (*PeerRegistry, error) {
+	legacy, err := localPeerStore(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &PeerRegistry{
+		store:  store,
+		prefix: ".sloth/vpn/",
+		legacy: legacy,
+		peers:  make(map[string]map[string]RegisteredPeer),
+	}
+
+	return registry, nil
+}
+
+// localPeerStore returns the file store for dataDir, ~/.sloth-kubernetes/vpn by default
+func localPeerStore(dataDir string) (operations.LockStore, error) {
 	if dataDir == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
@@ -43,25 +87,23 @@ func NewPeerRegistry(dataDir string) (*PeerRegistry, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	registry := &PeerRegistry{
-		dataDir: dataDir,
-		peers:   make(map[string]map[string]RegisteredPeer),
-	}
-
-	return registry, nil
+	return &operations.FileLockStore{Dir: dataDir}, nil
 }
 
-// registryFilePath returns the path to the registry file for a stack
-func (r *PeerRegistry) registryFilePath(stackName string) string {
-	return filepath.Join(r.dataDir, fmt.Sprintf("%s-peers.json", stackName))
+// registryKey returns the store key of the registry document for a stack
+func registryKey(stackName string) string {
+	return fmt.Sprintf("%s-peers.json", stackName)
 }
 
-// loadStack loads peers for a specific stack from disk
+// loadStack loads peers for a specific stack from the store
 func (r *PeerRegistry) loadStack(stackName string) error {
-	filePath := r.registryFilePath(stackName)
+	ctx := context.Background()
 
-	data, err := os.ReadFile(filePath)
-	if os.IsNotExist(err) {
+	data, err := r.store.Read(ctx, r.prefix+registryKey(stackName))
+	if errors.Is(err, operations.ErrLockNotFound) && r.legacy != nil {
+		data, err = r.legacy.Read(ctx, registryKey(stackName))
+	}
+	if errors.Is(err, operations.ErrLockNotFound) {
 		r.peers[stackName] = make(map[string]RegisteredPeer)
 		return nil
 	}
@@ -100,8 +142,7 @@ func (r *PeerRegistry) saveStack(stackName string) error {
 		return fmt.Errorf("failed to marshal peers: %w", err)
 	}
 
-	filePath := r.registryFilePath(stackName)
-	if err := os.WriteFile(filePath, data, 0600); err != nil {
+	if err := r.store.Put(context.Background(), r.prefix+registryKey(stackName), data); err != nil {
 		return fmt.Errorf("failed to write registry file: %w", err)
 	}
 
@@ -116,13 +157,26 @@ func (r *PeerRegistry) ensureStackLoaded(stackName string) error {
 	return nil
 }
 
-// Register adds or updates a peer in the registry
-func (r *PeerRegistry) Register(stackName string, peer RegisteredPeer) error {
+// Register adds or updates a peer in the registry. Entries of other keys with
+// the same label or VPN IP belong to an earlier join of the same machine; they
+// are dropped and returned so their keys can be removed from the nodes.
+func (r *PeerRegistry) Register(stackName string, peer RegisteredPeer) ([]RegisteredPeer, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if err := r.ensureStackLoaded(stackName); err != nil {
-		return err
+		return nil, err
+	}
+
+	var superseded []RegisteredPeer
+	for key, existing := range r.peers[stackName] {
+		if key == peer.PublicKey {
+			continue
+		}
+		if existing.VPNIP == peer.VPNIP || (peer.Label != "" && existing.Label == peer.Label) {
+			superseded = append(superseded, existing)
+			delete(r.peers[stackName], key)
+		}
 	}
 
 	// Set timestamps
@@ -138,7 +192,7 @@ func (r *PeerRegistry) Register(stackName string, peer RegisteredPeer) error {
 
 	r.peers[stackName][peer.PublicKey] = peer
 
-	return r.saveStack(stackName)
+	return superseded, r.saveStack(stackName)
 }
 
 // Unregister removes a peer from the registry
@@ -155,6 +209,44 @@ func (r *PeerRegistry) Unregister(stackName string, publicKey string) error {
 	return r.saveStack(stackName)
 }
 
+// UnregisterByIP removes the peer with the given VPN IP and returns it
+func (r *PeerRegistry) UnregisterByIP(stackName string, vpnIP string) (*RegisteredPeer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.ensureStackLoaded(stackName); err != nil {
+		return nil, err
+	}
+
+	for key, peer := range r.peers[stackName] {
+		if peer.VPNIP == vpnIP {
+			delete(r.peers[stackName], key)
+			return &peer, r.saveStack(stackName)
+		}
+	}
+
+	return nil, fmt.Errorf("peer with IP '%s' not found", vpnIP)
+}
+
+// Stale returns the peers whose public key is not in liveKeys
+func (r *PeerRegistry) Stale(stackName string, liveKeys map[string]bool) ([]RegisteredPeer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if err := r.ensureStackLoaded(stackName); err != nil {
+		return nil, err
+	}
+
+	var stale []RegisteredPeer
+	for key, peer := range r.peers[stackName] {
+		if !liveKeys[key] {
+			stale = append(stale, peer)
+		}
+	}
+
+	return stale, nil
+}
+
 // GetByPublicKey retrieves a peer by public key
 func (r *PeerRegistry) GetByPublicKey(stackName string, publicKey string) (*RegisteredPeer, error) {
 	r.mu.RLock()
@@ -320,8 +412,7 @@ func (r *PeerRegistry) Clear(stackName string) error {
 	r.peers[stackName] = make(map[string]RegisteredPeer)
 
 	// Delete the file
-	filePath := r.registryFilePath(stackName)
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+	if err := r.store.Delete(context.Background(), r.prefix+registryKey(stackName)); err != nil {
 		return fmt.Errorf("failed to remove registry file: %w", err)
 	}
 
@@ -0,0 +1,118 @@
+package vpn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chalkan3/sloth-kubernetes/pkg/operations"
+)
+
+func TestBackendPeerRegistry_SharedAcrossInstances(t *testing.T) {
+	backend := &operations.FileLockStore{Dir: t.TempDir()}
+
+	first, err := NewBackendPeerRegistry(backend, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBackendPeerRegistry failed: %v", err)
+	}
+	if _, err := first.Register("prod", RegisteredPeer{PublicKey: "laptop-key", VPNIP: "10.8.0.100", Label: "laptop"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(backend.Dir, ".sloth", "vpn", "prod-peers.json")); err != nil {
+		t.Fatalf("registry should be stored in the backend: %v", err)
+	}
+
+	// Another machine with its own data dir sees the same registry
+	second, err := NewBackendPeerRegistry(backend, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBackendPeerRegistry failed: %v", err)
+	}
+	peer, err := second.GetByLabel("prod", "laptop")
+	if err != nil {
+		t.Fatalf("GetByLabel failed: %v", err)
+	}
+	if peer.VPNIP != "10.8.0.100" {
+		t.Errorf("expected 10.8.0.100, got %s", peer.VPNIP)
+	}
+}
+
+func TestBackendPeerRegistry_ImportsLocalRegistry(t *testing.T) {
+	dataDir := t.TempDir()
+	local, err := NewPeerRegistry(dataDir)
+	if err != nil {
+		t.Fatalf("NewPeerRegistry failed: %v", err)
+	}
+	if _, err := local.Register("prod", RegisteredPeer{PublicKey: "old-key", VPNIP: "10.8.0.101", Label: "desktop"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	registry, err := NewBackendPeerRegistry(&operations.FileLockStore{Dir: t.TempDir()}, dataDir)
+	if err != nil {
+		t.Fatalf("NewBackendPeerRegistry failed: %v", err)
+	}
+	if _, err := registry.GetByLabel("prod", "desktop"); err != nil {
+		t.Errorf("a stack without a backend registry should start from the local one: %v", err)
+	}
+}
+
+func TestPeerRegistry_RegisterSupersedesRejoin(t *testing.T) {
+	registry, err := NewPeerRegistry(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPeerRegistry failed: %v", err)
+	}
+
+	registry.Register("prod", RegisteredPeer{PublicKey: "key-1", VPNIP: "10.8.0.100", Label: "laptop"})
+	registry.Register("prod", RegisteredPeer{PublicKey: "phone-key", VPNIP: "10.8.0.102", Label: "phone"})
+
+	superseded, err := registry.Register("prod", RegisteredPeer{PublicKey: "key-2", VPNIP: "10.8.0.100", Label: "laptop"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if len(superseded) != 1 || superseded[0].PublicKey != "key-1" {
+		t.Fatalf("expected key-1 to be superseded, got %+v", superseded)
+	}
+
+	if count, _ := registry.Count("prod"); count != 2 {
+		t.Errorf("expected 2 peers after re-join, got %d", count)
+	}
+
+	// Re-registering the same key is an update, not a new join
+	superseded, _ = registry.Register("prod", RegisteredPeer{PublicKey: "key-2", VPNIP: "10.8.0.100", Label: "laptop"})
+	if len(superseded) != 0 {
+		t.Errorf("updating a peer should not supersede anything, got %+v", superseded)
+	}
+}
+
+func TestPeerRegistry_UnregisterByIPAndStale(t *testing.T) {
+	registry, err := NewPeerRegistry(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPeerRegistry failed: %v", err)
+	}
+	registry.Register("prod", RegisteredPeer{PublicKey: "laptop-key", VPNIP: "10.8.0.100"})
+	registry.Register("prod", RegisteredPeer{PublicKey: "phone-key", VPNIP: "10.8.0.101"})
+	registry.Register("prod", RegisteredPeer{PublicKey: "gone-key", VPNIP: "10.8.0.102"})
+
+	stale, err := registry.Stale("prod", map[string]bool{"laptop-key": true, "phone-key": true})
+	if err != nil {
+		t.Fatalf("Stale failed: %v", err)
+	}
+	if len(stale) != 1 || stale[0].PublicKey != "gone-key" {
+		t.Errorf("expected only gone-key to be stale, got %+v", stale)
+	}
+
+	removed, err := registry.UnregisterByIP("prod", "10.8.0.101")
+	if err != nil {
+		t.Fatalf("UnregisterByIP failed: %v", err)
+	}
+	if removed.PublicKey != "phone-key" {
+		t.Errorf("expected phone-key to be removed, got %s", removed.PublicKey)
+	}
+	if registry.Exists("prod", "phone-key") {
+		t.Error("phone-key should no longer be registered")
+	}
+
+	if _, err := registry.UnregisterByIP("prod", "10.8.0.200"); err == nil {
+		t.Error("expected an error for an unknown IP")
+	}
+}
@@ -0,0 +1,50 @@
+package vpn
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// PresharedKeySize is the length in bytes of a WireGuard preshared key
+const PresharedKeySize = 32
+
+// GeneratePresharedKey returns a new random WireGuard preshared key (base64, like `wg genpsk`)
+func GeneratePresharedKey() (string, error) {
+	key := make([]byte, PresharedKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate preshared key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ValidatePresharedKey checks that key is a base64-encoded 32-byte value
+func ValidatePresharedKey(key string) error {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("invalid preshared key: not valid base64: %w", err)
+	}
+	if len(decoded) != PresharedKeySize {
+		return fmt.Errorf("invalid preshared key: expected %d bytes, got %d", PresharedKeySize, len(decoded))
+	}
+	return nil
+}
+
+// EnsurePresharedKeys returns one preshared key per node name. Valid keys in
+// existing are kept so both ends of a tunnel stay in sync; missing or invalid
+// ones are regenerated.
+func EnsurePresharedKeys(nodeNames []string, existing map[string]string) (map[string]string, error) {
+	keys := make(map[string]string, len(nodeNames))
+	for _, name := range nodeNames {
+		if key, ok := existing[name]; ok && ValidatePresharedKey(key) == nil {
+			keys[name] = key
+			continue
+		}
+		key, err := GeneratePresharedKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[name] = key
+	}
+	return keys, nil
+}
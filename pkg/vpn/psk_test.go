@@ -0,0 +1,96 @@
+package vpn
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePresharedKey(t *testing.T) {
+	key, err := GeneratePresharedKey()
+	if err != nil {
+		t.Fatalf("GeneratePresharedKey failed: %v", err)
+	}
+	if err := ValidatePresharedKey(key); err != nil {
+		t.Errorf("generated key should be valid: %v", err)
+	}
+
+	other, _ := GeneratePresharedKey()
+	if key == other {
+		t.Error("two generated keys should differ")
+	}
+}
+
+func TestValidatePresharedKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{"valid", base64.StdEncoding.EncodeToString(make([]byte, 32)), ""},
+		{"not base64", "not-a-key!", "not valid base64"},
+		{"too short", base64.StdEncoding.EncodeToString(make([]byte, 16)), "expected 32 bytes, got 16"},
+		{"too long", base64.StdEncoding.EncodeToString(make([]byte, 33)), "expected 32 bytes, got 33"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePresharedKey(tt.key)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEnsurePresharedKeys_KeepsValidExistingKeys(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	existing := map[string]string{
+		"master-1": valid,
+		"worker-1": "corrupted",
+	}
+
+	keys, err := EnsurePresharedKeys([]string{"master-1", "worker-1", "worker-2"}, existing)
+	if err != nil {
+		t.Fatalf("EnsurePresharedKeys failed: %v", err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 keys, got %d", len(keys))
+	}
+	if keys["master-1"] != valid {
+		t.Error("valid existing key should be reused")
+	}
+	if keys["worker-1"] == "corrupted" {
+		t.Error("invalid existing key should be regenerated")
+	}
+	for name, key := range keys {
+		if err := ValidatePresharedKey(key); err != nil {
+			t.Errorf("key for %s is invalid: %v", name, err)
+		}
+	}
+}
+
+func TestValidatePeerConfig_PresharedKey(t *testing.T) {
+	mgr := &ConfigManager{}
+	peer := PeerConfig{
+		PublicKey:  strings.Repeat("A", 43) + "=",
+		AllowedIPs: []string{"10.8.0.100/32"},
+	}
+
+	peer.PresharedKey = base64.StdEncoding.EncodeToString(make([]byte, 32))
+	if err := mgr.ValidatePeerConfig(peer); err != nil {
+		t.Errorf("valid PSK should pass: %v", err)
+	}
+
+	peer.PresharedKey = base64.StdEncoding.EncodeToString(make([]byte, 8))
+	if err := mgr.ValidatePeerConfig(peer); err == nil {
+		t.Error("short PSK should be rejected")
+	}
+}
@@ -0,0 +1,117 @@
+package vpn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AdvertisedRoute is a CIDR reachable through a cluster node acting as a subnet router
+type AdvertisedRoute struct {
+	CIDR    string    `json:"cidr"`
+	Via     string    `json:"via"` // Name of the node routing the CIDR
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// RouteRegistry persists the routes advertised into the VPN, per stack
+type RouteRegistry struct {
+	dataDir string
+	mu      sync.Mutex
+}
+
+// NewRouteRegistry creates a new RouteRegistry
+func NewRouteRegistry(dataDir string) (*RouteRegistry, error) {
+	if dataDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dataDir = filepath.Join(homeDir, ".sloth-kubernetes", "vpn")
+	}
+
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	return &RouteRegistry{dataDir: dataDir}, nil
+}
+
+// registryFilePath returns the path to the routes file for a stack
+func (r *RouteRegistry) registryFilePath(stackName string) string {
+	return filepath.Join(r.dataDir, fmt.Sprintf("%s-routes.json", stackName))
+}
+
+// load reads the routes of a stack, sorted by CIDR
+func (r *RouteRegistry) load(stackName string) ([]AdvertisedRoute, error) {
+	data, err := os.ReadFile(r.registryFilePath(stackName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routes file: %w", err)
+	}
+
+	var routes []AdvertisedRoute
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("failed to parse routes file: %w", err)
+	}
+
+	sort.Slice(routes, func(i, j int) bool { return routes[i].CIDR < routes[j].CIDR })
+	return routes, nil
+}
+
+// Add records a route, replacing any existing route for the same CIDR
+func (r *RouteRegistry) Add(stackName string, route AdvertisedRoute) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	routes, err := r.load(stackName)
+	if err != nil {
+		return err
+	}
+
+	if route.AddedAt.IsZero() {
+		route.AddedAt = time.Now()
+	}
+
+	updated := []AdvertisedRoute{route}
+	for _, existing := range routes {
+		if existing.CIDR != route.CIDR {
+			updated = append(updated, existing)
+		}
+	}
+
+	data, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal routes: %w", err)
+	}
+
+	if err := os.WriteFile(r.registryFilePath(stackName), data, 0600); err != nil {
+		return fmt.Errorf("failed to write routes file: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the routes advertised for a stack, sorted by CIDR
+func (r *RouteRegistry) List(stackName string) ([]AdvertisedRoute, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.load(stackName)
+}
+
+// RoutesVia returns the CIDRs routed through the named node
+func RoutesVia(routes []AdvertisedRoute, node string) []string {
+	var cidrs []string
+	for _, route := range routes {
+		if route.Via == node {
+			cidrs = append(cidrs, route.CIDR)
+		}
+	}
+	return cidrs
+}
@@ -0,0 +1,64 @@
+package vpn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRouteRegistry_AddAndList(t *testing.T) {
+	registry, err := NewRouteRegistry(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewRouteRegistry failed: %v", err)
+	}
+
+	routes, err := registry.List("prod")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(routes) != 0 {
+		t.Fatalf("expected no routes for a new stack, got %v", routes)
+	}
+
+	for _, route := range []AdvertisedRoute{
+		{CIDR: "10.96.0.0/12", Via: "master-1"},
+		{CIDR: "10.244.0.0/16", Via: "master-1"},
+		{CIDR: "10.244.0.0/16", Via: "master-2"},
+	} {
+		if err := registry.Add("prod", route); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	routes, err = registry.List("prod")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %v", routes)
+	}
+	if routes[0].CIDR != "10.244.0.0/16" || routes[0].Via != "master-2" {
+		t.Errorf("re-adding a CIDR should replace its router, got %+v", routes[0])
+	}
+	if routes[1].AddedAt.IsZero() {
+		t.Error("AddedAt should be set")
+	}
+
+	if other, _ := registry.List("staging"); len(other) != 0 {
+		t.Errorf("routes should be kept per stack, got %v", other)
+	}
+}
+
+func TestRoutesVia(t *testing.T) {
+	routes := []AdvertisedRoute{
+		{CIDR: "10.244.0.0/16", Via: "master-1"},
+		{CIDR: "10.96.0.0/12", Via: "master-1"},
+		{CIDR: "192.168.10.0/24", Via: "worker-1"},
+	}
+
+	if got := RoutesVia(routes, "master-1"); !reflect.DeepEqual(got, []string{"10.244.0.0/16", "10.96.0.0/12"}) {
+		t.Errorf("unexpected routes via master-1: %v", got)
+	}
+	if got := RoutesVia(routes, "worker-2"); got != nil {
+		t.Errorf("expected no routes via worker-2, got %v", got)
+	}
+}
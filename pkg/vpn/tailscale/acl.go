@@ -0,0 +1,154 @@
+package tailscale
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tailscale/hujson"
+)
+
+// ACLPolicy is a Headscale ACL policy. Only the sections checked before an
+// upload are typed; the policy itself is uploaded as written.
+type ACLPolicy struct {
+	Groups        map[string][]string `json:"groups,omitempty"`
+	Hosts         map[string]string   `json:"hosts,omitempty"`
+	TagOwners     map[string][]string `json:"tagOwners,omitempty"`
+	ACLs          []ACLRule           `json:"acls"`
+	SSH           []json.RawMessage   `json:"ssh,omitempty"`
+	AutoApprovers json.RawMessage     `json:"autoApprovers,omitempty"`
+}
+
+// ACLRule allows traffic from the src aliases to the dst alias:port pairs
+type ACLRule struct {
+	Action string   `json:"action"`
+	Proto  string   `json:"proto,omitempty"`
+	Src    []string `json:"src"`
+	Dst    []string `json:"dst"`
+}
+
+// HeadscalePolicy is the ACL policy stored in Headscale
+type HeadscalePolicy struct {
+	Policy    string    `json:"policy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type setPolicyRequest struct {
+	Policy string `json:"policy"`
+}
+
+// ParseACLPolicy parses and validates a policy in the HuJSON format Headscale
+// reads (JSON with comments and trailing commas). It rejects unknown
+// sections, rules other than accept, destinations without ports, and groups
+// or tags that the policy does not define.
+func ParseACLPolicy(data string) (*ACLPolicy, error) {
+	standard, err := hujson.Standardize([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy JSON: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(standard))
+	decoder.DisallowUnknownFields()
+	var policy ACLPolicy
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	for i, rule := range policy.ACLs {
+		if rule.Action != "accept" {
+			return nil, fmt.Errorf("acls[%d]: action must be \"accept\", got %q", i, rule.Action)
+		}
+		if len(rule.Src) == 0 || len(rule.Dst) == 0 {
+			return nil, fmt.Errorf("acls[%d]: src and dst are required", i)
+		}
+		for _, src := range rule.Src {
+			if err := policy.checkAlias(src); err != nil {
+				return nil, fmt.Errorf("acls[%d]: %w", i, err)
+			}
+		}
+		for _, dst := range rule.Dst {
+			sep := strings.LastIndex(dst, ":")
+			if sep <= 0 || sep == len(dst)-1 {
+				return nil, fmt.Errorf("acls[%d]: destination %q must be alias:ports", i, dst)
+			}
+			if err := policy.checkAlias(dst[:sep]); err != nil {
+				return nil, fmt.Errorf("acls[%d]: %w", i, err)
+			}
+		}
+	}
+
+	for tag := range policy.TagOwners {
+		if !strings.HasPrefix(tag, "tag:") {
+			return nil, fmt.Errorf("tagOwners: %q must start with tag:", tag)
+		}
+	}
+	return &policy, nil
+}
+
+// checkAlias fails for a group or tag the policy does not define
+func (p *ACLPolicy) checkAlias(alias string) error {
+	switch {
+	case strings.HasPrefix(alias, "group:"):
+		if _, ok := p.Groups[alias]; !ok {
+			return fmt.Errorf("%s is not defined in groups", alias)
+		}
+	case strings.HasPrefix(alias, "tag:"):
+		if _, ok := p.TagOwners[alias]; !ok {
+			return fmt.Errorf("%s is not defined in tagOwners", alias)
+		}
+	}
+	return nil
+}
+
+// ResolveACLPolicy returns the policy a TailscaleConfig.ACLPolicy value
+// holds: the value itself when it is an inline policy, or the contents of
+// the file it names. The policy is validated with ParseACLPolicy.
+func ResolveACLPolicy(value string) (string, error) {
+	policy := strings.TrimSpace(value)
+	if !strings.HasPrefix(policy, "{") {
+		path := policy
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(home, path[2:])
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read ACL policy: %w", err)
+		}
+		policy = string(data)
+	}
+
+	if _, err := ParseACLPolicy(policy); err != nil {
+		return "", err
+	}
+	return policy, nil
+}
+
+// GetPolicy returns the ACL policy Headscale enforces. Headscale serves it
+// when its policy mode is database.
+func (h *HeadscaleManager) GetPolicy(ctx context.Context) (*HeadscalePolicy, error) {
+	var resp HeadscalePolicy
+	if err := h.apiCall(ctx, "GET", "/api/v1/policy", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get policy: %w", err)
+	}
+	return &resp, nil
+}
+
+// SetPolicy validates an ACL policy and replaces the one Headscale enforces
+func (h *HeadscaleManager) SetPolicy(ctx context.Context, policy string) error {
+	if _, err := ParseACLPolicy(policy); err != nil {
+		return err
+	}
+	if err := h.apiCall(ctx, "PUT", "/api/v1/policy", setPolicyRequest{Policy: policy}, nil); err != nil {
+		return fmt.Errorf("failed to set policy: %w", err)
+	}
+	return nil
+}
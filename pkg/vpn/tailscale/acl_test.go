@@ -0,0 +1,132 @@
+package tailscale
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testACLPolicy = `{
+	// Admins reach everything, nodes only the API server
+	"groups": {"group:admins": ["alice@"]},
+	"tagOwners": {"tag:k8s-node": ["group:admins"]},
+	"acls": [
+		{"action": "accept", "src": ["group:admins"], "dst": ["*:*"]},
+		{"action": "accept", "src": ["tag:k8s-node"], "dst": ["tag:k8s-node:6443,10250"]},
+	],
+}`
+
+func TestParseACLPolicy(t *testing.T) {
+	policy, err := ParseACLPolicy(testACLPolicy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(policy.ACLs) != 2 || policy.ACLs[1].Dst[0] != "tag:k8s-node:6443,10250" {
+		t.Errorf("unexpected rules: %+v", policy.ACLs)
+	}
+}
+
+func TestParseACLPolicy_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		policy string
+		want   string
+	}{
+		"not json":      {`{"acls": [`, "invalid policy JSON"},
+		"unknown key":   {`{"acl": []}`, "unknown field"},
+		"deny action":   {`{"acls": [{"action": "deny", "src": ["*"], "dst": ["*:*"]}]}`, "must be \"accept\""},
+		"no src":        {`{"acls": [{"action": "accept", "dst": ["*:*"]}]}`, "src and dst are required"},
+		"no port":       {`{"acls": [{"action": "accept", "src": ["*"], "dst": ["10.0.0.1"]}]}`, "alias:ports"},
+		"unknown group": {`{"acls": [{"action": "accept", "src": ["group:ops"], "dst": ["*:*"]}]}`, "group:ops is not defined"},
+		"unknown tag":   {`{"acls": [{"action": "accept", "src": ["*"], "dst": ["tag:db:5432"]}]}`, "tag:db is not defined"},
+		"bad tag owner": {`{"tagOwners": {"db": []}, "acls": []}`, "must start with tag:"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseACLPolicy(tt.policy)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestResolveACLPolicy(t *testing.T) {
+	inline, err := ResolveACLPolicy("  " + testACLPolicy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inline != testACLPolicy {
+		t.Errorf("expected the inline policy, got %q", inline)
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.hujson")
+	if err := os.WriteFile(path, []byte(testACLPolicy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := ResolveACLPolicy(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fromFile != testACLPolicy {
+		t.Errorf("expected the file contents, got %q", fromFile)
+	}
+
+	if _, err := ResolveACLPolicy(filepath.Join(t.TempDir(), "missing.hujson")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestSetAndGetPolicy(t *testing.T) {
+	var stored string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/policy" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		switch r.Method {
+		case "PUT":
+			var req setPolicyRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			stored = req.Policy
+			w.WriteHeader(http.StatusOK)
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(HeadscalePolicy{Policy: stored})
+		default:
+			t.Errorf("unexpected method: %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	manager := NewHeadscaleManager(HeadscaleConfig{
+		APIURL:    server.URL,
+		APIKey:    "test-api-key",
+		Namespace: "kubernetes",
+	})
+
+	ctx := context.Background()
+	if err := manager.SetPolicy(ctx, `{"acls": [{"action": "deny"}]}`); err == nil {
+		t.Error("expected an invalid policy to be rejected")
+	}
+	if stored != "" {
+		t.Error("expected an invalid policy not to be uploaded")
+	}
+
+	if err := manager.SetPolicy(ctx, testACLPolicy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	policy, err := manager.GetPolicy(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.Policy != testACLPolicy {
+		t.Errorf("expected the uploaded policy, got %q", policy.Policy)
+	}
+}
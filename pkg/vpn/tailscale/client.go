@@ -13,6 +13,8 @@ import (
 	"sync"
 	"time"
 
+	"tailscale.com/ipn"
+	"tailscale.com/tailcfg"
 	"tailscale.com/tsnet"
 )
 
@@ -45,6 +47,7 @@ type EmbeddedClientStatus struct {
 	ClusterName  string    `json:"clusterName"`
 	HeadscaleURL string    `json:"headscaleUrl"`
 	PeerCount    int       `json:"peerCount"`
+	ExitNode     string    `json:"exitNode,omitempty"`
 	ConnectedAt  time.Time `json:"connectedAt,omitempty"`
 }
 
@@ -131,6 +134,14 @@ func (c *EmbeddedClient) Disconnect() error {
 		return fmt.Errorf("not connected")
 	}
 
+	// Log the ephemeral node out so it leaves the tailnet now instead of
+	// when Headscale expires it
+	if lc, err := c.server.LocalClient(); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		lc.Logout(ctx)
+		cancel()
+	}
+
 	if err := c.server.Close(); err != nil {
 		return fmt.Errorf("failed to disconnect: %w", err)
 	}
@@ -166,6 +177,13 @@ func (c *EmbeddedClient) Status(ctx context.Context) (*EmbeddedClientStatus, err
 					status.TailscaleIP = tsStatus.Self.TailscaleIPs[0].String()
 				}
 				status.PeerCount = len(tsStatus.Peer)
+				if exit := tsStatus.ExitNodeStatus; exit != nil {
+					for _, peer := range tsStatus.Peer {
+						if peer.ID == exit.ID {
+							status.ExitNode = peer.HostName
+						}
+					}
+				}
 			}
 		}
 	}
@@ -173,6 +191,63 @@ func (c *EmbeddedClient) Status(ctx context.Context) (*EmbeddedClientStatus, err
 	return status, nil
 }
 
+// UseExitNode routes the client's traffic through the peer with the given
+// hostname. The peer must advertise itself as an exit node with its routes
+// approved in Headscale; UseExitNode waits up to timeout for the tailnet to
+// offer it as one and for the client to switch to it.
+func (c *EmbeddedClient) UseExitNode(ctx context.Context, hostname string, timeout time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected || c.server == nil {
+		return fmt.Errorf("not connected")
+	}
+
+	lc, err := c.server.LocalClient()
+	if err != nil {
+		return fmt.Errorf("failed to get local client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var exitID tailcfg.StableNodeID
+	for {
+		status, err := lc.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+
+		for _, peer := range status.Peer {
+			if peer.HostName != hostname {
+				continue
+			}
+			if exitID == "" && peer.ExitNodeOption {
+				exitID = peer.ID
+				if _, err := lc.EditPrefs(ctx, &ipn.MaskedPrefs{
+					Prefs:         ipn.Prefs{ExitNodeID: exitID},
+					ExitNodeIDSet: true,
+				}); err != nil {
+					return fmt.Errorf("failed to set exit node: %w", err)
+				}
+			}
+		}
+
+		if exitID != "" && status.ExitNodeStatus != nil && status.ExitNodeStatus.ID == exitID {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if exitID == "" {
+				return fmt.Errorf("'%s' is not offered as an exit node: it must advertise itself and have its exit routes approved", hostname)
+			}
+			return fmt.Errorf("timed out switching to exit node '%s'", hostname)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
 // GetTailscaleIP returns the Tailscale IP of the local client
 func (c *EmbeddedClient) GetTailscaleIP(ctx context.Context) (string, error) {
 	c.mu.Lock()
@@ -0,0 +1,145 @@
+package tailscale
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Connection modes recorded in the daemon state
+const (
+	ModeDaemon     = "daemon"
+	ModeForeground = "foreground"
+)
+
+// DaemonState describes the 'vpn connect' process holding a cluster's
+// connection, so a second connect can refuse and disconnect can stop it
+type DaemonState struct {
+	PID       int       `json:"pid"`
+	Mode      string    `json:"mode"`
+	Hostname  string    `json:"hostname,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// GetDaemonStateFile returns the path to the daemon state file
+func GetDaemonStateFile(clusterName string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".sloth", "vpn", clusterName, "daemon.json")
+}
+
+// SaveDaemonState records the process holding the connection. The PID file
+// is written too for tools that only read it.
+func SaveDaemonState(clusterName string, state DaemonState) error {
+	stateFile := GetDaemonStateFile(clusterName)
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal daemon state: %w", err)
+	}
+	if err := os.WriteFile(stateFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write daemon state: %w", err)
+	}
+	if err := os.WriteFile(GetPIDFile(clusterName), []byte(strconv.Itoa(state.PID)), 0600); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	return nil
+}
+
+// LoadDaemonState returns the recorded daemon state, falling back to the PID
+// file written by older versions
+func LoadDaemonState(clusterName string) (*DaemonState, error) {
+	data, err := os.ReadFile(GetDaemonStateFile(clusterName))
+	if err == nil {
+		var state DaemonState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse daemon state: %w", err)
+		}
+		return &state, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read daemon state: %w", err)
+	}
+
+	data, err = os.ReadFile(GetPIDFile(clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("no VPN daemon recorded for %s: %w", clusterName, err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PID file: %w", err)
+	}
+	return &DaemonState{PID: pid, Mode: ModeDaemon}, nil
+}
+
+// RemoveDaemonState removes the daemon state, PID file, health socket and
+// proxy port file, leaving the tailnet node state in place
+func RemoveDaemonState(clusterName string) {
+	for _, path := range []string{
+		GetDaemonStateFile(clusterName),
+		GetPIDFile(clusterName),
+		GetHealthSocket(clusterName),
+		GetProxyFile(clusterName),
+	} {
+		os.Remove(path)
+	}
+}
+
+// RunningDaemon returns the state of the process holding the cluster's
+// connection when it is still running. State left behind by a process that
+// died is removed.
+func RunningDaemon(clusterName string) (*DaemonState, bool) {
+	state, err := LoadDaemonState(clusterName)
+	if err != nil || state.PID <= 0 {
+		return nil, false
+	}
+	if state.PID == os.Getpid() || !isProcessRunning(state.PID) {
+		RemoveDaemonState(clusterName)
+		return nil, false
+	}
+	return state, true
+}
+
+// StopDaemon asks the process holding the cluster's connection to disconnect
+// and waits up to timeout for it to exit before killing it. Its state is
+// removed either way.
+func StopDaemon(clusterName string, timeout time.Duration) error {
+	state, running := RunningDaemon(clusterName)
+	if !running {
+		return nil
+	}
+	defer RemoveDaemonState(clusterName)
+
+	if err := terminateProcess(state.PID); err != nil {
+		return fmt.Errorf("failed to stop VPN daemon (PID %d): %w", state.PID, err)
+	}
+	if waitForExit(state.PID, timeout) {
+		return nil
+	}
+
+	if err := killProcess(state.PID); err != nil {
+		return fmt.Errorf("VPN daemon (PID %d) did not exit after %s and could not be killed: %w", state.PID, timeout, err)
+	}
+	if !waitForExit(state.PID, 2*time.Second) {
+		return fmt.Errorf("VPN daemon (PID %d) is still running after being killed", state.PID)
+	}
+	return nil
+}
+
+// waitForExit polls until the process exits or timeout passes
+func waitForExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for isProcessRunning(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package tailscale
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// startProcess starts a long-running child and reaps it when it exits, so
+// it is not seen running as a zombie
+func startProcess(t *testing.T) *exec.Cmd {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a Unix sleep process")
+	}
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start process: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		<-done
+	})
+	return cmd
+}
+
+func TestSaveAndLoadDaemonState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := DaemonState{PID: 4242, Mode: ModeForeground, Hostname: "laptop", StartedAt: started}
+	if err := SaveDaemonState("prod", want); err != nil {
+		t.Fatalf("SaveDaemonState failed: %v", err)
+	}
+
+	got, err := LoadDaemonState("prod")
+	if err != nil {
+		t.Fatalf("LoadDaemonState failed: %v", err)
+	}
+	if *got != want {
+		t.Errorf("LoadDaemonState = %+v, want %+v", *got, want)
+	}
+	if pid := GetDaemonPID("prod"); pid != 4242 {
+		t.Errorf("PID file holds %d, want 4242", pid)
+	}
+
+	info, err := os.Stat(GetDaemonStateFile("prod"))
+	if err != nil {
+		t.Fatalf("state file missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("state file mode = %o, want 600", perm)
+	}
+}
+
+func TestLoadDaemonState_PIDFileOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := LoadDaemonState("prod"); err == nil {
+		t.Error("expected an error without any state")
+	}
+
+	if err := SaveDaemonState("prod", DaemonState{PID: 1}); err != nil {
+		t.Fatalf("SaveDaemonState failed: %v", err)
+	}
+	os.Remove(GetDaemonStateFile("prod"))
+	if err := os.WriteFile(GetPIDFile("prod"), []byte("777\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := LoadDaemonState("prod")
+	if err != nil {
+		t.Fatalf("LoadDaemonState failed: %v", err)
+	}
+	if state.PID != 777 || state.Mode != ModeDaemon {
+		t.Errorf("LoadDaemonState = %+v, want PID 777 in daemon mode", *state)
+	}
+}
+
+func TestRunningDaemon(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cmd := startProcess(t)
+
+	if _, running := RunningDaemon("prod"); running {
+		t.Fatal("no daemon should be running without state")
+	}
+
+	if err := SaveDaemonState("prod", DaemonState{PID: cmd.Process.Pid, Mode: ModeDaemon}); err != nil {
+		t.Fatalf("SaveDaemonState failed: %v", err)
+	}
+	state, running := RunningDaemon("prod")
+	if !running || state.PID != cmd.Process.Pid {
+		t.Fatalf("RunningDaemon = %+v, %t; want PID %d running", state, running, cmd.Process.Pid)
+	}
+
+	// This process never counts as the running daemon
+	if err := SaveDaemonState("prod", DaemonState{PID: os.Getpid(), Mode: ModeForeground}); err != nil {
+		t.Fatalf("SaveDaemonState failed: %v", err)
+	}
+	if _, running := RunningDaemon("prod"); running {
+		t.Error("the current process should not be reported as running")
+	}
+}
+
+func TestRunningDaemon_RemovesStaleState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cmd := startProcess(t)
+
+	if err := SaveDaemonState("prod", DaemonState{PID: cmd.Process.Pid, Mode: ModeDaemon}); err != nil {
+		t.Fatalf("SaveDaemonState failed: %v", err)
+	}
+	if err := SaveProxyPort("prod", 1080); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Process.Kill()
+	if !waitForExit(cmd.Process.Pid, 5*time.Second) {
+		t.Fatal("process did not exit")
+	}
+
+	if _, running := RunningDaemon("prod"); running {
+		t.Fatal("a dead process should not be reported as running")
+	}
+	for _, path := range []string{GetDaemonStateFile("prod"), GetPIDFile("prod"), GetProxyFile("prod")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should have been removed", path)
+		}
+	}
+}
+
+func TestStopDaemon(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cmd := startProcess(t)
+
+	if err := SaveDaemonState("prod", DaemonState{PID: cmd.Process.Pid, Mode: ModeDaemon}); err != nil {
+		t.Fatalf("SaveDaemonState failed: %v", err)
+	}
+	if err := StopDaemon("prod", 5*time.Second); err != nil {
+		t.Fatalf("StopDaemon failed: %v", err)
+	}
+
+	if isProcessRunning(cmd.Process.Pid) {
+		t.Error("daemon should have been stopped")
+	}
+	if _, err := os.Stat(GetDaemonStateFile("prod")); !os.IsNotExist(err) {
+		t.Error("daemon state should have been removed")
+	}
+
+	// Stopping without a daemon is a no-op
+	if err := StopDaemon("prod", time.Second); err != nil {
+		t.Errorf("StopDaemon without a daemon failed: %v", err)
+	}
+}
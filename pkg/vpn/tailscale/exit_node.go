@@ -0,0 +1,23 @@
+package tailscale
+
+import "slices"
+
+// ExitRoutes are the routes a node advertises as an exit node
+var ExitRoutes = []string{"0.0.0.0/0", "::/0"}
+
+// ExitNodeForwardingScript enables the IPv4 and IPv6 forwarding an exit node
+// needs, now and across reboots
+const ExitNodeForwardingScript = `
+printf 'net.ipv4.ip_forward=1\nnet.ipv6.conf.all.forwarding=1\n' | sudo tee /etc/sysctl.d/99-sloth-exit-node.conf > /dev/null
+sudo sysctl -q -p /etc/sysctl.d/99-sloth-exit-node.conf
+`
+
+// hasExitRoutes reports whether routes holds both exit routes
+func hasExitRoutes(routes []string) bool {
+	for _, route := range ExitRoutes {
+		if !slices.Contains(routes, route) {
+			return false
+		}
+	}
+	return true
+}
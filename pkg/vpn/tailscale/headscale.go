@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"time"
 )
 
@@ -58,6 +59,18 @@ type HeadscaleNode struct {
 	ForcedTags           []string  `json:"forcedTags"`
 	ValidTags            []string  `json:"validTags"`
 	InvalidTags          []string  `json:"invalidTags"`
+	ApprovedRoutes       []string  `json:"approvedRoutes"`
+	AvailableRoutes      []string  `json:"availableRoutes"`
+}
+
+// AdvertisesExitNode reports whether the node offers itself as an exit node
+func (n *HeadscaleNode) AdvertisesExitNode() bool {
+	return hasExitRoutes(n.AvailableRoutes)
+}
+
+// IsExitNode reports whether the node's exit routes are approved
+func (n *HeadscaleNode) IsExitNode() bool {
+	return hasExitRoutes(n.ApprovedRoutes)
 }
 
 // HeadscalePreAuthKey represents a pre-auth key
@@ -254,6 +267,65 @@ func (h *HeadscaleManager) ExpireNode(ctx context.Context, nodeID string) error
 	return nil
 }
 
+// ApproveRoutes approves the subnet routes a node advertises. The approved set
+// replaces the node's previous one, so routes must list every route to keep.
+func (h *HeadscaleManager) ApproveRoutes(ctx context.Context, nodeID string, routes []string) error {
+	endpoint := fmt.Sprintf("/api/v1/node/%s/approve_routes", nodeID)
+	req := map[string][]string{
+		"routes": routes,
+	}
+	if err := h.apiCall(ctx, "POST", endpoint, req, nil); err != nil {
+		return fmt.Errorf("failed to approve routes: %w", err)
+	}
+
+	return nil
+}
+
+// ApproveExitNode approves the exit routes of a node, keeping the subnet
+// routes already approved for it, and checks Headscale applied them
+func (h *HeadscaleManager) ApproveExitNode(ctx context.Context, nodeID string) error {
+	node, err := h.GetNode(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	routes := append([]string{}, node.ApprovedRoutes...)
+	for _, route := range ExitRoutes {
+		if !slices.Contains(routes, route) {
+			routes = append(routes, route)
+		}
+	}
+	if err := h.ApproveRoutes(ctx, nodeID, routes); err != nil {
+		return err
+	}
+
+	node, err = h.GetNode(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	if !node.IsExitNode() {
+		return fmt.Errorf("exit routes of node '%s' are not approved", node.GivenName)
+	}
+
+	return nil
+}
+
+// FindNodeByName returns the node whose given name or hostname matches name
+func (h *HeadscaleManager) FindNodeByName(ctx context.Context, name string) (*HeadscaleNode, error) {
+	nodes, err := h.ListNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range nodes {
+		if nodes[i].GivenName == name || nodes[i].Name == name {
+			return &nodes[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("node '%s' not found in Headscale", name)
+}
+
 // CreateNamespace creates a new namespace
 func (h *HeadscaleManager) CreateNamespace(ctx context.Context, name string) (*HeadscaleNamespace, error) {
 	req := createNamespaceRequest{Name: name}
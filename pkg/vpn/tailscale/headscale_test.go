@@ -288,3 +288,124 @@ func TestRenameNode(t *testing.T) {
 		t.Error("expected rename to be called")
 	}
 }
+
+func TestApproveRoutes(t *testing.T) {
+	var approved map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST request, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/v1/node/7/approve_routes" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&approved); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	manager := NewHeadscaleManager(HeadscaleConfig{
+		APIURL:    server.URL,
+		APIKey:    "test-api-key",
+		Namespace: "kubernetes",
+	})
+
+	ctx := context.Background()
+	err := manager.ApproveRoutes(ctx, "7", []string{"10.244.0.0/16", "10.96.0.0/12"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	routes := approved["routes"]
+	if len(routes) != 2 || routes[0] != "10.244.0.0/16" || routes[1] != "10.96.0.0/12" {
+		t.Errorf("unexpected approved routes: %v", routes)
+	}
+}
+
+func TestFindNodeByName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := listNodesResponse{
+			Nodes: []HeadscaleNode{
+				{ID: "1", Name: "ip-10-0-0-1", GivenName: "master-1"},
+				{ID: "2", Name: "worker-1", GivenName: "worker-1-renamed"},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	manager := NewHeadscaleManager(HeadscaleConfig{
+		APIURL:    server.URL,
+		APIKey:    "test-api-key",
+		Namespace: "kubernetes",
+	})
+
+	ctx := context.Background()
+	for name, wantID := range map[string]string{"master-1": "1", "worker-1": "2"} {
+		node, err := manager.FindNodeByName(ctx, name)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", name, err)
+		}
+		if node.ID != wantID {
+			t.Errorf("expected node %s to have ID %s, got %s", name, wantID, node.ID)
+		}
+	}
+
+	if _, err := manager.FindNodeByName(ctx, "worker-9"); err == nil {
+		t.Error("expected an error for an unknown node")
+	}
+}
+
+func TestApproveExitNode(t *testing.T) {
+	node := HeadscaleNode{ID: "3", GivenName: "worker-1", ApprovedRoutes: []string{"10.244.0.0/16"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/node/3":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(getNodeResponse{Node: node})
+		case r.Method == "POST" && r.URL.Path == "/api/v1/node/3/approve_routes":
+			var req map[string][]string
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			node.ApprovedRoutes = req["routes"]
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	manager := NewHeadscaleManager(HeadscaleConfig{
+		APIURL:    server.URL,
+		APIKey:    "test-api-key",
+		Namespace: "kubernetes",
+	})
+
+	if err := manager.ApproveExitNode(context.Background(), "3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	routes := node.ApprovedRoutes
+	if len(routes) != 3 || routes[0] != "10.244.0.0/16" || routes[1] != "0.0.0.0/0" || routes[2] != "::/0" {
+		t.Errorf("unexpected approved routes: %v", routes)
+	}
+	if !node.IsExitNode() {
+		t.Error("expected the node to be an exit node")
+	}
+}
+
+func TestHeadscaleNode_ExitNode(t *testing.T) {
+	node := HeadscaleNode{
+		AvailableRoutes: []string{"0.0.0.0/0", "::/0"},
+		ApprovedRoutes:  []string{"0.0.0.0/0"},
+	}
+	if !node.AdvertisesExitNode() {
+		t.Error("expected the node to advertise itself as exit node")
+	}
+	if node.IsExitNode() {
+		t.Error("expected the node not to be an exit node with only one exit route approved")
+	}
+}
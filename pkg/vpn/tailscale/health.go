@@ -0,0 +1,131 @@
+package tailscale
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DaemonHealth is the payload served by the daemon health endpoint
+type DaemonHealth struct {
+	Healthy   bool `json:"healthy"`
+	PID       int  `json:"pid"`
+	ProxyPort int  `json:"proxyPort,omitempty"`
+	EmbeddedClientStatus
+}
+
+// HealthStatusFunc reports the daemon health on each request
+type HealthStatusFunc func(ctx context.Context) (*DaemonHealth, error)
+
+// GetHealthSocket returns the path to the daemon health socket
+func GetHealthSocket(clusterName string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".sloth", "vpn", clusterName, "daemon.sock")
+}
+
+// HealthStatus returns the client status as daemon health. The daemon is
+// healthy once it is connected and has a tailnet IP.
+func (c *EmbeddedClient) HealthStatus(ctx context.Context) (*DaemonHealth, error) {
+	status, err := c.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DaemonHealth{
+		Healthy:              status.Connected && status.TailscaleIP != "",
+		PID:                  os.Getpid(),
+		ProxyPort:            c.GetProxyPort(),
+		EmbeddedClientStatus: *status,
+	}, nil
+}
+
+// NewHealthHandler serves the daemon health as JSON on /healthz. Unhealthy
+// daemons answer 503 so callers can rely on the status code alone.
+func NewHealthHandler(statusFn HealthStatusFunc) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		health, err := statusFn(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !health.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(health)
+	})
+	return mux
+}
+
+// ServeHealth serves the health handler on a unix socket. A stale socket left
+// by a crashed daemon is replaced. The returned function stops the server and
+// removes the socket.
+func ServeHealth(socketPath string, handler http.Handler) (func() error, error) {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale health socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on health socket: %w", err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict health socket permissions: %w", err)
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	go server.Serve(listener)
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		err := server.Shutdown(ctx)
+		os.Remove(socketPath)
+		return err
+	}, nil
+}
+
+// QueryHealth asks the daemon listening on socketPath for its health
+func QueryHealth(ctx context.Context, socketPath string) (*DaemonHealth, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://daemon/healthz", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("daemon health endpoint not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, fmt.Errorf("daemon health endpoint returned %s", resp.Status)
+	}
+
+	var health DaemonHealth
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to decode daemon health: %w", err)
+	}
+
+	return &health, nil
+}
@@ -0,0 +1,94 @@
+package tailscale
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		health     *DaemonHealth
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "healthy",
+			health:     &DaemonHealth{Healthy: true, EmbeddedClientStatus: EmbeddedClientStatus{Connected: true, TailscaleIP: "100.64.0.5"}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "not connected",
+			health:     &DaemonHealth{Healthy: false},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "status error",
+			err:        errors.New("local client unavailable"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHealthHandler(func(ctx context.Context) (*DaemonHealth, error) {
+				return tt.health, tt.err
+			})
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServeAndQueryHealth(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "daemon.sock")
+
+	// A stale socket file from a crashed daemon must not block startup
+	if err := os.WriteFile(socketPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stop, err := ServeHealth(socketPath, NewHealthHandler(func(ctx context.Context) (*DaemonHealth, error) {
+		return &DaemonHealth{
+			Healthy:   true,
+			PID:       4242,
+			ProxyPort: 1080,
+			EmbeddedClientStatus: EmbeddedClientStatus{
+				Connected:   true,
+				TailscaleIP: "100.64.0.5",
+				PeerCount:   3,
+			},
+		}, nil
+	}))
+	if err != nil {
+		t.Fatalf("ServeHealth() error = %v", err)
+	}
+
+	health, err := QueryHealth(context.Background(), socketPath)
+	if err != nil {
+		t.Fatalf("QueryHealth() error = %v", err)
+	}
+	if !health.Healthy || health.TailscaleIP != "100.64.0.5" || health.PeerCount != 3 || health.PID != 4242 {
+		t.Errorf("QueryHealth() = %+v", health)
+	}
+
+	if err := stop(); err != nil {
+		t.Errorf("stop() error = %v", err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Error("health socket was not removed on stop")
+	}
+	if _, err := QueryHealth(context.Background(), socketPath); err == nil {
+		t.Error("QueryHealth() should fail once the daemon stopped")
+	}
+}
@@ -18,3 +18,13 @@ func isProcessRunning(pid int) bool {
 	err = process.Signal(syscall.Signal(0))
 	return err == nil
 }
+
+// terminateProcess asks the process to exit cleanly
+func terminateProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// killProcess forces the process to exit
+func killProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGKILL)
+}
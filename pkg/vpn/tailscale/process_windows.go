@@ -18,3 +18,18 @@ func isProcessRunning(pid int) bool {
 	_ = process
 	return true
 }
+
+// terminateProcess stops the process. Windows has no termination signal, so
+// the process is killed.
+func terminateProcess(pid int) error {
+	return killProcess(pid)
+}
+
+// killProcess forces the process to exit
+func killProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
@@ -0,0 +1,275 @@
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tailscale/wireguard-go/conn"
+	"github.com/tailscale/wireguard-go/device"
+	"github.com/tailscale/wireguard-go/tun"
+)
+
+// Backends an embedded client can bring the tunnel up with
+const (
+	BackendKernel    = "kernel"
+	BackendUserspace = "userspace"
+)
+
+// DefaultMTU is the tunnel MTU when the configuration sets none
+const DefaultMTU = 1420
+
+// kernelConfigName is the wg-quick config written to the state directory;
+// wg-quick names the interface after it
+const kernelConfigName = "sloth0.conf"
+
+// EmbeddedClient brings up a WireGuard tunnel to the cluster mesh from the
+// local machine. It prefers kernel WireGuard through wg-quick and falls back
+// to the userspace wireguard-go implementation on a TUN interface.
+type EmbeddedClient struct {
+	clusterName string
+	config      *EmbeddedClientConfig
+	mu          sync.Mutex
+	connected   bool
+	connectedAt time.Time
+	backend     string
+	ifaceName   string
+	device      *device.Device
+}
+
+// EmbeddedClientConfig holds configuration for the embedded client
+type EmbeddedClientConfig struct {
+	// Config is the wg-quick client configuration to bring up
+	Config string
+	// StateDir defaults to ~/.sloth/vpn/<cluster>
+	StateDir string
+	// Userspace skips kernel WireGuard even when it is available
+	Userspace bool
+}
+
+// EmbeddedClientStatus represents the current status of the embedded client
+type EmbeddedClientStatus struct {
+	Connected   bool      `json:"connected"`
+	Backend     string    `json:"backend,omitempty"`
+	Interface   string    `json:"interface,omitempty"`
+	Address     string    `json:"address,omitempty"`
+	ClusterName string    `json:"clusterName"`
+	PeerCount   int       `json:"peerCount"`
+	ConnectedAt time.Time `json:"connectedAt,omitempty"`
+}
+
+// NewEmbeddedClient creates a new embedded WireGuard client
+func NewEmbeddedClient(clusterName string, config *EmbeddedClientConfig) (*EmbeddedClient, error) {
+	if config.Config == "" {
+		return nil, fmt.Errorf("WireGuard configuration is required")
+	}
+
+	if config.StateDir == "" {
+		config.StateDir = stateDir(clusterName)
+	}
+	if err := os.MkdirAll(config.StateDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return &EmbeddedClient{
+		clusterName: clusterName,
+		config:      config,
+	}, nil
+}
+
+// KernelAvailable reports whether the tunnel can use kernel WireGuard: the
+// process runs as root on Linux and wg-quick is installed
+func KernelAvailable() bool {
+	if _, err := exec.LookPath("wg-quick"); err != nil {
+		return false
+	}
+	return isLinux && os.Geteuid() == 0
+}
+
+// Connect brings the tunnel up, with kernel WireGuard when available and
+// wireguard-go otherwise
+func (c *EmbeddedClient) Connect(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.connected {
+		return fmt.Errorf("already connected")
+	}
+
+	cfg, err := ParseConfig(c.config.Config)
+	if err != nil {
+		return fmt.Errorf("invalid WireGuard configuration: %w", err)
+	}
+
+	if !c.config.Userspace && KernelAvailable() {
+		err := c.connectKernel(ctx)
+		if err == nil {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Warning: kernel WireGuard failed, using userspace: %v\n", err)
+	}
+
+	return c.connectUserspace(cfg)
+}
+
+// connectKernel writes the configuration to the state directory and brings
+// it up with wg-quick
+func (c *EmbeddedClient) connectKernel(ctx context.Context) error {
+	configPath := filepath.Join(c.config.StateDir, kernelConfigName)
+	if err := os.WriteFile(configPath, []byte(c.config.Config), 0600); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+
+	output, err := exec.CommandContext(ctx, "wg-quick", "up", configPath).CombinedOutput()
+	if err != nil {
+		os.Remove(configPath)
+		return fmt.Errorf("wg-quick up failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	c.backend = BackendKernel
+	c.ifaceName = strings.TrimSuffix(kernelConfigName, ".conf")
+	c.connected = true
+	c.connectedAt = time.Now()
+	return nil
+}
+
+// connectUserspace runs wireguard-go on a new TUN interface and gives the
+// interface the client address and a route for every allowed IP
+func (c *EmbeddedClient) connectUserspace(cfg *Config) error {
+	uapi, err := cfg.UAPI()
+	if err != nil {
+		return err
+	}
+
+	mtu := cfg.MTU
+	if mtu == 0 {
+		mtu = DefaultMTU
+	}
+
+	tunDevice, err := tun.CreateTUN(defaultTUNName, mtu)
+	if err != nil {
+		return fmt.Errorf("failed to create TUN interface (needs root or CAP_NET_ADMIN): %w", err)
+	}
+	ifaceName, err := tunDevice.Name()
+	if err != nil {
+		tunDevice.Close()
+		return fmt.Errorf("failed to get TUN interface name: %w", err)
+	}
+
+	// Closing the device closes the TUN interface too
+	dev := device.NewDevice(tunDevice, conn.NewDefaultBind(), device.NewLogger(device.LogLevelSilent, ""))
+	if err := dev.IpcSet(uapi); err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to configure WireGuard device: %w", err)
+	}
+	if err := dev.Up(); err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to bring WireGuard device up: %w", err)
+	}
+	if err := configureInterface(ifaceName, cfg, mtu); err != nil {
+		dev.Close()
+		return fmt.Errorf("failed to configure %s: %w", ifaceName, err)
+	}
+
+	c.device = dev
+	c.backend = BackendUserspace
+	c.ifaceName = ifaceName
+	c.connected = true
+	c.connectedAt = time.Now()
+	return nil
+}
+
+// Disconnect tears the tunnel down
+func (c *EmbeddedClient) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected {
+		return fmt.Errorf("not connected")
+	}
+
+	switch c.backend {
+	case BackendKernel:
+		if err := kernelDown(c.config.StateDir); err != nil {
+			return err
+		}
+	case BackendUserspace:
+		// Removing the interface drops its address and routes
+		c.device.Close()
+		c.device = nil
+	}
+
+	c.connected = false
+	return nil
+}
+
+// Backend returns the backend the tunnel is up with
+func (c *EmbeddedClient) Backend() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.backend
+}
+
+// Status returns the current connection status
+func (c *EmbeddedClient) Status(ctx context.Context) (*EmbeddedClientStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := &EmbeddedClientStatus{
+		Connected:   c.connected,
+		ClusterName: c.clusterName,
+	}
+	if !c.connected {
+		return status, nil
+	}
+
+	cfg, err := ParseConfig(c.config.Config)
+	if err != nil {
+		return nil, err
+	}
+	status.Backend = c.backend
+	status.Interface = c.ifaceName
+	status.Address = cfg.Addresses[0]
+	status.PeerCount = len(cfg.Peers)
+	status.ConnectedAt = c.connectedAt
+	return status, nil
+}
+
+// IsConnected reports whether a kernel tunnel brought up by an embedded
+// client is left for the cluster
+func IsConnected(clusterName string) bool {
+	_, err := os.Stat(filepath.Join(stateDir(clusterName), kernelConfigName))
+	return err == nil
+}
+
+// Cleanup brings down a kernel tunnel left behind by a client that exited
+// without disconnecting. Userspace tunnels go away with their process.
+func Cleanup(clusterName string) error {
+	if !IsConnected(clusterName) {
+		return nil
+	}
+	return kernelDown(stateDir(clusterName))
+}
+
+// kernelDown runs wg-quick down on the configuration in dir and removes it
+func kernelDown(dir string) error {
+	configPath := filepath.Join(dir, kernelConfigName)
+	output, err := exec.Command("wg-quick", "down", configPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("wg-quick down failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	os.Remove(configPath)
+	return nil
+}
+
+// stateDir returns the cluster's connection state directory, shared with the
+// Tailscale client
+func stateDir(clusterName string) string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".sloth", "vpn", clusterName)
+}
@@ -0,0 +1,258 @@
+package wireguard
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Config is a parsed wg-quick client configuration
+type Config struct {
+	PrivateKey string
+	Addresses  []string
+	DNS        []string
+	MTU        int
+	Peers      []Peer
+}
+
+// Peer is a [Peer] section of a wg-quick configuration
+type Peer struct {
+	Name                string
+	PublicKey           string
+	PresharedKey        string
+	Endpoint            string
+	AllowedIPs          []string
+	PersistentKeepalive int
+}
+
+// ParseConfig parses a wg-quick configuration, as written by 'vpn join' and
+// 'vpn client-config'. The comment directly after a [Peer] header names the peer.
+func ParseConfig(data string) (*Config, error) {
+	cfg := &Config{}
+	var peer *Peer
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if peer != nil && peer.Name == "" && peer.PublicKey == "" {
+				peer.Name = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			}
+			continue
+		}
+
+		switch strings.ToLower(line) {
+		case "[interface]":
+			section = "interface"
+			peer = nil
+			continue
+		case "[peer]":
+			section = "peer"
+			cfg.Peers = append(cfg.Peers, Peer{})
+			peer = &cfg.Peers[len(cfg.Peers)-1]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch section {
+		case "interface":
+			switch key {
+			case "privatekey":
+				cfg.PrivateKey = value
+			case "address":
+				cfg.Addresses = append(cfg.Addresses, splitList(value)...)
+			case "dns":
+				cfg.DNS = append(cfg.DNS, splitList(value)...)
+			case "mtu":
+				mtu, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid MTU %q", lineNo, value)
+				}
+				cfg.MTU = mtu
+			}
+		case "peer":
+			switch key {
+			case "publickey":
+				peer.PublicKey = value
+			case "presharedkey":
+				peer.PresharedKey = value
+			case "endpoint":
+				peer.Endpoint = value
+			case "allowedips":
+				peer.AllowedIPs = append(peer.AllowedIPs, splitList(value)...)
+			case "persistentkeepalive":
+				keepalive, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid PersistentKeepalive %q", lineNo, value)
+				}
+				peer.PersistentKeepalive = keepalive
+			}
+		default:
+			return nil, fmt.Errorf("line %d: %s outside of a section", lineNo, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if cfg.PrivateKey == "" {
+		return nil, fmt.Errorf("configuration has no PrivateKey")
+	}
+	if len(cfg.Addresses) == 0 {
+		return nil, fmt.Errorf("configuration has no Address")
+	}
+	return cfg, nil
+}
+
+// Prune drops the peers whose public key is not a valid WireGuard key, such
+// as the placeholder written when a node's key could not be fetched, and
+// returns their names
+func (c *Config) Prune() []string {
+	var dropped []string
+	peers := c.Peers[:0]
+	for _, peer := range c.Peers {
+		if _, err := keyToHex(peer.PublicKey); err != nil {
+			dropped = append(dropped, peer.Name)
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	c.Peers = peers
+	return dropped
+}
+
+// UAPI renders the configuration in the userspace API format the device is
+// configured with. Endpoints are resolved here since the device only takes
+// IP addresses.
+func (c *Config) UAPI() (string, error) {
+	var b strings.Builder
+
+	privateKey, err := keyToHex(c.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+	fmt.Fprintf(&b, "private_key=%s\nreplace_peers=true\n", privateKey)
+
+	for _, peer := range c.Peers {
+		publicKey, err := keyToHex(peer.PublicKey)
+		if err != nil {
+			return "", fmt.Errorf("peer %s: invalid public key: %w", peer.Name, err)
+		}
+		fmt.Fprintf(&b, "public_key=%s\n", publicKey)
+
+		if peer.PresharedKey != "" {
+			presharedKey, err := keyToHex(peer.PresharedKey)
+			if err != nil {
+				return "", fmt.Errorf("peer %s: invalid preshared key: %w", peer.Name, err)
+			}
+			fmt.Fprintf(&b, "preshared_key=%s\n", presharedKey)
+		}
+		if peer.Endpoint != "" {
+			endpoint, err := net.ResolveUDPAddr("udp", peer.Endpoint)
+			if err != nil {
+				return "", fmt.Errorf("peer %s: failed to resolve endpoint %s: %w", peer.Name, peer.Endpoint, err)
+			}
+			fmt.Fprintf(&b, "endpoint=%s\n", endpoint)
+		}
+		if peer.PersistentKeepalive > 0 {
+			fmt.Fprintf(&b, "persistent_keepalive_interval=%d\n", peer.PersistentKeepalive)
+		}
+
+		b.WriteString("replace_allowed_ips=true\n")
+		for _, allowedIP := range peer.AllowedIPs {
+			_, prefix, err := net.ParseCIDR(allowedIP)
+			if err != nil {
+				return "", fmt.Errorf("peer %s: invalid allowed IP %s: %w", peer.Name, allowedIP, err)
+			}
+			fmt.Fprintf(&b, "allowed_ip=%s\n", prefix)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Routes returns the networks routed through the tunnel: every peer's
+// allowed IPs, without duplicates
+func (c *Config) Routes() []string {
+	seen := make(map[string]bool)
+	var routes []string
+	for _, peer := range c.Peers {
+		for _, allowedIP := range peer.AllowedIPs {
+			_, prefix, err := net.ParseCIDR(allowedIP)
+			if err != nil || seen[prefix.String()] {
+				continue
+			}
+			seen[prefix.String()] = true
+			routes = append(routes, prefix.String())
+		}
+	}
+	return routes
+}
+
+// keyToHex converts a base64 WireGuard key to the hex form of the UAPI
+func keyToHex(key string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) != 32 {
+		return "", fmt.Errorf("key is %d bytes, want 32", len(raw))
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// splitList splits a comma separated wg-quick value
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// String renders the configuration in wg-quick format
+func (c *Config) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Interface]\nPrivateKey = %s\nAddress = %s\n", c.PrivateKey, strings.Join(c.Addresses, ", "))
+	if len(c.DNS) > 0 {
+		fmt.Fprintf(&b, "DNS = %s\n", strings.Join(c.DNS, ", "))
+	}
+	if c.MTU > 0 {
+		fmt.Fprintf(&b, "MTU = %d\n", c.MTU)
+	}
+
+	for _, peer := range c.Peers {
+		b.WriteString("\n[Peer]\n")
+		if peer.Name != "" {
+			fmt.Fprintf(&b, "# %s\n", peer.Name)
+		}
+		fmt.Fprintf(&b, "PublicKey = %s\n", peer.PublicKey)
+		if peer.PresharedKey != "" {
+			fmt.Fprintf(&b, "PresharedKey = %s\n", peer.PresharedKey)
+		}
+		if peer.Endpoint != "" {
+			fmt.Fprintf(&b, "Endpoint = %s\n", peer.Endpoint)
+		}
+		fmt.Fprintf(&b, "AllowedIPs = %s\n", strings.Join(peer.AllowedIPs, ", "))
+		if peer.PersistentKeepalive > 0 {
+			fmt.Fprintf(&b, "PersistentKeepalive = %d\n", peer.PersistentKeepalive)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package wireguard
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func testClientConfig() string {
+	return `[Interface]
+# WireGuard Client Configuration
+# Peer Label: laptop
+PrivateKey = ` + testKey('a') + `
+Address = 10.8.0.100/24
+DNS = 1.1.1.1
+
+[Peer]
+# master-1 (digitalocean)
+PublicKey = ` + testKey('b') + `
+PresharedKey = ` + testKey('c') + `
+Endpoint = 203.0.113.10:51820
+AllowedIPs = 10.8.0.10/32, 10.0.0.0/8
+PersistentKeepalive = 25
+
+[Peer]
+# worker-1 (linode)
+PublicKey = <PUBLIC_KEY_PLACEHOLDER>
+Endpoint = 203.0.113.11:51820
+AllowedIPs = 10.8.0.11/32, 10.0.0.0/8
+
+[Peer]
+# External VPN Client
+PublicKey = ` + testKey('d') + `
+AllowedIPs = 10.8.0.101/32
+`
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(testClientConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, testKey('a'), cfg.PrivateKey)
+	assert.Equal(t, []string{"10.8.0.100/24"}, cfg.Addresses)
+	assert.Equal(t, []string{"1.1.1.1"}, cfg.DNS)
+	require.Len(t, cfg.Peers, 3)
+
+	master := cfg.Peers[0]
+	assert.Equal(t, "master-1 (digitalocean)", master.Name)
+	assert.Equal(t, testKey('c'), master.PresharedKey)
+	assert.Equal(t, "203.0.113.10:51820", master.Endpoint)
+	assert.Equal(t, []string{"10.8.0.10/32", "10.0.0.0/8"}, master.AllowedIPs)
+	assert.Equal(t, 25, master.PersistentKeepalive)
+
+	assert.Empty(t, cfg.Peers[2].Endpoint)
+	assert.Zero(t, cfg.Peers[2].PersistentKeepalive)
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	_, err := ParseConfig("[Interface]\nAddress = 10.8.0.100/24\n")
+	assert.ErrorContains(t, err, "PrivateKey")
+
+	_, err = ParseConfig("[Interface]\nPrivateKey = " + testKey('a') + "\n")
+	assert.ErrorContains(t, err, "Address")
+
+	_, err = ParseConfig("PrivateKey = " + testKey('a') + "\n")
+	assert.ErrorContains(t, err, "outside of a section")
+
+	_, err = ParseConfig("[Interface]\nPrivateKey\n")
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestConfig_Prune(t *testing.T) {
+	cfg, err := ParseConfig(testClientConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"worker-1 (linode)"}, cfg.Prune())
+	require.Len(t, cfg.Peers, 2)
+	assert.Equal(t, "External VPN Client", cfg.Peers[1].Name)
+}
+
+func TestConfig_UAPI(t *testing.T) {
+	cfg, err := ParseConfig(testClientConfig())
+	require.NoError(t, err)
+
+	_, err = cfg.UAPI()
+	assert.ErrorContains(t, err, "worker-1", "placeholder keys are rejected")
+
+	cfg.Prune()
+	uapi, err := cfg.UAPI()
+	require.NoError(t, err)
+
+	hexKey := func(b byte) string { return hex.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32))) }
+	assert.Equal(t, "private_key="+hexKey('a')+"\n"+
+		"replace_peers=true\n"+
+		"public_key="+hexKey('b')+"\n"+
+		"preshared_key="+hexKey('c')+"\n"+
+		"endpoint=203.0.113.10:51820\n"+
+		"persistent_keepalive_interval=25\n"+
+		"replace_allowed_ips=true\n"+
+		"allowed_ip=10.8.0.10/32\n"+
+		"allowed_ip=10.0.0.0/8\n"+
+		"public_key="+hexKey('d')+"\n"+
+		"replace_allowed_ips=true\n"+
+		"allowed_ip=10.8.0.101/32\n", uapi)
+}
+
+func TestConfig_Routes(t *testing.T) {
+	cfg, err := ParseConfig(testClientConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"10.8.0.10/32", "10.0.0.0/8", "10.8.0.11/32", "10.8.0.101/32"}, cfg.Routes())
+}
+
+func TestNewEmbeddedClient(t *testing.T) {
+	_, err := NewEmbeddedClient("test", &EmbeddedClientConfig{})
+	assert.Error(t, err)
+
+	client, err := NewEmbeddedClient("test", &EmbeddedClientConfig{Config: testClientConfig(), StateDir: t.TempDir()})
+	require.NoError(t, err)
+
+	status, err := client.Status(t.Context())
+	require.NoError(t, err)
+	assert.False(t, status.Connected)
+	assert.Equal(t, "test", status.ClusterName)
+	assert.Error(t, client.Disconnect())
+}
+
+func TestConfig_String(t *testing.T) {
+	cfg, err := ParseConfig(testClientConfig())
+	require.NoError(t, err)
+	cfg.Prune()
+
+	reparsed, err := ParseConfig(cfg.String())
+	require.NoError(t, err)
+	assert.Equal(t, cfg, reparsed)
+}
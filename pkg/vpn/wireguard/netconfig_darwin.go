@@ -0,0 +1,40 @@
+//go:build darwin
+
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const isLinux = false
+
+// defaultTUNName lets the kernel pick the next free utun interface
+const defaultTUNName = "utun"
+
+// configureInterface gives the TUN interface its address and MTU, brings it
+// up and routes every allowed IP through it (macOS-specific)
+func configureInterface(name string, cfg *Config, mtu int) error {
+	commands := [][]string{}
+	for _, address := range cfg.Addresses {
+		ip, _, err := net.ParseCIDR(address)
+		if err != nil {
+			return fmt.Errorf("invalid address %s: %w", address, err)
+		}
+		commands = append(commands, []string{"ifconfig", name, "inet", address, ip.String(), "alias"})
+	}
+	commands = append(commands, []string{"ifconfig", name, "mtu", strconv.Itoa(mtu), "up"})
+	for _, route := range cfg.Routes() {
+		commands = append(commands, []string{"route", "-q", "-n", "add", "-inet", route, "-interface", name})
+	}
+
+	for _, args := range commands {
+		if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package wireguard
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const isLinux = true
+
+// defaultTUNName is the name of the userspace tunnel interface
+const defaultTUNName = "sloth0"
+
+// configureInterface gives the TUN interface its address and MTU, brings it
+// up and routes every allowed IP through it (Linux-specific)
+func configureInterface(name string, cfg *Config, mtu int) error {
+	commands := [][]string{}
+	for _, address := range cfg.Addresses {
+		commands = append(commands, []string{"ip", "address", "add", address, "dev", name})
+	}
+	commands = append(commands, []string{"ip", "link", "set", "dev", name, "mtu", strconv.Itoa(mtu), "up"})
+	for _, route := range cfg.Routes() {
+		commands = append(commands, []string{"ip", "route", "replace", route, "dev", name})
+	}
+
+	for _, args := range commands {
+		if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
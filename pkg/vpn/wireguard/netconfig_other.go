@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package wireguard
+
+import (
+	"fmt"
+	"runtime"
+)
+
+const isLinux = false
+
+// defaultTUNName is the name of the userspace tunnel interface
+const defaultTUNName = "sloth0"
+
+// configureInterface is not supported on this platform; use the WireGuard
+// app with a 'vpn client-config' file instead
+func configureInterface(name string, cfg *Config, mtu int) error {
+	return fmt.Errorf("userspace WireGuard is not supported on %s", runtime.GOOS)
+}